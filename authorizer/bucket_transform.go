@@ -0,0 +1,68 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.BucketTransformService = (*BucketTransformService)(nil)
+
+// BucketTransformService wraps an influxdb.BucketTransformService and
+// authorizes actions against it appropriately. Transforms are authorized as
+// their bucket: reading them requires read access to the bucket and changing
+// them requires write access.
+type BucketTransformService struct {
+	s influxdb.BucketTransformService
+}
+
+// NewBucketTransformService constructs an instance of an authorizing bucket
+// transform service.
+func NewBucketTransformService(s influxdb.BucketTransformService) *BucketTransformService {
+	return &BucketTransformService{
+		s: s,
+	}
+}
+
+// FindBucketTransform checks to see if the authorizer on context has read access to the bucket of the transform.
+func (s *BucketTransformService) FindBucketTransform(ctx context.Context, bucketID platform.ID) (*influxdb.BucketTransform, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	t, err := s.s.FindBucketTransform(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, t.BucketID, t.OrgID); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// PutBucketTransform checks to see if the authorizer on context has write access to the bucket of the transform.
+func (s *BucketTransformService) PutBucketTransform(ctx context.Context, t *influxdb.BucketTransform) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, t.BucketID, t.OrgID); err != nil {
+		return err
+	}
+	return s.s.PutBucketTransform(ctx, t)
+}
+
+// DeleteBucketTransform checks to see if the authorizer on context has write access to the bucket of the transform.
+func (s *BucketTransformService) DeleteBucketTransform(ctx context.Context, bucketID platform.ID) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	t, err := s.s.FindBucketTransform(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, t.BucketID, t.OrgID); err != nil {
+		return err
+	}
+	return s.s.DeleteBucketTransform(ctx, bucketID)
+}
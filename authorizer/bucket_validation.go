@@ -0,0 +1,74 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.BucketValidationService = (*BucketValidationService)(nil)
+
+// BucketValidationService wraps an influxdb.BucketValidationService and
+// authorizes actions against it appropriately. Validations are authorized as
+// their bucket: reading them requires read access to the bucket and changing
+// them requires write access.
+type BucketValidationService struct {
+	s influxdb.BucketValidationService
+}
+
+// NewBucketValidationService constructs an instance of an authorizing bucket
+// validation service.
+func NewBucketValidationService(s influxdb.BucketValidationService) *BucketValidationService {
+	return &BucketValidationService{
+		s: s,
+	}
+}
+
+// FindBucketValidation checks to see if the authorizer on context has read access to the bucket of the validation.
+func (s *BucketValidationService) FindBucketValidation(ctx context.Context, bucketID platform.ID) (*influxdb.BucketValidation, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	v, err := s.s.FindBucketValidation(ctx, bucketID)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, v.BucketID, v.OrgID); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// PutBucketValidation checks to see if the authorizer on context has write access to the bucket of the validation
+// and to its quarantine bucket.
+func (s *BucketValidationService) PutBucketValidation(ctx context.Context, v *influxdb.BucketValidation) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, v.BucketID, v.OrgID); err != nil {
+		return err
+	}
+	if v.QuarantineBucketID != nil {
+		if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, *v.QuarantineBucketID, v.OrgID); err != nil {
+			return err
+		}
+	}
+	return s.s.PutBucketValidation(ctx, v)
+}
+
+// DeleteBucketValidation checks to see if the authorizer on context has write access to the bucket of the validation.
+func (s *BucketValidationService) DeleteBucketValidation(ctx context.Context, bucketID platform.ID) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	v, err := s.s.FindBucketValidation(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, v.BucketID, v.OrgID); err != nil {
+		return err
+	}
+	return s.s.DeleteBucketValidation(ctx, bucketID)
+}
@@ -0,0 +1,34 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.CardinalityService = (*CardinalityService)(nil)
+
+// CardinalityService wraps a influxdb.CardinalityService and authorizes actions
+// against it appropriately.
+type CardinalityService struct {
+	s influxdb.CardinalityService
+}
+
+// NewCardinalityService constructs an instance of an authorizing cardinality service.
+func NewCardinalityService(s influxdb.CardinalityService) *CardinalityService {
+	return &CardinalityService{
+		s: s,
+	}
+}
+
+func (c CardinalityService) BucketCardinality(ctx context.Context, orgID, bucketID platform.ID, opts influxdb.CardinalityOptions) (*influxdb.BucketCardinality, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, bucketID, orgID); err != nil {
+		return nil, err
+	}
+	return c.s.BucketCardinality(ctx, orgID, bucketID, opts)
+}
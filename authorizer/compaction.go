@@ -0,0 +1,44 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.CompactionService = (*CompactionService)(nil)
+
+// CompactionService wraps a influxdb.CompactionService and authorizes actions
+// against it appropriately.
+type CompactionService struct {
+	s influxdb.CompactionService
+}
+
+// NewCompactionService constructs an instance of an authorizing compaction service.
+func NewCompactionService(s influxdb.CompactionService) *CompactionService {
+	return &CompactionService{
+		s: s,
+	}
+}
+
+func (c CompactionService) CompactionStatus(ctx context.Context, bucketID platform.ID, shardID uint64) ([]*influxdb.ShardCompactionStatus, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return nil, err
+	}
+	return c.s.CompactionStatus(ctx, bucketID, shardID)
+}
+
+func (c CompactionService) Compact(ctx context.Context, req influxdb.CompactionRequest, progress func(influxdb.CompactionProgress)) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := IsAllowedAll(ctx, influxdb.OperPermissions()); err != nil {
+		return err
+	}
+	return c.s.Compact(ctx, req, progress)
+}
@@ -0,0 +1,94 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.IngestMappingService = (*IngestMappingService)(nil)
+
+// IngestMappingService wraps an influxdb.IngestMappingService and authorizes
+// actions against it appropriately.
+type IngestMappingService struct {
+	s influxdb.IngestMappingService
+}
+
+// NewIngestMappingService constructs an instance of an authorizing ingest
+// mapping service.
+func NewIngestMappingService(s influxdb.IngestMappingService) *IngestMappingService {
+	return &IngestMappingService{
+		s: s,
+	}
+}
+
+// FindIngestMappingByID checks to see if the authorizer on context has read access to the id provided.
+func (s *IngestMappingService) FindIngestMappingByID(ctx context.Context, id platform.ID) (*influxdb.IngestMapping, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	m, err := s.s.FindIngestMappingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.IngestMappingsResourceType, m.ID, m.OrgID); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FindIngestMappings retrieves all ingest mappings that match the provided filter and then filters the list down to only the resources that are authorized.
+func (s *IngestMappingService) FindIngestMappings(ctx context.Context, filter influxdb.IngestMappingFilter) ([]*influxdb.IngestMapping, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	ms, err := s.s.FindIngestMappings(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	ms, _, err = AuthorizeFindIngestMappings(ctx, ms)
+	return ms, err
+}
+
+// CreateIngestMapping checks to see if the authorizer on context has write access to ingest mappings of the organization.
+func (s *IngestMappingService) CreateIngestMapping(ctx context.Context, m *influxdb.IngestMapping) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeCreate(ctx, influxdb.IngestMappingsResourceType, m.OrgID); err != nil {
+		return err
+	}
+	return s.s.CreateIngestMapping(ctx, m)
+}
+
+// UpdateIngestMapping checks to see if the authorizer on context has write access to the ingest mapping provided.
+func (s *IngestMappingService) UpdateIngestMapping(ctx context.Context, id platform.ID, upd influxdb.IngestMappingUpdate) (*influxdb.IngestMapping, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	m, err := s.s.FindIngestMappingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.IngestMappingsResourceType, m.ID, m.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateIngestMapping(ctx, id, upd)
+}
+
+// DeleteIngestMapping checks to see if the authorizer on context has write access to the ingest mapping provided.
+func (s *IngestMappingService) DeleteIngestMapping(ctx context.Context, id platform.ID) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	m, err := s.s.FindIngestMappingByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.IngestMappingsResourceType, m.ID, m.OrgID); err != nil {
+		return err
+	}
+	return s.s.DeleteIngestMapping(ctx, id)
+}
@@ -0,0 +1,94 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.MeasurementSchemaService = (*MeasurementSchemaService)(nil)
+
+// MeasurementSchemaService wraps an influxdb.MeasurementSchemaService and
+// authorizes actions against it appropriately. Measurement schemas are
+// authorized as their bucket: reading them requires read access to the bucket
+// and changing them requires write access.
+type MeasurementSchemaService struct {
+	s influxdb.MeasurementSchemaService
+}
+
+// NewMeasurementSchemaService constructs an instance of an authorizing
+// measurement schema service.
+func NewMeasurementSchemaService(s influxdb.MeasurementSchemaService) *MeasurementSchemaService {
+	return &MeasurementSchemaService{
+		s: s,
+	}
+}
+
+// FindMeasurementSchemaByID checks to see if the authorizer on context has read access to the bucket of the measurement schema.
+func (s *MeasurementSchemaService) FindMeasurementSchemaByID(ctx context.Context, id platform.ID) (*influxdb.MeasurementSchema, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	m, err := s.s.FindMeasurementSchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, m.BucketID, m.OrgID); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// FindMeasurementSchemas checks to see if the authorizer on context has read access to the bucket of the filter.
+func (s *MeasurementSchemaService) FindMeasurementSchemas(ctx context.Context, filter influxdb.MeasurementSchemaFilter) ([]*influxdb.MeasurementSchema, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, filter.BucketID, filter.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.FindMeasurementSchemas(ctx, filter)
+}
+
+// CreateMeasurementSchema checks to see if the authorizer on context has write access to the bucket of the measurement schema.
+func (s *MeasurementSchemaService) CreateMeasurementSchema(ctx context.Context, m *influxdb.MeasurementSchema) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, m.BucketID, m.OrgID); err != nil {
+		return err
+	}
+	return s.s.CreateMeasurementSchema(ctx, m)
+}
+
+// UpdateMeasurementSchema checks to see if the authorizer on context has write access to the bucket of the measurement schema.
+func (s *MeasurementSchemaService) UpdateMeasurementSchema(ctx context.Context, id platform.ID, upd influxdb.MeasurementSchemaUpdate) (*influxdb.MeasurementSchema, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	m, err := s.s.FindMeasurementSchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, m.BucketID, m.OrgID); err != nil {
+		return nil, err
+	}
+	return s.s.UpdateMeasurementSchema(ctx, id, upd)
+}
+
+// DeleteMeasurementSchema checks to see if the authorizer on context has write access to the bucket of the measurement schema.
+func (s *MeasurementSchemaService) DeleteMeasurementSchema(ctx context.Context, id platform.ID) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	m, err := s.s.FindMeasurementSchemaByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if _, _, err := AuthorizeWrite(ctx, influxdb.BucketsResourceType, m.BucketID, m.OrgID); err != nil {
+		return err
+	}
+	return s.s.DeleteMeasurementSchema(ctx, id)
+}
@@ -0,0 +1,35 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.OrgUsageService = (*OrgUsageService)(nil)
+
+// OrgUsageService wraps a influxdb.OrgUsageService and authorizes actions
+// against it appropriately.
+type OrgUsageService struct {
+	s influxdb.OrgUsageService
+}
+
+// NewOrgUsageService constructs an instance of an authorizing org usage service.
+func NewOrgUsageService(s influxdb.OrgUsageService) *OrgUsageService {
+	return &OrgUsageService{
+		s: s,
+	}
+}
+
+// FindOrgUsage checks to see if the authorizer on context has read access to the organization.
+func (s OrgUsageService) FindOrgUsage(ctx context.Context, orgID platform.ID) (*influxdb.OrgUsage, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeReadOrg(ctx, orgID); err != nil {
+		return nil, err
+	}
+	return s.s.FindOrgUsage(ctx, orgID)
+}
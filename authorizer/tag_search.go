@@ -0,0 +1,34 @@
+package authorizer
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+var _ influxdb.TagSearchService = (*TagSearchService)(nil)
+
+// TagSearchService wraps a influxdb.TagSearchService and authorizes actions
+// against it appropriately.
+type TagSearchService struct {
+	s influxdb.TagSearchService
+}
+
+// NewTagSearchService constructs an instance of an authorizing tag search service.
+func NewTagSearchService(s influxdb.TagSearchService) *TagSearchService {
+	return &TagSearchService{
+		s: s,
+	}
+}
+
+func (t TagSearchService) SearchTagValues(ctx context.Context, orgID, bucketID platform.ID, key string, opts influxdb.TagSearchOptions) (*influxdb.TagSearchResult, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if _, _, err := AuthorizeRead(ctx, influxdb.BucketsResourceType, bucketID, orgID); err != nil {
+		return nil, err
+	}
+	return t.s.SearchTagValues(ctx, orgID, bucketID, key, opts)
+}
@@ -143,6 +143,8 @@ const (
 	RemotesResourceType = ResourceType("remotes") // 20
 	// ReplicationsResourceType gives permission to one or more replications.
 	ReplicationsResourceType = ResourceType("replications") // 21
+	// IngestMappingsResourceType gives permission to one or more ingest mappings.
+	IngestMappingsResourceType = ResourceType("ingestMappings") // 22
 )
 
 // AllResourceTypes is the list of all known resource types.
@@ -169,6 +171,7 @@ var AllResourceTypes = []ResourceType{
 	AnnotationsResourceType,          // 19
 	RemotesResourceType,              // 20
 	ReplicationsResourceType,         // 21
+	IngestMappingsResourceType,       // 22
 	// NOTE: when modifying this list, please update the swagger for components.schemas.Permission resource enum.
 }
 
@@ -202,6 +205,7 @@ func (t ResourceType) Valid() (err error) {
 	case AnnotationsResourceType: // 19
 	case RemotesResourceType: // 20
 	case ReplicationsResourceType: // 21
+	case IngestMappingsResourceType: // 22
 	default:
 		err = ErrInvalidResourceType
 	}
@@ -40,6 +40,27 @@ type Bucket struct {
 	RetentionPolicyName string        `json:"rp,omitempty"` // This to support v1 sources
 	RetentionPeriod     time.Duration `json:"retentionPeriod"`
 	ShardGroupDuration  time.Duration `json:"shardGroupDuration"`
+	WALMode             BucketWALMode `json:"walMode,omitempty"`
+	WALFsyncDelay       time.Duration `json:"walFsyncDelay,omitempty"`
+
+	// LatenessWindow and FutureSkewLimit bound how far a written point's
+	// timestamp may be behind or ahead of the server clock; zero disables the
+	// bound. Points outside the bounds are handled by LatePointsAction.
+	LatenessWindow     time.Duration          `json:"latenessWindow,omitempty"`
+	FutureSkewLimit    time.Duration          `json:"futureSkewLimit,omitempty"`
+	LatePointsAction   BucketLatePointsAction `json:"latePointsAction,omitempty"`
+	LatePointsBucketID platform.ID            `json:"latePointsBucketID,omitempty"`
+
+	CardinalityLimits *CardinalityLimits `json:"cardinalityLimits,omitempty"`
+
+	// DeadLetterBucketID is the bucket of the organization the lines of the
+	// writes to this bucket which are rejected are recorded in, as described
+	// by DeadLetter. Rejected lines are not recorded if it is invalid.
+	DeadLetterBucketID platform.ID `json:"deadLetterBucketID,omitempty"`
+
+	// SchemaType is set when the bucket is created. The points written to a
+	// bucket with an explicit schema must match its measurement schemas.
+	SchemaType SchemaType `json:"schemaType,omitempty"`
 	CRUDLog
 }
 
@@ -60,6 +81,59 @@ func (bt BucketType) String() string {
 	return "user"
 }
 
+// BucketWALMode controls how writes to a bucket are made durable.
+type BucketWALMode string
+
+const (
+	// BucketWALModeDefault uses the server-wide write-ahead log settings.
+	BucketWALModeDefault BucketWALMode = ""
+	// BucketWALModeFsync fsyncs the write-ahead log before every write is acknowledged.
+	BucketWALModeFsync BucketWALMode = "fsync"
+	// BucketWALModeGroupCommit batches fsyncs of concurrent writes for up to the bucket's WALFsyncDelay.
+	BucketWALModeGroupCommit BucketWALMode = "group-commit"
+	// BucketWALModeNone skips the write-ahead log. Unsnapshotted writes are lost on a crash.
+	BucketWALModeNone BucketWALMode = "none"
+)
+
+// Valid returns an error if m is not a known write-ahead log mode.
+func (m BucketWALMode) Valid() error {
+	switch m {
+	case BucketWALModeDefault, BucketWALModeFsync, BucketWALModeGroupCommit, BucketWALModeNone:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf("invalid WAL mode %q; must be one of %q, %q or %q", string(m), BucketWALModeFsync, BucketWALModeGroupCommit, BucketWALModeNone),
+	}
+}
+
+// BucketLatePointsAction is the action taken for points written to a bucket
+// with timestamps outside its lateness window or future skew limit.
+type BucketLatePointsAction string
+
+const (
+	// BucketLatePointsAccept writes the points as usual.
+	BucketLatePointsAccept BucketLatePointsAction = "accept"
+	// BucketLatePointsReject refuses the points with a partial write error.
+	BucketLatePointsReject BucketLatePointsAction = "reject"
+	// BucketLatePointsRedirect writes the points to the bucket's LatePointsBucketID,
+	// through its transform, validation and schema but not its own late points
+	// policy.
+	BucketLatePointsRedirect BucketLatePointsAction = "redirect"
+)
+
+// Valid returns an error if a is not a known late points action.
+func (a BucketLatePointsAction) Valid() error {
+	switch a {
+	case "", BucketLatePointsAccept, BucketLatePointsReject, BucketLatePointsRedirect:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf("invalid late points action %q; must be one of %q, %q or %q", string(a), BucketLatePointsAccept, BucketLatePointsReject, BucketLatePointsRedirect),
+	}
+}
+
 // ParseBucketType parses a bucket type from a string
 func ParseBucketType(s string) BucketType {
 	if s == "system" {
@@ -110,6 +184,14 @@ type BucketUpdate struct {
 	Description        *string
 	RetentionPeriod    *time.Duration
 	ShardGroupDuration *time.Duration
+	WALMode            *BucketWALMode
+	WALFsyncDelay      *time.Duration
+	LatenessWindow     *time.Duration
+	FutureSkewLimit    *time.Duration
+	LatePointsAction   *BucketLatePointsAction
+	LatePointsBucketID *platform.ID
+	CardinalityLimits  *CardinalityLimits
+	DeadLetterBucketID *platform.ID
 }
 
 // BucketFilter represents a set of filter that restrict the returned results.
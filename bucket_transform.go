@@ -0,0 +1,157 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// TransformRuleType is the type of a transform rule.
+type TransformRuleType string
+
+const (
+	// TransformDropMeasurement drops the points whose measurement matches
+	// Pattern.
+	TransformDropMeasurement TransformRuleType = "dropMeasurement"
+	// TransformDropField drops the fields whose key matches Pattern. Points
+	// left without fields are dropped.
+	TransformDropField TransformRuleType = "dropField"
+	// TransformRenameTag renames the tag From to To, replacing any tag To.
+	TransformRenameTag TransformRuleType = "renameTag"
+	// TransformRenameField renames the field From to To, replacing any field
+	// To.
+	TransformRenameField TransformRuleType = "renameField"
+	// TransformAddTag sets the tag Key to Value, replacing any value it has.
+	TransformAddTag TransformRuleType = "addTag"
+	// TransformConvertField converts the field Key to DataType. Points whose
+	// field cannot be converted are rejected.
+	TransformConvertField TransformRuleType = "convertField"
+	// TransformRequireTags rejects the points missing any of Tags.
+	TransformRequireTags TransformRuleType = "requireTags"
+)
+
+// TransformRule is a rule of the transform of a bucket. Only the settings of
+// its type are used.
+type TransformRule struct {
+	Type TransformRuleType `json:"type"`
+	// Measurement is a regular expression restricting the rule to the
+	// points of the measurements it matches. The rule applies to every
+	// point if it is empty.
+	Measurement string `json:"measurement,omitempty"`
+
+	// Pattern is the regular expression of the dropMeasurement and
+	// dropField rules.
+	Pattern string `json:"pattern,omitempty"`
+	// From and To are the keys of the renameTag and renameField rules.
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+	// Key is the tag key of the addTag rule and the field key of the
+	// convertField rule.
+	Key string `json:"key,omitempty"`
+	// Value is the tag value of the addTag rule.
+	Value string `json:"value,omitempty"`
+	// DataType is the data type of the convertField rule.
+	DataType *SchemaColumnDataType `json:"dataType,omitempty"`
+	// Tags are the tag keys of the requireTags rule.
+	Tags []string `json:"tags,omitempty"`
+}
+
+// Validate returns an error if the rule is missing a setting of its type or
+// has an invalid regular expression.
+func (r *TransformRule) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid %s transform rule: ", r.Type) + fmt.Sprintf(format, args...),
+		}
+	}
+
+	if _, err := regexp.Compile(r.Measurement); err != nil {
+		return invalid("measurement: %v", err)
+	}
+
+	switch r.Type {
+	case TransformDropMeasurement, TransformDropField:
+		if r.Pattern == "" {
+			return invalid("pattern is required")
+		}
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return invalid("pattern: %v", err)
+		}
+	case TransformRenameTag, TransformRenameField:
+		if r.From == "" || r.To == "" {
+			return invalid("from and to are required")
+		}
+	case TransformAddTag:
+		if r.Key == "" || r.Value == "" {
+			return invalid("key and value are required")
+		}
+	case TransformConvertField:
+		if r.Key == "" || r.DataType == nil {
+			return invalid("key and dataType are required")
+		}
+	case TransformRequireTags:
+		if len(r.Tags) == 0 {
+			return invalid("tags are required")
+		}
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("unknown transform rule type %q", r.Type),
+		}
+	}
+	return nil
+}
+
+// BucketTransform is the chain of rules applied, in order, to the points
+// written to a bucket before they are stored.
+type BucketTransform struct {
+	OrgID    platform.ID     `json:"orgID"`
+	BucketID platform.ID     `json:"bucketID"`
+	Rules    []TransformRule `json:"rules"`
+	CRUDLog
+}
+
+// Validate returns an error if a rule of the transform is invalid.
+func (t *BucketTransform) Validate() error {
+	if len(t.Rules) == 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucket transform requires at least one rule",
+		}
+	}
+	for i := range t.Rules {
+		if err := t.Rules[i].Validate(); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("rule %d", i),
+				Err:  err,
+			}
+		}
+	}
+	return nil
+}
+
+var (
+	// ErrBucketTransformNotFound is returned when a bucket has no transform.
+	ErrBucketTransformNotFound = &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  "bucket transform not found",
+	}
+)
+
+// BucketTransformService manages the transforms of buckets.
+type BucketTransformService interface {
+	// FindBucketTransform returns the transform of a bucket.
+	FindBucketTransform(ctx context.Context, bucketID platform.ID) (*BucketTransform, error)
+
+	// PutBucketTransform sets the transform of the bucket of t, replacing
+	// the rules of any transform it has.
+	PutBucketTransform(ctx context.Context, t *BucketTransform) error
+
+	// DeleteBucketTransform removes the transform of a bucket.
+	DeleteBucketTransform(ctx context.Context, bucketID platform.ID) error
+}
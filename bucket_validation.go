@@ -0,0 +1,218 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// ValidationRuleType is the type of a validation rule.
+type ValidationRuleType string
+
+const (
+	// ValidationRange requires the numeric field Field to be within Min and
+	// Max, either of which may be omitted.
+	ValidationRange ValidationRuleType = "range"
+	// ValidationEnum requires the string field Field, or the tag Tag, to be
+	// one of Values.
+	ValidationEnum ValidationRuleType = "enum"
+	// ValidationRequiredFields requires the points to have every field of
+	// Fields.
+	ValidationRequiredFields ValidationRuleType = "requiredFields"
+	// ValidationRequiredTags requires the points to have every tag of Tags.
+	ValidationRequiredTags ValidationRuleType = "requiredTags"
+	// ValidationTimestamp requires the time of the points to be at most
+	// MaxPastSeconds before and MaxFutureSeconds after the time they are
+	// written. Either bound may be omitted.
+	ValidationTimestamp ValidationRuleType = "timestamp"
+)
+
+// ValidationMode is what is done with a point violating a validation rule.
+type ValidationMode string
+
+const (
+	// ValidationModeReject rejects the point, which is reported as dropped
+	// to the writer.
+	ValidationModeReject ValidationMode = "reject"
+	// ValidationModeDropField drops the field violating the rule and keeps
+	// the rest of the point. A point left without fields is rejected. Only
+	// range and enum rules on a field may drop it.
+	ValidationModeDropField ValidationMode = "dropField"
+	// ValidationModeQuarantine writes the point to the quarantine bucket of
+	// the validation instead, with the violation in a "_violation" field.
+	ValidationModeQuarantine ValidationMode = "quarantine"
+)
+
+// ValidationViolationField is the field of a quarantined point describing
+// the rule it violated.
+const ValidationViolationField = "_violation"
+
+// ValidationRule is a rule of the validation of a bucket. Only the settings
+// of its type are used.
+type ValidationRule struct {
+	Type ValidationRuleType `json:"type"`
+	Mode ValidationMode     `json:"mode"`
+	// Measurement is a regular expression restricting the rule to the
+	// points of the measurements it matches. The rule applies to every
+	// point if it is empty.
+	Measurement string `json:"measurement,omitempty"`
+
+	// Field is the field key of the range and enum rules. Points without
+	// the field are not checked.
+	Field string `json:"field,omitempty"`
+	// Tag is the tag key of an enum rule checking a tag. Points without the
+	// tag are not checked.
+	Tag string `json:"tag,omitempty"`
+	// Min and Max are the inclusive bounds of the range rule.
+	Min *float64 `json:"min,omitempty"`
+	Max *float64 `json:"max,omitempty"`
+	// Values are the values allowed by the enum rule.
+	Values []string `json:"values,omitempty"`
+	// Fields are the field keys of the requiredFields rule.
+	Fields []string `json:"fields,omitempty"`
+	// Tags are the tag keys of the requiredTags rule.
+	Tags []string `json:"tags,omitempty"`
+	// MaxPastSeconds and MaxFutureSeconds bound the timestamp rule.
+	MaxPastSeconds   *int64 `json:"maxPastSeconds,omitempty"`
+	MaxFutureSeconds *int64 `json:"maxFutureSeconds,omitempty"`
+}
+
+// Validate returns an error if the rule is missing a setting of its type, has
+// an invalid regular expression or a mode its type does not support.
+func (r *ValidationRule) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("invalid %s validation rule: ", r.Type) + fmt.Sprintf(format, args...),
+		}
+	}
+
+	if _, err := regexp.Compile(r.Measurement); err != nil {
+		return invalid("measurement: %v", err)
+	}
+
+	switch r.Type {
+	case ValidationRange:
+		if r.Field == "" {
+			return invalid("field is required")
+		}
+		if r.Min == nil && r.Max == nil {
+			return invalid("min or max is required")
+		}
+		if r.Min != nil && r.Max != nil && *r.Min > *r.Max {
+			return invalid("min must not be greater than max")
+		}
+	case ValidationEnum:
+		if (r.Field == "") == (r.Tag == "") {
+			return invalid("exactly one of field and tag is required")
+		}
+		if len(r.Values) == 0 {
+			return invalid("values are required")
+		}
+		if r.Tag != "" && r.Mode == ValidationModeDropField {
+			return invalid("a tag cannot be dropped")
+		}
+	case ValidationRequiredFields:
+		if len(r.Fields) == 0 {
+			return invalid("fields are required")
+		}
+	case ValidationRequiredTags:
+		if len(r.Tags) == 0 {
+			return invalid("tags are required")
+		}
+	case ValidationTimestamp:
+		if r.MaxPastSeconds == nil && r.MaxFutureSeconds == nil {
+			return invalid("maxPastSeconds or maxFutureSeconds is required")
+		}
+		if (r.MaxPastSeconds != nil && *r.MaxPastSeconds < 0) || (r.MaxFutureSeconds != nil && *r.MaxFutureSeconds < 0) {
+			return invalid("maxPastSeconds and maxFutureSeconds must not be negative")
+		}
+	default:
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  fmt.Sprintf("unknown validation rule type %q", r.Type),
+		}
+	}
+
+	switch r.Mode {
+	case ValidationModeReject, ValidationModeQuarantine:
+	case ValidationModeDropField:
+		if r.Type != ValidationRange && r.Type != ValidationEnum {
+			return invalid("mode %s is only supported by range and enum rules", r.Mode)
+		}
+	default:
+		return invalid("unknown mode %q", r.Mode)
+	}
+	return nil
+}
+
+// BucketValidation is the set of rules checked, in order, against the points
+// written to a bucket before they are stored.
+type BucketValidation struct {
+	OrgID    platform.ID `json:"orgID"`
+	BucketID platform.ID `json:"bucketID"`
+	// QuarantineBucketID is the bucket of the organization the points
+	// violating a quarantine rule are written to. It is required by
+	// quarantine rules.
+	QuarantineBucketID *platform.ID     `json:"quarantineBucketID,omitempty"`
+	Rules              []ValidationRule `json:"rules"`
+	CRUDLog
+}
+
+// Validate returns an error if a rule of the validation is invalid, or if it
+// has quarantine rules without a quarantine bucket.
+func (v *BucketValidation) Validate() error {
+	if len(v.Rules) == 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucket validation requires at least one rule",
+		}
+	}
+	for i := range v.Rules {
+		if err := v.Rules[i].Validate(); err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("rule %d", i),
+				Err:  err,
+			}
+		}
+		if v.Rules[i].Mode == ValidationModeQuarantine && v.QuarantineBucketID == nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("rule %d: quarantine rules require a quarantine bucket", i),
+			}
+		}
+	}
+	if v.QuarantineBucketID != nil && *v.QuarantineBucketID == v.BucketID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "a bucket cannot be its own quarantine bucket",
+		}
+	}
+	return nil
+}
+
+var (
+	// ErrBucketValidationNotFound is returned when a bucket has no
+	// validation.
+	ErrBucketValidationNotFound = &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  "bucket validation not found",
+	}
+)
+
+// BucketValidationService manages the validations of buckets.
+type BucketValidationService interface {
+	// FindBucketValidation returns the validation of a bucket.
+	FindBucketValidation(ctx context.Context, bucketID platform.ID) (*BucketValidation, error)
+
+	// PutBucketValidation sets the validation of the bucket of v, replacing
+	// any validation it has.
+	PutBucketValidation(ctx context.Context, v *BucketValidation) error
+
+	// DeleteBucketValidation removes the validation of a bucket.
+	DeleteBucketValidation(ctx context.Context, bucketID platform.ID) error
+}
@@ -0,0 +1,100 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// CardinalityLimits bounds the number of series of a bucket or of all the
+// buckets of an organization. A limit of zero inherits the limit of the
+// bucket's organization or of the server, and a negative limit disables it.
+//
+// The limits are checked when new series are created; points that would
+// create series beyond a limit are rejected with a partial write error.
+type CardinalityLimits struct {
+	// MaxSeries bounds the number of series of a bucket, or of all the
+	// buckets of an organization together.
+	MaxSeries int64 `json:"maxSeries,omitempty"`
+
+	// MaxSeriesPerMeasurement bounds the number of series of each
+	// measurement within a shard.
+	MaxSeriesPerMeasurement int64 `json:"maxSeriesPerMeasurement,omitempty"`
+
+	// MaxValuesPerTag bounds the number of values of each tag key of a
+	// measurement within a shard.
+	MaxValuesPerTag int64 `json:"maxValuesPerTag,omitempty"`
+}
+
+// DefaultCardinalityLimit is the number of measurements, tag keys and tag
+// values reported by a cardinality report unless a limit is given.
+const DefaultCardinalityLimit = 10
+
+// DefaultCardinalityShardGroups is the number of most recent shard groups whose
+// series growth is reported by a cardinality report unless a number is given.
+const DefaultCardinalityShardGroups = 3
+
+// CardinalityOptions selects what a cardinality report includes.
+type CardinalityOptions struct {
+	// Limit bounds the number of measurements reported, and the number of
+	// tag keys and tag values reported for each of them.
+	Limit int
+
+	// Exact counts series exactly by unioning the series of every shard
+	// instead of estimating the overlap between shards from their sketches.
+	Exact bool
+
+	// ShardGroups is the number of most recent shard groups whose series
+	// growth is reported.
+	ShardGroups int
+}
+
+// BucketCardinality reports the series cardinality of a bucket, its
+// measurements with the most series and the series growth of its most recent
+// shard groups.
+type BucketCardinality struct {
+	BucketID     platform.ID              `json:"bucketID"`
+	Exact        bool                     `json:"exact"`
+	SeriesN      int64                    `json:"seriesN"`
+	Measurements []MeasurementCardinality `json:"measurements"`
+	Growth       []ShardGroupCardinality  `json:"growth"`
+}
+
+// MeasurementCardinality reports the number of series of a measurement and
+// its tag keys with the most values.
+type MeasurementCardinality struct {
+	Name    string              `json:"name"`
+	SeriesN int64               `json:"seriesN"`
+	TagKeys []TagKeyCardinality `json:"tagKeys"`
+}
+
+// TagKeyCardinality reports the number of values of a tag key and its values
+// with the most series.
+type TagKeyCardinality struct {
+	Key     string                `json:"key"`
+	ValuesN int64                 `json:"valuesN"`
+	Values  []TagValueCardinality `json:"values"`
+}
+
+// TagValueCardinality reports the number of series of a tag value.
+type TagValueCardinality struct {
+	Value   string `json:"value"`
+	SeriesN int64  `json:"seriesN"`
+}
+
+// ShardGroupCardinality reports the number of series of a shard group and the
+// number of those that do not exist in any earlier shard group.
+type ShardGroupCardinality struct {
+	ShardGroupID uint64    `json:"shardGroupID"`
+	StartTime    time.Time `json:"startTime"`
+	EndTime      time.Time `json:"endTime"`
+	SeriesN      int64     `json:"seriesN"`
+	NewSeriesN   int64     `json:"newSeriesN"`
+}
+
+// CardinalityService reports the series cardinality of buckets.
+type CardinalityService interface {
+	// BucketCardinality returns the cardinality report of a bucket.
+	BucketCardinality(ctx context.Context, orgID, bucketID platform.ID, opts CardinalityOptions) (*BucketCardinality, error)
+}
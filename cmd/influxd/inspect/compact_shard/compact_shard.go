@@ -0,0 +1,153 @@
+package compact_shard
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/spf13/cobra"
+	"go.uber.org/zap"
+)
+
+type args struct {
+	path    string        // shard directory
+	mode    string        // compaction to run, if any
+	cold    time.Duration // duration after which a shard is planned for a full compaction
+	verbose bool          // log compaction progress
+}
+
+func NewCompactShardCommand() *cobra.Command {
+	var arguments args
+	cmd := &cobra.Command{
+		Use:   "compact-shard",
+		Short: "Shows and runs TSM compactions for a shard",
+		Long: `
+This command shows the TSM generations of a shard directory, their compaction
+level, and the groups of files the compaction planner would compact next.
+With --compact, it then runs a full or optimize compaction of the shard and
+reports the resulting layout.
+The shard must not be open by a running influxd while it is compacted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if arguments.mode != "" {
+				if err := influxdb.CompactionMode(arguments.mode).Valid(); err != nil {
+					return err
+				}
+			}
+			return arguments.run(cmd)
+		},
+	}
+
+	cmd.Flags().StringVarP(&arguments.path, "path", "p", "", "Path to the shard directory")
+	cmd.Flags().StringVar(&arguments.mode, "compact", "",
+		fmt.Sprintf("Compact the shard; one of %q or %q", influxdb.CompactionModeFull, influxdb.CompactionModeOptimize))
+	cmd.Flags().DurationVar(&arguments.cold, "compact-full-write-cold-duration", tsdb.DefaultCompactFullWriteColdDuration,
+		"Duration without writes after which the planner schedules a full compaction")
+	cmd.Flags().BoolVarP(&arguments.verbose, "verbose", "v", false, "Enable verbose logging")
+	cmd.MarkFlagRequired("path")
+
+	return cmd
+}
+
+func (a *args) run(cmd *cobra.Command) error {
+	if fi, err := os.Stat(a.path); err != nil {
+		return fmt.Errorf("failed to read shard directory %q: %w", a.path, err)
+	} else if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", a.path)
+	}
+
+	log := zap.NewNop()
+	if a.verbose {
+		var err error
+		if log, err = zap.NewDevelopment(); err != nil {
+			return err
+		}
+	}
+
+	fs := tsm1.NewFileStore(a.path, tsdb.EngineTags{})
+	fs.WithLogger(log)
+	if err := fs.Open(context.Background()); err != nil {
+		return fmt.Errorf("failed to open shard %q: %w", a.path, err)
+	}
+	defer fs.Close()
+
+	planner := tsm1.NewDefaultPlanner(fs, a.cold)
+	a.printLayout(cmd, fs, planner)
+
+	if a.mode == "" {
+		return nil
+	}
+
+	var files []string
+	for _, stat := range fs.Stats() {
+		files = append(files, stat.Path)
+	}
+	if len(files) == 0 {
+		cmd.Println("No TSM files to compact.")
+		return nil
+	}
+	sort.Strings(files)
+
+	compactor := tsm1.NewCompactor()
+	compactor.Dir = a.path
+	compactor.FileStore = fs
+	compactor.Size = tsdb.DefaultMaxPointsPerBlock
+	compactor.Open()
+	defer compactor.Close()
+
+	cmd.Printf("Running %s compaction of %d files...\n", a.mode, len(files))
+	start := time.Now()
+
+	var newFiles []string
+	var err error
+	if influxdb.CompactionMode(a.mode) == influxdb.CompactionModeOptimize {
+		newFiles, err = compactor.CompactFast(files, log)
+	} else {
+		newFiles, err = compactor.CompactFull(files, log)
+	}
+	if err != nil {
+		return fmt.Errorf("compaction failed: %w", err)
+	}
+	if err := fs.Replace(files, newFiles); err != nil {
+		for _, f := range newFiles {
+			_ = os.Remove(f)
+		}
+		return fmt.Errorf("failed to replace compacted files: %w", err)
+	}
+
+	cmd.Printf("Compacted %d files into %d in %s.\n\n", len(files), len(newFiles), time.Since(start).Round(time.Millisecond))
+	a.printLayout(cmd, fs, planner)
+	return nil
+}
+
+func (a *args) printLayout(cmd *cobra.Command, fs *tsm1.FileStore, planner tsm1.CompactionPlanner) {
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 8, 2, 1, ' ', 0)
+	_, _ = fmt.Fprintln(tw, strings.Join([]string{"Generation", "Level", "Files", "Size", "Tombstones"}, "\t"))
+	for _, g := range tsm1.FileStoreGenerations(fs) {
+		_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%t\n", g.ID, g.Level, len(g.Files), g.Size, g.HasTombstones)
+	}
+	_ = tw.Flush()
+
+	if ok, reason := planner.FullyCompacted(); ok {
+		cmd.Println("\nShard is fully compacted.")
+	} else {
+		cmd.Printf("\nShard is not fully compacted: %s\n", reason)
+	}
+
+	plan := tsm1.PlanCompactions(planner, fs.LastModified(), float64(tsdb.DefaultCompactTombstoneThreshold)/100)
+	if len(plan) == 0 {
+		cmd.Println("No compactions planned.")
+		return
+	}
+	cmd.Println("Planned compactions:")
+	for _, g := range plan {
+		cmd.Printf("  level %s: %s\n", g.Level, strings.Join(g.Files, ", "))
+	}
+}
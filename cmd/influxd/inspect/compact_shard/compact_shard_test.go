@@ -0,0 +1,80 @@
+package compact_shard
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CompactShard_Layout(t *testing.T) {
+	dir := createShard(t, 3)
+
+	out, err := runCommand(t, "--path", dir)
+	require.NoError(t, err)
+	require.Contains(t, out, "Generation")
+	require.Contains(t, out, "not fully compacted")
+	require.Len(t, tsmFiles(t, dir), 3)
+}
+
+func Test_CompactShard_Full(t *testing.T) {
+	dir := createShard(t, 3)
+
+	out, err := runCommand(t, "--path", dir, "--compact", "full")
+	require.NoError(t, err)
+	require.Contains(t, out, "Compacted 3 files into 1")
+	require.Contains(t, out, "Shard is fully compacted.")
+	require.Len(t, tsmFiles(t, dir), 1)
+}
+
+func Test_CompactShard_InvalidMode(t *testing.T) {
+	dir := createShard(t, 1)
+
+	_, err := runCommand(t, "--path", dir, "--compact", "bogus")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid compaction mode")
+}
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := NewCompactShardCommand()
+	cmd.SetArgs(args)
+
+	b := &bytes.Buffer{}
+	cmd.SetOut(b)
+	cmd.SetErr(b)
+
+	err := cmd.Execute()
+	return b.String(), err
+}
+
+// createShard writes n level 1 TSM files, one per generation, to a new shard directory.
+func createShard(t *testing.T, n int) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	for gen := 1; gen <= n; gen++ {
+		f, err := os.Create(filepath.Join(dir, tsm1.DefaultFormatFileName(gen, 1)+"."+tsm1.TSMFileExtension))
+		require.NoError(t, err)
+
+		w, err := tsm1.NewTSMWriter(f)
+		require.NoError(t, err)
+		key := tsm1.SeriesFieldKeyBytes(fmt.Sprintf("cpu,host=%d", gen), "value")
+		require.NoError(t, w.Write(key, []tsm1.Value{tsm1.NewValue(int64(gen), float64(gen))}))
+		require.NoError(t, w.WriteIndex())
+		require.NoError(t, w.Close())
+	}
+	return dir
+}
+
+func tsmFiles(t *testing.T, dir string) []string {
+	t.Helper()
+	files, err := filepath.Glob(filepath.Join(dir, "*."+tsm1.TSMFileExtension))
+	require.NoError(t, err)
+	return files
+}
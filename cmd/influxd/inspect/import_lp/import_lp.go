@@ -0,0 +1,1022 @@
+package import_lp
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/bolt"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/build_tsi"
+	"github.com/influxdata/influxdb/v2/kit/cli"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/influxdata/influxql"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// defaultBatchSize is the number of lines parsed at once from the input.
+	defaultBatchSize = 5000
+
+	// defaultMaxShardBufferSize is the amount of decoded point data buffered
+	// per shard before it is written out as a new TSM file.
+	defaultMaxShardBufferSize = 64 * 1024 * 1024
+)
+
+// importFlags contains CLI-compatible forms of import options.
+type importFlags struct {
+	enginePath string
+	boltPath   string
+	bucketID   platform.ID
+
+	inputPaths []string
+	compressed bool
+	precision  string
+
+	batchSize          int
+	maxShardBufferSize int64
+	maxLogFileSize     int64
+	maxCacheSize       uint64
+	concurrency        int
+
+	logLevel zapcore.Level
+}
+
+func newFlags() *importFlags {
+	return &importFlags{
+		boltPath:           filepath.Join(os.Getenv("HOME"), ".influxdbv2", bolt.DefaultFilename),
+		precision:          "ns",
+		batchSize:          defaultBatchSize,
+		maxShardBufferSize: defaultMaxShardBufferSize,
+		maxLogFileSize:     tsdb.DefaultMaxIndexLogFileSize,
+		maxCacheSize:       tsdb.DefaultCacheMaxMemorySize,
+		concurrency:        runtime.GOMAXPROCS(0),
+		logLevel:           zapcore.InfoLevel,
+	}
+}
+
+// NewImportLineProtocolCommand builds and registers the `import` subcommand of `influxd inspect`.
+func NewImportLineProtocolCommand(v *viper.Viper) (*cobra.Command, error) {
+	flags := newFlags()
+
+	cmd := &cobra.Command{
+		Use:   `import`,
+		Short: "Import line protocol or TSM files directly into TSM and TSI files",
+		Long: `
+This command will bulk-load line protocol, such as the output of
+'influxd inspect export-lp', or TSM files exported from another engine
+into the shards of a bucket. Inputs ending in .tsm are read as TSM files.
+
+Points are mapped onto shard groups using the bucket's retention policy.
+TSM files are first written to a staging directory under the engine path.
+Only once every input has been read are new shard groups created in the
+metadata store, the files moved into their shards and the TSI index of
+every touched shard rebuilt. If any input fails, the staged files are
+removed and neither the shards nor the metadata are changed.
+
+influxd must not be running against the engine or bolt paths while this
+command runs.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return importRunE(cmd, flags)
+		},
+	}
+
+	opts := []cli.Opt{
+		{
+			DestP:    &flags.enginePath,
+			Flag:     "engine-path",
+			Desc:     "path to persistent engine files",
+			Required: true,
+		},
+		{
+			DestP:   &flags.boltPath,
+			Flag:    "bolt-path",
+			Desc:    "path to the BoltDB file holding bucket metadata",
+			Default: flags.boltPath,
+		},
+		{
+			DestP:    &flags.bucketID,
+			Flag:     "bucket-id",
+			Desc:     "ID of bucket to import data into",
+			Required: true,
+		},
+		{
+			DestP:    &flags.inputPaths,
+			Flag:     "input-path",
+			Desc:     "path(s) to line protocol or .tsm files to import. Use '-' to read line protocol from standard in",
+			Required: true,
+		},
+		{
+			DestP: &flags.compressed,
+			Flag:  "compressed",
+			Desc:  "if true, input is GZIP compressed. Files ending in .gz are always decompressed",
+		},
+		{
+			DestP:   &flags.precision,
+			Flag:    "precision",
+			Desc:    "precision of the timestamps in the input (ns, us, ms or s)",
+			Default: flags.precision,
+		},
+		{
+			DestP:   &flags.batchSize,
+			Flag:    "batch-size",
+			Desc:    "number of lines parsed from the input at once",
+			Default: flags.batchSize,
+		},
+		{
+			DestP:   &flags.maxShardBufferSize,
+			Flag:    "max-shard-buffer-size",
+			Desc:    "bytes of point data buffered per shard before a TSM file is written",
+			Default: flags.maxShardBufferSize,
+		},
+		{
+			DestP:   &flags.maxLogFileSize,
+			Flag:    "max-log-file-size",
+			Desc:    "maximum TSI log file size used while building indexes",
+			Default: flags.maxLogFileSize,
+		},
+		{
+			DestP:   &flags.maxCacheSize,
+			Flag:    "max-cache-size",
+			Desc:    "maximum cache size used when indexing existing WAL files",
+			Default: flags.maxCacheSize,
+		},
+		{
+			DestP:   &flags.concurrency,
+			Flag:    "concurrency",
+			Desc:    "number of shards indexed in parallel",
+			Default: flags.concurrency,
+		},
+		{
+			DestP:   &flags.logLevel,
+			Flag:    "log-level",
+			Default: flags.logLevel,
+		},
+	}
+
+	if err := cli.BindOptions(v, cmd, opts); err != nil {
+		return nil, err
+	}
+	return cmd, nil
+}
+
+func importRunE(cmd *cobra.Command, flags *importFlags) error {
+	logconf := zap.NewProductionConfig()
+	logconf.Level = zap.NewAtomicLevelAt(flags.logLevel)
+	log, err := logconf.Build()
+	if err != nil {
+		return err
+	}
+
+	switch flags.precision {
+	case "ns", "us", "ms", "s":
+	default:
+		return fmt.Errorf("invalid precision %q", flags.precision)
+	}
+	if flags.batchSize <= 0 {
+		return errors.New("batch-size must be greater than 0")
+	}
+	if flags.concurrency <= 0 {
+		return errors.New("concurrency must be greater than 0")
+	}
+
+	ctx := context.Background()
+	store := bolt.NewKVStore(log.With(zap.String("service", "kvstore-bolt")), flags.boltPath)
+	if err := store.Open(ctx); err != nil {
+		return fmt.Errorf("failed to open bolt DB (is influxd running?): %w", err)
+	}
+	defer store.Close()
+
+	metaClient := meta.NewClient(meta.NewConfig(), store)
+	if err := metaClient.Open(); err != nil {
+		return err
+	}
+	defer metaClient.Close()
+
+	imp, err := newImporter(metaClient, flags.enginePath, flags.bucketID, flags.maxShardBufferSize, log)
+	if err != nil {
+		return err
+	}
+
+	for _, path := range flags.inputPaths {
+		if err := importInput(cmd, imp, path, flags); err != nil {
+			imp.abort()
+			return fmt.Errorf("importing %q: %w", path, err)
+		}
+	}
+
+	if err := imp.finish(flags.concurrency, flags.maxLogFileSize, flags.maxCacheSize, flags.batchSize); err != nil {
+		return err
+	}
+
+	log.Info("import complete",
+		zap.Int64("points", imp.stats.points),
+		zap.Int64("values", imp.stats.values),
+		zap.Int64("dropped_retention", imp.stats.droppedRetention),
+		zap.Int64("dropped_type_conflict", imp.stats.droppedConflict),
+		zap.Int("shards", len(imp.shards)),
+	)
+	return nil
+}
+
+// importInput imports a single input path, either as a TSM file or as line
+// protocol.
+func importInput(cmd *cobra.Command, imp *importer, path string, flags *importFlags) error {
+	if strings.HasSuffix(path, "."+tsm1.TSMFileExtension) {
+		return imp.importTSMFile(path)
+	}
+
+	r, closeFn, err := openInput(cmd, path, flags.compressed)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	return imp.importLines(r, flags.precision, flags.batchSize)
+}
+
+// openInput opens a single input path, transparently decompressing it when needed.
+func openInput(cmd *cobra.Command, path string, compressed bool) (io.Reader, func(), error) {
+	var (
+		r       io.Reader
+		closers []io.Closer
+	)
+	if path == "-" {
+		r = cmd.InOrStdin()
+	} else {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		r = f
+		closers = append(closers, f)
+	}
+
+	if compressed || strings.HasSuffix(path, ".gz") {
+		gzr, err := gzip.NewReader(r)
+		if err != nil {
+			for _, c := range closers {
+				c.Close()
+			}
+			return nil, nil, err
+		}
+		r = gzr
+		closers = append(closers, gzr)
+	}
+
+	return r, func() {
+		for i := len(closers) - 1; i >= 0; i-- {
+			closers[i].Close()
+		}
+	}, nil
+}
+
+type importStats struct {
+	points           int64
+	values           int64
+	droppedRetention int64
+	droppedConflict  int64
+}
+
+// importer routes parsed points to per-shard writers which run concurrently.
+//
+// The writers stage their TSM files under stagingDir. Points are routed using
+// layout, a copy of the metadata that the shard groups the imported data needs
+// are added to, so neither the metadata store nor the shards are changed until
+// finish.
+type importer struct {
+	metaClient *meta.Client
+	enginePath string
+	database   string
+	rp         *meta.RetentionPolicyInfo
+	stagingDir string
+
+	maxBufferSize int64
+	log           *zap.Logger
+
+	// groups holds every shard group of layout points have been routed to
+	// so far, and created those of them not in the metadata store yet.
+	layout  meta.Data
+	groups  []meta.ShardGroupInfo
+	created map[uint64]bool
+	shards  map[uint64]*shardWriter
+	errs    errgroup.Group
+
+	stats importStats
+}
+
+func newImporter(metaClient *meta.Client, enginePath string, bucketID platform.ID, maxBufferSize int64, log *zap.Logger) (*importer, error) {
+	database := bucketID.String()
+	dbi := metaClient.Database(database)
+	if dbi == nil {
+		return nil, fmt.Errorf("bucket %s not found in metadata", database)
+	}
+	rpName := dbi.DefaultRetentionPolicy
+	if rpName == "" {
+		rpName = meta.DefaultRetentionPolicyName
+	}
+	rp, err := metaClient.RetentionPolicy(database, rpName)
+	if err != nil {
+		return nil, err
+	} else if rp == nil {
+		return nil, fmt.Errorf("retention policy %q not found for bucket %s", rpName, database)
+	}
+
+	stagingDir, err := os.MkdirTemp(enginePath, ".import-")
+	if err != nil {
+		return nil, err
+	}
+
+	return &importer{
+		metaClient:    metaClient,
+		enginePath:    enginePath,
+		database:      database,
+		rp:            rp,
+		stagingDir:    stagingDir,
+		maxBufferSize: maxBufferSize,
+		log:           log.With(logger.Database(database), logger.RetentionPolicy(rpName)),
+		layout:        metaClient.Data(),
+		created:       make(map[uint64]bool),
+		shards:        make(map[uint64]*shardWriter),
+	}, nil
+}
+
+// importLines reads line protocol from r in batches and routes each point to its shard.
+func (imp *importer) importLines(r io.Reader, precision string, batchSize int) error {
+	br := bufio.NewReaderSize(r, 1024*1024)
+
+	var (
+		buf   []byte
+		lines int
+	)
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			buf = append(buf, line...)
+			if line[len(line)-1] != '\n' {
+				buf = append(buf, '\n')
+			}
+			lines++
+		}
+
+		if lines == batchSize || (err == io.EOF && len(buf) > 0) {
+			if err := imp.importBatch(buf, precision); err != nil {
+				return err
+			}
+			// Parsed points reference buf and are consumed asynchronously
+			// by the shard writers, so it cannot be reused.
+			buf, lines = nil, 0
+		}
+
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+func (imp *importer) importBatch(buf []byte, precision string) error {
+	points, err := models.ParsePointsWithPrecision(buf, time.Now().UTC(), precision)
+	if err != nil {
+		return err
+	}
+
+	min := imp.minTime()
+	batches := make(map[*shardWriter][]models.Point)
+	for _, p := range points {
+		if p.Time().Before(min) {
+			imp.stats.droppedRetention++
+			continue
+		}
+
+		sw, err := imp.shardFor(p.Key(), p.Time())
+		if err != nil {
+			return err
+		}
+		batches[sw] = append(batches[sw], p)
+		imp.stats.points++
+	}
+
+	for sw, pts := range batches {
+		sw.in <- shardBatch{points: pts}
+	}
+	return nil
+}
+
+// importTSMFile reads the TSM file at path and routes the values of each of its
+// keys to their shards.
+func (imp *importer) importTSMFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	r, err := tsm1.NewTSMReader(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	defer r.Close()
+
+	min := imp.minTime()
+	for i := 0; i < r.KeyCount(); i++ {
+		key, typ := r.KeyAt(i)
+		dataType := tsm1.BlockTypeToInfluxQLDataType(typ)
+		if dataType == influxql.Unknown {
+			return fmt.Errorf("unknown block type %d for key %q", typ, key)
+		}
+
+		values, err := r.ReadAll(key)
+		if err != nil {
+			return err
+		}
+
+		// The key refers to the file, which is closed before the shard
+		// writers are done with it.
+		seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey(key)
+		seriesKey, field = append([]byte(nil), seriesKey...), append([]byte(nil), field...)
+
+		batches := make(map[*shardWriter][]tsm1.Value)
+		for _, v := range values {
+			t := time.Unix(0, v.UnixNano())
+			if t.Before(min) {
+				imp.stats.droppedRetention++
+				continue
+			}
+
+			sw, err := imp.shardFor(seriesKey, t)
+			if err != nil {
+				return err
+			}
+			batches[sw] = append(batches[sw], v)
+		}
+
+		for sw, vs := range batches {
+			sw.in <- shardBatch{values: []seriesValues{{key: seriesKey, field: field, typ: dataType, values: vs}}}
+		}
+	}
+	return nil
+}
+
+// minTime returns the time before which data is dropped by the retention policy.
+func (imp *importer) minTime() time.Time {
+	if imp.rp.Duration > 0 {
+		return time.Now().Add(-imp.rp.Duration)
+	}
+	return time.Unix(0, models.MinNanoTime)
+}
+
+// shardFor returns the writer for the shard owning the series at time t,
+// laying out a new shard group if needed.
+func (imp *importer) shardFor(seriesKey []byte, t time.Time) (*shardWriter, error) {
+	var sg *meta.ShardGroupInfo
+	for i := range imp.groups {
+		if imp.groups[i].Contains(t) {
+			sg = &imp.groups[i]
+			break
+		}
+	}
+	if sg == nil {
+		g, err := imp.layout.ShardGroupByTimestamp(imp.database, imp.rp.Name, t)
+		if err != nil {
+			return nil, err
+		} else if g == nil {
+			if err := imp.layout.CreateShardGroup(imp.database, imp.rp.Name, t); err != nil {
+				return nil, err
+			} else if g, err = imp.layout.ShardGroupByTimestamp(imp.database, imp.rp.Name, t); err != nil {
+				return nil, err
+			} else if g == nil {
+				return nil, errors.New("nil shard group")
+			}
+			imp.created[g.ID] = true
+		}
+		imp.groups = append(imp.groups, *g)
+		sg = &imp.groups[len(imp.groups)-1]
+	}
+
+	sh := shardOf(sg, seriesKey)
+	if sw, ok := imp.shards[sh.ID]; ok {
+		return sw, nil
+	}
+
+	// Values conflicting with the types of fields already in the shard are
+	// dropped, as the engine would drop them.
+	var fieldTypes map[string]map[string]influxql.DataType
+	if !imp.created[sg.ID] {
+		var err error
+		if fieldTypes, err = existingFieldTypes(imp.shardPath(sh.ID)); err != nil {
+			return nil, fmt.Errorf("reading fields of shard %d: %w", sh.ID, err)
+		}
+	}
+
+	sw, err := newShardWriter(
+		sh.ID,
+		filepath.Join(imp.stagingDir, fmt.Sprint(sh.ID)),
+		fieldTypes,
+		imp.maxBufferSize,
+		imp.log.With(logger.Shard(sh.ID)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	imp.shards[sh.ID] = sw
+	imp.errs.Go(sw.run)
+	return sw, nil
+}
+
+// shardOf returns the shard of sg owning the series, as sg.ShardFor does for
+// a point.
+func shardOf(sg *meta.ShardGroupInfo, seriesKey []byte) meta.ShardInfo {
+	if len(sg.Shards) == 1 {
+		return sg.Shards[0]
+	}
+	h := models.NewInlineFNV64a()
+	h.Write(seriesKey)
+	return sg.Shards[h.Sum64()%uint64(len(sg.Shards))]
+}
+
+func (imp *importer) shardPath(id uint64) string {
+	return filepath.Join(imp.enginePath, "data", imp.database, imp.rp.Name, fmt.Sprint(id))
+}
+
+// abort stops all running shard writers without flushing their buffered data
+// and removes the files staged so far.
+func (imp *importer) abort() {
+	for _, sw := range imp.shards {
+		sw.discard = true
+		close(sw.in)
+	}
+	_ = imp.errs.Wait()
+
+	if err := os.RemoveAll(imp.stagingDir); err != nil {
+		imp.log.Warn("failed to remove staged files", zap.String("path", imp.stagingDir), zap.Error(err))
+	}
+}
+
+// finish flushes all shard writers, creates the shard groups the data was
+// routed to, moves the staged files into their shards and rebuilds the TSI
+// index of every shard written to. Nothing is changed if flushing fails.
+func (imp *importer) finish(concurrency int, maxLogFileSize int64, maxCacheSize uint64, batchSize int) error {
+	for _, sw := range imp.shards {
+		close(sw.in)
+	}
+	defer os.RemoveAll(imp.stagingDir)
+	if err := imp.errs.Wait(); err != nil {
+		return err
+	}
+
+	shardIDs, err := imp.createShardGroups()
+	if err != nil {
+		return err
+	}
+
+	ids := make([]uint64, 0, len(imp.shards))
+	for id, sw := range imp.shards {
+		if err := sw.moveTo(imp.shardPath(shardIDs[id])); err != nil {
+			return err
+		}
+		imp.stats.values += sw.values
+		imp.stats.droppedConflict += sw.droppedConflict
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	sfile := tsdb.NewSeriesFile(filepath.Join(imp.enginePath, "data", imp.database, tsdb.SeriesFileDirectory))
+	sfile.Logger = imp.log
+	if err := sfile.Open(); err != nil {
+		return err
+	}
+	defer sfile.Close()
+
+	idCh := make(chan uint64, len(ids))
+	for _, id := range ids {
+		idCh <- id
+	}
+	close(idCh)
+
+	var g errgroup.Group
+	for i := 0; i < concurrency; i++ {
+		g.Go(func() error {
+			for id := range idCh {
+				sw := imp.shards[id]
+				walDir := filepath.Join(imp.enginePath, "wal", imp.database, imp.rp.Name, fmt.Sprint(shardIDs[id]))
+				if err := sw.reindex(sfile, walDir, maxLogFileSize, maxCacheSize, batchSize); err != nil {
+					return fmt.Errorf("indexing shard %d: %w", shardIDs[id], err)
+				}
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// createShardGroups creates the shard groups laid out for the imported data
+// that are not in the metadata store yet. It returns the IDs of the shards in
+// the metadata store by their IDs in the layout. If a shard group cannot be
+// created as laid out, those created before it are deleted again.
+func (imp *importer) createShardGroups() (map[uint64]uint64, error) {
+	ids := make(map[uint64]uint64)
+	var created []uint64
+	for _, g := range imp.groups {
+		if !imp.created[g.ID] {
+			for _, sh := range g.Shards {
+				ids[sh.ID] = sh.ID
+			}
+			continue
+		}
+
+		sg, err := imp.metaClient.CreateShardGroup(imp.database, imp.rp.Name, g.StartTime)
+		if err == nil && (sg == nil || !sg.StartTime.Equal(g.StartTime) || !sg.EndTime.Equal(g.EndTime) || len(sg.Shards) != len(g.Shards)) {
+			err = fmt.Errorf("shard group for %s changed during the import", g.StartTime)
+		}
+		if err != nil {
+			for _, id := range created {
+				if err := imp.metaClient.DeleteShardGroup(imp.database, imp.rp.Name, id); err != nil {
+					imp.log.Warn("failed to delete shard group", zap.Uint64("id", id), zap.Error(err))
+				}
+			}
+			return nil, err
+		}
+
+		created = append(created, sg.ID)
+		for i, sh := range g.Shards {
+			ids[sh.ID] = sg.Shards[i].ID
+		}
+	}
+	return ids, nil
+}
+
+// existingFieldTypes returns the types of the fields already in the shard at
+// path. They are read from the shard's field set or, if it has none, from the
+// keys of its TSM files.
+func existingFieldTypes(path string) (map[string]map[string]influxql.DataType, error) {
+	types := make(map[string]map[string]influxql.DataType)
+	add := func(name, field string, typ influxql.DataType) {
+		if types[name] == nil {
+			types[name] = make(map[string]influxql.DataType)
+		}
+		types[name][field] = typ
+	}
+
+	fs, err := tsdb.NewMeasurementFieldSet(filepath.Join(path, "fields.idx"))
+	if err != nil {
+		fs.Close()
+		return nil, err
+	}
+	defer fs.Close()
+	for _, name := range fs.MeasurementNames() {
+		fs.FieldsByString(name).ForEachField(func(field string, typ influxql.DataType) bool {
+			add(name, field, typ)
+			return true
+		})
+	}
+	if len(types) > 0 {
+		return types, nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		r, err := tsm1.NewTSMReader(f)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		for i := 0; i < r.KeyCount(); i++ {
+			key, typ := r.KeyAt(i)
+			seriesKey, field := tsm1.SeriesAndFieldFromCompositeKey(key)
+			add(string(models.ParseName(seriesKey)), string(field), tsm1.BlockTypeToInfluxQLDataType(typ))
+		}
+		if err := r.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return types, nil
+}
+
+// shardBatch is data routed to a shard, either parsed points or the values of
+// series read from a TSM file.
+type shardBatch struct {
+	points []models.Point
+	values []seriesValues
+}
+
+// seriesValues are values of a field of a series.
+type seriesValues struct {
+	key    []byte
+	field  []byte
+	typ    influxql.DataType
+	values []tsm1.Value
+}
+
+// shardWriter buffers values for a single shard and writes them out as TSM
+// files into its staging directory.
+type shardWriter struct {
+	id   uint64
+	path string
+	in   chan shardBatch
+	log  *zap.Logger
+
+	// discard is set before in is closed to drop buffered values instead of
+	// flushing them.
+	discard bool
+
+	maxBufferSize int64
+	bufferSize    int64
+	values        int64
+	generation    int
+
+	cache map[string][]tsm1.Value
+
+	// fieldTypes tracks the type of each field per measurement, mirroring the
+	// field type constraint the engine enforces on each shard.
+	fieldTypes map[string]map[string]influxql.DataType
+
+	droppedConflict int64
+	logConflict     sync.Once
+}
+
+func newShardWriter(id uint64, path string, fieldTypes map[string]map[string]influxql.DataType, maxBufferSize int64, log *zap.Logger) (*shardWriter, error) {
+	if err := os.MkdirAll(path, 0777); err != nil {
+		return nil, err
+	}
+	if fieldTypes == nil {
+		fieldTypes = make(map[string]map[string]influxql.DataType)
+	}
+
+	return &shardWriter{
+		id:            id,
+		path:          path,
+		in:            make(chan shardBatch, 4),
+		log:           log,
+		maxBufferSize: maxBufferSize,
+		cache:         make(map[string][]tsm1.Value),
+		fieldTypes:    fieldTypes,
+	}, nil
+}
+
+func (sw *shardWriter) run() error {
+	var err error
+	for b := range sw.in {
+		if err != nil {
+			// Drain the channel so the importer never blocks on a failed shard.
+			continue
+		}
+		if err = sw.add(b); err == nil && sw.bufferSize >= sw.maxBufferSize {
+			err = sw.flush()
+		}
+	}
+	if err != nil || sw.discard {
+		return err
+	}
+	return sw.flush()
+}
+
+func (sw *shardWriter) add(b shardBatch) error {
+	for _, p := range b.points {
+		seriesKey := string(p.Key())
+		t := p.Time().UnixNano()
+		iter := p.FieldIterator()
+		for iter.Next() {
+			field := iter.FieldKey()
+			if bytes.Equal(field, []byte("time")) {
+				continue
+			} else if !sw.accept(p.Name(), field, dataType(iter.Type()), 1) {
+				continue
+			}
+
+			var v tsm1.Value
+			switch iter.Type() {
+			case models.Float:
+				fv, err := iter.FloatValue()
+				if err != nil {
+					return err
+				}
+				v = tsm1.NewFloatValue(t, fv)
+			case models.Integer:
+				iv, err := iter.IntegerValue()
+				if err != nil {
+					return err
+				}
+				v = tsm1.NewIntegerValue(t, iv)
+			case models.Unsigned:
+				uv, err := iter.UnsignedValue()
+				if err != nil {
+					return err
+				}
+				v = tsm1.NewUnsignedValue(t, uv)
+			case models.String:
+				v = tsm1.NewStringValue(t, iter.StringValue())
+			case models.Boolean:
+				bv, err := iter.BooleanValue()
+				if err != nil {
+					return err
+				}
+				v = tsm1.NewBooleanValue(t, bv)
+			default:
+				return fmt.Errorf("unknown field type for %s: %s", string(iter.FieldKey()), p.String())
+			}
+
+			sw.cacheValues(string(tsm1.SeriesFieldKeyBytes(seriesKey, string(field))), v)
+		}
+	}
+
+	for _, sv := range b.values {
+		if sw.accept(models.ParseName(sv.key), sv.field, sv.typ, len(sv.values)) {
+			sw.cacheValues(string(tsm1.SeriesFieldKeyBytes(string(sv.key), string(sv.field))), sv.values...)
+		}
+	}
+	return nil
+}
+
+// accept returns true if n values of type typ may be written to a field,
+// recording the type of fields not seen before. Values conflicting with the
+// type of a field are counted as dropped.
+func (sw *shardWriter) accept(name, field []byte, typ influxql.DataType, n int) bool {
+	types := sw.fieldTypes[string(name)]
+	if types == nil {
+		types = make(map[string]influxql.DataType)
+		sw.fieldTypes[string(name)] = types
+	}
+
+	if t, ok := types[string(field)]; !ok {
+		types[string(field)] = typ
+		return true
+	} else if t == typ {
+		return true
+	}
+
+	sw.droppedConflict += int64(n)
+	sw.logConflict.Do(func() {
+		sw.log.Warn("dropping values with conflicting field types",
+			zap.ByteString("measurement", name), zap.ByteString("field", field))
+	})
+	return false
+}
+
+func (sw *shardWriter) cacheValues(key string, values ...tsm1.Value) {
+	if _, ok := sw.cache[key]; !ok {
+		sw.bufferSize += int64(len(key))
+	}
+	sw.cache[key] = append(sw.cache[key], values...)
+	for _, v := range values {
+		sw.bufferSize += int64(v.Size())
+	}
+	sw.values += int64(len(values))
+}
+
+// dataType returns the type fields of typ are stored as.
+func dataType(typ models.FieldType) influxql.DataType {
+	switch typ {
+	case models.Float:
+		return influxql.Float
+	case models.Integer:
+		return influxql.Integer
+	case models.Unsigned:
+		return influxql.Unsigned
+	case models.String:
+		return influxql.String
+	case models.Boolean:
+		return influxql.Boolean
+	default:
+		return influxql.Unknown
+	}
+}
+
+// flush writes all buffered values to a new TSM file in the staging directory.
+func (sw *shardWriter) flush() error {
+	if len(sw.cache) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(sw.cache))
+	for k := range sw.cache {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	sw.generation++
+	name := filepath.Join(sw.path, fmt.Sprintf("%s.%s", tsm1.DefaultFormatFileName(sw.generation, 1), tsm1.TSMFileExtension))
+	tmpName := name + "." + tsm1.TmpTSMFileExtension
+	sw.log.Debug("writing TSM file", zap.String("path", name), zap.Int("keys", len(keys)))
+
+	f, err := os.OpenFile(tmpName, os.O_CREATE|os.O_RDWR|os.O_EXCL, 0666)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w, err := tsm1.NewTSMWriterWithDiskBuffer(f)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		values := tsm1.Values(sw.cache[key]).Deduplicate()
+		for len(values) > 0 {
+			n := len(values)
+			if n > tsdb.DefaultMaxPointsPerBlock {
+				n = tsdb.DefaultMaxPointsPerBlock
+			}
+			if err := w.Write([]byte(key), values[:n]); err != nil {
+				w.Close()
+				return err
+			}
+			values = values[n:]
+		}
+	}
+
+	if err := w.WriteIndex(); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpName, name); err != nil {
+		return err
+	}
+
+	sw.cache = make(map[string][]tsm1.Value)
+	sw.bufferSize = 0
+	return nil
+}
+
+// moveTo moves the staged TSM files into the shard directory at path. They
+// are numbered after any TSM files already in the shard, so imported data
+// supersedes existing data with the same timestamps.
+func (sw *shardWriter) moveTo(path string) error {
+	if err := os.MkdirAll(path, 0777); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(path, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		return err
+	}
+	var generation int
+	for _, f := range files {
+		gen, _, err := tsm1.DefaultParseFileName(f)
+		if err != nil {
+			return err
+		}
+		if gen > generation {
+			generation = gen
+		}
+	}
+
+	// Staged files are named in the order they were written.
+	staged, err := filepath.Glob(filepath.Join(sw.path, "*."+tsm1.TSMFileExtension))
+	if err != nil {
+		return err
+	}
+	for _, f := range staged {
+		generation++
+		name := filepath.Join(path, fmt.Sprintf("%s.%s", tsm1.DefaultFormatFileName(generation, 1), tsm1.TSMFileExtension))
+		if err := os.Rename(f, name); err != nil {
+			return err
+		}
+	}
+	sw.path = path
+	return nil
+}
+
+// reindex rebuilds the shard's TSI index and drops its cached field set so
+// both are regenerated from the full set of TSM and WAL files.
+func (sw *shardWriter) reindex(sfile *tsdb.SeriesFile, walDir string, maxLogFileSize int64, maxCacheSize uint64, batchSize int) error {
+	for _, p := range []string{filepath.Join(sw.path, "index"), filepath.Join(sw.path, "fields.idx")} {
+		if err := os.RemoveAll(p); err != nil {
+			return err
+		}
+	}
+	return build_tsi.IndexShard(sfile, sw.path, walDir, maxLogFileSize, maxCacheSize, batchSize, sw.log)
+}
@@ -0,0 +1,261 @@
+package import_lp
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/inmem"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxdb/v2/tsdb/index/tsi1"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+func newMetaClient(t *testing.T, bucketID platform.ID, rp, sgDuration time.Duration) *meta.Client {
+	t.Helper()
+
+	store := inmem.NewKVStore()
+	require.NoError(t, store.CreateBucket(context.Background(), meta.BucketName))
+
+	c := meta.NewClient(meta.NewConfig(), store)
+	require.NoError(t, c.Open())
+	t.Cleanup(func() { c.Close() })
+
+	_, err := c.CreateDatabaseWithRetentionPolicy(bucketID.String(), &meta.RetentionPolicySpec{
+		Name:               meta.DefaultRetentionPolicyName,
+		Duration:           &rp,
+		ShardGroupDuration: sgDuration,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+func readTSMValues(t *testing.T, shardDir string) map[string][]tsm1.Value {
+	t.Helper()
+
+	paths, err := filepath.Glob(filepath.Join(shardDir, "*."+tsm1.TSMFileExtension))
+	require.NoError(t, err)
+
+	values := make(map[string][]tsm1.Value)
+	for _, path := range paths {
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		r, err := tsm1.NewTSMReader(f)
+		require.NoError(t, err)
+		for i := 0; i < r.KeyCount(); i++ {
+			key, _ := r.KeyAt(i)
+			vs, err := r.ReadAll(key)
+			require.NoError(t, err)
+			values[string(key)] = append(values[string(key)], vs...)
+		}
+		require.NoError(t, r.Close())
+	}
+	return values
+}
+
+func Test_importer(t *testing.T) {
+	bucketID := platform.ID(0x1000)
+	enginePath := t.TempDir()
+	metaClient := newMetaClient(t, bucketID, 0, 24*time.Hour)
+
+	day := int64(24 * time.Hour)
+	lines := []string{
+		"# a comment",
+		fmt.Sprintf("cpu,host=a usage=1.5,count=2i %d", 1*day),
+		fmt.Sprintf("cpu,host=a usage=2.5 %d", 1*day+10),
+		fmt.Sprintf("cpu,host=b usage=3.5 %d", 2*day),
+		// Conflicts with the float type already seen for usage in the first shard.
+		fmt.Sprintf("cpu,host=c usage=\"bad\" %d", 1*day+20),
+		"",
+		fmt.Sprintf("mem,host=a free=10u,ok=true %d", 2*day+5),
+	}
+
+	imp, err := newImporter(metaClient, enginePath, bucketID, 1, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, imp.importLines(strings.NewReader(strings.Join(lines, "\n")), "ns", 2))
+	require.NoError(t, imp.finish(2, tsdb.DefaultMaxIndexLogFileSize, tsdb.DefaultCacheMaxMemorySize, 100))
+
+	require.Equal(t, int64(5), imp.stats.points)
+	require.Equal(t, int64(6), imp.stats.values)
+	require.Equal(t, int64(1), imp.stats.droppedConflict)
+
+	groups, err := metaClient.ShardGroupsByTimeRange(bucketID.String(), meta.DefaultRetentionPolicyName, time.Unix(0, 0), time.Unix(0, 3*day))
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	require.Len(t, imp.shards, 2)
+
+	rpDir := filepath.Join(enginePath, "data", bucketID.String(), meta.DefaultRetentionPolicyName)
+	first := readTSMValues(t, filepath.Join(rpDir, fmt.Sprint(groups[0].Shards[0].ID)))
+	require.Equal(t, map[string][]tsm1.Value{
+		"cpu,host=a#!~#count": {tsm1.NewIntegerValue(1*day, 2)},
+		"cpu,host=a#!~#usage": {tsm1.NewFloatValue(1*day, 1.5), tsm1.NewFloatValue(1*day+10, 2.5)},
+	}, first)
+
+	second := readTSMValues(t, filepath.Join(rpDir, fmt.Sprint(groups[1].Shards[0].ID)))
+	require.Equal(t, map[string][]tsm1.Value{
+		"cpu,host=b#!~#usage": {tsm1.NewFloatValue(2*day, 3.5)},
+		"mem,host=a#!~#free":  {tsm1.NewUnsignedValue(2*day+5, 10)},
+		"mem,host=a#!~#ok":    {tsm1.NewBooleanValue(2*day+5, true)},
+	}, second)
+
+	sfile := tsdb.NewSeriesFile(filepath.Join(enginePath, "data", bucketID.String(), tsdb.SeriesFileDirectory))
+	require.NoError(t, sfile.Open())
+	defer sfile.Close()
+	for i, exp := range [][]string{{"cpu"}, {"cpu", "mem"}} {
+		require.Equal(t, exp, indexedMeasurements(t, sfile, filepath.Join(rpDir, fmt.Sprint(groups[i].Shards[0].ID))))
+	}
+	requireNoStagedFiles(t, enginePath)
+}
+
+func indexedMeasurements(t *testing.T, sfile *tsdb.SeriesFile, shardDir string) []string {
+	t.Helper()
+
+	idx := tsi1.NewIndex(sfile, "", tsi1.WithPath(filepath.Join(shardDir, "index")))
+	require.NoError(t, idx.Open())
+	defer idx.Close()
+
+	var names []string
+	require.NoError(t, idx.ForEachMeasurementName(func(name []byte) error {
+		names = append(names, string(name))
+		return nil
+	}))
+	return names
+}
+
+func requireNoStagedFiles(t *testing.T, enginePath string) {
+	t.Helper()
+
+	staged, err := filepath.Glob(filepath.Join(enginePath, ".import-*"))
+	require.NoError(t, err)
+	require.Empty(t, staged)
+}
+
+func Test_importer_Abort(t *testing.T) {
+	bucketID := platform.ID(0x1000)
+	enginePath := t.TempDir()
+	metaClient := newMetaClient(t, bucketID, 0, 24*time.Hour)
+
+	day := int64(24 * time.Hour)
+	lines := []string{
+		fmt.Sprintf("cpu,host=a usage=1.5 %d", 1*day),
+		fmt.Sprintf("cpu,host=b usage=2.5 %d", 2*day),
+		"cpu,host=c usage=",
+	}
+
+	// The first batch is flushed before the second fails to parse.
+	imp, err := newImporter(metaClient, enginePath, bucketID, 1, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.Error(t, imp.importLines(strings.NewReader(strings.Join(lines, "\n")), "ns", 2))
+	imp.abort()
+
+	groups, err := metaClient.ShardGroupsByTimeRange(bucketID.String(), meta.DefaultRetentionPolicyName, time.Unix(0, 0), time.Unix(0, 3*day))
+	require.NoError(t, err)
+	require.Empty(t, groups)
+
+	_, err = os.Stat(filepath.Join(enginePath, "data"))
+	require.True(t, os.IsNotExist(err))
+	requireNoStagedFiles(t, enginePath)
+}
+
+func Test_importer_ExistingFields(t *testing.T) {
+	bucketID := platform.ID(0x1000)
+	enginePath := t.TempDir()
+	metaClient := newMetaClient(t, bucketID, 0, 24*time.Hour)
+
+	day := int64(24 * time.Hour)
+	importLines := func(lines ...string) *importer {
+		imp, err := newImporter(metaClient, enginePath, bucketID, defaultMaxShardBufferSize, zaptest.NewLogger(t))
+		require.NoError(t, err)
+		require.NoError(t, imp.importLines(strings.NewReader(strings.Join(lines, "\n")), "ns", defaultBatchSize))
+		require.NoError(t, imp.finish(1, tsdb.DefaultMaxIndexLogFileSize, tsdb.DefaultCacheMaxMemorySize, 100))
+		return imp
+	}
+
+	importLines(fmt.Sprintf("cpu,host=a usage=1.5 %d", 1*day))
+	imp := importLines(
+		fmt.Sprintf("cpu,host=a usage=\"bad\" %d", 1*day+10),
+		fmt.Sprintf("cpu,host=a usage=2.5 %d", 1*day+20),
+	)
+	require.Equal(t, int64(1), imp.stats.droppedConflict)
+
+	groups, err := metaClient.ShardGroupsByTimeRange(bucketID.String(), meta.DefaultRetentionPolicyName, time.Unix(0, 0), time.Unix(0, 3*day))
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+
+	shardDir := filepath.Join(enginePath, "data", bucketID.String(), meta.DefaultRetentionPolicyName, fmt.Sprint(groups[0].Shards[0].ID))
+	files, err := filepath.Glob(filepath.Join(shardDir, "*."+tsm1.TSMFileExtension))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, map[string][]tsm1.Value{
+		"cpu,host=a#!~#usage": {tsm1.NewFloatValue(1*day, 1.5), tsm1.NewFloatValue(1*day+20, 2.5)},
+	}, readTSMValues(t, shardDir))
+}
+
+func Test_importer_TSM(t *testing.T) {
+	bucketID := platform.ID(0x1000)
+	enginePath := t.TempDir()
+	metaClient := newMetaClient(t, bucketID, 0, 24*time.Hour)
+
+	day := int64(24 * time.Hour)
+	path := filepath.Join(t.TempDir(), "000000001-000000001.tsm")
+	f, err := os.Create(path)
+	require.NoError(t, err)
+	w, err := tsm1.NewTSMWriter(f)
+	require.NoError(t, err)
+	require.NoError(t, w.Write([]byte("cpu,host=a#!~#usage"), []tsm1.Value{tsm1.NewFloatValue(1*day, 1.5), tsm1.NewFloatValue(2*day, 2.5)}))
+	require.NoError(t, w.Write([]byte("mem,host=a#!~#free"), []tsm1.Value{tsm1.NewUnsignedValue(2*day, 10)}))
+	require.NoError(t, w.WriteIndex())
+	require.NoError(t, w.Close())
+
+	imp, err := newImporter(metaClient, enginePath, bucketID, defaultMaxShardBufferSize, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, imp.importTSMFile(path))
+	require.NoError(t, imp.finish(1, tsdb.DefaultMaxIndexLogFileSize, tsdb.DefaultCacheMaxMemorySize, 100))
+	require.Equal(t, int64(3), imp.stats.values)
+
+	groups, err := metaClient.ShardGroupsByTimeRange(bucketID.String(), meta.DefaultRetentionPolicyName, time.Unix(0, 0), time.Unix(0, 3*day))
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+
+	rpDir := filepath.Join(enginePath, "data", bucketID.String(), meta.DefaultRetentionPolicyName)
+	require.Equal(t, map[string][]tsm1.Value{
+		"cpu,host=a#!~#usage": {tsm1.NewFloatValue(1*day, 1.5)},
+	}, readTSMValues(t, filepath.Join(rpDir, fmt.Sprint(groups[0].Shards[0].ID))))
+	require.Equal(t, map[string][]tsm1.Value{
+		"cpu,host=a#!~#usage": {tsm1.NewFloatValue(2*day, 2.5)},
+		"mem,host=a#!~#free":  {tsm1.NewUnsignedValue(2*day, 10)},
+	}, readTSMValues(t, filepath.Join(rpDir, fmt.Sprint(groups[1].Shards[0].ID))))
+}
+
+func Test_importer_Retention(t *testing.T) {
+	bucketID := platform.ID(0x2000)
+	enginePath := t.TempDir()
+	metaClient := newMetaClient(t, bucketID, time.Hour, time.Hour)
+
+	now := time.Now().UnixNano()
+	lines := fmt.Sprintf("m v=1 %d\nm v=2 %d\n", now, now-int64(2*time.Hour))
+
+	imp, err := newImporter(metaClient, enginePath, bucketID, defaultMaxShardBufferSize, zaptest.NewLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, imp.importLines(strings.NewReader(lines), "ns", defaultBatchSize))
+	require.NoError(t, imp.finish(1, tsdb.DefaultMaxIndexLogFileSize, tsdb.DefaultCacheMaxMemorySize, 100))
+
+	require.Equal(t, int64(1), imp.stats.points)
+	require.Equal(t, int64(1), imp.stats.droppedRetention)
+	require.Len(t, imp.shards, 1)
+}
+
+func Test_newImporter_MissingBucket(t *testing.T) {
+	metaClient := newMetaClient(t, platform.ID(0x3000), 0, 24*time.Hour)
+
+	_, err := newImporter(metaClient, t.TempDir(), platform.ID(0x4000), defaultMaxShardBufferSize, zaptest.NewLogger(t))
+	require.Error(t, err)
+}
@@ -2,14 +2,17 @@ package inspect
 
 import (
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/build_tsi"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/compact_shard"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/delete_tsm"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/dump_tsi"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/dump_tsm"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/dump_wal"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/export_index"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/export_lp"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/import_lp"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/report_tsi"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/report_tsm"
+	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/series_gc"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/verify_seriesfile"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/verify_tombstone"
 	"github.com/influxdata/influxdb/v2/cmd/influxd/inspect/verify_tsm"
@@ -34,6 +37,11 @@ func NewCommand(v *viper.Viper) (*cobra.Command, error) {
 		return nil, err
 	}
 	base.AddCommand(exportLp)
+	importLp, err := import_lp.NewImportLineProtocolCommand(v)
+	if err != nil {
+		return nil, err
+	}
+	base.AddCommand(importLp)
 	base.AddCommand(report_tsi.NewReportTSICommand())
 	base.AddCommand(export_index.NewExportIndexCommand())
 	base.AddCommand(verify_tsm.NewTSMVerifyCommand())
@@ -46,6 +54,8 @@ func NewCommand(v *viper.Viper) (*cobra.Command, error) {
 	base.AddCommand(verify_wal.NewVerifyWALCommand())
 	base.AddCommand(report_tsm.NewReportTSMCommand())
 	base.AddCommand(build_tsi.NewBuildTSICommand())
+	base.AddCommand(compact_shard.NewCompactShardCommand())
+	base.AddCommand(series_gc.NewSeriesGCCommand())
 
 	return base, nil
 }
@@ -0,0 +1,85 @@
+package series_gc
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/spf13/cobra"
+)
+
+type args struct {
+	path    string // series file directory
+	compact bool   // rewrite the segments without deleted series
+}
+
+func NewSeriesGCCommand() *cobra.Command {
+	var arguments args
+	cmd := &cobra.Command{
+		Use:   "series-gc",
+		Short: "Shows and reclaims the space held by deleted series in a series file",
+		Long: `
+This command shows, for each partition of a series file, the number of series,
+the number of deleted series whose entries are still held by its segments and
+the number of bytes they take up. Series are deleted by the series garbage
+collector once they have no data in any shard of the bucket.
+With --compact, it then rewrites the segments without the deleted series.
+The series file must not be open by a running influxd while it is compacted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			return arguments.run(cmd)
+		},
+	}
+
+	cmd.Flags().StringVarP(&arguments.path, "series-file", "s", "", "Path to the series file directory, e.g. ~/.influxdbv2/engine/data/<bucket-id>/_series")
+	cmd.Flags().BoolVar(&arguments.compact, "compact", false, "Rewrite the series file segments without deleted series")
+	cmd.MarkFlagRequired("series-file")
+
+	return cmd
+}
+
+func (a *args) run(cmd *cobra.Command) error {
+	if fi, err := os.Stat(a.path); err != nil {
+		return fmt.Errorf("failed to read series file directory %q: %w", a.path, err)
+	} else if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", a.path)
+	}
+
+	sfile := tsdb.NewSeriesFile(a.path)
+	if err := sfile.Open(); err != nil {
+		return fmt.Errorf("failed to open series file %q: %w", a.path, err)
+	}
+	defer sfile.Close()
+
+	a.printPartitions(cmd, sfile)
+
+	if !a.compact {
+		return nil
+	}
+
+	n, err := sfile.CompactSegments()
+	if err != nil {
+		return fmt.Errorf("compaction failed: %w", err)
+	}
+	cmd.Printf("\nReclaimed %d bytes.\n\n", n)
+	a.printPartitions(cmd, sfile)
+	return nil
+}
+
+func (a *args) printPartitions(cmd *cobra.Command, sfile *tsdb.SeriesFile) {
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 8, 2, 1, ' ', 0)
+	_, _ = fmt.Fprintln(tw, strings.Join([]string{"Partition", "Segments", "Series", "Deleted", "Reclaimable"}, "\t"))
+
+	var seriesN uint64
+	var deletedN int
+	var size int64
+	for _, p := range sfile.Partitions() {
+		n, sz := p.DeletedSeries()
+		_, _ = fmt.Fprintf(tw, "%d\t%d\t%d\t%d\t%d\n", p.ID(), len(p.Segments()), p.SeriesCount(), n, sz)
+		seriesN, deletedN, size = seriesN+p.SeriesCount(), deletedN+n, size+sz
+	}
+	_, _ = fmt.Fprintf(tw, "Total\t\t%d\t%d\t%d\n", seriesN, deletedN, size)
+	_ = tw.Flush()
+}
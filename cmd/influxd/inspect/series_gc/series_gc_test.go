@@ -0,0 +1,79 @@
+package series_gc
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SeriesGC_Report(t *testing.T) {
+	dir := createSeriesFile(t, 100, 10)
+
+	out, err := runCommand(t, "--series-file", dir)
+	require.NoError(t, err)
+	require.Contains(t, out, "Reclaimable")
+	require.NotContains(t, out, "Reclaimed")
+}
+
+func Test_SeriesGC_Compact(t *testing.T) {
+	dir := createSeriesFile(t, 100, 10)
+
+	out, err := runCommand(t, "--series-file", dir, "--compact")
+	require.NoError(t, err)
+	require.Contains(t, out, "Reclaimed")
+
+	sfile := tsdb.NewSeriesFile(dir)
+	require.NoError(t, sfile.Open())
+	defer sfile.Close()
+	for i := 0; i < 100; i++ {
+		id := sfile.SeriesID([]byte("cpu"), models.NewTags(map[string]string{"host": fmt.Sprint(i)}), nil)
+		require.Equal(t, i < 10, id == 0, "series %d", i)
+	}
+}
+
+func Test_SeriesGC_MissingPath(t *testing.T) {
+	_, err := runCommand(t, "--series-file", "/does/not/exist")
+	require.Error(t, err)
+}
+
+func runCommand(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+
+	cmd := NewSeriesGCCommand()
+	cmd.SetArgs(args)
+
+	b := &bytes.Buffer{}
+	cmd.SetOut(b)
+	cmd.SetErr(b)
+
+	err := cmd.Execute()
+	return b.String(), err
+}
+
+// createSeriesFile creates a series file with n series and deletes the first
+// deleted of them.
+func createSeriesFile(t *testing.T, n, deleted int) string {
+	t.Helper()
+	dir := t.TempDir()
+
+	sfile := tsdb.NewSeriesFile(dir)
+	require.NoError(t, sfile.Open())
+	defer sfile.Close()
+
+	names := make([][]byte, 0, n)
+	tagsSlice := make([]models.Tags, 0, n)
+	for i := 0; i < n; i++ {
+		names = append(names, []byte("cpu"))
+		tagsSlice = append(tagsSlice, models.NewTags(map[string]string{"host": fmt.Sprint(i)}))
+	}
+	ids, err := sfile.CreateSeriesListIfNotExists(names, tagsSlice)
+	require.NoError(t, err)
+	for _, id := range ids[:deleted] {
+		require.NoError(t, sfile.DeleteSeriesID(id))
+	}
+	return dir
+}
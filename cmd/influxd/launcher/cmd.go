@@ -10,15 +10,18 @@ import (
 
 	"github.com/influxdata/influxdb/v2/bolt"
 	"github.com/influxdata/influxdb/v2/fluxinit"
+	"github.com/influxdata/influxdb/v2/idempotency"
 	"github.com/influxdata/influxdb/v2/internal/fs"
 	"github.com/influxdata/influxdb/v2/kit/cli"
 	"github.com/influxdata/influxdb/v2/kit/signals"
 	influxlogger "github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/pprof"
+	"github.com/influxdata/influxdb/v2/quota"
 	"github.com/influxdata/influxdb/v2/sqlite"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/v1/coordinator"
 	"github.com/influxdata/influxdb/v2/vault"
+	"github.com/influxdata/influxdb/v2/writequeue"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"go.uber.org/zap/zapcore"
@@ -167,6 +170,8 @@ type InfluxdOpts struct {
 	SessionLength         int // in minutes
 	SessionRenewDisabled  bool
 
+	StorageGRPCBindAddress string
+
 	ProfilingDisabled bool
 	MetricsDisabled   bool
 	UIDisabled        bool
@@ -185,6 +190,26 @@ type InfluxdOpts struct {
 	QueueSize                       int32
 	CoordinatorConfig               coordinator.Config
 
+	// Quota options.
+	QuotaStorageRefreshInterval time.Duration
+
+	// WriteIdempotencyWindow is how long the results of writes made with an
+	// idempotency key are remembered.
+	WriteIdempotencyWindow time.Duration
+
+	// ListenersConfigPath is the path of the TOML file configuring the UDP
+	// and TCP listeners.
+	ListenersConfigPath string
+
+	// WriteQueueEnabled enables the durable queue of the writes preferring
+	// an asynchronous response.
+	WriteQueueEnabled bool
+	// WriteQueueMaxSize is the size in bytes of the write queue.
+	WriteQueueMaxSize int64
+	// WriteQueueStatusRetention is how long the status of an applied batch
+	// of the write queue is kept.
+	WriteQueueStatusRetention time.Duration
+
 	// Storage options.
 	StorageConfig storage.Config
 
@@ -239,6 +264,13 @@ func NewOpts(viper *viper.Viper) *InfluxdOpts {
 		MaxMemoryBytes:                  0,
 		QueueSize:                       1024,
 
+		QuotaStorageRefreshInterval: quota.DefaultStorageRefreshInterval,
+
+		WriteIdempotencyWindow: idempotency.DefaultWindow,
+
+		WriteQueueMaxSize:         writequeue.DefaultMaxSize,
+		WriteQueueStatusRetention: writequeue.DefaultStatusRetention,
+
 		Testing:                 false,
 		TestingAlwaysAllowSetup: false,
 
@@ -435,6 +467,11 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.HttpTLSStrictCiphers,
 			Desc:    "Restrict accept ciphers to: ECDHE_ECDSA_WITH_AES_128_GCM_SHA256, ECDHE_RSA_WITH_AES_128_GCM_SHA256, ECDHE_ECDSA_WITH_AES_256_GCM_SHA384, ECDHE_RSA_WITH_AES_256_GCM_SHA384, ECDHE_ECDSA_WITH_CHACHA20_POLY1305, ECDHE_RSA_WITH_CHACHA20_POLY1305",
 		},
+		{
+			DestP: &o.StorageGRPCBindAddress,
+			Flag:  "storage-grpc-bind-address",
+			Desc:  "bind address for the gRPC storage read API, which streams raw reads of buckets to external clients. Uses the TLS certificate and key of the HTTP API if set. Disabled if empty",
+		},
 
 		{
 			DestP:   &o.NoTasks,
@@ -472,6 +509,41 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Default: o.QueueSize,
 			Desc:    "the number of queries that are allowed to be awaiting execution before new queries are rejected. Must be > 0 if query-concurrency is not unlimited",
 		},
+		{
+			DestP:   &o.QuotaStorageRefreshInterval,
+			Flag:    "quota-storage-refresh-interval",
+			Default: o.QuotaStorageRefreshInterval,
+			Desc:    "how often the size on disk of organizations with a storage quota is measured",
+		},
+		{
+			DestP:   &o.WriteIdempotencyWindow,
+			Flag:    "write-idempotency-window",
+			Default: o.WriteIdempotencyWindow,
+			Desc:    "how long the result of a write with an Idempotency-Key header is returned for retries of the write with the same key",
+		},
+		{
+			DestP: &o.ListenersConfigPath,
+			Flag:  "listeners-config",
+			Desc:  "path to a TOML file whose [[listener]] tables configure UDP and TCP listeners receiving line protocol or Graphite plaintext",
+		},
+		{
+			DestP:   &o.WriteQueueEnabled,
+			Flag:    "write-queue-enabled",
+			Default: o.WriteQueueEnabled,
+			Desc:    "queue writes with a 'Prefer: respond-async' header in a durable queue under the engine path and answer them with 202 Accepted before they are written",
+		},
+		{
+			DestP:   &o.WriteQueueMaxSize,
+			Flag:    "write-queue-max-size",
+			Default: o.WriteQueueMaxSize,
+			Desc:    "maximum size in bytes of the write queue; asynchronous writes are rejected with 429 once it is full",
+		},
+		{
+			DestP:   &o.WriteQueueStatusRetention,
+			Flag:    "write-queue-status-retention",
+			Default: o.WriteQueueStatusRetention,
+			Desc:    "how long the status of a batch applied from the write queue is served",
+		},
 		{
 			DestP: &o.FeatureFlags,
 			Flag:  "feature-flags",
@@ -537,6 +609,16 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-compact-full-write-cold-duration",
 			Desc:  "The duration at which the engine will compact all TSM files in a shard if it hasn't received a write or delete.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.CompactTombstoneThreshold,
+			Flag:  "storage-compact-tombstone-threshold",
+			Desc:  "The percentage of a TSM generation's data that must be deleted before the engine rewrites it to reclaim disk space. A value of 0 disables tombstone compactions.",
+		},
+		{
+			DestP: &o.StorageConfig.Data.CompactRollups,
+			Flag:  "storage-compact-rollups",
+			Desc:  "Write summaries of the blocks of TSM files alongside them so that window aggregates over whole blocks are answered without decoding them.",
+		},
 		{
 			DestP: &o.StorageConfig.Data.CompactThroughputBurst,
 			Flag:  "storage-compact-throughput-burst",
@@ -548,6 +630,26 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-max-concurrent-compactions",
 			Desc:  "The maximum number of concurrent full and level compactions that can run at one time.  A value of 0 results in 50% of runtime.GOMAXPROCS(0) used at runtime.  Any number greater than 0 limits compactions to that value.  This setting does not apply to cache snapshotting.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.MaxSeriesPerBucket,
+			Flag:  "storage-max-series-per-bucket",
+			Desc:  "The maximum number of series a bucket can have, unless the bucket sets its own limit. Writes that would create more series are rejected. A value of 0 disables the limit.",
+		},
+		{
+			DestP: &o.StorageConfig.Data.MaxSeriesPerMeasurement,
+			Flag:  "storage-max-series-per-measurement",
+			Desc:  "The maximum number of series a measurement can have within a shard, unless its bucket or organization sets its own limit. A value of 0 disables the limit.",
+		},
+		{
+			DestP: &o.StorageConfig.Data.MaxValuesPerTag,
+			Flag:  "storage-max-values-per-tag",
+			Desc:  "The maximum number of values a tag key of a measurement can have within a shard, unless its bucket or organization sets its own limit. A value of 0 disables the limit.",
+		},
+		{
+			DestP: &o.StorageConfig.Data.MaxSeriesPerOrg,
+			Flag:  "storage-max-series-per-org",
+			Desc:  "The maximum number of series all buckets of an organization can have together, unless the organization sets its own limit. A value of 0 disables the limit.",
+		},
 		{
 			DestP: &o.StorageConfig.Data.MaxIndexLogFileSize,
 			Flag:  "storage-max-index-log-file-size",
@@ -563,6 +665,11 @@ func (o *InfluxdOpts) BindCliOpts() []cli.Opt {
 			Flag:  "storage-series-file-max-concurrent-snapshot-compactions",
 			Desc:  "The maximum number of concurrent snapshot compactions that can be running at one time across all series partitions in a database.",
 		},
+		{
+			DestP: &o.StorageConfig.Data.SeriesGCInterval,
+			Flag:  "storage-series-gc-interval",
+			Desc:  "The interval between passes of the series garbage collector, which removes series that no longer have data in any shard from the index and series file. A value of 0 disables it.",
+		},
 		{
 			DestP: &o.StorageConfig.Data.TSMWillNeed,
 			Flag:  "storage-tsm-use-madv-willneed",
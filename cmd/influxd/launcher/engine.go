@@ -23,17 +23,25 @@ var _ Engine = (*storage.Engine)(nil)
 // to facilitate testing.
 type Engine interface {
 	influxdb.DeleteService
+	influxdb.TombstonePurger
 	storage.PointsWriter
 	storage.EngineSchema
+	storage.EngineCardinality
 	prom.PrometheusCollector
 	influxdb.BackupService
 	influxdb.RestoreService
+	influxdb.CompactionService
+	influxdb.CardinalityService
+	influxdb.TagSearchService
 
 	SeriesCardinality(ctx context.Context, bucketID platform.ID) int64
+	BucketDiskSize(ctx context.Context, bucketID platform.ID) (int64, error)
 
 	TSDBStore() storage.TSDBStore
 	MetaClient() storage.MetaClient
 
+	SetLatePointsWriter(w storage.PointsWriter)
+
 	WithLogger(log *zap.Logger)
 	Open(context.Context) error
 	Close() error
@@ -52,8 +60,9 @@ type TemporaryEngine struct {
 	mu     sync.Mutex
 	opened bool
 
-	engine    *storage.Engine
-	tsdbStore temporaryTSDBStore
+	engine     *storage.Engine
+	tsdbStore  temporaryTSDBStore
+	latePoints storage.PointsWriter
 
 	log *zap.Logger
 }
@@ -85,6 +94,9 @@ func (t *TemporaryEngine) Open(ctx context.Context) error {
 	t.path = path
 	t.engine = storage.NewEngine(path, t.config, t.options...)
 	t.engine.WithLogger(t.log)
+	if t.latePoints != nil {
+		t.engine.SetLatePointsWriter(t.latePoints)
+	}
 
 	if err := t.engine.Open(ctx); err != nil {
 		_ = os.RemoveAll(path)
@@ -123,6 +135,30 @@ func (t *TemporaryEngine) DeleteBucketRangePredicate(ctx context.Context, orgID,
 	return t.engine.DeleteBucketRangePredicate(ctx, orgID, bucketID, min, max, pred)
 }
 
+// PurgeTombstones rewrites the bucket's TSM files that hold deleted data.
+func (t *TemporaryEngine) PurgeTombstones(ctx context.Context, bucketID platform.ID) error {
+	return t.engine.PurgeTombstones(ctx, bucketID)
+}
+
+func (t *TemporaryEngine) SetBucketOrganization(bucketID, orgID platform.ID) {
+	t.engine.SetBucketOrganization(bucketID, orgID)
+}
+
+func (t *TemporaryEngine) SetOrganizationCardinalityLimits(orgID platform.ID, limits *influxdb.CardinalityLimits) {
+	t.engine.SetOrganizationCardinalityLimits(orgID, limits)
+}
+
+// SetLatePointsWriter sets the writer of the points redirected to late points
+// buckets, which is kept when the engine is flushed.
+func (t *TemporaryEngine) SetLatePointsWriter(w storage.PointsWriter) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.latePoints = w
+	if t.engine != nil {
+		t.engine.SetLatePointsWriter(w)
+	}
+}
+
 func (t *TemporaryEngine) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
 	return t.engine.CreateBucket(ctx, b)
 }
@@ -186,6 +222,26 @@ func (t *TemporaryEngine) RestoreShard(ctx context.Context, shardID uint64, r io
 	return t.engine.RestoreShard(ctx, shardID, r)
 }
 
+func (t *TemporaryEngine) CompactionStatus(ctx context.Context, bucketID platform.ID, shardID uint64) ([]*influxdb.ShardCompactionStatus, error) {
+	return t.engine.CompactionStatus(ctx, bucketID, shardID)
+}
+
+func (t *TemporaryEngine) Compact(ctx context.Context, req influxdb.CompactionRequest, progress func(influxdb.CompactionProgress)) error {
+	return t.engine.Compact(ctx, req, progress)
+}
+
+func (t *TemporaryEngine) BucketCardinality(ctx context.Context, orgID, bucketID platform.ID, opts influxdb.CardinalityOptions) (*influxdb.BucketCardinality, error) {
+	return t.engine.BucketCardinality(ctx, orgID, bucketID, opts)
+}
+
+func (t *TemporaryEngine) SearchTagValues(ctx context.Context, orgID, bucketID platform.ID, key string, opts influxdb.TagSearchOptions) (*influxdb.TagSearchResult, error) {
+	return t.engine.SearchTagValues(ctx, orgID, bucketID, key, opts)
+}
+
+func (t *TemporaryEngine) BucketDiskSize(ctx context.Context, bucketID platform.ID) (int64, error) {
+	return t.engine.BucketDiskSize(ctx, bucketID)
+}
+
 func (t *TemporaryEngine) TSDBStore() storage.TSDBStore {
 	return &t.tsdbStore
 }
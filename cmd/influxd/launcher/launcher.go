@@ -27,10 +27,13 @@ import (
 	"github.com/influxdata/influxdb/v2/dashboards"
 	dashboardTransport "github.com/influxdata/influxdb/v2/dashboards/transport"
 	"github.com/influxdata/influxdb/v2/dbrp"
+	"github.com/influxdata/influxdb/v2/deadletter"
 	"github.com/influxdata/influxdb/v2/gather"
 	"github.com/influxdata/influxdb/v2/http"
+	"github.com/influxdata/influxdb/v2/idempotency"
 	iqlcontrol "github.com/influxdata/influxdb/v2/influxql/control"
 	iqlquery "github.com/influxdata/influxdb/v2/influxql/query"
+	"github.com/influxdata/influxdb/v2/ingest"
 	"github.com/influxdata/influxdb/v2/inmem"
 	"github.com/influxdata/influxdb/v2/internal/resource"
 	"github.com/influxdata/influxdb/v2/kit/feature"
@@ -44,6 +47,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kv/migration"
 	"github.com/influxdata/influxdb/v2/kv/migration/all"
 	"github.com/influxdata/influxdb/v2/label"
+	"github.com/influxdata/influxdb/v2/listener"
 	"github.com/influxdata/influxdb/v2/notebooks"
 	notebookTransport "github.com/influxdata/influxdb/v2/notebooks/transport"
 	endpointservice "github.com/influxdata/influxdb/v2/notification/endpoint/service"
@@ -54,10 +58,12 @@ import (
 	"github.com/influxdata/influxdb/v2/query/control"
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/query/stdlib/influxdata/influxdb"
+	"github.com/influxdata/influxdb/v2/quota"
 	"github.com/influxdata/influxdb/v2/remotes"
 	remotesTransport "github.com/influxdata/influxdb/v2/remotes/transport"
 	"github.com/influxdata/influxdb/v2/replications"
 	replicationTransport "github.com/influxdata/influxdb/v2/replications/transport"
+	"github.com/influxdata/influxdb/v2/schema"
 	"github.com/influxdata/influxdb/v2/secret"
 	"github.com/influxdata/influxdb/v2/session"
 	"github.com/influxdata/influxdb/v2/snowflake"
@@ -76,6 +82,8 @@ import (
 	telegrafservice "github.com/influxdata/influxdb/v2/telegraf/service"
 	"github.com/influxdata/influxdb/v2/telemetry"
 	"github.com/influxdata/influxdb/v2/tenant"
+	"github.com/influxdata/influxdb/v2/transform"
+	"github.com/influxdata/influxdb/v2/validation"
 
 	// needed for tsm1
 	_ "github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
@@ -87,11 +95,14 @@ import (
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	storage2 "github.com/influxdata/influxdb/v2/v1/services/storage"
 	"github.com/influxdata/influxdb/v2/vault"
+	"github.com/influxdata/influxdb/v2/writequeue"
 	pzap "github.com/influxdata/influxdb/v2/zap"
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	jaegerconfig "github.com/uber/jaeger-client-go/config"
 	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
 const (
@@ -355,11 +366,23 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	// The Engine's metrics must be registered after it opens.
 	m.reg.MustRegister(m.engine.PrometheusCollectors()...)
 
+	// Organizations' cardinality limits are applied by the engine, so it must
+	// be told of them and of the organization owning each bucket.
+	orgSvc := storage.NewOrganizationService(m.log, ts.OrganizationService, m.engine)
+	if err := orgSvc.LoadCardinalityLimits(ctx, ts.BucketService); err != nil {
+		m.log.Error("Failed to load cardinality limits", zap.Error(err))
+		return err
+	}
+	ts.OrganizationService = orgSvc
+
 	var (
-		deleteService  platform.DeleteService  = m.engine
-		pointsWriter   storage.PointsWriter    = m.engine
-		backupService  platform.BackupService  = m.engine
-		restoreService platform.RestoreService = m.engine
+		deleteService      platform.DeleteService      = m.engine
+		pointsWriter       storage.PointsWriter        = m.engine
+		backupService      platform.BackupService      = m.engine
+		restoreService     platform.RestoreService     = m.engine
+		compactionService  platform.CompactionService  = m.engine
+		cardinalityService platform.CardinalityService = m.engine
+		tagSearchService   platform.TagSearchService   = m.engine
 	)
 
 	remotesSvc := remotes.NewService(m.sqlStore)
@@ -388,6 +411,62 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 
 	pointsWriter = replicationSvc
 
+	// Points written to buckets with an explicit schema are validated before
+	// they are replicated or stored.
+	schemaSvc := schema.NewService(m.sqlStore, ts.BucketService)
+	pointsWriter = schema.NewPointsWriter(pointsWriter, schemaSvc)
+	ts.BucketService = schema.NewBucketService(
+		m.log.With(zap.String("service", "measurement_schema_buckets")), ts.BucketService, schemaSvc)
+
+	// The points set aside from the writes to a bucket, its dead letters and
+	// the points quarantined by its validation, are written to their side
+	// bucket below the validations and transforms, so that they are not
+	// checked or transformed again.
+	deadLetterSvc := deadletter.NewService(m.log.With(zap.String("service", "dead_letters")), ts.BucketService, pointsWriter)
+	m.reg.MustRegister(deadLetterSvc.PrometheusCollectors()...)
+
+	// Points are checked against the validation of their bucket after its
+	// transform.
+	validationSvc := validation.NewService(m.sqlStore, ts.BucketService)
+	validationPointsWriter := validation.NewPointsWriter(pointsWriter, validationSvc, deadLetterSvc)
+	m.reg.MustRegister(validationPointsWriter.PrometheusCollectors()...)
+	pointsWriter = validationPointsWriter
+	ts.BucketService = validation.NewBucketService(
+		m.log.With(zap.String("service", "bucket_validation_buckets")), ts.BucketService, validationSvc)
+
+	// The transform of a bucket is applied to the points written to it before
+	// they are validated against its rules and its schema.
+	transformSvc := transform.NewService(m.sqlStore, ts.BucketService)
+	transformPointsWriter := transform.NewPointsWriter(pointsWriter, transformSvc)
+	m.reg.MustRegister(transformPointsWriter.PrometheusCollectors()...)
+	pointsWriter = transformPointsWriter
+	ts.BucketService = transform.NewBucketService(
+		m.log.With(zap.String("service", "bucket_transform_buckets")), ts.BucketService, transformSvc)
+
+	// The points dropped from the writes to a bucket, by its transform or
+	// below, are recorded in its dead-letter bucket, if it has one.
+	pointsWriter = deadletter.NewPointsWriter(pointsWriter, deadLetterSvc)
+
+	// The late points a bucket redirects are written to their bucket through
+	// its transform, validation and schema, as if written to it.
+	m.engine.SetLatePointsWriter(pointsWriter)
+
+	ingestMappingSvc := ingest.NewService(m.sqlStore)
+
+	// Writes retried with the same idempotency key are answered with the
+	// result of the first rather than written again.
+	idempotencySvc := idempotency.NewService(m.log.With(zap.String("service", "write_idempotency")), m.sqlStore, opts.WriteIdempotencyWindow)
+	if err := idempotencySvc.Open(ctx); err != nil {
+		m.log.Error("Failed to open write idempotency service", zap.Error(err))
+		return err
+	}
+	m.closers = append(m.closers, labeledCloser{
+		label: "write_idempotency",
+		closer: func(context.Context) error {
+			return idempotencySvc.Close()
+		},
+	})
+
 	// When --hardening-enabled, use an HTTP IP validator that restricts
 	// flux and pkger HTTP requests to private addressess.
 	var urlValidator url.Validator
@@ -614,6 +693,50 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	ts.BucketService = storage.NewBucketService(m.log, ts.BucketService, m.engine)
 	ts.BucketService = dbrp.NewBucketService(m.log, ts.BucketService, dbrpSvc)
 
+	// Organizations' quotas are enforced by the quota service, which tracks
+	// their usage, so it must be told of them.
+	quotaSvc := quota.NewService(m.log.With(zap.String("service", "quota")), ts.BucketService, taskSvc, m.engine, opts.QuotaStorageRefreshInterval)
+	quotaOrgSvc := quota.NewOrganizationService(ts.OrganizationService, quotaSvc)
+	if err := quotaOrgSvc.LoadQuotas(ctx); err != nil {
+		m.log.Error("Failed to load quotas", zap.Error(err))
+		return err
+	}
+	if err := quotaSvc.Open(ctx); err != nil {
+		m.log.Error("Failed to open quota service", zap.Error(err))
+		return err
+	}
+	m.closers = append(m.closers, labeledCloser{
+		label: "quota",
+		closer: func(context.Context) error {
+			return quotaSvc.Close()
+		},
+	})
+	m.reg.MustRegister(quotaSvc.PrometheusCollectors()...)
+
+	ts.OrganizationService = quotaOrgSvc
+	ts.BucketService = quota.NewBucketService(ts.BucketService, quotaSvc)
+	taskSvc = quota.NewTaskService(taskSvc, quotaSvc)
+
+	if opts.ListenersConfigPath != "" {
+		listenerConfigs, err := listener.LoadConfigs(opts.ListenersConfigPath)
+		if err != nil {
+			m.log.Error("Failed to load listeners config", zap.Error(err))
+			return err
+		}
+		listenerSvc := listener.NewService(m.log.With(zap.String("service", "listener")), listenerConfigs.Listeners, authSvc, ts.BucketService, pointsWriter, quotaSvc)
+		if err := listenerSvc.Open(ctx); err != nil {
+			m.log.Error("Failed to open listeners", zap.Error(err))
+			return err
+		}
+		m.closers = append(m.closers, labeledCloser{
+			label: "listener",
+			closer: func(context.Context) error {
+				return listenerSvc.Close()
+			},
+		})
+		m.reg.MustRegister(listenerSvc.PrometheusCollectors()...)
+	}
+
 	bucketManifestWriter := backup.NewBucketManifestWriter(ts, metaClient)
 
 	onboardingLogger := m.log.With(zap.String("handler", "onboard"))
@@ -671,25 +794,55 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		NotificationRuleFinder:     notificationRuleSvc,
 	}
 
+	apiPointsWriter := &storage.LoggingPointsWriter{
+		Underlying:    pointsWriter,
+		BucketFinder:  ts.BucketService,
+		LogBucketName: platform.MonitoringSystemBucketName,
+	}
+
+	// Writes preferring an asynchronous response are queued and written by
+	// the write queue.
+	var writeQueue platform.WriteQueue
+	if opts.WriteQueueEnabled {
+		writeQueueSvc := writequeue.NewService(m.log.With(zap.String("service", "write_queue")),
+			filepath.Join(opts.EnginePath, "writeq"), opts.WriteQueueMaxSize, opts.WriteQueueStatusRetention, apiPointsWriter)
+		if err := writeQueueSvc.Open(ctx); err != nil {
+			m.log.Error("Failed to open write queue", zap.Error(err))
+			return err
+		}
+		m.closers = append(m.closers, labeledCloser{
+			label: "write_queue",
+			closer: func(context.Context) error {
+				return writeQueueSvc.Close()
+			},
+		})
+		m.reg.MustRegister(writeQueueSvc.PrometheusCollectors()...)
+		writeQueue = writeQueueSvc
+	}
+
 	errorHandler := kithttp.NewErrorHandler(m.log.With(zap.String("handler", "error_logger")))
 	m.apibackend = &http.APIBackend{
-		AssetsPath:           opts.AssetsPath,
-		UIDisabled:           opts.UIDisabled,
-		HTTPErrorHandler:     errorHandler,
-		Logger:               m.log,
-		FluxLogEnabled:       opts.FluxLogEnabled,
-		SessionRenewDisabled: opts.SessionRenewDisabled,
-		NewQueryService:      source.NewQueryService,
-		PointsWriter: &storage.LoggingPointsWriter{
-			Underlying:    pointsWriter,
-			BucketFinder:  ts.BucketService,
-			LogBucketName: platform.MonitoringSystemBucketName,
-		},
+		AssetsPath:              opts.AssetsPath,
+		UIDisabled:              opts.UIDisabled,
+		HTTPErrorHandler:        errorHandler,
+		Logger:                  m.log,
+		FluxLogEnabled:          opts.FluxLogEnabled,
+		SessionRenewDisabled:    opts.SessionRenewDisabled,
+		NewQueryService:         source.NewQueryService,
+		PointsWriter:            apiPointsWriter,
+		ReadsStore:              storage2.NewStore(m.engine.TSDBStore(), m.engine.MetaClient()),
+		WriteLimiter:            quotaSvc,
+		IngestMappingService:    ingestMappingSvc,
+		WriteIdempotencyService: idempotencySvc,
+		WriteQueue:              writeQueue,
+		DeadLetterWriter:        deadLetterSvc,
 		DeleteService:           deleteService,
+		TombstonePurger:         m.engine,
 		BackupService:           backupService,
 		SqlBackupRestoreService: m.sqlStore,
 		BucketManifestWriter:    bucketManifestWriter,
 		RestoreService:          restoreService,
+		CompactionService:       compactionService,
 		AuthorizationService:    authSvc,
 		AuthorizationV1Service:  authSvcV1,
 		PasswordV1Service:       passwordV1,
@@ -717,8 +870,8 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		SourceService:                   sourceSvc,
 		VariableService:                 variableSvc,
 		PasswordsService:                ts.PasswordsService,
-		InfluxqldService:                iqlquery.NewProxyExecutor(m.log, qe),
-		FluxService:                     storageQueryService,
+		InfluxqldService:                quota.NewInfluxQLQueryService(iqlquery.NewProxyExecutor(m.log, qe), quotaSvc),
+		FluxService:                     quota.NewFluxQueryService(storageQueryService, quotaSvc),
 		FluxLanguageService:             fluxlang.DefaultService,
 		TaskService:                     taskSvc,
 		TelegrafService:                 telegrafSvc,
@@ -751,6 +904,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 			pkger.WithLogger(pkgerLogger),
 			pkger.WithStore(pkger.NewStoreKV(m.kvStore)),
 			pkger.WithBucketSVC(authorizer.NewBucketService(b.BucketService)),
+			pkger.WithBucketTransformSVC(authorizer.NewBucketTransformService(transformSvc)),
 			pkger.WithCheckSVC(authorizer.NewCheckService(b.CheckService, authedUrmSVC, authedOrgSVC)),
 			pkger.WithDashboardSVC(authorizer.NewDashboardService(b.DashboardService)),
 			pkger.WithLabelSVC(label.NewAuthedLabelService(labelSvc, b.OrgLookupService)),
@@ -824,9 +978,22 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		sessionHTTPServer = session.NewSessionHandler(m.log.With(zap.String("handler", "session")), sessionSvc, ts.UserService, ts.PasswordsService)
 	}
 
-	orgHTTPServer := ts.NewOrgHTTPHandler(m.log, secret.NewAuthedService(secretSvc))
-
-	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc)
+	orgUsageHTTPServer := http.NewOrgUsageHandler(m.log.With(zap.String("handler", "org_usage")), authorizer.NewOrgUsageService(quotaSvc))
+	orgHTTPServer := ts.NewOrgHTTPHandler(m.log, secret.NewAuthedService(secretSvc), orgUsageHTTPServer)
+
+	cardinalityHTTPServer := http.NewCardinalityHandler(m.log.With(zap.String("handler", "cardinality")), authorizer.NewCardinalityService(cardinalityService))
+	tagSearchHTTPServer := http.NewTagSearchHandler(m.log.With(zap.String("handler", "tag_search")), authorizer.NewTagSearchService(tagSearchService))
+	schemaHTTPServer := http.NewMeasurementSchemaHandler(m.log.With(zap.String("handler", "measurement_schema")), authorizer.NewMeasurementSchemaService(schemaSvc))
+	ingestMappingHTTPServer := http.NewIngestMappingHandler(m.log.With(zap.String("handler", "ingest_mapping")), authorizer.NewIngestMappingService(ingestMappingSvc))
+	transformHTTPServer := http.NewBucketTransformHandler(m.log.With(zap.String("handler", "bucket_transform")), authorizer.NewBucketTransformService(transformSvc))
+	validationHTTPServer := http.NewBucketValidationHandler(m.log.With(zap.String("handler", "bucket_validation")), authorizer.NewBucketValidationService(validationSvc))
+	bucketHTTPServer := ts.NewBucketHTTPHandler(m.log, labelSvc,
+		tenant.WithBucketResource("/cardinality", cardinalityHTTPServer),
+		tenant.WithBucketResource("/tags", tagSearchHTTPServer),
+		tenant.WithBucketResource("/schema/measurements", schemaHTTPServer),
+		tenant.WithBucketResource("/transform", transformHTTPServer),
+		tenant.WithBucketResource("/validation", validationHTTPServer),
+	)
 
 	var dashboardServer *dashboardTransport.DashboardHandler
 	{
@@ -895,6 +1062,7 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 		http.WithResourceHandler(userHTTPServer.UserResourceHandler()),
 		http.WithResourceHandler(orgHTTPServer),
 		http.WithResourceHandler(bucketHTTPServer),
+		http.WithResourceHandler(ingestMappingHTTPServer),
 		http.WithResourceHandler(v1AuthHTTPServer),
 		http.WithResourceHandler(dashboardServer),
 		http.WithResourceHandler(notebookServer),
@@ -927,6 +1095,18 @@ func (m *Launcher) run(ctx context.Context, opts *InfluxdOpts) (err error) {
 	if err := m.runHTTP(opts, httpHandler, httpLogger); err != nil {
 		return err
 	}
+	if opts.StorageGRPCBindAddress != "" {
+		readServer := readservice.NewServer(
+			m.log.With(zap.String("service", "storage-grpc")),
+			m.apibackend.ReadsStore,
+			authSvc,
+			ts.UserService,
+			ts.BucketService,
+		)
+		if err := m.runStorageGRPC(opts, readServer); err != nil {
+			return err
+		}
+	}
 
 	return nil
 }
@@ -1177,6 +1357,50 @@ func (m *Launcher) runHTTP(opts *InfluxdOpts, handler nethttp.Handler, httpLogge
 	return nil
 }
 
+// runStorageGRPC launches a listener for the gRPC storage read API in a
+// separate goroutine, using the TLS certificate and key of the HTTP API if
+// TLS is enabled. If it fails to serve, it will cancel the launcher.
+func (m *Launcher) runStorageGRPC(opts *InfluxdOpts, readServer *readservice.Server) error {
+	log := m.log.With(zap.String("service", "storage-grpc-listener"))
+
+	var serverOpts []grpc.ServerOption
+	if m.tlsEnabled {
+		creds, err := credentials.NewServerTLSFromFile(opts.HttpTLSCert, opts.HttpTLSKey)
+		if err != nil {
+			log.Error("Failed to load x509 key pair", zap.String("cert-path", opts.HttpTLSCert), zap.String("key-path", opts.HttpTLSKey))
+			return err
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+	grpcServer := readServer.NewGRPCServer(serverOpts...)
+	m.closers = append(m.closers, labeledCloser{
+		label: "storage gRPC server",
+		closer: func(context.Context) error {
+			grpcServer.GracefulStop()
+			return nil
+		},
+	})
+
+	ln, err := net.Listen("tcp", opts.StorageGRPCBindAddress)
+	if err != nil {
+		log.Error("Failed to set up TCP listener", zap.String("addr", opts.StorageGRPCBindAddress), zap.Error(err))
+		return err
+	}
+	m.wg.Add(1)
+	go func(log *zap.Logger) {
+		defer m.wg.Done()
+		log.Info("Listening", zap.String("transport", "grpc"), zap.String("addr", ln.Addr().String()), zap.Bool("tls", m.tlsEnabled))
+
+		if err := grpcServer.Serve(ln); err != nil {
+			log.Error("Failed to serve gRPC", zap.Error(err))
+			m.cancel()
+		}
+		log.Info("Stopping")
+	}(log)
+
+	return nil
+}
+
 // runReporter configures and launches a periodic telemetry report for the server.
 func (m *Launcher) runReporter(ctx context.Context) {
 	reporter := telemetry.NewReporter(m.log, m.reg)
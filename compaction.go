@@ -0,0 +1,108 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// CompactionMode is the kind of compaction to run on demand.
+type CompactionMode string
+
+const (
+	// CompactionModeFull rewrites all TSM files of a shard into as few,
+	// fully compressed files as possible.
+	CompactionModeFull CompactionMode = "full"
+	// CompactionModeOptimize rewrites all TSM files of a shard without
+	// recompressing blocks. It is faster than a full compaction but produces
+	// larger files.
+	CompactionModeOptimize CompactionMode = "optimize"
+)
+
+// Valid returns an error if m is not a known compaction mode.
+func (m CompactionMode) Valid() error {
+	switch m {
+	case CompactionModeFull, CompactionModeOptimize:
+		return nil
+	}
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Msg:  fmt.Sprintf("invalid compaction mode %q; must be %q or %q", string(m), CompactionModeFull, CompactionModeOptimize),
+	}
+}
+
+// ShardCompactionStatus describes the TSM file layout of a shard and the
+// compactions the engine's planner most recently scheduled for it.
+type ShardCompactionStatus struct {
+	ShardID  uint64      `json:"shardID"`
+	BucketID platform.ID `json:"bucketID"`
+
+	// Generations lists the shard's TSM generations, newest first.
+	Generations []CompactionGeneration `json:"generations"`
+
+	// Planned lists the groups of files the planner selected for compaction
+	// the last time it ran.
+	Planned []CompactionGroup `json:"planned"`
+
+	FullyCompacted bool   `json:"fullyCompacted"`
+	Reason         string `json:"reason,omitempty"` // Why the shard is not fully compacted
+}
+
+// CompactionGeneration describes the TSM files sharing a generation.
+type CompactionGeneration struct {
+	ID            int      `json:"id"`
+	Level         int      `json:"level"`
+	Files         []string `json:"files"`
+	Size          int64    `json:"size"`
+	HasTombstones bool     `json:"hasTombstones"`
+}
+
+// CompactionGroup is a set of TSM files planned to be compacted together.
+// Level is "1", "2" or "3" for level compactions, or "full", "opt" or
+// "tombstone".
+type CompactionGroup struct {
+	Level string   `json:"level"`
+	Files []string `json:"files"`
+}
+
+// CompactionRequest selects the shards to compact on demand. Either a
+// bucket, to compact each of its shards in turn, or a single shard must be
+// given.
+type CompactionRequest struct {
+	BucketID platform.ID    `json:"bucketID,omitempty"`
+	ShardID  uint64         `json:"shardID,omitempty"`
+	Mode     CompactionMode `json:"mode"`
+}
+
+// Valid returns an error if the request is incomplete.
+func (r CompactionRequest) Valid() error {
+	if !r.BucketID.Valid() && r.ShardID == 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "a bucket or shard to compact must be provided",
+		}
+	}
+	return r.Mode.Valid()
+}
+
+// CompactionProgress reports the progress of an on-demand compaction after
+// each shard is compacted.
+type CompactionProgress struct {
+	ShardID     uint64 `json:"shardID"`
+	ShardsDone  int    `json:"shardsDone"`
+	ShardsTotal int    `json:"shardsTotal"`
+	Error       string `json:"error,omitempty"`
+}
+
+// CompactionService inspects and triggers TSM compactions.
+type CompactionService interface {
+	// CompactionStatus returns the compaction status of the shards of a
+	// bucket, or of a single shard if shardID is non-zero.
+	CompactionStatus(ctx context.Context, bucketID platform.ID, shardID uint64) ([]*ShardCompactionStatus, error)
+
+	// Compact compacts the requested shards one at a time, calling progress
+	// after each shard. It returns once all shards are compacted.
+	Compact(ctx context.Context, req CompactionRequest, progress func(CompactionProgress)) error
+}
@@ -0,0 +1,56 @@
+package influxdb
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// The rejected lines of the writes to a bucket with a DeadLetterBucketID are
+// recorded in it as points of DeadLetterMeasurement, stamped with the time
+// they were rejected at. The tags of a point identify the bucket written to,
+// why the line was rejected and, when the write was authorized, by whom; its
+// fields hold the line and the reason it was rejected for.
+const (
+	DeadLetterMeasurement = "rejected_writes"
+
+	DeadLetterBucketTag = "bucket_id"
+	DeadLetterCodeTag   = "code"
+	DeadLetterAuthTag   = "auth_id"
+	DeadLetterUserTag   = "user_id"
+
+	DeadLetterLineField   = "line"
+	DeadLetterReasonField = "reason"
+)
+
+// DeadLetter is a line of a write which was rejected.
+type DeadLetter struct {
+	// Line is the line as written, or the line protocol of the point it was
+	// parsed to if the line itself is no longer known.
+	Line string
+	// Code classifies why the line was rejected, such as a tsdb.DropCode.
+	Code   string
+	Reason string
+}
+
+// DeadLetterWriter records the rejected lines of the writes to a bucket in its
+// dead-letter bucket.
+type DeadLetterWriter interface {
+	// WriteDeadLetters records the lines rejected from a write to a bucket
+	// of an organization. The lines are dropped if the bucket has no
+	// dead-letter bucket. The authorizer of ctx, if any, is recorded as the
+	// source of the lines.
+	WriteDeadLetters(ctx context.Context, orgID, bucketID platform.ID, letters []DeadLetter)
+}
+
+// SideBucketWriter writes the points set aside from the writes to a bucket,
+// such as its dead letters and the points quarantined by its validation, to
+// another bucket of its organization. The points are written as they are,
+// without the transform, validation or schema of either bucket.
+type SideBucketWriter interface {
+	// WriteSidePoints writes the points set aside from a write to bucketID
+	// to the bucket sideID. The points which cannot be written are reported
+	// by the error returned, as by a storage.PointsWriter.
+	WriteSidePoints(ctx context.Context, orgID, bucketID, sideID platform.ID, points []models.Point) error
+}
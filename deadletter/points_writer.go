@@ -0,0 +1,46 @@
+package deadletter
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// PointsWriter wraps a storage.PointsWriter and records the points it drops
+// with a DeadLetterWriter, as the line protocol of the dropped points. The
+// error of the write is returned as it is, so that the writer still learns
+// of the dropped points.
+type PointsWriter struct {
+	underlying  storage.PointsWriter
+	deadLetters influxdb.DeadLetterWriter
+}
+
+// NewPointsWriter returns a new PointsWriter recording the points dropped by w
+// with d.
+func NewPointsWriter(w storage.PointsWriter, d influxdb.DeadLetterWriter) *PointsWriter {
+	return &PointsWriter{
+		underlying:  w,
+		deadLetters: d,
+	}
+}
+
+// WritePoints writes the points to the underlying PointsWriter.
+func (w *PointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	err := w.underlying.WritePoints(ctx, orgID, bucketID, points)
+	if perr, ok := err.(tsdb.PartialWriteError); ok && len(perr.Points) > 0 {
+		letters := make([]influxdb.DeadLetter, 0, len(perr.Points))
+		for _, d := range perr.Points {
+			letters = append(letters, influxdb.DeadLetter{
+				Line:   d.Point.String(),
+				Code:   string(d.Code),
+				Reason: d.Reason,
+			})
+		}
+		w.deadLetters.WriteDeadLetters(ctx, orgID, bucketID, letters)
+	}
+	return err
+}
@@ -0,0 +1,179 @@
+// Package deadletter records the lines rejected from the writes to a bucket in
+// the dead-letter bucket of the bucket, so that they can be queried instead of
+// being lost.
+package deadletter
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+var (
+	_ influxdb.DeadLetterWriter = (*Service)(nil)
+	_ influxdb.SideBucketWriter = (*Service)(nil)
+)
+
+// Service is an influxdb.DeadLetterWriter writing the rejected lines to the
+// dead-letter buckets with a storage.PointsWriter. Lines which cannot be
+// recorded are logged and counted, but do not fail the write they were
+// rejected from.
+//
+// Service is also the influxdb.SideBucketWriter of the other points set aside
+// from writes, so that all of them are written, logged and counted alike.
+type Service struct {
+	log     *zap.Logger
+	buckets influxdb.BucketService
+	writer  storage.PointsWriter
+	now     func() time.Time
+
+	// last is the time of the last rejected line. Every line is recorded at
+	// a distinct time, so that the lines rejected together are not recorded
+	// as the same point.
+	mu   sync.Mutex
+	last int64
+
+	lines  *prometheus.CounterVec
+	errors prometheus.Counter
+}
+
+// NewService returns a new Service. buckets is used to find the dead-letter
+// buckets, and w to write to them and the other side buckets. w should write
+// below the transforms and validations of the buckets.
+func NewService(log *zap.Logger, buckets influxdb.BucketService, w storage.PointsWriter) *Service {
+	return &Service{
+		log:     log,
+		buckets: buckets,
+		writer:  w,
+		now:     time.Now,
+		lines: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "dead_letter",
+			Name:      "lines_total",
+			Help:      "Number of rejected lines recorded in the dead-letter bucket of the bucket written to",
+		}, []string{"bucket", "code"}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "dead_letter",
+			Name:      "errors_total",
+			Help:      "Number of rejected lines or other points set aside which could not be written to a side bucket",
+		}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the rejected lines recorded.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{s.lines, s.errors}
+}
+
+// WriteDeadLetters records the lines rejected from a write to the bucket in
+// its dead-letter bucket, if it has one.
+func (s *Service) WriteDeadLetters(ctx context.Context, orgID, bucketID platform.ID, letters []influxdb.DeadLetter) {
+	if len(letters) == 0 {
+		return
+	}
+
+	log := s.log.With(zap.String("bucket_id", bucketID.String()))
+	b, err := s.buckets.FindBucketByID(ctx, bucketID)
+	if err != nil {
+		log.Error("Failed to find bucket of rejected lines", zap.Error(err))
+		s.errors.Add(float64(len(letters)))
+		return
+	}
+	if !b.DeadLetterBucketID.Valid() {
+		return
+	}
+
+	tags := map[string]string{
+		influxdb.DeadLetterBucketTag: bucketID.String(),
+	}
+	if auth, err := icontext.GetAuthorizer(ctx); err == nil {
+		if id := auth.Identifier(); id.Valid() {
+			tags[influxdb.DeadLetterAuthTag] = id.String()
+		}
+		if id := auth.GetUserID(); id.Valid() {
+			tags[influxdb.DeadLetterUserTag] = id.String()
+		}
+	}
+
+	var (
+		points = make([]models.Point, 0, len(letters))
+		codes  = make([]string, 0, len(letters))
+		times  = s.times(len(letters))
+	)
+	for i, l := range letters {
+		// A line longer than a string field can hold is truncated.
+		line := l.Line
+		if len(line) > tsdb.MaxFieldValueLength {
+			line = line[:tsdb.MaxFieldValueLength]
+		}
+		tags[influxdb.DeadLetterCodeTag] = l.Code
+		pt, err := models.NewPoint(influxdb.DeadLetterMeasurement,
+			models.NewTags(tags),
+			models.Fields{
+				influxdb.DeadLetterLineField:   line,
+				influxdb.DeadLetterReasonField: l.Reason,
+			},
+			time.Unix(0, times+int64(i)))
+		if err != nil {
+			log.Error("Failed to record rejected line", zap.Error(err))
+			s.errors.Inc()
+			continue
+		}
+		points = append(points, pt)
+		codes = append(codes, l.Code)
+	}
+	if len(points) == 0 {
+		return
+	}
+
+	// The lines of a partial write are not counted as recorded, as the points
+	// dropped from it are not matched to their lines.
+	if err := s.WriteSidePoints(ctx, orgID, bucketID, b.DeadLetterBucketID, points); err != nil {
+		return
+	}
+
+	bucket := bucketID.String()
+	for _, code := range codes {
+		s.lines.WithLabelValues(bucket, code).Inc()
+	}
+}
+
+// WriteSidePoints writes the points set aside from a write to a bucket to the
+// bucket sideID. The points which cannot be written are logged and counted
+// before the error is returned.
+func (s *Service) WriteSidePoints(ctx context.Context, orgID, bucketID, sideID platform.ID, points []models.Point) error {
+	err := s.writer.WritePoints(ctx, orgID, sideID, points)
+	if perr, ok := err.(tsdb.PartialWriteError); ok {
+		s.log.Error("Failed to write some points to side bucket",
+			zap.String("bucket_id", bucketID.String()), zap.String("side_bucket_id", sideID.String()), zap.Error(perr))
+		s.errors.Add(float64(perr.Dropped))
+	} else if err != nil {
+		s.log.Error("Failed to write points to side bucket",
+			zap.String("bucket_id", bucketID.String()), zap.String("side_bucket_id", sideID.String()), zap.Error(err))
+		s.errors.Add(float64(len(points)))
+	}
+	return err
+}
+
+// times reserves n distinct times for rejected lines, returning the first.
+func (s *Service) times(n int) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := s.now().UnixNano()
+	if t <= s.last {
+		t = s.last + 1
+	}
+	s.last = t + int64(n) - 1
+	return t
+}
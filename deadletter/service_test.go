@@ -0,0 +1,142 @@
+package deadletter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx = context.Background()
+
+	orgID        = platform.ID(10)
+	bucketID     = platform.ID(100)
+	deadLetterID = platform.ID(101)
+	plainID      = platform.ID(102)
+)
+
+// recordingPointsWriter records the points written to each bucket. It fails
+// the writes with err, if set.
+type recordingPointsWriter struct {
+	points map[platform.ID][]models.Point
+	err    error
+}
+
+func (w *recordingPointsWriter) WritePoints(_ context.Context, _, bucketID platform.ID, points []models.Point) error {
+	if w.err != nil {
+		return w.err
+	}
+	if w.points == nil {
+		w.points = make(map[platform.ID][]models.Point)
+	}
+	w.points[bucketID] = append(w.points[bucketID], points...)
+	return nil
+}
+
+func pointLines(points []models.Point) []string {
+	var lines []string
+	for _, p := range points {
+		lines = append(lines, p.String())
+	}
+	return lines
+}
+
+func newTestService(t *testing.T, w *recordingPointsWriter) *Service {
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		switch id {
+		case bucketID:
+			return &influxdb.Bucket{ID: id, OrgID: orgID, DeadLetterBucketID: deadLetterID}, nil
+		case plainID:
+			return &influxdb.Bucket{ID: id, OrgID: orgID}, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	}
+
+	svc := NewService(zaptest.NewLogger(t), buckets, w)
+	svc.now = func() time.Time { return time.Unix(0, 1000) }
+	return svc
+}
+
+func TestService_WriteDeadLetters(t *testing.T) {
+	var underlying recordingPointsWriter
+	svc := newTestService(t, &underlying)
+
+	auth := &influxdb.Authorization{ID: platform.ID(20), UserID: platform.ID(30)}
+	svc.WriteDeadLetters(icontext.SetAuthorizer(ctx, auth), orgID, bucketID, []influxdb.DeadLetter{
+		{Line: "m f= 1", Code: "parse", Reason: "missing field value"},
+		{Line: "m f=1i 2", Code: "field_type_conflict", Reason: "conflict"},
+	})
+	// Lines rejected at the same time are recorded at distinct times.
+	svc.WriteDeadLetters(ctx, orgID, bucketID, []influxdb.DeadLetter{
+		{Line: "m f=1 3", Code: "retention", Reason: "too old"},
+	})
+	require.Equal(t, []string{
+		`rejected_writes,auth_id=0000000000000014,bucket_id=0000000000000064,code=parse,user_id=000000000000001e line="m f= 1",reason="missing field value" 1000`,
+		`rejected_writes,auth_id=0000000000000014,bucket_id=0000000000000064,code=field_type_conflict,user_id=000000000000001e line="m f=1i 2",reason="conflict" 1001`,
+		`rejected_writes,bucket_id=0000000000000064,code=retention line="m f=1 3",reason="too old" 1002`,
+	}, pointLines(underlying.points[deadLetterID]))
+
+	bucket := bucketID.String()
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.lines.WithLabelValues(bucket, "parse")))
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.lines.WithLabelValues(bucket, "retention")))
+
+	// The lines of buckets without a dead-letter bucket are not recorded.
+	underlying.points = nil
+	svc.WriteDeadLetters(ctx, orgID, plainID, []influxdb.DeadLetter{{Line: "m f= 1", Code: "parse"}})
+	require.Empty(t, underlying.points)
+
+	// Failing to record lines does not fail the write.
+	underlying.err = &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	svc.WriteDeadLetters(ctx, orgID, bucketID, []influxdb.DeadLetter{{Line: "m f= 1", Code: "parse"}})
+	svc.WriteDeadLetters(ctx, orgID, platform.ID(1), []influxdb.DeadLetter{{Line: "m f= 1", Code: "parse"}})
+	require.Equal(t, float64(2), testutil.ToFloat64(svc.errors))
+}
+
+func TestService_WriteSidePoints(t *testing.T) {
+	var underlying recordingPointsWriter
+	svc := newTestService(t, &underlying)
+
+	points, err := models.ParsePointsString("m f=1 1\nm f=2 2")
+	require.NoError(t, err)
+	require.NoError(t, svc.WriteSidePoints(ctx, orgID, bucketID, plainID, points))
+	require.Equal(t, []string{"m f=1 1", "m f=2 2"}, pointLines(underlying.points[plainID]))
+
+	// The points which cannot be written are counted, and reported.
+	underlying.err = tsdb.PartialWriteError{Reason: "dropped", Dropped: 1}
+	require.Equal(t, underlying.err, svc.WriteSidePoints(ctx, orgID, bucketID, plainID, points))
+	underlying.err = &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	require.Equal(t, underlying.err, svc.WriteSidePoints(ctx, orgID, bucketID, plainID, points))
+	require.Equal(t, float64(3), testutil.ToFloat64(svc.errors))
+}
+
+func TestPointsWriter_WritePoints(t *testing.T) {
+	var recorder recordingPointsWriter
+	svc := newTestService(t, &recorder)
+
+	points, err := models.ParsePointsString("m f=1 1\nm f=2 2")
+	require.NoError(t, err)
+	perr := tsdb.PartialWriteError{
+		Reason:  "too old",
+		Dropped: 1,
+		Points:  []tsdb.DroppedPoint{{Point: points[1], Code: tsdb.DropRetention, Reason: "too old"}},
+	}
+	w := NewPointsWriter(&mock.PointsWriter{Err: perr}, svc)
+
+	// The error is returned once the dropped points are recorded.
+	require.Equal(t, perr, w.WritePoints(ctx, orgID, bucketID, points))
+	require.Equal(t, []string{
+		`rejected_writes,bucket_id=0000000000000064,code=retention line="m f=2 2",reason="too old" 1000`,
+	}, pointLines(recorder.points[deadLetterID]))
+}
@@ -17,3 +17,10 @@ type Predicate interface {
 type DeleteService interface {
 	DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred Predicate) error
 }
+
+// TombstonePurger removes deleted data that is still stored on disk.
+type TombstonePurger interface {
+	// PurgeTombstones rewrites the storage files of a bucket that hold deleted
+	// data, blocking until the space is reclaimed.
+	PurgeTombstones(ctx context.Context, bucketID platform.ID) error
+}
@@ -20,6 +20,7 @@ import (
 	"github.com/influxdata/influxdb/v2/query/fluxlang"
 	"github.com/influxdata/influxdb/v2/static"
 	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/storage/reads"
 	"github.com/influxdata/influxdb/v2/task/taskmodel"
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
@@ -63,10 +64,18 @@ type APIBackend struct {
 	AlgoWProxy FeatureProxyHandler
 
 	PointsWriter                    storage.PointsWriter
+	ReadsStore                      reads.Store
+	WriteLimiter                    influxdb.WriteLimiter
+	IngestMappingService            influxdb.IngestMappingService
+	WriteIdempotencyService         influxdb.WriteIdempotencyService
+	WriteQueue                      influxdb.WriteQueue
+	DeadLetterWriter                influxdb.DeadLetterWriter
 	DeleteService                   influxdb.DeleteService
+	TombstonePurger                 influxdb.TombstonePurger
 	BackupService                   influxdb.BackupService
 	SqlBackupRestoreService         influxdb.SqlBackupRestoreService
 	BucketManifestWriter            influxdb.BucketManifestWriter
+	CompactionService               influxdb.CompactionService
 	RestoreService                  influxdb.RestoreService
 	AuthorizationService            influxdb.AuthorizationService
 	AuthorizationV1Service          influxdb.AuthorizationService
@@ -208,17 +217,27 @@ func NewAPIHandler(b *APIBackend, opts ...APIHandlerOptFn) *APIHandler {
 	restoreBackend.SqlBackupRestoreService = authorizer.NewSqlBackupRestoreService(restoreBackend.SqlBackupRestoreService)
 	h.Mount(prefixRestore, NewRestoreHandler(restoreBackend))
 
+	compactionBackend := NewCompactionBackend(b)
+	compactionBackend.CompactionService = authorizer.NewCompactionService(compactionBackend.CompactionService)
+	h.Mount(prefixCompaction, NewCompactionHandler(compactionBackend))
+
 	h.Mount(dbrp.PrefixDBRP, dbrp.NewHTTPHandler(b.Logger, b.DBRPService, b.OrganizationService))
 
 	writeBackend := NewWriteBackend(b.Logger.With(zap.String("handler", "write")), b)
-	h.Mount(prefixWrite, NewWriteHandler(b.Logger, writeBackend,
+	writeHandler := NewWriteHandler(b.Logger, writeBackend,
 		WithMaxBatchSizeBytes(b.MaxBatchSizeBytes),
 		// WithParserOptions(
 		//	models.WithParserMaxBytes(b.WriteParserMaxBytes),
 		//	models.WithParserMaxLines(b.WriteParserMaxLines),
 		//	models.WithParserMaxValues(b.WriteParserMaxValues),
 		// ),
-	))
+	)
+	h.Mount(prefixWrite, writeHandler)
+	h.Mount(prefixPromWrite, writeHandler)
+	h.Mount(prefixOTLPMetrics, writeHandler)
+
+	promReadBackend := NewPromReadBackend(b.Logger.With(zap.String("handler", "prom_read")), b)
+	h.Mount(prefixPromRead, NewPromReadHandler(b.Logger, promReadBackend))
 
 	for _, o := range opts {
 		o(h)
@@ -232,6 +251,7 @@ var apiLinks = map[string]interface{}{
 	"authorizations": "/api/v2/authorizations",
 	"backup":         "/api/v2/backup",
 	"buckets":        "/api/v2/buckets",
+	"compaction":     "/api/v2/compaction",
 	"dashboards":     "/api/v2/dashboards",
 	"external": map[string]string{
 		"statusFeed": "https://www.influxdata.com/feed/json",
@@ -0,0 +1,158 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// BucketTransformHandler serves the transform of a bucket. It is embedded in
+// the bucket routes at /api/v2/buckets/:id/transform, which resolve the
+// bucket's organization.
+type BucketTransformHandler struct {
+	chi.Router
+	api          *kithttp.API
+	log          *zap.Logger
+	transformSvc influxdb.BucketTransformService
+}
+
+// NewBucketTransformHandler returns a new instance of BucketTransformHandler.
+func NewBucketTransformHandler(log *zap.Logger, s influxdb.BucketTransformService) *BucketTransformHandler {
+	h := &BucketTransformHandler{
+		api:          kithttp.NewAPI(kithttp.WithLog(log)),
+		log:          log,
+		transformSvc: s,
+	}
+
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "path not found",
+		})
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  fmt.Sprintf("allow: %s", w.Header().Get("Allow")),
+		})
+	})
+	r.Use(
+		kithttp.SkipOptions,
+		middleware.StripSlashes,
+		kithttp.SetCORS,
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", h.handleGetBucketTransform)
+	r.Put("/", h.handlePutBucketTransform)
+	r.Delete("/", h.handleDeleteBucketTransform)
+
+	h.Router = r
+	return h
+}
+
+type putBucketTransformRequest struct {
+	Rules []influxdb.TransformRule `json:"rules"`
+}
+
+// bucketScope returns the organization and bucket IDs of a request.
+func (h *BucketTransformHandler) bucketScope(r *http.Request) (orgID, bucketID platform.ID, err error) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	oid := kithttp.OrgIDFromContext(r.Context())
+	if oid == nil {
+		return 0, 0, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+	return *oid, *id, nil
+}
+
+// handleGetBucketTransform is the HTTP handler for the GET /api/v2/buckets/:id/transform route.
+func (h *BucketTransformHandler) handleGetBucketTransform(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	t, err := h.transformSvc.FindBucketTransform(r.Context(), bucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if t.OrgID != orgID {
+		h.api.Err(w, r, influxdb.ErrBucketTransformNotFound)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, t)
+}
+
+// handlePutBucketTransform is the HTTP handler for the PUT /api/v2/buckets/:id/transform route.
+func (h *BucketTransformHandler) handlePutBucketTransform(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req putBucketTransformRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	t := &influxdb.BucketTransform{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Rules:    req.Rules,
+	}
+	if err := h.transformSvc.PutBucketTransform(r.Context(), t); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket transform set", zap.String("bucket_transform", fmt.Sprint(t)))
+
+	h.api.Respond(w, r, http.StatusOK, t)
+}
+
+// handleDeleteBucketTransform is the HTTP handler for the DELETE /api/v2/buckets/:id/transform route.
+func (h *BucketTransformHandler) handleDeleteBucketTransform(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	t, err := h.transformSvc.FindBucketTransform(r.Context(), bucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if t.OrgID != orgID {
+		h.api.Err(w, r, influxdb.ErrBucketTransformNotFound)
+		return
+	}
+
+	if err := h.transformSvc.DeleteBucketTransform(r.Context(), bucketID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket transform deleted", zap.String("bucketID", bucketID.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
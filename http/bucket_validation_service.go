@@ -0,0 +1,160 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// BucketValidationHandler serves the validation of a bucket. It is embedded in
+// the bucket routes at /api/v2/buckets/:id/validation, which resolve the
+// bucket's organization.
+type BucketValidationHandler struct {
+	chi.Router
+	api           *kithttp.API
+	log           *zap.Logger
+	validationSvc influxdb.BucketValidationService
+}
+
+// NewBucketValidationHandler returns a new instance of BucketValidationHandler.
+func NewBucketValidationHandler(log *zap.Logger, s influxdb.BucketValidationService) *BucketValidationHandler {
+	h := &BucketValidationHandler{
+		api:           kithttp.NewAPI(kithttp.WithLog(log)),
+		log:           log,
+		validationSvc: s,
+	}
+
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "path not found",
+		})
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  fmt.Sprintf("allow: %s", w.Header().Get("Allow")),
+		})
+	})
+	r.Use(
+		kithttp.SkipOptions,
+		middleware.StripSlashes,
+		kithttp.SetCORS,
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", h.handleGetBucketValidation)
+	r.Put("/", h.handlePutBucketValidation)
+	r.Delete("/", h.handleDeleteBucketValidation)
+
+	h.Router = r
+	return h
+}
+
+type putBucketValidationRequest struct {
+	QuarantineBucketID *platform.ID              `json:"quarantineBucketID,omitempty"`
+	Rules              []influxdb.ValidationRule `json:"rules"`
+}
+
+// bucketScope returns the organization and bucket IDs of a request.
+func (h *BucketValidationHandler) bucketScope(r *http.Request) (orgID, bucketID platform.ID, err error) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	oid := kithttp.OrgIDFromContext(r.Context())
+	if oid == nil {
+		return 0, 0, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+	return *oid, *id, nil
+}
+
+// handleGetBucketValidation is the HTTP handler for the GET /api/v2/buckets/:id/validation route.
+func (h *BucketValidationHandler) handleGetBucketValidation(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	v, err := h.validationSvc.FindBucketValidation(r.Context(), bucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if v.OrgID != orgID {
+		h.api.Err(w, r, influxdb.ErrBucketValidationNotFound)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, v)
+}
+
+// handlePutBucketValidation is the HTTP handler for the PUT /api/v2/buckets/:id/validation route.
+func (h *BucketValidationHandler) handlePutBucketValidation(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req putBucketValidationRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	v := &influxdb.BucketValidation{
+		OrgID:              orgID,
+		BucketID:           bucketID,
+		QuarantineBucketID: req.QuarantineBucketID,
+		Rules:              req.Rules,
+	}
+	if err := h.validationSvc.PutBucketValidation(r.Context(), v); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket validation set", zap.String("bucket_validation", fmt.Sprint(v)))
+
+	h.api.Respond(w, r, http.StatusOK, v)
+}
+
+// handleDeleteBucketValidation is the HTTP handler for the DELETE /api/v2/buckets/:id/validation route.
+func (h *BucketValidationHandler) handleDeleteBucketValidation(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	v, err := h.validationSvc.FindBucketValidation(r.Context(), bucketID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	if v.OrgID != orgID {
+		h.api.Err(w, r, influxdb.ErrBucketValidationNotFound)
+		return
+	}
+
+	if err := h.validationSvc.DeleteBucketValidation(r.Context(), bucketID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Bucket validation deleted", zap.String("bucketID", bucketID.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
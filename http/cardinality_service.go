@@ -0,0 +1,128 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// CardinalityHandler serves the cardinality report of a bucket. It is
+// embedded in the bucket routes at /api/v2/buckets/:id/cardinality, which
+// resolve the bucket's organization.
+type CardinalityHandler struct {
+	chi.Router
+	api            *kithttp.API
+	log            *zap.Logger
+	cardinalitySvc influxdb.CardinalityService
+}
+
+// NewCardinalityHandler returns a new instance of CardinalityHandler.
+func NewCardinalityHandler(log *zap.Logger, cs influxdb.CardinalityService) *CardinalityHandler {
+	h := &CardinalityHandler{
+		api:            kithttp.NewAPI(kithttp.WithLog(log)),
+		log:            log,
+		cardinalitySvc: cs,
+	}
+
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "path not found",
+		})
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  fmt.Sprintf("allow: %s", w.Header().Get("Allow")),
+		})
+	})
+	r.Use(
+		kithttp.SkipOptions,
+		middleware.StripSlashes,
+		kithttp.SetCORS,
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", h.handleGetBucketCardinality)
+
+	h.Router = r
+	return h
+}
+
+// handleGetBucketCardinality is the HTTP handler for the GET /api/v2/buckets/:id/cardinality route.
+func (h *CardinalityHandler) handleGetBucketCardinality(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bucketID, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	orgID := kithttp.OrgIDFromContext(ctx)
+	if orgID == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "bucket not found",
+		})
+		return
+	}
+
+	opts, err := decodeCardinalityOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	report, err := h.cardinalitySvc.BucketCardinality(ctx, *orgID, *bucketID, opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, report)
+}
+
+func decodeCardinalityOptions(r *http.Request) (influxdb.CardinalityOptions, error) {
+	var (
+		opts influxdb.CardinalityOptions
+		qp   = r.URL.Query()
+		err  error
+	)
+
+	if s := qp.Get("limit"); s != "" {
+		if opts.Limit, err = strconv.Atoi(s); err != nil || opts.Limit < 1 {
+			return opts, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "limit must be a positive integer",
+			}
+		}
+	}
+	if s := qp.Get("exact"); s != "" {
+		if opts.Exact, err = strconv.ParseBool(s); err != nil {
+			return opts, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "exact must be a boolean",
+			}
+		}
+	}
+	if s := qp.Get("shardGroups"); s != "" {
+		if opts.ShardGroups, err = strconv.Atoi(s); err != nil || opts.ShardGroups < 1 {
+			return opts, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "shardGroups must be a positive integer",
+			}
+		}
+	}
+	return opts, nil
+}
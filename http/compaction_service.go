@@ -0,0 +1,176 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/influxdata/httprouter"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"go.uber.org/zap"
+)
+
+// CompactionBackend is all services and associated parameters required to construct the CompactionHandler.
+type CompactionBackend struct {
+	Logger *zap.Logger
+	errors.HTTPErrorHandler
+
+	CompactionService influxdb.CompactionService
+}
+
+// NewCompactionBackend returns a new instance of CompactionBackend.
+func NewCompactionBackend(b *APIBackend) *CompactionBackend {
+	return &CompactionBackend{
+		Logger: b.Logger.With(zap.String("handler", "compaction")),
+
+		HTTPErrorHandler:  b.HTTPErrorHandler,
+		CompactionService: b.CompactionService,
+	}
+}
+
+// CompactionHandler is http handler for compaction service.
+type CompactionHandler struct {
+	*httprouter.Router
+	errors.HTTPErrorHandler
+	Logger *zap.Logger
+
+	CompactionService influxdb.CompactionService
+}
+
+const (
+	prefixCompaction     = "/api/v2/compaction"
+	compactionShardsPath = prefixCompaction + "/shards"
+)
+
+// NewCompactionHandler creates a new handler at /api/v2/compaction to inspect
+// and trigger TSM compactions.
+func NewCompactionHandler(b *CompactionBackend) *CompactionHandler {
+	h := &CompactionHandler{
+		HTTPErrorHandler:  b.HTTPErrorHandler,
+		Router:            NewRouter(b.HTTPErrorHandler),
+		Logger:            b.Logger,
+		CompactionService: b.CompactionService,
+	}
+
+	h.HandlerFunc(http.MethodGet, compactionShardsPath, h.handleGetCompactionStatus)
+	h.HandlerFunc(http.MethodPost, prefixCompaction, h.handlePostCompaction)
+
+	return h
+}
+
+type compactionStatusResponse struct {
+	Shards []*influxdb.ShardCompactionStatus `json:"shards"`
+}
+
+// handleGetCompactionStatus is the HTTP handler for the GET /api/v2/compaction/shards route.
+func (h *CompactionHandler) handleGetCompactionStatus(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "CompactionHandler.handleGetCompactionStatus")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	bucketID, shardID, err := decodeCompactionTarget(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	shards, err := h.CompactionService.CompactionStatus(ctx, bucketID, shardID)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if err := encodeResponse(ctx, w, http.StatusOK, compactionStatusResponse{Shards: shards}); err != nil {
+		logEncodingError(h.Logger, r, err)
+		return
+	}
+}
+
+// handlePostCompaction is the HTTP handler for the POST /api/v2/compaction route.
+// Progress is streamed as newline-delimited JSON, one object per compacted shard.
+func (h *CompactionHandler) handlePostCompaction(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "CompactionHandler.handlePostCompaction")
+	defer span.Finish()
+
+	ctx := r.Context()
+
+	var req influxdb.CompactionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.HandleHTTPError(ctx, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid compaction request",
+			Err:  err,
+		}, w)
+		return
+	}
+	if req.Mode == "" {
+		req.Mode = influxdb.CompactionModeFull
+	}
+	if err := req.Valid(); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	started := false
+	progress := func(p influxdb.CompactionProgress) {
+		if !started {
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+		if err := enc.Encode(p); err != nil {
+			logEncodingError(h.Logger, r, err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if err := h.CompactionService.Compact(ctx, req, progress); err != nil && !started {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	} else if !started {
+		// No shards to compact.
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func decodeCompactionTarget(r *http.Request) (platform.ID, uint64, error) {
+	var (
+		bucketID platform.ID
+		shardID  uint64
+		qp       = r.URL.Query()
+	)
+
+	if s := qp.Get("bucketID"); s != "" {
+		if err := bucketID.DecodeFromString(s); err != nil {
+			return 0, 0, err
+		}
+	}
+	if s := qp.Get("shardID"); s != "" {
+		id, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			return 0, 0, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid shardID",
+				Err:  err,
+			}
+		}
+		shardID = id
+	}
+
+	if !bucketID.Valid() && shardID == 0 {
+		return 0, 0, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "bucketID or shardID is required",
+		}
+	}
+	return bucketID, shardID, nil
+}
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	http "net/http"
+	"strconv"
 	"time"
 
 	"github.com/influxdata/httprouter"
@@ -25,6 +26,7 @@ type DeleteBackend struct {
 	errors.HTTPErrorHandler
 
 	DeleteService       influxdb.DeleteService
+	TombstonePurger     influxdb.TombstonePurger
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
 }
@@ -36,6 +38,7 @@ func NewDeleteBackend(log *zap.Logger, b *APIBackend) *DeleteBackend {
 
 		HTTPErrorHandler:    b.HTTPErrorHandler,
 		DeleteService:       b.DeleteService,
+		TombstonePurger:     b.TombstonePurger,
 		BucketService:       b.BucketService,
 		OrganizationService: b.OrganizationService,
 	}
@@ -49,6 +52,7 @@ type DeleteHandler struct {
 	log *zap.Logger
 
 	DeleteService       influxdb.DeleteService
+	TombstonePurger     influxdb.TombstonePurger
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
 }
@@ -71,6 +75,7 @@ func NewDeleteHandler(log *zap.Logger, b *DeleteBackend) *DeleteHandler {
 
 		BucketService:       b.BucketService,
 		DeleteService:       b.DeleteService,
+		TombstonePurger:     b.TombstonePurger,
 		OrganizationService: b.OrganizationService,
 	}
 
@@ -136,6 +141,27 @@ func (h *DeleteHandler) handleDelete(w http.ResponseWriter, r *http.Request) {
 		zap.String("bucketID", fmt.Sprint(dr.Bucket.ID.String())),
 	)
 
+	// Optionally wait until the deleted data is removed from disk.
+	if dr.Wait {
+		if h.TombstonePurger == nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.ENotImplemented,
+				Op:   "http/handleDelete",
+				Msg:  "waiting for deleted data to be reclaimed is not supported",
+			}, w)
+			return
+		}
+		if err := h.TombstonePurger.PurgeTombstones(ctx, dr.Bucket.ID); err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInternal,
+				Op:   "http/handleDelete",
+				Msg:  fmt.Sprintf("deleted, but unable to reclaim space: %v", err),
+				Err:  err,
+			}, w)
+			return
+		}
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
@@ -156,6 +182,16 @@ func decodeDeleteRequest(ctx context.Context, r *http.Request, orgSvc influxdb.O
 	if dr.Bucket, err = queryBucket(ctx, dr.Org.ID, r, bucketSvc); err != nil {
 		return nil, err
 	}
+
+	if s := r.URL.Query().Get("wait"); s != "" {
+		if dr.Wait, err = strconv.ParseBool(s); err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "invalid wait parameter; must be true or false",
+				Err:  err,
+			}
+		}
+	}
 	return dr, nil
 }
 
@@ -165,6 +201,7 @@ type deleteRequest struct {
 	Start     int64
 	Stop      int64
 	Predicate influxdb.Predicate
+	Wait      bool
 }
 
 type deleteRequestDecode struct {
@@ -182,6 +219,9 @@ type DeleteRequest struct {
 	Start     string `json:"start"`
 	Stop      string `json:"stop"`
 	Predicate string `json:"predicate"`
+
+	// Wait blocks the request until the deleted data is removed from disk.
+	Wait bool `json:"-"`
 }
 
 func (dr *deleteRequest) UnmarshalJSON(b []byte) error {
@@ -272,6 +312,10 @@ func (s *DeleteService) DeleteBucketRangePredicate(ctx context.Context, dr Delet
 	} else if dr.Bucket != "" {
 		params.Set("bucket", dr.Bucket)
 	}
+
+	if dr.Wait {
+		params.Set("wait", "true")
+	}
 	req.URL.RawQuery = params.Encode()
 
 	hc := NewClient(u.Scheme, s.InsecureSkipVerify)
@@ -37,6 +37,7 @@ func NewMockDeleteBackend(t *testing.T) *DeleteBackend {
 func TestDelete(t *testing.T) {
 	type fields struct {
 		DeleteService       influxdb.DeleteService
+		TombstonePurger     influxdb.TombstonePurger
 		OrganizationService influxdb.OrganizationService
 		BucketService       influxdb.BucketService
 	}
@@ -271,6 +272,113 @@ func TestDelete(t *testing.T) {
 				body:       ``,
 			},
 		},
+		{
+			name: "delete and wait for space to be reclaimed",
+			args: args{
+				queryParams: map[string][]string{
+					"org":    {"org1"},
+					"bucket": {"buck1"},
+					"wait":   {"true"},
+				},
+				body: []byte(`{"start":"2009-01-01T23:00:00Z","stop":"2019-11-10T01:00:00Z"}`),
+				authorizer: &influxdb.Authorization{
+					UserID: user1ID,
+					Status: influxdb.Active,
+					Permissions: []influxdb.Permission{
+						{
+							Action: influxdb.WriteAction,
+							Resource: influxdb.Resource{
+								Type:  influxdb.BucketsResourceType,
+								ID:    influxtesting.IDPtr(platform.ID(2)),
+								OrgID: influxtesting.IDPtr(platform.ID(1)),
+							},
+						},
+					},
+				},
+			},
+			fields: fields{
+				DeleteService: mock.NewDeleteService(),
+				TombstonePurger: &mock.TombstonePurger{
+					PurgeTombstonesF: func(ctx context.Context, bucketID platform.ID) error {
+						if bucketID != platform.ID(2) {
+							return fmt.Errorf("unexpected bucket %s", bucketID)
+						}
+						return nil
+					},
+				},
+				BucketService: &mock.BucketService{
+					FindBucketFn: func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+						return &influxdb.Bucket{
+							ID:   platform.ID(2),
+							Name: "bucket1",
+						}, nil
+					},
+				},
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+						return &influxdb.Organization{
+							ID:   platform.ID(1),
+							Name: "org1",
+						}, nil
+					},
+				},
+			},
+			wants: wants{
+				statusCode: http.StatusNoContent,
+				body:       ``,
+			},
+		},
+		{
+			name: "wait without a tombstone purger",
+			args: args{
+				queryParams: map[string][]string{
+					"org":    {"org1"},
+					"bucket": {"buck1"},
+					"wait":   {"true"},
+				},
+				body: []byte(`{"start":"2009-01-01T23:00:00Z","stop":"2019-11-10T01:00:00Z"}`),
+				authorizer: &influxdb.Authorization{
+					UserID: user1ID,
+					Status: influxdb.Active,
+					Permissions: []influxdb.Permission{
+						{
+							Action: influxdb.WriteAction,
+							Resource: influxdb.Resource{
+								Type:  influxdb.BucketsResourceType,
+								ID:    influxtesting.IDPtr(platform.ID(2)),
+								OrgID: influxtesting.IDPtr(platform.ID(1)),
+							},
+						},
+					},
+				},
+			},
+			fields: fields{
+				DeleteService: mock.NewDeleteService(),
+				BucketService: &mock.BucketService{
+					FindBucketFn: func(ctx context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+						return &influxdb.Bucket{
+							ID:   platform.ID(2),
+							Name: "bucket1",
+						}, nil
+					},
+				},
+				OrganizationService: &mock.OrganizationService{
+					FindOrganizationF: func(ctx context.Context, f influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+						return &influxdb.Organization{
+							ID:   platform.ID(1),
+							Name: "org1",
+						}, nil
+					},
+				},
+			},
+			wants: wants{
+				statusCode: http.StatusNotImplemented,
+				body: `{
+					"code": "not implemented",
+					"message": "waiting for deleted data to be reclaimed is not supported"
+				}`,
+			},
+		},
 		{
 			name: "unsupported delete",
 			args: args{
@@ -382,6 +490,7 @@ func TestDelete(t *testing.T) {
 			deleteBackend := NewMockDeleteBackend(t)
 			deleteBackend.HTTPErrorHandler = kithttp.NewErrorHandler(zaptest.NewLogger(t))
 			deleteBackend.DeleteService = tt.fields.DeleteService
+			deleteBackend.TombstonePurger = tt.fields.TombstonePurger
 			deleteBackend.OrganizationService = tt.fields.OrganizationService
 			deleteBackend.BucketService = tt.fields.BucketService
 			h := NewDeleteHandler(zaptest.NewLogger(t), deleteBackend)
@@ -0,0 +1,191 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+const prefixIngestMappings = "/api/v2/ingest/mappings"
+
+// IngestMappingHandler serves the ingest mappings of organizations at
+// /api/v2/ingest/mappings, which writes of JSON and CSV documents refer to.
+type IngestMappingHandler struct {
+	chi.Router
+	api        *kithttp.API
+	log        *zap.Logger
+	mappingSvc influxdb.IngestMappingService
+}
+
+// NewIngestMappingHandler returns a new instance of IngestMappingHandler.
+func NewIngestMappingHandler(log *zap.Logger, s influxdb.IngestMappingService) *IngestMappingHandler {
+	h := &IngestMappingHandler{
+		api:        kithttp.NewAPI(kithttp.WithLog(log)),
+		log:        log,
+		mappingSvc: s,
+	}
+
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "path not found",
+		})
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  fmt.Sprintf("allow: %s", w.Header().Get("Allow")),
+		})
+	})
+	r.Use(
+		kithttp.SkipOptions,
+		middleware.StripSlashes,
+		kithttp.SetCORS,
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", h.handleGetIngestMappings)
+	r.Post("/", h.handlePostIngestMapping)
+	r.Route("/{id}", func(r chi.Router) {
+		r.Get("/", h.handleGetIngestMapping)
+		r.Patch("/", h.handlePatchIngestMapping)
+		r.Delete("/", h.handleDeleteIngestMapping)
+	})
+
+	h.Router = r
+	return h
+}
+
+// Prefix provides the route prefix.
+func (*IngestMappingHandler) Prefix() string {
+	return prefixIngestMappings
+}
+
+type ingestMappingsResponse struct {
+	IngestMappings []*influxdb.IngestMapping `json:"ingestMappings"`
+}
+
+type postIngestMappingRequest struct {
+	OrgID       platform.ID         `json:"orgID"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Spec        influxdb.IngestSpec `json:"spec"`
+}
+
+// handleGetIngestMappings is the HTTP handler for the GET /api/v2/ingest/mappings route.
+func (h *IngestMappingHandler) handleGetIngestMappings(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	orgID, err := platform.IDFromString(q.Get("orgID"))
+	if err != nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid or missing org ID",
+			Err:  err,
+		})
+		return
+	}
+
+	filter := influxdb.IngestMappingFilter{OrgID: *orgID}
+	if name := q.Get("name"); name != "" {
+		filter.Name = &name
+	}
+
+	mappings, err := h.mappingSvc.FindIngestMappings(r.Context(), filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, ingestMappingsResponse{IngestMappings: mappings})
+}
+
+// handlePostIngestMapping is the HTTP handler for the POST /api/v2/ingest/mappings route.
+func (h *IngestMappingHandler) handlePostIngestMapping(w http.ResponseWriter, r *http.Request) {
+	var req postIngestMappingRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	m := &influxdb.IngestMapping{
+		OrgID:       req.OrgID,
+		Name:        req.Name,
+		Description: req.Description,
+		Spec:        req.Spec,
+	}
+	if err := h.mappingSvc.CreateIngestMapping(r.Context(), m); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Ingest mapping created", zap.String("ingest_mapping", fmt.Sprint(m)))
+
+	h.api.Respond(w, r, http.StatusCreated, m)
+}
+
+// handleGetIngestMapping is the HTTP handler for the GET /api/v2/ingest/mappings/:id route.
+func (h *IngestMappingHandler) handleGetIngestMapping(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	m, err := h.mappingSvc.FindIngestMappingByID(r.Context(), *id)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, m)
+}
+
+// handlePatchIngestMapping is the HTTP handler for the PATCH /api/v2/ingest/mappings/:id route.
+func (h *IngestMappingHandler) handlePatchIngestMapping(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var upd influxdb.IngestMappingUpdate
+	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	m, err := h.mappingSvc.UpdateIngestMapping(r.Context(), *id, upd)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Ingest mapping updated", zap.String("ingest_mapping", fmt.Sprint(m)))
+
+	h.api.Respond(w, r, http.StatusOK, m)
+}
+
+// handleDeleteIngestMapping is the HTTP handler for the DELETE /api/v2/ingest/mappings/:id route.
+func (h *IngestMappingHandler) handleDeleteIngestMapping(w http.ResponseWriter, r *http.Request) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.mappingSvc.DeleteIngestMapping(r.Context(), *id); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Ingest mapping deleted", zap.String("ingestMappingID", id.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -15,9 +15,11 @@ func newLegacyBackend(b *APIBackend) *legacy.Backend {
 		OrganizationService:   b.OrganizationService,
 		BucketService:         b.BucketService,
 		PointsWriter:          b.PointsWriter,
+		WriteLimiter:          b.WriteLimiter,
 		DBRPMappingService:    b.DBRPService,
 		InfluxqldQueryService: b.InfluxqldService,
 		WriteEventRecorder:    b.WriteEventRecorder,
+		DeadLetterWriter:      b.DeadLetterWriter,
 	}
 }
 
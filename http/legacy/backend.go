@@ -31,8 +31,10 @@ type Backend struct {
 	OrganizationService   influxdb.OrganizationService
 	BucketService         influxdb.BucketService
 	PointsWriter          storage.PointsWriter
+	WriteLimiter          influxdb.WriteLimiter
 	DBRPMappingService    influxdb.DBRPMappingService
 	InfluxqldQueryService influxql.ProxyQueryService
+	DeadLetterWriter      influxdb.DeadLetterWriter
 }
 
 // HandlerConfig provides configuration for the legacy handler.
@@ -2,6 +2,7 @@ package legacy
 
 import (
 	"context"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -14,6 +15,7 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"go.uber.org/zap"
@@ -33,7 +35,11 @@ type PointsWriterBackend struct {
 	EventRecorder      metric.EventRecorder
 	BucketService      influxdb.BucketService
 	PointsWriter       storage.PointsWriter
+	WriteLimiter       influxdb.WriteLimiter
 	DBRPMappingService influxdb.DBRPMappingService
+	// DeadLetterWriter records the line protocol lines which cannot be
+	// parsed. The points dropped by the PointsWriter are recorded below it.
+	DeadLetterWriter influxdb.DeadLetterWriter
 }
 
 // NewPointsWriterBackend creates a new backend for legacy work.
@@ -44,7 +50,9 @@ func NewPointsWriterBackend(b *Backend) *PointsWriterBackend {
 		EventRecorder:      b.WriteEventRecorder,
 		BucketService:      b.BucketService,
 		PointsWriter:       b.PointsWriter,
+		WriteLimiter:       b.WriteLimiter,
 		DBRPMappingService: b.DBRPMappingService,
+		DeadLetterWriter:   b.DeadLetterWriter,
 	}
 }
 
@@ -54,24 +62,32 @@ type WriteHandler struct {
 	EventRecorder      metric.EventRecorder
 	BucketService      influxdb.BucketService
 	PointsWriter       storage.PointsWriter
+	WriteLimiter       influxdb.WriteLimiter
 	DBRPMappingService influxdb.DBRPMappingService
+	DeadLetterWriter   influxdb.DeadLetterWriter
 
 	router            *httprouter.Router
 	logger            *zap.Logger
 	maxBatchSizeBytes int64
+	chunkSizeBytes    int
 }
 
-// NewWriterHandler returns a new instance of PointsWriterHandler.
+// NewWriterHandler returns a new instance of PointsWriterHandler. With a
+// DeadLetterWriter, the line protocol lines which cannot be parsed are recorded
+// in the dead-letter bucket of the bucket written to.
 func NewWriterHandler(b *PointsWriterBackend, opts ...WriteHandlerOption) *WriteHandler {
 	h := &WriteHandler{
 		HTTPErrorHandler:   b.HTTPErrorHandler,
 		EventRecorder:      b.EventRecorder,
 		BucketService:      b.BucketService,
 		PointsWriter:       b.PointsWriter,
+		WriteLimiter:       b.WriteLimiter,
 		DBRPMappingService: b.DBRPMappingService,
+		DeadLetterWriter:   b.DeadLetterWriter,
 
-		router: NewRouter(b.HTTPErrorHandler),
-		logger: b.Logger.With(zap.String("handler", "points_writer")),
+		router:         NewRouter(b.HTTPErrorHandler),
+		logger:         b.Logger.With(zap.String("handler", "points_writer")),
+		chunkSizeBytes: points.DefaultChunkSize,
 	}
 
 	for _, opt := range opts {
@@ -94,6 +110,15 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithChunkSizeBytes configures the number of bytes of line protocol parsed
+// and written at once by the write handler. Atomic writes are only parsed in
+// chunks, and written once the whole body is.
+func WithChunkSizeBytes(n int) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.chunkSizeBytes = n
+	}
+}
+
 // ServeHTTP implements http.Handler
 func (h *WriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	h.router.ServeHTTP(w, r)
@@ -150,29 +175,61 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, auth.OrgID, bucket.ID, req.Body)
-	if err != nil {
-		h.HandleHTTPError(ctx, err, sw)
-		return
-	}
+	// The points are parsed and written in chunks, so that the body of large
+	// writes is not held in memory at once, unless the write is atomic.
+	writePoints := func(pts models.Points, size int) error {
+		requestBytes += size
+		if h.WriteLimiter != nil {
+			if err := h.WriteLimiter.AllowWrite(ctx, auth.OrgID, len(pts), size); err != nil {
+				return err
+			}
+		}
 
-	if err := h.PointsWriter.WritePoints(ctx, auth.OrgID, bucket.ID, parsed.Points); err != nil {
-		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
-			h.HandleHTTPError(ctx, &errors.Error{
-				Code: errors.EUnprocessableEntity,
+		err := h.PointsWriter.WritePoints(ctx, auth.OrgID, bucket.ID, pts)
+		if _, ok := err.(tsdb.PartialWriteError); ok {
+			return err
+		} else if err != nil {
+			return &errors.Error{
+				Code: errors.EInternal,
 				Op:   opWriteHandler,
-				Msg:  "failure writing points to database",
-				Err:  partialErr,
-			}, sw)
-			return
+				Msg:  "unexpected error writing points to database",
+				Err:  err,
+			}
 		}
+		return nil
+	}
 
-		h.HandleHTTPError(ctx, &errors.Error{
-			Code: errors.EInternal,
+	parser := points.NewParser(req.Precision)
+	if h.DeadLetterWriter != nil {
+		var letters []influxdb.DeadLetter
+		parser.OnParseError = func(line []byte, err error) {
+			letters = append(letters, influxdb.DeadLetter{Line: string(line), Code: points.RejectParse, Reason: err.Error()})
+		}
+		defer func() { h.DeadLetterWriter.WriteDeadLetters(ctx, auth.OrgID, bucket.ID, letters) }()
+	}
+
+	parse := parser.ParseChunks
+	if req.Partial {
+		parse = parser.ParseLines
+	} else if req.Atomic {
+		parse = parser.ParseAtomic
+	}
+	_, err = parse(ctx, req.Body, h.chunkSizeBytes, writePoints)
+	var rejected *points.RejectedLinesError
+	if stderrors.As(err, &rejected) {
+		points.WriteRejectedLines(ctx, sw, rejected)
+		return
+	}
+	if perr, ok := err.(tsdb.PartialWriteError); ok {
+		err = &errors.Error{
+			Code: errors.EUnprocessableEntity,
 			Op:   opWriteHandler,
-			Msg:  "unexpected error writing points to database",
-			Err:  err,
-		}, sw)
+			Msg:  "failure writing points to database",
+			Err:  perr,
+		}
+	}
+	if err != nil {
+		h.HandleHTTPError(ctx, err, sw)
 		return
 	}
 
@@ -247,6 +304,8 @@ type writeRequest struct {
 	Database         string
 	RetentionPolicy  string
 	Precision        string
+	Partial          bool
+	Atomic           bool
 	Body             io.ReadCloser
 }
 
@@ -266,6 +325,15 @@ func decodeWriteRequest(_ context.Context, r *http.Request, maxBatchSizeBytes in
 		}
 	}
 
+	partial, err := points.PartialWriteRequested(qp)
+	if err != nil {
+		return nil, err
+	}
+	atomic, err := points.AtomicWriteRequested(qp)
+	if err != nil {
+		return nil, err
+	}
+
 	encoding := r.Header.Get("Content-Encoding")
 	body, err := points.BatchReadCloser(r.Body, encoding, maxBatchSizeBytes)
 	if err != nil {
@@ -277,6 +345,8 @@ func decodeWriteRequest(_ context.Context, r *http.Request, maxBatchSizeBytes in
 		Database:         db,
 		RetentionPolicy:  qp.Get("rp"),
 		Precision:        precision,
+		Partial:          partial,
+		Atomic:           atomic,
 		Body:             body,
 	}, nil
 }
@@ -271,6 +271,300 @@ func TestWriteHandler_PartialWrite(t *testing.T) {
 	assert.Equal(t, `{"code":"unprocessable entity","message":"failure writing points to database: partial write: bad points dropped=1"}`, w.Body.String())
 }
 
+func TestWriteHandler_Chunked(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		// Mocked Services
+		eventRecorder  = mocks.NewMockEventRecorder(ctrl)
+		dbrpMappingSvc = mocks.NewMockDBRPMappingService(ctrl)
+		bucketService  = mocks.NewMockBucketService(ctrl)
+		pointsWriter   = mocks.NewMockPointsWriter(ctrl)
+
+		// Found Resources
+		orgID  = generator.ID()
+		bucket = &influxdb.Bucket{
+			ID:                  generator.ID(),
+			OrgID:               orgID,
+			Name:                "mydb/autogen",
+			RetentionPolicyName: "autogen",
+			RetentionPeriod:     72 * time.Hour,
+		}
+		mapping = &influxdb.DBRPMapping{
+			OrganizationID:  orgID,
+			BucketID:        bucket.ID,
+			Database:        "mydb",
+			RetentionPolicy: "autogen",
+			Default:         true,
+		}
+
+		lines = []string{"m,t1=v1 f1=2 100", "m,t1=v2 f1=3 100", "m,t1=v3 f1=4 100"}
+	)
+
+	dbrpMappingSvc.
+		EXPECT().
+		FindMany(gomock.Any(), gomock.Any()).Return([]*influxdb.DBRPMapping{mapping}, 1, nil)
+
+	bucketService.
+		EXPECT().
+		FindBucketByID(gomock.Any(), bucket.ID).Return(bucket, nil)
+
+	// Every line is written on its own, and the points dropped from each
+	// are reported together.
+	var writes []*gomock.Call
+	for _, line := range lines {
+		writes = append(writes, pointsWriter.
+			EXPECT().
+			WritePoints(gomock.Any(), orgID, bucket.ID, pointsMatcher{parseLineProtocol(t, line)}).
+			Return(tsdb.PartialWriteError{Reason: "bad points", Dropped: 1}))
+	}
+	gomock.InOrder(writes...)
+
+	eventRecorder.EXPECT().
+		Record(gomock.Any(), gomock.Any())
+
+	perms := newPermissions(influxdb.WriteAction, influxdb.BucketsResourceType, &orgID, nil)
+	auth := newAuthorization(orgID, perms...)
+	ctx := pcontext.SetAuthorizer(context.Background(), auth)
+	r := newWriteRequest(ctx, strings.Join(lines, "\n"))
+	params := r.URL.Query()
+	params.Set("db", "mydb")
+	r.URL.RawQuery = params.Encode()
+
+	handler := NewWriterHandler(&PointsWriterBackend{
+		HTTPErrorHandler:   kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:             zaptest.NewLogger(t),
+		BucketService:      bucketService,
+		DBRPMappingService: dbrp.NewAuthorizedService(dbrpMappingSvc),
+		PointsWriter:       pointsWriter,
+		EventRecorder:      eventRecorder,
+	}, WithChunkSizeBytes(1))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"code":"unprocessable entity","message":"failure writing points to database: partial write: bad points dropped=3"}`, w.Body.String())
+}
+
+func TestWriteHandler_ChunkedAtomic(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		// Mocked Services
+		eventRecorder  = mocks.NewMockEventRecorder(ctrl)
+		dbrpMappingSvc = mocks.NewMockDBRPMappingService(ctrl)
+		bucketService  = mocks.NewMockBucketService(ctrl)
+		pointsWriter   = mocks.NewMockPointsWriter(ctrl)
+
+		// Found Resources
+		orgID  = generator.ID()
+		bucket = &influxdb.Bucket{
+			ID:                  generator.ID(),
+			OrgID:               orgID,
+			Name:                "mydb/autogen",
+			RetentionPolicyName: "autogen",
+			RetentionPeriod:     72 * time.Hour,
+		}
+		mapping = &influxdb.DBRPMapping{
+			OrganizationID:  orgID,
+			BucketID:        bucket.ID,
+			Database:        "mydb",
+			RetentionPolicy: "autogen",
+			Default:         true,
+		}
+	)
+
+	dbrpMappingSvc.
+		EXPECT().
+		FindMany(gomock.Any(), gomock.Any()).Return([]*influxdb.DBRPMapping{mapping}, 1, nil)
+
+	bucketService.
+		EXPECT().
+		FindBucketByID(gomock.Any(), bucket.ID).Return(bucket, nil)
+
+	// The line of the second chunk of the atomic write cannot be parsed, so
+	// the first one is not written either: pointsWriter expects no write.
+	eventRecorder.EXPECT().
+		Record(gomock.Any(), gomock.Any())
+
+	perms := newPermissions(influxdb.WriteAction, influxdb.BucketsResourceType, &orgID, nil)
+	auth := newAuthorization(orgID, perms...)
+	ctx := pcontext.SetAuthorizer(context.Background(), auth)
+	r := newWriteRequest(ctx, "m,t1=v1 f1=2 100\nm,t1=v2 f1= 100")
+	params := r.URL.Query()
+	params.Set("db", "mydb")
+	params.Set("atomic", "true")
+	r.URL.RawQuery = params.Encode()
+
+	handler := NewWriterHandler(&PointsWriterBackend{
+		HTTPErrorHandler:   kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:             zaptest.NewLogger(t),
+		BucketService:      bucketService,
+		DBRPMappingService: dbrp.NewAuthorizedService(dbrpMappingSvc),
+		PointsWriter:       pointsWriter,
+		EventRecorder:      eventRecorder,
+	}, WithChunkSizeBytes(1))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWriteHandler_PartialLines(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		// Mocked Services
+		eventRecorder  = mocks.NewMockEventRecorder(ctrl)
+		dbrpMappingSvc = mocks.NewMockDBRPMappingService(ctrl)
+		bucketService  = mocks.NewMockBucketService(ctrl)
+		pointsWriter   = mocks.NewMockPointsWriter(ctrl)
+
+		// Found Resources
+		orgID  = generator.ID()
+		bucket = &influxdb.Bucket{
+			ID:                  generator.ID(),
+			OrgID:               orgID,
+			Name:                "mydb/autogen",
+			RetentionPolicyName: "autogen",
+			RetentionPeriod:     72 * time.Hour,
+		}
+		mapping = &influxdb.DBRPMapping{
+			OrganizationID:  orgID,
+			BucketID:        bucket.ID,
+			Database:        "mydb",
+			RetentionPolicy: "autogen",
+			Default:         true,
+		}
+	)
+
+	dbrpMappingSvc.
+		EXPECT().
+		FindMany(gomock.Any(), gomock.Any()).Return([]*influxdb.DBRPMapping{mapping}, 1, nil)
+
+	bucketService.
+		EXPECT().
+		FindBucketByID(gomock.Any(), bucket.ID).Return(bucket, nil)
+
+	// The lines which cannot be parsed are rejected, and the others written.
+	conflict := parseLineProtocol(t, "m,t1=v3 f1=true 100")
+	pointsWriter.
+		EXPECT().
+		WritePoints(gomock.Any(), orgID, bucket.ID, pointsMatcher{parseLineProtocol(t, "m,t1=v1 f1=2 100\nm,t1=v3 f1=true 100")}).
+		Return(tsdb.PartialWriteError{
+			Reason:  "field type conflict",
+			Dropped: 1,
+			Points:  tsdb.DropPoints(tsdb.DropFieldTypeConflict, "field type conflict", conflict),
+		})
+
+	eventRecorder.EXPECT().
+		Record(gomock.Any(), gomock.Any())
+
+	perms := newPermissions(influxdb.WriteAction, influxdb.BucketsResourceType, &orgID, nil)
+	auth := newAuthorization(orgID, perms...)
+	ctx := pcontext.SetAuthorizer(context.Background(), auth)
+	r := newWriteRequest(ctx, "m,t1=v1 f1=2 100\nm,t1=v2 f1= 100\nm,t1=v3 f1=true 100")
+	params := r.URL.Query()
+	params.Set("db", "mydb")
+	params.Set("partial", "true")
+	r.URL.RawQuery = params.Encode()
+
+	handler := NewWriterHandler(&PointsWriterBackend{
+		HTTPErrorHandler:   kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:             zaptest.NewLogger(t),
+		BucketService:      bucketService,
+		DBRPMappingService: dbrp.NewAuthorizedService(dbrpMappingSvc),
+		PointsWriter:       pointsWriter,
+		EventRecorder:      eventRecorder,
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"code":"unprocessable entity","message":"partial write: 2 lines rejected","rejected":[`+
+		`{"line":2,"code":"parse","message":"unable to parse 'm,t1=v2 f1= 100': missing field value"},`+
+		`{"line":3,"code":"field_type_conflict","message":"field type conflict"}]}`, w.Body.String())
+}
+
+func TestWriteHandler_DeadLetters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		// Mocked Services
+		eventRecorder  = mocks.NewMockEventRecorder(ctrl)
+		dbrpMappingSvc = mocks.NewMockDBRPMappingService(ctrl)
+		bucketService  = mocks.NewMockBucketService(ctrl)
+		pointsWriter   = mocks.NewMockPointsWriter(ctrl)
+
+		// Found Resources
+		orgID  = generator.ID()
+		bucket = &influxdb.Bucket{
+			ID:                  generator.ID(),
+			OrgID:               orgID,
+			Name:                "mydb/autogen",
+			RetentionPolicyName: "autogen",
+			RetentionPeriod:     72 * time.Hour,
+		}
+		mapping = &influxdb.DBRPMapping{
+			OrganizationID:  orgID,
+			BucketID:        bucket.ID,
+			Database:        "mydb",
+			RetentionPolicy: "autogen",
+			Default:         true,
+		}
+	)
+
+	dbrpMappingSvc.
+		EXPECT().
+		FindMany(gomock.Any(), gomock.Any()).Return([]*influxdb.DBRPMapping{mapping}, 1, nil)
+
+	bucketService.
+		EXPECT().
+		FindBucketByID(gomock.Any(), bucket.ID).Return(bucket, nil)
+
+	eventRecorder.EXPECT().
+		Record(gomock.Any(), gomock.Any())
+
+	perms := newPermissions(influxdb.WriteAction, influxdb.BucketsResourceType, &orgID, nil)
+	auth := newAuthorization(orgID, perms...)
+	ctx := pcontext.SetAuthorizer(context.Background(), auth)
+	r := newWriteRequest(ctx, "m,t1=v1 f1=2 100\nm,t1=v2 f1= 100")
+	params := r.URL.Query()
+	params.Set("db", "mydb")
+	r.URL.RawQuery = params.Encode()
+
+	// The lines which cannot be parsed are recorded, though the write fails.
+	dl := &deadLetters{}
+	handler := NewWriterHandler(&PointsWriterBackend{
+		HTTPErrorHandler:   kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:             zaptest.NewLogger(t),
+		BucketService:      bucketService,
+		DBRPMappingService: dbrp.NewAuthorizedService(dbrpMappingSvc),
+		PointsWriter:       pointsWriter,
+		EventRecorder:      eventRecorder,
+		DeadLetterWriter:   dl,
+	})
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, bucket.ID, dl.bucketID)
+	assert.Equal(t, []influxdb.DeadLetter{
+		{Line: "m,t1=v2 f1= 100", Code: "parse", Reason: "unable to parse 'm,t1=v2 f1= 100': missing field value"},
+	}, dl.letters)
+}
+
+// deadLetters records the dead letters written to it.
+type deadLetters struct {
+	bucketID platform.ID
+	letters  []influxdb.DeadLetter
+}
+
+func (d *deadLetters) WriteDeadLetters(_ context.Context, _, bucketID platform.ID, letters []influxdb.DeadLetter) {
+	d.bucketID = bucketID
+	d.letters = append(d.letters, letters...)
+}
+
 func TestWriteHandler_BucketAndMappingExistsNoPermissions(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
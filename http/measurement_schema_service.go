@@ -0,0 +1,216 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// MeasurementSchemaHandler serves the measurement schemas of a bucket with an
+// explicit schema type. It is embedded in the bucket routes at
+// /api/v2/buckets/:id/schema/measurements, which resolve the bucket's
+// organization.
+type MeasurementSchemaHandler struct {
+	chi.Router
+	api       *kithttp.API
+	log       *zap.Logger
+	schemaSvc influxdb.MeasurementSchemaService
+}
+
+// NewMeasurementSchemaHandler returns a new instance of MeasurementSchemaHandler.
+func NewMeasurementSchemaHandler(log *zap.Logger, s influxdb.MeasurementSchemaService) *MeasurementSchemaHandler {
+	h := &MeasurementSchemaHandler{
+		api:       kithttp.NewAPI(kithttp.WithLog(log)),
+		log:       log,
+		schemaSvc: s,
+	}
+
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "path not found",
+		})
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  fmt.Sprintf("allow: %s", w.Header().Get("Allow")),
+		})
+	})
+	r.Use(
+		kithttp.SkipOptions,
+		middleware.StripSlashes,
+		kithttp.SetCORS,
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", h.handleGetMeasurementSchemas)
+	r.Post("/", h.handlePostMeasurementSchema)
+	r.Route("/{measurementID}", func(r chi.Router) {
+		r.Get("/", h.handleGetMeasurementSchema)
+		r.Patch("/", h.handlePatchMeasurementSchema)
+		r.Delete("/", h.handleDeleteMeasurementSchema)
+	})
+
+	h.Router = r
+	return h
+}
+
+type measurementSchemasResponse struct {
+	MeasurementSchemas []*influxdb.MeasurementSchema `json:"measurementSchemas"`
+}
+
+type postMeasurementSchemaRequest struct {
+	Name    string                             `json:"name"`
+	Columns []influxdb.MeasurementSchemaColumn `json:"columns"`
+}
+
+// bucketScope returns the organization and bucket IDs of a request.
+func (h *MeasurementSchemaHandler) bucketScope(r *http.Request) (orgID, bucketID platform.ID, err error) {
+	id, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	oid := kithttp.OrgIDFromContext(r.Context())
+	if oid == nil {
+		return 0, 0, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+	return *oid, *id, nil
+}
+
+// findMeasurementSchema returns the measurement schema of a request, which
+// must belong to the request's bucket.
+func (h *MeasurementSchemaHandler) findMeasurementSchema(r *http.Request) (*influxdb.MeasurementSchema, error) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		return nil, err
+	}
+	id, err := platform.IDFromString(chi.URLParam(r, "measurementID"))
+	if err != nil {
+		return nil, err
+	}
+	m, err := h.schemaSvc.FindMeasurementSchemaByID(r.Context(), *id)
+	if err != nil {
+		return nil, err
+	}
+	if m.OrgID != orgID || m.BucketID != bucketID {
+		return nil, influxdb.ErrMeasurementSchemaNotFound
+	}
+	return m, nil
+}
+
+// handleGetMeasurementSchemas is the HTTP handler for the GET /api/v2/buckets/:id/schema/measurements route.
+func (h *MeasurementSchemaHandler) handleGetMeasurementSchemas(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	filter := influxdb.MeasurementSchemaFilter{OrgID: orgID, BucketID: bucketID}
+	if name := r.URL.Query().Get("name"); name != "" {
+		filter.Name = &name
+	}
+
+	schemas, err := h.schemaSvc.FindMeasurementSchemas(r.Context(), filter)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, measurementSchemasResponse{MeasurementSchemas: schemas})
+}
+
+// handlePostMeasurementSchema is the HTTP handler for the POST /api/v2/buckets/:id/schema/measurements route.
+func (h *MeasurementSchemaHandler) handlePostMeasurementSchema(w http.ResponseWriter, r *http.Request) {
+	orgID, bucketID, err := h.bucketScope(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var req postMeasurementSchemaRequest
+	if err := h.api.DecodeJSON(r.Body, &req); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	m := &influxdb.MeasurementSchema{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Name:     req.Name,
+		Columns:  req.Columns,
+	}
+	if err := h.schemaSvc.CreateMeasurementSchema(r.Context(), m); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Measurement schema created", zap.String("measurement_schema", fmt.Sprint(m)))
+
+	h.api.Respond(w, r, http.StatusCreated, m)
+}
+
+// handleGetMeasurementSchema is the HTTP handler for the GET /api/v2/buckets/:id/schema/measurements/:measurementID route.
+func (h *MeasurementSchemaHandler) handleGetMeasurementSchema(w http.ResponseWriter, r *http.Request) {
+	m, err := h.findMeasurementSchema(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, m)
+}
+
+// handlePatchMeasurementSchema is the HTTP handler for the PATCH /api/v2/buckets/:id/schema/measurements/:measurementID route.
+func (h *MeasurementSchemaHandler) handlePatchMeasurementSchema(w http.ResponseWriter, r *http.Request) {
+	m, err := h.findMeasurementSchema(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	var upd influxdb.MeasurementSchemaUpdate
+	if err := h.api.DecodeJSON(r.Body, &upd); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	m, err = h.schemaSvc.UpdateMeasurementSchema(r.Context(), m.ID, upd)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Measurement schema updated", zap.String("measurement_schema", fmt.Sprint(m)))
+
+	h.api.Respond(w, r, http.StatusOK, m)
+}
+
+// handleDeleteMeasurementSchema is the HTTP handler for the DELETE /api/v2/buckets/:id/schema/measurements/:measurementID route.
+func (h *MeasurementSchemaHandler) handleDeleteMeasurementSchema(w http.ResponseWriter, r *http.Request) {
+	m, err := h.findMeasurementSchema(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	if err := h.schemaSvc.DeleteMeasurementSchema(r.Context(), m.ID); err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	h.log.Debug("Measurement schema deleted", zap.String("measurementSchemaID", m.ID.String()))
+
+	w.WriteHeader(http.StatusNoContent)
+}
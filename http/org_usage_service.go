@@ -0,0 +1,76 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// OrgUsageHandler serves the resource usage and quotas of an organization. It
+// is embedded in the organization routes at /api/v2/orgs/:id/usage.
+type OrgUsageHandler struct {
+	chi.Router
+	api      *kithttp.API
+	log      *zap.Logger
+	usageSvc influxdb.OrgUsageService
+}
+
+// NewOrgUsageHandler returns a new instance of OrgUsageHandler.
+func NewOrgUsageHandler(log *zap.Logger, us influxdb.OrgUsageService) *OrgUsageHandler {
+	h := &OrgUsageHandler{
+		api:      kithttp.NewAPI(kithttp.WithLog(log)),
+		log:      log,
+		usageSvc: us,
+	}
+
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "path not found",
+		})
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  fmt.Sprintf("allow: %s", w.Header().Get("Allow")),
+		})
+	})
+	r.Use(
+		kithttp.SkipOptions,
+		middleware.StripSlashes,
+		kithttp.SetCORS,
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/", h.handleGetOrgUsage)
+
+	h.Router = r
+	return h
+}
+
+// handleGetOrgUsage is the HTTP handler for the GET /api/v2/orgs/:id/usage route.
+func (h *OrgUsageHandler) handleGetOrgUsage(w http.ResponseWriter, r *http.Request) {
+	orgID, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	usage, err := h.usageSvc.FindOrgUsage(r.Context(), *orgID)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, usage)
+}
@@ -1,11 +1,14 @@
 package points
 
 import (
+	"bufio"
+	"bytes"
 	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"time"
 
 	io2 "github.com/influxdata/influxdb/v2/kit/io"
@@ -13,6 +16,7 @@ import (
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/opentracing/opentracing-go"
 )
 
@@ -28,6 +32,10 @@ const (
 	msgUnableToReadData = "unable to read data"
 )
 
+// DefaultChunkSize is the number of bytes of line protocol parsed at once by
+// ParseChunks unless another size is given.
+const DefaultChunkSize = 4 << 20
+
 // ParsedPoints contains the points parsed as well as the total number of bytes
 // after decompression.
 type ParsedPoints struct {
@@ -39,6 +47,11 @@ type ParsedPoints struct {
 type Parser struct {
 	Precision string
 	//ParserOptions []models.ParserOption
+
+	// OnParseError, if set, is called by ParseChunks, ParseAtomic and
+	// ParseLines with every line which cannot be parsed and why. The line
+	// refers to the body read, and must be copied to be kept.
+	OnParseError func(line []byte, err error)
 }
 
 // Parse parses the points from an io.ReadCloser for a specific Bucket.
@@ -51,18 +64,7 @@ func (pw *Parser) Parse(ctx context.Context, orgID, bucketID platform.ID, rc io.
 func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID, rc io.ReadCloser) (*ParsedPoints, error) {
 	data, err := readAll(ctx, rc)
 	if err != nil {
-		code := errors2.EInternal
-		if errors.Is(err, ErrMaxBatchSizeExceeded) {
-			code = errors2.ETooLarge
-		} else if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) {
-			code = errors2.EInvalid
-		}
-		return nil, &errors2.Error{
-			Code: code,
-			Op:   opPointsWriter,
-			Msg:  msgUnableToReadData,
-			Err:  err,
-		}
+		return nil, readError(err)
 	}
 
 	span, _ := tracing.StartSpanFromContextWithOperationName(ctx, "encoding and parsing")
@@ -95,6 +97,285 @@ func (pw *Parser) parsePoints(ctx context.Context, orgID, bucketID platform.ID,
 	}, nil
 }
 
+// ParseChunks parses the points from an io.ReadCloser in chunks of whole lines
+// of about chunkSize bytes, calling fn with the points of each chunk and its
+// size before reading the next one. The body is thus never held in memory at
+// once, and a slow fn slows down the reading of the body.
+//
+// fn is called at least once. If a chunk cannot be parsed, the points of the
+// chunks before it have already been passed to fn. If fn returns a
+// tsdb.PartialWriteError, the following chunks are still parsed, and the
+// points dropped from all chunks are reported by the tsdb.PartialWriteError
+// returned once they are. ParseChunks returns the number of bytes read.
+func (pw *Parser) ParseChunks(ctx context.Context, rc io.ReadCloser, chunkSize int, fn func(pts models.Points, size int) error) (n int, err error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "write points")
+	defer func() {
+		span.LogKV("request_bytes", n)
+		span.Finish()
+	}()
+
+	var (
+		now     = time.Now().UTC()
+		partial *tsdb.PartialWriteError
+	)
+	n, err = readChunks(rc, chunkSize, func(chunk []byte, line int) error {
+		// The points refer to the chunk, which must not be reused.
+		points, err := models.ParsePointsWithPrecision(chunk, now, pw.Precision)
+		if err != nil {
+			tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
+			pw.parseErrors(chunk, now)
+			perr := &errors2.Error{
+				Code: errors2.EInvalid,
+				Op:   opPointsWriter,
+				Err:  err,
+			}
+			if line > 1 {
+				perr.Msg = fmt.Sprintf("the lines before line %d were written", line)
+			}
+			return perr
+		}
+		err = fn(points, len(chunk))
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			partial = mergePartialWriteErrors(partial, perr)
+			return nil
+		}
+		return err
+	})
+	if err == nil && partial != nil {
+		err = *partial
+	}
+	return n, err
+}
+
+// ParseAtomic is like ParseChunks, except that fn is called only once the
+// whole body is parsed, with all of its points and its size. A body with a
+// line which cannot be parsed is thus not written at all, but is held in
+// memory at once.
+func (pw *Parser) ParseAtomic(ctx context.Context, rc io.ReadCloser, chunkSize int, fn func(pts models.Points, size int) error) (n int, err error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "write points")
+	defer func() {
+		span.LogKV("request_bytes", n)
+		span.Finish()
+	}()
+
+	var (
+		now      = time.Now().UTC()
+		points   models.Points
+		parseErr error
+	)
+	n, err = readChunks(rc, chunkSize, func(chunk []byte, line int) error {
+		// The points refer to the chunk, which must not be reused. The
+		// chunks after one which cannot be parsed are still parsed, so that
+		// all of the lines which cannot be are reported.
+		pts, err := models.ParsePointsWithPrecision(chunk, now, pw.Precision)
+		if err != nil {
+			tracing.LogError(span, fmt.Errorf("error parsing points: %v", err))
+			pw.parseErrors(chunk, now)
+			if parseErr == nil {
+				parseErr = &errors2.Error{
+					Code: errors2.EInvalid,
+					Op:   opPointsWriter,
+					Err:  err,
+				}
+			}
+			return nil
+		}
+		if parseErr == nil {
+			points = append(points, pts...)
+		}
+		return nil
+	})
+	if err != nil {
+		return n, err
+	}
+	if parseErr != nil {
+		return n, parseErr
+	}
+	span.LogKV("values_total", len(points))
+	return n, fn(points, n)
+}
+
+// ParseLines is like ParseChunks, except that every line is parsed on its
+// own, so that the lines which cannot be parsed do not fail the request. If
+// fn returns a tsdb.PartialWriteError, the lines of the points it dropped are
+// found by their series key and time.
+//
+// Once every chunk is written, the lines which could not be parsed or whose
+// points were dropped are reported by a *RejectedLinesError.
+func (pw *Parser) ParseLines(ctx context.Context, rc io.ReadCloser, chunkSize int, fn func(pts models.Points, size int) error) (n int, err error) {
+	span, _ := opentracing.StartSpanFromContext(ctx, "write points")
+	defer func() {
+		span.LogKV("request_bytes", n)
+		span.Finish()
+	}()
+
+	var (
+		now      = time.Now().UTC()
+		rejected []RejectedLine
+	)
+	n, err = readChunks(rc, chunkSize, func(chunk []byte, line int) error {
+		var (
+			size   = len(chunk)
+			points models.Points
+			lines  []int
+		)
+		for ; len(chunk) > 0; line++ {
+			b := chunk
+			if i := bytes.IndexByte(chunk, '\n'); i >= 0 {
+				b, chunk = chunk[:i], chunk[i+1:]
+			} else {
+				chunk = nil
+			}
+
+			pts, err := models.ParsePointsWithPrecision(b, now, pw.Precision)
+			if err != nil {
+				rejected = append(rejected, RejectedLine{Line: line, Code: RejectParse, Message: err.Error()})
+				if pw.OnParseError != nil {
+					pw.OnParseError(b, err)
+				}
+				continue
+			}
+			for range pts {
+				lines = append(lines, line)
+			}
+			points = append(points, pts...)
+		}
+
+		// The writer may reorder the points it is passed.
+		written := append(models.Points(nil), points...)
+		err := fn(points, size)
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			rejected = append(rejected, rejectedPoints(perr, written, lines)...)
+			return nil
+		}
+		return err
+	})
+	if err == nil && len(rejected) > 0 {
+		sort.SliceStable(rejected, func(i, j int) bool { return rejected[i].Line < rejected[j].Line })
+		err = &RejectedLinesError{Lines: rejected}
+	}
+	return n, err
+}
+
+// parseErrors passes the lines of a chunk which cannot be parsed to
+// OnParseError, if set. The chunk is parsed again line by line to find them.
+func (pw *Parser) parseErrors(chunk []byte, now time.Time) {
+	if pw.OnParseError == nil {
+		return
+	}
+	for len(chunk) > 0 {
+		b := chunk
+		if i := bytes.IndexByte(chunk, '\n'); i >= 0 {
+			b, chunk = chunk[:i], chunk[i+1:]
+		} else {
+			chunk = nil
+		}
+		if _, err := models.ParsePointsWithPrecision(b, now, pw.Precision); err != nil {
+			pw.OnParseError(b, err)
+		}
+	}
+}
+
+// readChunks reads rc in chunks of whole lines of about chunkSize bytes,
+// calling parse with each chunk and the number of its first line. The body
+// is closed before its last chunk is parsed, which reports a body exceeding
+// its size limit instead of parsing its truncated last line.
+func readChunks(rc io.ReadCloser, chunkSize int, parse func(chunk []byte, line int) error) (n int, err error) {
+	closed := false
+	defer func() {
+		if closed {
+			return
+		}
+		if cerr := rc.Close(); cerr != nil && err == nil {
+			err = readError(cerr)
+		}
+	}()
+
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+
+	var (
+		br     = bufio.NewReader(rc)
+		chunk  = make([]byte, 0, chunkSize)
+		line   = 1 // the first line of the chunk
+		lines  int
+		chunks int
+	)
+	next := func() error {
+		chunks++
+		n += len(chunk)
+		if err := parse(chunk, line); err != nil {
+			return err
+		}
+		line += lines
+		lines = 0
+		chunk = make([]byte, 0, chunkSize)
+		return nil
+	}
+
+	for {
+		b, err := br.ReadSlice('\n')
+		chunk = append(chunk, b...)
+		if err == bufio.ErrBufferFull {
+			// The rest of the line is read before the chunk is parsed.
+			continue
+		} else if err == io.EOF {
+			closed = true
+			if err := rc.Close(); err != nil {
+				return n, readError(err)
+			}
+			if len(chunk) > 0 || chunks == 0 {
+				return n, next()
+			}
+			return n, nil
+		} else if err != nil {
+			return n, readError(err)
+		}
+
+		lines++
+		if len(chunk) >= chunkSize {
+			if err := next(); err != nil {
+				return n, err
+			}
+		}
+	}
+}
+
+// mergePartialWriteErrors adds the points dropped by err to those of the
+// partial write error of the chunks written before, if any, which keeps its
+// reason. The dropped points themselves are not kept, as they refer to the
+// chunks they were parsed from.
+func mergePartialWriteErrors(partial *tsdb.PartialWriteError, err tsdb.PartialWriteError) *tsdb.PartialWriteError {
+	err.Points = nil
+	if partial == nil {
+		return &err
+	}
+	partial.Dropped += err.Dropped
+	partial.DroppedKeys = append(partial.DroppedKeys, err.DroppedKeys...)
+	return partial
+}
+
+// readError wraps an error reading the body of a request.
+func readError(err error) error {
+	if errors.Is(err, io2.ErrReadLimitExceeded) {
+		err = ErrMaxBatchSizeExceeded
+	}
+
+	code := errors2.EInternal
+	if errors.Is(err, ErrMaxBatchSizeExceeded) {
+		code = errors2.ETooLarge
+	} else if errors.Is(err, gzip.ErrHeader) || errors.Is(err, gzip.ErrChecksum) {
+		code = errors2.EInvalid
+	}
+	return &errors2.Error{
+		Code: code,
+		Op:   opPointsWriter,
+		Msg:  msgUnableToReadData,
+		Err:  err,
+	}
+}
+
 func readAll(ctx context.Context, rc io.ReadCloser) (data []byte, err error) {
 	defer func() {
 		if cerr := rc.Close(); cerr != nil && err == nil {
@@ -0,0 +1,208 @@
+package points
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+func parseChunks(t *testing.T, body string, limit int64, chunkSize int) ([][]string, int, error) {
+	t.Helper()
+	return parseWith(t, (*Parser).ParseChunks, body, limit, chunkSize)
+}
+
+func parseAtomic(t *testing.T, body string, limit int64, chunkSize int) ([][]string, int, error) {
+	t.Helper()
+	return parseWith(t, (*Parser).ParseAtomic, body, limit, chunkSize)
+}
+
+type parseFunc func(pw *Parser, ctx context.Context, rc io.ReadCloser, chunkSize int, fn func(pts models.Points, size int) error) (int, error)
+
+func parseWith(t *testing.T, parse parseFunc, body string, limit int64, chunkSize int) ([][]string, int, error) {
+	t.Helper()
+
+	rc, err := BatchReadCloser(io.NopCloser(strings.NewReader(body)), "", limit)
+	require.NoError(t, err)
+
+	var chunks [][]string
+	n, err := parse(NewParser("ns"), context.Background(), rc, chunkSize, func(pts models.Points, size int) error {
+		var lines []string
+		for _, p := range pts {
+			lines = append(lines, p.String())
+		}
+		chunks = append(chunks, lines)
+		return nil
+	})
+	return chunks, n, err
+}
+
+func TestParser_ParseChunks(t *testing.T) {
+	body := "m f=1 1\nm f=2 2\n\nm f=3 3\n# comment\nm f=4 4"
+	chunks, n, err := parseChunks(t, body, 0, 8)
+	require.NoError(t, err)
+	require.Equal(t, len(body), n)
+	require.Equal(t, [][]string{{"m f=1 1"}, {"m f=2 2"}, {"m f=3 3"}, nil, {"m f=4 4"}}, chunks)
+
+	chunks, _, err = parseChunks(t, body, 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{"m f=1 1", "m f=2 2", "m f=3 3", "m f=4 4"}}, chunks)
+
+	// Lines longer than the read buffer are kept whole.
+	long := "m f=\"" + strings.Repeat("x", 10000) + "\" 1"
+	chunks, _, err = parseChunks(t, long+"\n"+long, 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{{long}, {long}}, chunks)
+
+	// An empty body is passed on once.
+	chunks, _, err = parseChunks(t, "", 0, 10)
+	require.NoError(t, err)
+	require.Equal(t, [][]string{nil}, chunks)
+}
+
+func TestParser_ParseChunks_Errors(t *testing.T) {
+	chunks, _, err := parseChunks(t, "m f=1 1\nm f=2 2\nm f= 3\nm f=4 4", 0, 8)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	require.Contains(t, err.Error(), "the lines before line 3 were written")
+	require.Equal(t, [][]string{{"m f=1 1"}, {"m f=2 2"}}, chunks)
+
+	// The truncated last line of a body exceeding its limit is not parsed.
+	chunks, _, err = parseChunks(t, "m f=1 1\nm f=22 2", 12, 0)
+	require.Equal(t, errors.ETooLarge, errors.ErrorCode(err))
+	require.Contains(t, err.Error(), ErrMaxBatchSizeExceeded.Error())
+	require.Empty(t, chunks)
+}
+
+func TestParser_ParseChunks_PartialWrite(t *testing.T) {
+	rc, err := BatchReadCloser(io.NopCloser(strings.NewReader("m f=1 1\nm f=2 2\nm f=3 3")), "", 0)
+	require.NoError(t, err)
+
+	// The points dropped from every chunk are reported once all are written.
+	var chunks int
+	_, err = NewParser("ns").ParseChunks(context.Background(), rc, 8, func(pts models.Points, size int) error {
+		chunks++
+		if chunks == 2 {
+			return nil
+		}
+		return tsdb.PartialWriteError{Reason: "bad points", Dropped: 1, DroppedKeys: [][]byte{pts[0].Key()}}
+	})
+	require.Equal(t, 3, chunks)
+	require.Equal(t, tsdb.PartialWriteError{
+		Reason:      "bad points",
+		Dropped:     2,
+		DroppedKeys: [][]byte{[]byte("m"), []byte("m")},
+	}, err)
+}
+
+func TestParser_ParseAtomic(t *testing.T) {
+	// The points of every chunk are written together once all are parsed.
+	body := "m f=1 1\nm f=2 2\n\nm f=3 3\n# comment\nm f=4 4"
+	chunks, n, err := parseAtomic(t, body, 0, 8)
+	require.NoError(t, err)
+	require.Equal(t, len(body), n)
+	require.Equal(t, [][]string{{"m f=1 1", "m f=2 2", "m f=3 3", "m f=4 4"}}, chunks)
+
+	// Nothing is written if a line of a later chunk cannot be parsed.
+	chunks, _, err = parseAtomic(t, "m f=1 1\nm f=2 2\nm f= 3\nm f=4 4", 0, 8)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	require.Contains(t, err.Error(), "unable to parse 'm f= 3'")
+	require.Empty(t, chunks)
+
+	// Neither is anything written if the body exceeds its limit after the
+	// first chunk.
+	chunks, _, err = parseAtomic(t, "m f=1 1\nm f=2 2\nm f=3 3", 20, 8)
+	require.Equal(t, errors.ETooLarge, errors.ErrorCode(err))
+	require.Empty(t, chunks)
+
+	// The points dropped by the writer are reported as it reports them.
+	rc, err := BatchReadCloser(io.NopCloser(strings.NewReader("m f=1 1\nm f=2 2\nm f=3 3")), "", 0)
+	require.NoError(t, err)
+	perr := tsdb.PartialWriteError{Reason: "bad points", Dropped: 1, DroppedKeys: [][]byte{[]byte("m")}}
+	var writes int
+	_, err = NewParser("ns").ParseAtomic(context.Background(), rc, 8, func(pts models.Points, size int) error {
+		writes++
+		require.Len(t, pts, 3)
+		return perr
+	})
+	require.Equal(t, 1, writes)
+	require.Equal(t, perr, err)
+}
+
+func TestParser_ParseLines(t *testing.T) {
+	body := "m,t=a f=1 1\nm,t=b f= 2\n\n# comment\nm,t=c f=3 3\nm,t=a f=1 1\nm,t=d f=4 4\nm,t=e f=\"x\" 5"
+	rc, err := BatchReadCloser(io.NopCloser(strings.NewReader(body)), "", 0)
+	require.NoError(t, err)
+
+	// The writer drops the points of "t=a", which are both rejected, and one
+	// of "t=e".
+	var written []string
+	n, err := NewParser("ns").ParseLines(context.Background(), rc, 24, func(pts models.Points, size int) error {
+		var dropped []tsdb.DroppedPoint
+		for _, p := range pts {
+			switch string(p.Key()) {
+			case "m,t=a":
+				dropped = append(dropped, tsdb.DroppedPoint{Point: p, Code: tsdb.DropRetention, Reason: "too old"})
+			case "m,t=e":
+				dropped = append(dropped, tsdb.DroppedPoint{Point: p, Code: tsdb.DropFieldTypeConflict, Reason: "conflict"})
+			default:
+				written = append(written, p.String())
+			}
+		}
+		if len(dropped) > 0 {
+			return tsdb.PartialWriteError{Reason: "dropped", Dropped: len(dropped), Points: dropped}
+		}
+		return nil
+	})
+	require.Equal(t, len(body), n)
+	require.Equal(t, []string{"m,t=c f=3 3", "m,t=d f=4 4"}, written)
+
+	var rerr *RejectedLinesError
+	require.ErrorAs(t, err, &rerr)
+	require.Equal(t, []RejectedLine{
+		{Line: 1, Code: "retention", Message: "too old"},
+		{Line: 2, Code: RejectParse, Message: "unable to parse 'm,t=b f= 2': missing field value"},
+		{Line: 6, Code: "retention", Message: "too old"},
+		{Line: 8, Code: "field_type_conflict", Message: "conflict"},
+	}, rerr.Lines)
+
+	// Bodies without rejected lines are written as usual.
+	rc, err = BatchReadCloser(io.NopCloser(strings.NewReader("m f=1 1\n")), "", 0)
+	require.NoError(t, err)
+	_, err = NewParser("ns").ParseLines(context.Background(), rc, 0, func(models.Points, int) error { return nil })
+	require.NoError(t, err)
+}
+
+func TestParser_OnParseError(t *testing.T) {
+	var failed []string
+	p := NewParser("ns")
+	p.OnParseError = func(line []byte, err error) {
+		failed = append(failed, string(line)+": "+err.Error())
+	}
+
+	// The lines of every chunk which cannot be parsed are reported, though
+	// the whole body is rejected.
+	rc, err := BatchReadCloser(io.NopCloser(strings.NewReader("m f=1 1\nm f= 2\nm f=3 3\nm,t f=4 4")), "", 0)
+	require.NoError(t, err)
+	_, err = p.ParseAtomic(context.Background(), rc, 8, func(models.Points, int) error {
+		require.Fail(t, "unexpected write")
+		return nil
+	})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+	require.Equal(t, []string{
+		"m f= 2: unable to parse 'm f= 2': missing field value",
+		"m,t f=4 4: unable to parse 'm,t f=4 4': missing tag value",
+	}, failed)
+
+	failed = nil
+	rc, err = BatchReadCloser(io.NopCloser(strings.NewReader("m f=1 1\nm f= 2\nm f=3 3")), "", 0)
+	require.NoError(t, err)
+	_, err = p.ParseLines(context.Background(), rc, 0, func(models.Points, int) error { return nil })
+	var rerr *RejectedLinesError
+	require.ErrorAs(t, err, &rerr)
+	require.Equal(t, []string{"m f= 2: unable to parse 'm f= 2': missing field value"}, failed)
+}
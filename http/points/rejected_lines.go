@@ -0,0 +1,141 @@
+package points
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// PartialParam is the query parameter of the write requests whose lines are
+// written or rejected on their own, as described by ParseLines.
+const PartialParam = "partial"
+
+// AtomicParam is the query parameter of the write requests whose points are
+// written only once the whole body is parsed, as described by ParseAtomic.
+const AtomicParam = "atomic"
+
+// PartialWriteRequested returns whether the query of a write request asks
+// for the lines which cannot be written to be rejected on their own.
+func PartialWriteRequested(qp url.Values) (bool, error) {
+	return boolParam(qp, PartialParam)
+}
+
+// AtomicWriteRequested returns whether the query of a write request asks for
+// nothing to be written if a line cannot be parsed. A write cannot be both
+// atomic and partial.
+func AtomicWriteRequested(qp url.Values) (bool, error) {
+	atomic, err := boolParam(qp, AtomicParam)
+	if err != nil || !atomic {
+		return false, err
+	}
+	if partial, _ := boolParam(qp, PartialParam); partial {
+		return false, &errors2.Error{
+			Code: errors2.EInvalid,
+			Op:   opPointsWriter,
+			Msg:  fmt.Sprintf("the %s and %s parameters cannot both be true", AtomicParam, PartialParam),
+		}
+	}
+	return true, nil
+}
+
+// boolParam returns the value of the boolean query parameter name, which is
+// false if not given.
+func boolParam(qp url.Values, name string) (bool, error) {
+	v := qp.Get(name)
+	if v == "" {
+		return false, nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, &errors2.Error{
+			Code: errors2.EInvalid,
+			Op:   opPointsWriter,
+			Msg:  fmt.Sprintf("invalid %s parameter %q; must be true or false", name, v),
+		}
+	}
+	return b, nil
+}
+
+// RejectParse is the code of lines which could not be parsed. The lines whose
+// points were dropped by the writer have the tsdb.DropCode of the point.
+const RejectParse = "parse"
+
+// RejectedLine is a line of a write request which was not written.
+type RejectedLine struct {
+	Line    int    `json:"line"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// RejectedLinesError is returned by ParseLines when lines of the body were
+// not written, once the others are.
+type RejectedLinesError struct {
+	Lines []RejectedLine
+}
+
+func (e *RejectedLinesError) Error() string {
+	return fmt.Sprintf("partial write: %d lines rejected", len(e.Lines))
+}
+
+// WriteRejectedLines writes the response to a write request whose rejected
+// lines are reported by err. It is the usual error response with the
+// unprocessable entity code, with the rejected lines added to it.
+func WriteRejectedLines(ctx context.Context, w http.ResponseWriter, err *RejectedLinesError) {
+	code := errors2.EUnprocessableEntity
+	w.Header().Set(kithttp.PlatformErrorCodeHeader, code)
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(kithttp.ErrorCodeToStatusCode(ctx, code))
+	b, _ := json.Marshal(struct {
+		Code     string         `json:"code"`
+		Message  string         `json:"message"`
+		Rejected []RejectedLine `json:"rejected"`
+	}{
+		Code:     code,
+		Message:  err.Error(),
+		Rejected: err.Lines,
+	})
+	_, _ = w.Write(b)
+}
+
+// pointID identifies the points of a chunk by what the storage engine
+// identifies them by.
+type pointID struct {
+	key  string
+	time int64
+}
+
+// rejectedPoints returns the lines of the points dropped by err, which were
+// parsed from the given lines. A point is matched by its series key and time,
+// and each line is rejected for at most one dropped point.
+func rejectedPoints(err tsdb.PartialWriteError, points models.Points, lines []int) []RejectedLine {
+	byID := make(map[pointID][]int, len(err.Points))
+	for _, d := range err.Points {
+		byID[pointID{key: string(d.Point.Key()), time: d.Point.UnixNano()}] = nil
+	}
+	for i, p := range points {
+		id := pointID{key: string(p.Key()), time: p.UnixNano()}
+		if ls, ok := byID[id]; ok {
+			byID[id] = append(ls, lines[i])
+		}
+	}
+
+	rejected := make([]RejectedLine, 0, len(err.Points))
+	for _, d := range err.Points {
+		id := pointID{key: string(d.Point.Key()), time: d.Point.UnixNano()}
+		ls := byID[id]
+		if len(ls) == 0 {
+			continue
+		}
+		byID[id] = ls[1:]
+		rejected = append(rejected, RejectedLine{Line: ls[0], Code: string(d.Code), Message: d.Reason})
+	}
+	return rejected
+}
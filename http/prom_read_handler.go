@@ -0,0 +1,272 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/influxdata/httprouter"
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/http/points"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/prometheus"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	prefixPromRead = "/api/v2/prom/read"
+
+	opPromReadHandler = "http/promReadHandler"
+)
+
+// PromReadBackend is all services and associated parameters required to
+// construct the PromReadHandler.
+type PromReadBackend struct {
+	errors.HTTPErrorHandler
+	log *zap.Logger
+
+	Store               reads.Store
+	BucketService       influxdb.BucketService
+	OrganizationService influxdb.OrganizationService
+	MaxBatchSizeBytes   int64
+}
+
+// NewPromReadBackend returns a new instance of PromReadBackend.
+func NewPromReadBackend(log *zap.Logger, b *APIBackend) *PromReadBackend {
+	return &PromReadBackend{
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		log:              log,
+
+		Store:               b.ReadsStore,
+		BucketService:       b.BucketService,
+		OrganizationService: b.OrganizationService,
+		MaxBatchSizeBytes:   b.MaxBatchSizeBytes,
+	}
+}
+
+// PromReadHandler answers Prometheus remote read requests with the series of
+// a bucket stored as described by the prometheus package.
+type PromReadHandler struct {
+	errors.HTTPErrorHandler
+	*httprouter.Router
+
+	log *zap.Logger
+
+	Store               reads.Store
+	BucketService       influxdb.BucketService
+	OrganizationService influxdb.OrganizationService
+	maxBatchSizeBytes   int64
+}
+
+// NewPromReadHandler creates a new handler at /api/v2/prom/read to receive
+// Prometheus remote read requests.
+func NewPromReadHandler(log *zap.Logger, b *PromReadBackend) *PromReadHandler {
+	h := &PromReadHandler{
+		HTTPErrorHandler: b.HTTPErrorHandler,
+		Router:           NewRouter(b.HTTPErrorHandler),
+		log:              log,
+
+		Store:               b.Store,
+		BucketService:       b.BucketService,
+		OrganizationService: b.OrganizationService,
+		maxBatchSizeBytes:   b.MaxBatchSizeBytes,
+	}
+
+	h.HandlerFunc(http.MethodPost, prefixPromRead, h.handlePromRead)
+	return h
+}
+
+func (h *PromReadHandler) handlePromRead(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "PromReadHandler")
+	defer span.Finish()
+
+	ctx := r.Context()
+	auth, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	org, err := queryOrganization(ctx, r, h.OrganizationService)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	span.LogKV("org_id", org.ID)
+
+	bucket, err := queryBucket(ctx, org.ID, r, h.BucketService)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	span.LogKV("bucket_id", bucket.ID)
+
+	if err := checkBucketReadPermissions(auth, org.ID, bucket.ID); err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	req, err := h.decodeReadRequest(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	source, err := anypb.New(h.Store.GetSource(uint64(org.ID), uint64(bucket.ID)))
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
+	if req.ResponseType() == prometheus.StreamedXORChunksResponse {
+		h.writeChunks(w, r, req, source)
+		return
+	}
+	h.writeSamples(w, r, req, source)
+}
+
+func (h *PromReadHandler) decodeReadRequest(r *http.Request) (*prometheus.ReadRequest, error) {
+	// A request body larger than it may decompress to is not read in full.
+	maxBytes := h.maxBatchSizeBytes
+	if maxBytes <= 0 {
+		maxBytes = prometheus.DefaultMaxDecodedBytes
+	}
+	body, err := points.BatchReadCloser(r.Body, r.Header.Get("Content-Encoding"), maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	b, err := readRequestBody(opPromReadHandler, body)
+	if err != nil {
+		return nil, err
+	}
+	req, err := prometheus.DecodeReadRequest(b, maxBytes)
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Op:   opPromReadHandler,
+			Msg:  "invalid remote read request",
+			Err:  err,
+		}
+	}
+	for i := range req.Queries {
+		if _, err := req.Queries[i].Predicate(); err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Op:   opPromReadHandler,
+				Msg:  "invalid remote read request",
+				Err:  err,
+			}
+		}
+	}
+	return req, nil
+}
+
+// readQuery returns the result set of a query, which may be nil.
+func (h *PromReadHandler) readQuery(r *http.Request, q *prometheus.Query, source *anypb.Any) (reads.ResultSet, error) {
+	req, err := q.ReadFilterRequest(source)
+	if err != nil {
+		return nil, err
+	}
+	rs, err := h.Store.ReadFilter(r.Context(), req)
+	if err != nil {
+		return nil, &errors.Error{
+			Code: errors.EInternal,
+			Op:   opPromReadHandler,
+			Msg:  "unable to read series",
+			Err:  err,
+		}
+	}
+	return rs, nil
+}
+
+// writeSamples answers with a single response holding the samples of every
+// query.
+func (h *PromReadHandler) writeSamples(w http.ResponseWriter, r *http.Request, req *prometheus.ReadRequest, source *anypb.Any) {
+	ctx := r.Context()
+	var resp prometheus.ReadResponse
+	for i := range req.Queries {
+		rs, err := h.readQuery(r, &req.Queries[i], source)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		}
+		res, err := prometheus.ReadQueryResult(rs)
+		if err != nil {
+			h.HandleHTTPError(ctx, &errors.Error{
+				Code: errors.EInternal,
+				Op:   opPromReadHandler,
+				Msg:  "unable to read series",
+				Err:  err,
+			}, w)
+			return
+		}
+		resp.Results = append(resp.Results, *res)
+	}
+
+	w.Header().Set("Content-Type", prometheus.SamplesContentType)
+	w.Header().Set("Content-Encoding", "snappy")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(resp.Encode()); err != nil {
+		h.log.Info("Failed to write remote read response", zap.Error(err))
+	}
+}
+
+// writeChunks streams the series of every query as they are read. Errors
+// occurring once the response has started cannot be reported to the client,
+// which detects the truncated stream.
+func (h *PromReadHandler) writeChunks(w http.ResponseWriter, r *http.Request, req *prometheus.ReadRequest, source *anypb.Any) {
+	ctx := r.Context()
+	cw := prometheus.NewChunkedWriter(w)
+	started := false
+	start := func() {
+		if !started {
+			w.Header().Set("Content-Type", prometheus.StreamedContentType)
+			w.WriteHeader(http.StatusOK)
+			started = true
+		}
+	}
+
+	for i := range req.Queries {
+		rs, err := h.readQuery(r, &req.Queries[i], source)
+		if err != nil && !started {
+			h.HandleHTTPError(ctx, err, w)
+			return
+		} else if err != nil {
+			h.log.Info("Failed to read remote read query", zap.Int("query", i), zap.Error(err))
+			return
+		}
+		start()
+		if err := cw.WriteResultSet(i, rs); err != nil {
+			h.log.Info("Failed to stream remote read response", zap.Int("query", i), zap.Error(err))
+			return
+		}
+	}
+	start()
+}
+
+// checkBucketReadPermissions checks an Authorizer for read permissions to a
+// specific Bucket.
+func checkBucketReadPermissions(auth influxdb.Authorizer, orgID, bucketID platform.ID) error {
+	p, err := influxdb.NewPermissionAtID(bucketID, influxdb.ReadAction, influxdb.BucketsResourceType, orgID)
+	if err != nil {
+		return &errors.Error{
+			Code: errors.EInternal,
+			Op:   opPromReadHandler,
+			Msg:  fmt.Sprintf("unable to create permission for bucket: %v", err),
+			Err:  err,
+		}
+	}
+	if pset, err := auth.PermissionSet(); err != nil || !pset.Allowed(*p) {
+		return &errors.Error{
+			Code: errors.EForbidden,
+			Op:   opPromReadHandler,
+			Msg:  "insufficient permissions for read",
+			Err:  err,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2"
+	httpmock "github.com/influxdata/influxdb/v2/http/mock"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/prometheus"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	influxtesting "github.com/influxdata/influxdb/v2/testing"
+	storage "github.com/influxdata/influxdb/v2/v1/services/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPromReadHandler_handlePromRead(t *testing.T) {
+	var got []*datatypes.ReadFilterRequest
+	store := &mock.ReadsStore{
+		ReadFilterFn: func(ctx context.Context, req *datatypes.ReadFilterRequest) (reads.ResultSet, error) {
+			got = append(got, req)
+			return nil, nil
+		},
+		GetSourceFn: func(orgID, bucketID uint64) proto.Message {
+			return &storage.ReadSource{OrgID: orgID, BucketID: bucketID}
+		},
+	}
+
+	req := &prometheus.ReadRequest{
+		Queries: []prometheus.Query{{
+			StartTimestampMs: 1000,
+			EndTimestampMs:   2000,
+			Matchers:         []prometheus.LabelMatcher{{Type: prometheus.MatchEqual, Name: "__name__", Value: "up"}},
+		}},
+	}
+	body := snappy.Encode(nil, req.Marshal())
+	const target = "http://localhost:8086/api/v2/prom/read?org=043e0780ee2b1000&bucket=04504b356e23b000"
+
+	handler := newTestPromReadHandler(t, store, influxdb.ReadAction)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", target, bytes.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, prometheus.SamplesContentType, w.Header().Get("Content-Type"))
+	b, err := snappy.Decode(nil, w.Body.Bytes())
+	require.NoError(t, err)
+	// A single, empty query result.
+	require.Equal(t, []byte{0x0a, 0x00}, b)
+
+	require.Len(t, got, 1)
+	require.Equal(t, int64(1e9), got[0].Range.Start)
+	require.Equal(t, int64(2001e6), got[0].Range.End)
+	var src storage.ReadSource
+	require.NoError(t, got[0].ReadSource.UnmarshalTo(&src))
+	require.Equal(t, uint64(influxtesting.MustIDBase16("04504b356e23b000")), src.BucketID)
+
+	req.AcceptedResponseTypes = []prometheus.ResponseType{prometheus.StreamedXORChunksResponse}
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", target, bytes.NewReader(snappy.Encode(nil, req.Marshal()))))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, prometheus.StreamedContentType, w.Header().Get("Content-Type"))
+	require.Empty(t, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", target, bytes.NewReader([]byte("not snappy"))))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	handler = newTestPromReadHandler(t, store, influxdb.WriteAction)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest("POST", target, bytes.NewReader(body)))
+	require.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func newTestPromReadHandler(t *testing.T, store reads.Store, action influxdb.Action) http.Handler {
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg("043e0780ee2b1000"), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket("043e0780ee2b1000", "04504b356e23b000"), nil
+	}
+	b := &APIBackend{
+		HTTPErrorHandler:    kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:              zaptest.NewLogger(t),
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		ReadsStore:          store,
+	}
+	auth := bucketWritePermission("043e0780ee2b1000", "04504b356e23b000")
+	auth.Permissions[0].Action = action
+	h := NewPromReadHandler(zaptest.NewLogger(t), NewPromReadBackend(zaptest.NewLogger(t), b))
+	return httpmock.NewAuthMiddlewareHandler(h, auth)
+}
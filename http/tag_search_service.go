@@ -0,0 +1,117 @@
+package http
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
+	"go.uber.org/zap"
+)
+
+// TagSearchHandler serves searches of the tag values of a bucket. It is
+// embedded in the bucket routes at /api/v2/buckets/:id/tags, which resolve
+// the bucket's organization.
+type TagSearchHandler struct {
+	chi.Router
+	api          *kithttp.API
+	log          *zap.Logger
+	tagSearchSvc influxdb.TagSearchService
+}
+
+// NewTagSearchHandler returns a new instance of TagSearchHandler.
+func NewTagSearchHandler(log *zap.Logger, ts influxdb.TagSearchService) *TagSearchHandler {
+	h := &TagSearchHandler{
+		api:          kithttp.NewAPI(kithttp.WithLog(log)),
+		log:          log,
+		tagSearchSvc: ts,
+	}
+
+	r := chi.NewRouter()
+	r.NotFound(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "path not found",
+		})
+	})
+	r.MethodNotAllowed(func(w http.ResponseWriter, r *http.Request) {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.EMethodNotAllowed,
+			Msg:  fmt.Sprintf("allow: %s", w.Header().Get("Allow")),
+		})
+	})
+	r.Use(
+		kithttp.SkipOptions,
+		middleware.StripSlashes,
+		kithttp.SetCORS,
+		middleware.Recoverer,
+		middleware.RequestID,
+		middleware.RealIP,
+	)
+
+	r.Get("/{key}/values", h.handleGetTagValues)
+
+	h.Router = r
+	return h
+}
+
+// handleGetTagValues is the HTTP handler for the GET /api/v2/buckets/:id/tags/:key/values route.
+func (h *TagSearchHandler) handleGetTagValues(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	bucketID, err := platform.IDFromString(chi.URLParam(r, "id"))
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+	orgID := kithttp.OrgIDFromContext(ctx)
+	if orgID == nil {
+		h.api.Err(w, r, &errors.Error{
+			Code: errors.ENotFound,
+			Msg:  "bucket not found",
+		})
+		return
+	}
+
+	opts, err := decodeTagSearchOptions(r)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	result, err := h.tagSearchSvc.SearchTagValues(ctx, *orgID, *bucketID, chi.URLParam(r, "key"), opts)
+	if err != nil {
+		h.api.Err(w, r, err)
+		return
+	}
+
+	h.api.Respond(w, r, http.StatusOK, result)
+}
+
+func decodeTagSearchOptions(r *http.Request) (influxdb.TagSearchOptions, error) {
+	qp := r.URL.Query()
+	opts := influxdb.TagSearchOptions{
+		Measurement: qp.Get("measurement"),
+		Prefix:      qp.Get("prefix"),
+		Regex:       qp.Get("regex"),
+		Fuzzy:       qp.Get("fuzzy"),
+		After:       qp.Get("after"),
+	}
+
+	if s := qp.Get("limit"); s != "" {
+		limit, err := strconv.Atoi(s)
+		if err != nil || limit < 1 || limit > influxdb.MaxTagSearchLimit {
+			return opts, &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  fmt.Sprintf("limit must be an integer between 1 and %d", influxdb.MaxTagSearchLimit),
+			}
+		}
+		opts.Limit = limit
+	}
+	return opts, nil
+}
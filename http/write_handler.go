@@ -1,24 +1,34 @@
 package http
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/influxdata/httprouter"
 	"github.com/influxdata/influxdb/v2"
 	pcontext "github.com/influxdata/influxdb/v2/context"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	"github.com/influxdata/influxdb/v2/http/points"
+	"github.com/influxdata/influxdb/v2/ingest"
+	io2 "github.com/influxdata/influxdb/v2/kit/io"
 	"github.com/influxdata/influxdb/v2/kit/platform"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/otlp"
+	"github.com/influxdata/influxdb/v2/prometheus"
 	"github.com/influxdata/influxdb/v2/storage"
 	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/opentracing/opentracing-go"
 	"go.uber.org/zap"
 )
 
@@ -32,6 +42,20 @@ type WriteBackend struct {
 	PointsWriter        storage.PointsWriter
 	BucketService       influxdb.BucketService
 	OrganizationService influxdb.OrganizationService
+	WriteLimiter        influxdb.WriteLimiter
+	// IngestMappingService finds the ingest mappings of JSON and CSV writes
+	// without checking permissions: the writer may use any mapping of the
+	// organization it writes to.
+	IngestMappingService influxdb.IngestMappingService
+	// WriteIdempotencyService remembers the writes made with an idempotency
+	// key. The Idempotency-Key header is ignored without it.
+	WriteIdempotencyService influxdb.WriteIdempotencyService
+	// WriteQueue queues the line protocol writes preferring an asynchronous
+	// response. They are written synchronously without it.
+	WriteQueue influxdb.WriteQueue
+	// DeadLetterWriter records the lines, records and series which cannot
+	// be parsed. The points dropped by the PointsWriter are recorded below it.
+	DeadLetterWriter influxdb.DeadLetterWriter
 }
 
 // NewWriteBackend returns a new instance of WriteBackend.
@@ -44,20 +68,32 @@ func NewWriteBackend(log *zap.Logger, b *APIBackend) *WriteBackend {
 		PointsWriter:        b.PointsWriter,
 		BucketService:       b.BucketService,
 		OrganizationService: b.OrganizationService,
+		WriteLimiter:        b.WriteLimiter,
+
+		IngestMappingService:    b.IngestMappingService,
+		WriteIdempotencyService: b.WriteIdempotencyService,
+		WriteQueue:              b.WriteQueue,
+		DeadLetterWriter:        b.DeadLetterWriter,
 	}
 }
 
 // WriteHandler receives line protocol and sends to a publish function.
 type WriteHandler struct {
 	errors.HTTPErrorHandler
-	BucketService       influxdb.BucketService
-	OrganizationService influxdb.OrganizationService
-	PointsWriter        storage.PointsWriter
-	EventRecorder       metric.EventRecorder
+	BucketService           influxdb.BucketService
+	OrganizationService     influxdb.OrganizationService
+	PointsWriter            storage.PointsWriter
+	EventRecorder           metric.EventRecorder
+	WriteLimiter            influxdb.WriteLimiter
+	IngestMappingService    influxdb.IngestMappingService
+	WriteIdempotencyService influxdb.WriteIdempotencyService
+	WriteQueue              influxdb.WriteQueue
+	DeadLetterWriter        influxdb.DeadLetterWriter
 
 	router            *httprouter.Router
 	log               *zap.Logger
 	maxBatchSizeBytes int64
+	chunkSizeBytes    int
 	// parserOptions     []models.ParserOption
 }
 
@@ -72,6 +108,15 @@ func WithMaxBatchSizeBytes(n int64) WriteHandlerOption {
 	}
 }
 
+// WithChunkSizeBytes configures the number of bytes of line protocol parsed
+// and written at once by the write handler. Atomic writes are only parsed in
+// chunks, and written once the whole body is.
+func WithChunkSizeBytes(n int) WriteHandlerOption {
+	return func(w *WriteHandler) {
+		w.chunkSizeBytes = n
+	}
+}
+
 //func WithParserOptions(opts ...models.ParserOption) WriteHandlerOption {
 //	return func(w *WriteHandler) {
 //		w.parserOptions = opts
@@ -85,23 +130,54 @@ func (*WriteHandler) Prefix() string {
 
 const (
 	prefixWrite          = "/api/v2/write"
+	prefixPromWrite      = "/api/v2/prom/write"
+	prefixOTLPMetrics    = "/api/v2/otlp/v1/metrics"
+	prefixWriteNDJSON    = prefixWrite + "/ndjson"
+	prefixWriteCSV       = prefixWrite + "/csv"
+	prefixWriteBatches   = prefixWrite + "/batches"
+	ingestMappingHeader  = "Ingest-Mapping"
+	idempotencyKeyHeader = "Idempotency-Key"
+	replayedHeader       = "Idempotent-Replayed"
+	preferHeader         = "Prefer"
+	preferenceApplied    = "Preference-Applied"
+	respondAsync         = "respond-async"
+	otlpContentType      = "application/x-protobuf"
 	msgInvalidGzipHeader = "gzipped HTTP body contains an invalid header"
 	msgInvalidPrecision  = "invalid precision; valid precision units are ns, us, ms, and s"
 
 	opWriteHandler = "http/writeHandler"
 )
 
-// NewWriteHandler creates a new handler at /api/v2/write to receive line protocol.
+// NewWriteHandler creates a new handler at /api/v2/write to receive line protocol,
+// at /api/v2/write/ndjson and /api/v2/write/csv to receive JSON and CSV
+// documents, at /api/v2/prom/write to receive Prometheus remote writes and at
+// /api/v2/otlp/v1/metrics to receive OTLP metrics. A write with an
+// Idempotency-Key header which is retried within the window of the
+// WriteIdempotencyService is answered with the response to the first write.
+//
+// With a WriteQueue, a line protocol write with a "Prefer: respond-async"
+// header is queued and answered with 202 Accepted and the ID of its batch,
+// whose status is served at /api/v2/write/batches/:id.
+//
+// With a DeadLetterWriter, the line protocol lines, JSON and CSV records and
+// Prometheus series which cannot be parsed are recorded in the dead-letter
+// bucket of the bucket written to.
 func NewWriteHandler(log *zap.Logger, b *WriteBackend, opts ...WriteHandlerOption) *WriteHandler {
 	h := &WriteHandler{
-		HTTPErrorHandler:    b.HTTPErrorHandler,
-		PointsWriter:        b.PointsWriter,
-		BucketService:       b.BucketService,
-		OrganizationService: b.OrganizationService,
-		EventRecorder:       b.WriteEventRecorder,
-
-		router: NewRouter(b.HTTPErrorHandler),
-		log:    log,
+		HTTPErrorHandler:        b.HTTPErrorHandler,
+		PointsWriter:            b.PointsWriter,
+		BucketService:           b.BucketService,
+		OrganizationService:     b.OrganizationService,
+		EventRecorder:           b.WriteEventRecorder,
+		WriteLimiter:            b.WriteLimiter,
+		IngestMappingService:    b.IngestMappingService,
+		WriteIdempotencyService: b.WriteIdempotencyService,
+		WriteQueue:              b.WriteQueue,
+		DeadLetterWriter:        b.DeadLetterWriter,
+
+		router:         NewRouter(b.HTTPErrorHandler),
+		log:            log,
+		chunkSizeBytes: points.DefaultChunkSize,
 	}
 
 	for _, opt := range opts {
@@ -109,6 +185,11 @@ func NewWriteHandler(log *zap.Logger, b *WriteBackend, opts ...WriteHandlerOptio
 	}
 
 	h.router.HandlerFunc(http.MethodPost, prefixWrite, h.handleWrite)
+	h.router.HandlerFunc(http.MethodPost, prefixWriteNDJSON, h.handleIngestWrite(influxdb.IngestFormatNDJSON))
+	h.router.HandlerFunc(http.MethodPost, prefixWriteCSV, h.handleIngestWrite(influxdb.IngestFormatCSV))
+	h.router.HandlerFunc(http.MethodGet, prefixWriteBatches+"/:id", h.handleGetWriteBatch)
+	h.router.HandlerFunc(http.MethodPost, prefixPromWrite, h.handlePromWrite)
+	h.router.HandlerFunc(http.MethodPost, prefixOTLPMetrics, h.handleOTLPMetrics)
 	return h
 }
 
@@ -139,6 +220,205 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 	span, r := tracing.ExtractFromHTTPRequest(r, "WriteHandler")
 	defer span.Finish()
 
+	h.write(w, r, span, func(ctx context.Context, req *writeRequest, orgID, bucketID platform.ID, write writePointsFunc) error {
+		// TODO: Backport?
+		//opts := append([]models.ParserOption{}, h.parserOptions...)
+		//opts = append(opts, models.WithParserPrecision(req.Precision))
+		parser := points.NewParser(req.Precision)
+		reject, flush := h.collectDeadLetters(ctx, orgID, bucketID)
+		defer flush()
+		if reject != nil {
+			parser.OnParseError = func(line []byte, err error) { reject(string(line), err) }
+		}
+
+		parse := parser.ParseChunks
+		if req.Partial {
+			parse = parser.ParseLines
+		} else if req.Atomic {
+			parse = parser.ParseAtomic
+		}
+		_, err := parse(ctx, req.Body, h.chunkSizeBytes, write)
+		return err
+	})
+}
+
+// collectDeadLetters returns a function collecting the lines of a write to a
+// bucket which cannot be parsed, and a function recording them in the
+// dead-letter bucket of the bucket once the write is done. Without a
+// DeadLetterWriter, the lines are not collected: reject is nil.
+func (h *WriteHandler) collectDeadLetters(ctx context.Context, orgID, bucketID platform.ID) (reject func(line string, err error), flush func()) {
+	if h.DeadLetterWriter == nil {
+		return nil, func() {}
+	}
+	var letters []influxdb.DeadLetter
+	reject = func(line string, err error) {
+		letters = append(letters, influxdb.DeadLetter{Line: line, Code: points.RejectParse, Reason: err.Error()})
+	}
+	flush = func() { h.DeadLetterWriter.WriteDeadLetters(ctx, orgID, bucketID, letters) }
+	return reject, flush
+}
+
+// handleIngestWrite returns the handler receiving documents of format, whose
+// records are stored as declared by an ingest mapping as described by the
+// ingest package. The mapping is either the stored mapping of the
+// organization named or identified by the mapping parameter, or the spec
+// given as JSON by the Ingest-Mapping header.
+func (h *WriteHandler) handleIngestWrite(format influxdb.IngestFormat) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		span, r := tracing.ExtractFromHTTPRequest(r, "IngestWriteHandler")
+		defer span.Finish()
+
+		h.write(w, r, span, func(ctx context.Context, req *writeRequest, orgID, bucketID platform.ID, write writePointsFunc) error {
+			spec, err := h.ingestSpec(ctx, r, orgID)
+			if err != nil {
+				req.Body.Close()
+				return err
+			}
+
+			reject, flush := h.collectDeadLetters(ctx, orgID, bucketID)
+			defer flush()
+
+			body := &bodyReader{rc: req.Body}
+			defer body.close()
+			_, err = ingest.Convert(body, format, spec, time.Now().UTC(), reject, write)
+			if body.err != nil {
+				return readBodyError(opWriteHandler, body.err)
+			}
+			return err
+		})
+	}
+}
+
+// ingestSpec returns the spec of the ingest mapping of a write request to an
+// organization.
+func (h *WriteHandler) ingestSpec(ctx context.Context, r *http.Request, orgID platform.ID) (*influxdb.IngestSpec, error) {
+	mapping := r.URL.Query().Get("mapping")
+	header := r.Header.Get(ingestMappingHeader)
+	if (mapping == "") == (header == "") {
+		return nil, &errors.Error{
+			Code: errors.EInvalid,
+			Op:   opWriteHandler,
+			Msg:  fmt.Sprintf("exactly one of the mapping parameter and the %s header is required", ingestMappingHeader),
+		}
+	}
+
+	if header != "" {
+		var spec influxdb.IngestSpec
+		if err := json.Unmarshal([]byte(header), &spec); err != nil {
+			return nil, &errors.Error{
+				Code: errors.EInvalid,
+				Op:   opWriteHandler,
+				Msg:  fmt.Sprintf("invalid %s header", ingestMappingHeader),
+				Err:  err,
+			}
+		}
+		return &spec, nil
+	}
+
+	if id, err := platform.IDFromString(mapping); err == nil {
+		m, err := h.IngestMappingService.FindIngestMappingByID(ctx, *id)
+		if err != nil && errors.ErrorCode(err) != errors.ENotFound {
+			return nil, err
+		} else if err == nil && m.OrgID == orgID {
+			return &m.Spec, nil
+		}
+	}
+
+	ms, err := h.IngestMappingService.FindIngestMappings(ctx, influxdb.IngestMappingFilter{
+		OrgID: orgID,
+		Name:  &mapping,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(ms) == 0 {
+		return nil, influxdb.ErrIngestMappingNotFound
+	}
+	return &ms[0].Spec, nil
+}
+
+// handlePromWrite receives a Prometheus remote write request and stores its
+// series as described by the prometheus package.
+func (h *WriteHandler) handlePromWrite(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "PromWriteHandler")
+	defer span.Finish()
+
+	h.write(w, r, span, func(ctx context.Context, req *writeRequest, orgID, bucketID platform.ID, write writePointsFunc) error {
+		b, err := readRequestBody(opWriteHandler, req.Body)
+		if err != nil {
+			return err
+		}
+		wr, err := prometheus.DecodeWriteRequest(b, h.maxBatchSizeBytes)
+		if err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Op:   opWriteHandler,
+				Msg:  "invalid remote write request",
+				Err:  err,
+			}
+		}
+		reject, flush := h.collectDeadLetters(ctx, orgID, bucketID)
+		defer flush()
+		pts, err := wr.Points(reject)
+		if err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Op:   opWriteHandler,
+				Msg:  "invalid remote write request",
+				Err:  err,
+			}
+		}
+		return write(pts, len(b))
+	})
+}
+
+// handleOTLPMetrics receives an OTLP/HTTP metrics export request encoded as
+// protobuf and stores its metrics as described by the otlp package.
+func (h *WriteHandler) handleOTLPMetrics(w http.ResponseWriter, r *http.Request) {
+	span, r := tracing.ExtractFromHTTPRequest(r, "OTLPMetricsHandler")
+	defer span.Finish()
+
+	if ct := r.Header.Get("Content-Type"); ct != otlpContentType {
+		h.HandleHTTPError(r.Context(), &errors.Error{
+			Code: errors.EInvalid,
+			Op:   opWriteHandler,
+			Msg:  fmt.Sprintf("unsupported content type %q; OTLP metrics must be encoded as %s", ct, otlpContentType),
+		}, w)
+		return
+	}
+
+	h.write(w, r, span, func(ctx context.Context, req *writeRequest, orgID, bucketID platform.ID, write writePointsFunc) error {
+		b, err := readRequestBody(opWriteHandler, req.Body)
+		if err != nil {
+			return err
+		}
+		pts, err := otlp.MetricsPoints(b)
+		if err != nil {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Op:   opWriteHandler,
+				Msg:  "invalid OTLP metrics request",
+				Err:  err,
+			}
+		}
+		return write(pts, len(b))
+	})
+}
+
+// decodePointsFunc decodes the points of the body of a write request to a
+// bucket, passing them to write in one or more batches.
+type decodePointsFunc func(ctx context.Context, req *writeRequest, orgID, bucketID platform.ID, write writePointsFunc) error
+
+// writePointsFunc writes a batch of points decoded from size bytes of the body
+// of a write request. It returns a tsdb.PartialWriteError if points of the
+// batch were dropped.
+type writePointsFunc func(pts models.Points, size int) error
+
+// write writes the points decoded from the request by decode to the bucket of
+// the request, once the authorizer of the request is allowed to. The points
+// dropped from every batch are reported together once all are written, as
+// decode reports them.
+func (h *WriteHandler) write(w http.ResponseWriter, r *http.Request, span opentracing.Span, decode decodePointsFunc) {
 	ctx := r.Context()
 	auth, err := pcontext.GetAuthorizer(ctx)
 	if err != nil {
@@ -152,6 +432,12 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	key, err := h.idempotencyKey(r)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+
 	org, err := queryOrganization(ctx, r, h.OrganizationService)
 	if err != nil {
 		h.HandleHTTPError(ctx, err, w)
@@ -161,10 +447,16 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 
 	sw := kithttp.NewStatusResponseWriter(w)
 	recorder := NewWriteUsageRecorder(sw, h.EventRecorder)
-	var requestBytes int
+	var (
+		requestBytes int
+		replayed     bool
+	)
 	defer func() {
 		// Close around the requestBytes variable to placate the linter.
-		recorder.Record(ctx, requestBytes, org.ID, r.URL.Path)
+		// Replayed writes wrote nothing, so they are not recorded.
+		if !replayed {
+			recorder.Record(ctx, requestBytes, org.ID, r.URL.Path)
+		}
 	}()
 
 	bucket, err := h.findBucket(ctx, org.ID, req.Bucket)
@@ -179,37 +471,350 @@ func (h *WriteHandler) handleWrite(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// TODO: Backport?
-	//opts := append([]models.ParserOption{}, h.parserOptions...)
-	//opts = append(opts, models.WithParserPrecision(req.Precision))
-	parsed, err := points.NewParser(req.Precision).Parse(ctx, org.ID, bucket.ID, req.Body)
+	// The response of a write with an idempotency key is recorded, so that
+	// retrying the write returns it again without writing. written reports
+	// whether points of the write were stored, even if it then failed.
+	var (
+		rw      http.ResponseWriter = sw
+		written bool
+	)
+	if key != "" {
+		result, err := h.WriteIdempotencyService.BeginWrite(ctx, org.ID, key)
+		if err != nil {
+			h.HandleHTTPError(ctx, err, sw)
+			return
+		}
+		if result != nil {
+			replayed = true
+			writeReplayedResult(w, result)
+			return
+		}
+		rec := &resultRecorder{ResponseWriter: sw}
+		defer func() { h.completeWrite(org.ID, key, rec, written) }()
+		rw = rec
+	}
+
+	// The points of an asynchronous write are queued as a batch, in as many
+	// parts as they are decoded in.
+	var (
+		batchID platform.ID
+		queued  bool
+	)
+	async := h.respondAsync(r)
+	if async {
+		batchID = h.WriteQueue.NewWriteBatchID()
+	}
+
+	writePoints := func(pts models.Points, size int) error {
+		requestBytes += size
+		if h.WriteLimiter != nil {
+			if err := h.WriteLimiter.AllowWrite(ctx, org.ID, len(pts), size); err != nil {
+				return err
+			}
+		}
+
+		if async {
+			if err := h.WriteQueue.EnqueuePoints(ctx, batchID, org.ID, bucket.ID, pts); err != nil {
+				return err
+			}
+			queued, written = true, true
+			return nil
+		}
+
+		err := h.PointsWriter.WritePoints(ctx, org.ID, bucket.ID, pts)
+		if _, ok := err.(tsdb.PartialWriteError); ok {
+			written = true
+			return err
+		} else if err != nil {
+			return &errors.Error{
+				Code: errors.EInternal,
+				Op:   opWriteHandler,
+				Msg:  "unexpected error writing points to database",
+				Err:  err,
+			}
+		}
+		written = true
+		return nil
+	}
+
+	err = decode(ctx, req, org.ID, bucket.ID, writePoints)
+	if queued {
+		// The batch is reported even if the write fails, since the points
+		// queued before the failure are written.
+		rw.Header().Set("Location", prefixWriteBatches+"/"+batchID.String())
+		rw.Header().Set(preferenceApplied, respondAsync)
+	}
+	var rejected *points.RejectedLinesError
+	if stderrors.As(err, &rejected) {
+		points.WriteRejectedLines(ctx, rw, rejected)
+		return
+	}
+	if perr, ok := err.(tsdb.PartialWriteError); ok {
+		err = &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Op:   opWriteHandler,
+			Msg:  "failure writing points to database",
+			Err:  perr,
+		}
+	}
 	if err != nil {
-		h.HandleHTTPError(ctx, err, sw)
+		h.HandleHTTPError(ctx, err, rw)
 		return
 	}
-	requestBytes = parsed.RawSize
 
-	if err := h.PointsWriter.WritePoints(ctx, org.ID, bucket.ID, parsed.Points); err != nil {
-		if partialErr, ok := err.(tsdb.PartialWriteError); ok {
-			h.HandleHTTPError(ctx, &errors.Error{
-				Code: errors.EUnprocessableEntity,
-				Op:   opWriteHandler,
-				Msg:  "failure writing points to database",
-				Err:  partialErr,
-			}, sw)
-			return
+	if queued {
+		if err := encodeResponse(ctx, rw, http.StatusAccepted, newWriteBatchResponse(batchID)); err != nil {
+			logEncodingError(h.log, r, err)
+		}
+		return
+	}
+	if r.URL.Path == prefixOTLPMetrics {
+		// An empty ExportMetricsServiceResponse reports full success.
+		rw.Header().Set("Content-Type", otlpContentType)
+		rw.WriteHeader(http.StatusOK)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// respondAsync reports whether a write request is to be queued: it must be a
+// line protocol write preferring an asynchronous response, as by RFC 7240,
+// to a handler with a WriteQueue.
+func (h *WriteHandler) respondAsync(r *http.Request) bool {
+	if h.WriteQueue == nil || r.URL.Path != prefixWrite {
+		return false
+	}
+	for _, v := range r.Header.Values(preferHeader) {
+		for _, pref := range strings.Split(v, ",") {
+			pref, _, _ = strings.Cut(pref, ";")
+			if strings.EqualFold(strings.TrimSpace(pref), respondAsync) {
+				return true
+			}
 		}
+	}
+	return false
+}
+
+// writeBatchResponse is the response to an asynchronous write.
+type writeBatchResponse struct {
+	BatchID platform.ID       `json:"batchID"`
+	Links   map[string]string `json:"links"`
+}
+
+func newWriteBatchResponse(id platform.ID) *writeBatchResponse {
+	return &writeBatchResponse{
+		BatchID: id,
+		Links: map[string]string{
+			"self": prefixWriteBatches + "/" + id.String(),
+		},
+	}
+}
+
+// handleGetWriteBatch serves the status of a batch queued by an asynchronous
+// write. It is only served to authorizers allowed to write to the bucket of
+// the batch.
+func (h *WriteHandler) handleGetWriteBatch(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	auth, err := pcontext.GetAuthorizer(ctx)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
 
+	var id platform.ID
+	if err := id.DecodeFromString(httprouter.ParamsFromContext(ctx).ByName("id")); err != nil {
 		h.HandleHTTPError(ctx, &errors.Error{
-			Code: errors.EInternal,
+			Code: errors.EInvalid,
 			Op:   opWriteHandler,
-			Msg:  "unexpected error writing points to database",
+			Msg:  "invalid write batch ID",
 			Err:  err,
-		}, sw)
+		}, w)
+		return
+	}
+
+	if h.WriteQueue == nil {
+		h.HandleHTTPError(ctx, influxdb.ErrWriteBatchNotFound, w)
+		return
+	}
+	b, err := h.WriteQueue.FindWriteBatchByID(ctx, id)
+	if err != nil {
+		h.HandleHTTPError(ctx, err, w)
+		return
+	}
+	// Batches written by others are not disclosed.
+	if err := checkBucketWritePermissions(auth, b.OrgID, b.BucketID); err != nil {
+		h.HandleHTTPError(ctx, influxdb.ErrWriteBatchNotFound, w)
 		return
 	}
 
-	sw.WriteHeader(http.StatusNoContent)
+	if err := encodeResponse(ctx, w, http.StatusOK, b); err != nil {
+		logEncodingError(h.log, r, err)
+	}
+}
+
+// completeWrite records the response recorded by rec as the result of the
+// write made with key. The key of a write failing with a server error or
+// limited by a quota before anything was written is released instead, so that
+// the write can be retried. A write which failed after some of its points were
+// written, such as a partial write stopping after a chunk, is recorded, so
+// that retrying it does not write them again.
+func (h *WriteHandler) completeWrite(orgID platform.ID, key string, rec *resultRecorder, written bool) {
+	// The request may be canceled, yet its points written, so the result is
+	// recorded regardless.
+	ctx := context.Background()
+	var err error
+	code := rec.statusCode
+	if code == 0 || (!written && (code >= http.StatusInternalServerError || code == http.StatusTooManyRequests)) {
+		err = h.WriteIdempotencyService.ReleaseWrite(ctx, orgID, key)
+	} else {
+		err = h.WriteIdempotencyService.CompleteWrite(ctx, orgID, key, rec.result())
+	}
+	if err != nil {
+		h.log.Error("Failed to record the result of a write with an idempotency key",
+			zap.String("org_id", orgID.String()), zap.String("idempotency_key", key), zap.Error(err))
+	}
+}
+
+// idempotencyKey returns the idempotency key of a write request, which is
+// empty if it has none or the handler has no WriteIdempotencyService.
+func (h *WriteHandler) idempotencyKey(r *http.Request) (string, error) {
+	key := r.Header.Get(idempotencyKeyHeader)
+	if key == "" || h.WriteIdempotencyService == nil {
+		return "", nil
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return "", &errors.Error{
+			Code: errors.EInvalid,
+			Op:   opWriteHandler,
+			Msg:  fmt.Sprintf("%s header must not be longer than %d bytes", idempotencyKeyHeader, maxIdempotencyKeyLength),
+		}
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < ' ' || key[i] > '~' {
+			return "", &errors.Error{
+				Code: errors.EInvalid,
+				Op:   opWriteHandler,
+				Msg:  fmt.Sprintf("%s header must only contain printable ASCII characters", idempotencyKeyHeader),
+			}
+		}
+	}
+	return key, nil
+}
+
+// maxIdempotencyKeyLength is the maximum length of an idempotency key.
+const maxIdempotencyKeyLength = 255
+
+// replayedHeaders are the headers of the response to a write which are
+// replayed with it.
+var replayedHeaders = []string{"Content-Type", kithttp.PlatformErrorCodeHeader, "Location", preferenceApplied}
+
+// resultRecorder records the response to a write made with an idempotency
+// key while writing it.
+type resultRecorder struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (w *resultRecorder) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+	w.ResponseWriter.WriteHeader(statusCode)
+}
+
+func (w *resultRecorder) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// result returns the recorded response.
+func (w *resultRecorder) result() *influxdb.WriteResult {
+	r := &influxdb.WriteResult{StatusCode: w.statusCode}
+	for _, k := range replayedHeaders {
+		if v := w.Header().Get(k); v != "" {
+			if r.Header == nil {
+				r.Header = make(http.Header)
+			}
+			r.Header.Set(k, v)
+		}
+	}
+	if w.body.Len() > 0 {
+		r.Body = w.body.Bytes()
+	}
+	return r
+}
+
+// writeReplayedResult writes the recorded response to a write again.
+func writeReplayedResult(w http.ResponseWriter, r *influxdb.WriteResult) {
+	for k, v := range r.Header {
+		w.Header()[k] = v
+	}
+	w.Header().Set(replayedHeader, "true")
+	w.WriteHeader(r.StatusCode)
+	_, _ = w.Write(r.Body)
+}
+
+// readRequestBody reads and closes the body of a request handled by op.
+func readRequestBody(op string, rc io.ReadCloser) ([]byte, error) {
+	b, err := io.ReadAll(rc)
+	if cerr := rc.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, readBodyError(op, err)
+	}
+	return b, nil
+}
+
+// readBodyError wraps an error reading the body of a request handled by op.
+func readBodyError(op string, err error) error {
+	code := errors.EInternal
+	if stderrors.Is(err, io2.ErrReadLimitExceeded) {
+		code = errors.ETooLarge
+	} else if stderrors.Is(err, gzip.ErrHeader) || stderrors.Is(err, gzip.ErrChecksum) {
+		code = errors.EInvalid
+	}
+	return &errors.Error{
+		Code: code,
+		Op:   op,
+		Msg:  "unable to read data",
+		Err:  err,
+	}
+}
+
+// bodyReader reads the body of a request, recording the first error reading
+// it. The body is closed once read, so that a body exceeding its size limit
+// fails the last read rather than ending early.
+type bodyReader struct {
+	rc     io.ReadCloser
+	closed bool
+	err    error
+}
+
+func (r *bodyReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	if err == io.EOF && !r.closed {
+		if cerr := r.close(); cerr != nil {
+			err = cerr
+		}
+	}
+	if err != nil && err != io.EOF && r.err == nil {
+		r.err = err
+	}
+	return n, err
+}
+
+// close closes the body unless it was already.
+func (r *bodyReader) close() error {
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.rc.Close()
 }
 
 // checkBucketWritePermissions checks an Authorizer for write permissions to a
@@ -241,6 +846,8 @@ type writeRequest struct {
 	Org       string
 	Bucket    string
 	Precision string
+	Partial   bool
+	Atomic    bool
 	Body      io.ReadCloser
 }
 
@@ -270,6 +877,15 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 		}
 	}
 
+	partial, err := points.PartialWriteRequested(qp)
+	if err != nil {
+		return nil, err
+	}
+	atomic, err := points.AtomicWriteRequested(qp)
+	if err != nil {
+		return nil, err
+	}
+
 	encoding := r.Header.Get("Content-Encoding")
 	body, err := points.BatchReadCloser(r.Body, encoding, maxBatchSizeBytes)
 	if err != nil {
@@ -280,6 +896,8 @@ func decodeWriteRequest(ctx context.Context, r *http.Request, maxBatchSizeBytes
 		Bucket:    qp.Get("bucket"),
 		Org:       qp.Get("org"),
 		Precision: precision,
+		Partial:   partial,
+		Atomic:    atomic,
 		Body:      body,
 	}, nil
 }
@@ -1,15 +1,20 @@
 package http
 
 import (
+	"bytes"
 	"compress/gzip"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/golang/snappy"
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/http/metric"
 	httpmock "github.com/influxdata/influxdb/v2/http/mock"
@@ -17,10 +22,13 @@ import (
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	kithttp "github.com/influxdata/influxdb/v2/kit/transport/http"
 	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/prometheus"
 	influxtesting "github.com/influxdata/influxdb/v2/testing"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap/zaptest"
+	"google.golang.org/protobuf/encoding/protowire"
 )
 
 func TestWriteService_WriteTo(t *testing.T) {
@@ -420,6 +428,439 @@ func TestWriteHandler_handleWrite(t *testing.T) {
 	}
 }
 
+type writeLimiterFunc func(ctx context.Context, orgID platform.ID, points, bytes int) error
+
+func (f writeLimiterFunc) AllowWrite(ctx context.Context, orgID platform.ID, points, bytes int) error {
+	return f(ctx, orgID, points, bytes)
+}
+
+func TestWriteHandler_handleWrite_Quota(t *testing.T) {
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg("043e0780ee2b1000"), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket("043e0780ee2b1000", "04504b356e23b000"), nil
+	}
+
+	var points, bytes int
+	b := &APIBackend{
+		HTTPErrorHandler:    kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:              zaptest.NewLogger(t),
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        &mock.PointsWriter{},
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+		WriteLimiter: writeLimiterFunc(func(_ context.Context, orgID platform.ID, p, n int) error {
+			points, bytes = p, n
+			return influxdb.NewQuotaExceededError("test", "maxWritePointsPerSecond", 1, 2500*time.Millisecond)
+		}),
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+	handler := httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"))
+
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader("m1,t1=v1 f1=1\nm1,t1=v2 f1=2"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+	require.Equal(t, "3", w.Header().Get("Retry-After"))
+	require.Equal(t, 2, points)
+	require.Equal(t, 27, bytes)
+}
+
+func TestWriteHandler_handlePromWrite(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	handler := newTestWriteHandler(t, pw)
+
+	req := &prometheus.WriteRequest{
+		Timeseries: []prometheus.TimeSeries{{
+			Labels:  []prometheus.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "api"}},
+			Samples: []prometheus.Sample{{Value: 1, Timestamp: 1000}},
+		}},
+	}
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/prom/write?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		bytes.NewReader(snappy.Encode(nil, req.Marshal())))
+	r.Header.Set("Content-Encoding", "snappy")
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	require.Len(t, pw.Points, 1)
+	require.Equal(t, "up,job=api value=1 1000000000", pw.Points[0].String())
+
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/prom/write?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader("m1 f1=1"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWriteHandler_handleOTLPMetrics(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	handler := newTestWriteHandler(t, pw)
+
+	// An ExportMetricsServiceRequest with a single gauge data point.
+	var dp, metric, scope, resource, req []byte
+	dp = protowire.AppendTag(dp, 3, protowire.Fixed64Type)
+	dp = protowire.AppendFixed64(dp, 1e9)
+	dp = protowire.AppendTag(dp, 4, protowire.Fixed64Type)
+	dp = protowire.AppendFixed64(dp, math.Float64bits(1.5))
+	metric = protowire.AppendTag(metric, 1, protowire.BytesType)
+	metric = protowire.AppendString(metric, "temperature")
+	metric = protowire.AppendTag(metric, 5, protowire.BytesType)
+	metric = protowire.AppendBytes(metric, protowire.AppendBytes(protowire.AppendTag(nil, 1, protowire.BytesType), dp))
+	scope = protowire.AppendTag(scope, 2, protowire.BytesType)
+	scope = protowire.AppendBytes(scope, metric)
+	resource = protowire.AppendTag(resource, 2, protowire.BytesType)
+	resource = protowire.AppendBytes(resource, scope)
+	req = protowire.AppendTag(req, 1, protowire.BytesType)
+	req = protowire.AppendBytes(req, resource)
+
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/otlp/v1/metrics?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		bytes.NewReader(req))
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "application/x-protobuf", w.Header().Get("Content-Type"))
+	require.Len(t, pw.Points, 1)
+	require.Equal(t, "temperature gauge=1.5 1000000000", pw.Points[0].String())
+
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/otlp/v1/metrics?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader("{}"))
+	r.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWriteHandler_handleIngestWrite(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	handler := newTestWriteHandler(t, pw)
+
+	spec := `{"measurement":"sensor","tags":[{"column":"device"}],"fields":[{"column":"temp"}],"time":{"column":"ts","format":"unix"}}`
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write/ndjson?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader(`{"device":"a","temp":21.5,"ts":1}`+"\n"))
+	r.Header.Set("Ingest-Mapping", spec)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	require.Len(t, pw.Points, 1)
+	require.Equal(t, "sensor,device=a temp=21.5 1000000000", pw.Points[0].String())
+
+	pw.Points = nil
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/write/csv?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader("device,temp,ts\nb,20,2\n"))
+	r.Header.Set("Ingest-Mapping", spec)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	require.Len(t, pw.Points, 1)
+	require.Equal(t, "sensor,device=b temp=20 2000000000", pw.Points[0].String())
+
+	// A record which cannot be converted fails the request.
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/write/csv?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader("device,temp\nb,20\n"))
+	r.Header.Set("Ingest-Mapping", spec)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	// The mapping is required.
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/write/csv?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader("device,temp,ts\nb,20,2\n"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestWriteHandler_handleWrite_IdempotencyKey(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	keys := &idempotencyKeys{results: map[string]*influxdb.WriteResult{}}
+	handler := newTestWriteHandler(t, pw, func(b *APIBackend) { b.WriteIdempotencyService = keys })
+
+	write := func(key, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader(body))
+		r.Header.Set("Idempotency-Key", key)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	w := write("batch-1", "m f=1 1\n")
+	require.Equal(t, http.StatusNoContent, w.Code, w.Body.String())
+	require.Len(t, pw.Points, 1)
+
+	// A retry is answered with the first response without writing.
+	w = write("batch-1", "m f=1 1\n")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Equal(t, "true", w.Header().Get("Idempotent-Replayed"))
+	require.Len(t, pw.Points, 1)
+
+	// Error responses are replayed too.
+	w = write("batch-2", "m f=\n")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	first := w.Body.String()
+	w = write("batch-2", "m f=1 2\n")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, first, w.Body.String())
+	require.Equal(t, "application/json; charset=utf-8", w.Header().Get("Content-Type"))
+	require.Len(t, pw.Points, 1)
+
+	// Server errors release the key so that the write can be retried.
+	pw.Points = nil
+	pw.Err = fmt.Errorf("disk full")
+	w = write("batch-3", "m f=1 3\n")
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	pw.Err = nil
+	w = write("batch-3", "m f=1 3\n")
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Empty(t, w.Header().Get("Idempotent-Replayed"))
+	require.Len(t, pw.Points, 2)
+
+	w = write(strings.Repeat("k", 256), "m f=1 4\n")
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+// idempotencyKeys is an in-memory influxdb.WriteIdempotencyService.
+type idempotencyKeys struct {
+	results map[string]*influxdb.WriteResult
+}
+
+func (k *idempotencyKeys) BeginWrite(_ context.Context, orgID platform.ID, key string) (*influxdb.WriteResult, error) {
+	r, ok := k.results[orgID.String()+key]
+	if !ok {
+		k.results[orgID.String()+key] = nil
+		return nil, nil
+	}
+	if r == nil {
+		return nil, influxdb.ErrWriteInProgress
+	}
+	return r, nil
+}
+
+func (k *idempotencyKeys) CompleteWrite(_ context.Context, orgID platform.ID, key string, result *influxdb.WriteResult) error {
+	k.results[orgID.String()+key] = result
+	return nil
+}
+
+func (k *idempotencyKeys) ReleaseWrite(_ context.Context, orgID platform.ID, key string) error {
+	delete(k.results, orgID.String()+key)
+	return nil
+}
+
+func TestWriteHandler_handleWrite_Async(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	queue := &writeQueue{batches: map[platform.ID]*influxdb.WriteBatch{}}
+	handler := newTestWriteHandler(t, pw, func(b *APIBackend) { b.WriteQueue = queue })
+
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader("m f=1 1\nm f=2 2\n"))
+	r.Header.Set("Prefer", "wait=10, respond-async")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+	require.Equal(t, "respond-async", w.Header().Get("Preference-Applied"))
+	require.Equal(t, "/api/v2/write/batches/0000000000000001", w.Header().Get("Location"))
+	require.JSONEq(t, `{"batchID":"0000000000000001","links":{"self":"/api/v2/write/batches/0000000000000001"}}`, w.Body.String())
+	require.Empty(t, pw.Points)
+	require.Equal(t, 2, queue.batches[1].Points)
+
+	r = httptest.NewRequest("GET", "http://localhost:8086/api/v2/write/batches/0000000000000001", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var b influxdb.WriteBatch
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &b))
+	require.Equal(t, influxdb.WriteBatchPending, b.Status)
+	require.Equal(t, 2, b.Points)
+
+	// Batches of buckets which cannot be written to are not disclosed.
+	queue.batches[2] = &influxdb.WriteBatch{ID: 2, OrgID: 3, BucketID: 4, Status: influxdb.WriteBatchApplied}
+	r = httptest.NewRequest("GET", "http://localhost:8086/api/v2/write/batches/0000000000000002", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	// Writes without the preference are written synchronously.
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader("m f=3 3\n"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusNoContent, w.Code)
+	require.Len(t, pw.Points, 1)
+
+	// A full queue asks the client to retry.
+	queue.err = influxdb.ErrWriteQueueFull
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader("m f=4 4\n"))
+	r.Header.Set("Prefer", "respond-async")
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+}
+
+func TestWriteHandler_handleWrite_DeadLetters(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	dl := &deadLetters{}
+	handler := newTestWriteHandler(t, pw, func(b *APIBackend) { b.DeadLetterWriter = dl })
+
+	// The lines which cannot be parsed are recorded, though the write fails.
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000", strings.NewReader("m f=1 1\nm f= 2\n"))
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Equal(t, platform.ID(0x04504b356e23b000), dl.bucketID)
+	require.Equal(t, []influxdb.DeadLetter{
+		{Line: "m f= 2", Code: "parse", Reason: "unable to parse 'm f= 2': missing field value"},
+	}, dl.letters)
+
+	// So are those of partial writes, which are written.
+	dl.letters = nil
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/write?org=043e0780ee2b1000&bucket=04504b356e23b000&partial=true", strings.NewReader("m f=1 1\nm,t f=2 2\n"))
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	require.Len(t, pw.Points, 1)
+	require.Equal(t, []influxdb.DeadLetter{
+		{Line: "m,t f=2 2", Code: "parse", Reason: "unable to parse 'm,t f=2 2': missing tag value"},
+	}, dl.letters)
+}
+
+func TestWriteHandler_handleIngestWrite_DeadLetters(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	dl := &deadLetters{}
+	handler := newTestWriteHandler(t, pw, func(b *APIBackend) { b.DeadLetterWriter = dl })
+
+	// The records which cannot be converted are recorded, though the write
+	// fails.
+	spec := `{"measurement":"sensor","fields":[{"column":"temp","type":"float"}]}`
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/write/ndjson?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader(`{"temp":21.5}`+"\n"+`{"temp":"warm"}`+"\n"))
+	r.Header.Set("Ingest-Mapping", spec)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Empty(t, pw.Points)
+	require.Len(t, dl.letters, 1)
+	require.Equal(t, `{"temp":"warm"}`, dl.letters[0].Line)
+	require.Equal(t, "parse", dl.letters[0].Code)
+
+	dl.letters = nil
+	r = httptest.NewRequest("POST", "http://localhost:8086/api/v2/write/csv?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		strings.NewReader("temp,note\nwarm,\"a,b\"\n"))
+	r.Header.Set("Ingest-Mapping", spec)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Len(t, dl.letters, 1)
+	require.Equal(t, `warm,"a,b"`, dl.letters[0].Line)
+}
+
+func TestWriteHandler_handlePromWrite_DeadLetters(t *testing.T) {
+	pw := &mock.PointsWriter{}
+	dl := &deadLetters{}
+	handler := newTestWriteHandler(t, pw, func(b *APIBackend) { b.DeadLetterWriter = dl })
+
+	// The series which cannot be converted are recorded, though the write
+	// fails.
+	req := &prometheus.WriteRequest{
+		Timeseries: []prometheus.TimeSeries{
+			{
+				Labels:  []prometheus.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prometheus.Sample{{Value: 1, Timestamp: 1000}},
+			},
+			{
+				Labels:  []prometheus.Label{{Name: "job", Value: "api"}},
+				Samples: []prometheus.Sample{{Value: 1, Timestamp: 1000}},
+			},
+		},
+	}
+	r := httptest.NewRequest("POST", "http://localhost:8086/api/v2/prom/write?org=043e0780ee2b1000&bucket=04504b356e23b000",
+		bytes.NewReader(snappy.Encode(nil, req.Marshal())))
+	r.Header.Set("Content-Encoding", "snappy")
+	r.Header.Set("Content-Type", "application/x-protobuf")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+	require.Empty(t, pw.Points)
+	require.Equal(t, []influxdb.DeadLetter{
+		{Line: `{job="api"}`, Code: "parse", Reason: "series has no __name__ label"},
+	}, dl.letters)
+}
+
+// deadLetters records the dead letters written to it.
+type deadLetters struct {
+	bucketID platform.ID
+	letters  []influxdb.DeadLetter
+}
+
+func (d *deadLetters) WriteDeadLetters(_ context.Context, _, bucketID platform.ID, letters []influxdb.DeadLetter) {
+	d.bucketID = bucketID
+	d.letters = append(d.letters, letters...)
+}
+
+// writeQueue is an in-memory influxdb.WriteQueue.
+type writeQueue struct {
+	batches map[platform.ID]*influxdb.WriteBatch
+	lastID  platform.ID
+	err     error
+}
+
+func (q *writeQueue) NewWriteBatchID() platform.ID {
+	q.lastID++
+	return q.lastID
+}
+
+func (q *writeQueue) EnqueuePoints(_ context.Context, id, orgID, bucketID platform.ID, points []models.Point) error {
+	if q.err != nil {
+		return q.err
+	}
+	b, ok := q.batches[id]
+	if !ok {
+		b = &influxdb.WriteBatch{ID: id, OrgID: orgID, BucketID: bucketID, Status: influxdb.WriteBatchPending}
+		q.batches[id] = b
+	}
+	b.Points += len(points)
+	return nil
+}
+
+func (q *writeQueue) FindWriteBatchByID(_ context.Context, id platform.ID) (*influxdb.WriteBatch, error) {
+	b, ok := q.batches[id]
+	if !ok {
+		return nil, influxdb.ErrWriteBatchNotFound
+	}
+	return b, nil
+}
+
+func newTestWriteHandler(t *testing.T, pw *mock.PointsWriter, opts ...func(*APIBackend)) http.Handler {
+	orgs := mock.NewOrganizationService()
+	orgs.FindOrganizationF = func(ctx context.Context, filter influxdb.OrganizationFilter) (*influxdb.Organization, error) {
+		return testOrg("043e0780ee2b1000"), nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(context.Context, influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		return testBucket("043e0780ee2b1000", "04504b356e23b000"), nil
+	}
+	b := &APIBackend{
+		HTTPErrorHandler:    kithttp.NewErrorHandler(zaptest.NewLogger(t)),
+		Logger:              zaptest.NewLogger(t),
+		OrganizationService: orgs,
+		BucketService:       buckets,
+		PointsWriter:        pw,
+		WriteEventRecorder:  &metric.NopEventRecorder{},
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	writeHandler := NewWriteHandler(zaptest.NewLogger(t), NewWriteBackend(zaptest.NewLogger(t), b))
+	return httpmock.NewAuthMiddlewareHandler(writeHandler, bucketWritePermission("043e0780ee2b1000", "04504b356e23b000"))
+}
+
 func bucketWritePermission(org, bucket string) *influxdb.Authorization {
 	oid := influxtesting.MustIDBase16(org)
 	bid := influxtesting.MustIDBase16(bucket)
@@ -0,0 +1,256 @@
+// Package idempotency remembers the results of the writes made with an
+// idempotency key, so that a client retrying a write after a timeout is
+// answered with the result of the first attempt instead of writing the batch
+// again.
+//
+// Keys are scoped to an organization and remembered for a window of time in
+// the sqlite store, so that they survive restarts.
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"go.uber.org/zap"
+)
+
+// DefaultWindow is how long the result of a write is remembered unless a
+// window is given.
+const DefaultWindow = 10 * time.Minute
+
+var _ influxdb.WriteIdempotencyService = (*Service)(nil)
+
+// Service is a WriteIdempotencyService backed by the sqlite store.
+//
+// The keys older than the window are removed periodically once the Service
+// is opened.
+type Service struct {
+	log    *zap.Logger
+	store  *sqlite.SqlStore
+	window time.Duration
+	now    func() time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewService returns a Service remembering the results of writes for window,
+// or for DefaultWindow if it is zero.
+func NewService(log *zap.Logger, store *sqlite.SqlStore, window time.Duration) *Service {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Service{
+		log:    log,
+		store:  store,
+		window: window,
+		now:    time.Now,
+	}
+}
+
+// row is a key as stored in the write_idempotency_keys table.
+type row struct {
+	StatusCode sql.NullInt64 `db:"status_code"`
+	Header     header        `db:"header"`
+	Body       []byte        `db:"body"`
+}
+
+// header stores the header of a write result as a JSON object.
+type header http.Header
+
+// Value implements the database/sql Valuer interface.
+func (h header) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(http.Header(h))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the database/sql Scanner interface.
+func (h *header) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), (*http.Header)(h))
+	default:
+		return fmt.Errorf("write idempotency key header: unexpected type %T", value)
+	}
+}
+
+// Open removes the keys of the writes left in progress by a previous run and
+// the expired keys, and starts removing the expired keys periodically.
+func (s *Service) Open(ctx context.Context) error {
+	if err := s.removeInProgress(ctx); err != nil {
+		return err
+	}
+	if err := s.removeExpired(ctx); err != nil {
+		return err
+	}
+
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.window)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.removeExpired(ctx); err != nil && ctx.Err() == nil {
+					s.log.Warn("Failed to remove expired write idempotency keys", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops removing the expired keys.
+func (s *Service) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+		s.wg.Wait()
+	}
+	return nil
+}
+
+// BeginWrite reserves key for a write to an organization, unless a write used
+// it within the window.
+func (s *Service) BeginWrite(ctx context.Context, orgID platform.ID, key string) (*influxdb.WriteResult, error) {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	now := s.now().UTC()
+	query, args, err := sq.Delete("write_idempotency_keys").
+		Where(sq.Eq{"org_id": orgID, "key": key}).
+		Where(sq.Lt{"created_at": now.Add(-s.window)}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+
+	query, args, err = sq.Insert("write_idempotency_keys").
+		Columns("org_id", "key", "created_at").
+		Values(orgID, key, now).
+		Suffix("ON CONFLICT (org_id, key) DO NOTHING").
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	res, err := s.store.DB.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return nil, err
+	} else if n == 1 {
+		return nil, nil
+	}
+
+	query, args, err = sq.Select("status_code", "header", "body").
+		From("write_idempotency_keys").
+		Where(sq.Eq{"org_id": orgID, "key": key}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+	var r row
+	if err := s.store.DB.GetContext(ctx, &r, query, args...); err != nil {
+		return nil, err
+	}
+	if !r.StatusCode.Valid {
+		return nil, influxdb.ErrWriteInProgress
+	}
+	return &influxdb.WriteResult{
+		StatusCode: int(r.StatusCode.Int64),
+		Header:     http.Header(r.Header),
+		Body:       r.Body,
+	}, nil
+}
+
+// CompleteWrite records the result of the write key was reserved for.
+func (s *Service) CompleteWrite(ctx context.Context, orgID platform.ID, key string, result *influxdb.WriteResult) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Update("write_idempotency_keys").
+		SetMap(sq.Eq{
+			"status_code": result.StatusCode,
+			"header":      header(result.Header),
+			"body":        result.Body,
+		}).
+		Where(sq.Eq{"org_id": orgID, "key": key}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = s.store.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// ReleaseWrite releases key without recording a result.
+func (s *Service) ReleaseWrite(ctx context.Context, orgID platform.ID, key string) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("write_idempotency_keys").
+		Where(sq.Eq{"org_id": orgID, "key": key, "status_code": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = s.store.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// removeInProgress removes the keys of the writes in progress, which can only
+// have been left by a previous run once no write is being served.
+func (s *Service) removeInProgress(ctx context.Context) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("write_idempotency_keys").
+		Where(sq.Eq{"status_code": nil}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = s.store.DB.ExecContext(ctx, query, args...)
+	return err
+}
+
+// removeExpired removes the keys older than the window.
+func (s *Service) removeExpired(ctx context.Context) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("write_idempotency_keys").
+		Where(sq.Lt{"created_at": s.now().UTC().Add(-s.window)}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+	_, err = s.store.DB.ExecContext(ctx, query, args...)
+	return err
+}
@@ -0,0 +1,124 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx = context.Background()
+
+	orgID      = platform.ID(10)
+	otherOrgID = platform.ID(20)
+)
+
+func TestService_Write(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	result, err := svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	// The key is in use until the write completes.
+	_, err = svc.BeginWrite(ctx, orgID, "batch-1")
+	require.Equal(t, influxdb.ErrWriteInProgress, err)
+
+	// Keys are scoped to an organization.
+	result, err = svc.BeginWrite(ctx, otherOrgID, "batch-1")
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	written := &influxdb.WriteResult{
+		StatusCode: http.StatusUnprocessableEntity,
+		Header:     http.Header{"Content-Type": {"application/json; charset=utf-8"}},
+		Body:       []byte(`{"code":"unprocessable entity"}`),
+	}
+	require.NoError(t, svc.CompleteWrite(ctx, orgID, "batch-1", written))
+
+	result, err = svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.Equal(t, written, result)
+
+	// Releasing a completed key keeps its result.
+	require.NoError(t, svc.ReleaseWrite(ctx, orgID, "batch-1"))
+	result, err = svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.Equal(t, written, result)
+}
+
+func TestService_ReleaseWrite(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	_, err := svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.NoError(t, svc.ReleaseWrite(ctx, orgID, "batch-1"))
+
+	result, err := svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
+
+func TestService_Window(t *testing.T) {
+	svc, _ := newTestService(t)
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	_, err := svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.NoError(t, svc.CompleteWrite(ctx, orgID, "batch-1", &influxdb.WriteResult{StatusCode: http.StatusNoContent}))
+
+	now = now.Add(DefaultWindow - time.Second)
+	result, err := svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.Equal(t, &influxdb.WriteResult{StatusCode: http.StatusNoContent}, result)
+
+	// Once the window has passed, the key is reserved again.
+	now = now.Add(2 * time.Second)
+	result, err = svc.BeginWrite(ctx, orgID, "batch-1")
+	require.NoError(t, err)
+	require.Nil(t, result)
+}
+
+func TestService_Open(t *testing.T) {
+	svc, store := newTestService(t)
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	_, err := svc.BeginWrite(ctx, orgID, "in-progress")
+	require.NoError(t, err)
+	_, err = svc.BeginWrite(ctx, orgID, "completed")
+	require.NoError(t, err)
+	require.NoError(t, svc.CompleteWrite(ctx, orgID, "completed", &influxdb.WriteResult{StatusCode: http.StatusNoContent}))
+
+	// A restarted server forgets the writes it was serving, but not the
+	// completed ones.
+	svc = NewService(zaptest.NewLogger(t), store, 0)
+	svc.now = func() time.Time { return now }
+	require.NoError(t, svc.Open(ctx))
+	t.Cleanup(func() { require.NoError(t, svc.Close()) })
+
+	result, err := svc.BeginWrite(ctx, orgID, "in-progress")
+	require.NoError(t, err)
+	require.Nil(t, result)
+
+	result, err = svc.BeginWrite(ctx, orgID, "completed")
+	require.NoError(t, err)
+	require.Equal(t, &influxdb.WriteResult{StatusCode: http.StatusNoContent}, result)
+}
+
+func newTestService(t *testing.T) (*Service, *sqlite.SqlStore) {
+	store, clean := sqlite.NewTestStore(t)
+	t.Cleanup(func() { clean(t) })
+	require.NoError(t, sqlite.NewMigrator(store, zaptest.NewLogger(t)).Up(ctx, migrations.AllUp))
+
+	return NewService(zaptest.NewLogger(t), store, 0), store
+}
@@ -0,0 +1,275 @@
+// Package ingest stores ingest mappings and converts the records of JSON and
+// CSV documents to points as declared by them.
+//
+// Every record of a document is converted to a point:
+//
+//   - the measurement is the measurement of the spec, or the value of its
+//     measurement column;
+//   - the tags and fields are the values of their columns, columns missing
+//     from the record or empty being left out;
+//   - fields are typed by their type in the spec or, if it has none, by their
+//     type in the document: JSON numbers are floats, CSV values are typed by
+//     the #datatype annotation of their column or else inferred as floats,
+//     booleans or strings;
+//   - the time is the value of the time column in its format, or the time of
+//     the request if the spec has no time column.
+package ingest
+
+import (
+	"fmt"
+	"io"
+	"math/big"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+const opConvert = "ingest/convert"
+
+// value is the value of a column of a record, as text.
+type value struct {
+	text string
+	// typ is the type of the value in the document, if it has one.
+	typ influxdb.IngestDataType
+}
+
+// record is a record of a document.
+type record interface {
+	// value returns the value of a column, false if the record has none.
+	value(column string) (value, bool, error)
+	// text returns the record as written in the document.
+	text() string
+}
+
+// reader reads the records of a document.
+type reader interface {
+	// next returns the next record and the line it starts on, io.EOF once
+	// there are none left.
+	next() (record, int, error)
+	// offset returns the number of bytes read from the document.
+	offset() int
+}
+
+// Convert reads the records of a document of the given format from r and
+// converts them to points as declared by spec and, once all are converted,
+// calls fn with all of the points and the size of the document. A document
+// with a record which cannot be converted is thus not written at all. Points
+// without a time column are written at now. Convert returns the number of
+// bytes read; errors reading r are returned as they are.
+//
+// onError, if not nil, is called with the text and the error of every record
+// which cannot be converted. The records after one which cannot be read are
+// not converted.
+func Convert(r io.Reader, format influxdb.IngestFormat, spec *influxdb.IngestSpec, now time.Time, onError func(record string, err error), fn func(pts models.Points, size int) error) (n int, err error) {
+	if err := spec.Validate(); err != nil {
+		return 0, err
+	}
+
+	var rd reader
+	switch format {
+	case influxdb.IngestFormatNDJSON:
+		rd = newJSONReader(r)
+	case influxdb.IngestFormatCSV:
+		rd = newCSVReader(r)
+	default:
+		return 0, &errors.Error{
+			Code: errors.EInvalid,
+			Op:   opConvert,
+			Msg:  fmt.Sprintf("unknown ingest format %q", format),
+		}
+	}
+
+	// The records which cannot be converted are reported, if onError is
+	// set, but the first one fails the document.
+	var (
+		points   models.Points
+		firstErr error
+	)
+	for {
+		rec, line, err := rd.next()
+		if err == io.EOF {
+			break
+		} else if serr, ok := err.(*syntaxError); ok {
+			if onError != nil {
+				onError(serr.text, serr.err)
+			}
+			if firstErr == nil {
+				firstErr = recordError(err, line)
+			}
+			return rd.offset(), firstErr
+		} else if err != nil {
+			return rd.offset(), err
+		}
+
+		p, err := convert(spec, rec, now)
+		if err != nil {
+			if onError != nil {
+				onError(rec.text(), err)
+			}
+			if firstErr == nil {
+				firstErr = recordError(err, line)
+			}
+			continue
+		}
+		if firstErr == nil {
+			points = append(points, p)
+		}
+	}
+	if firstErr != nil {
+		return rd.offset(), firstErr
+	}
+	return rd.offset(), fn(points, rd.offset())
+}
+
+// syntaxError is an error in the syntax of a document, with the text of the
+// record it is in if it is known.
+type syntaxError struct {
+	err  error
+	text string
+}
+
+func (e *syntaxError) Error() string { return e.err.Error() }
+
+// recordError returns the error reading or converting the record starting on
+// line.
+func recordError(err error, line int) error {
+	return &errors.Error{
+		Code: errors.EInvalid,
+		Op:   opConvert,
+		Msg:  fmt.Sprintf("invalid record on line %d", line),
+		Err:  err,
+	}
+}
+
+// convert converts a record to a point.
+func convert(spec *influxdb.IngestSpec, rec record, now time.Time) (models.Point, error) {
+	name := spec.Measurement
+	if spec.MeasurementColumn != "" {
+		v, ok, err := rec.value(spec.MeasurementColumn)
+		if err != nil {
+			return nil, err
+		}
+		if !ok || v.text == "" {
+			return nil, fmt.Errorf("missing measurement column %q", spec.MeasurementColumn)
+		}
+		name = v.text
+	}
+
+	tags := make(map[string]string, len(spec.Tags))
+	for _, c := range spec.Tags {
+		v, ok, err := rec.value(c.Column)
+		if err != nil {
+			return nil, err
+		}
+		if ok && v.text != "" {
+			tags[c.Key()] = v.text
+		}
+	}
+
+	fields := make(models.Fields, len(spec.Fields))
+	for _, c := range spec.Fields {
+		v, ok, err := rec.value(c.Column)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		f, err := fieldValue(v, c.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %q: %w", c.Key(), err)
+		}
+		fields[c.Key()] = f
+	}
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("none of the field columns has a value")
+	}
+
+	t := now
+	if c := spec.Time; c != nil {
+		v, ok, err := rec.value(c.Column)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, fmt.Errorf("missing time column %q", c.Column)
+		}
+		if t, err = timeValue(v.text, c.Format); err != nil {
+			return nil, fmt.Errorf("time column %q: %w", c.Column, err)
+		}
+	}
+
+	return models.NewPoint(name, models.NewTags(tags), fields, t)
+}
+
+// fieldValue returns the field value of v as typ, the type of v in its
+// document if typ is empty.
+func fieldValue(v value, typ influxdb.IngestDataType) (interface{}, error) {
+	if typ == "" {
+		typ = v.typ
+	}
+	switch typ {
+	case influxdb.IngestDataTypeFloat:
+		return strconv.ParseFloat(v.text, 64)
+	case influxdb.IngestDataTypeInteger:
+		return strconv.ParseInt(v.text, 10, 64)
+	case influxdb.IngestDataTypeUnsigned:
+		return strconv.ParseUint(v.text, 10, 64)
+	case influxdb.IngestDataTypeBoolean:
+		return strconv.ParseBool(v.text)
+	case influxdb.IngestDataTypeString:
+		return v.text, nil
+	}
+
+	if f, err := strconv.ParseFloat(v.text, 64); err == nil {
+		return f, nil
+	}
+	if b, err := strconv.ParseBool(v.text); err == nil {
+		return b, nil
+	}
+	return v.text, nil
+}
+
+// unixUnits are the durations of the units of the unix time formats.
+var unixUnits = map[string]time.Duration{
+	influxdb.IngestTimeUnix:   time.Second,
+	influxdb.IngestTimeUnixMs: time.Millisecond,
+	influxdb.IngestTimeUnixUs: time.Microsecond,
+	influxdb.IngestTimeUnixNs: time.Nanosecond,
+}
+
+// timeValue parses the text of a time in format.
+func timeValue(text, format string) (time.Time, error) {
+	switch format {
+	case "", influxdb.IngestTimeRFC3339:
+		return time.Parse(time.RFC3339Nano, text)
+	case influxdb.IngestTimeUnix, influxdb.IngestTimeUnixMs, influxdb.IngestTimeUnixUs, influxdb.IngestTimeUnixNs:
+		unit := int64(unixUnits[format])
+		if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+			if i < models.MinNanoTime/unit || i > models.MaxNanoTime/unit {
+				return time.Time{}, models.ErrTimeOutOfRange
+			}
+			return time.Unix(0, i*unit).UTC(), nil
+		}
+		// Fractions, such as seconds with milliseconds, are converted
+		// exactly rather than as floats.
+		if _, err := strconv.ParseFloat(text, 64); err != nil {
+			return time.Time{}, fmt.Errorf("invalid %s time %q", format, text)
+		}
+		r, ok := new(big.Rat).SetString(text)
+		if !ok {
+			return time.Time{}, fmt.Errorf("invalid %s time %q", format, text)
+		}
+		r.Mul(r, new(big.Rat).SetInt64(unit))
+		ns := new(big.Int).Quo(r.Num(), r.Denom())
+		if !ns.IsInt64() || ns.Int64() < models.MinNanoTime || ns.Int64() > models.MaxNanoTime {
+			return time.Time{}, models.ErrTimeOutOfRange
+		}
+		return time.Unix(0, ns.Int64()).UTC(), nil
+	default:
+		return time.Parse(format, text)
+	}
+}
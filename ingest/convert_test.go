@@ -0,0 +1,218 @@
+package ingest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/require"
+)
+
+var now = time.Date(2021, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// convertAll converts a document, returning the line protocol of its points
+// and the number of times they were written.
+func convertAll(t *testing.T, doc string, format influxdb.IngestFormat, spec influxdb.IngestSpec) ([]string, int, error) {
+	t.Helper()
+	var (
+		lines  []string
+		writes int
+		size   int
+	)
+	n, err := Convert(strings.NewReader(doc), format, &spec, now, nil, func(pts models.Points, sz int) error {
+		writes++
+		size += sz
+		for _, p := range pts {
+			lines = append(lines, p.String())
+		}
+		return nil
+	})
+	if err == nil {
+		require.Equal(t, len(doc), n)
+		require.Equal(t, n, size)
+	}
+	return lines, writes, err
+}
+
+func TestConvert_NDJSON(t *testing.T) {
+	spec := influxdb.IngestSpec{
+		Measurement: "sensor",
+		Tags: []influxdb.IngestColumn{
+			{Column: "device.id", Name: "device"},
+			{Column: "site"},
+		},
+		Fields: []influxdb.IngestColumn{
+			{Column: "temp"},
+			{Column: "count", Type: influxdb.IngestDataTypeInteger},
+			{Column: "ok"},
+			{Column: "note"},
+		},
+		Time: &influxdb.IngestColumn{Column: "ts", Format: influxdb.IngestTimeUnixMs},
+	}
+	doc := `{"device":{"id":"a"},"site":"x","temp":21.5,"count":3,"ok":true,"ts":1609556645000}
+
+{"device":{"id":"b"},"temp":"warm","ts":1609556646500,"extra":[1,2]}
+`
+	lines, writes, err := convertAll(t, doc, influxdb.IngestFormatNDJSON, spec)
+	require.NoError(t, err)
+	require.Equal(t, 1, writes)
+	require.Equal(t, []string{
+		`sensor,device=a,site=x count=3i,ok=true,temp=21.5 1609556645000000000`,
+		`sensor,device=b temp="warm" 1609556646500000000`,
+	}, lines)
+}
+
+func TestConvert_CSV(t *testing.T) {
+	spec := influxdb.IngestSpec{
+		MeasurementColumn: "_measurement",
+		Tags:              []influxdb.IngestColumn{{Column: "host"}},
+		Fields: []influxdb.IngestColumn{
+			{Column: "usage"},
+			{Column: "cores"},
+			{Column: "up"},
+		},
+		Time: &influxdb.IngestColumn{Column: "time", Format: "2006-01-02 15:04:05"},
+	}
+	doc := `#datatype,string,string,long,double,boolean,dateTime
+#default,,cpu,,,,
+,host,_measurement,cores,usage,up,time
+,a,,4,1,true,2021-01-02 03:04:05
+,b,mem,,2.5,,2021-01-02 03:04:06
+
+#datatype,string,string,string,dateTime
+,host,_measurement,usage,time
+,c,disk,full,2021-01-02 03:04:07
+`
+	lines, _, err := convertAll(t, doc, influxdb.IngestFormatCSV, spec)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`cpu,host=a cores=4i,up=true,usage=1 1609556645000000000`,
+		`mem,host=b usage=2.5 1609556646000000000`,
+		`disk,host=c usage="full" 1609556647000000000`,
+	}, lines)
+
+	// Without annotations, the types are inferred and the points are
+	// written at the time of the request.
+	spec.Time = nil
+	lines, _, err = convertAll(t, "host,_measurement,usage,up\na,cpu,1,false\nb,cpu,busy,\n", influxdb.IngestFormatCSV, spec)
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		`cpu,host=a up=false,usage=1 1609556645000000000`,
+		`cpu,host=b usage="busy" 1609556645000000000`,
+	}, lines)
+}
+
+func TestConvert_Times(t *testing.T) {
+	for _, tt := range []struct {
+		format string
+		value  string
+		want   time.Time
+	}{
+		{"", "2021-01-02T03:04:05.5Z", now.Add(500 * time.Millisecond)},
+		{influxdb.IngestTimeRFC3339, "2021-01-02T04:04:05+01:00", now},
+		{influxdb.IngestTimeUnix, "1609556645", now},
+		{influxdb.IngestTimeUnix, "1609556645.25", now.Add(250 * time.Millisecond)},
+		{influxdb.IngestTimeUnixUs, "1609556645000001", now.Add(time.Microsecond)},
+		{influxdb.IngestTimeUnixNs, "1609556645000000001", now.Add(1)},
+		{"02/01/2006 15:04", "02/01/2021 03:04", now.Add(-5 * time.Second)},
+	} {
+		got, err := timeValue(tt.value, tt.format)
+		require.NoError(t, err, tt.value)
+		require.True(t, tt.want.Equal(got), "%s: got %v, want %v", tt.value, got, tt.want)
+	}
+
+	_, err := timeValue("1609556645000000000", influxdb.IngestTimeUnix)
+	require.Equal(t, models.ErrTimeOutOfRange, err)
+}
+
+func TestConvert_Errors(t *testing.T) {
+	spec := influxdb.IngestSpec{
+		Measurement: "m",
+		Fields:      []influxdb.IngestColumn{{Column: "v", Type: influxdb.IngestDataTypeInteger}},
+		Time:        &influxdb.IngestColumn{Column: "t", Format: influxdb.IngestTimeUnix},
+	}
+	for _, tt := range []struct {
+		name   string
+		format influxdb.IngestFormat
+		doc    string
+		msg    string
+	}{
+		{"json syntax", influxdb.IngestFormatNDJSON, "{\"v\":1,\"t\":1}\n{\"v\":", "invalid record on line 2"},
+		{"json array", influxdb.IngestFormatNDJSON, "[1]\n", "invalid record on line 1"},
+		{"not an object", influxdb.IngestFormatNDJSON, "null\n", "invalid record on line 1"},
+		{"nested value", influxdb.IngestFormatNDJSON, "{\"v\":{\"a\":1},\"t\":1}\n", "invalid record on line 1"},
+		{"field type", influxdb.IngestFormatNDJSON, "\n{\"v\":1.5,\"t\":1}\n", "invalid record on line 2"},
+		{"missing time", influxdb.IngestFormatCSV, "v,x\n1,2\n", "invalid record on line 2"},
+		{"no fields", influxdb.IngestFormatCSV, "v,t\n,1\n", "invalid record on line 2"},
+		{"csv syntax", influxdb.IngestFormatCSV, "v,t\n1,\"1\n", "invalid record on line 2"},
+		{"too many cells", influxdb.IngestFormatCSV, "v,t\n1,1,1\n", "invalid record on line 2"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Nothing is written, even the records before the one which
+			// cannot be converted.
+			_, writes, err := convertAll(t, tt.doc, tt.format, spec)
+			require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+			require.Equal(t, tt.msg, errors.ErrorMessage(err))
+			require.Zero(t, writes)
+		})
+	}
+
+	_, _, err := convertAll(t, "", "xml", spec)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	spec.Fields = nil
+	_, _, err = convertAll(t, "", influxdb.IngestFormatCSV, spec)
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+}
+
+func TestConvert_OnError(t *testing.T) {
+	spec := influxdb.IngestSpec{
+		Measurement: "m",
+		Fields:      []influxdb.IngestColumn{{Column: "v", Type: influxdb.IngestDataTypeInteger}},
+	}
+	for _, tt := range []struct {
+		name    string
+		format  influxdb.IngestFormat
+		doc     string
+		msg     string
+		records []string
+	}{
+		// Every record which cannot be converted is reported, up to one
+		// which cannot be read.
+		{"json", influxdb.IngestFormatNDJSON, "{\"v\":1.5}\n{\"v\":1}\n {\"v\":\"x\"} \n{\"v\":\n{\"v\":\"y\"}\n", "invalid record on line 1", []string{`{"v":1.5}`, `{"v":"x"}`, `{"v":`}},
+		{"csv", influxdb.IngestFormatCSV, "v,t\n1.5,a\n1,b\n\"x,y\",c\n1,2,3\ny,d\n", "invalid record on line 2", []string{"1.5,a", `"x,y",c`, "1,2,3"}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var records []string
+			_, err := Convert(strings.NewReader(tt.doc), tt.format, &spec, now, func(record string, err error) {
+				require.Error(t, err)
+				records = append(records, record)
+			}, func(pts models.Points, size int) error {
+				t.Fatal("document with invalid records written")
+				return nil
+			})
+			require.Equal(t, tt.msg, errors.ErrorMessage(err))
+			require.Equal(t, tt.records, records)
+		})
+	}
+}
+
+func TestConvert_PartialWrite(t *testing.T) {
+	spec := influxdb.IngestSpec{
+		Measurement: "m",
+		Fields:      []influxdb.IngestColumn{{Column: "v"}},
+	}
+	perr := tsdb.PartialWriteError{Reason: "dropped", Dropped: 1, DroppedKeys: [][]byte{[]byte("m")}}
+	var writes int
+	_, err := Convert(strings.NewReader("{\"v\":1}\n{\"v\":2}\n{\"v\":3}\n"), influxdb.IngestFormatNDJSON, &spec, now, nil, func(pts models.Points, size int) error {
+		writes++
+		require.Len(t, pts, 3)
+		return perr
+	})
+	require.Equal(t, 1, writes)
+	require.Equal(t, perr, err)
+}
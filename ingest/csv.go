@@ -0,0 +1,144 @@
+package ingest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// csvDataTypes are the types of fields of the #datatype annotations of
+// annotated CSV. Columns of other types have their type inferred.
+var csvDataTypes = map[string]influxdb.IngestDataType{
+	"double":       influxdb.IngestDataTypeFloat,
+	"long":         influxdb.IngestDataTypeInteger,
+	"unsignedLong": influxdb.IngestDataTypeUnsigned,
+	"boolean":      influxdb.IngestDataTypeBoolean,
+	"string":       influxdb.IngestDataTypeString,
+}
+
+// csvReader reads the records of CSV with a header row. The header may be
+// preceded by the annotation rows of annotated CSV, whose first cell starts
+// with '#': the #datatype annotation types the values of its columns and the
+// #default annotation gives the values of the empty cells of its columns.
+// Other annotations are ignored.
+//
+// A row of annotations after the records starts a new table, with its own
+// header, as in the results of queries.
+type csvReader struct {
+	cr *csv.Reader
+	cn *countingReader
+
+	header   map[string]int
+	types    []string
+	defaults []string
+}
+
+func newCSVReader(r io.Reader) *csvReader {
+	cn := &countingReader{r: r}
+	cr := csv.NewReader(cn)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+	return &csvReader{cr: cr, cn: cn}
+}
+
+// offset returns the number of bytes read from the document, which includes
+// those buffered by the CSV reader.
+func (r *csvReader) offset() int { return r.cn.n }
+
+func (r *csvReader) next() (record, int, error) {
+	for {
+		row, err := r.cr.Read()
+		if err == io.EOF {
+			return nil, 0, io.EOF
+		} else if perr, ok := err.(*csv.ParseError); ok {
+			return nil, perr.StartLine, &syntaxError{err: perr.Err}
+		} else if err != nil {
+			return nil, 0, err
+		}
+		line, _ := r.cr.FieldPos(0)
+
+		if strings.HasPrefix(row[0], "#") {
+			if r.header != nil {
+				r.header, r.types, r.defaults = nil, nil, nil
+			}
+			switch row[0] {
+			case "#datatype":
+				r.types = append([]string(nil), row...)
+			case "#default":
+				r.defaults = append([]string(nil), row...)
+			}
+			continue
+		}
+
+		if r.header == nil {
+			r.header = make(map[string]int, len(row))
+			for i, c := range row {
+				if _, ok := r.header[c]; ok && c != "" {
+					return nil, line, &syntaxError{err: fmt.Errorf("duplicate column %q", c), text: csvText(row)}
+				}
+				r.header[c] = i
+			}
+			continue
+		}
+
+		if len(row) > len(r.header) {
+			return nil, line, &syntaxError{err: fmt.Errorf("row has %d cells, the header %d", len(row), len(r.header)), text: csvText(row)}
+		}
+		return &csvRecord{r: r, row: row}, line, nil
+	}
+}
+
+// csvRecord is a row of CSV, whose columns are those of the header.
+type csvRecord struct {
+	r   *csvReader
+	row []string
+}
+
+func (rec *csvRecord) value(column string) (value, bool, error) {
+	i, ok := rec.r.header[column]
+	if !ok {
+		return value{}, false, nil
+	}
+
+	var v value
+	if i < len(rec.row) {
+		v.text = rec.row[i]
+	}
+	if v.text == "" && i < len(rec.r.defaults) {
+		v.text = rec.r.defaults[i]
+	}
+	if v.text == "" {
+		return value{}, false, nil
+	}
+	if i < len(rec.r.types) {
+		v.typ = csvDataTypes[rec.r.types[i]]
+	}
+	return v, true, nil
+}
+
+func (rec *csvRecord) text() string { return csvText(rec.row) }
+
+// csvText returns a row encoded as CSV. The row as written is not kept by the
+// CSV reader, so it may be quoted differently.
+func csvText(row []string) string {
+	var b strings.Builder
+	w := csv.NewWriter(&b)
+	_ = w.Write(row)
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, err := r.r.Read(p)
+	r.n += n
+	return n, err
+}
@@ -0,0 +1,101 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+)
+
+// jsonReader reads the records of newline-delimited JSON, one object per
+// line. Blank lines are skipped.
+type jsonReader struct {
+	br   *bufio.Reader
+	line int
+	n    int
+}
+
+func newJSONReader(r io.Reader) *jsonReader {
+	return &jsonReader{br: bufio.NewReader(r)}
+}
+
+func (r *jsonReader) offset() int { return r.n }
+
+func (r *jsonReader) next() (record, int, error) {
+	for {
+		b, err := r.br.ReadBytes('\n')
+		r.n += len(b)
+		if err != nil && err != io.EOF {
+			return nil, 0, err
+		}
+		if len(b) > 0 {
+			r.line++
+		}
+		if len(bytes.TrimSpace(b)) == 0 {
+			if err == io.EOF {
+				return nil, 0, io.EOF
+			}
+			continue
+		}
+
+		text := string(bytes.TrimSpace(b))
+		d := json.NewDecoder(bytes.NewReader(b))
+		d.UseNumber()
+		var obj map[string]interface{}
+		if err := d.Decode(&obj); err != nil {
+			return nil, r.line, &syntaxError{err: err, text: text}
+		}
+		if obj == nil {
+			return nil, r.line, &syntaxError{err: fmt.Errorf("record is not an object"), text: text}
+		}
+		if _, err := d.Token(); err != io.EOF {
+			return nil, r.line, &syntaxError{err: fmt.Errorf("more than one value on the line"), text: text}
+		}
+		return &jsonRecord{obj: obj, line: text}, r.line, nil
+	}
+}
+
+// jsonRecord is a JSON object, whose columns are its keys. The keys of nested
+// objects are separated from those of their parents by dots.
+type jsonRecord struct {
+	obj  map[string]interface{}
+	line string
+}
+
+func (rec *jsonRecord) text() string { return rec.line }
+
+func (rec *jsonRecord) value(column string) (value, bool, error) {
+	v, ok := rec.obj[column]
+	if !ok {
+		// The key may be a path into nested objects.
+		obj := rec.obj
+		path := strings.Split(column, ".")
+		for i, k := range path {
+			if v, ok = obj[k]; !ok {
+				return value{}, false, nil
+			}
+			if i < len(path)-1 {
+				if obj, ok = v.(map[string]interface{}); !ok {
+					return value{}, false, nil
+				}
+			}
+		}
+	}
+
+	switch v := v.(type) {
+	case nil:
+		return value{}, false, nil
+	case string:
+		return value{text: v, typ: influxdb.IngestDataTypeString}, true, nil
+	case json.Number:
+		return value{text: v.String(), typ: influxdb.IngestDataTypeFloat}, true, nil
+	case bool:
+		return value{text: fmt.Sprint(v), typ: influxdb.IngestDataTypeBoolean}, true, nil
+	default:
+		return value{}, false, fmt.Errorf("column %q is not a string, number or boolean", column)
+	}
+}
@@ -0,0 +1,241 @@
+package ingest
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/mattn/go-sqlite3"
+)
+
+var _ influxdb.IngestMappingService = (*Service)(nil)
+
+// Service is an IngestMappingService backed by the sqlite store.
+type Service struct {
+	store       *sqlite.SqlStore
+	idGenerator platform.IDGenerator
+	now         func() time.Time
+}
+
+// NewService returns a new Service.
+func NewService(store *sqlite.SqlStore) *Service {
+	return &Service{
+		store:       store,
+		idGenerator: snowflake.NewIDGenerator(),
+		now:         time.Now,
+	}
+}
+
+// row is an ingest mapping as stored in the ingest_mappings table.
+type row struct {
+	ID          platform.ID `db:"id"`
+	OrgID       platform.ID `db:"org_id"`
+	Name        string      `db:"name"`
+	Description string      `db:"description"`
+	Spec        spec        `db:"spec"`
+	CreatedAt   time.Time   `db:"created_at"`
+	UpdatedAt   time.Time   `db:"updated_at"`
+}
+
+func (r *row) toInfluxDB() *influxdb.IngestMapping {
+	return &influxdb.IngestMapping{
+		ID:          r.ID,
+		OrgID:       r.OrgID,
+		Name:        r.Name,
+		Description: r.Description,
+		Spec:        influxdb.IngestSpec(r.Spec),
+		CRUDLog: influxdb.CRUDLog{
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		},
+	}
+}
+
+// spec stores the spec of an ingest mapping as a JSON object.
+type spec influxdb.IngestSpec
+
+// Value implements the database/sql Valuer interface.
+func (s spec) Value() (driver.Value, error) {
+	b, err := json.Marshal(influxdb.IngestSpec(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the database/sql Scanner interface.
+func (s *spec) Scan(value interface{}) error {
+	v, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("ingest mapping spec: unexpected type %T", value)
+	}
+	return json.Unmarshal([]byte(v), (*influxdb.IngestSpec)(s))
+}
+
+var selectColumns = []string{"id", "org_id", "name", "description", "spec", "created_at", "updated_at"}
+
+// FindIngestMappingByID returns a single ingest mapping by ID.
+func (s *Service) FindIngestMappingByID(ctx context.Context, id platform.ID) (*influxdb.IngestMapping, error) {
+	query, args, err := sq.Select(selectColumns...).
+		From("ingest_mappings").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var r row
+	if err := s.store.DB.GetContext(ctx, &r, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, influxdb.ErrIngestMappingNotFound
+		}
+		return nil, err
+	}
+	return r.toInfluxDB(), nil
+}
+
+// FindIngestMappings returns the ingest mappings of an organization matching
+// filter, sorted by name.
+func (s *Service) FindIngestMappings(ctx context.Context, filter influxdb.IngestMappingFilter) ([]*influxdb.IngestMapping, error) {
+	q := sq.Select(selectColumns...).
+		From("ingest_mappings").
+		Where(sq.Eq{"org_id": filter.OrgID}).
+		OrderBy("name")
+	if filter.Name != nil {
+		q = q.Where(sq.Eq{"name": *filter.Name})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []row
+	if err := s.store.DB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	ms := make([]*influxdb.IngestMapping, 0, len(rows))
+	for i := range rows {
+		ms = append(ms, rows[i].toInfluxDB())
+	}
+	return ms, nil
+}
+
+// CreateIngestMapping creates a new ingest mapping and sets m.ID with the new
+// identifier.
+func (s *Service) CreateIngestMapping(ctx context.Context, m *influxdb.IngestMapping) error {
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	now := s.now().UTC()
+	r := row{
+		ID:          s.idGenerator.ID(),
+		OrgID:       m.OrgID,
+		Name:        m.Name,
+		Description: m.Description,
+		Spec:        spec(m.Spec),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	query, args, err := sq.Insert("ingest_mappings").
+		Columns(selectColumns...).
+		Values(r.ID, r.OrgID, r.Name, r.Description, r.Spec, r.CreatedAt, r.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return influxdb.ErrIngestMappingExists
+		}
+		return err
+	}
+
+	*m = *r.toInfluxDB()
+	return nil
+}
+
+// UpdateIngestMapping updates a single ingest mapping.
+func (s *Service) UpdateIngestMapping(ctx context.Context, id platform.ID, upd influxdb.IngestMappingUpdate) (*influxdb.IngestMapping, error) {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	m, err := s.FindIngestMappingByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if upd.Name != nil {
+		m.Name = *upd.Name
+	}
+	if upd.Description != nil {
+		m.Description = *upd.Description
+	}
+	if upd.Spec != nil {
+		m.Spec = *upd.Spec
+	}
+	if err := m.Validate(); err != nil {
+		return nil, err
+	}
+
+	query, args, err := sq.Update("ingest_mappings").
+		SetMap(sq.Eq{
+			"name":        m.Name,
+			"description": m.Description,
+			"spec":        spec(m.Spec),
+			"updated_at":  s.now().UTC(),
+		}).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return nil, influxdb.ErrIngestMappingExists
+		}
+		return nil, err
+	}
+
+	// The types of the columns returned by RETURNING are not known to the
+	// driver, so the timestamps are read back with a query.
+	return s.FindIngestMappingByID(ctx, id)
+}
+
+// DeleteIngestMapping removes an ingest mapping by ID.
+func (s *Service) DeleteIngestMapping(ctx context.Context, id platform.ID) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("ingest_mappings").
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var d platform.ID
+	if err := s.store.DB.GetContext(ctx, &d, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return influxdb.ErrIngestMappingNotFound
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package ingest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx   = context.Background()
+	orgID = platform.ID(10)
+)
+
+func newSensorMapping() *influxdb.IngestMapping {
+	return &influxdb.IngestMapping{
+		OrgID: orgID,
+		Name:  "sensors",
+		Spec: influxdb.IngestSpec{
+			Measurement: "sensor",
+			Tags:        []influxdb.IngestColumn{{Column: "device.id", Name: "device"}},
+			Fields:      []influxdb.IngestColumn{{Column: "temp", Type: influxdb.IngestDataTypeFloat}},
+			Time:        &influxdb.IngestColumn{Column: "ts", Format: influxdb.IngestTimeUnixMs},
+		},
+	}
+}
+
+func TestService_CRUD(t *testing.T) {
+	svc := newTestService(t)
+
+	m := newSensorMapping()
+	require.NoError(t, svc.CreateIngestMapping(ctx, m))
+	require.True(t, m.ID.Valid())
+	require.False(t, m.CreatedAt.IsZero())
+
+	got, err := svc.FindIngestMappingByID(ctx, m.ID)
+	require.NoError(t, err)
+	require.Equal(t, m, got)
+
+	require.Equal(t, influxdb.ErrIngestMappingExists, svc.CreateIngestMapping(ctx, newSensorMapping()))
+
+	invalid := newSensorMapping()
+	invalid.Name = "invalid"
+	invalid.Spec.Fields = nil
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.CreateIngestMapping(ctx, invalid)))
+
+	other := newSensorMapping()
+	other.Name = "meters"
+	require.NoError(t, svc.CreateIngestMapping(ctx, other))
+
+	otherOrg := newSensorMapping()
+	otherOrg.OrgID = platform.ID(11)
+	require.NoError(t, svc.CreateIngestMapping(ctx, otherOrg))
+
+	list, err := svc.FindIngestMappings(ctx, influxdb.IngestMappingFilter{OrgID: orgID})
+	require.NoError(t, err)
+	require.Equal(t, []*influxdb.IngestMapping{other, m}, list)
+
+	name := "sensors"
+	list, err = svc.FindIngestMappings(ctx, influxdb.IngestMappingFilter{OrgID: orgID, Name: &name})
+	require.NoError(t, err)
+	require.Equal(t, []*influxdb.IngestMapping{m}, list)
+
+	desc := "room sensors"
+	spec := m.Spec
+	spec.Measurement = "room"
+	updated, err := svc.UpdateIngestMapping(ctx, m.ID, influxdb.IngestMappingUpdate{Description: &desc, Spec: &spec})
+	require.NoError(t, err)
+	require.Equal(t, "sensors", updated.Name)
+	require.Equal(t, desc, updated.Description)
+	require.Equal(t, spec, updated.Spec)
+
+	_, err = svc.UpdateIngestMapping(ctx, m.ID, influxdb.IngestMappingUpdate{Name: &other.Name})
+	require.Equal(t, influxdb.ErrIngestMappingExists, err)
+
+	spec.Fields = nil
+	_, err = svc.UpdateIngestMapping(ctx, m.ID, influxdb.IngestMappingUpdate{Spec: &spec})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	require.NoError(t, svc.DeleteIngestMapping(ctx, m.ID))
+	_, err = svc.FindIngestMappingByID(ctx, m.ID)
+	require.Equal(t, influxdb.ErrIngestMappingNotFound, err)
+	require.Equal(t, influxdb.ErrIngestMappingNotFound, svc.DeleteIngestMapping(ctx, m.ID))
+	_, err = svc.UpdateIngestMapping(ctx, m.ID, influxdb.IngestMappingUpdate{Description: &desc})
+	require.Equal(t, influxdb.ErrIngestMappingNotFound, err)
+}
+
+func newTestService(t *testing.T) *Service {
+	store, clean := sqlite.NewTestStore(t)
+	t.Cleanup(func() { clean(t) })
+	require.NoError(t, sqlite.NewMigrator(store, zaptest.NewLogger(t)).Up(ctx, migrations.AllUp))
+
+	svc := NewService(store)
+	svc.idGenerator = mock.NewIncrementingIDGenerator(platform.ID(1))
+	return svc
+}
@@ -0,0 +1,225 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// IngestFormat is a format of documents which an ingest mapping converts to
+// points.
+type IngestFormat string
+
+const (
+	// IngestFormatNDJSON is newline-delimited JSON, one object per record.
+	IngestFormatNDJSON IngestFormat = "ndjson"
+	// IngestFormatCSV is CSV with a header row, optionally preceded by the
+	// #datatype and #default annotation rows of annotated CSV.
+	IngestFormatCSV IngestFormat = "csv"
+)
+
+// IngestDataType is the type of a field written from a column.
+type IngestDataType string
+
+const (
+	IngestDataTypeFloat    IngestDataType = "float"
+	IngestDataTypeInteger  IngestDataType = "integer"
+	IngestDataTypeUnsigned IngestDataType = "unsigned"
+	IngestDataTypeString   IngestDataType = "string"
+	IngestDataTypeBoolean  IngestDataType = "boolean"
+)
+
+// Time formats of the time column of an ingest mapping. Any other format is
+// a Go time layout, such as "2006-01-02 15:04:05".
+const (
+	IngestTimeRFC3339 = "rfc3339"
+	IngestTimeUnix    = "unix"
+	IngestTimeUnixMs  = "unix_ms"
+	IngestTimeUnixUs  = "unix_us"
+	IngestTimeUnixNs  = "unix_ns"
+)
+
+// IngestColumn maps a column of the records of a document to a tag, a field
+// or the time of the points written from them. The column is a key of the
+// objects of JSON documents, where nested keys are separated by dots, or a
+// header of CSV documents.
+type IngestColumn struct {
+	Column string `json:"column"`
+	// Name is the tag or field key, the column if empty.
+	Name string `json:"name,omitempty"`
+	// Type is the type of a field. If empty, JSON numbers are floats and
+	// CSV values are typed by their #datatype annotation, or else inferred
+	// as floats, booleans or strings.
+	Type IngestDataType `json:"type,omitempty"`
+	// Format is the format of the time column, rfc3339 if empty.
+	Format string `json:"format,omitempty"`
+}
+
+// Key returns the tag or field key written from the column.
+func (c IngestColumn) Key() string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return c.Column
+}
+
+// IngestSpec declares how the records of a document become points: every
+// record is a point of the measurement, tags and fields mapped from its
+// columns. Columns missing from a record are left out of its point, except
+// for the time column.
+type IngestSpec struct {
+	// Measurement is the measurement of every point, unless
+	// MeasurementColumn names the column holding it.
+	Measurement       string `json:"measurement,omitempty"`
+	MeasurementColumn string `json:"measurementColumn,omitempty"`
+
+	Tags   []IngestColumn `json:"tags,omitempty"`
+	Fields []IngestColumn `json:"fields"`
+	// Time is the column holding the time of the points. Points are written
+	// at the time of the request if it is nil.
+	Time *IngestColumn `json:"time,omitempty"`
+}
+
+// Validate returns an error if the spec cannot be used to convert records.
+func (s *IngestSpec) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "invalid ingest mapping spec: " + fmt.Sprintf(format, args...),
+		}
+	}
+
+	if (s.Measurement == "") == (s.MeasurementColumn == "") {
+		return invalid("exactly one of measurement and measurementColumn is required")
+	}
+	if len(s.Fields) == 0 {
+		return invalid("at least one field is required")
+	}
+
+	keys := make(map[string]bool)
+	for _, c := range s.Tags {
+		if c.Column == "" {
+			return invalid("tag column is required")
+		}
+		if c.Type != "" || c.Format != "" {
+			return invalid("tag %q has a type or format", c.Key())
+		}
+		if keys[c.Key()] {
+			return invalid("key %q is mapped more than once", c.Key())
+		}
+		keys[c.Key()] = true
+	}
+	for _, c := range s.Fields {
+		if c.Column == "" {
+			return invalid("field column is required")
+		}
+		switch c.Type {
+		case "", IngestDataTypeFloat, IngestDataTypeInteger, IngestDataTypeUnsigned, IngestDataTypeString, IngestDataTypeBoolean:
+		default:
+			return invalid("field %q has unknown type %q", c.Key(), c.Type)
+		}
+		if c.Format != "" {
+			return invalid("field %q has a format", c.Key())
+		}
+		if keys[c.Key()] {
+			return invalid("key %q is mapped more than once", c.Key())
+		}
+		keys[c.Key()] = true
+	}
+	if t := s.Time; t != nil {
+		if t.Column == "" {
+			return invalid("time column is required")
+		}
+		if t.Name != "" || t.Type != "" {
+			return invalid("time has a name or type")
+		}
+		switch f := t.Format; f {
+		case "", IngestTimeRFC3339, IngestTimeUnix, IngestTimeUnixMs, IngestTimeUnixUs, IngestTimeUnixNs:
+		default:
+			// The elements of Go time layouts are all numbers.
+			if strings.HasPrefix(f, "unix") || !strings.ContainsAny(f, "0123456789") {
+				return invalid("unknown time format %q", f)
+			}
+		}
+	}
+	return nil
+}
+
+// IngestMapping is an ingest spec stored under a name, which writes of JSON
+// and CSV documents of its organization may refer to.
+type IngestMapping struct {
+	ID          platform.ID `json:"id"`
+	OrgID       platform.ID `json:"orgID"`
+	Name        string      `json:"name"`
+	Description string      `json:"description,omitempty"`
+	Spec        IngestSpec  `json:"spec"`
+	CRUDLog
+}
+
+// Validate returns an error if the mapping cannot be stored.
+func (m *IngestMapping) Validate() error {
+	if !m.OrgID.Valid() {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "ingest mapping organization ID is required",
+		}
+	}
+	if strings.TrimSpace(m.Name) == "" {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "ingest mapping name is required",
+		}
+	}
+	return m.Spec.Validate()
+}
+
+// IngestMappingFilter selects the ingest mappings of an organization.
+type IngestMappingFilter struct {
+	OrgID platform.ID
+	Name  *string
+}
+
+// IngestMappingUpdate is a partial update of an ingest mapping.
+type IngestMappingUpdate struct {
+	Name        *string     `json:"name,omitempty"`
+	Description *string     `json:"description,omitempty"`
+	Spec        *IngestSpec `json:"spec,omitempty"`
+}
+
+var (
+	// ErrIngestMappingNotFound is returned when an ingest mapping does not exist.
+	ErrIngestMappingNotFound = &errors.Error{
+		Code: errors.ENotFound,
+		Msg:  "ingest mapping not found",
+	}
+
+	// ErrIngestMappingExists is returned when an organization already has an
+	// ingest mapping with the same name.
+	ErrIngestMappingExists = &errors.Error{
+		Code: errors.EConflict,
+		Msg:  "ingest mapping with name already exists",
+	}
+)
+
+// IngestMappingService manages the ingest mappings of organizations.
+type IngestMappingService interface {
+	// FindIngestMappingByID returns a single ingest mapping by ID.
+	FindIngestMappingByID(ctx context.Context, id platform.ID) (*IngestMapping, error)
+
+	// FindIngestMappings returns the ingest mappings matching filter, sorted
+	// by name.
+	FindIngestMappings(ctx context.Context, filter IngestMappingFilter) ([]*IngestMapping, error)
+
+	// CreateIngestMapping creates a new ingest mapping and sets m.ID with the
+	// new identifier.
+	CreateIngestMapping(ctx context.Context, m *IngestMapping) error
+
+	// UpdateIngestMapping updates a single ingest mapping.
+	UpdateIngestMapping(ctx context.Context, id platform.ID, upd IngestMappingUpdate) (*IngestMapping, error)
+
+	// DeleteIngestMapping removes an ingest mapping by ID.
+	DeleteIngestMapping(ctx context.Context, id platform.ID) error
+}
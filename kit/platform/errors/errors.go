@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 )
 
 // Some error code constant, ideally we want define common platform codes here
@@ -197,6 +198,23 @@ func ErrorMessage(err error) string {
 	return "An internal error has occurred."
 }
 
+// RetryAfter returns how long to wait before retrying the operation that
+// failed with err, if err or an error it wraps tells with a
+// RetryAfter() time.Duration method; otherwise it returns zero.
+func RetryAfter(err error) time.Duration {
+	for err != nil {
+		if r, ok := err.(interface{ RetryAfter() time.Duration }); ok {
+			return r.RetryAfter()
+		}
+		if e, ok := err.(*Error); ok {
+			err = e.Err
+		} else {
+			err = errors.Unwrap(err)
+		}
+	}
+	return 0
+}
+
 // errEncode an JSON encoding helper that is needed to handle the recursive stack of errors.
 type errEncode struct {
 	Code string      `json:"code"`              // Code is the machine-readable error code.
@@ -227,9 +227,9 @@ func (a *API) Err(w http.ResponseWriter, r *http.Request, err error) {
 
 	a.logErr("api error encountered", zap.Error(err))
 
-	v, status, err := a.errFn(r.Context(), err)
-	if err != nil {
-		a.logErr("failed to write err to response writer", zap.Error(err))
+	v, status, encErr := a.errFn(r.Context(), err)
+	if encErr != nil {
+		a.logErr("failed to write err to response writer", zap.Error(encErr))
 		a.Respond(w, r, http.StatusInternalServerError, ErrBody{
 			Code: "internal error",
 			Msg:  "an unexpected error occurred",
@@ -240,6 +240,7 @@ func (a *API) Err(w http.ResponseWriter, r *http.Request, err error) {
 	if eb, ok := v.(ErrBody); ok {
 		w.Header().Set(PlatformErrorCodeHeader, eb.Code)
 	}
+	SetRetryAfter(w, err)
 
 	a.Respond(w, r, status, v)
 }
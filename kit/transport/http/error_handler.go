@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"mime"
 	"net/http"
+	"strconv"
 	"strings"
 
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -42,9 +44,22 @@ func (h ErrorHandler) HandleHTTPError(ctx context.Context, err error, w http.Res
 		h.logger.Warn("internal error not returned to client", zap.Error(err))
 	}
 
+	SetRetryAfter(w, err)
 	WriteErrorResponse(ctx, w, code, msg)
 }
 
+// RetryAfterHeader tells clients how many seconds to wait before retrying a
+// request.
+const RetryAfterHeader = "Retry-After"
+
+// SetRetryAfter sets the Retry-After header on the response if err tells when
+// the failed request may be retried.
+func SetRetryAfter(w http.ResponseWriter, err error) {
+	if d := errors2.RetryAfter(err); d > 0 {
+		w.Header().Set(RetryAfterHeader, strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10))
+	}
+}
+
 func WriteErrorResponse(ctx context.Context, w http.ResponseWriter, code string, msg string) {
 	w.Header().Set(PlatformErrorCodeHeader, code)
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/influxdata/influxdb/v2/http"
 	"github.com/influxdata/influxdb/v2/kit/platform/errors"
@@ -56,3 +57,27 @@ func TestEncodeErrorWithError(t *testing.T) {
 		t.Errorf("unexpected message -want/+got:\n\t- %q\n\t+ %q", want, got)
 	}
 }
+
+type retryAfterError time.Duration
+
+func (e retryAfterError) Error() string             { return "slow down" }
+func (e retryAfterError) RetryAfter() time.Duration { return time.Duration(e) }
+
+func TestEncodeErrorWithRetryAfter(t *testing.T) {
+	ctx := context.TODO()
+	err := &errors.Error{
+		Code: errors.ETooManyRequests,
+		Err:  retryAfterError(1500 * time.Millisecond),
+	}
+
+	w := httptest.NewRecorder()
+
+	kithttp.NewErrorHandler(zaptest.NewLogger(t)).HandleHTTPError(ctx, err, w)
+
+	if w.Code != 429 {
+		t.Errorf("expected status code 429, got: %d", w.Code)
+	}
+	if got := w.Header().Get("Retry-After"); got != "2" {
+		t.Errorf("expected Retry-After: 2, got: %q", got)
+	}
+}
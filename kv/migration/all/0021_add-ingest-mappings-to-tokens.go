@@ -0,0 +1,60 @@
+package all
+
+import (
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+var Migration0021_AddIngestMappingsToTokens = &Migration{
+	name: "add ingest mappings resource type to operator and all-access tokens",
+	up: migrateTokensMigration(
+		func(t influxdb.Authorization) bool {
+			return permListsMatch(preIngestMappingsOpPerms(), t.Permissions) ||
+				permListsMatch(preIngestMappingsAllAccessPerms(t.OrgID, t.UserID), t.Permissions)
+		},
+		func(t *influxdb.Authorization) {
+			if permListsMatch(preIngestMappingsOpPerms(), t.Permissions) {
+				t.Permissions = append(t.Permissions, ingestMappingsPerms(0)...)
+			} else {
+				t.Permissions = append(t.Permissions, ingestMappingsPerms(t.OrgID)...)
+			}
+		},
+	),
+	down: migrateTokensMigration(
+		func(t influxdb.Authorization) bool {
+			return permListsMatch(append(preIngestMappingsOpPerms(), ingestMappingsPerms(0)...), t.Permissions) ||
+				permListsMatch(append(preIngestMappingsAllAccessPerms(t.OrgID, t.UserID), ingestMappingsPerms(t.OrgID)...), t.Permissions)
+		},
+		func(t *influxdb.Authorization) {
+			newPerms := t.Permissions[:0]
+			for _, p := range t.Permissions {
+				if p.Resource.Type != influxdb.IngestMappingsResourceType {
+					newPerms = append(newPerms, p)
+				}
+			}
+			t.Permissions = newPerms
+		},
+	),
+}
+
+func preIngestMappingsOpPerms() []influxdb.Permission {
+	return append(preReplicationOpPerms(), remotesAndReplicationsPerms(0)...)
+}
+
+func preIngestMappingsAllAccessPerms(orgID platform.ID, userID platform.ID) []influxdb.Permission {
+	return append(preReplicationAllAccessPerms(orgID, userID), remotesAndReplicationsPerms(orgID)...)
+}
+
+func ingestMappingsPerms(orgID platform.ID) []influxdb.Permission {
+	perms := permListFromResources([]influxdb.Resource{
+		{
+			Type: influxdb.IngestMappingsResourceType,
+		},
+	})
+	if orgID.Valid() {
+		for i := range perms {
+			perms[i].Resource.OrgID = &orgID
+		}
+	}
+	return perms
+}
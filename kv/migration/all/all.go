@@ -47,5 +47,7 @@ var Migrations = [...]migration.Spec{
 	Migration0019_AddRemotesReplicationsToTokens,
 	// add_remotes_replications_metrics_buckets
 	Migration0020_Add_remotes_replications_metrics_buckets,
+	// add ingest mappings resource type to operator and all-access tokens
+	Migration0021_AddIngestMappingsToTokens,
 	// {{ do_not_edit . }}
 }
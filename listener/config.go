@@ -0,0 +1,166 @@
+package listener
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/influxdata/influxdb/v2/models"
+	itoml "github.com/influxdata/influxdb/v2/toml"
+)
+
+const (
+	// DefaultBatchSize is the number of points written at once unless a
+	// batch size is given.
+	DefaultBatchSize = 5000
+
+	// DefaultBatchTimeout is how long points wait for their batch to fill
+	// before being written unless a timeout is given.
+	DefaultBatchTimeout = time.Second
+
+	// DefaultSeparator joins the parts of Graphite metric names which make
+	// up a measurement, field or tag value unless a separator is given.
+	DefaultSeparator = "."
+)
+
+// The protocols listened on.
+const (
+	ProtocolUDP = "udp"
+	ProtocolTCP = "tcp"
+)
+
+// The formats of the data received.
+const (
+	FormatLineProtocol = "line"
+	FormatGraphite     = "graphite"
+)
+
+// Config configures a listener.
+type Config struct {
+	// Name identifies the listener in logs and metrics.
+	Name string `toml:"name"`
+
+	// Protocol is udp or tcp.
+	Protocol string `toml:"protocol"`
+	// BindAddress is the address listened on.
+	BindAddress string `toml:"bind-address"`
+	// Format is line, for line protocol, or graphite, for the Graphite
+	// plaintext protocol.
+	Format string `toml:"format"`
+
+	// Bucket is the name or ID of the bucket written to, in the
+	// organization of Token.
+	Bucket string `toml:"bucket"`
+	// Token is the API token the points are written with. It must be
+	// allowed to write to Bucket.
+	Token string `toml:"token"`
+
+	// BatchSize is the number of points written at once.
+	BatchSize int `toml:"batch-size"`
+	// BatchTimeout is how long points wait for their batch to fill.
+	BatchTimeout itoml.Duration `toml:"batch-timeout"`
+	// ReadBuffer is the size of the socket receive buffer of UDP listeners.
+	// The system default is used if it is zero.
+	ReadBuffer int `toml:"read-buffer"`
+
+	// Precision is the precision of the timestamps of line protocol.
+	Precision string `toml:"precision"`
+
+	// Separator joins the parts of Graphite metric names.
+	Separator string `toml:"separator"`
+	// Templates are the templates turning Graphite metric names into
+	// measurements, fields and tags, as described by NewGraphiteParser.
+	Templates []string `toml:"templates"`
+	// Tags are tags, as key=value, added to every Graphite point.
+	Tags []string `toml:"tags"`
+}
+
+// WithDefaults returns c with the defaults of the settings it does not set.
+func (c Config) WithDefaults() Config {
+	if c.BatchSize == 0 {
+		c.BatchSize = DefaultBatchSize
+	}
+	if c.BatchTimeout == 0 {
+		c.BatchTimeout = itoml.Duration(DefaultBatchTimeout)
+	}
+	if c.Precision == "" {
+		c.Precision = "ns"
+	}
+	if c.Separator == "" {
+		c.Separator = DefaultSeparator
+	}
+	return c
+}
+
+// Validate returns an error if the Config is invalid.
+func (c Config) Validate() error {
+	if c.Name == "" {
+		return errors.New("name is required")
+	}
+	if c.Protocol != ProtocolUDP && c.Protocol != ProtocolTCP {
+		return fmt.Errorf("invalid protocol %q; valid protocols are %s and %s", c.Protocol, ProtocolUDP, ProtocolTCP)
+	}
+	if c.BindAddress == "" {
+		return errors.New("bind-address is required")
+	}
+	if c.Bucket == "" {
+		return errors.New("bucket is required")
+	}
+	if c.Token == "" {
+		return errors.New("token is required")
+	}
+	if c.BatchSize < 0 {
+		return errors.New("batch-size must not be negative")
+	}
+	if c.BatchTimeout < 0 {
+		return errors.New("batch-timeout must not be negative")
+	}
+
+	switch c.Format {
+	case FormatLineProtocol:
+		if c.Precision != "" && !models.ValidPrecision(c.Precision) {
+			return fmt.Errorf("invalid precision %q; valid precisions are ns, us, ms and s", c.Precision)
+		}
+	case FormatGraphite:
+		if _, err := NewGraphiteParser(c.WithDefaults().Separator, c.Templates, c.Tags); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("invalid format %q; valid formats are %s and %s", c.Format, FormatLineProtocol, FormatGraphite)
+	}
+	return nil
+}
+
+// Configs are the listeners of a listeners config file.
+type Configs struct {
+	Listeners []Config `toml:"listener"`
+}
+
+// Validate returns an error if a listener is invalid or two share a name.
+func (c Configs) Validate() error {
+	names := make(map[string]bool, len(c.Listeners))
+	for i, l := range c.Listeners {
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("listener %d: %w", i, err)
+		}
+		if names[l.Name] {
+			return fmt.Errorf("listener %d: duplicate name %q", i, l.Name)
+		}
+		names[l.Name] = true
+	}
+	return nil
+}
+
+// LoadConfigs reads and validates the listeners of the TOML file at path,
+// whose [[listener]] tables are each decoded as a Config.
+func LoadConfigs(path string) (Configs, error) {
+	var c Configs
+	if _, err := toml.DecodeFile(path, &c); err != nil {
+		return Configs{}, fmt.Errorf("reading listeners config: %w", err)
+	}
+	if err := c.Validate(); err != nil {
+		return Configs{}, fmt.Errorf("invalid listeners config: %w", err)
+	}
+	return c, nil
+}
@@ -0,0 +1,288 @@
+package listener
+
+import (
+	"fmt"
+	"math"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// GraphiteParser parses lines of the Graphite plaintext protocol,
+//
+//	<metric name> <value> [<unix timestamp>]
+//
+// into points. The parts of the dot-separated metric name are turned into the
+// measurement, the field and tags of the point by the first template matching
+// it, the most specific filter matching first.
+//
+// A template is "[filter] template [tags]":
+//
+//   - the filter is a metric name pattern whose parts are matched as by
+//     path.Match, so that "servers.*.cpu" matches "servers.a.cpu.idle";
+//   - the template names each part of the metric name: "measurement" and
+//     "field" append it to the measurement or field name, "measurement*" and
+//     "field*" append it and every part after it, an empty part skips it and
+//     any other name makes it the value of a tag of that name;
+//   - the tags, as "k1=v1,k2=v2", are added to every point of the template.
+//
+// Parts appended to the same name are joined by the separator. A metric
+// without measurement parts uses its whole name as measurement, and one
+// without field parts stores its value in the field "value". Timestamps are
+// in seconds, possibly fractional; -1 or none is the time the line is parsed.
+type GraphiteParser struct {
+	separator string
+	templates []*graphiteTemplate
+	tags      models.Tags
+}
+
+// defaultGraphiteTemplate is used if no template matches a metric name.
+var defaultGraphiteTemplate = &graphiteTemplate{parts: []string{"measurement*"}}
+
+// NewGraphiteParser returns a GraphiteParser joining the parts of metric
+// names with separator, applying templates and adding tags, as key=value, to
+// every point.
+func NewGraphiteParser(separator string, templates []string, tags []string) (*GraphiteParser, error) {
+	p := &GraphiteParser{separator: separator}
+
+	for _, s := range templates {
+		t, err := parseGraphiteTemplate(s)
+		if err != nil {
+			return nil, err
+		}
+		p.templates = append(p.templates, t)
+	}
+
+	for _, s := range tags {
+		k, v, ok := strings.Cut(s, "=")
+		if !ok || k == "" || v == "" {
+			return nil, fmt.Errorf("invalid graphite tag %q; tags must be key=value", s)
+		}
+		p.tags = append(p.tags, models.NewTag([]byte(k), []byte(v)))
+	}
+	return p, nil
+}
+
+// Parse parses a line into a point. now is the time of points without a
+// timestamp.
+func (p *GraphiteParser) Parse(line string, now time.Time) (models.Point, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 2 && len(fields) != 3 {
+		return nil, fmt.Errorf("received %q which doesn't have required fields", line)
+	}
+	name := fields[0]
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("field %q value: %w", name, err)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return nil, fmt.Errorf("field %q value: unsupported value %s", name, fields[1])
+	}
+
+	ts := now
+	if len(fields) == 3 {
+		sec, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, fmt.Errorf("field %q time: %w", name, err)
+		}
+		if sec != -1 {
+			whole, frac := math.Modf(sec)
+			ts = time.Unix(int64(whole), int64(frac*float64(time.Second))).UTC()
+			if err := models.CheckTime(ts); err != nil {
+				return nil, fmt.Errorf("field %q time: %w", name, err)
+			}
+		}
+	}
+
+	parts := strings.Split(name, ".")
+	measurement, field, tags := p.match(parts).apply(parts, p.separator)
+	if measurement == "" {
+		measurement = name
+	}
+	if field == "" {
+		field = "value"
+	}
+	for _, t := range p.tags {
+		if _, ok := tags[string(t.Key)]; !ok {
+			tags[string(t.Key)] = string(t.Value)
+		}
+	}
+
+	return models.NewPoint(measurement, models.NewTags(tags), models.Fields{field: value}, ts)
+}
+
+// match returns the template of the most specific filter matching the parts
+// of a metric name.
+func (p *GraphiteParser) match(parts []string) *graphiteTemplate {
+	var best *graphiteTemplate
+	for _, t := range p.templates {
+		if t.matches(parts) && (best == nil || t.moreSpecific(best)) {
+			best = t
+		}
+	}
+	if best == nil {
+		return defaultGraphiteTemplate
+	}
+	return best
+}
+
+// graphiteTemplate is a template of a GraphiteParser.
+type graphiteTemplate struct {
+	// filter is the parts of the filter, which is empty if the template
+	// matches every metric name.
+	filter []string
+	parts  []string
+	tags   map[string]string
+}
+
+func parseGraphiteTemplate(s string) (*graphiteTemplate, error) {
+	var filter, template, tags string
+	switch fields := strings.Fields(s); len(fields) {
+	case 1:
+		template = fields[0]
+	case 2:
+		if strings.Contains(fields[1], "=") {
+			template, tags = fields[0], fields[1]
+		} else {
+			filter, template = fields[0], fields[1]
+		}
+	case 3:
+		filter, template, tags = fields[0], fields[1], fields[2]
+	default:
+		return nil, fmt.Errorf("invalid graphite template %q; templates are [filter] template [tags]", s)
+	}
+
+	t := &graphiteTemplate{parts: strings.Split(template, ".")}
+	if filter != "" {
+		t.filter = strings.Split(filter, ".")
+		for _, f := range t.filter {
+			if _, err := path.Match(f, ""); err != nil {
+				return nil, fmt.Errorf("invalid graphite template %q: filter: %w", s, err)
+			}
+		}
+	}
+
+	var measurementGreedy, field, fieldGreedy bool
+	for _, part := range t.parts {
+		switch part {
+		case "measurement*":
+			measurementGreedy = true
+		case "field":
+			field = true
+		case "field*":
+			fieldGreedy = true
+		}
+	}
+	if field && fieldGreedy {
+		return nil, fmt.Errorf("invalid graphite template %q: either 'field' or 'field*' can be used, but not both", s)
+	}
+	if measurementGreedy && fieldGreedy {
+		return nil, fmt.Errorf("invalid graphite template %q: either 'measurement*' or 'field*' can be used, but not both", s)
+	}
+
+	if tags != "" {
+		t.tags = make(map[string]string)
+		for _, kv := range strings.Split(tags, ",") {
+			k, v, ok := strings.Cut(kv, "=")
+			if !ok || k == "" || v == "" {
+				return nil, fmt.Errorf("invalid graphite template %q: invalid tag %q", s, kv)
+			}
+			t.tags[k] = v
+		}
+	}
+	return t, nil
+}
+
+// matches reports whether the filter of t matches the parts of a metric name.
+func (t *graphiteTemplate) matches(parts []string) bool {
+	if len(t.filter) > len(parts) {
+		return false
+	}
+	for i, f := range t.filter {
+		if ok, _ := path.Match(f, parts[i]); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// moreSpecific reports whether the filter of t is more specific than the
+// filter of o. Their parts are compared in order: a part without wildcards is
+// more specific than one with them, and a part with wildcards is more
+// specific than one with fewer other characters. A filter is more specific
+// than its prefix.
+func (t *graphiteTemplate) moreSpecific(o *graphiteTemplate) bool {
+	for i := 0; i < len(t.filter) && i < len(o.filter); i++ {
+		tw, ow := hasWildcard(t.filter[i]), hasWildcard(o.filter[i])
+		if tw != ow {
+			return ow
+		}
+		if tw {
+			if tl, ol := literalLen(t.filter[i]), literalLen(o.filter[i]); tl != ol {
+				return tl > ol
+			}
+		}
+	}
+	return len(t.filter) > len(o.filter)
+}
+
+const wildcards = `*?[\`
+
+func hasWildcard(s string) bool {
+	return strings.ContainsAny(s, wildcards)
+}
+
+// literalLen returns the number of characters of a filter part which are not
+// wildcards.
+func literalLen(s string) int {
+	n := 0
+	for _, r := range s {
+		if !strings.ContainsRune(wildcards, r) {
+			n++
+		}
+	}
+	return n
+}
+
+// apply returns the measurement, field and tags of a metric name.
+func (t *graphiteTemplate) apply(parts []string, separator string) (measurement, field string, tags map[string]string) {
+	var (
+		measurementParts []string
+		fieldParts       []string
+		tagParts         = make(map[string][]string)
+	)
+	for i, part := range t.parts {
+		if i >= len(parts) {
+			break
+		}
+		switch part {
+		case "measurement":
+			measurementParts = append(measurementParts, parts[i])
+		case "measurement*":
+			measurementParts = append(measurementParts, parts[i:]...)
+		case "field":
+			fieldParts = append(fieldParts, parts[i])
+		case "field*":
+			fieldParts = append(fieldParts, parts[i:]...)
+		case "":
+		default:
+			tagParts[part] = append(tagParts[part], parts[i])
+		}
+		if part == "measurement*" || part == "field*" {
+			break
+		}
+	}
+
+	tags = make(map[string]string, len(t.tags)+len(tagParts))
+	for k, v := range t.tags {
+		tags[k] = v
+	}
+	for k, v := range tagParts {
+		tags[k] = strings.Join(v, separator)
+	}
+	return strings.Join(measurementParts, separator), strings.Join(fieldParts, separator), tags
+}
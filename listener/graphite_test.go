@@ -0,0 +1,123 @@
+package listener
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGraphiteParser_Parse(t *testing.T) {
+	now := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		templates []string
+		tags      []string
+		line      string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name: "default template",
+			line: "servers.localhost.cpu 42 1609459200",
+			want: "servers.localhost.cpu value=42 1609459200000000000",
+		},
+		{
+			name: "without timestamp",
+			line: "cpu 0.5",
+			want: "cpu value=0.5 1614834367000000000",
+		},
+		{
+			name: "current time",
+			line: "cpu 0.5 -1",
+			want: "cpu value=0.5 1614834367000000000",
+		},
+		{
+			name: "fractional timestamp",
+			line: "cpu 1 1609459200.5",
+			want: "cpu value=1 1609459200500000000",
+		},
+		{
+			name:      "tags and measurement",
+			templates: []string{"region.host.measurement*"},
+			line:      "us-west.server01.cpu.load 3 1609459200",
+			want:      "cpu.load,host=server01,region=us-west value=3 1609459200000000000",
+		},
+		{
+			name:      "field",
+			templates: []string{".host.measurement.field*"},
+			line:      "servers.server01.cpu.load.avg 3 1609459200",
+			want:      "cpu,host=server01 load.avg=3 1609459200000000000",
+		},
+		{
+			name:      "most specific filter",
+			templates: []string{"*.* .measurement", "servers.* .host.measurement*", "servers.web* .role.measurement* app=web"},
+			line:      "servers.web01.http.requests 7 1609459200",
+			want:      "http.requests,app=web,role=web01 value=7 1609459200000000000",
+		},
+		{
+			name:      "unmatched filter",
+			templates: []string{"servers.* .host.measurement*"},
+			line:      "stats.cpu 1 1609459200",
+			want:      "stats.cpu value=1 1609459200000000000",
+		},
+		{
+			name:      "global tags",
+			templates: []string{"host.measurement*"},
+			tags:      []string{"dc=eu", "host=default"},
+			line:      "server01.cpu 1 1609459200",
+			want:      "cpu,dc=eu,host=server01 value=1 1609459200000000000",
+		},
+		{
+			name:    "missing value",
+			line:    "cpu",
+			wantErr: true,
+		},
+		{
+			name:    "invalid value",
+			line:    "cpu abc",
+			wantErr: true,
+		},
+		{
+			name:    "NaN",
+			line:    "cpu NaN",
+			wantErr: true,
+		},
+		{
+			name:    "invalid timestamp",
+			line:    "cpu 1 yesterday",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := NewGraphiteParser(".", tt.templates, tt.tags)
+			require.NoError(t, err)
+
+			pt, err := p.Parse(tt.line, now)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, pt.String())
+		})
+	}
+}
+
+func TestNewGraphiteParser_Invalid(t *testing.T) {
+	for _, tmpl := range []string{
+		"measurement.field.field*",
+		"measurement*.field*",
+		"filter measurement tag",
+		"a b c d",
+		"[ measurement",
+	} {
+		_, err := NewGraphiteParser(".", []string{tmpl}, nil)
+		require.Error(t, err, tmpl)
+	}
+
+	_, err := NewGraphiteParser(".", nil, []string{"region"})
+	require.Error(t, err)
+}
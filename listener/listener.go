@@ -0,0 +1,348 @@
+package listener
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"go.uber.org/zap"
+)
+
+// maxUDPPayload is the largest UDP datagram read.
+const maxUDPPayload = 64 * 1024
+
+// maxTCPLine is the longest line read from a TCP connection.
+const maxTCPLine = 1024 * 1024
+
+// batch is points to write with the number of bytes they were parsed from.
+type batch struct {
+	points models.Points
+	bytes  int
+}
+
+// listener receives the points of a Config and writes them in batches.
+type listener struct {
+	Config
+	svc    *Service
+	log    *zap.Logger
+	bucket *influxdb.Bucket
+	m      *listenerMetrics
+
+	// parse parses a line into points.
+	parse func(line []byte, now time.Time) (models.Points, error)
+
+	packetConn net.PacketConn
+	ln         net.Listener
+
+	mu      sync.Mutex
+	pending batch
+	conns   map[net.Conn]struct{}
+	closed  bool
+
+	batches chan batch
+	done    chan struct{}
+	readers sync.WaitGroup
+	flusher sync.WaitGroup
+	writer  sync.WaitGroup
+}
+
+func newListener(s *Service, c Config, bucket *influxdb.Bucket) (*listener, error) {
+	l := &listener{
+		Config:  c,
+		svc:     s,
+		log:     s.log.With(zap.String("listener", c.Name)),
+		bucket:  bucket,
+		m:       s.metrics.listener(c.Name),
+		conns:   make(map[net.Conn]struct{}),
+		batches: make(chan batch, 1),
+		done:    make(chan struct{}),
+	}
+
+	switch c.Format {
+	case FormatGraphite:
+		p, err := NewGraphiteParser(c.Separator, c.Templates, c.Tags)
+		if err != nil {
+			return nil, err
+		}
+		l.parse = func(line []byte, now time.Time) (models.Points, error) {
+			pt, err := p.Parse(string(line), now)
+			if err != nil {
+				return nil, err
+			}
+			return models.Points{pt}, nil
+		}
+	default:
+		l.parse = func(line []byte, now time.Time) (models.Points, error) {
+			return models.ParsePointsWithPrecision(line, now, c.Precision)
+		}
+	}
+	return l, nil
+}
+
+// open binds the address of the listener and starts receiving points.
+func (l *listener) open() error {
+	switch l.Protocol {
+	case ProtocolUDP:
+		conn, err := net.ListenPacket("udp", l.BindAddress)
+		if err != nil {
+			return err
+		}
+		if l.ReadBuffer > 0 {
+			if uc, ok := conn.(*net.UDPConn); ok {
+				if err := uc.SetReadBuffer(l.ReadBuffer); err != nil {
+					conn.Close()
+					return err
+				}
+			}
+		}
+		l.packetConn = conn
+		l.readers.Add(1)
+		go l.serveUDP()
+	default:
+		ln, err := net.Listen("tcp", l.BindAddress)
+		if err != nil {
+			return err
+		}
+		l.ln = ln
+		l.readers.Add(1)
+		go l.serveTCP()
+	}
+
+	l.flusher.Add(1)
+	go l.flushPeriodically()
+	l.writer.Add(1)
+	go l.writeBatches()
+	return nil
+}
+
+// addr returns the address listened on.
+func (l *listener) addr() net.Addr {
+	if l.packetConn != nil {
+		return l.packetConn.LocalAddr()
+	}
+	return l.ln.Addr()
+}
+
+// close stops receiving points and writes the points received.
+func (l *listener) close() {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.closed = true
+	if l.packetConn != nil {
+		l.packetConn.Close()
+	}
+	if l.ln != nil {
+		l.ln.Close()
+	}
+	for c := range l.conns {
+		c.Close()
+	}
+	l.mu.Unlock()
+	l.readers.Wait()
+
+	close(l.done)
+	l.flusher.Wait()
+	l.flush()
+	close(l.batches)
+	l.writer.Wait()
+}
+
+func (l *listener) serveUDP() {
+	defer l.readers.Done()
+
+	buf := make([]byte, maxUDPPayload)
+	for {
+		n, _, err := l.packetConn.ReadFrom(buf)
+		if err != nil {
+			if !l.isClosed() {
+				l.log.Error("Failed to read UDP packet", zap.Error(err))
+				continue
+			}
+			return
+		}
+		l.m.bytesReceived.Add(float64(n))
+
+		// Parsed points refer to the bytes they were parsed from, so the
+		// packet is copied out of the reused buffer.
+		data := make([]byte, n)
+		copy(data, buf[:n])
+		now := time.Now().UTC()
+		for len(data) > 0 {
+			var line []byte
+			if i := bytes.IndexByte(data, '\n'); i >= 0 {
+				line, data = data[:i], data[i+1:]
+			} else {
+				line, data = data, nil
+			}
+			l.receive(line, now)
+		}
+	}
+}
+
+func (l *listener) serveTCP() {
+	defer l.readers.Done()
+
+	for {
+		conn, err := l.ln.Accept()
+		if err != nil {
+			if !l.isClosed() {
+				l.log.Error("Failed to accept TCP connection", zap.Error(err))
+				continue
+			}
+			return
+		}
+
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			conn.Close()
+			return
+		}
+		l.conns[conn] = struct{}{}
+		l.mu.Unlock()
+
+		l.m.connections.Inc()
+		l.readers.Add(1)
+		go l.serveConn(conn)
+	}
+}
+
+func (l *listener) serveConn(conn net.Conn) {
+	defer l.readers.Done()
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		conn.Close()
+		l.m.connections.Dec()
+	}()
+
+	r := bufio.NewReader(conn)
+	for {
+		line, err := r.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			// Lines are read whole unless they are too long.
+			full := append([]byte(nil), line...)
+			for err == bufio.ErrBufferFull && len(full) <= maxTCPLine {
+				line, err = r.ReadSlice('\n')
+				full = append(full, line...)
+			}
+			if len(full) > maxTCPLine {
+				l.log.Error("Closing TCP connection sending a line which is too long", zap.Stringer("remote_addr", conn.RemoteAddr()))
+				l.m.parseErrors.Inc()
+				return
+			}
+			line = full
+		} else {
+			// Parsed points refer to the bytes they were parsed from, so
+			// the line is copied out of the reader's buffer.
+			line = append([]byte(nil), line...)
+		}
+
+		if len(line) > 0 {
+			l.m.bytesReceived.Add(float64(len(line)))
+			l.receive(bytes.TrimRight(line, "\r\n"), time.Now().UTC())
+		}
+		if err != nil {
+			if err != io.EOF && !l.isClosed() {
+				l.log.Info("TCP connection failed", zap.Stringer("remote_addr", conn.RemoteAddr()), zap.Error(err))
+			}
+			return
+		}
+	}
+}
+
+// receive parses a line and adds its points to the pending batch.
+func (l *listener) receive(line []byte, now time.Time) {
+	if len(bytes.TrimSpace(line)) == 0 {
+		return
+	}
+	pts, err := l.parse(line, now)
+	if err != nil {
+		l.m.parseErrors.Inc()
+		l.log.Debug("Dropping line which cannot be parsed", zap.ByteString("line", line), zap.Error(err))
+	}
+	if len(pts) == 0 {
+		return
+	}
+	l.m.pointsReceived.Add(float64(len(pts)))
+
+	l.mu.Lock()
+	l.pending.points = append(l.pending.points, pts...)
+	l.pending.bytes += len(line)
+	var full batch
+	if len(l.pending.points) >= l.BatchSize {
+		full, l.pending = l.pending, batch{}
+	}
+	l.mu.Unlock()
+
+	if len(full.points) > 0 {
+		l.batches <- full
+	}
+}
+
+// flush queues the pending points to be written.
+func (l *listener) flush() {
+	l.mu.Lock()
+	b := l.pending
+	l.pending = batch{}
+	l.mu.Unlock()
+
+	if len(b.points) > 0 {
+		l.batches <- b
+	}
+}
+
+// flushPeriodically flushes the pending points every batch timeout, so that
+// points wait at most that long to be written.
+func (l *listener) flushPeriodically() {
+	defer l.flusher.Done()
+
+	ticker := time.NewTicker(time.Duration(l.BatchTimeout))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-l.done:
+			return
+		case <-ticker.C:
+			l.flush()
+		}
+	}
+}
+
+// writeBatches writes the queued batches until the listener is closed.
+func (l *listener) writeBatches() {
+	defer l.writer.Done()
+
+	for b := range l.batches {
+		err := l.svc.write(l, &b)
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			l.m.pointsWritten.Add(float64(len(b.points) - perr.Dropped))
+			l.m.pointsDropped.Add(float64(perr.Dropped))
+			l.log.Debug("Points were dropped writing batch", zap.Error(err))
+		} else if err != nil {
+			l.m.pointsDropped.Add(float64(len(b.points)))
+			l.m.writeErrors.Inc()
+			l.log.Error("Failed to write batch", zap.Int("points", len(b.points)), zap.Error(err))
+			continue
+		} else {
+			l.m.pointsWritten.Add(float64(len(b.points)))
+		}
+		l.m.batchesWritten.Inc()
+	}
+}
+
+func (l *listener) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
@@ -0,0 +1,83 @@
+package listener
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// metrics are the metrics of the listeners, labelled by listener name.
+type metrics struct {
+	bytesReceived  *prometheus.CounterVec
+	pointsReceived *prometheus.CounterVec
+	parseErrors    *prometheus.CounterVec
+	pointsWritten  *prometheus.CounterVec
+	pointsDropped  *prometheus.CounterVec
+	batchesWritten *prometheus.CounterVec
+	writeErrors    *prometheus.CounterVec
+	connections    *prometheus.GaugeVec
+}
+
+func newMetrics() *metrics {
+	const namespace = "listener"
+	labels := []string{"listener"}
+	counter := func(name, help string) *prometheus.CounterVec {
+		return prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      name,
+			Help:      help,
+		}, labels)
+	}
+
+	return &metrics{
+		bytesReceived:  counter("bytes_received_total", "Number of bytes received by the listener"),
+		pointsReceived: counter("points_received_total", "Number of points parsed by the listener"),
+		parseErrors:    counter("parse_errors_total", "Number of lines dropped by the listener because they could not be parsed"),
+		pointsWritten:  counter("points_written_total", "Number of points written by the listener"),
+		pointsDropped:  counter("points_dropped_total", "Number of points parsed by the listener which could not be written"),
+		batchesWritten: counter("batches_written_total", "Number of batches of points written by the listener"),
+		writeErrors:    counter("write_errors_total", "Number of batches of points the listener failed to write"),
+		connections: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "tcp_connections",
+			Help:      "Number of open TCP connections of the listener",
+		}, labels),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.bytesReceived,
+		m.pointsReceived,
+		m.parseErrors,
+		m.pointsWritten,
+		m.pointsDropped,
+		m.batchesWritten,
+		m.writeErrors,
+		m.connections,
+	}
+}
+
+// listenerMetrics are the metrics of a listener.
+type listenerMetrics struct {
+	bytesReceived  prometheus.Counter
+	pointsReceived prometheus.Counter
+	parseErrors    prometheus.Counter
+	pointsWritten  prometheus.Counter
+	pointsDropped  prometheus.Counter
+	batchesWritten prometheus.Counter
+	writeErrors    prometheus.Counter
+	connections    prometheus.Gauge
+}
+
+// listener returns the metrics of the listener called name.
+func (m *metrics) listener(name string) *listenerMetrics {
+	return &listenerMetrics{
+		bytesReceived:  m.bytesReceived.WithLabelValues(name),
+		pointsReceived: m.pointsReceived.WithLabelValues(name),
+		parseErrors:    m.parseErrors.WithLabelValues(name),
+		pointsWritten:  m.pointsWritten.WithLabelValues(name),
+		pointsDropped:  m.pointsDropped.WithLabelValues(name),
+		batchesWritten: m.batchesWritten.WithLabelValues(name),
+		writeErrors:    m.writeErrors.WithLabelValues(name),
+		connections:    m.connections.WithLabelValues(name),
+	}
+}
@@ -0,0 +1,184 @@
+// Package listener receives line protocol and Graphite plaintext over UDP
+// and TCP, as the listeners of InfluxDB 1.x did, and writes it to buckets.
+//
+// Each listener writes to a single bucket with a bound API token, which must
+// be allowed to write to the bucket when the listener starts and whenever it
+// writes a batch. Points are written in batches of up to a batch size, or
+// once they have waited for the batch timeout. Lines which cannot be parsed
+// and batches which cannot be written are dropped, logged and counted by the
+// metrics of the listener.
+package listener
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2"
+	pcontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// Service runs listeners.
+type Service struct {
+	log     *zap.Logger
+	configs []Config
+
+	auths   influxdb.AuthorizationService
+	buckets influxdb.BucketService
+	writer  storage.PointsWriter
+	limiter influxdb.WriteLimiter
+
+	listeners []*listener
+	metrics   *metrics
+}
+
+// NewService returns a Service running the listeners of configs, writing
+// with writer the points of each to the bucket of its config as the
+// authorization of its token. limiter, if not nil, admits the writes.
+func NewService(log *zap.Logger, configs []Config, auths influxdb.AuthorizationService, buckets influxdb.BucketService, writer storage.PointsWriter, limiter influxdb.WriteLimiter) *Service {
+	return &Service{
+		log:     log,
+		configs: configs,
+		auths:   auths,
+		buckets: buckets,
+		writer:  writer,
+		limiter: limiter,
+		metrics: newMetrics(),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the listeners.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return s.metrics.collectors()
+}
+
+// Open starts the listeners. It fails if a listener cannot bind its address
+// or its token cannot write to its bucket.
+func (s *Service) Open(ctx context.Context) error {
+	for _, c := range s.configs {
+		c = c.WithDefaults()
+		l, err := s.open(ctx, c)
+		if err != nil {
+			_ = s.Close()
+			return fmt.Errorf("listener %q: %w", c.Name, err)
+		}
+		s.listeners = append(s.listeners, l)
+		s.log.Info("Listening",
+			zap.String("listener", c.Name),
+			zap.String("protocol", c.Protocol),
+			zap.String("format", c.Format),
+			zap.String("addr", l.addr().String()))
+	}
+	return nil
+}
+
+func (s *Service) open(ctx context.Context, c Config) (*listener, error) {
+	auth, err := s.authorize(ctx, c.Token, nil)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := s.findBucket(ctx, auth.OrgID, c.Bucket)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkWritePermission(auth, bucket); err != nil {
+		return nil, err
+	}
+
+	l, err := newListener(s, c, bucket)
+	if err != nil {
+		return nil, err
+	}
+	if err := l.open(); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Close stops the listeners, writing the points they received.
+func (s *Service) Close() error {
+	for _, l := range s.listeners {
+		l.close()
+	}
+	s.listeners = nil
+	return nil
+}
+
+// authorize returns the authorization of token, which must be active and,
+// if bucket is not nil, allowed to write to it.
+func (s *Service) authorize(ctx context.Context, token string, bucket *influxdb.Bucket) (*influxdb.Authorization, error) {
+	auth, err := s.auths.FindAuthorizationByToken(ctx, token)
+	if err != nil {
+		if ierrors.ErrorCode(err) == ierrors.ENotFound {
+			return nil, errors.New("token not found")
+		}
+		return nil, err
+	}
+	if !auth.IsActive() {
+		return nil, errors.New("token is inactive")
+	}
+	if bucket != nil {
+		if err := checkWritePermission(auth, bucket); err != nil {
+			return nil, err
+		}
+	}
+	return auth, nil
+}
+
+// findBucket returns the bucket of an organization with the ID or name
+// bucket.
+func (s *Service) findBucket(ctx context.Context, orgID platform.ID, bucket string) (*influxdb.Bucket, error) {
+	if id, err := platform.IDFromString(bucket); err == nil {
+		b, err := s.buckets.FindBucket(ctx, influxdb.BucketFilter{
+			OrganizationID: &orgID,
+			ID:             id,
+		})
+		if err != nil && ierrors.ErrorCode(err) != ierrors.ENotFound {
+			return nil, err
+		} else if err == nil {
+			return b, nil
+		}
+	}
+	return s.buckets.FindBucket(ctx, influxdb.BucketFilter{
+		OrganizationID: &orgID,
+		Name:           &bucket,
+	})
+}
+
+// write writes a batch received by l as the authorization of its token.
+func (s *Service) write(l *listener, b *batch) error {
+	ctx := context.Background()
+	auth, err := s.authorize(ctx, l.Token, l.bucket)
+	if err != nil {
+		return err
+	}
+	ctx = pcontext.SetAuthorizer(ctx, auth)
+
+	if s.limiter != nil {
+		if err := s.limiter.AllowWrite(ctx, l.bucket.OrgID, len(b.points), b.bytes); err != nil {
+			return err
+		}
+	}
+	return s.writer.WritePoints(ctx, l.bucket.OrgID, l.bucket.ID, b.points)
+}
+
+// checkWritePermission returns an error if auth cannot write to bucket.
+func checkWritePermission(auth *influxdb.Authorization, bucket *influxdb.Bucket) error {
+	p, err := influxdb.NewPermissionAtID(bucket.ID, influxdb.WriteAction, influxdb.BucketsResourceType, bucket.OrgID)
+	if err != nil {
+		return err
+	}
+	ps, err := auth.PermissionSet()
+	if err != nil {
+		return err
+	}
+	if !ps.Allowed(*p) {
+		return fmt.Errorf("token is not allowed to write to bucket %q", bucket.Name)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package listener
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/models"
+	itoml "github.com/influxdata/influxdb/v2/toml"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx = context.Background()
+
+	orgID    = platform.ID(10)
+	bucketID = platform.ID(100)
+)
+
+func TestService_UDP(t *testing.T) {
+	pw := &pointsWriter{}
+	svc := newTestService(t, pw, Config{
+		Name:         "udp",
+		Protocol:     ProtocolUDP,
+		BindAddress:  "127.0.0.1:0",
+		Format:       FormatLineProtocol,
+		Bucket:       "metrics",
+		Token:        "token",
+		Precision:    "s",
+		BatchTimeout: itoml.Duration(10 * time.Millisecond),
+	})
+
+	conn, err := net.Dial("udp", svc.listeners[0].addr().String())
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("cpu value=1 1\ncpu value=\nmem value=2 2\n"))
+	require.NoError(t, err)
+
+	points := waitForPoints(t, pw, 2)
+	require.Equal(t, "cpu value=1 1000000000", points[0].String())
+	require.Equal(t, "mem value=2 2000000000", points[1].String())
+
+	m := svc.metrics.listener("udp")
+	require.Equal(t, float64(1), testutil.ToFloat64(m.parseErrors))
+	require.Equal(t, float64(2), testutil.ToFloat64(m.pointsReceived))
+}
+
+func TestService_TCP_Graphite(t *testing.T) {
+	pw := &pointsWriter{}
+	svc := newTestService(t, pw, Config{
+		Name:        "graphite",
+		Protocol:    ProtocolTCP,
+		BindAddress: "127.0.0.1:0",
+		Format:      FormatGraphite,
+		Bucket:      bucketID.String(),
+		Token:       "token",
+		BatchSize:   2,
+		Templates:   []string{"host.measurement*"},
+	})
+
+	conn, err := net.Dial("tcp", svc.listeners[0].addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("server01.cpu.load 3 1609459200\r\nserver02.cpu.load 4 1609459200\n"))
+	require.NoError(t, err)
+
+	points := waitForPoints(t, pw, 2)
+	require.Equal(t, "cpu.load,host=server01 value=3 1609459200000000000", points[0].String())
+	require.Equal(t, "cpu.load,host=server02 value=4 1609459200000000000", points[1].String())
+
+	// The points still pending are written when the listener is closed.
+	_, err = conn.Write([]byte("server03.cpu.load 5 1609459200\n"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	require.Eventually(t, func() bool {
+		return testutil.ToFloat64(svc.metrics.listener("graphite").pointsReceived) == 3
+	}, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, svc.Close())
+	require.Len(t, pw.written(), 3)
+
+	m := svc.metrics.listener("graphite")
+	require.Equal(t, float64(3), testutil.ToFloat64(m.pointsWritten))
+	require.Equal(t, float64(2), testutil.ToFloat64(m.batchesWritten))
+}
+
+func TestService_Open_Unauthorized(t *testing.T) {
+	auths := mock.NewAuthorizationService()
+	auths.FindAuthorizationByTokenFn = func(_ context.Context, token string) (*influxdb.Authorization, error) {
+		return &influxdb.Authorization{OrgID: orgID, Status: influxdb.Active}, nil
+	}
+	svc := NewService(zaptest.NewLogger(t), []Config{{
+		Name:        "udp",
+		Protocol:    ProtocolUDP,
+		BindAddress: "127.0.0.1:0",
+		Format:      FormatLineProtocol,
+		Bucket:      "metrics",
+		Token:       "token",
+	}}, auths, testBuckets(), &pointsWriter{}, nil)
+
+	err := svc.Open(ctx)
+	require.EqualError(t, err, `listener "udp": token is not allowed to write to bucket "metrics"`)
+}
+
+func TestService_RevokedToken(t *testing.T) {
+	pw := &pointsWriter{}
+	svc := newTestService(t, pw, Config{
+		Name:         "udp",
+		Protocol:     ProtocolUDP,
+		BindAddress:  "127.0.0.1:0",
+		Format:       FormatLineProtocol,
+		Bucket:       "metrics",
+		Token:        "token",
+		BatchTimeout: itoml.Duration(time.Hour),
+	})
+	svc.auths.(*mock.AuthorizationService).FindAuthorizationByTokenFn = func(context.Context, string) (*influxdb.Authorization, error) {
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "authorization not found"}
+	}
+
+	l := svc.listeners[0]
+	l.receive([]byte("cpu value=1"), time.Now())
+	require.NoError(t, svc.Close())
+
+	require.Empty(t, pw.written())
+	require.Equal(t, float64(1), testutil.ToFloat64(l.m.writeErrors))
+	require.Equal(t, float64(1), testutil.ToFloat64(l.m.pointsDropped))
+}
+
+func TestLoadConfigs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "listeners.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+[[listener]]
+  name = "graphite"
+  protocol = "tcp"
+  bind-address = ":2003"
+  format = "graphite"
+  bucket = "metrics"
+  token = "token"
+  batch-timeout = "500ms"
+  templates = ["servers.* .host.measurement*"]
+
+[[listener]]
+  name = "udp"
+  protocol = "udp"
+  bind-address = ":8089"
+  format = "line"
+  bucket = "metrics"
+  token = "token"
+`), 0600))
+
+	c, err := LoadConfigs(path)
+	require.NoError(t, err)
+	require.Len(t, c.Listeners, 2)
+	require.Equal(t, itoml.Duration(500*time.Millisecond), c.Listeners[0].BatchTimeout)
+	require.Equal(t, []string{"servers.* .host.measurement*"}, c.Listeners[0].Templates)
+	require.Equal(t, DefaultBatchSize, c.Listeners[1].WithDefaults().BatchSize)
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+[[listener]]
+  name = "udp"
+  protocol = "sctp"
+  bind-address = ":8089"
+  format = "line"
+  bucket = "metrics"
+  token = "token"
+`), 0600))
+	_, err = LoadConfigs(path)
+	require.Error(t, err)
+}
+
+func newTestService(t *testing.T, pw *pointsWriter, c Config) *Service {
+	auths := mock.NewAuthorizationService()
+	auths.FindAuthorizationByTokenFn = func(_ context.Context, token string) (*influxdb.Authorization, error) {
+		if token != "token" {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "authorization not found"}
+		}
+		p, err := influxdb.NewPermissionAtID(bucketID, influxdb.WriteAction, influxdb.BucketsResourceType, orgID)
+		require.NoError(t, err)
+		return &influxdb.Authorization{OrgID: orgID, Status: influxdb.Active, Permissions: []influxdb.Permission{*p}}, nil
+	}
+
+	svc := NewService(zaptest.NewLogger(t), []Config{c}, auths, testBuckets(), pw, nil)
+	prometheus.NewRegistry().MustRegister(svc.PrometheusCollectors()...)
+	require.NoError(t, svc.Open(ctx))
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+func testBuckets() *mock.BucketService {
+	buckets := mock.NewBucketService()
+	buckets.FindBucketFn = func(_ context.Context, f influxdb.BucketFilter) (*influxdb.Bucket, error) {
+		if *f.OrganizationID != orgID || (f.ID != nil && *f.ID != bucketID) || (f.Name != nil && *f.Name != "metrics") {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		}
+		return &influxdb.Bucket{ID: bucketID, OrgID: orgID, Name: "metrics"}, nil
+	}
+	return buckets
+}
+
+// pointsWriter records the points written to it.
+type pointsWriter struct {
+	mu     sync.Mutex
+	points []models.Point
+}
+
+func (w *pointsWriter) WritePoints(_ context.Context, _, _ platform.ID, points []models.Point) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.points = append(w.points, points...)
+	return nil
+}
+
+func (w *pointsWriter) written() []models.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]models.Point(nil), w.points...)
+}
+
+// waitForPoints waits for n points to be written to w.
+func waitForPoints(t *testing.T, w *pointsWriter, n int) []models.Point {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		return len(w.written()) >= n
+	}, 5*time.Second, 10*time.Millisecond)
+	return w.written()
+}
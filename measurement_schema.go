@@ -1,6 +1,7 @@
 package influxdb
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -443,3 +444,37 @@ type MeasurementSchemaColumn struct {
 	Type     SemanticColumnType    `json:"type"`
 	DataType *SchemaColumnDataType `json:"dataType,omitempty"`
 }
+
+// MeasurementSchemaFilter selects the measurement schemas of a bucket.
+type MeasurementSchemaFilter struct {
+	OrgID    influxid.ID
+	BucketID influxid.ID
+	Name     *string
+}
+
+// MeasurementSchemaUpdate replaces the columns of a measurement schema. Columns
+// must hold every existing column unchanged; only new columns may be added.
+type MeasurementSchemaUpdate struct {
+	Columns []MeasurementSchemaColumn `json:"columns"`
+}
+
+// MeasurementSchemaService manages the measurement schemas of buckets with an
+// explicit schema type.
+type MeasurementSchemaService interface {
+	// FindMeasurementSchemaByID returns a single measurement schema by ID.
+	FindMeasurementSchemaByID(ctx context.Context, id influxid.ID) (*MeasurementSchema, error)
+
+	// FindMeasurementSchemas returns the measurement schemas of a bucket
+	// matching filter, sorted by name.
+	FindMeasurementSchemas(ctx context.Context, filter MeasurementSchemaFilter) ([]*MeasurementSchema, error)
+
+	// CreateMeasurementSchema creates a new measurement schema in the bucket
+	// of m and sets m.ID with the new identifier.
+	CreateMeasurementSchema(ctx context.Context, m *MeasurementSchema) error
+
+	// UpdateMeasurementSchema adds columns to a single measurement schema.
+	UpdateMeasurementSchema(ctx context.Context, id influxid.ID, upd MeasurementSchemaUpdate) (*MeasurementSchema, error)
+
+	// DeleteMeasurementSchema removes a measurement schema by ID.
+	DeleteMeasurementSchema(ctx context.Context, id influxid.ID) error
+}
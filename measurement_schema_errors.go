@@ -70,3 +70,25 @@ var (
 		Msg:  "measurement schema columns contains duplicate column names",
 	}
 )
+
+var (
+	ErrMeasurementSchemaNotFound = &influxerror.Error{
+		Code: influxerror.ENotFound,
+		Msg:  "measurement schema not found",
+	}
+
+	ErrMeasurementSchemaExists = &influxerror.Error{
+		Code: influxerror.EConflict,
+		Msg:  "measurement schema with name already exists in bucket",
+	}
+
+	ErrMeasurementSchemaBucketImplicit = &influxerror.Error{
+		Code: influxerror.EInvalid,
+		Msg:  "measurement schemas require a bucket with an explicit schema type",
+	}
+
+	ErrMeasurementSchemaColumnsChanged = &influxerror.Error{
+		Code: influxerror.EInvalid,
+		Msg:  "measurement schema columns may only be added; existing columns cannot be changed or removed",
+	}
+)
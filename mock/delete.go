@@ -28,3 +28,15 @@ func NewDeleteService() DeleteService {
 func (s DeleteService) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID platform.ID, min, max int64, pred influxdb.Predicate) error {
 	return s.DeleteBucketRangePredicateF(ctx, orgID, bucketID, min, max, pred)
 }
+
+var _ influxdb.TombstonePurger = &TombstonePurger{}
+
+// TombstonePurger is a mock tombstone purger.
+type TombstonePurger struct {
+	PurgeTombstonesF func(ctx context.Context, bucketID platform.ID) error
+}
+
+// PurgeTombstones calls PurgeTombstonesF.
+func (s *TombstonePurger) PurgeTombstones(ctx context.Context, bucketID platform.ID) error {
+	return s.PurgeTombstonesF(ctx, bucketID)
+}
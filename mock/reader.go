@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/influxdata/flux/memory"
+	"github.com/influxdata/flux/plan"
 	"github.com/influxdata/influxdb/v2/query"
 )
 
@@ -15,6 +16,7 @@ type StorageReader struct {
 	ReadWindowAggregateFn          func(ctx context.Context, spec query.ReadWindowAggregateSpec, alloc memory.Allocator) (query.TableIterator, error)
 	ReadSeriesCardinalityFn        func(ctx context.Context, spec query.ReadSeriesCardinalitySpec, alloc memory.Allocator) (query.TableIterator, error)
 	SupportReadSeriesCardinalityFn func(ctx context.Context) bool
+	SupportWindowAggregateFn       func(ctx context.Context, kind plan.ProcedureKind) bool
 	CloseFn                        func()
 }
 
@@ -42,6 +44,10 @@ func (s *StorageReader) SupportReadSeriesCardinality(ctx context.Context) bool {
 	return s.SupportReadSeriesCardinalityFn(ctx)
 }
 
+func (s *StorageReader) SupportWindowAggregate(ctx context.Context, kind plan.ProcedureKind) bool {
+	return s.SupportWindowAggregateFn(ctx, kind)
+}
+
 func (s *StorageReader) Close() {
 	// Only invoke the close function if it is set.
 	// We want this to be a no-op and work without
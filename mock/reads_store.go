@@ -17,6 +17,7 @@ type ReadsStore struct {
 	TagValuesFn                    func(ctx context.Context, req *datatypes.TagValuesRequest) (cursors.StringIterator, error)
 	ReadSeriesCardinalityFn        func(ctx context.Context, req *datatypes.ReadSeriesCardinalityRequest) (cursors.Int64Iterator, error)
 	SupportReadSeriesCardinalityFn func(ctx context.Context) bool
+	SupportWindowAggregateFn       func(ctx context.Context, agg datatypes.Aggregate_AggregateType) bool
 	GetSourceFn                    func(orgID, bucketID uint64) proto.Message
 }
 
@@ -48,6 +49,10 @@ func (s *ReadsStore) SupportReadSeriesCardinality(ctx context.Context) bool {
 	return s.SupportReadSeriesCardinalityFn(ctx)
 }
 
+func (s *ReadsStore) SupportWindowAggregate(ctx context.Context, agg datatypes.Aggregate_AggregateType) bool {
+	return s.SupportWindowAggregateFn(ctx, agg)
+}
+
 func (s *ReadsStore) GetSource(orgID, bucketID uint64) proto.Message {
 	return s.GetSourceFn(orgID, bucketID)
 }
@@ -13,6 +13,14 @@ type Organization struct {
 	ID          platform.ID `json:"id,omitempty"`
 	Name        string      `json:"name"`
 	Description string      `json:"description"`
+
+	// CardinalityLimits bounds the series of the organization's buckets. The
+	// per-measurement and per-tag limits apply to each bucket that does not
+	// set its own.
+	CardinalityLimits *CardinalityLimits `json:"cardinalityLimits,omitempty"`
+
+	// Quotas bounds the resources used by the organization.
+	Quotas *OrgQuotas `json:"quotas,omitempty"`
 	CRUDLog
 }
 
@@ -62,8 +70,10 @@ type OrganizationService interface {
 // OrganizationUpdate represents updates to a organization.
 // Only fields which are set are updated.
 type OrganizationUpdate struct {
-	Name        *string
-	Description *string `json:"description,omitempty"`
+	Name              *string
+	Description       *string            `json:"description,omitempty"`
+	CardinalityLimits *CardinalityLimits `json:"cardinalityLimits,omitempty"`
+	Quotas            *OrgQuotas         `json:"quotas,omitempty"`
 }
 
 // ErrInvalidOrgFilter is the error indicate org filter is empty
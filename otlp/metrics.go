@@ -0,0 +1,369 @@
+// Package otlp converts OpenTelemetry metrics received over OTLP to points.
+//
+// Metrics are stored with the same schema as scraped Prometheus metrics:
+//
+//   - the measurement is the metric name;
+//   - the attributes of the resource and of the data point are tags, the data
+//     point's taking precedence. Attributes whose values are arrays, maps or
+//     bytes are dropped;
+//   - gauges and non-monotonic sums are stored in the float field "gauge",
+//     monotonic sums in the float field "counter";
+//   - histograms are stored in the float fields "count" and "sum" and, for
+//     each bucket, a field named after its upper bound holding the
+//     cumulative count, the last being "+Inf";
+//   - exponential histograms are stored in the fields "count" and "sum" only;
+//   - summaries are stored in the fields "count" and "sum" and, for each
+//     quantile, a field named after the quantile;
+//   - the timestamp is the time of the data point.
+//
+// Values are stored as floats regardless of their OTLP type, so that a
+// metric's field keeps a single type. Data points flagged as having no
+// recorded value, and NaN values, are dropped.
+package otlp
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/encoding/pbwire"
+)
+
+// flagNoRecordedValue is the data point flag marking a point without a value.
+const flagNoRecordedValue = 1
+
+// Field names of the schema.
+const (
+	FieldGauge   = "gauge"
+	FieldCounter = "counter"
+	FieldCount   = "count"
+	FieldSum     = "sum"
+)
+
+// MetricsPoints converts the protobuf encoding of an OTLP
+// ExportMetricsServiceRequest to points.
+func MetricsPoints(b []byte) (models.Points, error) {
+	var c converter
+	err := pbwire.Range(b, func(f pbwire.Field) error {
+		if f.Num != 1 {
+			return nil
+		}
+		return c.resourceMetrics(f.Bytes)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return c.points, nil
+}
+
+type converter struct {
+	resource map[string]string
+	points   models.Points
+}
+
+func (c *converter) resourceMetrics(b []byte) error {
+	c.resource = map[string]string{}
+	var scopes [][]byte
+	err := pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1: // resource
+			return pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+				if f.Num != 1 {
+					return nil
+				}
+				return attribute(c.resource, f.Bytes)
+			})
+		case 2, 1000: // scope_metrics, instrumentation_library_metrics
+			scopes = append(scopes, f.Bytes)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, scope := range scopes {
+		err := pbwire.Range(scope, func(f pbwire.Field) error {
+			if f.Num != 2 {
+				return nil
+			}
+			return c.metric(f.Bytes)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *converter) metric(b []byte) error {
+	var (
+		name string
+		kind int
+		data []byte
+	)
+	err := pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1:
+			name = f.String()
+		case 5, 7, 9, 10, 11: // gauge, sum, histogram, exponential_histogram, summary
+			kind, data = int(f.Num), f.Bytes
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		return fmt.Errorf("metric has no name")
+	}
+
+	var (
+		monotonic bool
+		points    [][]byte
+	)
+	err = pbwire.Range(data, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1:
+			points = append(points, f.Bytes)
+		case 3:
+			monotonic = f.Int != 0
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, p := range points {
+		var dp dataPoint
+		var err error
+		switch kind {
+		case 5:
+			err = dp.number(p, FieldGauge)
+		case 7:
+			field := FieldGauge
+			if monotonic {
+				field = FieldCounter
+			}
+			err = dp.number(p, field)
+		case 9:
+			err = dp.histogram(p)
+		case 10:
+			err = dp.exponentialHistogram(p)
+		case 11:
+			err = dp.summary(p)
+		}
+		if err != nil {
+			return fmt.Errorf("metric %s: %w", name, err)
+		}
+		if err := c.addPoint(name, &dp); err != nil {
+			return fmt.Errorf("metric %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (c *converter) addPoint(name string, dp *dataPoint) error {
+	if dp.flags&flagNoRecordedValue != 0 || len(dp.fields) == 0 {
+		return nil
+	}
+
+	tags := make(models.Tags, 0, len(c.resource)+len(dp.attributes))
+	for k, v := range c.resource {
+		if _, ok := dp.attributes[k]; !ok {
+			tags = append(tags, models.NewTag([]byte(k), []byte(v)))
+		}
+	}
+	for k, v := range dp.attributes {
+		tags = append(tags, models.NewTag([]byte(k), []byte(v)))
+	}
+	sort.Sort(tags)
+
+	pt, err := models.NewPoint(name, tags, dp.fields, time.Unix(0, int64(dp.time)))
+	if err != nil {
+		return err
+	}
+	c.points = append(c.points, pt)
+	return nil
+}
+
+// dataPoint holds the parts of an OTLP data point that are stored.
+type dataPoint struct {
+	attributes map[string]string
+	time       uint64
+	flags      uint64
+	fields     models.Fields
+}
+
+func (dp *dataPoint) setField(k string, v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+	if dp.fields == nil {
+		dp.fields = models.Fields{}
+	}
+	dp.fields[k] = v
+}
+
+func (dp *dataPoint) addAttribute(b []byte) error {
+	if dp.attributes == nil {
+		dp.attributes = map[string]string{}
+	}
+	return attribute(dp.attributes, b)
+}
+
+func (dp *dataPoint) number(b []byte, field string) error {
+	return pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 7:
+			return dp.addAttribute(f.Bytes)
+		case 3:
+			dp.time = f.Int
+		case 4:
+			dp.setField(field, f.Float64())
+		case 6:
+			dp.setField(field, float64(f.Int64()))
+		case 8:
+			dp.flags = f.Int
+		}
+		return nil
+	})
+}
+
+func (dp *dataPoint) histogram(b []byte) error {
+	var (
+		count  uint64
+		counts []uint64
+		bounds []uint64
+	)
+	err := pbwire.Range(b, func(f pbwire.Field) (err error) {
+		switch f.Num {
+		case 9:
+			return dp.addAttribute(f.Bytes)
+		case 3:
+			dp.time = f.Int
+		case 4:
+			count = f.Int
+		case 5:
+			dp.setField(FieldSum, f.Float64())
+		case 6:
+			counts, err = f.Fixed64s(counts)
+		case 7:
+			bounds, err = f.Fixed64s(bounds)
+		case 10:
+			dp.flags = f.Int
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	dp.setField(FieldCount, float64(count))
+	if len(counts) == 0 {
+		return nil
+	}
+	if len(counts) != len(bounds)+1 {
+		return fmt.Errorf("histogram has %d bucket counts for %d bounds", len(counts), len(bounds))
+	}
+	var cumulative uint64
+	for i, bound := range bounds {
+		cumulative += counts[i]
+		dp.setField(strconv.FormatFloat(math.Float64frombits(bound), 'f', -1, 64), float64(cumulative))
+	}
+	dp.setField(strconv.FormatFloat(math.Inf(1), 'f', -1, 64), float64(count))
+	return nil
+}
+
+func (dp *dataPoint) exponentialHistogram(b []byte) error {
+	return pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1:
+			return dp.addAttribute(f.Bytes)
+		case 3:
+			dp.time = f.Int
+		case 4:
+			dp.setField(FieldCount, float64(f.Int))
+		case 5:
+			dp.setField(FieldSum, f.Float64())
+		case 10:
+			dp.flags = f.Int
+		}
+		return nil
+	})
+}
+
+func (dp *dataPoint) summary(b []byte) error {
+	return pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 7:
+			return dp.addAttribute(f.Bytes)
+		case 3:
+			dp.time = f.Int
+		case 4:
+			dp.setField(FieldCount, float64(f.Int))
+		case 5:
+			dp.setField(FieldSum, f.Float64())
+		case 6:
+			var q, v float64
+			err := pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+				switch f.Num {
+				case 1:
+					q = f.Float64()
+				case 2:
+					v = f.Float64()
+				}
+				return nil
+			})
+			dp.setField(strconv.FormatFloat(q, 'f', -1, 64), v)
+			return err
+		case 8:
+			dp.flags = f.Int
+		}
+		return nil
+	})
+}
+
+// attribute adds the KeyValue encoded in b to attrs, unless its value is not
+// a scalar.
+func attribute(attrs map[string]string, b []byte) error {
+	var (
+		key, value string
+		ok         bool
+	)
+	err := pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1:
+			key = f.String()
+		case 2:
+			return pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+				ok = true
+				switch f.Num {
+				case 1:
+					value = f.String()
+				case 2:
+					value = strconv.FormatBool(f.Int != 0)
+				case 3:
+					value = strconv.FormatInt(f.Int64(), 10)
+				case 4:
+					value = strconv.FormatFloat(f.Float64(), 'f', -1, 64)
+				default:
+					ok = false
+				}
+				return nil
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if ok && key != "" && value != "" {
+		attrs[key] = value
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package otlp_test
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/otlp"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// field appends an encoded field to a message.
+type field func([]byte) []byte
+
+func msg(fs ...field) []byte {
+	var b []byte
+	for _, f := range fs {
+		b = f(b)
+	}
+	return b
+}
+
+func bytesField(num protowire.Number, v []byte) field {
+	return func(b []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.BytesType)
+		return protowire.AppendBytes(b, v)
+	}
+}
+
+func stringField(num protowire.Number, v string) field {
+	return bytesField(num, []byte(v))
+}
+
+func fixed64Field(num protowire.Number, v uint64) field {
+	return func(b []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+		return protowire.AppendFixed64(b, v)
+	}
+}
+
+func doubleField(num protowire.Number, v float64) field {
+	return fixed64Field(num, math.Float64bits(v))
+}
+
+func varintField(num protowire.Number, v uint64) field {
+	return func(b []byte) []byte {
+		b = protowire.AppendTag(b, num, protowire.VarintType)
+		return protowire.AppendVarint(b, v)
+	}
+}
+
+// attr returns a KeyValue field numbered num.
+func attr(num protowire.Number, key string, value field) field {
+	return bytesField(num, msg(stringField(1, key), bytesField(2, msg(value))))
+}
+
+func TestMetricsPoints(t *testing.T) {
+	const ts = 1e9
+	gauge := msg(
+		stringField(1, "temperature"),
+		bytesField(5, msg(
+			bytesField(1, msg(attr(7, "room", stringField(1, "kitchen")), fixed64Field(3, ts), doubleField(4, 21.5))),
+			// Points without a recorded value are dropped.
+			bytesField(1, msg(fixed64Field(3, ts), doubleField(4, 0), varintField(8, 1))),
+		)),
+	)
+	counter := msg(
+		stringField(1, "requests"),
+		bytesField(7, msg(
+			bytesField(1, msg(attr(7, "host", stringField(1, "b")), fixed64Field(3, ts), fixed64Field(6, 42))),
+			varintField(2, 2),
+			varintField(3, 1),
+		)),
+	)
+	upDown := msg(
+		stringField(1, "queue_length"),
+		bytesField(7, msg(bytesField(1, msg(fixed64Field(3, ts), fixed64Field(6, 3))))),
+	)
+	histogram := msg(
+		stringField(1, "latency"),
+		bytesField(9, msg(bytesField(1, msg(
+			fixed64Field(3, ts),
+			fixed64Field(4, 6),
+			doubleField(5, 12),
+			bytesField(6, protowire.AppendFixed64(protowire.AppendFixed64(protowire.AppendFixed64(nil, 1), 2), 3)),
+			bytesField(7, protowire.AppendFixed64(protowire.AppendFixed64(nil, math.Float64bits(0.5)), math.Float64bits(1))),
+		)))),
+	)
+	summary := msg(
+		stringField(1, "duration"),
+		bytesField(11, msg(bytesField(1, msg(
+			fixed64Field(3, ts),
+			fixed64Field(4, 10),
+			doubleField(5, 20),
+			bytesField(6, msg(doubleField(1, 0.99), doubleField(2, 4))),
+		)))),
+	)
+
+	req := msg(bytesField(1, msg(
+		bytesField(1, msg(
+			attr(1, "service.name", stringField(1, "api")),
+			attr(1, "host", stringField(1, "a")),
+			attr(1, "replicas", varintField(3, 2)),
+			attr(1, "ignored", bytesField(5, nil)),
+		)),
+		bytesField(2, msg(
+			bytesField(1, msg(stringField(1, "scope"))),
+			bytesField(2, gauge),
+			bytesField(2, counter),
+			bytesField(2, upDown),
+			bytesField(2, histogram),
+			bytesField(2, summary),
+		)),
+	)))
+
+	pts, err := otlp.MetricsPoints(req)
+	require.NoError(t, err)
+
+	var lines []string
+	for _, pt := range pts {
+		lines = append(lines, pt.String())
+	}
+	sort.Strings(lines)
+	require.Equal(t, []string{
+		"duration,host=a,replicas=2,service.name=api 0.99=4,count=10,sum=20 1000000000",
+		"latency,host=a,replicas=2,service.name=api +Inf=6,0.5=1,1=3,count=6,sum=12 1000000000",
+		"queue_length,host=a,replicas=2,service.name=api gauge=3 1000000000",
+		"requests,host=b,replicas=2,service.name=api counter=42 1000000000",
+		"temperature,host=a,replicas=2,room=kitchen,service.name=api gauge=21.5 1000000000",
+	}, lines)
+}
+
+func TestMetricsPoints_Invalid(t *testing.T) {
+	_, err := otlp.MetricsPoints([]byte{0x0a, 0x05, 0x01})
+	require.Error(t, err)
+
+	// Metrics must be named.
+	req := msg(bytesField(1, msg(bytesField(2, msg(bytesField(2, msg(
+		bytesField(5, msg(bytesField(1, msg(doubleField(4, 1))))),
+	)))))))
+	_, err = otlp.MetricsPoints(req)
+	require.Error(t, err)
+}
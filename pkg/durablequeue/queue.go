@@ -554,6 +554,35 @@ func (l *Queue) NewScanner() (Scanner, error) {
 	return &queueScanner{q: l, ss: ss}, nil
 }
 
+// ForEach calls fn with each byte slice in the queue, from the head to the
+// tail, without advancing the head. It stops at the first error returned by
+// fn. Appends are blocked until it returns.
+func (l *Queue) ForEach(fn func([]byte) error) error {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if l.head == nil {
+		return ErrNotOpen
+	}
+
+	for _, s := range l.segments {
+		ss, err := s.newScanner()
+		if err == io.EOF {
+			continue
+		} else if err != nil {
+			return err
+		}
+		for ss.Next() {
+			if err := fn(ss.Bytes()); err != nil {
+				return err
+			}
+		}
+		if err := ss.Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Advance moves the Head point to the next byte slice in the queue.
 func (l *Queue) Advance() error {
 	l.mu.Lock()
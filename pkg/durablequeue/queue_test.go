@@ -261,6 +261,38 @@ func TestQueueChangeMaxSize(t *testing.T) {
 	require.Equal(t, io.EOF, err)
 }
 
+func TestQueueForEach(t *testing.T) {
+	q, dir := newTestQueue(t, withMaxSize(1024), withMaxSegmentSize(24))
+	defer os.RemoveAll(dir)
+
+	// Spread the blocks over several segments.
+	for _, b := range []string{"one", "two", "three", "four", "five"} {
+		require.NoError(t, q.Append([]byte(b)))
+	}
+	require.Greater(t, q.TotalSegments(), 1)
+	require.NoError(t, q.Advance())
+
+	var got []string
+	require.NoError(t, q.ForEach(func(b []byte) error {
+		got = append(got, string(b))
+		return nil
+	}))
+	require.Equal(t, []string{"two", "three", "four", "five"}, got)
+
+	// The head is not advanced.
+	cur, err := q.Current()
+	require.NoError(t, err)
+	require.Equal(t, "two", string(cur))
+
+	errStop := fmt.Errorf("stop")
+	got = nil
+	require.Equal(t, errStop, q.ForEach(func(b []byte) error {
+		got = append(got, string(b))
+		return errStop
+	}))
+	require.Equal(t, []string{"two"}, got)
+}
+
 func TestQueueReopen(t *testing.T) {
 	q, dir := newTestQueue(t, withVerify(func([]byte) error { return nil }))
 	defer os.RemoveAll(dir)
@@ -0,0 +1,123 @@
+// Package pbwire decodes protobuf messages field by field.
+//
+// It is used to read the messages of external protocols, such as Prometheus
+// remote storage and OTLP, whose generated code is not vendored. Only the
+// fields a caller looks for are decoded; all others are skipped.
+package pbwire
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Field is a field of an encoded protobuf message.
+type Field struct {
+	Num  protowire.Number
+	Type protowire.Type
+
+	// Int holds the value of varint, fixed32 and fixed64 fields.
+	Int uint64
+
+	// Bytes holds the value of length-delimited fields. It refers to the
+	// encoded message.
+	Bytes []byte
+}
+
+// Float64 returns the value of a double field.
+func (f Field) Float64() float64 { return math.Float64frombits(f.Int) }
+
+// Int64 returns the value of an int64 or sfixed64 field.
+func (f Field) Int64() int64 { return int64(f.Int) }
+
+// String returns the value of a string field.
+func (f Field) String() string { return string(f.Bytes) }
+
+// Fixed64s returns the values of a repeated fixed64 or double field, which
+// may be packed. The values are appended to dst.
+func (f Field) Fixed64s(dst []uint64) ([]uint64, error) {
+	switch f.Type {
+	case protowire.Fixed64Type:
+		return append(dst, f.Int), nil
+	case protowire.BytesType:
+		b := f.Bytes
+		for len(b) > 0 {
+			v, n := protowire.ConsumeFixed64(b)
+			if n < 0 {
+				return dst, fieldError(f.Num, n)
+			}
+			dst = append(dst, v)
+			b = b[n:]
+		}
+		return dst, nil
+	default:
+		return dst, fmt.Errorf("field %d: unexpected wire type %d for fixed64", f.Num, f.Type)
+	}
+}
+
+// Varints returns the values of a repeated varint or enum field, which may be
+// packed. The values are appended to dst.
+func (f Field) Varints(dst []uint64) ([]uint64, error) {
+	switch f.Type {
+	case protowire.VarintType:
+		return append(dst, f.Int), nil
+	case protowire.BytesType:
+		b := f.Bytes
+		for len(b) > 0 {
+			v, n := protowire.ConsumeVarint(b)
+			if n < 0 {
+				return dst, fieldError(f.Num, n)
+			}
+			dst = append(dst, v)
+			b = b[n:]
+		}
+		return dst, nil
+	default:
+		return dst, fmt.Errorf("field %d: unexpected wire type %d for varint", f.Num, f.Type)
+	}
+}
+
+// Range calls fn with each field of the encoded message b in order, stopping
+// at the first error.
+func Range(b []byte, fn func(Field) error) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		f := Field{Num: num, Type: typ}
+		switch typ {
+		case protowire.VarintType:
+			f.Int, n = protowire.ConsumeVarint(b)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(b)
+			f.Int = uint64(v)
+		case protowire.Fixed64Type:
+			f.Int, n = protowire.ConsumeFixed64(b)
+		case protowire.BytesType:
+			f.Bytes, n = protowire.ConsumeBytes(b)
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return fieldError(num, n)
+		}
+		b = b[n:]
+
+		if typ == protowire.StartGroupType {
+			continue
+		}
+		if err := fn(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func fieldError(num protowire.Number, n int) error {
+	return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+}
@@ -0,0 +1,90 @@
+package pbwire_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2/pkg/encoding/pbwire"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func TestRange(t *testing.T) {
+	var packed []byte
+	packed = protowire.AppendFixed64(packed, 1)
+	packed = protowire.AppendFixed64(packed, 2)
+
+	neg := int64(-3)
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "name")
+	b = protowire.AppendTag(b, 2, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(1.5))
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(neg))
+	b = protowire.AppendTag(b, 4, protowire.BytesType)
+	b = protowire.AppendBytes(b, packed)
+	b = protowire.AppendTag(b, 4, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, 3)
+	b = protowire.AppendTag(b, 5, protowire.Fixed32Type)
+	b = protowire.AppendFixed32(b, 7)
+
+	var (
+		name   string
+		value  float64
+		i      int64
+		counts []uint64
+		other  []protowire.Number
+	)
+	err := pbwire.Range(b, func(f pbwire.Field) (err error) {
+		switch f.Num {
+		case 1:
+			name = f.String()
+		case 2:
+			value = f.Float64()
+		case 3:
+			i = f.Int64()
+		case 4:
+			counts, err = f.Fixed64s(counts)
+		default:
+			other = append(other, f.Num)
+		}
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, "name", name)
+	require.Equal(t, 1.5, value)
+	require.Equal(t, int64(-3), i)
+	require.Equal(t, []uint64{1, 2, 3}, counts)
+	require.Equal(t, []protowire.Number{5}, other)
+}
+
+func TestRange_Truncated(t *testing.T) {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendString(b, "name")
+
+	err := pbwire.Range(b[:len(b)-1], func(pbwire.Field) error { return nil })
+	require.Error(t, err)
+}
+
+func TestField_Varints(t *testing.T) {
+	var packed []byte
+	packed = protowire.AppendVarint(packed, 1)
+	packed = protowire.AppendVarint(packed, 300)
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, packed)
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, 2)
+
+	var got []uint64
+	err := pbwire.Range(b, func(f pbwire.Field) (err error) {
+		got, err = f.Varints(got)
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, []uint64{1, 300, 2}, got)
+}
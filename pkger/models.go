@@ -473,6 +473,8 @@ type SummaryBucket struct {
 	SchemaType         string                     `json:"schemaType,omitempty"`
 	MeasurementSchemas []SummaryMeasurementSchema `json:"measurementSchemas,omitempty"`
 
+	Transforms []influxdb.TransformRule `json:"transforms,omitempty"`
+
 	LabelAssociations []SummaryLabel `json:"labelAssociations"`
 }
 
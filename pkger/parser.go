@@ -883,6 +883,23 @@ func (p *Template) graphBuckets() *parseErr {
 				bkt.MeasurementSchemas = append(bkt.MeasurementSchemas, ms)
 			}
 		}
+		if rules, ok := o.Spec[fieldBucketTransforms].(transformRules); ok {
+			bkt.Transforms = rules
+		} else {
+			for _, tr := range o.Spec.slcResource(fieldBucketTransforms) {
+				bkt.Transforms = append(bkt.Transforms, transformRule{
+					Type:        tr.stringShort(fieldType),
+					Measurement: tr.stringShort(fieldTransformMeasurement),
+					Pattern:     tr.stringShort(fieldTransformPattern),
+					From:        tr.stringShort(fieldTransformFrom),
+					To:          tr.stringShort(fieldTransformTo),
+					Key:         tr.stringShort(fieldTransformKey),
+					Value:       tr.stringShort(fieldTransformValue),
+					DataType:    tr.stringShort(fieldTransformDataType),
+					Tags:        tr.slcStr(fieldTransformTags),
+				})
+			}
+		}
 		p.setRefs(bkt.name, bkt.displayName)
 
 		failures := p.parseNestedLabels(o.Spec, func(l *label) error {
@@ -95,6 +95,8 @@ type bucket struct {
 	SchemaType         string
 	MeasurementSchemas measurementSchemas
 
+	Transforms transformRules
+
 	labels sortedLabels
 }
 
@@ -109,6 +111,7 @@ func (b *bucket) summarize() SummaryBucket {
 		Description:        b.Description,
 		SchemaType:         b.SchemaType,
 		MeasurementSchemas: b.MeasurementSchemas.summarize(),
+		Transforms:         b.Transforms.toInfluxDB(),
 		RetentionPeriod:    b.RetentionRules.RP(),
 		LabelAssociations:  toSummaryLabels(b.labels...),
 	}
@@ -125,6 +128,7 @@ func (b *bucket) valid() []validationErr {
 	}
 	vErrs = append(vErrs, b.RetentionRules.valid()...)
 	vErrs = append(vErrs, b.MeasurementSchemas.valid()...)
+	vErrs = append(vErrs, b.Transforms.valid()...)
 	if len(vErrs) == 0 {
 		return nil
 	}
@@ -0,0 +1,91 @@
+package pkger
+
+import (
+	"github.com/influxdata/influxdb/v2"
+)
+
+const (
+	fieldBucketTransforms = "transforms"
+
+	// transformRule fields
+	fieldTransformMeasurement = "measurement"
+	fieldTransformPattern     = "pattern"
+	fieldTransformFrom        = "from"
+	fieldTransformTo          = "to"
+	fieldTransformKey         = "key"
+	fieldTransformValue       = "value"
+	fieldTransformDataType    = "dataType"
+	fieldTransformTags        = "tags"
+)
+
+type transformRules []transformRule
+
+func (r transformRules) valid() []validationErr {
+	var errs []validationErr
+	for idx, tr := range r {
+		if nestedErrs := tr.valid(); len(nestedErrs) > 0 {
+			errs = append(errs, validationErr{
+				Field:  fieldBucketTransforms,
+				Index:  intPtr(idx),
+				Nested: nestedErrs,
+			})
+		}
+	}
+	return errs
+}
+
+// toInfluxDB returns the rules of a bucket transform, or nil if there are
+// none.
+func (r transformRules) toInfluxDB() []influxdb.TransformRule {
+	if len(r) == 0 {
+		return nil
+	}
+	rules := make([]influxdb.TransformRule, 0, len(r))
+	for _, tr := range r {
+		rules = append(rules, tr.toInfluxDB())
+	}
+	return rules
+}
+
+type transformRule struct {
+	Type        string   `json:"type" yaml:"type"`
+	Measurement string   `json:"measurement,omitempty" yaml:"measurement,omitempty"`
+	Pattern     string   `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	From        string   `json:"from,omitempty" yaml:"from,omitempty"`
+	To          string   `json:"to,omitempty" yaml:"to,omitempty"`
+	Key         string   `json:"key,omitempty" yaml:"key,omitempty"`
+	Value       string   `json:"value,omitempty" yaml:"value,omitempty"`
+	DataType    string   `json:"dataType,omitempty" yaml:"dataType,omitempty"`
+	Tags        []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+func (r transformRule) valid() []validationErr {
+	if r.DataType != "" && influxdb.SchemaColumnDataTypeFromString(r.DataType) == nil {
+		return []validationErr{{
+			Field: fieldTransformDataType,
+			Msg:   "invalid data type " + r.DataType,
+		}}
+	}
+	rule := r.toInfluxDB()
+	if err := rule.Validate(); err != nil {
+		return []validationErr{{
+			Field: fieldType,
+			Msg:   err.Error(),
+		}}
+	}
+	return nil
+}
+
+func (r transformRule) toInfluxDB() influxdb.TransformRule {
+	return influxdb.TransformRule{
+		Type:        influxdb.TransformRuleType(r.Type),
+		Measurement: r.Measurement,
+		Pattern:     r.Pattern,
+		From:        r.From,
+		To:          r.To,
+		Key:         r.Key,
+		Value:       r.Value,
+		DataType:    influxdb.SchemaColumnDataTypeFromString(r.DataType),
+		Tags:        r.Tags,
+	}
+}
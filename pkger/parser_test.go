@@ -88,6 +88,30 @@ func TestParse(t *testing.T) {
 			assert.Equal(t, exp, buckets[0])
 		})
 
+		t.Run("with valid bucket and transform should be valid", func(t *testing.T) {
+			template := validParsedTemplateFromFile(t, "testdata/bucket_transform.yml", EncodingYAML)
+			buckets := template.Summary().Buckets
+			require.Len(t, buckets, 1)
+
+			exp := SummaryBucket{
+				SummaryIdentifier: SummaryIdentifier{
+					Kind:          KindBucket,
+					MetaName:      "transformed-1",
+					EnvReferences: []SummaryReference{},
+				},
+				Name:              "my_transformed",
+				LabelAssociations: []SummaryLabel{},
+				Transforms: []influxdb.TransformRule{
+					{Type: influxdb.TransformDropMeasurement, Pattern: "^debug_"},
+					{Type: influxdb.TransformRenameTag, Measurement: "^cpu$", From: "hostname", To: "host"},
+					{Type: influxdb.TransformConvertField, Key: "usage_user", DataType: influxdb.SchemaColumnDataTypeFloat.Ptr()},
+					{Type: influxdb.TransformRequireTags, Tags: []string{"host", "region"}},
+				},
+			}
+
+			assert.Equal(t, exp, buckets[0])
+		})
+
 		t.Run("with env refs should be valid", func(t *testing.T) {
 			testfileRunner(t, "testdata/bucket_ref.yml", func(t *testing.T, template *Template) {
 				actual := template.Summary().Buckets
@@ -275,6 +299,39 @@ spec:
         - name: usage_user
           type: field
           dataType: float
+`,
+				},
+				{
+					name:           "invalid transform rule",
+					resourceErrs:   1,
+					validationErrs: 1,
+					valFields:      []string{strings.Join([]string{fieldSpec, fieldBucketTransforms, fieldType}, ".")},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: foo-1
+spec:
+  name: foo
+  transforms:
+    - type: renameTag
+      from: hostname
+`,
+				},
+				{
+					name:           "invalid transform data type",
+					resourceErrs:   1,
+					validationErrs: 1,
+					valFields:      []string{strings.Join([]string{fieldSpec, fieldBucketTransforms, fieldTransformDataType}, ".")},
+					templateStr: `apiVersion: influxdata.com/v2alpha1
+kind: Bucket
+metadata:
+  name: foo-1
+spec:
+  name: foo
+  transforms:
+    - type: convertField
+      key: usage_user
+      dataType: decimal
 `,
 				},
 			}
@@ -166,6 +166,7 @@ type serviceOpt struct {
 	secretSVC   influxdb.SecretService
 	taskSVC     taskmodel.TaskService
 	teleSVC     influxdb.TelegrafConfigStore
+	transSVC    influxdb.BucketTransformService
 	varSVC      influxdb.VariableService
 }
 
@@ -214,6 +215,14 @@ func WithBucketSVC(bktSVC influxdb.BucketService) ServiceSetterFn {
 	}
 }
 
+// WithBucketTransformSVC sets the bucket transform service. The transforms
+// of buckets are not applied without it.
+func WithBucketTransformSVC(transSVC influxdb.BucketTransformService) ServiceSetterFn {
+	return func(opt *serviceOpt) {
+		opt.transSVC = transSVC
+	}
+}
+
 // WithCheckSVC sets the check service.
 func WithCheckSVC(checkSVC influxdb.CheckService) ServiceSetterFn {
 	return func(opt *serviceOpt) {
@@ -323,6 +332,7 @@ type Service struct {
 	secretSVC   influxdb.SecretService
 	taskSVC     taskmodel.TaskService
 	teleSVC     influxdb.TelegrafConfigStore
+	transSVC    influxdb.BucketTransformService
 	varSVC      influxdb.VariableService
 }
 
@@ -361,6 +371,7 @@ func NewService(opts ...ServiceSetterFn) *Service {
 		secretSVC:   opt.secretSVC,
 		taskSVC:     opt.taskSVC,
 		teleSVC:     opt.teleSVC,
+		transSVC:    opt.transSVC,
 		varSVC:      opt.varSVC,
 	}
 }
@@ -1785,6 +1796,9 @@ func (s *Service) applyBucket(ctx context.Context, b *stateBucket) (influxdb.Buc
 		if err != nil {
 			return influxdb.Bucket{}, applyFailErr("update", b.stateIdentity(), err)
 		}
+		if err := s.applyBucketTransform(ctx, *influxBucket, b.parserBkt.Transforms); err != nil {
+			return influxdb.Bucket{}, applyFailErr("update", b.stateIdentity(), err)
+		}
 		return *influxBucket, nil
 	default:
 		rp := b.parserBkt.RetentionRules.RP()
@@ -1794,14 +1808,37 @@ func (s *Service) applyBucket(ctx context.Context, b *stateBucket) (influxdb.Buc
 			Name:            b.parserBkt.Name(),
 			RetentionPeriod: rp,
 		}
+		if st := influxdb.SchemaTypeFromString(b.parserBkt.SchemaType); st != nil {
+			influxBucket.SchemaType = *st
+		}
 		err := s.bucketSVC.CreateBucket(ctx, &influxBucket)
 		if err != nil {
 			return influxdb.Bucket{}, applyFailErr("create", b.stateIdentity(), err)
 		}
+		if err := s.applyBucketTransform(ctx, influxBucket, b.parserBkt.Transforms); err != nil {
+			// the bucket is deleted so that rolling back the template does
+			// not leave it behind.
+			_ = s.bucketSVC.DeleteBucket(ctx, influxBucket.ID)
+			return influxdb.Bucket{}, applyFailErr("create", b.stateIdentity(), err)
+		}
 		return influxBucket, nil
 	}
 }
 
+// applyBucketTransform sets the transform of a bucket to the rules of its
+// template. The transform of a bucket whose template has no rules is left as
+// it is.
+func (s *Service) applyBucketTransform(ctx context.Context, bkt influxdb.Bucket, rules transformRules) error {
+	if s.transSVC == nil || len(rules) == 0 {
+		return nil
+	}
+	return s.transSVC.PutBucketTransform(ctx, &influxdb.BucketTransform{
+		OrgID:    bkt.OrgID,
+		BucketID: bkt.ID,
+		Rules:    rules.toInfluxDB(),
+	})
+}
+
 func (s *Service) applyChecks(ctx context.Context, checks []*stateCheck) applier {
 	const resource = "check"
 
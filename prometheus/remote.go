@@ -0,0 +1,267 @@
+package prometheus
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/encoding/pbwire"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Prometheus remote storage stores each series of a remote write in a bucket
+// with the same schema as scraped untyped metrics:
+//
+//   - the measurement is the value of the series' MetricNameLabel;
+//   - every other label is a tag;
+//   - the sample value is the float field RemoteValueField;
+//   - the timestamp is the sample timestamp, in milliseconds.
+//
+// Samples that are NaN, including staleness markers, or infinite cannot be
+// stored and are dropped. Remote reads map series back to labels the same way.
+const (
+	// MetricNameLabel is the label holding the name of a Prometheus series.
+	MetricNameLabel = "__name__"
+
+	// RemoteValueField is the field holding the values of samples written
+	// by Prometheus remote write.
+	RemoteValueField = "value"
+)
+
+// DefaultMaxDecodedBytes is the number of bytes a remote request may
+// decompress to when no other limit is given.
+const DefaultMaxDecodedBytes = 32 * 1024 * 1024
+
+// Label is a label of a Prometheus series.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Sample is a value of a Prometheus series. Its timestamp is in milliseconds.
+type Sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+// TimeSeries is a Prometheus series and some of its samples.
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+// WriteRequest is the body of a Prometheus remote write request. Metadata is
+// ignored.
+type WriteRequest struct {
+	Timeseries []TimeSeries
+}
+
+// DecodeWriteRequest decodes the snappy-compressed remote write request
+// compressed. Requests that decompress to more than maxBytes bytes, or
+// DefaultMaxDecodedBytes if maxBytes is zero, are rejected.
+func DecodeWriteRequest(compressed []byte, maxBytes int64) (*WriteRequest, error) {
+	b, err := decodeSnappy(compressed, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	var req WriteRequest
+	if err := req.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// decodeSnappy decodes compressed, checking the length it decompresses to, as
+// stated by its header, before allocating it.
+func decodeSnappy(compressed []byte, maxBytes int64) ([]byte, error) {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxDecodedBytes
+	}
+	n, err := snappy.DecodedLen(compressed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy encoding: %w", err)
+	}
+	if int64(n) > maxBytes {
+		return nil, fmt.Errorf("decompressed request of %d bytes exceeds the limit of %d bytes", n, maxBytes)
+	}
+	b, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		return nil, fmt.Errorf("invalid snappy encoding: %w", err)
+	}
+	return b, nil
+}
+
+// Unmarshal decodes the protobuf encoding of a remote write request.
+func (r *WriteRequest) Unmarshal(b []byte) error {
+	return pbwire.Range(b, func(f pbwire.Field) error {
+		if f.Num != 1 {
+			return nil
+		}
+		var ts TimeSeries
+		if err := ts.Unmarshal(f.Bytes); err != nil {
+			return err
+		}
+		r.Timeseries = append(r.Timeseries, ts)
+		return nil
+	})
+}
+
+// Marshal returns the protobuf encoding of the request.
+func (r *WriteRequest) Marshal() []byte {
+	var b []byte
+	for i := range r.Timeseries {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, r.Timeseries[i].Marshal())
+	}
+	return b
+}
+
+// Unmarshal decodes the protobuf encoding of a series.
+func (ts *TimeSeries) Unmarshal(b []byte) error {
+	return pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1:
+			var l Label
+			err := pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+				switch f.Num {
+				case 1:
+					l.Name = f.String()
+				case 2:
+					l.Value = f.String()
+				}
+				return nil
+			})
+			ts.Labels = append(ts.Labels, l)
+			return err
+		case 2:
+			var s Sample
+			err := pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+				switch f.Num {
+				case 1:
+					s.Value = f.Float64()
+				case 2:
+					s.Timestamp = f.Int64()
+				}
+				return nil
+			})
+			ts.Samples = append(ts.Samples, s)
+			return err
+		}
+		return nil
+	})
+}
+
+// Marshal returns the protobuf encoding of the series.
+func (ts *TimeSeries) Marshal() []byte {
+	var b []byte
+	for _, l := range ts.Labels {
+		var lb []byte
+		lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.Name)
+		lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+		lb = protowire.AppendString(lb, l.Value)
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, lb)
+	}
+	for _, s := range ts.Samples {
+		var sb []byte
+		sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+		sb = protowire.AppendFixed64(sb, math.Float64bits(s.Value))
+		sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+		sb = protowire.AppendVarint(sb, uint64(s.Timestamp))
+		b = protowire.AppendTag(b, 2, protowire.BytesType)
+		b = protowire.AppendBytes(b, sb)
+	}
+	return b
+}
+
+// Points converts the series of the request to points. A request with a
+// series which cannot be converted is not converted at all: Points returns
+// the error of the first one. onError, if not nil, is called with every such
+// series, as formatted by its String method, and its error.
+func (r *WriteRequest) Points(onError func(series string, err error)) (models.Points, error) {
+	var (
+		pts      models.Points
+		firstErr error
+	)
+	for i := range r.Timeseries {
+		ts := &r.Timeseries[i]
+		spts, err := ts.points()
+		if err != nil {
+			if onError != nil {
+				onError(ts.String(), err)
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		pts = append(pts, spts...)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	return pts, nil
+}
+
+// points converts the samples of the series to points.
+func (ts *TimeSeries) points() (models.Points, error) {
+	var name string
+	tags := make(models.Tags, 0, len(ts.Labels))
+	for _, l := range ts.Labels {
+		if l.Name == MetricNameLabel {
+			name = l.Value
+		} else if l.Value != "" {
+			tags = append(tags, models.NewTag([]byte(l.Name), []byte(l.Value)))
+		}
+	}
+	if name == "" {
+		return nil, fmt.Errorf("series has no %s label", MetricNameLabel)
+	}
+	sort.Sort(tags)
+
+	pts := make(models.Points, 0, len(ts.Samples))
+	for _, s := range ts.Samples {
+		if math.IsNaN(s.Value) || math.IsInf(s.Value, 0) {
+			continue
+		}
+		pt, err := models.NewPoint(name, tags, models.Fields{RemoteValueField: s.Value}, time.Unix(0, s.Timestamp*nsPerMilliseconds))
+		if err != nil {
+			return nil, fmt.Errorf("series %s: %w", name, err)
+		}
+		pts = append(pts, pt)
+	}
+	return pts, nil
+}
+
+// String returns the series in the Prometheus text format, such as
+// up{job="api"}, without its samples.
+func (ts *TimeSeries) String() string {
+	var (
+		b    strings.Builder
+		sep  = "{"
+		name string
+	)
+	for _, l := range ts.Labels {
+		if l.Name == MetricNameLabel {
+			name = l.Value
+			continue
+		}
+		b.WriteString(sep)
+		b.WriteString(l.Name)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(l.Value))
+		sep = ","
+	}
+	if b.Len() > 0 {
+		b.WriteByte('}')
+	} else if name == "" {
+		b.WriteString("{}")
+	}
+	return name + b.String()
+}
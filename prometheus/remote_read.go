@@ -0,0 +1,519 @@
+package prometheus
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/encoding/pbwire"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+// Content types of remote read responses.
+const (
+	SamplesContentType  = "application/x-protobuf"
+	StreamedContentType = "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse"
+)
+
+const (
+	measurementTagKey = "_measurement"
+	fieldTagKey       = "_field"
+
+	// maxChunkSamples is the number of samples of a full chunk.
+	maxChunkSamples = 120
+
+	// maxFrameBytes is the size above which the chunks of a series are split
+	// across frames.
+	maxFrameBytes = 1 << 20
+)
+
+// MatchType is the type of a label matcher.
+type MatchType int32
+
+// Label matcher types.
+const (
+	MatchEqual MatchType = iota
+	MatchNotEqual
+	MatchRegexp
+	MatchNotRegexp
+)
+
+// LabelMatcher selects the series whose label Name matches Value.
+type LabelMatcher struct {
+	Type  MatchType
+	Name  string
+	Value string
+}
+
+// Query selects the samples of the series matching all of Matchers between
+// StartTimestampMs and EndTimestampMs inclusive.
+type Query struct {
+	StartTimestampMs int64
+	EndTimestampMs   int64
+	Matchers         []LabelMatcher
+}
+
+// ResponseType is a type of remote read response.
+type ResponseType int32
+
+// Remote read response types.
+const (
+	// SamplesResponse is a single snappy-compressed message holding the
+	// samples of every query.
+	SamplesResponse ResponseType = iota
+
+	// StreamedXORChunksResponse is a stream of frames holding the XOR
+	// encoded chunks of a series of a query.
+	StreamedXORChunksResponse
+)
+
+// ReadRequest is the body of a Prometheus remote read request. Hints are
+// ignored.
+type ReadRequest struct {
+	Queries               []Query
+	AcceptedResponseTypes []ResponseType
+}
+
+// DecodeReadRequest decodes the snappy-compressed remote read request
+// compressed. Requests that decompress to more than maxBytes bytes, or
+// DefaultMaxDecodedBytes if maxBytes is zero, are rejected.
+func DecodeReadRequest(compressed []byte, maxBytes int64) (*ReadRequest, error) {
+	b, err := decodeSnappy(compressed, maxBytes)
+	if err != nil {
+		return nil, err
+	}
+	var req ReadRequest
+	if err := req.Unmarshal(b); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// Unmarshal decodes the protobuf encoding of a remote read request.
+func (r *ReadRequest) Unmarshal(b []byte) error {
+	return pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1:
+			var q Query
+			if err := q.Unmarshal(f.Bytes); err != nil {
+				return err
+			}
+			r.Queries = append(r.Queries, q)
+		case 2:
+			types, err := f.Varints(nil)
+			for _, t := range types {
+				r.AcceptedResponseTypes = append(r.AcceptedResponseTypes, ResponseType(t))
+			}
+			return err
+		}
+		return nil
+	})
+}
+
+// Marshal returns the protobuf encoding of the request.
+func (r *ReadRequest) Marshal() []byte {
+	var b []byte
+	for i := range r.Queries {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, r.Queries[i].Marshal())
+	}
+	for _, t := range r.AcceptedResponseTypes {
+		b = protowire.AppendTag(b, 2, protowire.VarintType)
+		b = protowire.AppendVarint(b, uint64(t))
+	}
+	return b
+}
+
+// ResponseType returns the response type to answer the request with:
+// streamed XOR chunks if the client accepts them, samples otherwise.
+func (r *ReadRequest) ResponseType() ResponseType {
+	for _, t := range r.AcceptedResponseTypes {
+		if t == StreamedXORChunksResponse {
+			return t
+		}
+	}
+	return SamplesResponse
+}
+
+// Unmarshal decodes the protobuf encoding of a query.
+func (q *Query) Unmarshal(b []byte) error {
+	return pbwire.Range(b, func(f pbwire.Field) error {
+		switch f.Num {
+		case 1:
+			q.StartTimestampMs = f.Int64()
+		case 2:
+			q.EndTimestampMs = f.Int64()
+		case 3:
+			var m LabelMatcher
+			err := pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+				switch f.Num {
+				case 1:
+					m.Type = MatchType(f.Int)
+				case 2:
+					m.Name = f.String()
+				case 3:
+					m.Value = f.String()
+				}
+				return nil
+			})
+			q.Matchers = append(q.Matchers, m)
+			return err
+		}
+		return nil
+	})
+}
+
+// Marshal returns the protobuf encoding of the query.
+func (q *Query) Marshal() []byte {
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(q.StartTimestampMs))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(q.EndTimestampMs))
+	for _, m := range q.Matchers {
+		var mb []byte
+		mb = protowire.AppendTag(mb, 1, protowire.VarintType)
+		mb = protowire.AppendVarint(mb, uint64(m.Type))
+		mb = protowire.AppendTag(mb, 2, protowire.BytesType)
+		mb = protowire.AppendString(mb, m.Name)
+		mb = protowire.AppendTag(mb, 3, protowire.BytesType)
+		mb = protowire.AppendString(mb, m.Value)
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, mb)
+	}
+	return b
+}
+
+// ReadFilterRequest returns the storage request reading the series selected
+// by the query from source.
+func (q *Query) ReadFilterRequest(source *anypb.Any) (*datatypes.ReadFilterRequest, error) {
+	pred, err := q.Predicate()
+	if err != nil {
+		return nil, err
+	}
+	return &datatypes.ReadFilterRequest{
+		ReadSource: source,
+		Range: &datatypes.TimestampRange{
+			Start: q.StartTimestampMs * nsPerMilliseconds,
+			// The end of a storage range is exclusive.
+			End: (q.EndTimestampMs + 1) * nsPerMilliseconds,
+		},
+		Predicate: pred,
+	}, nil
+}
+
+// Predicate translates the matchers of the query to a storage predicate
+// selecting the RemoteValueField field of the matching series.
+//
+// MetricNameLabel is matched against the measurement and other labels against
+// the tag of the same name. As in Prometheus, regular expressions must match
+// the whole label value, and a missing label matches the empty string.
+func (q *Query) Predicate() (*datatypes.Predicate, error) {
+	root := comparisonNode(datatypes.Node_ComparisonEqual, fieldTagKey, stringNode(RemoteValueField))
+	for _, m := range q.Matchers {
+		key := m.Name
+		if key == MetricNameLabel {
+			key = measurementTagKey
+		}
+
+		var n *datatypes.Node
+		switch m.Type {
+		case MatchEqual:
+			n = comparisonNode(datatypes.Node_ComparisonEqual, key, stringNode(m.Value))
+		case MatchNotEqual:
+			n = comparisonNode(datatypes.Node_ComparisonNotEqual, key, stringNode(m.Value))
+		case MatchRegexp, MatchNotRegexp:
+			re := "^(?:" + m.Value + ")$"
+			if _, err := regexp.Compile(re); err != nil {
+				return nil, fmt.Errorf("invalid regular expression for label %s: %w", m.Name, err)
+			}
+			op := datatypes.Node_ComparisonRegex
+			if m.Type == MatchNotRegexp {
+				op = datatypes.Node_ComparisonNotRegex
+			}
+			n = comparisonNode(op, key, &datatypes.Node{
+				NodeType: datatypes.Node_TypeLiteral,
+				Value:    &datatypes.Node_RegexValue{RegexValue: re},
+			})
+		default:
+			return nil, fmt.Errorf("unknown matcher type %d for label %s", m.Type, m.Name)
+		}
+
+		root = &datatypes.Node{
+			NodeType: datatypes.Node_TypeLogicalExpression,
+			Value:    &datatypes.Node_Logical_{Logical: datatypes.Node_LogicalAnd},
+			Children: []*datatypes.Node{root, n},
+		}
+	}
+	return &datatypes.Predicate{Root: root}, nil
+}
+
+func comparisonNode(op datatypes.Node_Comparison, key string, value *datatypes.Node) *datatypes.Node {
+	return &datatypes.Node{
+		NodeType: datatypes.Node_TypeComparisonExpression,
+		Value:    &datatypes.Node_Comparison_{Comparison: op},
+		Children: []*datatypes.Node{
+			{NodeType: datatypes.Node_TypeTagRef, Value: &datatypes.Node_TagRefValue{TagRefValue: key}},
+			value,
+		},
+	}
+}
+
+func stringNode(s string) *datatypes.Node {
+	return &datatypes.Node{
+		NodeType: datatypes.Node_TypeLiteral,
+		Value:    &datatypes.Node_StringValue{StringValue: s},
+	}
+}
+
+// SeriesLabels returns the labels of the series with the tags of a storage
+// series, sorted by name.
+func SeriesLabels(tags models.Tags) []Label {
+	labels := make([]Label, 0, len(tags))
+	for _, t := range tags {
+		switch string(t.Key) {
+		case measurementTagKey:
+			labels = append(labels, Label{Name: MetricNameLabel, Value: string(t.Value)})
+		case fieldTagKey:
+		default:
+			labels = append(labels, Label{Name: string(t.Key), Value: string(t.Value)})
+		}
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Name < labels[j].Name })
+	return labels
+}
+
+// readSeries calls fn with the labels and the samples of each float series of
+// rs, with their timestamps truncated to milliseconds. Of several samples in
+// the same millisecond, only the first is kept. Series of other types cannot
+// have been written by Prometheus and are skipped.
+func readSeries(rs reads.ResultSet, fn func(labels []Label, samples []Sample) error) error {
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	var samples []Sample
+	for rs.Next() {
+		cur, ok := rs.Cursor().(cursors.FloatArrayCursor)
+		if !ok {
+			if c := rs.Cursor(); c != nil {
+				c.Close()
+			}
+			continue
+		}
+
+		samples = samples[:0]
+		for {
+			a := cur.Next()
+			if a.Len() == 0 {
+				break
+			}
+			for i, ts := range a.Timestamps {
+				ms := ts / nsPerMilliseconds
+				if n := len(samples); n > 0 && samples[n-1].Timestamp >= ms {
+					continue
+				}
+				samples = append(samples, Sample{Value: a.Values[i], Timestamp: ms})
+			}
+		}
+		err := cur.Err()
+		cur.Close()
+		if err != nil {
+			return err
+		}
+		if len(samples) == 0 {
+			continue
+		}
+		if err := fn(SeriesLabels(rs.Tags()), samples); err != nil {
+			return err
+		}
+	}
+	return rs.Err()
+}
+
+// QueryResult holds the series read by a query.
+type QueryResult struct {
+	Timeseries []TimeSeries
+}
+
+// ReadQueryResult reads the series of rs, the result set of a query, sorted
+// by labels.
+func ReadQueryResult(rs reads.ResultSet) (*QueryResult, error) {
+	var res QueryResult
+	err := readSeries(rs, func(labels []Label, samples []Sample) error {
+		res.Timeseries = append(res.Timeseries, TimeSeries{
+			Labels:  labels,
+			Samples: append([]Sample(nil), samples...),
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(res.Timeseries, func(i, j int) bool {
+		return lessLabels(res.Timeseries[i].Labels, res.Timeseries[j].Labels)
+	})
+	return &res, nil
+}
+
+func lessLabels(a, b []Label) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i].Name != b[i].Name {
+			return a[i].Name < b[i].Name
+		}
+		if a[i].Value != b[i].Value {
+			return a[i].Value < b[i].Value
+		}
+	}
+	return len(a) < len(b)
+}
+
+// Marshal returns the protobuf encoding of the result.
+func (r *QueryResult) Marshal() []byte {
+	var b []byte
+	for i := range r.Timeseries {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, r.Timeseries[i].Marshal())
+	}
+	return b
+}
+
+// ReadResponse is the response to a remote read request answered with
+// samples, holding the result of each query of the request in order.
+type ReadResponse struct {
+	Results []QueryResult
+}
+
+// Marshal returns the protobuf encoding of the response.
+func (r *ReadResponse) Marshal() []byte {
+	var b []byte
+	for i := range r.Results {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, r.Results[i].Marshal())
+	}
+	return b
+}
+
+// Encode returns the snappy-compressed protobuf encoding of the response, the
+// body of a SamplesResponse.
+func (r *ReadResponse) Encode() []byte {
+	return snappy.Encode(nil, r.Marshal())
+}
+
+// ChunkedWriter writes the frames of a StreamedXORChunksResponse. Each frame is
+// a ChunkedReadResponse holding XOR chunks of a single series, preceded by its
+// size as a uvarint and its CRC-32 checksum (Castagnoli), big-endian.
+type ChunkedWriter struct {
+	w       *bufio.Writer
+	flusher http.Flusher
+	buf     []byte
+}
+
+// NewChunkedWriter returns a ChunkedWriter writing frames to w. If w is an
+// http.Flusher, it is flushed after every frame.
+func NewChunkedWriter(w io.Writer) *ChunkedWriter {
+	cw := &ChunkedWriter{w: bufio.NewWriter(w)}
+	cw.flusher, _ = w.(http.Flusher)
+	return cw
+}
+
+// WriteResultSet writes the series of rs, the result set of the query at
+// queryIndex in the request, in the order of rs. The chunks of large series
+// are split across several frames.
+func (cw *ChunkedWriter) WriteResultSet(queryIndex int, rs reads.ResultSet) error {
+	return readSeries(rs, func(labels []Label, samples []Sample) error {
+		var chunks [][]byte
+		size := 0
+		for len(samples) > 0 {
+			n := len(samples)
+			if n > maxChunkSamples {
+				n = maxChunkSamples
+			}
+			chunk := encodeChunk(samples[:n])
+			samples = samples[n:]
+
+			chunks = append(chunks, chunk)
+			size += len(chunk)
+			if size >= maxFrameBytes {
+				if err := cw.writeFrame(queryIndex, labels, chunks); err != nil {
+					return err
+				}
+				chunks, size = chunks[:0], 0
+			}
+		}
+		if len(chunks) == 0 {
+			return nil
+		}
+		return cw.writeFrame(queryIndex, labels, chunks)
+	})
+}
+
+// encodeChunk returns the protobuf encoding of an XOR chunk of samples.
+func encodeChunk(samples []Sample) []byte {
+	c := newXORChunk()
+	for _, s := range samples {
+		c.Append(s.Timestamp, s.Value)
+	}
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(samples[0].Timestamp))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(samples[len(samples)-1].Timestamp))
+	b = protowire.AppendTag(b, 3, protowire.VarintType)
+	b = protowire.AppendVarint(b, 1) // XOR
+	b = protowire.AppendTag(b, 4, protowire.BytesType)
+	b = protowire.AppendBytes(b, c.Bytes())
+	return b
+}
+
+func (cw *ChunkedWriter) writeFrame(queryIndex int, labels []Label, chunks [][]byte) error {
+	series := (&TimeSeries{Labels: labels}).Marshal()
+	for _, c := range chunks {
+		series = protowire.AppendTag(series, 2, protowire.BytesType)
+		series = protowire.AppendBytes(series, c)
+	}
+
+	b := cw.buf[:0]
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	b = protowire.AppendBytes(b, series)
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(queryIndex))
+	cw.buf = b
+
+	var hdr [binary.MaxVarintLen64 + 4]byte
+	n := binary.PutUvarint(hdr[:], uint64(len(b)))
+	binary.BigEndian.PutUint32(hdr[n:], crc32.Checksum(b, castagnoliTable))
+	if _, err := cw.w.Write(hdr[:n+4]); err != nil {
+		return err
+	}
+	if _, err := cw.w.Write(b); err != nil {
+		return err
+	}
+	return cw.Flush()
+}
+
+// Flush writes any buffered frame to the underlying writer.
+func (cw *ChunkedWriter) Flush() error {
+	if err := cw.w.Flush(); err != nil {
+		return err
+	}
+	if cw.flusher != nil {
+		cw.flusher.Flush()
+	}
+	return nil
+}
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
@@ -0,0 +1,241 @@
+package prometheus_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/encoding/pbwire"
+	"github.com/influxdata/influxdb/v2/prometheus"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeReadRequest(t *testing.T) {
+	req := &prometheus.ReadRequest{
+		Queries: []prometheus.Query{{
+			StartTimestampMs: 1000,
+			EndTimestampMs:   2000,
+			Matchers: []prometheus.LabelMatcher{
+				{Type: prometheus.MatchEqual, Name: "__name__", Value: "up"},
+				{Type: prometheus.MatchNotRegexp, Name: "job", Value: "api|web"},
+			},
+		}},
+		AcceptedResponseTypes: []prometheus.ResponseType{prometheus.StreamedXORChunksResponse},
+	}
+
+	got, err := prometheus.DecodeReadRequest(snappy.Encode(nil, req.Marshal()), 0)
+	require.NoError(t, err)
+	require.Equal(t, req, got)
+	require.Equal(t, prometheus.StreamedXORChunksResponse, got.ResponseType())
+
+	got.AcceptedResponseTypes = nil
+	require.Equal(t, prometheus.SamplesResponse, got.ResponseType())
+}
+
+func TestDecodeReadRequest_DefaultLimit(t *testing.T) {
+	header := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(header, prometheus.DefaultMaxDecodedBytes+1)
+	_, err := prometheus.DecodeReadRequest(header[:n], 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds the limit")
+}
+
+func TestQuery_ReadFilterRequest(t *testing.T) {
+	q := &prometheus.Query{
+		StartTimestampMs: 1,
+		EndTimestampMs:   2,
+		Matchers: []prometheus.LabelMatcher{
+			{Type: prometheus.MatchEqual, Name: "__name__", Value: "up"},
+			{Type: prometheus.MatchNotEqual, Name: "job", Value: "api"},
+			{Type: prometheus.MatchRegexp, Name: "instance", Value: "a|b"},
+			{Type: prometheus.MatchNotRegexp, Name: "env", Value: "dev.*"},
+		},
+	}
+	req, err := q.ReadFilterRequest(nil)
+	require.NoError(t, err)
+	require.Equal(t, int64(1e6), req.Range.Start)
+	require.Equal(t, int64(3e6), req.Range.End)
+	require.Equal(t,
+		`'_field' = "value" AND '_measurement' = "up" AND 'job' != "api" AND 'instance' =~ /^(?:a|b)$/ AND 'env' !~ /^(?:dev.*)$/`,
+		reads.PredicateToExprString(req.Predicate))
+
+	q.Matchers = []prometheus.LabelMatcher{{Type: prometheus.MatchRegexp, Name: "job", Value: "("}}
+	_, err = q.ReadFilterRequest(nil)
+	require.Error(t, err)
+}
+
+// series is a float series of a sliceResultSet.
+type series struct {
+	tags       string
+	timestamps []int64
+	values     []float64
+}
+
+// sliceResultSet is a reads.ResultSet returning fixed series.
+type sliceResultSet struct {
+	series []series
+	cur    *series
+}
+
+func (rs *sliceResultSet) Next() bool {
+	if len(rs.series) == 0 {
+		return false
+	}
+	rs.cur, rs.series = &rs.series[0], rs.series[1:]
+	return true
+}
+
+func (rs *sliceResultSet) Cursor() cursors.Cursor {
+	if rs.cur.values == nil {
+		return &integerCursor{}
+	}
+	return &floatCursor{a: &cursors.FloatArray{Timestamps: rs.cur.timestamps, Values: rs.cur.values}}
+}
+
+func (rs *sliceResultSet) Tags() models.Tags { return models.ParseTags([]byte("m," + rs.cur.tags)) }
+func (rs *sliceResultSet) Close()            {}
+func (rs *sliceResultSet) Err() error        { return nil }
+
+func (rs *sliceResultSet) Stats() cursors.CursorStats { return cursors.CursorStats{} }
+
+type floatCursor struct {
+	a *cursors.FloatArray
+}
+
+func (c *floatCursor) Next() *cursors.FloatArray {
+	a := c.a
+	c.a = &cursors.FloatArray{}
+	return a
+}
+
+func (c *floatCursor) Close()                     {}
+func (c *floatCursor) Err() error                 { return nil }
+func (c *floatCursor) Stats() cursors.CursorStats { return cursors.CursorStats{} }
+
+type integerCursor struct{}
+
+func (c *integerCursor) Next() *cursors.IntegerArray { return &cursors.IntegerArray{} }
+func (c *integerCursor) Close()                      {}
+func (c *integerCursor) Err() error                  { return nil }
+func (c *integerCursor) Stats() cursors.CursorStats  { return cursors.CursorStats{} }
+
+func TestReadQueryResult(t *testing.T) {
+	rs := &sliceResultSet{series: []series{
+		{tags: "_field=value,_measurement=up,job=web", timestamps: []int64{1e6, 1e6 + 1, 2e6}, values: []float64{1, 2, 3}},
+		{tags: "_field=value,_measurement=up,job=api", timestamps: []int64{5e6}, values: []float64{0}},
+		{tags: "_field=value,_measurement=build_info,version=1"},
+		{tags: "_field=value,_measurement=up,job=empty", values: []float64{}},
+	}}
+	res, err := prometheus.ReadQueryResult(rs)
+	require.NoError(t, err)
+	require.Equal(t, []prometheus.TimeSeries{
+		{
+			Labels:  []prometheus.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "api"}},
+			Samples: []prometheus.Sample{{Value: 0, Timestamp: 5}},
+		},
+		{
+			Labels:  []prometheus.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "web"}},
+			Samples: []prometheus.Sample{{Value: 1, Timestamp: 1}, {Value: 3, Timestamp: 2}},
+		},
+	}, res.Timeseries)
+
+	resp := prometheus.ReadResponse{Results: []prometheus.QueryResult{*res}}
+	b, err := snappy.Decode(nil, resp.Encode())
+	require.NoError(t, err)
+	var got []prometheus.TimeSeries
+	err = pbwire.Range(b, func(f pbwire.Field) error {
+		return pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+			var ts prometheus.TimeSeries
+			got = append(got, ts)
+			return got[len(got)-1].Unmarshal(f.Bytes)
+		})
+	})
+	require.NoError(t, err)
+	require.Equal(t, res.Timeseries, got)
+}
+
+func TestChunkedWriter(t *testing.T) {
+	var timestamps []int64
+	var values []float64
+	for i := 0; i < 250; i++ {
+		timestamps = append(timestamps, int64(i)*15e9)
+		values = append(values, float64(i))
+	}
+	rs := &sliceResultSet{series: []series{
+		{tags: "_field=value,_measurement=up,job=web", timestamps: timestamps, values: values},
+		{tags: "_field=value,_measurement=up,job=api", timestamps: []int64{0}, values: []float64{1}},
+	}}
+
+	var buf bytes.Buffer
+	w := prometheus.NewChunkedWriter(&buf)
+	require.NoError(t, w.WriteResultSet(3, rs))
+
+	type frame struct {
+		labels     []prometheus.Label
+		chunks     [][2]int64 // min and max time
+		samples    int
+		queryIndex uint64
+	}
+	var frames []frame
+	b := buf.Bytes()
+	for len(b) > 0 {
+		size, n := binary.Uvarint(b)
+		require.Greater(t, n, 0)
+		b = b[n:]
+		sum, data := binary.BigEndian.Uint32(b), b[4:4+size]
+		b = b[4+size:]
+		require.Equal(t, crc32.Checksum(data, crc32.MakeTable(crc32.Castagnoli)), sum)
+
+		var fr frame
+		err := pbwire.Range(data, func(f pbwire.Field) error {
+			if f.Num == 2 {
+				fr.queryIndex = f.Int
+				return nil
+			}
+			return pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+				if f.Num == 1 {
+					var ts prometheus.TimeSeries
+					err := ts.Unmarshal(append([]byte{0x0a, byte(len(f.Bytes))}, f.Bytes...))
+					fr.labels = append(fr.labels, ts.Labels...)
+					return err
+				}
+				var c [2]int64
+				err := pbwire.Range(f.Bytes, func(f pbwire.Field) error {
+					switch f.Num {
+					case 1, 2:
+						c[f.Num-1] = f.Int64()
+					case 3:
+						require.Equal(t, uint64(1), f.Int)
+					case 4:
+						fr.samples += int(binary.BigEndian.Uint16(f.Bytes))
+					}
+					return nil
+				})
+				fr.chunks = append(fr.chunks, c)
+				return err
+			})
+		})
+		require.NoError(t, err)
+		frames = append(frames, fr)
+	}
+
+	require.Equal(t, []frame{
+		{
+			labels:     []prometheus.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "web"}},
+			chunks:     [][2]int64{{0, 119 * 15000}, {120 * 15000, 239 * 15000}, {240 * 15000, 249 * 15000}},
+			samples:    250,
+			queryIndex: 3,
+		},
+		{
+			labels:     []prometheus.Label{{Name: "__name__", Value: "up"}, {Name: "job", Value: "api"}},
+			chunks:     [][2]int64{{0, 0}},
+			samples:    1,
+			queryIndex: 3,
+		},
+	}, frames)
+}
@@ -0,0 +1,110 @@
+package prometheus_test
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/influxdata/influxdb/v2/prometheus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeWriteRequest(t *testing.T) {
+	req := &prometheus.WriteRequest{
+		Timeseries: []prometheus.TimeSeries{
+			{
+				Labels: []prometheus.Label{
+					{Name: "__name__", Value: "http_requests_total"},
+					{Name: "job", Value: "api"},
+					{Name: "code", Value: "200"},
+					{Name: "empty", Value: ""},
+				},
+				Samples: []prometheus.Sample{
+					{Value: 1, Timestamp: 1000},
+					{Value: math.NaN(), Timestamp: 2000},
+					{Value: 3, Timestamp: 3000},
+				},
+			},
+			{
+				Labels:  []prometheus.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prometheus.Sample{{Value: 1, Timestamp: -1}},
+			},
+		},
+	}
+	compressed := snappy.Encode(nil, req.Marshal())
+
+	got, err := prometheus.DecodeWriteRequest(compressed, 0)
+	require.NoError(t, err)
+	require.Len(t, got.Timeseries, 2)
+	require.Equal(t, req.Timeseries[0].Labels, got.Timeseries[0].Labels)
+	require.True(t, math.IsNaN(got.Timeseries[0].Samples[1].Value))
+	require.Equal(t, req.Timeseries[1], got.Timeseries[1])
+
+	pts, err := got.Points(nil)
+	require.NoError(t, err)
+	var lines []string
+	for _, pt := range pts {
+		lines = append(lines, pt.String())
+	}
+	require.Equal(t, []string{
+		"http_requests_total,code=200,job=api value=1 1000000000",
+		"http_requests_total,code=200,job=api value=3 3000000000",
+		"up value=1 -1000000",
+	}, lines)
+
+	_, err = prometheus.DecodeWriteRequest(compressed, 10)
+	require.Error(t, err)
+	_, err = prometheus.DecodeWriteRequest([]byte("not snappy"), 0)
+	require.Error(t, err)
+}
+
+func TestDecodeWriteRequest_DefaultLimit(t *testing.T) {
+	// The header of a snappy block states the length it decompresses to,
+	// which is checked before the block is decoded.
+	header := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(header, prometheus.DefaultMaxDecodedBytes+1)
+	_, err := prometheus.DecodeWriteRequest(header[:n], 0)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "exceeds the limit")
+}
+
+func TestWriteRequest_Points_NoName(t *testing.T) {
+	req := &prometheus.WriteRequest{
+		Timeseries: []prometheus.TimeSeries{{
+			Labels:  []prometheus.Label{{Name: "job", Value: "api"}},
+			Samples: []prometheus.Sample{{Value: 1}},
+		}},
+	}
+	_, err := req.Points(nil)
+	require.Error(t, err)
+}
+
+func TestWriteRequest_Points_OnError(t *testing.T) {
+	req := &prometheus.WriteRequest{
+		Timeseries: []prometheus.TimeSeries{
+			{
+				Labels:  []prometheus.Label{{Name: "job", Value: "api"}},
+				Samples: []prometheus.Sample{{Value: 1}},
+			},
+			{
+				Labels:  []prometheus.Label{{Name: "__name__", Value: "up"}},
+				Samples: []prometheus.Sample{{Value: 1}},
+			},
+			{
+				Labels:  []prometheus.Label{{Name: "__name__", Value: ""}, {Name: "path", Value: "a\"b"}},
+				Samples: []prometheus.Sample{{Value: 1}},
+			},
+		},
+	}
+
+	// Every series which cannot be converted is reported, and none written.
+	var series []string
+	pts, err := req.Points(func(s string, err error) {
+		require.Error(t, err)
+		series = append(series, s)
+	})
+	require.Error(t, err)
+	require.Empty(t, pts)
+	require.Equal(t, []string{`{job="api"}`, `{path="a\"b"}`}, series)
+}
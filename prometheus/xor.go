@@ -0,0 +1,150 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"math"
+	"math/bits"
+)
+
+// xorChunk encodes samples in the XOR chunk format of the Prometheus TSDB,
+// the encoding of the chunks of streamed remote read responses.
+type xorChunk struct {
+	b bstream
+
+	num      uint16
+	t        int64
+	v        float64
+	tDelta   uint64
+	leading  uint8
+	trailing uint8
+}
+
+func newXORChunk() *xorChunk {
+	c := &xorChunk{leading: 0xff}
+	c.b.stream = make([]byte, 2, 128)
+	return c
+}
+
+// Bytes returns the encoded chunk.
+func (c *xorChunk) Bytes() []byte {
+	binary.BigEndian.PutUint16(c.b.stream, c.num)
+	return c.b.stream
+}
+
+// Append adds a sample with a timestamp after that of the previous sample.
+func (c *xorChunk) Append(t int64, v float64) {
+	var tDelta uint64
+	switch c.num {
+	case 0:
+		var buf [binary.MaxVarintLen64]byte
+		for _, b := range buf[:binary.PutVarint(buf[:], t)] {
+			c.b.writeByte(b)
+		}
+		c.b.writeBits(math.Float64bits(v), 64)
+	case 1:
+		tDelta = uint64(t - c.t)
+		var buf [binary.MaxVarintLen64]byte
+		for _, b := range buf[:binary.PutUvarint(buf[:], tDelta)] {
+			c.b.writeByte(b)
+		}
+		c.writeVDelta(v)
+	default:
+		tDelta = uint64(t - c.t)
+		dod := int64(tDelta - c.tDelta)
+		switch {
+		case dod == 0:
+			c.b.writeBit(false)
+		case bitRange(dod, 14):
+			c.b.writeBits(0x02, 2)
+			c.b.writeBits(uint64(dod), 14)
+		case bitRange(dod, 17):
+			c.b.writeBits(0x06, 3)
+			c.b.writeBits(uint64(dod), 17)
+		case bitRange(dod, 20):
+			c.b.writeBits(0x0e, 4)
+			c.b.writeBits(uint64(dod), 20)
+		default:
+			c.b.writeBits(0x0f, 4)
+			c.b.writeBits(uint64(dod), 64)
+		}
+		c.writeVDelta(v)
+	}
+	c.t, c.v, c.tDelta = t, v, tDelta
+	c.num++
+}
+
+// bitRange reports whether x fits in nbits bits as encoded by the chunk.
+func bitRange(x int64, nbits uint8) bool {
+	return -((1<<(nbits-1))-1) <= x && x <= 1<<(nbits-1)
+}
+
+func (c *xorChunk) writeVDelta(v float64) {
+	delta := math.Float64bits(v) ^ math.Float64bits(c.v)
+	if delta == 0 {
+		c.b.writeBit(false)
+		return
+	}
+	c.b.writeBit(true)
+
+	leading := uint8(bits.LeadingZeros64(delta))
+	trailing := uint8(bits.TrailingZeros64(delta))
+	if leading >= 32 {
+		leading = 31
+	}
+	if c.leading != 0xff && leading >= c.leading && trailing >= c.trailing {
+		c.b.writeBit(false)
+		c.b.writeBits(delta>>c.trailing, 64-int(c.leading)-int(c.trailing))
+		return
+	}
+	c.leading, c.trailing = leading, trailing
+	c.b.writeBit(true)
+	c.b.writeBits(uint64(leading), 5)
+	// 64 significant bits are written as 0, since they do not fit in 6 bits
+	// and 0 significant bits never need to be written.
+	sigbits := 64 - leading - trailing
+	c.b.writeBits(uint64(sigbits), 6)
+	c.b.writeBits(delta>>trailing, int(sigbits))
+}
+
+// bstream is a stream of bits, written from the most significant bit of
+// each byte.
+type bstream struct {
+	stream []byte
+	count  uint8 // bits available in the last byte
+}
+
+func (b *bstream) writeBit(bit bool) {
+	if b.count == 0 {
+		b.stream = append(b.stream, 0)
+		b.count = 8
+	}
+	if bit {
+		b.stream[len(b.stream)-1] |= 1 << (b.count - 1)
+	}
+	b.count--
+}
+
+func (b *bstream) writeByte(byt byte) {
+	if b.count == 0 {
+		b.stream = append(b.stream, byt)
+		return
+	}
+	i := len(b.stream) - 1
+	b.stream[i] |= byt >> (8 - b.count)
+	b.stream = append(b.stream, byt<<b.count)
+}
+
+// writeBits writes the nbits least significant bits of u.
+func (b *bstream) writeBits(u uint64, nbits int) {
+	u <<= 64 - uint(nbits)
+	for nbits >= 8 {
+		b.writeByte(byte(u >> 56))
+		u <<= 8
+		nbits -= 8
+	}
+	for nbits > 0 {
+		b.writeBit((u >> 63) == 1)
+		u <<= 1
+		nbits--
+	}
+}
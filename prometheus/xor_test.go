@@ -0,0 +1,122 @@
+package prometheus
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// bitReader reads a bstream.
+type bitReader struct {
+	b   []byte
+	pos int // in bits
+}
+
+func (r *bitReader) bit() bool {
+	v := r.b[r.pos/8]&(0x80>>(r.pos%8)) != 0
+	r.pos++
+	return v
+}
+
+func (r *bitReader) bits(n int) uint64 {
+	var u uint64
+	for i := 0; i < n; i++ {
+		u <<= 1
+		if r.bit() {
+			u |= 1
+		}
+	}
+	return u
+}
+
+func (r *bitReader) ReadByte() (byte, error) { return byte(r.bits(8)), nil }
+
+// decodeXOR decodes an XOR chunk as the Prometheus TSDB does.
+func decodeXOR(t *testing.T, b []byte) []Sample {
+	num := int(binary.BigEndian.Uint16(b))
+	r := &bitReader{b: b[2:]}
+
+	var (
+		samples           []Sample
+		ts                int64
+		tDelta            uint64
+		v                 uint64
+		leading, trailing int
+	)
+	readValue := func() {
+		if !r.bit() {
+			return
+		}
+		if r.bit() {
+			leading = int(r.bits(5))
+			sigbits := int(r.bits(6))
+			if sigbits == 0 {
+				sigbits = 64
+			}
+			trailing = 64 - leading - sigbits
+		}
+		v ^= r.bits(64-leading-trailing) << trailing
+	}
+
+	for i := 0; i < num; i++ {
+		switch i {
+		case 0:
+			t0, err := binary.ReadVarint(r)
+			require.NoError(t, err)
+			ts = t0
+			v = r.bits(64)
+		case 1:
+			d, err := binary.ReadUvarint(r)
+			require.NoError(t, err)
+			tDelta = d
+			ts += int64(tDelta)
+			readValue()
+		default:
+			var n int
+			for n < 4 && r.bit() {
+				n++
+			}
+			var dod int64
+			if size := [...]int{0, 14, 17, 20, 64}[n]; size > 0 {
+				bits := r.bits(size)
+				if size < 64 && bits > 1<<(size-1) {
+					bits -= 1 << size
+				}
+				dod = int64(bits)
+			}
+			tDelta = uint64(int64(tDelta) + dod)
+			ts += int64(tDelta)
+			readValue()
+		}
+		samples = append(samples, Sample{Timestamp: ts, Value: math.Float64frombits(v)})
+	}
+	return samples
+}
+
+func TestXORChunk(t *testing.T) {
+	var samples []Sample
+	ts := int64(-5000)
+	for i, d := range []int64{0, 1000, 1000, 1000, 1007, 993, 20000, 1, 300000, 1 << 40, 15, 15, 8000} {
+		ts += d
+		v := float64(i) * 1.5
+		switch i % 4 {
+		case 1:
+			v = -v
+		case 2:
+			v = math.MaxFloat64 / float64(i)
+		case 3:
+			v = samples[i-1].Value
+		}
+		samples = append(samples, Sample{Timestamp: ts, Value: v})
+	}
+
+	for n := 1; n <= len(samples); n++ {
+		c := newXORChunk()
+		for _, s := range samples[:n] {
+			c.Append(s.Timestamp, s.Value)
+		}
+		require.Equal(t, samples[:n], decodeXOR(t, c.Bytes()), "%d samples", n)
+	}
+}
@@ -122,6 +122,10 @@ type ReadWindowAggregatePhysSpec struct {
 	// ForceAggregate forces the aggregates to be treated as
 	// aggregates even if they are selectors.
 	ForceAggregate bool
+
+	// Quantile and Compression are the arguments of the quantile aggregate.
+	Quantile    float64
+	Compression float64
 }
 
 func (s *ReadWindowAggregatePhysSpec) PlanDetails() string {
@@ -31,6 +31,7 @@ func init() {
 		PushDownWindowForceAggregateRule{},
 		PushDownWindowAggregateByTimeRule{},
 		PushDownBareAggregateRule{},
+		PushDownCountDistinctRule{},
 		GroupWindowAggregateTransposeRule{},
 		PushDownGroupAggregateRule{},
 	)
@@ -657,7 +658,7 @@ func (SortedPivotRule) Rewrite(ctx context.Context, pn plan.Node) (plan.Node, bo
 
 //
 // Push Down of window aggregates.
-// ReadRangePhys |> window |> { min, max, mean, count, sum }
+// ReadRangePhys |> window |> { min, max, mean, count, sum, stddev, spread, quantile }
 //
 type PushDownWindowAggregateRule struct{}
 
@@ -673,6 +674,9 @@ var windowPushableAggs = []plan.ProcedureKind{
 	universe.MeanKind,
 	universe.FirstKind,
 	universe.LastKind,
+	universe.StddevKind,
+	universe.SpreadKind,
+	universe.QuantileKind,
 }
 
 func (rule PushDownWindowAggregateRule) Pattern() plan.Pattern {
@@ -708,10 +712,51 @@ func canPushWindowedAggregate(ctx context.Context, fnNode plan.Node) bool {
 	case universe.LastKind:
 		lastSpec := fnNode.ProcedureSpec().(*universe.LastProcedureSpec)
 		return lastSpec.Column == execute.DefaultValueColLabel
+	case universe.StddevKind:
+		stddevSpec := fnNode.ProcedureSpec().(*universe.StddevProcedureSpec)
+		return stddevSpec.Mode == "sample" &&
+			len(stddevSpec.Columns) == 1 &&
+			stddevSpec.Columns[0] == execute.DefaultValueColLabel &&
+			storageSupportsWindowAggregate(ctx, fnNode.Kind())
+	case universe.SpreadKind:
+		spreadSpec := fnNode.ProcedureSpec().(*universe.SpreadProcedureSpec)
+		return len(spreadSpec.Columns) == 1 &&
+			spreadSpec.Columns[0] == execute.DefaultValueColLabel &&
+			storageSupportsWindowAggregate(ctx, fnNode.Kind())
+	case universe.QuantileKind:
+		// Only the estimate_tdigest method is computed by storage; the
+		// exact methods produce other specs.
+		quantileSpec, ok := fnNode.ProcedureSpec().(*universe.TDigestQuantileProcedureSpec)
+		return ok &&
+			len(quantileSpec.Columns) == 1 &&
+			quantileSpec.Columns[0] == execute.DefaultValueColLabel &&
+			storageSupportsWindowAggregate(ctx, fnNode.Kind())
 	}
 	return true
 }
 
+// storageSupportsWindowAggregate reports whether the storage reader advertises
+// that it computes aggregates of the given kind. Aggregates added after the
+// original set of pushed down aggregates are only pushed down if it does.
+func storageSupportsWindowAggregate(ctx context.Context, kind plan.ProcedureKind) bool {
+	reader := GetStorageDependencies(ctx).FromDeps.Reader
+	return reader != nil && reader.SupportWindowAggregate(ctx, kind)
+}
+
+// newReadWindowAggregatePhysSpec creates the spec of a window aggregate read
+// computing the aggregate of fnNode.
+func newReadWindowAggregatePhysSpec(fromSpec *ReadRangePhysSpec, fnNode plan.Node) *ReadWindowAggregatePhysSpec {
+	spec := &ReadWindowAggregatePhysSpec{
+		ReadRangePhysSpec: *fromSpec.Copy().(*ReadRangePhysSpec),
+		Aggregates:        []plan.ProcedureKind{fnNode.Kind()},
+	}
+	if quantileSpec, ok := fnNode.ProcedureSpec().(*universe.TDigestQuantileProcedureSpec); ok {
+		spec.Quantile = quantileSpec.Quantile
+		spec.Compression = quantileSpec.Compression
+	}
+	return spec
+}
+
 func isPushableWindow(windowSpec *universe.WindowProcedureSpec) bool {
 	// every and period must be equal
 	// every.isNegative must be false
@@ -747,13 +792,11 @@ func (PushDownWindowAggregateRule) Rewrite(ctx context.Context, pn plan.Node) (p
 	}
 
 	// Rule passes.
-	return plan.CreateUniquePhysicalNode(ctx, "ReadWindowAggregate", &ReadWindowAggregatePhysSpec{
-		ReadRangePhysSpec: *fromSpec.Copy().(*ReadRangePhysSpec),
-		Aggregates:        []plan.ProcedureKind{fnNode.Kind()},
-		WindowEvery:       windowSpec.Window.Every,
-		Offset:            windowSpec.Window.Offset,
-		CreateEmpty:       windowSpec.CreateEmpty,
-	}), true, nil
+	spec := newReadWindowAggregatePhysSpec(fromSpec, fnNode)
+	spec.WindowEvery = windowSpec.Window.Every
+	spec.Offset = windowSpec.Window.Offset
+	spec.CreateEmpty = windowSpec.CreateEmpty
+	return plan.CreateUniquePhysicalNode(ctx, "ReadWindowAggregate", spec), true, nil
 }
 
 // PushDownWindowForceAggregateRule will match the given pattern.
@@ -879,11 +922,66 @@ func (p PushDownBareAggregateRule) Rewrite(ctx context.Context, pn plan.Node) (p
 	fromNode := fnNode.Predecessors()[0]
 	fromSpec := fromNode.ProcedureSpec().(*ReadRangePhysSpec)
 
-	return plan.CreateUniquePhysicalNode(ctx, "ReadWindowAggregate", &ReadWindowAggregatePhysSpec{
-		ReadRangePhysSpec: *fromSpec.Copy().(*ReadRangePhysSpec),
-		Aggregates:        []plan.ProcedureKind{fnNode.Kind()},
-		WindowEvery:       flux.ConvertDuration(math.MaxInt64 * time.Nanosecond),
-	}), true, nil
+	spec := newReadWindowAggregatePhysSpec(fromSpec, fnNode)
+	spec.WindowEvery = flux.ConvertDuration(math.MaxInt64 * time.Nanosecond)
+	return plan.CreateUniquePhysicalNode(ctx, "ReadWindowAggregate", spec), true, nil
+}
+
+// CountDistinctKind is the kind of the aggregate counting the distinct
+// values of a window, computed by a window aggregate read.
+const CountDistinctKind plan.ProcedureKind = "countDistinct"
+
+// PushDownCountDistinctRule will match the given patterns.
+// ReadRangePhys |> window |> distinct |> count
+// ReadRangePhys |> distinct |> count
+//
+// If the storage reader computes the count of distinct values,
+// it rewrites them to a ReadWindowAggregatePhys with the count
+// distinct aggregate.
+type PushDownCountDistinctRule struct{}
+
+func (PushDownCountDistinctRule) Name() string {
+	return "PushDownCountDistinctRule"
+}
+
+func (PushDownCountDistinctRule) Pattern() plan.Pattern {
+	return plan.Pat(universe.CountKind,
+		plan.Pat(universe.DistinctKind, plan.Any()))
+}
+
+func (PushDownCountDistinctRule) Rewrite(ctx context.Context, pn plan.Node) (plan.Node, bool, error) {
+	countNode := pn
+	countSpec := countNode.ProcedureSpec().(*universe.CountProcedureSpec)
+	distinctNode := countNode.Predecessors()[0]
+	distinctSpec := distinctNode.ProcedureSpec().(*universe.DistinctProcedureSpec)
+	if len(countSpec.Columns) != 1 ||
+		countSpec.Columns[0] != execute.DefaultValueColLabel ||
+		distinctSpec.Column != execute.DefaultValueColLabel {
+		return pn, false, nil
+	}
+
+	spec := &ReadWindowAggregatePhysSpec{
+		Aggregates:  []plan.ProcedureKind{CountDistinctKind},
+		WindowEvery: flux.ConvertDuration(math.MaxInt64 * time.Nanosecond),
+	}
+	fromNode := distinctNode.Predecessors()[0]
+	if fromNode.Kind() == universe.WindowKind {
+		windowSpec := fromNode.ProcedureSpec().(*universe.WindowProcedureSpec)
+		if !isPushableWindow(windowSpec) {
+			return pn, false, nil
+		}
+		spec.WindowEvery = windowSpec.Window.Every
+		spec.Offset = windowSpec.Window.Offset
+		spec.CreateEmpty = windowSpec.CreateEmpty
+		fromNode = fromNode.Predecessors()[0]
+	}
+	if fromNode.Kind() != ReadRangePhysKind || !storageSupportsWindowAggregate(ctx, CountDistinctKind) {
+		return pn, false, nil
+	}
+	spec.ReadRangePhysSpec = *fromNode.ProcedureSpec().(*ReadRangePhysSpec).Copy().(*ReadRangePhysSpec)
+
+	// Rule passes.
+	return plan.CreateUniquePhysicalNode(ctx, "ReadWindowAggregate", spec), true, nil
 }
 
 // GroupWindowAggregateTransposeRule will match the given pattern.
@@ -1157,6 +1157,43 @@ func lastProcedureSpec() *universe.LastProcedureSpec {
 		SelectorConfig: execute.SelectorConfig{Column: execute.DefaultValueColLabel},
 	}
 }
+func stddevProcedureSpec() *universe.StddevProcedureSpec {
+	return &universe.StddevProcedureSpec{
+		Mode:                  "sample",
+		SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{execute.DefaultValueColLabel}},
+	}
+}
+func spreadProcedureSpec() *universe.SpreadProcedureSpec {
+	return &universe.SpreadProcedureSpec{
+		SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{execute.DefaultValueColLabel}},
+	}
+}
+func quantileProcedureSpec() *universe.TDigestQuantileProcedureSpec {
+	return &universe.TDigestQuantileProcedureSpec{
+		Quantile:              0.99,
+		Compression:           1000,
+		SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{execute.DefaultValueColLabel}},
+	}
+}
+func distinctProcedureSpec() *universe.DistinctProcedureSpec {
+	return &universe.DistinctProcedureSpec{
+		Column: execute.DefaultValueColLabel,
+	}
+}
+
+// withWindowAggregateCaps returns a context whose storage reader computes
+// every window aggregate.
+func withWindowAggregateCaps() context.Context {
+	return influxdb.StorageDependencies{
+		FromDeps: influxdb.FromDependencies{
+			Reader: &mock.StorageReader{
+				SupportWindowAggregateFn: func(context.Context, plan.ProcedureKind) bool {
+					return true
+				},
+			},
+		},
+	}.Inject(context.Background())
+}
 func meanProcedureSpec() *universe.MeanProcedureSpec {
 	return &universe.MeanProcedureSpec{
 		SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{execute.DefaultValueColLabel}},
@@ -1311,6 +1348,85 @@ func TestPushDownWindowAggregateRule(t *testing.T) {
 		After:   simpleResult(universe.LastKind, false),
 	})
 
+	// ReadRange -> window -> stddev => ReadWindowAggregate
+	tests = append(tests, plantest.RuleTestCase{
+		Context: withWindowAggregateCaps(),
+		Name:    "SimplePassStddev",
+		Rules:   []plan.Rule{influxdb.PushDownWindowAggregateRule{}},
+		Before:  simplePlanWithWindowAgg(window1m, universe.StddevKind, stddevProcedureSpec()),
+		After:   simpleResult(universe.StddevKind, false),
+	})
+
+	// ReadRange -> window -> spread => ReadWindowAggregate
+	tests = append(tests, plantest.RuleTestCase{
+		Context: withWindowAggregateCaps(),
+		Name:    "SimplePassSpread",
+		Rules:   []plan.Rule{influxdb.PushDownWindowAggregateRule{}},
+		Before:  simplePlanWithWindowAgg(window1m, universe.SpreadKind, spreadProcedureSpec()),
+		After:   simpleResult(universe.SpreadKind, false),
+	})
+
+	// ReadRange -> window -> quantile => ReadWindowAggregate
+	tests = append(tests, plantest.RuleTestCase{
+		Context: withWindowAggregateCaps(),
+		Name:    "SimplePassQuantile",
+		Rules:   []plan.Rule{influxdb.PushDownWindowAggregateRule{}},
+		Before:  simplePlanWithWindowAgg(window1m, universe.QuantileKind, quantileProcedureSpec()),
+		After: &plantest.PlanSpec{
+			Nodes: []plan.Node{
+				plan.CreatePhysicalNode("ReadWindowAggregate", &influxdb.ReadWindowAggregatePhysSpec{
+					ReadRangePhysSpec: *createRangeSpec(),
+					Aggregates:        []plan.ProcedureKind{universe.QuantileKind},
+					WindowEvery:       flux.ConvertDuration(60000000000 * time.Nanosecond),
+					Quantile:          0.99,
+					Compression:       1000,
+				}),
+			},
+		},
+	})
+
+	// Exact quantiles are not computed by storage.
+	tests = append(tests, plantest.RuleTestCase{
+		Context: withWindowAggregateCaps(),
+		Name:    "ExactMeanQuantile",
+		Rules:   []plan.Rule{influxdb.PushDownWindowAggregateRule{}},
+		Before: simplePlanWithWindowAgg(window1m, universe.QuantileKind, &universe.ExactQuantileAggProcedureSpec{
+			Quantile:              0.99,
+			SimpleAggregateConfig: execute.SimpleAggregateConfig{Columns: []string{execute.DefaultValueColLabel}},
+		}),
+		NoChange: true,
+	})
+	tests = append(tests, plantest.RuleTestCase{
+		Context: withWindowAggregateCaps(),
+		Name:    "ExactSelectorQuantile",
+		Rules:   []plan.Rule{influxdb.PushDownWindowAggregateRule{}},
+		Before: simplePlanWithWindowAgg(window1m, universe.QuantileKind, &universe.ExactQuantileSelectProcedureSpec{
+			Quantile:       0.99,
+			SelectorConfig: execute.SelectorConfig{Column: execute.DefaultValueColLabel},
+		}),
+		NoChange: true,
+	})
+
+	// Without storage support, the aggregate is not pushed down.
+	tests = append(tests, plantest.RuleTestCase{
+		Context:  context.Background(),
+		Name:     "StddevWithoutCaps",
+		Rules:    []plan.Rule{influxdb.PushDownWindowAggregateRule{}},
+		Before:   simplePlanWithWindowAgg(window1m, universe.StddevKind, stddevProcedureSpec()),
+		NoChange: true,
+	})
+
+	// Population standard deviations are not computed by storage.
+	populationStddev := stddevProcedureSpec()
+	populationStddev.Mode = "population"
+	tests = append(tests, plantest.RuleTestCase{
+		Context:  withWindowAggregateCaps(),
+		Name:     "PopulationStddev",
+		Rules:    []plan.Rule{influxdb.PushDownWindowAggregateRule{}},
+		Before:   simplePlanWithWindowAgg(window1m, universe.StddevKind, populationStddev),
+		NoChange: true,
+	})
+
 	// Rewrite with successors
 	// ReadRange -> window -> min -> count {2} => ReadWindowAggregate -> count {2}
 	tests = append(tests, plantest.RuleTestCase{
@@ -2746,6 +2862,127 @@ func TestPushDownBareAggregateRule(t *testing.T) {
 	}
 }
 
+func TestPushDownCountDistinctRule(t *testing.T) {
+	createRangeSpec := func() *influxdb.ReadRangePhysSpec {
+		return &influxdb.ReadRangePhysSpec{
+			Bucket: "my-bucket",
+			Bounds: flux.Bounds{
+				Start: fluxTime(5),
+				Stop:  fluxTime(10),
+			},
+		}
+	}
+
+	dur1m := values.ConvertDurationNsecs(60 * time.Second)
+	window1m := universe.WindowProcedureSpec{
+		Window: plan.WindowSpec{
+			Every:  dur1m,
+			Period: dur1m,
+			Offset: values.ConvertDurationNsecs(0),
+			Location: plan.Location{
+				Name: "UTC",
+			},
+		},
+		TimeColumn:  "_time",
+		StartColumn: "_start",
+		StopColumn:  "_stop",
+	}
+
+	windowPlan := &plantest.PlanSpec{
+		Nodes: []plan.Node{
+			plan.CreatePhysicalNode("ReadRange", createRangeSpec()),
+			plan.CreatePhysicalNode("window", &window1m),
+			plan.CreatePhysicalNode("distinct", distinctProcedureSpec()),
+			plan.CreatePhysicalNode("count", countProcedureSpec()),
+		},
+		Edges: [][2]int{
+			{0, 1},
+			{1, 2},
+			{2, 3},
+		},
+	}
+
+	otherColumn := distinctProcedureSpec()
+	otherColumn.Column = "host"
+
+	testcases := []plantest.RuleTestCase{
+		{
+			// ReadRange -> window -> distinct -> count => ReadWindowAggregate
+			Context: withWindowAggregateCaps(),
+			Name:    "window",
+			Rules:   []plan.Rule{influxdb.PushDownCountDistinctRule{}},
+			Before:  windowPlan,
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("ReadWindowAggregate", &influxdb.ReadWindowAggregatePhysSpec{
+						ReadRangePhysSpec: *createRangeSpec(),
+						Aggregates:        []plan.ProcedureKind{influxdb.CountDistinctKind},
+						WindowEvery:       flux.ConvertDuration(time.Minute),
+					}),
+				},
+			},
+		},
+		{
+			// ReadRange -> distinct -> count => ReadWindowAggregate
+			Context: withWindowAggregateCaps(),
+			Name:    "bare",
+			Rules:   []plan.Rule{influxdb.PushDownCountDistinctRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("ReadRange", createRangeSpec()),
+					plan.CreatePhysicalNode("distinct", distinctProcedureSpec()),
+					plan.CreatePhysicalNode("count", countProcedureSpec()),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			After: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("ReadWindowAggregate", &influxdb.ReadWindowAggregatePhysSpec{
+						ReadRangePhysSpec: *createRangeSpec(),
+						Aggregates:        []plan.ProcedureKind{influxdb.CountDistinctKind},
+						WindowEvery:       flux.ConvertDuration(math.MaxInt64 * time.Nanosecond),
+					}),
+				},
+			},
+		},
+		{
+			Context:  context.Background(),
+			Name:     "without caps",
+			Rules:    []plan.Rule{influxdb.PushDownCountDistinctRule{}},
+			Before:   windowPlan,
+			NoChange: true,
+		},
+		{
+			Context: withWindowAggregateCaps(),
+			Name:    "distinct column",
+			Rules:   []plan.Rule{influxdb.PushDownCountDistinctRule{}},
+			Before: &plantest.PlanSpec{
+				Nodes: []plan.Node{
+					plan.CreatePhysicalNode("ReadRange", createRangeSpec()),
+					plan.CreatePhysicalNode("distinct", otherColumn),
+					plan.CreatePhysicalNode("count", countProcedureSpec()),
+				},
+				Edges: [][2]int{
+					{0, 1},
+					{1, 2},
+				},
+			},
+			NoChange: true,
+		},
+	}
+
+	for _, tc := range testcases {
+		tc := tc
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Parallel()
+			plantest.PhysicalRuleTestHelper(t, &tc, protocmp.Transform())
+		})
+	}
+}
+
 //
 // Group Aggregate Testing
 //
@@ -351,6 +351,8 @@ func createReadWindowAggregateSource(s plan.ProcedureSpec, id execute.DatasetID,
 			CreateEmpty:    spec.CreateEmpty,
 			TimeColumn:     spec.TimeColumn,
 			ForceAggregate: spec.ForceAggregate,
+			Quantile:       spec.Quantile,
+			Compression:    spec.Compression,
 		},
 		a,
 	), nil
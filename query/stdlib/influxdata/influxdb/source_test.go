@@ -66,6 +66,10 @@ func (mockReader) SupportReadSeriesCardinality(ctx context.Context) bool {
 	return false
 }
 
+func (mockReader) SupportWindowAggregate(ctx context.Context, kind plan.ProcedureKind) bool {
+	return false
+}
+
 func (mockReader) Close() {
 }
 
@@ -25,6 +25,10 @@ type StorageReader interface {
 	ReadSeriesCardinality(ctx context.Context, spec ReadSeriesCardinalitySpec, alloc memory.Allocator) (TableIterator, error)
 	SupportReadSeriesCardinality(ctx context.Context) bool
 
+	// SupportWindowAggregate reports whether ReadWindowAggregate can compute
+	// the aggregate of the given kind.
+	SupportWindowAggregate(ctx context.Context, kind plan.ProcedureKind) bool
+
 	Close()
 }
 
@@ -75,6 +79,10 @@ type ReadWindowAggregateSpec struct {
 	TimeColumn  string
 	Window      execute.Window
 
+	// Quantile and Compression are the arguments of the quantile aggregate.
+	Quantile    float64
+	Compression float64
+
 	// ForceAggregate forces all aggregates to be treated as aggregates.
 	// This forces selectors, which normally don't return values for empty
 	// windows, to return a null value.
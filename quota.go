@@ -0,0 +1,121 @@
+package influxdb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// OrgQuotas bounds the resources used by an organization. A quota of zero is
+// unlimited.
+//
+// Operations that would exceed a quota are rejected with a too many requests
+// error, which tells the client when to retry if the quota frees up over time.
+type OrgQuotas struct {
+	// MaxStorageBytes bounds the size on disk of the shards of the
+	// organization's buckets. Writes are rejected while it is exceeded.
+	MaxStorageBytes int64 `json:"maxStorageBytes,omitempty"`
+
+	// MaxWritePointsPerSecond bounds the rate of points written to the
+	// organization's buckets.
+	MaxWritePointsPerSecond int64 `json:"maxWritePointsPerSecond,omitempty"`
+
+	// MaxWriteBytesPerSecond bounds the rate of line protocol bytes written
+	// to the organization's buckets.
+	MaxWriteBytesPerSecond int64 `json:"maxWriteBytesPerSecond,omitempty"`
+
+	// MaxConcurrentQueries bounds the number of Flux and InfluxQL queries of
+	// the organization running at once.
+	MaxConcurrentQueries int `json:"maxConcurrentQueries,omitempty"`
+
+	// MaxTasks bounds the number of tasks of the organization.
+	MaxTasks int `json:"maxTasks,omitempty"`
+
+	// MaxBuckets bounds the number of buckets of the organization.
+	MaxBuckets int `json:"maxBuckets,omitempty"`
+}
+
+// Valid returns an error if a quota is negative.
+func (q *OrgQuotas) Valid() error {
+	if q.MaxStorageBytes < 0 || q.MaxWritePointsPerSecond < 0 || q.MaxWriteBytesPerSecond < 0 ||
+		q.MaxConcurrentQueries < 0 || q.MaxTasks < 0 || q.MaxBuckets < 0 {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "quotas must not be negative",
+		}
+	}
+	return nil
+}
+
+// OrgUsage reports the resources used by an organization alongside its
+// quotas.
+type OrgUsage struct {
+	OrgID  platform.ID `json:"orgID"`
+	Quotas OrgQuotas   `json:"quotas"`
+
+	// StorageBytes is the size on disk of the organization's buckets as of
+	// StorageUpdatedAt; it is refreshed periodically.
+	StorageBytes     int64     `json:"storageBytes"`
+	StorageUpdatedAt time.Time `json:"storageUpdatedAt"`
+
+	// WritePointsPerSecond and WriteBytesPerSecond are the write rates
+	// averaged over the last period of at least a minute.
+	WritePointsPerSecond float64 `json:"writePointsPerSecond"`
+	WriteBytesPerSecond  float64 `json:"writeBytesPerSecond"`
+
+	ConcurrentQueries int `json:"concurrentQueries"`
+	Tasks             int `json:"tasks"`
+	Buckets           int `json:"buckets"`
+}
+
+// OrgUsageService reports the resource usage of organizations.
+type OrgUsageService interface {
+	// FindOrgUsage returns the resource usage of an organization.
+	FindOrgUsage(ctx context.Context, orgID platform.ID) (*OrgUsage, error)
+}
+
+// WriteLimiter admits writes to the buckets of organizations.
+type WriteLimiter interface {
+	// AllowWrite returns an error if writing points points of bytes bytes
+	// of line protocol to the buckets of an organization is not allowed,
+	// and otherwise records the write.
+	AllowWrite(ctx context.Context, orgID platform.ID, points, bytes int) error
+}
+
+// QuotaExceededError is the cause of the error of an operation rejected
+// because it would exceed a quota of an organization.
+type QuotaExceededError struct {
+	// Quota is the name of the quota, as in the JSON of OrgQuotas.
+	Quota string
+	Limit int64
+
+	// Wait is how long until the operation may succeed if retried, or zero
+	// if retrying will not help until the organization's usage goes down.
+	Wait time.Duration
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("organization quota exceeded: %s is %d", e.Quota, e.Limit)
+}
+
+// RetryAfter returns how long to wait before retrying.
+func (e *QuotaExceededError) RetryAfter() time.Duration {
+	return e.Wait
+}
+
+// NewQuotaExceededError returns a too many requests error caused by exceeding
+// quota.
+func NewQuotaExceededError(op, quota string, limit int64, wait time.Duration) *errors.Error {
+	return &errors.Error{
+		Code: errors.ETooManyRequests,
+		Op:   op,
+		Err: &QuotaExceededError{
+			Quota: quota,
+			Limit: limit,
+			Wait:  wait,
+		},
+	}
+}
@@ -0,0 +1,37 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+// BucketService wraps an existing influxdb.BucketService implementation.
+//
+// BucketService rejects the creation of buckets beyond the bucket quota of
+// their organization.
+type BucketService struct {
+	influxdb.BucketService
+	quotas *Service
+}
+
+// NewBucketService returns a new BucketService enforcing quotas.
+func NewBucketService(s influxdb.BucketService, quotas *Service) *BucketService {
+	return &BucketService{
+		BucketService: s,
+		quotas:        quotas,
+	}
+}
+
+// CreateBucket creates a bucket unless its organization has as many buckets
+// as its quota allows.
+func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.quotas.checkCount(ctx, "quota/CreateBucket", QuotaBuckets, b.OrgID, s.quotas.countBuckets); err != nil {
+		return err
+	}
+	return s.BucketService.CreateBucket(ctx, b)
+}
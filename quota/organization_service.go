@@ -0,0 +1,100 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+)
+
+// OrganizationService wraps an existing influxdb.OrganizationService
+// implementation.
+//
+// OrganizationService validates the quotas of organizations and ensures the
+// Service enforces their current quotas.
+type OrganizationService struct {
+	influxdb.OrganizationService
+	quotas *Service
+}
+
+// NewOrganizationService returns a new OrganizationService keeping quotas
+// current.
+func NewOrganizationService(s influxdb.OrganizationService, quotas *Service) *OrganizationService {
+	return &OrganizationService{
+		OrganizationService: s,
+		quotas:              quotas,
+	}
+}
+
+// LoadQuotas registers the quotas of every organization with the Service. It
+// is called once at startup; the quotas are kept current as organizations
+// change.
+func (s *OrganizationService) LoadQuotas(ctx context.Context) error {
+	for offset := 0; ; {
+		orgs, _, err := s.FindOrganizations(ctx, influxdb.OrganizationFilter{}, influxdb.FindOptions{Limit: influxdb.MaxPageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, o := range orgs {
+			if o.Quotas != nil {
+				s.quotas.SetOrgQuotas(o.ID, o.Quotas)
+			}
+		}
+		if len(orgs) < influxdb.MaxPageSize {
+			return nil
+		}
+		offset += len(orgs)
+	}
+}
+
+// CreateOrganization creates an organization and applies its quotas.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if o.Quotas != nil {
+		if err := o.Quotas.Valid(); err != nil {
+			return err
+		}
+	}
+	if err := s.OrganizationService.CreateOrganization(ctx, o); err != nil {
+		return err
+	}
+	if o.Quotas != nil {
+		s.quotas.SetOrgQuotas(o.ID, o.Quotas)
+	}
+	return nil
+}
+
+// UpdateOrganization updates an organization and applies its new quotas.
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id platform.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if upd.Quotas != nil {
+		if err := upd.Quotas.Valid(); err != nil {
+			return nil, err
+		}
+	}
+	o, err := s.OrganizationService.UpdateOrganization(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+	if upd.Quotas != nil {
+		s.quotas.SetOrgQuotas(o.ID, o.Quotas)
+	}
+	return o, nil
+}
+
+// DeleteOrganization removes an organization and its quotas.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id platform.ID) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.OrganizationService.DeleteOrganization(ctx, id); err != nil {
+		return err
+	}
+	s.quotas.SetOrgQuotas(id, nil)
+	return nil
+}
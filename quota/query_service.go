@@ -0,0 +1,61 @@
+package quota
+
+import (
+	"context"
+	"io"
+
+	"github.com/influxdata/flux"
+	"github.com/influxdata/influxdb/v2/influxql"
+	"github.com/influxdata/influxdb/v2/kit/check"
+	"github.com/influxdata/influxdb/v2/query"
+)
+
+// FluxQueryService wraps a query.ProxyQueryService and rejects queries beyond
+// the concurrent query quota of their organization.
+type FluxQueryService struct {
+	s      query.ProxyQueryService
+	quotas *Service
+}
+
+// NewFluxQueryService returns a new FluxQueryService enforcing quotas.
+func NewFluxQueryService(s query.ProxyQueryService, quotas *Service) *FluxQueryService {
+	return &FluxQueryService{s: s, quotas: quotas}
+}
+
+func (s *FluxQueryService) Query(ctx context.Context, w io.Writer, req *query.ProxyRequest) (flux.Statistics, error) {
+	orgID := req.Request.OrganizationID
+	if err := s.quotas.acquireQuery(orgID); err != nil {
+		return flux.Statistics{}, err
+	}
+	defer s.quotas.releaseQuery(orgID)
+	return s.s.Query(ctx, w, req)
+}
+
+func (s *FluxQueryService) Check(ctx context.Context) check.Response {
+	return s.s.Check(ctx)
+}
+
+// InfluxQLQueryService wraps an influxql.ProxyQueryService and rejects
+// queries beyond the concurrent query quota of their organization.
+type InfluxQLQueryService struct {
+	s      influxql.ProxyQueryService
+	quotas *Service
+}
+
+// NewInfluxQLQueryService returns a new InfluxQLQueryService enforcing
+// quotas.
+func NewInfluxQLQueryService(s influxql.ProxyQueryService, quotas *Service) *InfluxQLQueryService {
+	return &InfluxQLQueryService{s: s, quotas: quotas}
+}
+
+func (s *InfluxQLQueryService) Query(ctx context.Context, w io.Writer, req *influxql.QueryRequest) (influxql.Statistics, error) {
+	if err := s.quotas.acquireQuery(req.OrganizationID); err != nil {
+		return influxql.Statistics{}, err
+	}
+	defer s.quotas.releaseQuery(req.OrganizationID)
+	return s.s.Query(ctx, w, req)
+}
+
+func (s *InfluxQLQueryService) Check(ctx context.Context) check.Response {
+	return s.s.Check(ctx)
+}
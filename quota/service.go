@@ -0,0 +1,459 @@
+// Package quota enforces the resource quotas of organizations.
+//
+// The Service tracks the usage of every organization with quotas. Writes are
+// checked by the write handler, and the wrappers of this package check the
+// creation of buckets and tasks and the running of queries.
+package quota
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// The names of the quotas, as in the JSON of influxdb.OrgQuotas.
+const (
+	QuotaStorageBytes         = "maxStorageBytes"
+	QuotaWritePointsPerSecond = "maxWritePointsPerSecond"
+	QuotaWriteBytesPerSecond  = "maxWriteBytesPerSecond"
+	QuotaConcurrentQueries    = "maxConcurrentQueries"
+	QuotaTasks                = "maxTasks"
+	QuotaBuckets              = "maxBuckets"
+)
+
+const (
+	// DefaultStorageRefreshInterval is how often the size on disk of the
+	// organizations is refreshed unless an interval is given.
+	DefaultStorageRefreshInterval = time.Minute
+
+	// queryRetryAfter is how long a client is told to wait before retrying
+	// a query rejected because too many queries of its organization are
+	// running.
+	queryRetryAfter = time.Second
+)
+
+// DiskUsage reports the size on disk of buckets. It is typically the storage
+// engine.
+type DiskUsage interface {
+	BucketDiskSize(ctx context.Context, bucketID platform.ID) (int64, error)
+}
+
+var _ influxdb.OrgUsageService = (*Service)(nil)
+
+// Service tracks the resource usage of organizations and enforces their
+// quotas.
+//
+// The size on disk of each organization's buckets is refreshed periodically
+// once the Service is opened, so the storage quota is enforced with a delay
+// of up to the refresh interval.
+type Service struct {
+	log     *zap.Logger
+	buckets influxdb.BucketService
+	tasks   taskmodel.TaskService
+	disk    DiskUsage
+
+	refreshInterval time.Duration
+	now             func() time.Time
+
+	mu   sync.Mutex
+	orgs map[platform.ID]*orgState
+
+	// storageUpdatedAt is when the size on disk of the organizations was
+	// last refreshed.
+	storageUpdatedAt time.Time
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	rejections *prometheus.CounterVec
+}
+
+// orgState is the quotas and usage of an organization.
+type orgState struct {
+	quotas influxdb.OrgQuotas
+
+	points, bytes rateQuota
+	writes        writeMeter
+	queries       int
+	storageBytes  int64
+}
+
+// NewService returns a Service counting the buckets and tasks of
+// organizations with the given services, and measuring the size on disk of
+// their buckets with disk every refreshInterval, or every
+// DefaultStorageRefreshInterval if it is zero.
+func NewService(log *zap.Logger, buckets influxdb.BucketService, tasks taskmodel.TaskService, disk DiskUsage, refreshInterval time.Duration) *Service {
+	if refreshInterval <= 0 {
+		refreshInterval = DefaultStorageRefreshInterval
+	}
+	return &Service{
+		log:             log,
+		buckets:         buckets,
+		tasks:           tasks,
+		disk:            disk,
+		refreshInterval: refreshInterval,
+		now:             time.Now,
+		orgs:            make(map[platform.ID]*orgState),
+		rejections: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "quota",
+			Name:      "rejections_total",
+			Help:      "Number of operations rejected because they would exceed a quota of their organization",
+		}, []string{"quota"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the Service.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{s.rejections}
+}
+
+// Open refreshes the size on disk of the organizations and starts refreshing
+// it periodically.
+func (s *Service) Open(ctx context.Context) error {
+	if err := s.refreshStorage(ctx); err != nil {
+		return err
+	}
+
+	ctx, s.cancel = context.WithCancel(context.Background())
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.refreshStorage(ctx); err != nil && ctx.Err() == nil {
+					s.log.Warn("Failed to refresh storage usage", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// Close stops refreshing the size on disk of the organizations.
+func (s *Service) Close() error {
+	if s.cancel != nil {
+		s.cancel()
+		s.wg.Wait()
+	}
+	return nil
+}
+
+// SetOrgQuotas sets the quotas of an organization; nil quotas remove them.
+func (s *Service) SetOrgQuotas(orgID platform.ID, quotas *influxdb.OrgQuotas) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if quotas == nil {
+		delete(s.orgs, orgID)
+		return
+	}
+
+	now := s.now()
+	o := s.orgs[orgID]
+	if o == nil {
+		o = &orgState{writes: writeMeter{start: now}}
+		s.orgs[orgID] = o
+	}
+	o.quotas = *quotas
+	o.points.setLimit(now, quotas.MaxWritePointsPerSecond)
+	o.bytes.setLimit(now, quotas.MaxWriteBytesPerSecond)
+}
+
+// AllowWrite checks that writing points points of bytes bytes of line
+// protocol does not exceed the quotas of an organization, and records the
+// write. It returns a too many requests error otherwise.
+func (s *Service) AllowWrite(ctx context.Context, orgID platform.ID, points, bytes int) error {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.orgs[orgID]
+	if o == nil {
+		return nil
+	}
+
+	if max := o.quotas.MaxStorageBytes; max > 0 && o.storageBytes >= max {
+		return s.reject("quota/AllowWrite", QuotaStorageBytes, max, s.refreshInterval)
+	}
+
+	now := s.now()
+	pointsWait, bytesWait := o.points.wait(now), o.bytes.wait(now)
+	if pointsWait > 0 {
+		return s.reject("quota/AllowWrite", QuotaWritePointsPerSecond, o.quotas.MaxWritePointsPerSecond, pointsWait)
+	} else if bytesWait > 0 {
+		return s.reject("quota/AllowWrite", QuotaWriteBytesPerSecond, o.quotas.MaxWriteBytesPerSecond, bytesWait)
+	}
+	o.points.take(int64(points))
+	o.bytes.take(int64(bytes))
+	o.writes.add(now, int64(points), int64(bytes))
+	return nil
+}
+
+// acquireQuery counts a query of an organization as running. It returns a too
+// many requests error if too many of its queries are running already;
+// otherwise the query must be released when it completes.
+func (s *Service) acquireQuery(orgID platform.ID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.orgs[orgID]
+	if o == nil {
+		return nil
+	}
+	if max := o.quotas.MaxConcurrentQueries; max > 0 && o.queries >= max {
+		return s.reject("quota/Query", QuotaConcurrentQueries, int64(max), queryRetryAfter)
+	}
+	o.queries++
+	return nil
+}
+
+func (s *Service) releaseQuery(orgID platform.ID) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// The organization's quotas may have been removed while the query ran.
+	if o := s.orgs[orgID]; o != nil && o.queries > 0 {
+		o.queries--
+	}
+}
+
+// checkCount returns a too many requests error if an organization already has
+// as many resources of a kind, as counted by count, as its quota allows.
+func (s *Service) checkCount(ctx context.Context, op, quota string, orgID platform.ID, count func(context.Context, platform.ID) (int, error)) error {
+	max := s.countQuota(orgID, quota)
+	if max <= 0 {
+		return nil
+	}
+	n, err := count(ctx, orgID)
+	if err != nil {
+		return err
+	}
+	if n >= max {
+		return s.reject(op, quota, int64(max), 0)
+	}
+	return nil
+}
+
+func (s *Service) countQuota(orgID platform.ID, quota string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := s.orgs[orgID]
+	if o == nil {
+		return 0
+	}
+	switch quota {
+	case QuotaBuckets:
+		return o.quotas.MaxBuckets
+	case QuotaTasks:
+		return o.quotas.MaxTasks
+	}
+	return 0
+}
+
+// reject returns the error of an operation exceeding a quota.
+func (s *Service) reject(op, quota string, limit int64, wait time.Duration) error {
+	s.rejections.WithLabelValues(quota).Inc()
+	return influxdb.NewQuotaExceededError(op, quota, limit, wait)
+}
+
+// FindOrgUsage returns the resource usage of an organization.
+func (s *Service) FindOrgUsage(ctx context.Context, orgID platform.ID) (*influxdb.OrgUsage, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	buckets, err := s.countBuckets(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := s.countTasks(ctx, orgID)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &influxdb.OrgUsage{
+		OrgID:   orgID,
+		Tasks:   tasks,
+		Buckets: buckets,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	usage.StorageUpdatedAt = s.storageUpdatedAt
+	if o := s.orgs[orgID]; o != nil {
+		o.writes.roll(s.now())
+		usage.Quotas = o.quotas
+		usage.StorageBytes = o.storageBytes
+		usage.WritePointsPerSecond = o.writes.pointsRate
+		usage.WriteBytesPerSecond = o.writes.bytesRate
+		usage.ConcurrentQueries = o.queries
+	} else {
+		// The size on disk is only tracked for organizations with quotas.
+		size, err := s.orgDiskSize(ctx, orgID)
+		if err != nil {
+			return nil, err
+		}
+		usage.StorageBytes = size
+		usage.StorageUpdatedAt = s.now()
+	}
+	return usage, nil
+}
+
+// refreshStorage updates the size on disk of the buckets of every organization
+// with quotas.
+func (s *Service) refreshStorage(ctx context.Context) error {
+	s.mu.Lock()
+	orgIDs := make([]platform.ID, 0, len(s.orgs))
+	for id := range s.orgs {
+		orgIDs = append(orgIDs, id)
+	}
+	s.mu.Unlock()
+
+	sizes := make(map[platform.ID]int64, len(orgIDs))
+	for _, id := range orgIDs {
+		size, err := s.orgDiskSize(ctx, id)
+		if err != nil {
+			return err
+		}
+		sizes[id] = size
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, size := range sizes {
+		if o := s.orgs[id]; o != nil {
+			o.storageBytes = size
+		}
+	}
+	s.storageUpdatedAt = s.now()
+	return nil
+}
+
+func (s *Service) orgDiskSize(ctx context.Context, orgID platform.ID) (int64, error) {
+	var size int64
+	err := s.forEachBucket(ctx, orgID, func(b *influxdb.Bucket) error {
+		n, err := s.disk.BucketDiskSize(ctx, b.ID)
+		size += n
+		return err
+	})
+	return size, err
+}
+
+func (s *Service) countBuckets(ctx context.Context, orgID platform.ID) (int, error) {
+	var n int
+	err := s.forEachBucket(ctx, orgID, func(*influxdb.Bucket) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+func (s *Service) forEachBucket(ctx context.Context, orgID platform.ID, fn func(*influxdb.Bucket) error) error {
+	for offset := 0; ; {
+		bs, _, err := s.buckets.FindBuckets(ctx, influxdb.BucketFilter{OrganizationID: &orgID}, influxdb.FindOptions{Limit: influxdb.MaxPageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, b := range bs {
+			if err := fn(b); err != nil {
+				return err
+			}
+		}
+		if len(bs) < influxdb.MaxPageSize {
+			return nil
+		}
+		offset += len(bs)
+	}
+}
+
+func (s *Service) countTasks(ctx context.Context, orgID platform.ID) (int, error) {
+	var n int
+	filter := taskmodel.TaskFilter{OrganizationID: &orgID, Limit: taskmodel.TaskMaxPageSize}
+	for {
+		ts, _, err := s.tasks.FindTasks(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		n += len(ts)
+		if len(ts) < taskmodel.TaskMaxPageSize {
+			return n, nil
+		}
+		filter.After = &ts[len(ts)-1].ID
+	}
+}
+
+// rateQuota is a token bucket refilled at a rate of limit tokens per second
+// and holding up to a second's worth of them. A write is admitted while the
+// bucket is not empty and may overdraw it, so that batches larger than a
+// second's worth are admitted and paid off by the writes that follow waiting.
+type rateQuota struct {
+	limit  float64
+	tokens float64
+	last   time.Time
+}
+
+func (q *rateQuota) setLimit(now time.Time, limit int64) {
+	if float64(limit) != q.limit {
+		q.limit, q.tokens, q.last = float64(limit), float64(limit), now
+	}
+}
+
+// wait refills the bucket and returns how long until it is no longer empty.
+func (q *rateQuota) wait(now time.Time) time.Duration {
+	if q.limit <= 0 {
+		return 0
+	}
+	q.tokens = math.Min(q.limit, q.tokens+now.Sub(q.last).Seconds()*q.limit)
+	q.last = now
+	if q.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-q.tokens / q.limit * float64(time.Second))
+}
+
+func (q *rateQuota) take(n int64) {
+	if q.limit > 0 {
+		q.tokens -= float64(n)
+	}
+}
+
+// writeMeter measures the write rates of an organization over periods of at
+// least a minute.
+type writeMeter struct {
+	start                 time.Time
+	points, bytes         int64
+	pointsRate, bytesRate float64
+}
+
+func (m *writeMeter) add(now time.Time, points, bytes int64) {
+	m.roll(now)
+	m.points += points
+	m.bytes += bytes
+}
+
+// roll computes the rates once a minute has passed since they were last
+// computed, and starts measuring anew.
+func (m *writeMeter) roll(now time.Time) {
+	d := now.Sub(m.start)
+	if d < time.Minute {
+		return
+	}
+	m.pointsRate = float64(m.points) / d.Seconds()
+	m.bytesRate = float64(m.bytes) / d.Seconds()
+	m.start, m.points, m.bytes = now, 0, 0
+}
@@ -0,0 +1,210 @@
+package quota
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+const orgID = platform.ID(1)
+
+type diskUsage map[platform.ID]int64
+
+func (d diskUsage) BucketDiskSize(_ context.Context, bucketID platform.ID) (int64, error) {
+	return d[bucketID], nil
+}
+
+// newTestService returns a Service whose organization 1 has buckets 10 and 11,
+// of the sizes in disk, and the given number of tasks.
+func newTestService(t *testing.T, disk diskUsage, tasks int) (*Service, *time.Time) {
+	t.Helper()
+
+	buckets := &mock.BucketService{
+		FindBucketsFn: func(_ context.Context, filter influxdb.BucketFilter, _ ...influxdb.FindOptions) ([]*influxdb.Bucket, int, error) {
+			if *filter.OrganizationID != orgID {
+				return nil, 0, nil
+			}
+			return []*influxdb.Bucket{{ID: 10, OrgID: orgID}, {ID: 11, OrgID: orgID}}, 2, nil
+		},
+	}
+	taskSvc := &mock.TaskService{
+		FindTasksFn: func(_ context.Context, filter taskmodel.TaskFilter) ([]*taskmodel.Task, int, error) {
+			var ts []*taskmodel.Task
+			for i := 0; i < tasks && *filter.OrganizationID == orgID; i++ {
+				ts = append(ts, &taskmodel.Task{ID: platform.ID(i + 1)})
+			}
+			return ts, len(ts), nil
+		},
+	}
+
+	s := NewService(zaptest.NewLogger(t), buckets, taskSvc, disk, time.Minute)
+	now := time.Unix(0, 0)
+	s.now = func() time.Time { return now }
+	return s, &now
+}
+
+func requireQuotaExceeded(t *testing.T, err error, quota string, wait time.Duration) {
+	t.Helper()
+	require.Error(t, err)
+	require.Equal(t, errors.ETooManyRequests, errors.ErrorCode(err))
+	qe, ok := err.(*errors.Error).Err.(*influxdb.QuotaExceededError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, quota, qe.Quota)
+	require.Equal(t, wait, errors.RetryAfter(err))
+}
+
+func TestService_AllowWrite_Rate(t *testing.T) {
+	s, now := newTestService(t, nil, 0)
+	ctx := context.Background()
+
+	// Organizations without quotas are not limited.
+	require.NoError(t, s.AllowWrite(ctx, orgID, 1e6, 1e9))
+
+	s.SetOrgQuotas(orgID, &influxdb.OrgQuotas{MaxWritePointsPerSecond: 100, MaxWriteBytesPerSecond: 10000})
+
+	// A batch larger than a second's worth of points is admitted...
+	require.NoError(t, s.AllowWrite(ctx, orgID, 250, 100))
+	// ...and paid off by the writes that follow.
+	requireQuotaExceeded(t, s.AllowWrite(ctx, orgID, 1, 1), QuotaWritePointsPerSecond, 1500*time.Millisecond)
+
+	*now = now.Add(time.Second)
+	requireQuotaExceeded(t, s.AllowWrite(ctx, orgID, 1, 1), QuotaWritePointsPerSecond, 500*time.Millisecond)
+
+	*now = now.Add(500 * time.Millisecond)
+	require.NoError(t, s.AllowWrite(ctx, orgID, 0, 20000))
+	requireQuotaExceeded(t, s.AllowWrite(ctx, orgID, 0, 1), QuotaWriteBytesPerSecond, time.Second)
+
+	// The rates are reported once a minute has passed.
+	*now = now.Add(time.Minute)
+	usage, err := s.FindOrgUsage(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, 250.0/61.5, usage.WritePointsPerSecond)
+	require.Equal(t, 20100.0/61.5, usage.WriteBytesPerSecond)
+
+	// Removing the quotas removes the limits.
+	s.SetOrgQuotas(orgID, nil)
+	require.NoError(t, s.AllowWrite(ctx, orgID, 1e6, 1e9))
+}
+
+func TestService_AllowWrite_Storage(t *testing.T) {
+	disk := diskUsage{10: 600, 11: 500}
+	s, _ := newTestService(t, disk, 0)
+	ctx := context.Background()
+
+	s.SetOrgQuotas(orgID, &influxdb.OrgQuotas{MaxStorageBytes: 1000})
+	require.NoError(t, s.Open(ctx))
+	defer s.Close()
+
+	requireQuotaExceeded(t, s.AllowWrite(ctx, orgID, 1, 1), QuotaStorageBytes, time.Minute)
+
+	// The size on disk is only measured when it is refreshed.
+	disk[11] = 0
+	requireQuotaExceeded(t, s.AllowWrite(ctx, orgID, 1, 1), QuotaStorageBytes, time.Minute)
+	require.NoError(t, s.refreshStorage(ctx))
+	require.NoError(t, s.AllowWrite(ctx, orgID, 1, 1))
+
+	usage, err := s.FindOrgUsage(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, int64(600), usage.StorageBytes)
+	require.Equal(t, int64(1000), usage.Quotas.MaxStorageBytes)
+}
+
+func TestService_ConcurrentQueries(t *testing.T) {
+	s, _ := newTestService(t, nil, 0)
+	s.SetOrgQuotas(orgID, &influxdb.OrgQuotas{MaxConcurrentQueries: 2})
+
+	require.NoError(t, s.acquireQuery(orgID))
+	require.NoError(t, s.acquireQuery(orgID))
+	requireQuotaExceeded(t, s.acquireQuery(orgID), QuotaConcurrentQueries, queryRetryAfter)
+
+	usage, err := s.FindOrgUsage(context.Background(), orgID)
+	require.NoError(t, err)
+	require.Equal(t, 2, usage.ConcurrentQueries)
+
+	s.releaseQuery(orgID)
+	require.NoError(t, s.acquireQuery(orgID))
+
+	// Other organizations are not limited.
+	require.NoError(t, s.acquireQuery(2))
+}
+
+func TestBucketService_CreateBucket(t *testing.T) {
+	s, _ := newTestService(t, nil, 0)
+	var created int
+	buckets := NewBucketService(&mock.BucketService{
+		CreateBucketFn: func(context.Context, *influxdb.Bucket) error {
+			created++
+			return nil
+		},
+	}, s)
+	ctx := context.Background()
+
+	s.SetOrgQuotas(orgID, &influxdb.OrgQuotas{MaxBuckets: 3})
+	require.NoError(t, buckets.CreateBucket(ctx, &influxdb.Bucket{OrgID: orgID}))
+
+	s.SetOrgQuotas(orgID, &influxdb.OrgQuotas{MaxBuckets: 2})
+	requireQuotaExceeded(t, buckets.CreateBucket(ctx, &influxdb.Bucket{OrgID: orgID}), QuotaBuckets, 0)
+	require.Equal(t, 1, created)
+}
+
+func TestTaskService_CreateTask(t *testing.T) {
+	s, _ := newTestService(t, nil, 5)
+	var created int
+	tasks := NewTaskService(&mock.TaskService{
+		CreateTaskFn: func(context.Context, taskmodel.TaskCreate) (*taskmodel.Task, error) {
+			created++
+			return &taskmodel.Task{}, nil
+		},
+	}, s)
+	ctx := context.Background()
+
+	s.SetOrgQuotas(orgID, &influxdb.OrgQuotas{MaxTasks: 6})
+	_, err := tasks.CreateTask(ctx, taskmodel.TaskCreate{OrganizationID: orgID})
+	require.NoError(t, err)
+
+	s.SetOrgQuotas(orgID, &influxdb.OrgQuotas{MaxTasks: 5})
+	_, err = tasks.CreateTask(ctx, taskmodel.TaskCreate{OrganizationID: orgID})
+	requireQuotaExceeded(t, err, QuotaTasks, 0)
+	require.Equal(t, 1, created)
+
+	usage, err := s.FindOrgUsage(ctx, orgID)
+	require.NoError(t, err)
+	require.Equal(t, 5, usage.Tasks)
+	require.Equal(t, 2, usage.Buckets)
+}
+
+func TestOrganizationService(t *testing.T) {
+	s, _ := newTestService(t, nil, 0)
+	orgs := NewOrganizationService(&mock.OrganizationService{
+		FindOrganizationsF: func(context.Context, influxdb.OrganizationFilter, ...influxdb.FindOptions) ([]*influxdb.Organization, int, error) {
+			return []*influxdb.Organization{{ID: orgID, Quotas: &influxdb.OrgQuotas{MaxConcurrentQueries: 1}}, {ID: 2}}, 2, nil
+		},
+		UpdateOrganizationF: func(_ context.Context, id platform.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+			return &influxdb.Organization{ID: id, Quotas: upd.Quotas}, nil
+		},
+		DeleteOrganizationF: func(context.Context, platform.ID) error { return nil },
+	}, s)
+	ctx := context.Background()
+
+	require.NoError(t, orgs.LoadQuotas(ctx))
+	require.NoError(t, s.acquireQuery(orgID))
+	require.Error(t, s.acquireQuery(orgID))
+
+	_, err := orgs.UpdateOrganization(ctx, orgID, influxdb.OrganizationUpdate{Quotas: &influxdb.OrgQuotas{MaxConcurrentQueries: -1}})
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(err))
+
+	_, err = orgs.UpdateOrganization(ctx, orgID, influxdb.OrganizationUpdate{Quotas: &influxdb.OrgQuotas{MaxConcurrentQueries: 2}})
+	require.NoError(t, err)
+	require.NoError(t, s.acquireQuery(orgID))
+
+	require.NoError(t, orgs.DeleteOrganization(ctx, orgID))
+	require.Empty(t, s.orgs)
+}
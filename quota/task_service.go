@@ -0,0 +1,39 @@
+package quota
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/task/taskmodel"
+)
+
+// TaskService wraps an existing taskmodel.TaskService implementation.
+//
+// TaskService rejects the creation of tasks beyond the task quota of their
+// organization.
+type TaskService struct {
+	taskmodel.TaskService
+	quotas *Service
+}
+
+// NewTaskService returns a new TaskService enforcing quotas.
+func NewTaskService(s taskmodel.TaskService, quotas *Service) *TaskService {
+	return &TaskService{
+		TaskService: s,
+		quotas:      quotas,
+	}
+}
+
+// CreateTask creates a task unless its organization has as many tasks as its
+// quota allows.
+func (s *TaskService) CreateTask(ctx context.Context, tc taskmodel.TaskCreate) (*taskmodel.Task, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if tc.OrganizationID.Valid() {
+		if err := s.quotas.checkCount(ctx, "quota/CreateTask", QuotaTasks, tc.OrganizationID, s.quotas.countTasks); err != nil {
+			return nil, err
+		}
+	}
+	return s.TaskService.CreateTask(ctx, tc)
+}
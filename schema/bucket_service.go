@@ -0,0 +1,42 @@
+package schema
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"go.uber.org/zap"
+)
+
+// BucketService wraps an influxdb.BucketService and removes the measurement
+// schemas of the buckets it deletes.
+type BucketService struct {
+	influxdb.BucketService
+	log     *zap.Logger
+	schemas *Service
+}
+
+// NewBucketService returns a new BucketService deleting the measurement
+// schemas of s.
+func NewBucketService(log *zap.Logger, bucketSvc influxdb.BucketService, s *Service) *BucketService {
+	return &BucketService{
+		BucketService: bucketSvc,
+		log:           log,
+		schemas:       s,
+	}
+}
+
+// DeleteBucket deletes a bucket and its measurement schemas. The cached
+// schema type and schemas of the bucket are dropped even if its schemas
+// cannot be deleted.
+func (s *BucketService) DeleteBucket(ctx context.Context, id platform.ID) error {
+	if err := s.BucketService.DeleteBucket(ctx, id); err != nil {
+		return err
+	}
+	defer s.schemas.invalidate(id)
+	if err := s.schemas.DeleteBucketMeasurementSchemas(ctx, id); err != nil {
+		s.log.Error("Failed to delete measurement schemas for bucket",
+			zap.String("bucket_id", id.String()), zap.Error(err))
+	}
+	return nil
+}
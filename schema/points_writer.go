@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// measurementColumns holds the tag and field columns of a measurement schema.
+type measurementColumns struct {
+	tags   map[string]struct{}
+	fields map[string]influxdb.SchemaColumnDataType
+}
+
+func newMeasurementColumns(cols []influxdb.MeasurementSchemaColumn) *measurementColumns {
+	mc := &measurementColumns{
+		tags:   make(map[string]struct{}),
+		fields: make(map[string]influxdb.SchemaColumnDataType),
+	}
+	for _, c := range cols {
+		switch c.Type {
+		case influxdb.SemanticColumnTypeTag:
+			mc.tags[c.Name] = struct{}{}
+		case influxdb.SemanticColumnTypeField:
+			mc.fields[c.Name] = *c.DataType
+		}
+	}
+	return mc
+}
+
+// validate returns a description of the first difference between p and the
+// schema of its measurement, or an empty string if p matches it.
+func (bs bucketSchemas) validate(p models.Point) string {
+	mc := bs[string(p.Name())]
+	if mc == nil {
+		return fmt.Sprintf("measurement %q has no schema", p.Name())
+	}
+
+	for _, t := range p.Tags() {
+		if _, ok := mc.tags[string(t.Key)]; !ok {
+			return fmt.Sprintf("tag %q is not a tag column of measurement %q", t.Key, p.Name())
+		}
+	}
+
+	iter := p.FieldIterator()
+	for iter.Next() {
+		dt, ok := mc.fields[string(iter.FieldKey())]
+		if !ok {
+			return fmt.Sprintf("field %q is not a field column of measurement %q", iter.FieldKey(), p.Name())
+		}
+		if iter.Type() != dt.ToFieldType() {
+			return fmt.Sprintf("field %q of measurement %q is type %s, the schema requires type %s",
+				iter.FieldKey(), p.Name(), fieldTypeName(iter.Type()), dt.String())
+		}
+	}
+	return ""
+}
+
+// fieldTypeName returns the name of the schema data type of a field type.
+func fieldTypeName(typ models.FieldType) string {
+	switch typ {
+	case models.Float:
+		return "float"
+	case models.Integer:
+		return "integer"
+	case models.Unsigned:
+		return "unsigned"
+	case models.String:
+		return "string"
+	case models.Boolean:
+		return "boolean"
+	default:
+		return "unknown"
+	}
+}
+
+// PointsWriter wraps a storage.PointsWriter and drops the points written to
+// buckets with an explicit schema that do not match the measurement schemas
+// of the bucket. Dropped points are reported by a tsdb.PartialWriteError once
+// the remaining points are written.
+type PointsWriter struct {
+	underlying storage.PointsWriter
+	schemas    *Service
+}
+
+// NewPointsWriter returns a new PointsWriter validating the points written to
+// w with the measurement schemas of s.
+func NewPointsWriter(w storage.PointsWriter, s *Service) *PointsWriter {
+	return &PointsWriter{
+		underlying: w,
+		schemas:    s,
+	}
+}
+
+// WritePoints writes the points matching the schema of the bucket to the
+// underlying PointsWriter.
+func (w *PointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	st, err := w.schemas.schemaType(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if st != influxdb.SchemaTypeExplicit {
+		return w.underlying.WritePoints(ctx, orgID, bucketID, points)
+	}
+
+	bs, err := w.schemas.schemas(ctx, orgID, bucketID)
+	if err != nil {
+		return err
+	}
+
+	var (
+		valid   = points[:0:0]
+		partial tsdb.PartialWriteError
+	)
+	for _, p := range points {
+		reason := bs.validate(p)
+		if reason == "" {
+			valid = append(valid, p)
+			continue
+		}
+		if partial.Dropped == 0 {
+			partial.Reason = "schema violation: " + reason
+		}
+		partial.Dropped++
+		partial.DroppedKeys = append(partial.DroppedKeys, p.Key())
+		partial.Points = append(partial.Points, tsdb.DroppedPoint{Point: p, Code: tsdb.DropSchema, Reason: "schema violation: " + reason})
+	}
+	if partial.Dropped == 0 {
+		return w.underlying.WritePoints(ctx, orgID, bucketID, points)
+	}
+
+	if len(valid) > 0 {
+		err := w.underlying.WritePoints(ctx, orgID, bucketID, valid)
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			partial.Dropped += perr.Dropped
+			partial.DroppedKeys = append(partial.DroppedKeys, perr.DroppedKeys...)
+			partial.Points = append(partial.Points, perr.Points...)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	sort.Slice(partial.DroppedKeys, func(i, j int) bool {
+		return bytes.Compare(partial.DroppedKeys[i], partial.DroppedKeys[j]) < 0
+	})
+	return partial
+}
@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+type recordingPointsWriter struct {
+	points []models.Point
+	err    error
+}
+
+func (w *recordingPointsWriter) WritePoints(_ context.Context, _, _ platform.ID, points []models.Point) error {
+	w.points = append(w.points, points...)
+	return w.err
+}
+
+func parsePoints(t *testing.T, lp string) []models.Point {
+	points, err := models.ParsePointsString(lp)
+	require.NoError(t, err)
+	return points
+}
+
+func pointLines(points []models.Point) []string {
+	var lines []string
+	for _, p := range points {
+		lines = append(lines, p.String())
+	}
+	return lines
+}
+
+// droppedPoints returns the dropped points of err with their drop codes, and
+// err without them.
+func droppedPoints(t *testing.T, err error) ([]string, tsdb.PartialWriteError) {
+	t.Helper()
+	perr, ok := err.(tsdb.PartialWriteError)
+	require.True(t, ok, "expected a partial write error, got %v", err)
+	var dropped []string
+	for _, d := range perr.Points {
+		dropped = append(dropped, string(d.Code)+": "+d.Point.String())
+	}
+	perr.Points = nil
+	return dropped, perr
+}
+
+func TestPointsWriter_WritePoints(t *testing.T) {
+	svc, buckets := newTestService(t)
+	require.NoError(t, svc.CreateMeasurementSchema(ctx, newCPUSchema()))
+
+	var underlying recordingPointsWriter
+	w := NewPointsWriter(&underlying, svc)
+
+	// Implicit buckets accept anything.
+	points := parsePoints(t, "disk,path=/ free=1i 1\ncpu usage=\"high\" 1")
+	require.NoError(t, w.WritePoints(ctx, orgID, implicitBucketID, points))
+	require.Len(t, underlying.points, 2)
+	underlying.points = nil
+
+	points = parsePoints(t, `cpu,host=a usage=1 1
+cpu,host=a usage=1i 2
+cpu,region=west usage=1 3
+cpu,host=b idle=1 4
+mem,host=a used=1 5
+cpu usage=2 6`)
+	dropped, err := droppedPoints(t, w.WritePoints(ctx, orgID, explicitBucketID, points))
+	require.Equal(t, []string{
+		"schema: cpu,host=a usage=1i 2",
+		"schema: cpu,region=west usage=1 3",
+		"schema: cpu,host=b idle=1 4",
+		"schema: mem,host=a used=1 5",
+	}, dropped)
+	require.Equal(t, tsdb.PartialWriteError{
+		Reason:  `schema violation: field "usage" of measurement "cpu" is type integer, the schema requires type float`,
+		Dropped: 4,
+		DroppedKeys: [][]byte{
+			[]byte("cpu,host=a"),
+			[]byte("cpu,host=b"),
+			[]byte("cpu,region=west"),
+			[]byte("mem,host=a"),
+		},
+	}, err)
+	require.Equal(t, []string{"cpu,host=a usage=1 1", "cpu usage=2 6"}, pointLines(underlying.points))
+	underlying.points = nil
+
+	// Dropped points are added to those dropped by the underlying writer.
+	conflict := parsePoints(t, "cpu,host=c usage=1 1")
+	underlying.err = tsdb.PartialWriteError{
+		Reason:      "field type conflict",
+		Dropped:     1,
+		DroppedKeys: [][]byte{[]byte("cpu,host=c")},
+		Points:      tsdb.DropPoints(tsdb.DropFieldTypeConflict, "field type conflict", conflict),
+	}
+	dropped, err = droppedPoints(t, w.WritePoints(ctx, orgID, explicitBucketID, append(conflict, parsePoints(t, "mem used=1 1")...)))
+	require.Equal(t, []string{"schema: mem used=1 1", "field_type_conflict: cpu,host=c usage=1 1"}, dropped)
+	require.Equal(t, tsdb.PartialWriteError{
+		Reason:      `schema violation: measurement "mem" has no schema`,
+		Dropped:     2,
+		DroppedKeys: [][]byte{[]byte("cpu,host=c"), []byte("mem")},
+	}, err)
+	underlying.err = nil
+
+	// Adding columns to a schema applies to the following writes.
+	m := newCPUSchema()
+	m.Name = "mem"
+	m.Columns[2].Name = "used"
+	require.NoError(t, svc.CreateMeasurementSchema(ctx, m))
+	underlying.points = nil
+	require.NoError(t, w.WritePoints(ctx, orgID, explicitBucketID, parsePoints(t, "mem used=1 1")))
+	require.Len(t, underlying.points, 1)
+
+	// A deleted bucket is forgotten, so a bucket recreated with its ID is
+	// checked against its own schema type.
+	require.NoError(t, NewBucketService(zaptest.NewLogger(t), buckets, svc).DeleteBucket(ctx, explicitBucketID))
+	buckets.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		return &influxdb.Bucket{ID: id, OrgID: orgID, SchemaType: influxdb.SchemaTypeImplicit}, nil
+	}
+	underlying.points = nil
+	require.NoError(t, w.WritePoints(ctx, orgID, explicitBucketID, parsePoints(t, "disk free=1i 1")))
+	require.Len(t, underlying.points, 1)
+}
@@ -0,0 +1,367 @@
+// Package schema stores the measurement schemas of buckets with an explicit
+// schema type and enforces them on the points written to those buckets.
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/mattn/go-sqlite3"
+)
+
+var _ influxdb.MeasurementSchemaService = (*Service)(nil)
+
+// Service is a MeasurementSchemaService backed by the sqlite store. It caches
+// the schema types and schemas of the buckets written to so that points can
+// be validated without a query per write.
+type Service struct {
+	store       *sqlite.SqlStore
+	buckets     influxdb.BucketService
+	idGenerator platform.IDGenerator
+	now         func() time.Time
+
+	mu          sync.Mutex
+	cache       map[platform.ID]bucketSchemas
+	schemaTypes map[platform.ID]influxdb.SchemaType
+}
+
+// NewService returns a new Service. buckets is used to check the buckets
+// schemas are created in.
+func NewService(store *sqlite.SqlStore, buckets influxdb.BucketService) *Service {
+	return &Service{
+		store:       store,
+		buckets:     buckets,
+		idGenerator: snowflake.NewIDGenerator(),
+		now:         time.Now,
+		cache:       make(map[platform.ID]bucketSchemas),
+		schemaTypes: make(map[platform.ID]influxdb.SchemaType),
+	}
+}
+
+// row is a measurement schema as stored in the measurement_schemas table.
+type row struct {
+	ID        platform.ID `db:"id"`
+	OrgID     platform.ID `db:"org_id"`
+	BucketID  platform.ID `db:"bucket_id"`
+	Name      string      `db:"name"`
+	Columns   columns     `db:"columns"`
+	CreatedAt time.Time   `db:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at"`
+}
+
+func (r *row) toInfluxDB() *influxdb.MeasurementSchema {
+	return &influxdb.MeasurementSchema{
+		ID:       r.ID,
+		OrgID:    r.OrgID,
+		BucketID: r.BucketID,
+		Name:     r.Name,
+		Columns:  r.Columns,
+		CRUDLog: influxdb.CRUDLog{
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		},
+	}
+}
+
+// columns stores the columns of a measurement schema as a JSON array.
+type columns []influxdb.MeasurementSchemaColumn
+
+// Value implements the database/sql Valuer interface.
+func (c columns) Value() (driver.Value, error) {
+	b, err := json.Marshal([]influxdb.MeasurementSchemaColumn(c))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the database/sql Scanner interface.
+func (c *columns) Scan(value interface{}) error {
+	s, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("measurement schema columns: unexpected type %T", value)
+	}
+	return json.Unmarshal([]byte(s), c)
+}
+
+var selectColumns = []string{"id", "org_id", "bucket_id", "name", "columns", "created_at", "updated_at"}
+
+// FindMeasurementSchemaByID returns a single measurement schema by ID.
+func (s *Service) FindMeasurementSchemaByID(ctx context.Context, id platform.ID) (*influxdb.MeasurementSchema, error) {
+	query, args, err := sq.Select(selectColumns...).
+		From("measurement_schemas").
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var r row
+	if err := s.store.DB.GetContext(ctx, &r, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, influxdb.ErrMeasurementSchemaNotFound
+		}
+		return nil, err
+	}
+	return r.toInfluxDB(), nil
+}
+
+// FindMeasurementSchemas returns the measurement schemas of a bucket matching
+// filter, sorted by name.
+func (s *Service) FindMeasurementSchemas(ctx context.Context, filter influxdb.MeasurementSchemaFilter) ([]*influxdb.MeasurementSchema, error) {
+	q := sq.Select(selectColumns...).
+		From("measurement_schemas").
+		Where(sq.Eq{"org_id": filter.OrgID, "bucket_id": filter.BucketID}).
+		OrderBy("name")
+	if filter.Name != nil {
+		q = q.Where(sq.Eq{"name": *filter.Name})
+	}
+
+	query, args, err := q.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []row
+	if err := s.store.DB.SelectContext(ctx, &rows, query, args...); err != nil {
+		return nil, err
+	}
+
+	schemas := make([]*influxdb.MeasurementSchema, 0, len(rows))
+	for i := range rows {
+		schemas = append(schemas, rows[i].toInfluxDB())
+	}
+	return schemas, nil
+}
+
+// CreateMeasurementSchema creates a new measurement schema in the bucket of m,
+// which must have an explicit schema type, and sets m.ID with the new
+// identifier.
+func (s *Service) CreateMeasurementSchema(ctx context.Context, m *influxdb.MeasurementSchema) error {
+	if err := m.Validate(); err != nil {
+		return &ierrors.Error{
+			Code: ierrors.EInvalid,
+			Msg:  "invalid measurement schema",
+			Err:  err,
+		}
+	}
+
+	b, err := s.buckets.FindBucketByID(ctx, m.BucketID)
+	if err != nil {
+		return err
+	}
+	if b.OrgID != m.OrgID {
+		return &ierrors.Error{
+			Code: ierrors.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+	if b.SchemaType != influxdb.SchemaTypeExplicit {
+		return influxdb.ErrMeasurementSchemaBucketImplicit
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	now := s.now().UTC()
+	r := row{
+		ID:        s.idGenerator.ID(),
+		OrgID:     m.OrgID,
+		BucketID:  m.BucketID,
+		Name:      m.Name,
+		Columns:   m.Columns,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	query, args, err := sq.Insert("measurement_schemas").
+		Columns(selectColumns...).
+		Values(r.ID, r.OrgID, r.BucketID, r.Name, r.Columns, r.CreatedAt, r.UpdatedAt).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		if sqlErr, ok := err.(sqlite3.Error); ok && sqlErr.ExtendedCode == sqlite3.ErrConstraintUnique {
+			return influxdb.ErrMeasurementSchemaExists
+		}
+		return err
+	}
+	s.invalidate(m.BucketID)
+
+	*m = *r.toInfluxDB()
+	return nil
+}
+
+// UpdateMeasurementSchema replaces the columns of a measurement schema with
+// upd.Columns, which may only add columns to it.
+func (s *Service) UpdateMeasurementSchema(ctx context.Context, id platform.ID, upd influxdb.MeasurementSchemaUpdate) (*influxdb.MeasurementSchema, error) {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	m, err := s.FindMeasurementSchemaByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkColumnsKept(m.Columns, upd.Columns); err != nil {
+		return nil, err
+	}
+	m.Columns = upd.Columns
+	if err := m.Validate(); err != nil {
+		return nil, &ierrors.Error{
+			Code: ierrors.EInvalid,
+			Msg:  "invalid measurement schema",
+			Err:  err,
+		}
+	}
+
+	query, args, err := sq.Update("measurement_schemas").
+		SetMap(sq.Eq{
+			"columns":    columns(m.Columns),
+			"updated_at": s.now().UTC(),
+		}).
+		Where(sq.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		return nil, err
+	}
+	s.invalidate(m.BucketID)
+
+	// The types of the columns returned by RETURNING are not known to the
+	// driver, so the timestamps are read back with a query.
+	return s.FindMeasurementSchemaByID(ctx, id)
+}
+
+// DeleteMeasurementSchema removes a measurement schema by ID.
+func (s *Service) DeleteMeasurementSchema(ctx context.Context, id platform.ID) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("measurement_schemas").
+		Where(sq.Eq{"id": id}).
+		Suffix("RETURNING bucket_id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var bucketID platform.ID
+	if err := s.store.DB.GetContext(ctx, &bucketID, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return influxdb.ErrMeasurementSchemaNotFound
+		}
+		return err
+	}
+	s.invalidate(bucketID)
+
+	return nil
+}
+
+// DeleteBucketMeasurementSchemas removes all the measurement schemas of the
+// bucket with the given ID.
+func (s *Service) DeleteBucketMeasurementSchemas(ctx context.Context, bucketID platform.ID) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("measurement_schemas").
+		Where(sq.Eq{"bucket_id": bucketID}).
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	s.invalidate(bucketID)
+
+	return nil
+}
+
+// checkColumnsKept returns an error unless every column of old is in cols,
+// unchanged.
+func checkColumnsKept(old, cols []influxdb.MeasurementSchemaColumn) error {
+	byName := make(map[string]influxdb.MeasurementSchemaColumn, len(cols))
+	for _, c := range cols {
+		byName[c.Name] = c
+	}
+	for _, o := range old {
+		c, ok := byName[o.Name]
+		if !ok || c.Type != o.Type || c.DataType.String() != o.DataType.String() {
+			return influxdb.ErrMeasurementSchemaColumnsChanged
+		}
+	}
+	return nil
+}
+
+// bucketSchemas holds the schemas of the measurements of a bucket by name.
+type bucketSchemas map[string]*measurementColumns
+
+// schemas returns the cached schemas of a bucket, reading them on a miss.
+// Loading while holding the lock keeps a concurrent change from being
+// overwritten by the schemas read before it.
+func (s *Service) schemas(ctx context.Context, orgID, bucketID platform.ID) (bucketSchemas, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if bs, ok := s.cache[bucketID]; ok {
+		return bs, nil
+	}
+
+	ms, err := s.FindMeasurementSchemas(ctx, influxdb.MeasurementSchemaFilter{OrgID: orgID, BucketID: bucketID})
+	if err != nil {
+		return nil, err
+	}
+	bs := make(bucketSchemas, len(ms))
+	for _, m := range ms {
+		bs[m.Name] = newMeasurementColumns(m.Columns)
+	}
+	s.cache[bucketID] = bs
+	return bs, nil
+}
+
+// schemaType returns the cached schema type of a bucket, reading it on a
+// miss.
+func (s *Service) schemaType(ctx context.Context, bucketID platform.ID) (influxdb.SchemaType, error) {
+	s.mu.Lock()
+	st, ok := s.schemaTypes[bucketID]
+	s.mu.Unlock()
+	if ok {
+		return st, nil
+	}
+
+	b, err := s.buckets.FindBucketByID(ctx, bucketID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	s.schemaTypes[bucketID] = b.SchemaType
+	s.mu.Unlock()
+	return b.SchemaType, nil
+}
+
+// invalidate drops the cached schema type and schemas of a bucket, which are
+// read again on its next write.
+func (s *Service) invalidate(bucketID platform.ID) {
+	s.mu.Lock()
+	delete(s.cache, bucketID)
+	delete(s.schemaTypes, bucketID)
+	s.mu.Unlock()
+}
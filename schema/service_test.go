@@ -0,0 +1,134 @@
+package schema
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx = context.Background()
+
+	orgID            = platform.ID(10)
+	explicitBucketID = platform.ID(100)
+	implicitBucketID = platform.ID(200)
+)
+
+func column(name string, typ influxdb.SemanticColumnType, dt *influxdb.SchemaColumnDataType) influxdb.MeasurementSchemaColumn {
+	return influxdb.MeasurementSchemaColumn{Name: name, Type: typ, DataType: dt}
+}
+
+func newCPUSchema() *influxdb.MeasurementSchema {
+	return &influxdb.MeasurementSchema{
+		OrgID:    orgID,
+		BucketID: explicitBucketID,
+		Name:     "cpu",
+		Columns: []influxdb.MeasurementSchemaColumn{
+			column("time", influxdb.SemanticColumnTypeTimestamp, nil),
+			column("host", influxdb.SemanticColumnTypeTag, nil),
+			column("usage", influxdb.SemanticColumnTypeField, influxdb.SchemaColumnDataTypeFloat.Ptr()),
+		},
+	}
+}
+
+func TestService_CRUD(t *testing.T) {
+	svc, buckets := newTestService(t)
+
+	m := newCPUSchema()
+	require.NoError(t, svc.CreateMeasurementSchema(ctx, m))
+	require.True(t, m.ID.Valid())
+	require.False(t, m.CreatedAt.IsZero())
+
+	got, err := svc.FindMeasurementSchemaByID(ctx, m.ID)
+	require.NoError(t, err)
+	require.Equal(t, m, got)
+
+	err = svc.CreateMeasurementSchema(ctx, newCPUSchema())
+	require.Equal(t, errors.EConflict, errors.ErrorCode(err))
+
+	implicit := newCPUSchema()
+	implicit.BucketID = implicitBucketID
+	require.Equal(t, influxdb.ErrMeasurementSchemaBucketImplicit, svc.CreateMeasurementSchema(ctx, implicit))
+
+	invalid := newCPUSchema()
+	invalid.Columns = invalid.Columns[:2]
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.CreateMeasurementSchema(ctx, invalid)))
+
+	otherOrg := newCPUSchema()
+	otherOrg.OrgID = platform.ID(11)
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(svc.CreateMeasurementSchema(ctx, otherOrg)))
+
+	mem := newCPUSchema()
+	mem.Name = "mem"
+	require.NoError(t, svc.CreateMeasurementSchema(ctx, mem))
+
+	list, err := svc.FindMeasurementSchemas(ctx, influxdb.MeasurementSchemaFilter{OrgID: orgID, BucketID: explicitBucketID})
+	require.NoError(t, err)
+	require.Equal(t, []*influxdb.MeasurementSchema{m, mem}, list)
+
+	name := "mem"
+	list, err = svc.FindMeasurementSchemas(ctx, influxdb.MeasurementSchemaFilter{OrgID: orgID, BucketID: explicitBucketID, Name: &name})
+	require.NoError(t, err)
+	require.Equal(t, []*influxdb.MeasurementSchema{mem}, list)
+
+	// Columns may be added, but not changed or removed.
+	cols := append(m.Columns, column("idle", influxdb.SemanticColumnTypeField, influxdb.SchemaColumnDataTypeInteger.Ptr()))
+	updated, err := svc.UpdateMeasurementSchema(ctx, m.ID, influxdb.MeasurementSchemaUpdate{Columns: cols})
+	require.NoError(t, err)
+	require.Equal(t, cols, updated.Columns)
+
+	_, err = svc.UpdateMeasurementSchema(ctx, m.ID, influxdb.MeasurementSchemaUpdate{Columns: m.Columns[:2]})
+	require.Equal(t, influxdb.ErrMeasurementSchemaColumnsChanged, err)
+
+	changed := []influxdb.MeasurementSchemaColumn{
+		cols[0], cols[1],
+		column("usage", influxdb.SemanticColumnTypeField, influxdb.SchemaColumnDataTypeInteger.Ptr()),
+		cols[3],
+	}
+	_, err = svc.UpdateMeasurementSchema(ctx, m.ID, influxdb.MeasurementSchemaUpdate{Columns: changed})
+	require.Equal(t, influxdb.ErrMeasurementSchemaColumnsChanged, err)
+
+	require.NoError(t, svc.DeleteMeasurementSchema(ctx, m.ID))
+	_, err = svc.FindMeasurementSchemaByID(ctx, m.ID)
+	require.Equal(t, influxdb.ErrMeasurementSchemaNotFound, err)
+	require.Equal(t, influxdb.ErrMeasurementSchemaNotFound, svc.DeleteMeasurementSchema(ctx, m.ID))
+
+	// Deleting a bucket deletes its schemas.
+	bs := NewBucketService(zaptest.NewLogger(t), buckets, svc)
+	require.NoError(t, bs.DeleteBucket(ctx, explicitBucketID))
+	list, err = svc.FindMeasurementSchemas(ctx, influxdb.MeasurementSchemaFilter{OrgID: orgID, BucketID: explicitBucketID})
+	require.NoError(t, err)
+	require.Empty(t, list)
+}
+
+func newTestService(t *testing.T) (*Service, *mock.BucketService) {
+	store, clean := sqlite.NewTestStore(t)
+	t.Cleanup(func() { clean(t) })
+	require.NoError(t, sqlite.NewMigrator(store, zaptest.NewLogger(t)).Up(ctx, migrations.AllUp))
+
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		b := &influxdb.Bucket{ID: id, OrgID: orgID}
+		switch id {
+		case explicitBucketID:
+			b.SchemaType = influxdb.SchemaTypeExplicit
+		case implicitBucketID:
+		default:
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		}
+		return b, nil
+	}
+	buckets.DeleteBucketFn = func(context.Context, platform.ID) error { return nil }
+
+	svc := NewService(store, buckets)
+	svc.idGenerator = mock.NewIncrementingIDGenerator(platform.ID(1))
+	return svc, buckets
+}
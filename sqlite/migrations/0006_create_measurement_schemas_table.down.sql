@@ -0,0 +1 @@
+DROP TABLE measurement_schemas;
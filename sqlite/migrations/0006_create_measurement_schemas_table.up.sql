@@ -0,0 +1,12 @@
+CREATE TABLE measurement_schemas
+(
+    id         VARCHAR(16) NOT NULL PRIMARY KEY,
+    org_id     VARCHAR(16) NOT NULL,
+    bucket_id  VARCHAR(16) NOT NULL,
+    name       TEXT        NOT NULL,
+    columns    TEXT        NOT NULL,
+    created_at TIMESTAMP   NOT NULL,
+    updated_at TIMESTAMP   NOT NULL,
+
+    CONSTRAINT measurement_schemas_uniq_bucketid_name UNIQUE (bucket_id, name)
+);
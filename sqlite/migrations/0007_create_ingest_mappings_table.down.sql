@@ -0,0 +1 @@
+DROP TABLE ingest_mappings;
@@ -0,0 +1,12 @@
+CREATE TABLE ingest_mappings
+(
+    id          VARCHAR(16) NOT NULL PRIMARY KEY,
+    org_id      VARCHAR(16) NOT NULL,
+    name        TEXT        NOT NULL,
+    description TEXT        NOT NULL,
+    spec        TEXT        NOT NULL,
+    created_at  TIMESTAMP   NOT NULL,
+    updated_at  TIMESTAMP   NOT NULL,
+
+    CONSTRAINT ingest_mappings_uniq_orgid_name UNIQUE (org_id, name)
+);
@@ -0,0 +1 @@
+DROP TABLE bucket_transforms;
@@ -0,0 +1,8 @@
+CREATE TABLE bucket_transforms
+(
+    bucket_id  VARCHAR(16) NOT NULL PRIMARY KEY,
+    org_id     VARCHAR(16) NOT NULL,
+    rules      TEXT        NOT NULL,
+    created_at TIMESTAMP   NOT NULL,
+    updated_at TIMESTAMP   NOT NULL
+);
@@ -0,0 +1 @@
+DROP TABLE write_idempotency_keys;
@@ -0,0 +1,14 @@
+-- status_code is NULL while the write a key was reserved for is in progress.
+CREATE TABLE write_idempotency_keys
+(
+    org_id      VARCHAR(16) NOT NULL,
+    key         TEXT        NOT NULL,
+    status_code INTEGER,
+    header      TEXT,
+    body        BLOB,
+    created_at  TIMESTAMP   NOT NULL,
+
+    PRIMARY KEY (org_id, key)
+);
+
+CREATE INDEX idx_write_idempotency_keys_created_at ON write_idempotency_keys (created_at);
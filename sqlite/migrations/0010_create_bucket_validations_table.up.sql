@@ -0,0 +1,9 @@
+CREATE TABLE bucket_validations
+(
+    bucket_id            VARCHAR(16) NOT NULL PRIMARY KEY,
+    org_id               VARCHAR(16) NOT NULL,
+    quarantine_bucket_id VARCHAR(16),
+    rules                TEXT        NOT NULL,
+    created_at           TIMESTAMP   NOT NULL,
+    updated_at           TIMESTAMP   NOT NULL
+);
@@ -5,6 +5,7 @@ import (
 
 	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/kit/tracing"
 	"github.com/influxdata/influxdb/v2/v1/services/meta"
 	"go.uber.org/zap"
@@ -56,6 +57,17 @@ func (s *BucketService) CreateBucket(ctx context.Context, b *influxdb.Bucket) (e
 	// Normalize the bucket's shard-group
 	b.ShardGroupDuration = meta.NormalisedShardDuration(b.ShardGroupDuration, b.RetentionPeriod)
 
+	if b.LatePointsAction == influxdb.BucketLatePointsRedirect {
+		if err := s.validateLatePointsBucket(ctx, b.OrgID, platform.InvalidID(), b.LatePointsBucketID); err != nil {
+			return err
+		}
+	}
+	if b.DeadLetterBucketID.Valid() {
+		if err := s.validateDeadLetterBucket(ctx, b.OrgID, platform.InvalidID(), b.DeadLetterBucketID); err != nil {
+			return err
+		}
+	}
+
 	if err = s.BucketService.CreateBucket(ctx, b); err != nil {
 		return err
 	}
@@ -71,6 +83,34 @@ func (s *BucketService) UpdateBucket(ctx context.Context, id platform.ID, upd in
 	span, ctx := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	if upd.LatePointsAction != nil || upd.LatePointsBucketID != nil {
+		current, err := s.FindBucketByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		action, target := current.LatePointsAction, current.LatePointsBucketID
+		if upd.LatePointsAction != nil {
+			action = *upd.LatePointsAction
+		}
+		if upd.LatePointsBucketID != nil {
+			target = *upd.LatePointsBucketID
+		}
+		if action == influxdb.BucketLatePointsRedirect {
+			if err := s.validateLatePointsBucket(ctx, current.OrgID, id, target); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if upd.DeadLetterBucketID != nil && upd.DeadLetterBucketID.Valid() {
+		current, err := s.FindBucketByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.validateDeadLetterBucket(ctx, current.OrgID, id, *upd.DeadLetterBucketID); err != nil {
+			return nil, err
+		}
+	}
+
 	if err = s.engine.UpdateBucketRetentionPolicy(ctx, id, &upd); err != nil {
 		return nil, err
 	}
@@ -78,6 +118,54 @@ func (s *BucketService) UpdateBucket(ctx context.Context, id platform.ID, upd in
 	return s.BucketService.UpdateBucket(ctx, id, upd)
 }
 
+// validateLatePointsBucket ensures the bucket that late points are redirected
+// to exists, is not the redirecting bucket itself, and belongs to the same
+// organization.
+func (s *BucketService) validateLatePointsBucket(ctx context.Context, orgID, bucketID, target platform.ID) error {
+	if !target.Valid() || target == bucketID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "redirecting late points requires a different bucket to redirect them to",
+		}
+	}
+
+	tb, err := s.FindBucketByID(ctx, target)
+	if err != nil {
+		return err
+	}
+	if tb.OrgID != orgID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "late points can only be redirected to a bucket in the same organization",
+		}
+	}
+	return nil
+}
+
+// validateDeadLetterBucket ensures the bucket that rejected lines are recorded
+// in exists, is not the bucket they are rejected from, and belongs to the
+// same organization.
+func (s *BucketService) validateDeadLetterBucket(ctx context.Context, orgID, bucketID, target platform.ID) error {
+	if target == bucketID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "a bucket cannot be its own dead-letter bucket",
+		}
+	}
+
+	tb, err := s.FindBucketByID(ctx, target)
+	if err != nil {
+		return err
+	}
+	if tb.OrgID != orgID {
+		return &errors.Error{
+			Code: errors.EInvalid,
+			Msg:  "rejected lines can only be recorded in a bucket in the same organization",
+		}
+	}
+	return nil
+}
+
 // DeleteBucket removes a bucket by ID.
 func (s *BucketService) DeleteBucket(ctx context.Context, bucketID platform.ID) error {
 	span, ctx := tracing.StartSpanFromContext(ctx)
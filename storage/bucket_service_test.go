@@ -132,6 +132,116 @@ func TestBucketService_DeleteNonexistentBucket(t *testing.T) {
 	require.Error(t, service.DeleteBucket(ctx, *i))
 }
 
+func TestBucketService_LatePointsRedirect(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := mocks.NewMockEngineSchema(ctrl)
+	logger := zaptest.NewLogger(t)
+	inmemService := newTenantService(t, logger)
+	service := storage.NewBucketService(logger, inmemService, engine)
+	ctx := context.Background()
+
+	org := &influxdb.Organization{Name: "org1"}
+	require.NoError(t, inmemService.CreateOrganization(ctx, org))
+	other := &influxdb.Organization{Name: "org2"}
+	require.NoError(t, inmemService.CreateOrganization(ctx, other))
+
+	late := &influxdb.Bucket{OrgID: org.ID, Name: "late"}
+	require.NoError(t, inmemService.CreateBucket(ctx, late))
+	foreign := &influxdb.Bucket{OrgID: other.ID, Name: "foreign"}
+	require.NoError(t, inmemService.CreateBucket(ctx, foreign))
+
+	// A redirect without a target bucket is refused before reaching the engine.
+	require.Error(t, service.CreateBucket(ctx, &influxdb.Bucket{
+		OrgID:            org.ID,
+		Name:             "no-target",
+		LatenessWindow:   time.Hour,
+		LatePointsAction: influxdb.BucketLatePointsRedirect,
+	}))
+
+	bucket := &influxdb.Bucket{
+		OrgID:              org.ID,
+		Name:               "data",
+		LatenessWindow:     time.Hour,
+		LatePointsAction:   influxdb.BucketLatePointsRedirect,
+		LatePointsBucketID: late.ID,
+	}
+	engine.EXPECT().CreateBucket(gomock.Any(), bucket)
+	require.NoError(t, service.CreateBucket(ctx, bucket))
+
+	// Redirecting to a bucket in another organization or to itself is refused.
+	require.Error(t, func() error {
+		_, err := service.UpdateBucket(ctx, bucket.ID, influxdb.BucketUpdate{LatePointsBucketID: &foreign.ID})
+		return err
+	}())
+	require.Error(t, func() error {
+		_, err := service.UpdateBucket(ctx, bucket.ID, influxdb.BucketUpdate{LatePointsBucketID: &bucket.ID})
+		return err
+	}())
+
+	reject := influxdb.BucketLatePointsReject
+	upd := influxdb.BucketUpdate{LatePointsAction: &reject}
+	engine.EXPECT().UpdateBucketRetentionPolicy(gomock.Any(), bucket.ID, &upd)
+	updated, err := service.UpdateBucket(ctx, bucket.ID, upd)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.BucketLatePointsReject, updated.LatePointsAction)
+}
+
+func TestBucketService_DeadLetterBucket(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	engine := mocks.NewMockEngineSchema(ctrl)
+	logger := zaptest.NewLogger(t)
+	inmemService := newTenantService(t, logger)
+	service := storage.NewBucketService(logger, inmemService, engine)
+	ctx := context.Background()
+
+	org := &influxdb.Organization{Name: "org1"}
+	require.NoError(t, inmemService.CreateOrganization(ctx, org))
+	other := &influxdb.Organization{Name: "org2"}
+	require.NoError(t, inmemService.CreateOrganization(ctx, other))
+
+	rejected := &influxdb.Bucket{OrgID: org.ID, Name: "rejected"}
+	require.NoError(t, inmemService.CreateBucket(ctx, rejected))
+	foreign := &influxdb.Bucket{OrgID: other.ID, Name: "foreign"}
+	require.NoError(t, inmemService.CreateBucket(ctx, foreign))
+
+	// A dead-letter bucket in another organization is refused before
+	// reaching the engine.
+	require.Error(t, service.CreateBucket(ctx, &influxdb.Bucket{
+		OrgID:              org.ID,
+		Name:               "foreign-dead-letters",
+		DeadLetterBucketID: foreign.ID,
+	}))
+
+	bucket := &influxdb.Bucket{
+		OrgID:              org.ID,
+		Name:               "data",
+		DeadLetterBucketID: rejected.ID,
+	}
+	engine.EXPECT().CreateBucket(gomock.Any(), bucket)
+	require.NoError(t, service.CreateBucket(ctx, bucket))
+
+	require.Error(t, func() error {
+		_, err := service.UpdateBucket(ctx, bucket.ID, influxdb.BucketUpdate{DeadLetterBucketID: &foreign.ID})
+		return err
+	}())
+	require.Error(t, func() error {
+		_, err := service.UpdateBucket(ctx, bucket.ID, influxdb.BucketUpdate{DeadLetterBucketID: &bucket.ID})
+		return err
+	}())
+
+	// Updating the bucket to an invalid ID stops recording its rejected lines.
+	none := platform.InvalidID()
+	upd := influxdb.BucketUpdate{DeadLetterBucketID: &none}
+	engine.EXPECT().UpdateBucketRetentionPolicy(gomock.Any(), bucket.ID, &upd)
+	updated, err := service.UpdateBucket(ctx, bucket.ID, upd)
+	require.NoError(t, err)
+	require.False(t, updated.DeadLetterBucketID.Valid())
+}
+
 func newTenantService(t *testing.T, logger *zap.Logger) *tenant.Service {
 	t.Helper()
 
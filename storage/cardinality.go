@@ -0,0 +1,203 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/v1/services/meta"
+)
+
+// cardinalityState tracks what the engine knows of the organizations owning
+// its buckets, so their limits can be applied to the buckets' series.
+type cardinalityState struct {
+	mu         sync.RWMutex
+	bucketOrgs map[platform.ID]platform.ID
+	orgLimits  map[platform.ID]influxdb.CardinalityLimits
+}
+
+// SetBucketOrganization records the organization owning a bucket, so the
+// organization's cardinality limits apply to it.
+func (e *Engine) SetBucketOrganization(bucketID, orgID platform.ID) {
+	e.cardinality.mu.Lock()
+	e.cardinality.bucketOrgs[bucketID] = orgID
+	e.cardinality.mu.Unlock()
+
+	e.applyCardinalityLimits(bucketID)
+}
+
+// SetOrganizationCardinalityLimits sets the cardinality limits of an
+// organization and applies them to its buckets. Nil limits remove them.
+func (e *Engine) SetOrganizationCardinalityLimits(orgID platform.ID, limits *influxdb.CardinalityLimits) {
+	e.cardinality.mu.Lock()
+	if limits != nil {
+		e.cardinality.orgLimits[orgID] = *limits
+	} else {
+		delete(e.cardinality.orgLimits, orgID)
+	}
+	var buckets []platform.ID
+	for bucketID, id := range e.cardinality.bucketOrgs {
+		if id == orgID {
+			buckets = append(buckets, bucketID)
+		}
+	}
+	e.cardinality.mu.Unlock()
+
+	for _, bucketID := range buckets {
+		e.applyCardinalityLimits(bucketID)
+	}
+}
+
+// registerBucketOrganization records the organization of a bucket being
+// written to if it is not known yet.
+func (e *Engine) registerBucketOrganization(bucketID, orgID platform.ID) {
+	if !orgID.Valid() {
+		return
+	}
+
+	e.cardinality.mu.RLock()
+	known, ok := e.cardinality.bucketOrgs[bucketID]
+	e.cardinality.mu.RUnlock()
+	if !ok || known != orgID {
+		e.SetBucketOrganization(bucketID, orgID)
+	}
+}
+
+// applyCardinalityLimits resolves the cardinality limits of a bucket from its
+// own, its organization's and the server's, and passes them to the store.
+func (e *Engine) applyCardinalityLimits(bucketID platform.ID) {
+	rpi, err := e.metaClient.RetentionPolicy(bucketID.String(), meta.DefaultRetentionPolicyName)
+	if err != nil || rpi == nil {
+		return
+	}
+
+	e.cardinality.mu.RLock()
+	orgID, hasOrg := e.cardinality.bucketOrgs[bucketID]
+	org := e.cardinality.orgLimits[orgID]
+	e.cardinality.mu.RUnlock()
+
+	c := e.config.Data
+	l := tsdb.CardinalityLimits{
+		MaxSeries:               cardinalityLimit(rpi.MaxSeries, int64(c.MaxSeriesPerBucket)),
+		MaxSeriesPerMeasurement: cardinalityLimit(rpi.MaxSeriesPerMeasurement, org.MaxSeriesPerMeasurement, int64(c.MaxSeriesPerMeasurement)),
+		MaxValuesPerTag:         cardinalityLimit(rpi.MaxValuesPerTag, org.MaxValuesPerTag, int64(c.MaxValuesPerTag)),
+	}
+	if hasOrg {
+		l.Org = orgID.String()
+		l.MaxOrgSeries = cardinalityLimit(org.MaxSeries, int64(c.MaxSeriesPerOrg))
+	}
+	e.tsdbStore.SetCardinalityLimits(bucketID.String(), l)
+}
+
+// cardinalityLimit returns the first limit that is set. Negative limits
+// disable the limit and are returned as zero.
+func cardinalityLimit(limits ...int64) int64 {
+	for _, l := range limits {
+		if l < 0 {
+			return 0
+		} else if l > 0 {
+			return l
+		}
+	}
+	return 0
+}
+
+// BucketCardinality returns the cardinality report of a bucket.
+func (e *Engine) BucketCardinality(ctx context.Context, orgID, bucketID platform.ID, opts influxdb.CardinalityOptions) (*influxdb.BucketCardinality, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if opts.Limit <= 0 {
+		opts.Limit = influxdb.DefaultCardinalityLimit
+	}
+	if opts.ShardGroups <= 0 {
+		opts.ShardGroups = influxdb.DefaultCardinalityShardGroups
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	dbi := e.metaClient.Database(bucketID.String())
+	if dbi == nil {
+		return nil, &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+
+	var groups meta.ShardGroupInfos
+	for _, rp := range dbi.RetentionPolicies {
+		for _, sg := range rp.ShardGroups {
+			if !sg.Deleted() {
+				groups = append(groups, sg)
+			}
+		}
+	}
+	sort.Sort(groups)
+
+	var shardIDs []uint64
+	for _, sh := range e.tsdbStore.ShardsByDatabase(bucketID.String()) {
+		shardIDs = append(shardIDs, sh.ID())
+	}
+
+	seriesN, measurements, err := e.tsdbStore.CardinalityReport(ctx, shardIDs, opts.Limit, opts.Exact)
+	if err != nil {
+		return nil, err
+	}
+
+	// The growth of the most recent shard groups is measured against the
+	// series of every earlier group.
+	n := len(groups) - opts.ShardGroups
+	if n < 0 {
+		n = 0
+	}
+	var earlier []uint64
+	for _, sg := range groups[:n] {
+		earlier = append(earlier, shardGroupShardIDs(sg)...)
+	}
+	recent := make([][]uint64, 0, len(groups)-n)
+	for _, sg := range groups[n:] {
+		recent = append(recent, shardGroupShardIDs(sg))
+	}
+	growth, err := e.tsdbStore.SeriesGrowthReport(ctx, earlier, recent)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &influxdb.BucketCardinality{
+		BucketID:     bucketID,
+		Exact:        opts.Exact,
+		SeriesN:      seriesN,
+		Measurements: measurements,
+		Growth:       make([]influxdb.ShardGroupCardinality, 0, len(growth)),
+	}
+	if report.Measurements == nil {
+		report.Measurements = []influxdb.MeasurementCardinality{}
+	}
+	for i, sg := range groups[n:] {
+		report.Growth = append(report.Growth, influxdb.ShardGroupCardinality{
+			ShardGroupID: sg.ID,
+			StartTime:    sg.StartTime,
+			EndTime:      sg.EndTime,
+			SeriesN:      growth[i].SeriesN,
+			NewSeriesN:   growth[i].NewSeriesN,
+		})
+	}
+	return report, nil
+}
+
+func shardGroupShardIDs(sg meta.ShardGroupInfo) []uint64 {
+	ids := make([]uint64, 0, len(sg.Shards))
+	for _, sh := range sg.Shards {
+		ids = append(ids, sh.ID)
+	}
+	return ids
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -56,6 +57,8 @@ type Engine struct {
 
 	writePointsValidationEnabled bool
 
+	cardinality cardinalityState
+
 	logger          *zap.Logger
 	metricsDisabled bool
 }
@@ -121,6 +124,11 @@ func NewEngine(path string, c Config, options ...Option) *Engine {
 		logger:    zap.NewNop(),
 
 		writePointsValidationEnabled: true,
+
+		cardinality: cardinalityState{
+			bucketOrgs: make(map[platform.ID]platform.ID),
+			orgLimits:  make(map[platform.ID]influxdb.CardinalityLimits),
+		},
 	}
 
 	for _, opt := range options {
@@ -175,6 +183,7 @@ func (e *Engine) PrometheusCollectors() []prometheus.Collector {
 	metrics = append(metrics, coordinator.PrometheusCollectors()...)
 	metrics = append(metrics, tsdb.ShardCollectors()...)
 	metrics = append(metrics, tsdb.BucketCollectors()...)
+	metrics = append(metrics, tsdb.SeriesGCCollectors()...)
 	metrics = append(metrics, retention.PrometheusCollectors()...)
 	return metrics
 }
@@ -192,6 +201,19 @@ func (e *Engine) Open(ctx context.Context) (err error) {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
 
+	// WAL policies must be known before shards are loaded so writes replayed
+	// or received during startup are handled with the bucket's durability.
+	// The same goes for cardinality limits. The limits of the buckets'
+	// organizations are applied as they become known.
+	for _, di := range e.metaClient.Databases() {
+		if rpi := di.RetentionPolicy(meta.DefaultRetentionPolicyName); rpi != nil {
+			e.tsdbStore.SetWALPolicy(di.Name, walPolicy(rpi))
+		}
+		if bucketID, err := platform.IDFromString(di.Name); err == nil {
+			e.applyCardinalityLimits(*bucketID)
+		}
+	}
+
 	if err := e.tsdbStore.Open(ctx); err != nil {
 		return err
 	}
@@ -268,16 +290,57 @@ func (e *Engine) WritePoints(ctx context.Context, orgID platform.ID, bucketID pl
 
 	//TODO - remember to add back unicode validation...
 
-	e.mu.RLock()
-	defer e.mu.RUnlock()
+	// The points a bucket redirects to its late points bucket are written
+	// through the engine again while it is held for the write they come
+	// from, which must not wait for a pending Close.
+	if ctx.Value(engineWriteKey{}) != e {
+		e.mu.RLock()
+		defer e.mu.RUnlock()
+		ctx = context.WithValue(ctx, engineWriteKey{}, e)
+	}
 
 	if e.closing == nil {
 		return ErrEngineClosed
 	}
 
+	e.registerBucketOrganization(bucketID, orgID)
+
 	return e.pointsWriter.WritePoints(ctx, bucketID.String(), meta.DefaultRetentionPolicyName, models.ConsistencyLevelAll, &meta.UserInfo{}, points)
 }
 
+// engineWriteKey marks the context of the writes made while the engine is
+// held for a write.
+type engineWriteKey struct{}
+
+// SetLatePointsWriter sets the writer of the points a bucket redirects to its
+// late points bucket. It should be the whole write path of the server, so
+// that the transform, validation and schema of the late points bucket apply
+// to them as to the points written to it. Without it, the points are written
+// straight to the late points bucket. It must be called before writes.
+func (e *Engine) SetLatePointsWriter(w PointsWriter) {
+	pw, ok := e.pointsWriter.(*coordinator.PointsWriter)
+	if !ok {
+		return
+	}
+	pw.RedirectPoints = func(ctx context.Context, source, target string, points []models.Point) error {
+		sourceID, err := platform.IDFromString(source)
+		if err != nil {
+			return err
+		}
+		targetID, err := platform.IDFromString(target)
+		if err != nil {
+			return err
+		}
+
+		// Late points are only redirected within the organization of the
+		// bucket, which is known from its writes.
+		e.cardinality.mu.RLock()
+		orgID := e.cardinality.bucketOrgs[*sourceID]
+		e.cardinality.mu.RUnlock()
+		return w.WritePoints(ctx, orgID, *targetID, points)
+	}
+}
+
 func (e *Engine) CreateBucket(ctx context.Context, b *influxdb.Bucket) (err error) {
 	span, _ := tracing.StartSpanFromContext(ctx)
 	defer span.Finish()
@@ -286,12 +349,31 @@ func (e *Engine) CreateBucket(ctx context.Context, b *influxdb.Bucket) (err erro
 		Name:               meta.DefaultRetentionPolicyName,
 		Duration:           &b.RetentionPeriod,
 		ShardGroupDuration: b.ShardGroupDuration,
+		WALMode:            string(b.WALMode),
+		WALFsyncDelay:      b.WALFsyncDelay,
+		LatenessWindow:     b.LatenessWindow,
+		FutureSkewLimit:    b.FutureSkewLimit,
+		LatePointsAction:   string(b.LatePointsAction),
+	}
+	if b.LatePointsBucketID.Valid() {
+		spec.LatePointsDatabase = b.LatePointsBucketID.String()
+	}
+	if l := b.CardinalityLimits; l != nil {
+		spec.MaxSeries = l.MaxSeries
+		spec.MaxSeriesPerMeasurement = l.MaxSeriesPerMeasurement
+		spec.MaxValuesPerTag = l.MaxValuesPerTag
 	}
 
 	if _, err = e.metaClient.CreateDatabaseWithRetentionPolicy(b.ID.String(), &spec); err != nil {
 		return err
 	}
 
+	e.tsdbStore.SetWALPolicy(b.ID.String(), tsdb.WALPolicy{
+		Mode:       tsdb.WALMode(b.WALMode),
+		FsyncDelay: b.WALFsyncDelay,
+	})
+	e.SetBucketOrganization(b.ID, b.OrgID)
+
 	return nil
 }
 
@@ -302,6 +384,23 @@ func (e *Engine) UpdateBucketRetentionPolicy(ctx context.Context, bucketID platf
 	rpu := meta.RetentionPolicyUpdate{
 		Duration:           upd.RetentionPeriod,
 		ShardGroupDuration: upd.ShardGroupDuration,
+		WALFsyncDelay:      upd.WALFsyncDelay,
+		LatenessWindow:     upd.LatenessWindow,
+		FutureSkewLimit:    upd.FutureSkewLimit,
+	}
+	if upd.WALMode != nil {
+		rpu.SetWALMode(string(*upd.WALMode))
+	}
+	if upd.LatePointsAction != nil {
+		rpu.SetLatePointsAction(string(*upd.LatePointsAction))
+	}
+	if upd.LatePointsBucketID != nil {
+		rpu.SetLatePointsDatabase(upd.LatePointsBucketID.String())
+	}
+	if l := upd.CardinalityLimits; l != nil {
+		rpu.SetMaxSeries(l.MaxSeries)
+		rpu.SetMaxSeriesPerMeasurement(l.MaxSeriesPerMeasurement)
+		rpu.SetMaxValuesPerTag(l.MaxValuesPerTag)
 	}
 
 	err := e.metaClient.UpdateRetentionPolicy(bucketID.String(), meta.DefaultRetentionPolicyName, &rpu, true)
@@ -311,7 +410,30 @@ func (e *Engine) UpdateBucketRetentionPolicy(ctx context.Context, bucketID platf
 			Msg:  "shard-group duration must also be updated to be smaller than new retention duration",
 		}
 	}
-	return err
+	if err != nil {
+		return err
+	}
+
+	if upd.WALMode != nil || upd.WALFsyncDelay != nil {
+		rpi, err := e.metaClient.RetentionPolicy(bucketID.String(), meta.DefaultRetentionPolicyName)
+		if err != nil {
+			return err
+		} else if rpi != nil {
+			e.tsdbStore.SetWALPolicy(bucketID.String(), walPolicy(rpi))
+		}
+	}
+	if upd.CardinalityLimits != nil {
+		e.applyCardinalityLimits(bucketID)
+	}
+	return nil
+}
+
+// walPolicy returns the tsdb WAL policy for a retention policy.
+func walPolicy(rpi *meta.RetentionPolicyInfo) tsdb.WALPolicy {
+	return tsdb.WALPolicy{
+		Mode:       tsdb.WALMode(rpi.WALMode),
+		FsyncDelay: rpi.WALFsyncDelay,
+	}
 }
 
 // DeleteBucket deletes an entire bucket from the storage engine.
@@ -322,6 +444,11 @@ func (e *Engine) DeleteBucket(ctx context.Context, orgID, bucketID platform.ID)
 	if err != nil {
 		return err
 	}
+
+	e.cardinality.mu.Lock()
+	delete(e.cardinality.bucketOrgs, bucketID)
+	e.cardinality.mu.Unlock()
+
 	return e.metaClient.DropDatabase(bucketID.String())
 }
 
@@ -339,6 +466,28 @@ func (e *Engine) DeleteBucketRangePredicate(ctx context.Context, orgID, bucketID
 	return e.tsdbStore.DeleteSeriesWithPredicate(ctx, bucketID.String(), min, max, pred)
 }
 
+// PurgeTombstones rewrites the TSM files of a bucket that hold deleted data so
+// that the space is reclaimed, blocking until every shard of the bucket is done.
+func (e *Engine) PurgeTombstones(ctx context.Context, bucketID platform.ID) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+
+	for _, sh := range e.tsdbStore.ShardsByDatabase(bucketID.String()) {
+		if err := sh.PurgeTombstones(ctx); err == tsdb.ErrShardDisabled || err == tsdb.ErrEngineClosed {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("purging tombstones of shard %d: %w", sh.ID(), err)
+		}
+	}
+	return nil
+}
+
 // RLockKVStore locks the KV store as well as the engine in preparation for doing a backup.
 func (e *Engine) RLockKVStore() {
 	e.mu.RLock()
@@ -492,6 +641,122 @@ func (e *Engine) RestoreShard(ctx context.Context, shardID uint64, r io.Reader)
 	return e.tsdbStore.RestoreShard(ctx, shardID, r)
 }
 
+// CompactionStatus returns the TSM file layout and planned compactions of the
+// shards of a bucket, or of a single shard if shardID is non-zero.
+func (e *Engine) CompactionStatus(ctx context.Context, bucketID platform.ID, shardID uint64) ([]*influxdb.ShardCompactionStatus, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	shards, err := e.compactionShards(bucketID, shardID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]*influxdb.ShardCompactionStatus, 0, len(shards))
+	for _, sh := range shards {
+		status, err := sh.CompactionStatus()
+		if err == tsdb.ErrShardDisabled || err == tsdb.ErrEngineClosed {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if id, err := platform.IDFromString(sh.Database()); err == nil {
+			status.BucketID = *id
+		}
+		statuses = append(statuses, &status)
+	}
+	return statuses, nil
+}
+
+// Compact runs a full or optimize compaction on the shards of a bucket, or on
+// a single shard, one shard at a time. progress is called after each shard.
+// A shard that fails to compact is reported to progress and the remaining
+// shards are still compacted; the first such error is returned.
+func (e *Engine) Compact(ctx context.Context, req influxdb.CompactionRequest, progress func(influxdb.CompactionProgress)) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := req.Valid(); err != nil {
+		return err
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return ErrEngineClosed
+	}
+
+	shards, err := e.compactionShards(req.BucketID, req.ShardID)
+	if err != nil {
+		return err
+	}
+
+	// Abort the compaction if the engine starts closing.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func(closing <-chan struct{}) {
+		select {
+		case <-closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}(e.closing)
+
+	var firstErr error
+	for i, sh := range shards {
+		err := sh.Compact(ctx, req.Mode == influxdb.CompactionModeOptimize)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		p := influxdb.CompactionProgress{ShardID: sh.ID(), ShardsDone: i + 1, ShardsTotal: len(shards)}
+		if err != nil {
+			e.logger.Info("Failed to compact shard", zap.Uint64("shard_id", sh.ID()), zap.Error(err))
+			p.Error = err.Error()
+			if firstErr == nil {
+				firstErr = fmt.Errorf("compacting shard %d: %w", sh.ID(), err)
+			}
+		}
+		if progress != nil {
+			progress(p)
+		}
+	}
+	return firstErr
+}
+
+// compactionShards returns the shards selected by a bucket and/or shard ID,
+// ordered by shard ID.
+func (e *Engine) compactionShards(bucketID platform.ID, shardID uint64) ([]*tsdb.Shard, error) {
+	var shards []*tsdb.Shard
+	if shardID != 0 {
+		sh := e.tsdbStore.Shard(shardID)
+		if sh == nil || (bucketID.Valid() && sh.Database() != bucketID.String()) {
+			return nil, &errors2.Error{
+				Code: errors2.ENotFound,
+				Msg:  fmt.Sprintf("shard %d not found", shardID),
+			}
+		}
+		shards = append(shards, sh)
+	} else {
+		if e.metaClient.Database(bucketID.String()) == nil {
+			return nil, &errors2.Error{
+				Code: errors2.ENotFound,
+				Msg:  "bucket not found",
+			}
+		}
+		shards = e.tsdbStore.ShardsByDatabase(bucketID.String())
+	}
+
+	sort.Slice(shards, func(i, j int) bool { return shards[i].ID() < shards[j].ID() })
+	return shards, nil
+}
+
 // SeriesCardinality returns the number of series in the engine.
 func (e *Engine) SeriesCardinality(ctx context.Context, bucketID platform.ID) int64 {
 	e.mu.RLock()
@@ -507,6 +772,19 @@ func (e *Engine) SeriesCardinality(ctx context.Context, bucketID platform.ID) in
 	return n
 }
 
+// BucketDiskSize returns the size of the shard files of a bucket in bytes.
+func (e *Engine) BucketDiskSize(ctx context.Context, bucketID platform.ID) (int64, error) {
+	span, _ := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return 0, ErrEngineClosed
+	}
+	return e.tsdbStore.DatabaseDiskSize(bucketID.String())
+}
+
 // Path returns the path of the engine's base directory.
 func (e *Engine) Path() string {
 	return e.path
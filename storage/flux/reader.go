@@ -129,6 +129,14 @@ func (r *storeReader) SupportReadSeriesCardinality(ctx context.Context) bool {
 	return r.s.SupportReadSeriesCardinality(ctx)
 }
 
+func (r *storeReader) SupportWindowAggregate(ctx context.Context, kind plan.ProcedureKind) bool {
+	agg, err := determineAggregateMethod(string(kind))
+	if err != nil || agg == datatypes.Aggregate_AggregateTypeNone {
+		return false
+	}
+	return r.s.SupportWindowAggregate(ctx, agg)
+}
+
 func (r *storeReader) Close() {}
 
 type filterIterator struct {
@@ -396,6 +404,9 @@ READ:
 func determineAggregateMethod(agg string) (datatypes.Aggregate_AggregateType, error) {
 	if agg == "" {
 		return datatypes.Aggregate_AggregateTypeNone, nil
+	} else if agg == QuantileKind {
+		// The quantile aggregate is estimated with a t-digest.
+		return datatypes.Aggregate_AggregateTypePercentile, nil
 	}
 
 	if t, ok := datatypes.AggregateNameMap[strings.ToUpper(agg)]; ok {
@@ -674,6 +685,10 @@ func (wai *windowAggregateIterator) Do(f func(flux.Table) error) error {
 			return err
 		} else if agg != datatypes.Aggregate_AggregateTypeNone {
 			req.Aggregate[i] = &datatypes.Aggregate{Type: agg}
+			if agg == datatypes.Aggregate_AggregateTypePercentile {
+				req.Aggregate[i].Quantile = wai.spec.Quantile
+				req.Aggregate[i].Compression = wai.spec.Compression
+			}
 		}
 	}
 
@@ -689,13 +704,17 @@ func (wai *windowAggregateIterator) Do(f func(flux.Table) error) error {
 }
 
 const (
-	CountKind = "count"
-	SumKind   = "sum"
-	FirstKind = "first"
-	LastKind  = "last"
-	MinKind   = "min"
-	MaxKind   = "max"
-	MeanKind  = "mean"
+	CountKind         = "count"
+	SumKind           = "sum"
+	FirstKind         = "first"
+	LastKind          = "last"
+	MinKind           = "min"
+	MaxKind           = "max"
+	MeanKind          = "mean"
+	StddevKind        = "stddev"
+	SpreadKind        = "spread"
+	QuantileKind      = "quantile"
+	CountDistinctKind = "countDistinct"
 )
 
 // isSelector returns true if given a procedure kind that represents a selector operator.
@@ -855,7 +874,7 @@ READ:
 }
 
 func isAggregateCount(kind plan.ProcedureKind) bool {
-	return kind == CountKind
+	return kind == CountKind || kind == CountDistinctKind
 }
 
 type tagKeysIterator struct {
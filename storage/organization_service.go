@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"go.uber.org/zap"
+)
+
+// EngineCardinality is the part of the storage engine enforcing the
+// cardinality limits of organizations.
+type EngineCardinality interface {
+	SetBucketOrganization(bucketID, orgID platform.ID)
+	SetOrganizationCardinalityLimits(orgID platform.ID, limits *influxdb.CardinalityLimits)
+}
+
+// OrganizationService wraps an existing influxdb.OrganizationService
+// implementation.
+//
+// OrganizationService ensures that the storage engine enforces the current
+// cardinality limits of each organization.
+type OrganizationService struct {
+	influxdb.OrganizationService
+	log    *zap.Logger
+	engine EngineCardinality
+}
+
+// NewOrganizationService returns a new OrganizationService for the provided
+// EngineCardinality, which typically will be an Engine.
+func NewOrganizationService(log *zap.Logger, s influxdb.OrganizationService, engine EngineCardinality) *OrganizationService {
+	return &OrganizationService{
+		OrganizationService: s,
+		log:                 log,
+		engine:              engine,
+	}
+}
+
+// LoadCardinalityLimits registers the organization of every bucket, and the
+// cardinality limits of every organization, with the engine. It is called
+// once at startup; the limits are kept current as organizations change.
+func (s *OrganizationService) LoadCardinalityLimits(ctx context.Context, buckets BucketFinder) error {
+	for offset := 0; ; {
+		orgs, _, err := s.FindOrganizations(ctx, influxdb.OrganizationFilter{}, influxdb.FindOptions{Limit: influxdb.MaxPageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, o := range orgs {
+			if o.CardinalityLimits != nil {
+				s.engine.SetOrganizationCardinalityLimits(o.ID, o.CardinalityLimits)
+			}
+		}
+		if len(orgs) < influxdb.MaxPageSize {
+			break
+		}
+		offset += len(orgs)
+	}
+
+	for offset := 0; ; {
+		bs, _, err := buckets.FindBuckets(ctx, influxdb.BucketFilter{}, influxdb.FindOptions{Limit: influxdb.MaxPageSize, Offset: offset})
+		if err != nil {
+			return err
+		}
+		for _, b := range bs {
+			s.engine.SetBucketOrganization(b.ID, b.OrgID)
+		}
+		if len(bs) < influxdb.MaxPageSize {
+			break
+		}
+		offset += len(bs)
+	}
+	return nil
+}
+
+// CreateOrganization creates an organization and applies its cardinality
+// limits.
+func (s *OrganizationService) CreateOrganization(ctx context.Context, o *influxdb.Organization) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.OrganizationService.CreateOrganization(ctx, o); err != nil {
+		return err
+	}
+	if o.CardinalityLimits != nil {
+		s.engine.SetOrganizationCardinalityLimits(o.ID, o.CardinalityLimits)
+	}
+	return nil
+}
+
+// UpdateOrganization updates an organization and applies its new cardinality
+// limits.
+func (s *OrganizationService) UpdateOrganization(ctx context.Context, id platform.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	o, err := s.OrganizationService.UpdateOrganization(ctx, id, upd)
+	if err != nil {
+		return nil, err
+	}
+	if upd.CardinalityLimits != nil {
+		s.engine.SetOrganizationCardinalityLimits(o.ID, o.CardinalityLimits)
+	}
+	return o, nil
+}
+
+// DeleteOrganization removes an organization and its cardinality limits.
+func (s *OrganizationService) DeleteOrganization(ctx context.Context, id platform.ID) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.OrganizationService.DeleteOrganization(ctx, id); err != nil {
+		return err
+	}
+	s.engine.SetOrganizationCardinalityLimits(id, nil)
+	return nil
+}
@@ -97,7 +97,6 @@ func (r *windowAggregateResultSet) createCursor(seriesRow SeriesRow) (cursors.Cu
 	agg := r.req.Aggregate[0]
 	every := r.req.WindowEvery
 	offset := r.req.Offset
-	cursor := r.arrayCursors.createCursor(seriesRow)
 
 	var everyDur values.Duration
 	var offsetDur values.Duration
@@ -126,10 +125,15 @@ func (r *windowAggregateResultSet) createCursor(seriesRow SeriesRow) (cursors.Cu
 
 	if everyDur.Nanoseconds() == math.MaxInt64 {
 		// This means to aggregate over whole series for the query's time range
+		cursor := r.arrayCursors.createCursor(seriesRow)
 		return newAggregateArrayCursor(r.ctx, agg, cursor)
-	} else {
-		return newWindowAggregateArrayCursor(r.ctx, agg, window, cursor)
 	}
+
+	if m, ok := r.arrayCursors.(*multiShardArrayCursors); ok && m.req.Ascending && canSummarizeWindowAggregate(agg, window, seriesRow) {
+		return m.createWindowSummaryCursor(seriesRow, agg, window)
+	}
+	cursor := r.arrayCursors.createCursor(seriesRow)
+	return newWindowAggregateArrayCursor(r.ctx, agg, window, cursor)
 }
 
 func (r *windowAggregateResultSet) Cursor() cursors.Cursor {
@@ -333,3 +333,175 @@ func TestNewWindowAggregateResultSet_TimeRange(t *testing.T) {
 		t.Fatal("expected result")
 	}
 }
+
+// mockFloatBlock is a block of float values of a mockSummaryCursorIterator.
+type mockFloatBlock struct {
+	ts []int64
+	vs []float64
+}
+
+// mockSummaryCursorIterator returns cursors over blocks of float values,
+// summarizing the blocks accepted by the summarize function.
+type mockSummaryCursorIterator struct {
+	blocks     []mockFloatBlock
+	summarized int
+}
+
+func (i *mockSummaryCursorIterator) Next(ctx context.Context, req *cursors.CursorRequest) (cursors.Cursor, error) {
+	return i.NextSummarized(ctx, req, func(minTime, maxTime int64) bool { return false })
+}
+
+func (i *mockSummaryCursorIterator) NextSummarized(ctx context.Context, req *cursors.CursorRequest, summarize func(minTime, maxTime int64) bool) (cursors.Cursor, error) {
+	cur := &mockFloatSummaryArrayCursor{a: cursors.NewFloatArrayLen(0)}
+	for _, b := range i.blocks {
+		if !summarize(b.ts[0], b.ts[len(b.ts)-1]) {
+			cur.a.Timestamps = append(cur.a.Timestamps, b.ts...)
+			cur.a.Values = append(cur.a.Values, b.vs...)
+			continue
+		}
+
+		s := cursors.FloatBlockSummary{
+			MinTime: b.ts[0], MaxTime: b.ts[len(b.ts)-1], Count: int64(len(b.ts)),
+			Min: b.vs[0], Max: b.vs[0], First: b.vs[0], Last: b.vs[len(b.vs)-1],
+			MinValueTime: b.ts[0], MaxValueTime: b.ts[0],
+		}
+		for j, v := range b.vs {
+			s.Sum += v
+			if v < s.Min {
+				s.Min, s.MinValueTime = v, b.ts[j]
+			}
+			if v > s.Max {
+				s.Max, s.MaxValueTime = v, b.ts[j]
+			}
+		}
+		cur.summaries = append(cur.summaries, s)
+		i.summarized++
+	}
+	return cur, nil
+}
+
+func (i *mockSummaryCursorIterator) Stats() cursors.CursorStats { return cursors.CursorStats{} }
+
+type mockFloatSummaryArrayCursor struct {
+	a         *cursors.FloatArray
+	summaries []cursors.FloatBlockSummary
+}
+
+func (c *mockFloatSummaryArrayCursor) Close()                     {}
+func (c *mockFloatSummaryArrayCursor) Err() error                 { return nil }
+func (c *mockFloatSummaryArrayCursor) Stats() cursors.CursorStats { return cursors.CursorStats{} }
+func (c *mockFloatSummaryArrayCursor) Summaries() []cursors.FloatBlockSummary {
+	return c.summaries
+}
+func (c *mockFloatSummaryArrayCursor) Next() *cursors.FloatArray {
+	a := c.a
+	c.a = cursors.NewFloatArrayLen(0)
+	return a
+}
+
+// withoutSummaries returns c as a plain cursor.
+func (c *mockFloatSummaryArrayCursor) withoutSummaries() cursors.FloatArrayCursor {
+	return struct{ cursors.FloatArrayCursor }{c}
+}
+
+// Window aggregates computed from block summaries match those computed from
+// the values of the blocks.
+func TestNewWindowAggregateResultSet_Summaries(t *testing.T) {
+	shards := [][]mockFloatBlock{
+		{
+			{ts: []int64{1, 2, 3}, vs: []float64{5, 1, 9}},
+			{ts: []int64{11, 15}, vs: []float64{4, 4}},
+			{ts: []int64{18, 22}, vs: []float64{7, 3}},
+		},
+		{
+			{ts: []int64{24, 25}, vs: []float64{-2, 8}},
+			{ts: []int64{30, 31, 35}, vs: []float64{6, 2, 6}},
+			{ts: []int64{41}, vs: []float64{1}},
+		},
+	}
+
+	read := func(agg datatypes.Aggregate_AggregateType, summarize bool) (ts []int64, vs []float64, summarized int) {
+		t.Helper()
+
+		var itrs cursors.CursorIterators
+		var summaryItrs []*mockSummaryCursorIterator
+		for _, blocks := range shards {
+			itr := &mockSummaryCursorIterator{blocks: blocks}
+			summaryItrs = append(summaryItrs, itr)
+			if summarize {
+				itrs = append(itrs, itr)
+			} else {
+				itrs = append(itrs, &mockCursorIterator{
+					newCursorFn: func(req *cursors.CursorRequest) cursors.Cursor {
+						cur, _ := itr.Next(context.Background(), req)
+						return cur.(*mockFloatSummaryArrayCursor).withoutSummaries()
+					},
+				})
+			}
+		}
+
+		newCursor := newMockReadCursor("clicks click=1 1")
+		newCursor.rows[0].Query = itrs
+
+		req := datatypes.ReadWindowAggregateRequest{
+			Range:       &datatypes.TimestampRange{Start: 0, End: 50},
+			Aggregate:   []*datatypes.Aggregate{{Type: agg}},
+			WindowEvery: 10,
+		}
+		resultSet, err := reads.NewWindowAggregateResultSet(context.Background(), &req, &newCursor)
+		if err != nil {
+			t.Fatalf("error creating WindowAggregateResultSet: %s", err)
+		}
+		if !resultSet.Next() {
+			t.Fatal("expected result")
+		}
+
+		switch cur := resultSet.Cursor().(type) {
+		case cursors.FloatArrayCursor:
+			for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+				ts = append(ts, a.Timestamps...)
+				vs = append(vs, a.Values...)
+			}
+		case cursors.IntegerArrayCursor:
+			for a := cur.Next(); a.Len() > 0; a = cur.Next() {
+				ts = append(ts, a.Timestamps...)
+				for _, v := range a.Values {
+					vs = append(vs, float64(v))
+				}
+			}
+		default:
+			t.Fatalf("unexpected cursor type %T", cur)
+		}
+
+		for _, itr := range summaryItrs {
+			summarized += itr.summarized
+		}
+		return ts, vs, summarized
+	}
+
+	for _, agg := range []datatypes.Aggregate_AggregateType{
+		datatypes.Aggregate_AggregateTypeCount,
+		datatypes.Aggregate_AggregateTypeSum,
+		datatypes.Aggregate_AggregateTypeMin,
+		datatypes.Aggregate_AggregateTypeMax,
+		datatypes.Aggregate_AggregateTypeFirst,
+		datatypes.Aggregate_AggregateTypeLast,
+		datatypes.Aggregate_AggregateTypeMean,
+	} {
+		t.Run(agg.String(), func(t *testing.T) {
+			expTs, expVs, _ := read(agg, false)
+			ts, vs, summarized := read(agg, true)
+
+			// The blocks crossing a window boundary are read as values.
+			if got, exp := summarized, 5; got != exp {
+				t.Errorf("unexpected number of summarized blocks: got %d, exp %d", got, exp)
+			}
+			if !reflect.DeepEqual(ts, expTs) {
+				t.Errorf("unexpected timestamps: got %v, exp %v", ts, expTs)
+			}
+			if !reflect.DeepEqual(vs, expVs) {
+				t.Errorf("unexpected values: got %v, exp %v", vs, expVs)
+			}
+		})
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/influxdata/flux/values"
 	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
 	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"github.com/influxdata/tdigest"
 )
 
 const (
@@ -196,6 +197,89 @@ func newWindowMeanArrayCursor(cur cursors.Cursor, window interval.Window) (curso
 	}
 }
 
+func newWindowStddevArrayCursor(cur cursors.Cursor, window interval.Window) (cursors.Cursor, error) {
+	switch cur := cur.(type) {
+
+	case cursors.FloatArrayCursor:
+		return newFloatWindowStddevArrayCursor(cur, window), nil
+
+	case cursors.IntegerArrayCursor:
+		return newIntegerWindowStddevArrayCursor(cur, window), nil
+
+	case cursors.UnsignedArrayCursor:
+		return newUnsignedWindowStddevArrayCursor(cur, window), nil
+
+	default:
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("unsupported input type for stddev aggregate: %s", arrayCursorType(cur)),
+		}
+	}
+}
+
+func newWindowSpreadArrayCursor(cur cursors.Cursor, window interval.Window) (cursors.Cursor, error) {
+	switch cur := cur.(type) {
+
+	case cursors.FloatArrayCursor:
+		return newFloatWindowSpreadArrayCursor(cur, window), nil
+
+	case cursors.IntegerArrayCursor:
+		return newIntegerWindowSpreadArrayCursor(cur, window), nil
+
+	case cursors.UnsignedArrayCursor:
+		return newUnsignedWindowSpreadArrayCursor(cur, window), nil
+
+	default:
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("unsupported input type for spread aggregate: %s", arrayCursorType(cur)),
+		}
+	}
+}
+
+func newWindowPercentileArrayCursor(cur cursors.Cursor, window interval.Window, quantile, compression float64) (cursors.Cursor, error) {
+	switch cur := cur.(type) {
+
+	case cursors.FloatArrayCursor:
+		return newFloatWindowPercentileArrayCursor(cur, window, quantile, compression), nil
+
+	case cursors.IntegerArrayCursor:
+		return newIntegerWindowPercentileArrayCursor(cur, window, quantile, compression), nil
+
+	case cursors.UnsignedArrayCursor:
+		return newUnsignedWindowPercentileArrayCursor(cur, window, quantile, compression), nil
+
+	default:
+		return nil, &errors2.Error{
+			Code: errors2.EInvalid,
+			Msg:  fmt.Sprintf("unsupported input type for percentile aggregate: %s", arrayCursorType(cur)),
+		}
+	}
+}
+
+func newWindowCountDistinctArrayCursor(cur cursors.Cursor, window interval.Window) cursors.Cursor {
+	switch cur := cur.(type) {
+
+	case cursors.FloatArrayCursor:
+		return newFloatWindowCountDistinctArrayCursor(cur, window)
+
+	case cursors.IntegerArrayCursor:
+		return newIntegerWindowCountDistinctArrayCursor(cur, window)
+
+	case cursors.UnsignedArrayCursor:
+		return newUnsignedWindowCountDistinctArrayCursor(cur, window)
+
+	case cursors.StringArrayCursor:
+		return newStringWindowCountDistinctArrayCursor(cur, window)
+
+	case cursors.BooleanArrayCursor:
+		return newBooleanWindowCountDistinctArrayCursor(cur, window)
+
+	default:
+		panic(fmt.Sprintf("unreachable: %T", cur))
+	}
+}
+
 // ********************
 // Float Array Cursor
 
@@ -1066,70 +1150,95 @@ WINDOWS:
 	return c.res
 }
 
-type floatEmptyArrayCursor struct {
-	res cursors.FloatArray
-}
-
-var FloatEmptyArrayCursor cursors.FloatArrayCursor = &floatEmptyArrayCursor{}
-
-func (c *floatEmptyArrayCursor) Err() error                 { return nil }
-func (c *floatEmptyArrayCursor) Close()                     {}
-func (c *floatEmptyArrayCursor) Stats() cursors.CursorStats { return cursors.CursorStats{} }
-func (c *floatEmptyArrayCursor) Next() *cursors.FloatArray  { return &c.res }
-
-// ********************
-// Integer Array Cursor
-
-type integerArrayFilterCursor struct {
-	cursors.IntegerArrayCursor
-	cond expression
-	m    *singleValue
-	res  *cursors.IntegerArray
-	tmp  *cursors.IntegerArray
+type floatWindowStddevArrayCursor struct {
+	cursors.FloatArrayCursor
+	res    *cursors.FloatArray
+	tmp    *cursors.FloatArray
+	window interval.Window
 }
 
-func newIntegerFilterArrayCursor(cond expression) *integerArrayFilterCursor {
-	return &integerArrayFilterCursor{
-		cond: cond,
-		m:    &singleValue{},
-		res:  cursors.NewIntegerArrayLen(MaxPointsPerBlock),
-		tmp:  &cursors.IntegerArray{},
+func newFloatWindowStddevArrayCursor(cur cursors.FloatArrayCursor, window interval.Window) *floatWindowStddevArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &floatWindowStddevArrayCursor{
+		FloatArrayCursor: cur,
+		res:              cursors.NewFloatArrayLen(resLen),
+		tmp:              &cursors.FloatArray{},
+		window:           window,
 	}
 }
 
-func (c *integerArrayFilterCursor) reset(cur cursors.IntegerArrayCursor) {
-	c.IntegerArrayCursor = cur
-	c.tmp.Timestamps, c.tmp.Values = nil, nil
+func (c *floatWindowStddevArrayCursor) Stats() cursors.CursorStats {
+	return c.FloatArrayCursor.Stats()
 }
 
-func (c *integerArrayFilterCursor) Stats() cursors.CursorStats { return c.IntegerArrayCursor.Stats() }
-
-func (c *integerArrayFilterCursor) Next() *cursors.IntegerArray {
+func (c *floatWindowStddevArrayCursor) Next() *cursors.FloatArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
-	var a *cursors.IntegerArray
-
+	var a *cursors.FloatArray
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
-		a = c.IntegerArrayCursor.Next()
+		a = c.FloatArrayCursor.Next()
 	}
 
-LOOP:
-	for len(a.Timestamps) > 0 {
-		for i, v := range a.Values {
-			c.m.v = v
-			if c.cond.EvalBool(c.m) {
-				c.res.Timestamps[pos] = a.Timestamps[i]
-				c.res.Values[pos] = v
-				pos++
-				if pos >= MaxPointsPerBlock {
-					c.tmp.Timestamps = a.Timestamps[i+1:]
-					c.tmp.Values = a.Values[i+1:]
-					break LOOP
+	if a.Len() == 0 {
+		return &cursors.FloatArray{}
+	}
+
+	rowIdx := 0
+	var n, mean, m2 float64
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					if n > 1 {
+						c.res.Values[pos] = math.Sqrt(m2 / (n - 1))
+					} else {
+						c.res.Values[pos] = math.NaN()
+					}
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
 				}
+
+				// start the new window
+				n, mean, m2 = 0, 0, 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				n++
+				delta := a.Values[rowIdx] - mean
+				mean += delta / n
+				m2 += delta * (a.Values[rowIdx] - mean)
+				windowHasPoints = true
 			}
 		}
 
@@ -1138,7 +1247,23 @@ LOOP:
 		c.tmp.Timestamps = nil
 		c.tmp.Values = nil
 
-		a = c.IntegerArrayCursor.Next()
+		// get the next chunk
+		a = c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				if n > 1 {
+					c.res.Values[pos] = math.Sqrt(m2 / (n - 1))
+				} else {
+					c.res.Values[pos] = math.NaN()
+				}
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
 	}
 
 	c.res.Timestamps = c.res.Timestamps[:pos]
@@ -1147,65 +1272,489 @@ LOOP:
 	return c.res
 }
 
-type integerMultiShardArrayCursor struct {
-	cursors.IntegerArrayCursor
-	cursorContext
-	filter *integerArrayFilterCursor
+type floatWindowSpreadArrayCursor struct {
+	cursors.FloatArrayCursor
+	res    *cursors.FloatArray
+	tmp    *cursors.FloatArray
+	window interval.Window
 }
 
-func (c *integerMultiShardArrayCursor) reset(cur cursors.IntegerArrayCursor, itrs cursors.CursorIterators, cond expression) {
-	if cond != nil {
-		if c.filter == nil {
-			c.filter = newIntegerFilterArrayCursor(cond)
-		} else {
-			c.filter.cond = cond
-		}
-		c.filter.reset(cur)
-		cur = c.filter
+func newFloatWindowSpreadArrayCursor(cur cursors.FloatArrayCursor, window interval.Window) *floatWindowSpreadArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &floatWindowSpreadArrayCursor{
+		FloatArrayCursor: cur,
+		res:              cursors.NewFloatArrayLen(resLen),
+		tmp:              &cursors.FloatArray{},
+		window:           window,
 	}
-
-	c.IntegerArrayCursor = cur
-	c.itrs = itrs
-	c.err = nil
 }
 
-func (c *integerMultiShardArrayCursor) Err() error { return c.err }
-
-func (c *integerMultiShardArrayCursor) Stats() cursors.CursorStats {
-	return c.IntegerArrayCursor.Stats()
+func (c *floatWindowSpreadArrayCursor) Stats() cursors.CursorStats {
+	return c.FloatArrayCursor.Stats()
 }
 
-func (c *integerMultiShardArrayCursor) Next() *cursors.IntegerArray {
-	for {
-		a := c.IntegerArrayCursor.Next()
-		if a.Len() == 0 {
-			if c.nextArrayCursor() {
-				continue
-			}
-		}
-		return a
+func (c *floatWindowSpreadArrayCursor) Next() *cursors.FloatArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.FloatArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.FloatArrayCursor.Next()
 	}
-}
 
-func (c *integerMultiShardArrayCursor) nextArrayCursor() bool {
-	if len(c.itrs) == 0 {
-		return false
+	if a.Len() == 0 {
+		return &cursors.FloatArray{}
 	}
 
-	c.IntegerArrayCursor.Close()
+	rowIdx := 0
+	var lo, hi float64
 
-	var itr cursors.CursorIterator
-	var cur cursors.Cursor
-	for cur == nil && len(c.itrs) > 0 {
-		itr, c.itrs = c.itrs[0], c.itrs[1:]
-		cur, _ = itr.Next(c.ctx, c.req)
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
 	}
+	windowHasPoints := false
 
-	var ok bool
-	if cur != nil {
-		var next cursors.IntegerArrayCursor
-		next, ok = cur.(cursors.IntegerArrayCursor)
-		if !ok {
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = hi - lo
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				lo, hi = 0, 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				if !windowHasPoints || a.Values[rowIdx] < lo {
+					lo = a.Values[rowIdx]
+				}
+				if !windowHasPoints || a.Values[rowIdx] > hi {
+					hi = a.Values[rowIdx]
+				}
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = hi - lo
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type floatWindowPercentileArrayCursor struct {
+	cursors.FloatArrayCursor
+	res                   *cursors.FloatArray
+	tmp                   *cursors.FloatArray
+	window                interval.Window
+	quantile, compression float64
+}
+
+func newFloatWindowPercentileArrayCursor(cur cursors.FloatArrayCursor, window interval.Window, quantile, compression float64) *floatWindowPercentileArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &floatWindowPercentileArrayCursor{
+		FloatArrayCursor: cur,
+		res:              cursors.NewFloatArrayLen(resLen),
+		tmp:              &cursors.FloatArray{},
+		window:           window,
+		quantile:         quantile,
+		compression:      compression,
+	}
+}
+
+func (c *floatWindowPercentileArrayCursor) Stats() cursors.CursorStats {
+	return c.FloatArrayCursor.Stats()
+}
+
+func (c *floatWindowPercentileArrayCursor) Next() *cursors.FloatArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.FloatArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.FloatArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.FloatArray{}
+	}
+
+	rowIdx := 0
+	td := tdigest.NewWithCompression(c.compression)
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = td.Quantile(c.quantile)
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				td.Reset()
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				td.Add(a.Values[rowIdx], 1)
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = td.Quantile(c.quantile)
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type floatWindowCountDistinctArrayCursor struct {
+	cursors.FloatArrayCursor
+	res    *cursors.IntegerArray
+	tmp    *cursors.FloatArray
+	window interval.Window
+}
+
+func newFloatWindowCountDistinctArrayCursor(cur cursors.FloatArrayCursor, window interval.Window) *floatWindowCountDistinctArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &floatWindowCountDistinctArrayCursor{
+		FloatArrayCursor: cur,
+		res:              cursors.NewIntegerArrayLen(resLen),
+		tmp:              &cursors.FloatArray{},
+		window:           window,
+	}
+}
+
+func (c *floatWindowCountDistinctArrayCursor) Stats() cursors.CursorStats {
+	return c.FloatArrayCursor.Stats()
+}
+
+func (c *floatWindowCountDistinctArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.FloatArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.FloatArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.IntegerArray{}
+	}
+
+	rowIdx := 0
+	distinct := make(map[float64]struct{})
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = int64(len(distinct))
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				for v := range distinct {
+					delete(distinct, v)
+				}
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				distinct[a.Values[rowIdx]] = struct{}{}
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.FloatArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = int64(len(distinct))
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type floatEmptyArrayCursor struct {
+	res cursors.FloatArray
+}
+
+var FloatEmptyArrayCursor cursors.FloatArrayCursor = &floatEmptyArrayCursor{}
+
+func (c *floatEmptyArrayCursor) Err() error                 { return nil }
+func (c *floatEmptyArrayCursor) Close()                     {}
+func (c *floatEmptyArrayCursor) Stats() cursors.CursorStats { return cursors.CursorStats{} }
+func (c *floatEmptyArrayCursor) Next() *cursors.FloatArray  { return &c.res }
+
+// ********************
+// Integer Array Cursor
+
+type integerArrayFilterCursor struct {
+	cursors.IntegerArrayCursor
+	cond expression
+	m    *singleValue
+	res  *cursors.IntegerArray
+	tmp  *cursors.IntegerArray
+}
+
+func newIntegerFilterArrayCursor(cond expression) *integerArrayFilterCursor {
+	return &integerArrayFilterCursor{
+		cond: cond,
+		m:    &singleValue{},
+		res:  cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		tmp:  &cursors.IntegerArray{},
+	}
+}
+
+func (c *integerArrayFilterCursor) reset(cur cursors.IntegerArrayCursor) {
+	c.IntegerArrayCursor = cur
+	c.tmp.Timestamps, c.tmp.Values = nil, nil
+}
+
+func (c *integerArrayFilterCursor) Stats() cursors.CursorStats { return c.IntegerArrayCursor.Stats() }
+
+func (c *integerArrayFilterCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.IntegerArray
+
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+LOOP:
+	for len(a.Timestamps) > 0 {
+		for i, v := range a.Values {
+			c.m.v = v
+			if c.cond.EvalBool(c.m) {
+				c.res.Timestamps[pos] = a.Timestamps[i]
+				c.res.Values[pos] = v
+				pos++
+				if pos >= MaxPointsPerBlock {
+					c.tmp.Timestamps = a.Timestamps[i+1:]
+					c.tmp.Values = a.Values[i+1:]
+					break LOOP
+				}
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type integerMultiShardArrayCursor struct {
+	cursors.IntegerArrayCursor
+	cursorContext
+	filter *integerArrayFilterCursor
+}
+
+func (c *integerMultiShardArrayCursor) reset(cur cursors.IntegerArrayCursor, itrs cursors.CursorIterators, cond expression) {
+	if cond != nil {
+		if c.filter == nil {
+			c.filter = newIntegerFilterArrayCursor(cond)
+		} else {
+			c.filter.cond = cond
+		}
+		c.filter.reset(cur)
+		cur = c.filter
+	}
+
+	c.IntegerArrayCursor = cur
+	c.itrs = itrs
+	c.err = nil
+}
+
+func (c *integerMultiShardArrayCursor) Err() error { return c.err }
+
+func (c *integerMultiShardArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerMultiShardArrayCursor) Next() *cursors.IntegerArray {
+	for {
+		a := c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			if c.nextArrayCursor() {
+				continue
+			}
+		}
+		return a
+	}
+}
+
+func (c *integerMultiShardArrayCursor) nextArrayCursor() bool {
+	if len(c.itrs) == 0 {
+		return false
+	}
+
+	c.IntegerArrayCursor.Close()
+
+	var itr cursors.CursorIterator
+	var cur cursors.Cursor
+	for cur == nil && len(c.itrs) > 0 {
+		itr, c.itrs = c.itrs[0], c.itrs[1:]
+		cur, _ = itr.Next(c.ctx, c.req)
+	}
+
+	var ok bool
+	if cur != nil {
+		var next cursors.IntegerArrayCursor
+		next, ok = cur.(cursors.IntegerArrayCursor)
+		if !ok {
 			cur.Close()
 			next = IntegerEmptyArrayCursor
 			c.err = errors.New("expected integer cursor")
@@ -1215,73 +1764,890 @@ func (c *integerMultiShardArrayCursor) nextArrayCursor() bool {
 				next = c.filter
 			}
 		}
-		c.IntegerArrayCursor = next
+		c.IntegerArrayCursor = next
+	} else {
+		c.IntegerArrayCursor = IntegerEmptyArrayCursor
+	}
+
+	return ok
+}
+
+type integerLimitArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res  *cursors.IntegerArray
+	done bool
+}
+
+func newIntegerLimitArrayCursor(cur cursors.IntegerArrayCursor) *integerLimitArrayCursor {
+	return &integerLimitArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                cursors.NewIntegerArrayLen(1),
+	}
+}
+
+func (c *integerLimitArrayCursor) Stats() cursors.CursorStats { return c.IntegerArrayCursor.Stats() }
+
+func (c *integerLimitArrayCursor) Next() *cursors.IntegerArray {
+	if c.done {
+		return &cursors.IntegerArray{}
+	}
+	a := c.IntegerArrayCursor.Next()
+	if len(a.Timestamps) == 0 {
+		return a
+	}
+	c.done = true
+	c.res.Timestamps[0] = a.Timestamps[0]
+	c.res.Values[0] = a.Values[0]
+	return c.res
+}
+
+type integerWindowLastArrayCursor struct {
+	cursors.IntegerArrayCursor
+	windowEnd int64
+	res       *cursors.IntegerArray
+	tmp       *cursors.IntegerArray
+	window    interval.Window
+}
+
+// Window array cursors assume that every != 0 && every != MaxInt64.
+// Such a cursor will panic in the first case and possibly overflow in the second.
+func newIntegerWindowLastArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowLastArrayCursor {
+	return &integerWindowLastArrayCursor{
+		IntegerArrayCursor: cur,
+		windowEnd:          math.MinInt64,
+		res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowLastArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowLastArrayCursor) Next() *cursors.IntegerArray {
+	cur := -1
+
+NEXT:
+	var a *cursors.IntegerArray
+
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		c.res.Timestamps = c.res.Timestamps[:cur+1]
+		c.res.Values = c.res.Values[:cur+1]
+		return c.res
+	}
+
+	for i, t := range a.Timestamps {
+		if t >= c.windowEnd {
+			cur++
+		}
+
+		if cur == MaxPointsPerBlock {
+			c.tmp.Timestamps = a.Timestamps[i:]
+			c.tmp.Values = a.Values[i:]
+			return c.res
+		}
+
+		c.res.Timestamps[cur] = t
+		c.res.Values[cur] = a.Values[i]
+
+		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
+	}
+
+	c.tmp.Timestamps = nil
+	c.tmp.Values = nil
+
+	goto NEXT
+}
+
+type integerWindowFirstArrayCursor struct {
+	cursors.IntegerArrayCursor
+	windowEnd int64
+	res       *cursors.IntegerArray
+	tmp       *cursors.IntegerArray
+	window    interval.Window
+}
+
+// Window array cursors assume that every != 0 && every != MaxInt64.
+// Such a cursor will panic in the first case and possibly overflow in the second.
+func newIntegerWindowFirstArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowFirstArrayCursor {
+	return &integerWindowFirstArrayCursor{
+		IntegerArrayCursor: cur,
+		windowEnd:          math.MinInt64,
+		res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowFirstArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowFirstArrayCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+NEXT:
+	var a *cursors.IntegerArray
+
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return c.res
+	}
+
+	for i, t := range a.Timestamps {
+		if t < c.windowEnd {
+			continue
+		}
+
+		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
+
+		c.res.Timestamps = append(c.res.Timestamps, t)
+		c.res.Values = append(c.res.Values, a.Values[i])
+
+		if c.res.Len() == MaxPointsPerBlock {
+			c.tmp.Timestamps = a.Timestamps[i+1:]
+			c.tmp.Values = a.Values[i+1:]
+			return c.res
+		}
+	}
+
+	c.tmp.Timestamps = nil
+	c.tmp.Values = nil
+
+	goto NEXT
+}
+
+type integerWindowCountArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res    *cursors.IntegerArray
+	tmp    *cursors.IntegerArray
+	window interval.Window
+}
+
+func newIntegerWindowCountArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowCountArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowCountArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                cursors.NewIntegerArrayLen(resLen),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowCountArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowCountArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.IntegerArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.IntegerArray{}
+	}
+
+	rowIdx := 0
+	var acc int64 = 0
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = acc
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				acc = 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				acc++
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = acc
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type integerWindowSumArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res    *cursors.IntegerArray
+	tmp    *cursors.IntegerArray
+	window interval.Window
+}
+
+func newIntegerWindowSumArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowSumArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowSumArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                cursors.NewIntegerArrayLen(resLen),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowSumArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowSumArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.IntegerArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.IntegerArray{}
+	}
+
+	rowIdx := 0
+	var acc int64 = 0
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = acc
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				acc = 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				acc += a.Values[rowIdx]
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = acc
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type integerWindowMinArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res    *cursors.IntegerArray
+	tmp    *cursors.IntegerArray
+	window interval.Window
+}
+
+func newIntegerWindowMinArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowMinArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowMinArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                cursors.NewIntegerArrayLen(resLen),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowMinArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowMinArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.IntegerArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.IntegerArray{}
+	}
+
+	rowIdx := 0
+	var acc int64 = math.MaxInt64
+	var tsAcc int64
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = tsAcc
+					c.res.Values[pos] = acc
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				acc = math.MaxInt64
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				if !windowHasPoints || a.Values[rowIdx] < acc {
+					acc = a.Values[rowIdx]
+					tsAcc = a.Timestamps[rowIdx]
+				}
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = tsAcc
+				c.res.Values[pos] = acc
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type integerWindowMaxArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res    *cursors.IntegerArray
+	tmp    *cursors.IntegerArray
+	window interval.Window
+}
+
+func newIntegerWindowMaxArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowMaxArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowMaxArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                cursors.NewIntegerArrayLen(resLen),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowMaxArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowMaxArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.IntegerArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.IntegerArray{}
+	}
+
+	rowIdx := 0
+	var acc int64 = math.MinInt64
+	var tsAcc int64
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = tsAcc
+					c.res.Values[pos] = acc
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				acc = math.MinInt64
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				if !windowHasPoints || a.Values[rowIdx] > acc {
+					acc = a.Values[rowIdx]
+					tsAcc = a.Timestamps[rowIdx]
+				}
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = tsAcc
+				c.res.Values[pos] = acc
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type integerWindowMeanArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res    *cursors.FloatArray
+	tmp    *cursors.IntegerArray
+	window interval.Window
+}
+
+func newIntegerWindowMeanArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowMeanArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowMeanArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                cursors.NewFloatArrayLen(resLen),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowMeanArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowMeanArrayCursor) Next() *cursors.FloatArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.IntegerArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.FloatArray{}
+	}
+
+	rowIdx := 0
+	var sum int64
+	var count int64
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = float64(sum) / float64(count)
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				sum = 0
+				count = 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				sum += a.Values[rowIdx]
+				count++
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = float64(sum) / float64(count)
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type integerWindowStddevArrayCursor struct {
+	cursors.IntegerArrayCursor
+	res    *cursors.FloatArray
+	tmp    *cursors.IntegerArray
+	window interval.Window
+}
+
+func newIntegerWindowStddevArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowStddevArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowStddevArrayCursor{
+		IntegerArrayCursor: cur,
+		res:                cursors.NewFloatArrayLen(resLen),
+		tmp:                &cursors.IntegerArray{},
+		window:             window,
+	}
+}
+
+func (c *integerWindowStddevArrayCursor) Stats() cursors.CursorStats {
+	return c.IntegerArrayCursor.Stats()
+}
+
+func (c *integerWindowStddevArrayCursor) Next() *cursors.FloatArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.IntegerArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.IntegerArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.FloatArray{}
+	}
+
+	rowIdx := 0
+	var n, mean, m2 float64
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
 	} else {
-		c.IntegerArrayCursor = IntegerEmptyArrayCursor
+		windowEnd = math.MaxInt64
 	}
+	windowHasPoints := false
 
-	return ok
-}
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					if n > 1 {
+						c.res.Values[pos] = math.Sqrt(m2 / (n - 1))
+					} else {
+						c.res.Values[pos] = math.NaN()
+					}
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
 
-type integerLimitArrayCursor struct {
-	cursors.IntegerArrayCursor
-	res  *cursors.IntegerArray
-	done bool
-}
+				// start the new window
+				n, mean, m2 = 0, 0, 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
 
-func newIntegerLimitArrayCursor(cur cursors.IntegerArrayCursor) *integerLimitArrayCursor {
-	return &integerLimitArrayCursor{
-		IntegerArrayCursor: cur,
-		res:                cursors.NewIntegerArrayLen(1),
-	}
-}
+				continue WINDOWS
+			} else {
+				n++
+				delta := float64(a.Values[rowIdx]) - mean
+				mean += delta / n
+				m2 += delta * (float64(a.Values[rowIdx]) - mean)
+				windowHasPoints = true
+			}
+		}
 
-func (c *integerLimitArrayCursor) Stats() cursors.CursorStats { return c.IntegerArrayCursor.Stats() }
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
 
-func (c *integerLimitArrayCursor) Next() *cursors.IntegerArray {
-	if c.done {
-		return &cursors.IntegerArray{}
-	}
-	a := c.IntegerArrayCursor.Next()
-	if len(a.Timestamps) == 0 {
-		return a
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				if n > 1 {
+					c.res.Values[pos] = math.Sqrt(m2 / (n - 1))
+				} else {
+					c.res.Values[pos] = math.NaN()
+				}
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
 	}
-	c.done = true
-	c.res.Timestamps[0] = a.Timestamps[0]
-	c.res.Values[0] = a.Values[0]
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
 	return c.res
 }
 
-type integerWindowLastArrayCursor struct {
+type integerWindowSpreadArrayCursor struct {
 	cursors.IntegerArrayCursor
-	windowEnd int64
-	res       *cursors.IntegerArray
-	tmp       *cursors.IntegerArray
-	window    interval.Window
+	res    *cursors.IntegerArray
+	tmp    *cursors.IntegerArray
+	window interval.Window
 }
 
-// Window array cursors assume that every != 0 && every != MaxInt64.
-// Such a cursor will panic in the first case and possibly overflow in the second.
-func newIntegerWindowLastArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowLastArrayCursor {
-	return &integerWindowLastArrayCursor{
+func newIntegerWindowSpreadArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowSpreadArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowSpreadArrayCursor{
 		IntegerArrayCursor: cur,
-		windowEnd:          math.MinInt64,
-		res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		res:                cursors.NewIntegerArrayLen(resLen),
 		tmp:                &cursors.IntegerArray{},
 		window:             window,
 	}
 }
 
-func (c *integerWindowLastArrayCursor) Stats() cursors.CursorStats {
+func (c *integerWindowSpreadArrayCursor) Stats() cursors.CursorStats {
 	return c.IntegerArrayCursor.Stats()
 }
 
-func (c *integerWindowLastArrayCursor) Next() *cursors.IntegerArray {
-	cur := -1
+func (c *integerWindowSpreadArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
-NEXT:
 	var a *cursors.IntegerArray
-
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
@@ -1289,65 +2655,118 @@ NEXT:
 	}
 
 	if a.Len() == 0 {
-		c.res.Timestamps = c.res.Timestamps[:cur+1]
-		c.res.Values = c.res.Values[:cur+1]
-		return c.res
+		return &cursors.IntegerArray{}
 	}
 
-	for i, t := range a.Timestamps {
-		if t >= c.windowEnd {
-			cur++
-		}
+	rowIdx := 0
+	var lo, hi int64
 
-		if cur == MaxPointsPerBlock {
-			c.tmp.Timestamps = a.Timestamps[i:]
-			c.tmp.Values = a.Values[i:]
-			return c.res
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = hi - lo
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				lo, hi = 0, 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				if !windowHasPoints || a.Values[rowIdx] < lo {
+					lo = a.Values[rowIdx]
+				}
+				if !windowHasPoints || a.Values[rowIdx] > hi {
+					hi = a.Values[rowIdx]
+				}
+				windowHasPoints = true
+			}
 		}
 
-		c.res.Timestamps[cur] = t
-		c.res.Values[cur] = a.Values[i]
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
 
-		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = hi - lo
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
 	}
 
-	c.tmp.Timestamps = nil
-	c.tmp.Values = nil
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
 
-	goto NEXT
+	return c.res
 }
 
-type integerWindowFirstArrayCursor struct {
+type integerWindowPercentileArrayCursor struct {
 	cursors.IntegerArrayCursor
-	windowEnd int64
-	res       *cursors.IntegerArray
-	tmp       *cursors.IntegerArray
-	window    interval.Window
+	res                   *cursors.FloatArray
+	tmp                   *cursors.IntegerArray
+	window                interval.Window
+	quantile, compression float64
 }
 
-// Window array cursors assume that every != 0 && every != MaxInt64.
-// Such a cursor will panic in the first case and possibly overflow in the second.
-func newIntegerWindowFirstArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowFirstArrayCursor {
-	return &integerWindowFirstArrayCursor{
+func newIntegerWindowPercentileArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window, quantile, compression float64) *integerWindowPercentileArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &integerWindowPercentileArrayCursor{
 		IntegerArrayCursor: cur,
-		windowEnd:          math.MinInt64,
-		res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		res:                cursors.NewFloatArrayLen(resLen),
 		tmp:                &cursors.IntegerArray{},
 		window:             window,
+		quantile:           quantile,
+		compression:        compression,
 	}
 }
 
-func (c *integerWindowFirstArrayCursor) Stats() cursors.CursorStats {
+func (c *integerWindowPercentileArrayCursor) Stats() cursors.CursorStats {
 	return c.IntegerArrayCursor.Stats()
 }
 
-func (c *integerWindowFirstArrayCursor) Next() *cursors.IntegerArray {
-	c.res.Timestamps = c.res.Timestamps[:0]
-	c.res.Values = c.res.Values[:0]
+func (c *integerWindowPercentileArrayCursor) Next() *cursors.FloatArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
-NEXT:
 	var a *cursors.IntegerArray
-
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
@@ -1355,45 +2774,93 @@ NEXT:
 	}
 
 	if a.Len() == 0 {
-		return c.res
+		return &cursors.FloatArray{}
 	}
 
-	for i, t := range a.Timestamps {
-		if t < c.windowEnd {
-			continue
-		}
+	rowIdx := 0
+	td := tdigest.NewWithCompression(c.compression)
 
-		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
 
-		c.res.Timestamps = append(c.res.Timestamps, t)
-		c.res.Values = append(c.res.Values, a.Values[i])
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = td.Quantile(c.quantile)
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				td.Reset()
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				td.Add(float64(a.Values[rowIdx]), 1)
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
 
-		if c.res.Len() == MaxPointsPerBlock {
-			c.tmp.Timestamps = a.Timestamps[i+1:]
-			c.tmp.Values = a.Values[i+1:]
-			return c.res
+		// get the next chunk
+		a = c.IntegerArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = td.Quantile(c.quantile)
+				pos++
+			}
+			break WINDOWS
 		}
+		rowIdx = 0
 	}
 
-	c.tmp.Timestamps = nil
-	c.tmp.Values = nil
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
 
-	goto NEXT
+	return c.res
 }
 
-type integerWindowCountArrayCursor struct {
+type integerWindowCountDistinctArrayCursor struct {
 	cursors.IntegerArrayCursor
 	res    *cursors.IntegerArray
 	tmp    *cursors.IntegerArray
 	window interval.Window
 }
 
-func newIntegerWindowCountArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowCountArrayCursor {
+func newIntegerWindowCountDistinctArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowCountDistinctArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &integerWindowCountArrayCursor{
+	return &integerWindowCountDistinctArrayCursor{
 		IntegerArrayCursor: cur,
 		res:                cursors.NewIntegerArrayLen(resLen),
 		tmp:                &cursors.IntegerArray{},
@@ -1401,11 +2868,11 @@ func newIntegerWindowCountArrayCursor(cur cursors.IntegerArrayCursor, window int
 	}
 }
 
-func (c *integerWindowCountArrayCursor) Stats() cursors.CursorStats {
+func (c *integerWindowCountDistinctArrayCursor) Stats() cursors.CursorStats {
 	return c.IntegerArrayCursor.Stats()
 }
 
-func (c *integerWindowCountArrayCursor) Next() *cursors.IntegerArray {
+func (c *integerWindowCountDistinctArrayCursor) Next() *cursors.IntegerArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
@@ -1422,7 +2889,7 @@ func (c *integerWindowCountArrayCursor) Next() *cursors.IntegerArray {
 	}
 
 	rowIdx := 0
-	var acc int64 = 0
+	distinct := make(map[int64]struct{})
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -1442,7 +2909,7 @@ WINDOWS:
 				// do not generate a point for empty windows
 				if windowHasPoints {
 					c.res.Timestamps[pos] = windowEnd
-					c.res.Values[pos] = acc
+					c.res.Values[pos] = int64(len(distinct))
 					pos++
 					if pos >= MaxPointsPerBlock {
 						// the output array is full,
@@ -1455,13 +2922,15 @@ WINDOWS:
 				}
 
 				// start the new window
-				acc = 0
+				for v := range distinct {
+					delete(distinct, v)
+				}
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				acc++
+				distinct[a.Values[rowIdx]] = struct{}{}
 				windowHasPoints = true
 			}
 		}
@@ -1478,7 +2947,7 @@ WINDOWS:
 			// do not generate a point for empty windows
 			if windowHasPoints {
 				c.res.Timestamps[pos] = windowEnd
-				c.res.Values[pos] = acc
+				c.res.Values[pos] = int64(len(distinct))
 				pos++
 			}
 			break WINDOWS
@@ -1492,266 +2961,355 @@ WINDOWS:
 	return c.res
 }
 
-type integerWindowSumArrayCursor struct {
-	cursors.IntegerArrayCursor
-	res    *cursors.IntegerArray
-	tmp    *cursors.IntegerArray
-	window interval.Window
+type integerEmptyArrayCursor struct {
+	res cursors.IntegerArray
 }
 
-func newIntegerWindowSumArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowSumArrayCursor {
-	resLen := MaxPointsPerBlock
-	if window.IsZero() {
-		resLen = 1
-	}
-	return &integerWindowSumArrayCursor{
-		IntegerArrayCursor: cur,
-		res:                cursors.NewIntegerArrayLen(resLen),
-		tmp:                &cursors.IntegerArray{},
-		window:             window,
+var IntegerEmptyArrayCursor cursors.IntegerArrayCursor = &integerEmptyArrayCursor{}
+
+func (c *integerEmptyArrayCursor) Err() error                  { return nil }
+func (c *integerEmptyArrayCursor) Close()                      {}
+func (c *integerEmptyArrayCursor) Stats() cursors.CursorStats  { return cursors.CursorStats{} }
+func (c *integerEmptyArrayCursor) Next() *cursors.IntegerArray { return &c.res }
+
+// ********************
+// Unsigned Array Cursor
+
+type unsignedArrayFilterCursor struct {
+	cursors.UnsignedArrayCursor
+	cond expression
+	m    *singleValue
+	res  *cursors.UnsignedArray
+	tmp  *cursors.UnsignedArray
+}
+
+func newUnsignedFilterArrayCursor(cond expression) *unsignedArrayFilterCursor {
+	return &unsignedArrayFilterCursor{
+		cond: cond,
+		m:    &singleValue{},
+		res:  cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+		tmp:  &cursors.UnsignedArray{},
 	}
 }
 
-func (c *integerWindowSumArrayCursor) Stats() cursors.CursorStats {
-	return c.IntegerArrayCursor.Stats()
+func (c *unsignedArrayFilterCursor) reset(cur cursors.UnsignedArrayCursor) {
+	c.UnsignedArrayCursor = cur
+	c.tmp.Timestamps, c.tmp.Values = nil, nil
 }
 
-func (c *integerWindowSumArrayCursor) Next() *cursors.IntegerArray {
+func (c *unsignedArrayFilterCursor) Stats() cursors.CursorStats { return c.UnsignedArrayCursor.Stats() }
+
+func (c *unsignedArrayFilterCursor) Next() *cursors.UnsignedArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
-	var a *cursors.IntegerArray
+	var a *cursors.UnsignedArray
+
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
-		a = c.IntegerArrayCursor.Next()
+		a = c.UnsignedArrayCursor.Next()
 	}
 
-	if a.Len() == 0 {
-		return &cursors.IntegerArray{}
+LOOP:
+	for len(a.Timestamps) > 0 {
+		for i, v := range a.Values {
+			c.m.v = v
+			if c.cond.EvalBool(c.m) {
+				c.res.Timestamps[pos] = a.Timestamps[i]
+				c.res.Values[pos] = v
+				pos++
+				if pos >= MaxPointsPerBlock {
+					c.tmp.Timestamps = a.Timestamps[i+1:]
+					c.tmp.Values = a.Values[i+1:]
+					break LOOP
+				}
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		a = c.UnsignedArrayCursor.Next()
 	}
 
-	rowIdx := 0
-	var acc int64 = 0
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
+type unsignedMultiShardArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	cursorContext
+	filter *unsignedArrayFilterCursor
+}
+
+func (c *unsignedMultiShardArrayCursor) reset(cur cursors.UnsignedArrayCursor, itrs cursors.CursorIterators, cond expression) {
+	if cond != nil {
+		if c.filter == nil {
+			c.filter = newUnsignedFilterArrayCursor(cond)
+		} else {
+			c.filter.cond = cond
+		}
+		c.filter.reset(cur)
+		cur = c.filter
+	}
+
+	c.UnsignedArrayCursor = cur
+	c.itrs = itrs
+	c.err = nil
+}
+
+func (c *unsignedMultiShardArrayCursor) Err() error { return c.err }
+
+func (c *unsignedMultiShardArrayCursor) Stats() cursors.CursorStats {
+	return c.UnsignedArrayCursor.Stats()
+}
+
+func (c *unsignedMultiShardArrayCursor) Next() *cursors.UnsignedArray {
+	for {
+		a := c.UnsignedArrayCursor.Next()
+		if a.Len() == 0 {
+			if c.nextArrayCursor() {
+				continue
+			}
+		}
+		return a
+	}
+}
+
+func (c *unsignedMultiShardArrayCursor) nextArrayCursor() bool {
+	if len(c.itrs) == 0 {
+		return false
+	}
+
+	c.UnsignedArrayCursor.Close()
+
+	var itr cursors.CursorIterator
+	var cur cursors.Cursor
+	for cur == nil && len(c.itrs) > 0 {
+		itr, c.itrs = c.itrs[0], c.itrs[1:]
+		cur, _ = itr.Next(c.ctx, c.req)
+	}
+
+	var ok bool
+	if cur != nil {
+		var next cursors.UnsignedArrayCursor
+		next, ok = cur.(cursors.UnsignedArrayCursor)
+		if !ok {
+			cur.Close()
+			next = UnsignedEmptyArrayCursor
+			c.err = errors.New("expected unsigned cursor")
+		} else {
+			if c.filter != nil {
+				c.filter.reset(next)
+				next = c.filter
+			}
+		}
+		c.UnsignedArrayCursor = next
+	} else {
+		c.UnsignedArrayCursor = UnsignedEmptyArrayCursor
+	}
+
+	return ok
+}
+
+type unsignedLimitArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	res  *cursors.UnsignedArray
+	done bool
+}
+
+func newUnsignedLimitArrayCursor(cur cursors.UnsignedArrayCursor) *unsignedLimitArrayCursor {
+	return &unsignedLimitArrayCursor{
+		UnsignedArrayCursor: cur,
+		res:                 cursors.NewUnsignedArrayLen(1),
+	}
+}
+
+func (c *unsignedLimitArrayCursor) Stats() cursors.CursorStats { return c.UnsignedArrayCursor.Stats() }
+
+func (c *unsignedLimitArrayCursor) Next() *cursors.UnsignedArray {
+	if c.done {
+		return &cursors.UnsignedArray{}
+	}
+	a := c.UnsignedArrayCursor.Next()
+	if len(a.Timestamps) == 0 {
+		return a
+	}
+	c.done = true
+	c.res.Timestamps[0] = a.Timestamps[0]
+	c.res.Values[0] = a.Values[0]
+	return c.res
+}
+
+type unsignedWindowLastArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	windowEnd int64
+	res       *cursors.UnsignedArray
+	tmp       *cursors.UnsignedArray
+	window    interval.Window
+}
+
+// Window array cursors assume that every != 0 && every != MaxInt64.
+// Such a cursor will panic in the first case and possibly overflow in the second.
+func newUnsignedWindowLastArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowLastArrayCursor {
+	return &unsignedWindowLastArrayCursor{
+		UnsignedArrayCursor: cur,
+		windowEnd:           math.MinInt64,
+		res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+		tmp:                 &cursors.UnsignedArray{},
+		window:              window,
+	}
+}
+
+func (c *unsignedWindowLastArrayCursor) Stats() cursors.CursorStats {
+	return c.UnsignedArrayCursor.Stats()
+}
+
+func (c *unsignedWindowLastArrayCursor) Next() *cursors.UnsignedArray {
+	cur := -1
+
+NEXT:
+	var a *cursors.UnsignedArray
 
-	var windowEnd int64
-	if !c.window.IsZero() {
-		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	if c.tmp.Len() > 0 {
+		a = c.tmp
 	} else {
-		windowEnd = math.MaxInt64
+		a = c.UnsignedArrayCursor.Next()
 	}
-	windowHasPoints := false
 
-	// enumerate windows
-WINDOWS:
-	for {
-		for ; rowIdx < a.Len(); rowIdx++ {
-			ts := a.Timestamps[rowIdx]
-			if !c.window.IsZero() && ts >= windowEnd {
-				// new window detected, close the current window
-				// do not generate a point for empty windows
-				if windowHasPoints {
-					c.res.Timestamps[pos] = windowEnd
-					c.res.Values[pos] = acc
-					pos++
-					if pos >= MaxPointsPerBlock {
-						// the output array is full,
-						// save the remaining points in the input array in tmp.
-						// they will be processed in the next call to Next()
-						c.tmp.Timestamps = a.Timestamps[rowIdx:]
-						c.tmp.Values = a.Values[rowIdx:]
-						break WINDOWS
-					}
-				}
+	if a.Len() == 0 {
+		c.res.Timestamps = c.res.Timestamps[:cur+1]
+		c.res.Values = c.res.Values[:cur+1]
+		return c.res
+	}
 
-				// start the new window
-				acc = 0
-				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
-				windowHasPoints = false
+	for i, t := range a.Timestamps {
+		if t >= c.windowEnd {
+			cur++
+		}
 
-				continue WINDOWS
-			} else {
-				acc += a.Values[rowIdx]
-				windowHasPoints = true
-			}
+		if cur == MaxPointsPerBlock {
+			c.tmp.Timestamps = a.Timestamps[i:]
+			c.tmp.Values = a.Values[i:]
+			return c.res
 		}
 
-		// Clear buffered timestamps & values if we make it through a cursor.
-		// The break above will skip this if a cursor is partially read.
-		c.tmp.Timestamps = nil
-		c.tmp.Values = nil
+		c.res.Timestamps[cur] = t
+		c.res.Values[cur] = a.Values[i]
 
-		// get the next chunk
-		a = c.IntegerArrayCursor.Next()
-		if a.Len() == 0 {
-			// write the final point
-			// do not generate a point for empty windows
-			if windowHasPoints {
-				c.res.Timestamps[pos] = windowEnd
-				c.res.Values[pos] = acc
-				pos++
-			}
-			break WINDOWS
-		}
-		rowIdx = 0
+		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
 	}
 
-	c.res.Timestamps = c.res.Timestamps[:pos]
-	c.res.Values = c.res.Values[:pos]
+	c.tmp.Timestamps = nil
+	c.tmp.Values = nil
 
-	return c.res
+	goto NEXT
 }
 
-type integerWindowMinArrayCursor struct {
-	cursors.IntegerArrayCursor
-	res    *cursors.IntegerArray
-	tmp    *cursors.IntegerArray
-	window interval.Window
+type unsignedWindowFirstArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	windowEnd int64
+	res       *cursors.UnsignedArray
+	tmp       *cursors.UnsignedArray
+	window    interval.Window
 }
 
-func newIntegerWindowMinArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowMinArrayCursor {
-	resLen := MaxPointsPerBlock
-	if window.IsZero() {
-		resLen = 1
-	}
-	return &integerWindowMinArrayCursor{
-		IntegerArrayCursor: cur,
-		res:                cursors.NewIntegerArrayLen(resLen),
-		tmp:                &cursors.IntegerArray{},
-		window:             window,
+// Window array cursors assume that every != 0 && every != MaxInt64.
+// Such a cursor will panic in the first case and possibly overflow in the second.
+func newUnsignedWindowFirstArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowFirstArrayCursor {
+	return &unsignedWindowFirstArrayCursor{
+		UnsignedArrayCursor: cur,
+		windowEnd:           math.MinInt64,
+		res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+		tmp:                 &cursors.UnsignedArray{},
+		window:              window,
 	}
 }
 
-func (c *integerWindowMinArrayCursor) Stats() cursors.CursorStats {
-	return c.IntegerArrayCursor.Stats()
+func (c *unsignedWindowFirstArrayCursor) Stats() cursors.CursorStats {
+	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *integerWindowMinArrayCursor) Next() *cursors.IntegerArray {
-	pos := 0
-	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
-	c.res.Values = c.res.Values[:cap(c.res.Values)]
+func (c *unsignedWindowFirstArrayCursor) Next() *cursors.UnsignedArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+NEXT:
+	var a *cursors.UnsignedArray
 
-	var a *cursors.IntegerArray
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
-		a = c.IntegerArrayCursor.Next()
+		a = c.UnsignedArrayCursor.Next()
 	}
 
 	if a.Len() == 0 {
-		return &cursors.IntegerArray{}
-	}
-
-	rowIdx := 0
-	var acc int64 = math.MaxInt64
-	var tsAcc int64
-
-	var windowEnd int64
-	if !c.window.IsZero() {
-		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
-	} else {
-		windowEnd = math.MaxInt64
+		return c.res
 	}
-	windowHasPoints := false
-
-	// enumerate windows
-WINDOWS:
-	for {
-		for ; rowIdx < a.Len(); rowIdx++ {
-			ts := a.Timestamps[rowIdx]
-			if !c.window.IsZero() && ts >= windowEnd {
-				// new window detected, close the current window
-				// do not generate a point for empty windows
-				if windowHasPoints {
-					c.res.Timestamps[pos] = tsAcc
-					c.res.Values[pos] = acc
-					pos++
-					if pos >= MaxPointsPerBlock {
-						// the output array is full,
-						// save the remaining points in the input array in tmp.
-						// they will be processed in the next call to Next()
-						c.tmp.Timestamps = a.Timestamps[rowIdx:]
-						c.tmp.Values = a.Values[rowIdx:]
-						break WINDOWS
-					}
-				}
 
-				// start the new window
-				acc = math.MaxInt64
-				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
-				windowHasPoints = false
-
-				continue WINDOWS
-			} else {
-				if !windowHasPoints || a.Values[rowIdx] < acc {
-					acc = a.Values[rowIdx]
-					tsAcc = a.Timestamps[rowIdx]
-				}
-				windowHasPoints = true
-			}
+	for i, t := range a.Timestamps {
+		if t < c.windowEnd {
+			continue
 		}
 
-		// Clear buffered timestamps & values if we make it through a cursor.
-		// The break above will skip this if a cursor is partially read.
-		c.tmp.Timestamps = nil
-		c.tmp.Values = nil
+		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
 
-		// get the next chunk
-		a = c.IntegerArrayCursor.Next()
-		if a.Len() == 0 {
-			// write the final point
-			// do not generate a point for empty windows
-			if windowHasPoints {
-				c.res.Timestamps[pos] = tsAcc
-				c.res.Values[pos] = acc
-				pos++
-			}
-			break WINDOWS
+		c.res.Timestamps = append(c.res.Timestamps, t)
+		c.res.Values = append(c.res.Values, a.Values[i])
+
+		if c.res.Len() == MaxPointsPerBlock {
+			c.tmp.Timestamps = a.Timestamps[i+1:]
+			c.tmp.Values = a.Values[i+1:]
+			return c.res
 		}
-		rowIdx = 0
 	}
 
-	c.res.Timestamps = c.res.Timestamps[:pos]
-	c.res.Values = c.res.Values[:pos]
+	c.tmp.Timestamps = nil
+	c.tmp.Values = nil
 
-	return c.res
+	goto NEXT
 }
 
-type integerWindowMaxArrayCursor struct {
-	cursors.IntegerArrayCursor
+type unsignedWindowCountArrayCursor struct {
+	cursors.UnsignedArrayCursor
 	res    *cursors.IntegerArray
-	tmp    *cursors.IntegerArray
+	tmp    *cursors.UnsignedArray
 	window interval.Window
 }
 
-func newIntegerWindowMaxArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowMaxArrayCursor {
+func newUnsignedWindowCountArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowCountArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &integerWindowMaxArrayCursor{
-		IntegerArrayCursor: cur,
-		res:                cursors.NewIntegerArrayLen(resLen),
-		tmp:                &cursors.IntegerArray{},
-		window:             window,
+	return &unsignedWindowCountArrayCursor{
+		UnsignedArrayCursor: cur,
+		res:                 cursors.NewIntegerArrayLen(resLen),
+		tmp:                 &cursors.UnsignedArray{},
+		window:              window,
 	}
 }
 
-func (c *integerWindowMaxArrayCursor) Stats() cursors.CursorStats {
-	return c.IntegerArrayCursor.Stats()
+func (c *unsignedWindowCountArrayCursor) Stats() cursors.CursorStats {
+	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *integerWindowMaxArrayCursor) Next() *cursors.IntegerArray {
+func (c *unsignedWindowCountArrayCursor) Next() *cursors.IntegerArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
-	var a *cursors.IntegerArray
+	var a *cursors.UnsignedArray
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
-		a = c.IntegerArrayCursor.Next()
+		a = c.UnsignedArrayCursor.Next()
 	}
 
 	if a.Len() == 0 {
@@ -1759,8 +3317,7 @@ func (c *integerWindowMaxArrayCursor) Next() *cursors.IntegerArray {
 	}
 
 	rowIdx := 0
-	var acc int64 = math.MinInt64
-	var tsAcc int64
+	var acc int64 = 0
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -1779,7 +3336,7 @@ WINDOWS:
 				// new window detected, close the current window
 				// do not generate a point for empty windows
 				if windowHasPoints {
-					c.res.Timestamps[pos] = tsAcc
+					c.res.Timestamps[pos] = windowEnd
 					c.res.Values[pos] = acc
 					pos++
 					if pos >= MaxPointsPerBlock {
@@ -1793,16 +3350,13 @@ WINDOWS:
 				}
 
 				// start the new window
-				acc = math.MinInt64
+				acc = 0
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] > acc {
-					acc = a.Values[rowIdx]
-					tsAcc = a.Timestamps[rowIdx]
-				}
+				acc++
 				windowHasPoints = true
 			}
 		}
@@ -1813,12 +3367,12 @@ WINDOWS:
 		c.tmp.Values = nil
 
 		// get the next chunk
-		a = c.IntegerArrayCursor.Next()
+		a = c.UnsignedArrayCursor.Next()
 		if a.Len() == 0 {
 			// write the final point
 			// do not generate a point for empty windows
 			if windowHasPoints {
-				c.res.Timestamps[pos] = tsAcc
+				c.res.Timestamps[pos] = windowEnd
 				c.res.Values[pos] = acc
 				pos++
 			}
@@ -1831,51 +3385,50 @@ WINDOWS:
 	c.res.Values = c.res.Values[:pos]
 
 	return c.res
-}
-
-type integerWindowMeanArrayCursor struct {
-	cursors.IntegerArrayCursor
-	res    *cursors.FloatArray
-	tmp    *cursors.IntegerArray
+}
+
+type unsignedWindowSumArrayCursor struct {
+	cursors.UnsignedArrayCursor
+	res    *cursors.UnsignedArray
+	tmp    *cursors.UnsignedArray
 	window interval.Window
 }
 
-func newIntegerWindowMeanArrayCursor(cur cursors.IntegerArrayCursor, window interval.Window) *integerWindowMeanArrayCursor {
+func newUnsignedWindowSumArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowSumArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &integerWindowMeanArrayCursor{
-		IntegerArrayCursor: cur,
-		res:                cursors.NewFloatArrayLen(resLen),
-		tmp:                &cursors.IntegerArray{},
-		window:             window,
+	return &unsignedWindowSumArrayCursor{
+		UnsignedArrayCursor: cur,
+		res:                 cursors.NewUnsignedArrayLen(resLen),
+		tmp:                 &cursors.UnsignedArray{},
+		window:              window,
 	}
 }
 
-func (c *integerWindowMeanArrayCursor) Stats() cursors.CursorStats {
-	return c.IntegerArrayCursor.Stats()
+func (c *unsignedWindowSumArrayCursor) Stats() cursors.CursorStats {
+	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *integerWindowMeanArrayCursor) Next() *cursors.FloatArray {
+func (c *unsignedWindowSumArrayCursor) Next() *cursors.UnsignedArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
-	var a *cursors.IntegerArray
+	var a *cursors.UnsignedArray
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
-		a = c.IntegerArrayCursor.Next()
+		a = c.UnsignedArrayCursor.Next()
 	}
 
 	if a.Len() == 0 {
-		return &cursors.FloatArray{}
+		return &cursors.UnsignedArray{}
 	}
 
 	rowIdx := 0
-	var sum int64
-	var count int64
+	var acc uint64 = 0
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -1895,7 +3448,7 @@ WINDOWS:
 				// do not generate a point for empty windows
 				if windowHasPoints {
 					c.res.Timestamps[pos] = windowEnd
-					c.res.Values[pos] = float64(sum) / float64(count)
+					c.res.Values[pos] = acc
 					pos++
 					if pos >= MaxPointsPerBlock {
 						// the output array is full,
@@ -1908,15 +3461,13 @@ WINDOWS:
 				}
 
 				// start the new window
-				sum = 0
-				count = 0
+				acc = 0
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				sum += a.Values[rowIdx]
-				count++
+				acc += a.Values[rowIdx]
 				windowHasPoints = true
 			}
 		}
@@ -1927,13 +3478,13 @@ WINDOWS:
 		c.tmp.Values = nil
 
 		// get the next chunk
-		a = c.IntegerArrayCursor.Next()
+		a = c.UnsignedArrayCursor.Next()
 		if a.Len() == 0 {
 			// write the final point
 			// do not generate a point for empty windows
 			if windowHasPoints {
 				c.res.Timestamps[pos] = windowEnd
-				c.res.Values[pos] = float64(sum) / float64(count)
+				c.res.Values[pos] = acc
 				pos++
 			}
 			break WINDOWS
@@ -1947,288 +3498,151 @@ WINDOWS:
 	return c.res
 }
 
-type integerEmptyArrayCursor struct {
-	res cursors.IntegerArray
-}
-
-var IntegerEmptyArrayCursor cursors.IntegerArrayCursor = &integerEmptyArrayCursor{}
-
-func (c *integerEmptyArrayCursor) Err() error                  { return nil }
-func (c *integerEmptyArrayCursor) Close()                      {}
-func (c *integerEmptyArrayCursor) Stats() cursors.CursorStats  { return cursors.CursorStats{} }
-func (c *integerEmptyArrayCursor) Next() *cursors.IntegerArray { return &c.res }
-
-// ********************
-// Unsigned Array Cursor
-
-type unsignedArrayFilterCursor struct {
+type unsignedWindowMinArrayCursor struct {
 	cursors.UnsignedArrayCursor
-	cond expression
-	m    *singleValue
-	res  *cursors.UnsignedArray
-	tmp  *cursors.UnsignedArray
+	res    *cursors.UnsignedArray
+	tmp    *cursors.UnsignedArray
+	window interval.Window
 }
 
-func newUnsignedFilterArrayCursor(cond expression) *unsignedArrayFilterCursor {
-	return &unsignedArrayFilterCursor{
-		cond: cond,
-		m:    &singleValue{},
-		res:  cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
-		tmp:  &cursors.UnsignedArray{},
+func newUnsignedWindowMinArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowMinArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &unsignedWindowMinArrayCursor{
+		UnsignedArrayCursor: cur,
+		res:                 cursors.NewUnsignedArrayLen(resLen),
+		tmp:                 &cursors.UnsignedArray{},
+		window:              window,
 	}
 }
 
-func (c *unsignedArrayFilterCursor) reset(cur cursors.UnsignedArrayCursor) {
-	c.UnsignedArrayCursor = cur
-	c.tmp.Timestamps, c.tmp.Values = nil, nil
+func (c *unsignedWindowMinArrayCursor) Stats() cursors.CursorStats {
+	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *unsignedArrayFilterCursor) Stats() cursors.CursorStats { return c.UnsignedArrayCursor.Stats() }
-
-func (c *unsignedArrayFilterCursor) Next() *cursors.UnsignedArray {
+func (c *unsignedWindowMinArrayCursor) Next() *cursors.UnsignedArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
 	var a *cursors.UnsignedArray
-
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
 		a = c.UnsignedArrayCursor.Next()
 	}
 
-LOOP:
-	for len(a.Timestamps) > 0 {
-		for i, v := range a.Values {
-			c.m.v = v
-			if c.cond.EvalBool(c.m) {
-				c.res.Timestamps[pos] = a.Timestamps[i]
-				c.res.Values[pos] = v
-				pos++
-				if pos >= MaxPointsPerBlock {
-					c.tmp.Timestamps = a.Timestamps[i+1:]
-					c.tmp.Values = a.Values[i+1:]
-					break LOOP
-				}
-			}
-		}
-
-		// Clear buffered timestamps & values if we make it through a cursor.
-		// The break above will skip this if a cursor is partially read.
-		c.tmp.Timestamps = nil
-		c.tmp.Values = nil
-
-		a = c.UnsignedArrayCursor.Next()
-	}
-
-	c.res.Timestamps = c.res.Timestamps[:pos]
-	c.res.Values = c.res.Values[:pos]
-
-	return c.res
-}
-
-type unsignedMultiShardArrayCursor struct {
-	cursors.UnsignedArrayCursor
-	cursorContext
-	filter *unsignedArrayFilterCursor
-}
-
-func (c *unsignedMultiShardArrayCursor) reset(cur cursors.UnsignedArrayCursor, itrs cursors.CursorIterators, cond expression) {
-	if cond != nil {
-		if c.filter == nil {
-			c.filter = newUnsignedFilterArrayCursor(cond)
-		} else {
-			c.filter.cond = cond
-		}
-		c.filter.reset(cur)
-		cur = c.filter
-	}
-
-	c.UnsignedArrayCursor = cur
-	c.itrs = itrs
-	c.err = nil
-}
-
-func (c *unsignedMultiShardArrayCursor) Err() error { return c.err }
-
-func (c *unsignedMultiShardArrayCursor) Stats() cursors.CursorStats {
-	return c.UnsignedArrayCursor.Stats()
-}
-
-func (c *unsignedMultiShardArrayCursor) Next() *cursors.UnsignedArray {
-	for {
-		a := c.UnsignedArrayCursor.Next()
-		if a.Len() == 0 {
-			if c.nextArrayCursor() {
-				continue
-			}
-		}
-		return a
-	}
-}
-
-func (c *unsignedMultiShardArrayCursor) nextArrayCursor() bool {
-	if len(c.itrs) == 0 {
-		return false
-	}
-
-	c.UnsignedArrayCursor.Close()
-
-	var itr cursors.CursorIterator
-	var cur cursors.Cursor
-	for cur == nil && len(c.itrs) > 0 {
-		itr, c.itrs = c.itrs[0], c.itrs[1:]
-		cur, _ = itr.Next(c.ctx, c.req)
-	}
-
-	var ok bool
-	if cur != nil {
-		var next cursors.UnsignedArrayCursor
-		next, ok = cur.(cursors.UnsignedArrayCursor)
-		if !ok {
-			cur.Close()
-			next = UnsignedEmptyArrayCursor
-			c.err = errors.New("expected unsigned cursor")
-		} else {
-			if c.filter != nil {
-				c.filter.reset(next)
-				next = c.filter
-			}
-		}
-		c.UnsignedArrayCursor = next
-	} else {
-		c.UnsignedArrayCursor = UnsignedEmptyArrayCursor
-	}
-
-	return ok
-}
-
-type unsignedLimitArrayCursor struct {
-	cursors.UnsignedArrayCursor
-	res  *cursors.UnsignedArray
-	done bool
-}
-
-func newUnsignedLimitArrayCursor(cur cursors.UnsignedArrayCursor) *unsignedLimitArrayCursor {
-	return &unsignedLimitArrayCursor{
-		UnsignedArrayCursor: cur,
-		res:                 cursors.NewUnsignedArrayLen(1),
-	}
-}
-
-func (c *unsignedLimitArrayCursor) Stats() cursors.CursorStats { return c.UnsignedArrayCursor.Stats() }
-
-func (c *unsignedLimitArrayCursor) Next() *cursors.UnsignedArray {
-	if c.done {
+	if a.Len() == 0 {
 		return &cursors.UnsignedArray{}
 	}
-	a := c.UnsignedArrayCursor.Next()
-	if len(a.Timestamps) == 0 {
-		return a
-	}
-	c.done = true
-	c.res.Timestamps[0] = a.Timestamps[0]
-	c.res.Values[0] = a.Values[0]
-	return c.res
-}
-
-type unsignedWindowLastArrayCursor struct {
-	cursors.UnsignedArrayCursor
-	windowEnd int64
-	res       *cursors.UnsignedArray
-	tmp       *cursors.UnsignedArray
-	window    interval.Window
-}
-
-// Window array cursors assume that every != 0 && every != MaxInt64.
-// Such a cursor will panic in the first case and possibly overflow in the second.
-func newUnsignedWindowLastArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowLastArrayCursor {
-	return &unsignedWindowLastArrayCursor{
-		UnsignedArrayCursor: cur,
-		windowEnd:           math.MinInt64,
-		res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
-		tmp:                 &cursors.UnsignedArray{},
-		window:              window,
-	}
-}
-
-func (c *unsignedWindowLastArrayCursor) Stats() cursors.CursorStats {
-	return c.UnsignedArrayCursor.Stats()
-}
-
-func (c *unsignedWindowLastArrayCursor) Next() *cursors.UnsignedArray {
-	cur := -1
 
-NEXT:
-	var a *cursors.UnsignedArray
+	rowIdx := 0
+	var acc uint64 = math.MaxUint64
+	var tsAcc int64
 
-	if c.tmp.Len() > 0 {
-		a = c.tmp
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
 	} else {
-		a = c.UnsignedArrayCursor.Next()
+		windowEnd = math.MaxInt64
 	}
+	windowHasPoints := false
 
-	if a.Len() == 0 {
-		c.res.Timestamps = c.res.Timestamps[:cur+1]
-		c.res.Values = c.res.Values[:cur+1]
-		return c.res
-	}
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = tsAcc
+					c.res.Values[pos] = acc
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
 
-	for i, t := range a.Timestamps {
-		if t >= c.windowEnd {
-			cur++
-		}
+				// start the new window
+				acc = math.MaxUint64
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
 
-		if cur == MaxPointsPerBlock {
-			c.tmp.Timestamps = a.Timestamps[i:]
-			c.tmp.Values = a.Values[i:]
-			return c.res
+				continue WINDOWS
+			} else {
+				if !windowHasPoints || a.Values[rowIdx] < acc {
+					acc = a.Values[rowIdx]
+					tsAcc = a.Timestamps[rowIdx]
+				}
+				windowHasPoints = true
+			}
 		}
 
-		c.res.Timestamps[cur] = t
-		c.res.Values[cur] = a.Values[i]
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
 
-		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
+		// get the next chunk
+		a = c.UnsignedArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = tsAcc
+				c.res.Values[pos] = acc
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
 	}
 
-	c.tmp.Timestamps = nil
-	c.tmp.Values = nil
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
 
-	goto NEXT
+	return c.res
 }
 
-type unsignedWindowFirstArrayCursor struct {
+type unsignedWindowMaxArrayCursor struct {
 	cursors.UnsignedArrayCursor
-	windowEnd int64
-	res       *cursors.UnsignedArray
-	tmp       *cursors.UnsignedArray
-	window    interval.Window
+	res    *cursors.UnsignedArray
+	tmp    *cursors.UnsignedArray
+	window interval.Window
 }
 
-// Window array cursors assume that every != 0 && every != MaxInt64.
-// Such a cursor will panic in the first case and possibly overflow in the second.
-func newUnsignedWindowFirstArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowFirstArrayCursor {
-	return &unsignedWindowFirstArrayCursor{
+func newUnsignedWindowMaxArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowMaxArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &unsignedWindowMaxArrayCursor{
 		UnsignedArrayCursor: cur,
-		windowEnd:           math.MinInt64,
-		res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+		res:                 cursors.NewUnsignedArrayLen(resLen),
 		tmp:                 &cursors.UnsignedArray{},
 		window:              window,
 	}
 }
 
-func (c *unsignedWindowFirstArrayCursor) Stats() cursors.CursorStats {
+func (c *unsignedWindowMaxArrayCursor) Stats() cursors.CursorStats {
 	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *unsignedWindowFirstArrayCursor) Next() *cursors.UnsignedArray {
-	c.res.Timestamps = c.res.Timestamps[:0]
-	c.res.Values = c.res.Values[:0]
+func (c *unsignedWindowMaxArrayCursor) Next() *cursors.UnsignedArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
 
-NEXT:
 	var a *cursors.UnsignedArray
-
 	if c.tmp.Len() > 0 {
 		a = c.tmp
 	} else {
@@ -2236,57 +3650,109 @@ NEXT:
 	}
 
 	if a.Len() == 0 {
-		return c.res
+		return &cursors.UnsignedArray{}
 	}
 
-	for i, t := range a.Timestamps {
-		if t < c.windowEnd {
-			continue
-		}
+	rowIdx := 0
+	var acc uint64 = 0
+	var tsAcc int64
 
-		c.windowEnd = int64(c.window.GetLatestBounds(values.Time(t)).Stop())
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
 
-		c.res.Timestamps = append(c.res.Timestamps, t)
-		c.res.Values = append(c.res.Values, a.Values[i])
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = tsAcc
+					c.res.Values[pos] = acc
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
 
-		if c.res.Len() == MaxPointsPerBlock {
-			c.tmp.Timestamps = a.Timestamps[i+1:]
-			c.tmp.Values = a.Values[i+1:]
-			return c.res
+				// start the new window
+				acc = 0
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				if !windowHasPoints || a.Values[rowIdx] > acc {
+					acc = a.Values[rowIdx]
+					tsAcc = a.Timestamps[rowIdx]
+				}
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.UnsignedArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = tsAcc
+				c.res.Values[pos] = acc
+				pos++
+			}
+			break WINDOWS
 		}
+		rowIdx = 0
 	}
 
-	c.tmp.Timestamps = nil
-	c.tmp.Values = nil
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
 
-	goto NEXT
+	return c.res
 }
 
-type unsignedWindowCountArrayCursor struct {
+type unsignedWindowMeanArrayCursor struct {
 	cursors.UnsignedArrayCursor
-	res    *cursors.IntegerArray
+	res    *cursors.FloatArray
 	tmp    *cursors.UnsignedArray
 	window interval.Window
 }
 
-func newUnsignedWindowCountArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowCountArrayCursor {
+func newUnsignedWindowMeanArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowMeanArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &unsignedWindowCountArrayCursor{
+	return &unsignedWindowMeanArrayCursor{
 		UnsignedArrayCursor: cur,
-		res:                 cursors.NewIntegerArrayLen(resLen),
+		res:                 cursors.NewFloatArrayLen(resLen),
 		tmp:                 &cursors.UnsignedArray{},
 		window:              window,
 	}
 }
 
-func (c *unsignedWindowCountArrayCursor) Stats() cursors.CursorStats {
+func (c *unsignedWindowMeanArrayCursor) Stats() cursors.CursorStats {
 	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *unsignedWindowCountArrayCursor) Next() *cursors.IntegerArray {
+func (c *unsignedWindowMeanArrayCursor) Next() *cursors.FloatArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
@@ -2299,11 +3765,12 @@ func (c *unsignedWindowCountArrayCursor) Next() *cursors.IntegerArray {
 	}
 
 	if a.Len() == 0 {
-		return &cursors.IntegerArray{}
+		return &cursors.FloatArray{}
 	}
 
 	rowIdx := 0
-	var acc int64 = 0
+	var sum uint64
+	var count int64
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -2323,7 +3790,7 @@ WINDOWS:
 				// do not generate a point for empty windows
 				if windowHasPoints {
 					c.res.Timestamps[pos] = windowEnd
-					c.res.Values[pos] = acc
+					c.res.Values[pos] = float64(sum) / float64(count)
 					pos++
 					if pos >= MaxPointsPerBlock {
 						// the output array is full,
@@ -2336,13 +3803,15 @@ WINDOWS:
 				}
 
 				// start the new window
-				acc = 0
+				sum = 0
+				count = 0
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				acc++
+				sum += a.Values[rowIdx]
+				count++
 				windowHasPoints = true
 			}
 		}
@@ -2359,7 +3828,7 @@ WINDOWS:
 			// do not generate a point for empty windows
 			if windowHasPoints {
 				c.res.Timestamps[pos] = windowEnd
-				c.res.Values[pos] = acc
+				c.res.Values[pos] = float64(sum) / float64(count)
 				pos++
 			}
 			break WINDOWS
@@ -2373,31 +3842,31 @@ WINDOWS:
 	return c.res
 }
 
-type unsignedWindowSumArrayCursor struct {
+type unsignedWindowStddevArrayCursor struct {
 	cursors.UnsignedArrayCursor
-	res    *cursors.UnsignedArray
+	res    *cursors.FloatArray
 	tmp    *cursors.UnsignedArray
 	window interval.Window
 }
 
-func newUnsignedWindowSumArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowSumArrayCursor {
+func newUnsignedWindowStddevArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowStddevArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &unsignedWindowSumArrayCursor{
+	return &unsignedWindowStddevArrayCursor{
 		UnsignedArrayCursor: cur,
-		res:                 cursors.NewUnsignedArrayLen(resLen),
+		res:                 cursors.NewFloatArrayLen(resLen),
 		tmp:                 &cursors.UnsignedArray{},
 		window:              window,
 	}
 }
 
-func (c *unsignedWindowSumArrayCursor) Stats() cursors.CursorStats {
+func (c *unsignedWindowStddevArrayCursor) Stats() cursors.CursorStats {
 	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *unsignedWindowSumArrayCursor) Next() *cursors.UnsignedArray {
+func (c *unsignedWindowStddevArrayCursor) Next() *cursors.FloatArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
@@ -2410,11 +3879,11 @@ func (c *unsignedWindowSumArrayCursor) Next() *cursors.UnsignedArray {
 	}
 
 	if a.Len() == 0 {
-		return &cursors.UnsignedArray{}
+		return &cursors.FloatArray{}
 	}
 
 	rowIdx := 0
-	var acc uint64 = 0
+	var n, mean, m2 float64
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -2434,7 +3903,11 @@ WINDOWS:
 				// do not generate a point for empty windows
 				if windowHasPoints {
 					c.res.Timestamps[pos] = windowEnd
-					c.res.Values[pos] = acc
+					if n > 1 {
+						c.res.Values[pos] = math.Sqrt(m2 / (n - 1))
+					} else {
+						c.res.Values[pos] = math.NaN()
+					}
 					pos++
 					if pos >= MaxPointsPerBlock {
 						// the output array is full,
@@ -2447,13 +3920,16 @@ WINDOWS:
 				}
 
 				// start the new window
-				acc = 0
+				n, mean, m2 = 0, 0, 0
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				acc += a.Values[rowIdx]
+				n++
+				delta := float64(a.Values[rowIdx]) - mean
+				mean += delta / n
+				m2 += delta * (float64(a.Values[rowIdx]) - mean)
 				windowHasPoints = true
 			}
 		}
@@ -2470,7 +3946,11 @@ WINDOWS:
 			// do not generate a point for empty windows
 			if windowHasPoints {
 				c.res.Timestamps[pos] = windowEnd
-				c.res.Values[pos] = acc
+				if n > 1 {
+					c.res.Values[pos] = math.Sqrt(m2 / (n - 1))
+				} else {
+					c.res.Values[pos] = math.NaN()
+				}
 				pos++
 			}
 			break WINDOWS
@@ -2484,19 +3964,19 @@ WINDOWS:
 	return c.res
 }
 
-type unsignedWindowMinArrayCursor struct {
+type unsignedWindowSpreadArrayCursor struct {
 	cursors.UnsignedArrayCursor
 	res    *cursors.UnsignedArray
 	tmp    *cursors.UnsignedArray
 	window interval.Window
 }
 
-func newUnsignedWindowMinArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowMinArrayCursor {
+func newUnsignedWindowSpreadArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowSpreadArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &unsignedWindowMinArrayCursor{
+	return &unsignedWindowSpreadArrayCursor{
 		UnsignedArrayCursor: cur,
 		res:                 cursors.NewUnsignedArrayLen(resLen),
 		tmp:                 &cursors.UnsignedArray{},
@@ -2504,11 +3984,11 @@ func newUnsignedWindowMinArrayCursor(cur cursors.UnsignedArrayCursor, window int
 	}
 }
 
-func (c *unsignedWindowMinArrayCursor) Stats() cursors.CursorStats {
+func (c *unsignedWindowSpreadArrayCursor) Stats() cursors.CursorStats {
 	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *unsignedWindowMinArrayCursor) Next() *cursors.UnsignedArray {
+func (c *unsignedWindowSpreadArrayCursor) Next() *cursors.UnsignedArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
@@ -2525,8 +4005,7 @@ func (c *unsignedWindowMinArrayCursor) Next() *cursors.UnsignedArray {
 	}
 
 	rowIdx := 0
-	var acc uint64 = math.MaxUint64
-	var tsAcc int64
+	var lo, hi uint64
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -2545,8 +4024,8 @@ WINDOWS:
 				// new window detected, close the current window
 				// do not generate a point for empty windows
 				if windowHasPoints {
-					c.res.Timestamps[pos] = tsAcc
-					c.res.Values[pos] = acc
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = hi - lo
 					pos++
 					if pos >= MaxPointsPerBlock {
 						// the output array is full,
@@ -2559,15 +4038,17 @@ WINDOWS:
 				}
 
 				// start the new window
-				acc = math.MaxUint64
+				lo, hi = 0, 0
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] < acc {
-					acc = a.Values[rowIdx]
-					tsAcc = a.Timestamps[rowIdx]
+				if !windowHasPoints || a.Values[rowIdx] < lo {
+					lo = a.Values[rowIdx]
+				}
+				if !windowHasPoints || a.Values[rowIdx] > hi {
+					hi = a.Values[rowIdx]
 				}
 				windowHasPoints = true
 			}
@@ -2584,8 +4065,8 @@ WINDOWS:
 			// write the final point
 			// do not generate a point for empty windows
 			if windowHasPoints {
-				c.res.Timestamps[pos] = tsAcc
-				c.res.Values[pos] = acc
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = hi - lo
 				pos++
 			}
 			break WINDOWS
@@ -2599,31 +4080,34 @@ WINDOWS:
 	return c.res
 }
 
-type unsignedWindowMaxArrayCursor struct {
+type unsignedWindowPercentileArrayCursor struct {
 	cursors.UnsignedArrayCursor
-	res    *cursors.UnsignedArray
-	tmp    *cursors.UnsignedArray
-	window interval.Window
+	res                   *cursors.FloatArray
+	tmp                   *cursors.UnsignedArray
+	window                interval.Window
+	quantile, compression float64
 }
 
-func newUnsignedWindowMaxArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowMaxArrayCursor {
+func newUnsignedWindowPercentileArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window, quantile, compression float64) *unsignedWindowPercentileArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &unsignedWindowMaxArrayCursor{
+	return &unsignedWindowPercentileArrayCursor{
 		UnsignedArrayCursor: cur,
-		res:                 cursors.NewUnsignedArrayLen(resLen),
+		res:                 cursors.NewFloatArrayLen(resLen),
 		tmp:                 &cursors.UnsignedArray{},
 		window:              window,
+		quantile:            quantile,
+		compression:         compression,
 	}
 }
 
-func (c *unsignedWindowMaxArrayCursor) Stats() cursors.CursorStats {
+func (c *unsignedWindowPercentileArrayCursor) Stats() cursors.CursorStats {
 	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *unsignedWindowMaxArrayCursor) Next() *cursors.UnsignedArray {
+func (c *unsignedWindowPercentileArrayCursor) Next() *cursors.FloatArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
@@ -2636,12 +4120,11 @@ func (c *unsignedWindowMaxArrayCursor) Next() *cursors.UnsignedArray {
 	}
 
 	if a.Len() == 0 {
-		return &cursors.UnsignedArray{}
+		return &cursors.FloatArray{}
 	}
 
 	rowIdx := 0
-	var acc uint64 = 0
-	var tsAcc int64
+	td := tdigest.NewWithCompression(c.compression)
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -2660,8 +4143,8 @@ WINDOWS:
 				// new window detected, close the current window
 				// do not generate a point for empty windows
 				if windowHasPoints {
-					c.res.Timestamps[pos] = tsAcc
-					c.res.Values[pos] = acc
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = td.Quantile(c.quantile)
 					pos++
 					if pos >= MaxPointsPerBlock {
 						// the output array is full,
@@ -2674,16 +4157,13 @@ WINDOWS:
 				}
 
 				// start the new window
-				acc = 0
+				td.Reset()
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				if !windowHasPoints || a.Values[rowIdx] > acc {
-					acc = a.Values[rowIdx]
-					tsAcc = a.Timestamps[rowIdx]
-				}
+				td.Add(float64(a.Values[rowIdx]), 1)
 				windowHasPoints = true
 			}
 		}
@@ -2699,8 +4179,8 @@ WINDOWS:
 			// write the final point
 			// do not generate a point for empty windows
 			if windowHasPoints {
-				c.res.Timestamps[pos] = tsAcc
-				c.res.Values[pos] = acc
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = td.Quantile(c.quantile)
 				pos++
 			}
 			break WINDOWS
@@ -2714,31 +4194,31 @@ WINDOWS:
 	return c.res
 }
 
-type unsignedWindowMeanArrayCursor struct {
+type unsignedWindowCountDistinctArrayCursor struct {
 	cursors.UnsignedArrayCursor
-	res    *cursors.FloatArray
+	res    *cursors.IntegerArray
 	tmp    *cursors.UnsignedArray
 	window interval.Window
 }
 
-func newUnsignedWindowMeanArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowMeanArrayCursor {
+func newUnsignedWindowCountDistinctArrayCursor(cur cursors.UnsignedArrayCursor, window interval.Window) *unsignedWindowCountDistinctArrayCursor {
 	resLen := MaxPointsPerBlock
 	if window.IsZero() {
 		resLen = 1
 	}
-	return &unsignedWindowMeanArrayCursor{
+	return &unsignedWindowCountDistinctArrayCursor{
 		UnsignedArrayCursor: cur,
-		res:                 cursors.NewFloatArrayLen(resLen),
+		res:                 cursors.NewIntegerArrayLen(resLen),
 		tmp:                 &cursors.UnsignedArray{},
 		window:              window,
 	}
 }
 
-func (c *unsignedWindowMeanArrayCursor) Stats() cursors.CursorStats {
+func (c *unsignedWindowCountDistinctArrayCursor) Stats() cursors.CursorStats {
 	return c.UnsignedArrayCursor.Stats()
 }
 
-func (c *unsignedWindowMeanArrayCursor) Next() *cursors.FloatArray {
+func (c *unsignedWindowCountDistinctArrayCursor) Next() *cursors.IntegerArray {
 	pos := 0
 	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
 	c.res.Values = c.res.Values[:cap(c.res.Values)]
@@ -2751,12 +4231,11 @@ func (c *unsignedWindowMeanArrayCursor) Next() *cursors.FloatArray {
 	}
 
 	if a.Len() == 0 {
-		return &cursors.FloatArray{}
+		return &cursors.IntegerArray{}
 	}
 
 	rowIdx := 0
-	var sum uint64
-	var count int64
+	distinct := make(map[uint64]struct{})
 
 	var windowEnd int64
 	if !c.window.IsZero() {
@@ -2776,7 +4255,7 @@ WINDOWS:
 				// do not generate a point for empty windows
 				if windowHasPoints {
 					c.res.Timestamps[pos] = windowEnd
-					c.res.Values[pos] = float64(sum) / float64(count)
+					c.res.Values[pos] = int64(len(distinct))
 					pos++
 					if pos >= MaxPointsPerBlock {
 						// the output array is full,
@@ -2789,15 +4268,15 @@ WINDOWS:
 				}
 
 				// start the new window
-				sum = 0
-				count = 0
+				for v := range distinct {
+					delete(distinct, v)
+				}
 				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
 				windowHasPoints = false
 
 				continue WINDOWS
 			} else {
-				sum += a.Values[rowIdx]
-				count++
+				distinct[a.Values[rowIdx]] = struct{}{}
 				windowHasPoints = true
 			}
 		}
@@ -2814,7 +4293,7 @@ WINDOWS:
 			// do not generate a point for empty windows
 			if windowHasPoints {
 				c.res.Timestamps[pos] = windowEnd
-				c.res.Values[pos] = float64(sum) / float64(count)
+				c.res.Values[pos] = int64(len(distinct))
 				pos++
 			}
 			break WINDOWS
@@ -3254,6 +4733,119 @@ WINDOWS:
 	return c.res
 }
 
+type stringWindowCountDistinctArrayCursor struct {
+	cursors.StringArrayCursor
+	res    *cursors.IntegerArray
+	tmp    *cursors.StringArray
+	window interval.Window
+}
+
+func newStringWindowCountDistinctArrayCursor(cur cursors.StringArrayCursor, window interval.Window) *stringWindowCountDistinctArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &stringWindowCountDistinctArrayCursor{
+		StringArrayCursor: cur,
+		res:               cursors.NewIntegerArrayLen(resLen),
+		tmp:               &cursors.StringArray{},
+		window:            window,
+	}
+}
+
+func (c *stringWindowCountDistinctArrayCursor) Stats() cursors.CursorStats {
+	return c.StringArrayCursor.Stats()
+}
+
+func (c *stringWindowCountDistinctArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.StringArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.StringArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.IntegerArray{}
+	}
+
+	rowIdx := 0
+	distinct := make(map[string]struct{})
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = int64(len(distinct))
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				for v := range distinct {
+					delete(distinct, v)
+				}
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				distinct[a.Values[rowIdx]] = struct{}{}
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.StringArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = int64(len(distinct))
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
 type stringEmptyArrayCursor struct {
 	res cursors.StringArray
 }
@@ -3680,6 +5272,119 @@ WINDOWS:
 	return c.res
 }
 
+type booleanWindowCountDistinctArrayCursor struct {
+	cursors.BooleanArrayCursor
+	res    *cursors.IntegerArray
+	tmp    *cursors.BooleanArray
+	window interval.Window
+}
+
+func newBooleanWindowCountDistinctArrayCursor(cur cursors.BooleanArrayCursor, window interval.Window) *booleanWindowCountDistinctArrayCursor {
+	resLen := MaxPointsPerBlock
+	if window.IsZero() {
+		resLen = 1
+	}
+	return &booleanWindowCountDistinctArrayCursor{
+		BooleanArrayCursor: cur,
+		res:                cursors.NewIntegerArrayLen(resLen),
+		tmp:                &cursors.BooleanArray{},
+		window:             window,
+	}
+}
+
+func (c *booleanWindowCountDistinctArrayCursor) Stats() cursors.CursorStats {
+	return c.BooleanArrayCursor.Stats()
+}
+
+func (c *booleanWindowCountDistinctArrayCursor) Next() *cursors.IntegerArray {
+	pos := 0
+	c.res.Timestamps = c.res.Timestamps[:cap(c.res.Timestamps)]
+	c.res.Values = c.res.Values[:cap(c.res.Values)]
+
+	var a *cursors.BooleanArray
+	if c.tmp.Len() > 0 {
+		a = c.tmp
+	} else {
+		a = c.BooleanArrayCursor.Next()
+	}
+
+	if a.Len() == 0 {
+		return &cursors.IntegerArray{}
+	}
+
+	rowIdx := 0
+	distinct := make(map[bool]struct{})
+
+	var windowEnd int64
+	if !c.window.IsZero() {
+		windowEnd = int64(c.window.GetLatestBounds(values.Time(a.Timestamps[rowIdx])).Stop())
+	} else {
+		windowEnd = math.MaxInt64
+	}
+	windowHasPoints := false
+
+	// enumerate windows
+WINDOWS:
+	for {
+		for ; rowIdx < a.Len(); rowIdx++ {
+			ts := a.Timestamps[rowIdx]
+			if !c.window.IsZero() && ts >= windowEnd {
+				// new window detected, close the current window
+				// do not generate a point for empty windows
+				if windowHasPoints {
+					c.res.Timestamps[pos] = windowEnd
+					c.res.Values[pos] = int64(len(distinct))
+					pos++
+					if pos >= MaxPointsPerBlock {
+						// the output array is full,
+						// save the remaining points in the input array in tmp.
+						// they will be processed in the next call to Next()
+						c.tmp.Timestamps = a.Timestamps[rowIdx:]
+						c.tmp.Values = a.Values[rowIdx:]
+						break WINDOWS
+					}
+				}
+
+				// start the new window
+				for v := range distinct {
+					delete(distinct, v)
+				}
+				windowEnd = int64(c.window.GetLatestBounds(values.Time(ts)).Stop())
+				windowHasPoints = false
+
+				continue WINDOWS
+			} else {
+				distinct[a.Values[rowIdx]] = struct{}{}
+				windowHasPoints = true
+			}
+		}
+
+		// Clear buffered timestamps & values if we make it through a cursor.
+		// The break above will skip this if a cursor is partially read.
+		c.tmp.Timestamps = nil
+		c.tmp.Values = nil
+
+		// get the next chunk
+		a = c.BooleanArrayCursor.Next()
+		if a.Len() == 0 {
+			// write the final point
+			// do not generate a point for empty windows
+			if windowHasPoints {
+				c.res.Timestamps[pos] = windowEnd
+				c.res.Values[pos] = int64(len(distinct))
+				pos++
+			}
+			break WINDOWS
+		}
+		rowIdx = 0
+	}
+
+	c.res.Timestamps = c.res.Timestamps[:pos]
+	c.res.Values = c.res.Values[:pos]
+
+	return c.res
+}
+
 type booleanEmptyArrayCursor struct {
 	res cursors.BooleanArray
 }
@@ -5,10 +5,15 @@ import (
 	"fmt"
 
 	"github.com/influxdata/flux/interval"
+	"github.com/influxdata/flux/values"
 	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
 	"github.com/influxdata/influxdb/v2/tsdb/cursors"
 )
 
+// DefaultPercentileCompression is the compression of the t-digest used to
+// estimate percentiles when the aggregate does not specify one.
+const DefaultPercentileCompression = 1000
+
 type singleValue struct {
 	v interface{}
 }
@@ -45,6 +50,18 @@ func newWindowAggregateArrayCursor(ctx context.Context, agg *datatypes.Aggregate
 		return newWindowMaxArrayCursor(cursor, window), nil
 	case datatypes.Aggregate_AggregateTypeMean:
 		return newWindowMeanArrayCursor(cursor, window)
+	case datatypes.Aggregate_AggregateTypeStddev:
+		return newWindowStddevArrayCursor(cursor, window)
+	case datatypes.Aggregate_AggregateTypeSpread:
+		return newWindowSpreadArrayCursor(cursor, window)
+	case datatypes.Aggregate_AggregateTypePercentile:
+		compression := agg.Compression
+		if compression <= 0 {
+			compression = DefaultPercentileCompression
+		}
+		return newWindowPercentileArrayCursor(cursor, window, agg.Quantile, compression)
+	case datatypes.Aggregate_AggregateTypeCountDistinct:
+		return newWindowCountDistinctArrayCursor(cursor, window), nil
 	default:
 		// TODO(sgc): should be validated higher up
 		panic("invalid aggregate")
@@ -121,24 +138,99 @@ func (m *multiShardArrayCursors) createCursor(row SeriesRow) cursors.Cursor {
 	if cur == nil {
 		return nil
 	}
+	return m.resetCursor(cur, row.Query, cond)
+}
 
+// resetCursor resets the cursor of the type of cur to read cur followed by
+// the cursors of itrs.
+func (m *multiShardArrayCursors) resetCursor(cur cursors.Cursor, itrs cursors.CursorIterators, cond expression) cursors.Cursor {
 	switch c := cur.(type) {
 	case cursors.IntegerArrayCursor:
-		m.cursors.i.reset(c, row.Query, cond)
+		m.cursors.i.reset(c, itrs, cond)
 		return &m.cursors.i
 	case cursors.FloatArrayCursor:
-		m.cursors.f.reset(c, row.Query, cond)
+		m.cursors.f.reset(c, itrs, cond)
 		return &m.cursors.f
 	case cursors.UnsignedArrayCursor:
-		m.cursors.u.reset(c, row.Query, cond)
+		m.cursors.u.reset(c, itrs, cond)
 		return &m.cursors.u
 	case cursors.StringArrayCursor:
-		m.cursors.s.reset(c, row.Query, cond)
+		m.cursors.s.reset(c, itrs, cond)
 		return &m.cursors.s
 	case cursors.BooleanArrayCursor:
-		m.cursors.b.reset(c, row.Query, cond)
+		m.cursors.b.reset(c, itrs, cond)
 		return &m.cursors.b
 	default:
 		panic(fmt.Sprintf("unreachable: %T", cur))
 	}
 }
+
+// canSummarizeWindowAggregate reports whether the window aggregate agg of row
+// can be computed from the summaries of blocks of values.
+func canSummarizeWindowAggregate(agg *datatypes.Aggregate, window interval.Window, row SeriesRow) bool {
+	if window.IsZero() || row.ValueCond != nil {
+		return false
+	}
+	switch agg.Type {
+	case datatypes.Aggregate_AggregateTypeCount,
+		datatypes.Aggregate_AggregateTypeSum,
+		datatypes.Aggregate_AggregateTypeMin,
+		datatypes.Aggregate_AggregateTypeMax,
+		datatypes.Aggregate_AggregateTypeFirst,
+		datatypes.Aggregate_AggregateTypeLast,
+		datatypes.Aggregate_AggregateTypeMean:
+		return true
+	default:
+		return false
+	}
+}
+
+// createWindowSummaryCursor creates a cursor computing the window aggregate
+// agg of row. Blocks of values of numeric fields that lie within a single
+// window are aggregated from their summaries where the shards have them,
+// rather than decoded.
+func (m *multiShardArrayCursors) createWindowSummaryCursor(row SeriesRow, agg *datatypes.Aggregate, window interval.Window) (cursors.Cursor, error) {
+	m.req.Name = row.Name
+	m.req.Tags = row.SeriesTags
+	m.req.Field = row.Field
+
+	summarize := func(minTime, maxTime int64) bool {
+		return int64(window.GetLatestBounds(values.Time(minTime)).Stop()) > maxTime
+	}
+
+	var shard cursors.CursorIterator
+	var cur cursors.Cursor
+	for cur == nil && len(row.Query) > 0 {
+		shard, row.Query = row.Query[0], row.Query[1:]
+		cur, _ = nextSummarizedCursor(m.ctx, shard, &m.req, summarize)
+	}
+
+	if cur == nil {
+		return nil, nil
+	}
+
+	cc := cursorContext{
+		ctx:  m.ctx,
+		req:  &m.req,
+		itrs: row.Query,
+	}
+	switch c := cur.(type) {
+	case cursors.FloatArrayCursor:
+		return newFloatWindowSummaryArrayCursor(cc, c, agg.Type, window, summarize), nil
+	case cursors.IntegerArrayCursor:
+		return newIntegerWindowSummaryArrayCursor(cc, c, agg.Type, window, summarize), nil
+	case cursors.UnsignedArrayCursor:
+		return newUnsignedWindowSummaryArrayCursor(cc, c, agg.Type, window, summarize), nil
+	default:
+		return newWindowAggregateArrayCursor(m.ctx, agg, window, m.resetCursor(cur, row.Query, nil))
+	}
+}
+
+// nextSummarizedCursor returns a cursor for r from itr, which returns blocks
+// accepted by summarize as summaries if it supports them.
+func nextSummarizedCursor(ctx context.Context, itr cursors.CursorIterator, r *cursors.CursorRequest, summarize func(minTime, maxTime int64) bool) (cursors.Cursor, error) {
+	if s, ok := itr.(cursors.SummaryCursorIterator); ok {
+		return s.NextSummarized(ctx, r, summarize)
+	}
+	return itr.Next(ctx, r)
+}
@@ -124,6 +124,81 @@ func TestNewAggregateArrayCursor_Float(t *testing.T) {
 		}
 	})
 
+	t.Run("Stddev", func(t *testing.T) {
+		want := &floatWindowStddevArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(1),
+			tmp:              &cursors.FloatArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeStddev,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowStddevArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		want := &floatWindowSpreadArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(1),
+			tmp:              &cursors.FloatArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSpread,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		want := &floatWindowPercentileArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(1),
+			tmp:              &cursors.FloatArray{},
+			quantile:         0.5,
+			compression:      DefaultPercentileCompression,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("CountDistinct", func(t *testing.T) {
+		want := &floatWindowCountDistinctArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewIntegerArrayLen(1),
+			tmp:              &cursors.FloatArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 func TestNewWindowAggregateArrayCursorMonths_Float(t *testing.T) {
@@ -253,6 +328,109 @@ func TestNewWindowAggregateArrayCursorMonths_Float(t *testing.T) {
 		}
 	})
 
+	t.Run("Stddev", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowStddevArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeStddev,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowStddevArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowSpreadArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSpread,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowPercentileArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+			quantile:         0.5,
+			compression:      DefaultPercentileCompression,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowCountDistinctArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 func TestNewWindowAggregateArrayCursor_Float(t *testing.T) {
@@ -382,6 +560,109 @@ func TestNewWindowAggregateArrayCursor_Float(t *testing.T) {
 		}
 	})
 
+	t.Run("Stddev", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowStddevArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeStddev,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowStddevArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowSpreadArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSpread,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowPercentileArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+			quantile:         0.5,
+			compression:      DefaultPercentileCompression,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &floatWindowCountDistinctArrayCursor{
+			FloatArrayCursor: &MockFloatArrayCursor{},
+			res:              cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:              &cursors.FloatArray{},
+			window:           window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockFloatArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(floatWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 type MockIntegerArrayCursor struct {
@@ -488,54 +769,129 @@ func TestNewAggregateArrayCursor_Integer(t *testing.T) {
 		}
 	})
 
-}
-
-func TestNewWindowAggregateArrayCursorMonths_Integer(t *testing.T) {
-
-	t.Run("Count", func(t *testing.T) {
-		window, _ := interval.NewWindow(
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(0, 0, false),
-		)
-
-		want := &integerWindowCountArrayCursor{
+	t.Run("Stddev", func(t *testing.T) {
+		want := &integerWindowStddevArrayCursor{
 			IntegerArrayCursor: &MockIntegerArrayCursor{},
-			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			res:                cursors.NewFloatArrayLen(1),
 			tmp:                &cursors.IntegerArray{},
-			window:             window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeCount,
+			Type: datatypes.Aggregate_AggregateTypeStddev,
 		}
 
-		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockIntegerArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowCountArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowStddevArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Sum", func(t *testing.T) {
-		window, _ := interval.NewWindow(
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(0, 0, false),
-		)
-
-		want := &integerWindowSumArrayCursor{
+	t.Run("Spread", func(t *testing.T) {
+		want := &integerWindowSpreadArrayCursor{
 			IntegerArrayCursor: &MockIntegerArrayCursor{},
-			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			res:                cursors.NewIntegerArrayLen(1),
 			tmp:                &cursors.IntegerArray{},
-			window:             window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeSum,
+			Type: datatypes.Aggregate_AggregateTypeSpread,
 		}
 
-		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		want := &integerWindowPercentileArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewFloatArrayLen(1),
+			tmp:                &cursors.IntegerArray{},
+			quantile:           0.5,
+			compression:        DefaultPercentileCompression,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("CountDistinct", func(t *testing.T) {
+		want := &integerWindowCountDistinctArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(1),
+			tmp:                &cursors.IntegerArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+}
+
+func TestNewWindowAggregateArrayCursorMonths_Integer(t *testing.T) {
+
+	t.Run("Count", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowCountArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCount,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowCountArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Sum", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowSumArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSum,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
 
 		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowSumArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
@@ -617,6 +973,109 @@ func TestNewWindowAggregateArrayCursorMonths_Integer(t *testing.T) {
 		}
 	})
 
+	t.Run("Stddev", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowStddevArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeStddev,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowStddevArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowSpreadArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSpread,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowPercentileArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+			quantile:           0.5,
+			compression:        DefaultPercentileCompression,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowCountDistinctArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 func TestNewWindowAggregateArrayCursor_Integer(t *testing.T) {
@@ -746,6 +1205,109 @@ func TestNewWindowAggregateArrayCursor_Integer(t *testing.T) {
 		}
 	})
 
+	t.Run("Stddev", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowStddevArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeStddev,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowStddevArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowSpreadArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSpread,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowPercentileArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+			quantile:           0.5,
+			compression:        DefaultPercentileCompression,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &integerWindowCountDistinctArrayCursor{
+			IntegerArrayCursor: &MockIntegerArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.IntegerArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockIntegerArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(integerWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 type MockUnsignedArrayCursor struct {
@@ -765,101 +1327,408 @@ func TestNewAggregateArrayCursor_Unsigned(t *testing.T) {
 	t.Run("Count", func(t *testing.T) {
 		want := &unsignedWindowCountArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewIntegerArrayLen(1),
+			res:                 cursors.NewIntegerArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCount,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowCountArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Sum", func(t *testing.T) {
+		want := &unsignedWindowSumArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewUnsignedArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSum,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowSumArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Min", func(t *testing.T) {
+		want := &unsignedWindowMinArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewUnsignedArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeMin,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMinArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Max", func(t *testing.T) {
+		want := &unsignedWindowMaxArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewUnsignedArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeMax,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMaxArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Mean", func(t *testing.T) {
+		want := &unsignedWindowMeanArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewFloatArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeMean,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMeanArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Stddev", func(t *testing.T) {
+		want := &unsignedWindowStddevArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewFloatArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeStddev,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowStddevArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Spread", func(t *testing.T) {
+		want := &unsignedWindowSpreadArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewUnsignedArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSpread,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Percentile", func(t *testing.T) {
+		want := &unsignedWindowPercentileArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewFloatArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+			quantile:            0.5,
+			compression:         DefaultPercentileCompression,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("CountDistinct", func(t *testing.T) {
+		want := &unsignedWindowCountDistinctArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewIntegerArrayLen(1),
+			tmp:                 &cursors.UnsignedArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+}
+
+func TestNewWindowAggregateArrayCursorMonths_Unsigned(t *testing.T) {
+
+	t.Run("Count", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowCountArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCount,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowCountArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Sum", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowSumArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeSum,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowSumArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Min", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowMinArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeMin,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMinArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Max", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowMaxArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeMax,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMaxArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
+	t.Run("Mean", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowMeanArrayCursor{
+			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
+			res:                 cursors.NewFloatArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeCount,
+			Type: datatypes.Aggregate_AggregateTypeMean,
 		}
 
-		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowCountArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMeanArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Sum", func(t *testing.T) {
-		want := &unsignedWindowSumArrayCursor{
+	t.Run("Stddev", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowStddevArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewUnsignedArrayLen(1),
+			res:                 cursors.NewFloatArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeSum,
+			Type: datatypes.Aggregate_AggregateTypeStddev,
 		}
 
-		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowSumArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowStddevArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Min", func(t *testing.T) {
-		want := &unsignedWindowMinArrayCursor{
+	t.Run("Spread", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowSpreadArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewUnsignedArrayLen(1),
+			res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeMin,
+			Type: datatypes.Aggregate_AggregateTypeSpread,
 		}
 
-		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMinArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Max", func(t *testing.T) {
-		want := &unsignedWindowMaxArrayCursor{
+	t.Run("Percentile", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowPercentileArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewUnsignedArrayLen(1),
+			res:                 cursors.NewFloatArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
+			quantile:            0.5,
+			compression:         DefaultPercentileCompression,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeMax,
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
 		}
 
-		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMaxArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Mean", func(t *testing.T) {
-		want := &unsignedWindowMeanArrayCursor{
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &unsignedWindowCountDistinctArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewFloatArrayLen(1),
+			res:                 cursors.NewIntegerArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
+			window:              window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeMean,
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
 		}
 
-		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockUnsignedArrayCursor{})
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMeanArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
 }
 
-func TestNewWindowAggregateArrayCursorMonths_Unsigned(t *testing.T) {
+func TestNewWindowAggregateArrayCursor_Unsigned(t *testing.T) {
 
 	t.Run("Count", func(t *testing.T) {
 		window, _ := interval.NewWindow(
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
@@ -883,8 +1752,8 @@ func TestNewWindowAggregateArrayCursorMonths_Unsigned(t *testing.T) {
 
 	t.Run("Sum", func(t *testing.T) {
 		window, _ := interval.NewWindow(
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
@@ -908,8 +1777,8 @@ func TestNewWindowAggregateArrayCursorMonths_Unsigned(t *testing.T) {
 
 	t.Run("Min", func(t *testing.T) {
 		window, _ := interval.NewWindow(
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
@@ -933,8 +1802,8 @@ func TestNewWindowAggregateArrayCursorMonths_Unsigned(t *testing.T) {
 
 	t.Run("Max", func(t *testing.T) {
 		window, _ := interval.NewWindow(
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
@@ -958,8 +1827,8 @@ func TestNewWindowAggregateArrayCursorMonths_Unsigned(t *testing.T) {
 
 	t.Run("Mean", func(t *testing.T) {
 		window, _ := interval.NewWindow(
-			values.MakeDuration(int64(time.Hour), 0, false),
-			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
@@ -981,68 +1850,39 @@ func TestNewWindowAggregateArrayCursorMonths_Unsigned(t *testing.T) {
 		}
 	})
 
-}
-
-func TestNewWindowAggregateArrayCursor_Unsigned(t *testing.T) {
-
-	t.Run("Count", func(t *testing.T) {
-		window, _ := interval.NewWindow(
-			values.MakeDuration(0, 1, false),
-			values.MakeDuration(0, 1, false),
-			values.MakeDuration(0, 0, false),
-		)
-
-		want := &unsignedWindowCountArrayCursor{
-			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewIntegerArrayLen(MaxPointsPerBlock),
-			tmp:                 &cursors.UnsignedArray{},
-			window:              window,
-		}
-
-		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeCount,
-		}
-
-		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
-
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowCountArrayCursor{}), cmpOptions); diff != "" {
-			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
-		}
-	})
-
-	t.Run("Sum", func(t *testing.T) {
+	t.Run("Stddev", func(t *testing.T) {
 		window, _ := interval.NewWindow(
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
-		want := &unsignedWindowSumArrayCursor{
+		want := &unsignedWindowStddevArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+			res:                 cursors.NewFloatArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
 			window:              window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeSum,
+			Type: datatypes.Aggregate_AggregateTypeStddev,
 		}
 
 		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowSumArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowStddevArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Min", func(t *testing.T) {
+	t.Run("Spread", func(t *testing.T) {
 		window, _ := interval.NewWindow(
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
-		want := &unsignedWindowMinArrayCursor{
+		want := &unsignedWindowSpreadArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
 			res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
@@ -1050,62 +1890,65 @@ func TestNewWindowAggregateArrayCursor_Unsigned(t *testing.T) {
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeMin,
+			Type: datatypes.Aggregate_AggregateTypeSpread,
 		}
 
 		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMinArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowSpreadArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Max", func(t *testing.T) {
+	t.Run("Percentile", func(t *testing.T) {
 		window, _ := interval.NewWindow(
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
-		want := &unsignedWindowMaxArrayCursor{
+		want := &unsignedWindowPercentileArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+			res:                 cursors.NewFloatArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
 			window:              window,
+			quantile:            0.5,
+			compression:         DefaultPercentileCompression,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeMax,
+			Type:     datatypes.Aggregate_AggregateTypePercentile,
+			Quantile: 0.5,
 		}
 
 		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMaxArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowPercentileArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
 
-	t.Run("Mean", func(t *testing.T) {
+	t.Run("CountDistinct", func(t *testing.T) {
 		window, _ := interval.NewWindow(
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 1, false),
 			values.MakeDuration(0, 0, false),
 		)
 
-		want := &unsignedWindowMeanArrayCursor{
+		want := &unsignedWindowCountDistinctArrayCursor{
 			UnsignedArrayCursor: &MockUnsignedArrayCursor{},
-			res:                 cursors.NewFloatArrayLen(MaxPointsPerBlock),
+			res:                 cursors.NewIntegerArrayLen(MaxPointsPerBlock),
 			tmp:                 &cursors.UnsignedArray{},
 			window:              window,
 		}
 
 		agg := &datatypes.Aggregate{
-			Type: datatypes.Aggregate_AggregateTypeMean,
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
 		}
 
 		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockUnsignedArrayCursor{})
 
-		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowMeanArrayCursor{}), cmpOptions); diff != "" {
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(unsignedWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
 			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
 		}
 	})
@@ -1144,6 +1987,24 @@ func TestNewAggregateArrayCursor_String(t *testing.T) {
 		}
 	})
 
+	t.Run("CountDistinct", func(t *testing.T) {
+		want := &stringWindowCountDistinctArrayCursor{
+			StringArrayCursor: &MockStringArrayCursor{},
+			res:               cursors.NewIntegerArrayLen(1),
+			tmp:               &cursors.StringArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockStringArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(stringWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 func TestNewWindowAggregateArrayCursorMonths_String(t *testing.T) {
@@ -1173,6 +2034,31 @@ func TestNewWindowAggregateArrayCursorMonths_String(t *testing.T) {
 		}
 	})
 
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &stringWindowCountDistinctArrayCursor{
+			StringArrayCursor: &MockStringArrayCursor{},
+			res:               cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:               &cursors.StringArray{},
+			window:            window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockStringArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(stringWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 func TestNewWindowAggregateArrayCursor_String(t *testing.T) {
@@ -1202,6 +2088,31 @@ func TestNewWindowAggregateArrayCursor_String(t *testing.T) {
 		}
 	})
 
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &stringWindowCountDistinctArrayCursor{
+			StringArrayCursor: &MockStringArrayCursor{},
+			res:               cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:               &cursors.StringArray{},
+			window:            window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockStringArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(stringWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 type MockBooleanArrayCursor struct {
@@ -1236,6 +2147,24 @@ func TestNewAggregateArrayCursor_Boolean(t *testing.T) {
 		}
 	})
 
+	t.Run("CountDistinct", func(t *testing.T) {
+		want := &booleanWindowCountDistinctArrayCursor{
+			BooleanArrayCursor: &MockBooleanArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(1),
+			tmp:                &cursors.BooleanArray{},
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newAggregateArrayCursor(context.Background(), agg, &MockBooleanArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(booleanWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 func TestNewWindowAggregateArrayCursorMonths_Boolean(t *testing.T) {
@@ -1265,6 +2194,31 @@ func TestNewWindowAggregateArrayCursorMonths_Boolean(t *testing.T) {
 		}
 	})
 
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(int64(time.Hour), 0, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &booleanWindowCountDistinctArrayCursor{
+			BooleanArrayCursor: &MockBooleanArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.BooleanArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockBooleanArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(booleanWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
 
 func TestNewWindowAggregateArrayCursor_Boolean(t *testing.T) {
@@ -1294,4 +2248,29 @@ func TestNewWindowAggregateArrayCursor_Boolean(t *testing.T) {
 		}
 	})
 
+	t.Run("CountDistinct", func(t *testing.T) {
+		window, _ := interval.NewWindow(
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 1, false),
+			values.MakeDuration(0, 0, false),
+		)
+
+		want := &booleanWindowCountDistinctArrayCursor{
+			BooleanArrayCursor: &MockBooleanArrayCursor{},
+			res:                cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+			tmp:                &cursors.BooleanArray{},
+			window:             window,
+		}
+
+		agg := &datatypes.Aggregate{
+			Type: datatypes.Aggregate_AggregateTypeCountDistinct,
+		}
+
+		got, _ := newWindowAggregateArrayCursor(context.Background(), agg, window, &MockBooleanArrayCursor{})
+
+		if diff := cmp.Diff(got, want, cmp.AllowUnexported(booleanWindowCountDistinctArrayCursor{}), cmpOptions); diff != "" {
+			t.Fatalf("did not get expected cursor; -got/+want:\n%v", diff)
+		}
+	})
+
 }
@@ -2169,6 +2169,216 @@ func TestWindowMeanArrayCursor(t *testing.T) {
 	}
 }
 
+func TestWindowStddevArrayCursor(t *testing.T) {
+	maxTimestamp := time.Unix(0, math.MaxInt64)
+
+	testcases := []aggArrayCursorTest{
+		{
+			name:  "no window",
+			every: 0,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					5,
+					mustParseTime("2010-01-01T00:00:00Z"), time.Minute,
+					func(i int64) int64 { return i + 1 },
+				),
+			},
+			wantFloats: []*cursors.FloatArray{
+				makeFloatArray(1, maxTimestamp, 0, func(int64) float64 { return math.Sqrt(2.5) }),
+			},
+		},
+		{
+			name:        "no window empty",
+			every:       0,
+			inputArrays: []*cursors.IntegerArray{},
+			wantFloats:  []*cursors.FloatArray{},
+		},
+		{
+			name:  "window",
+			every: 30 * time.Minute,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					8,
+					mustParseTime("2010-01-01T00:00:00Z"), 15*time.Minute,
+					func(i int64) int64 {
+						return i
+					},
+				),
+			},
+			wantFloats: []*cursors.FloatArray{
+				makeFloatArray(4, mustParseTime("2010-01-01T00:30:00Z"), 30*time.Minute,
+					func(int64) float64 { return math.Sqrt(0.5) }),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc.createCursorFn = func(cur cursors.IntegerArrayCursor, every, offset int64, window interval.Window) cursors.Cursor {
+			if every != 0 || offset != 0 {
+				window, _ = interval.NewWindow(
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(offset, 0, false),
+				)
+			}
+			return newIntegerWindowStddevArrayCursor(cur, window)
+		}
+		tc.run(t)
+	}
+}
+
+func TestWindowSpreadArrayCursor(t *testing.T) {
+	maxTimestamp := time.Unix(0, math.MaxInt64)
+
+	testcases := []aggArrayCursorTest{
+		{
+			name:  "no window",
+			every: 0,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					5,
+					mustParseTime("2010-01-01T00:00:00Z"), time.Minute,
+					func(i int64) int64 { return []int64{3, -2, 7, 1, 0}[i] },
+				),
+			},
+			wantIntegers: []*cursors.IntegerArray{
+				makeIntegerArray(1, maxTimestamp, 0, func(int64) int64 { return 9 }),
+			},
+		},
+		{
+			name:  "window",
+			every: 30 * time.Minute,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					8,
+					mustParseTime("2010-01-01T00:00:00Z"), 15*time.Minute,
+					func(i int64) int64 {
+						return i * i
+					},
+				),
+			},
+			wantIntegers: []*cursors.IntegerArray{
+				makeIntegerArray(4, mustParseTime("2010-01-01T00:30:00Z"), 30*time.Minute,
+					func(i int64) int64 { return 4*i + 1 }),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc.createCursorFn = func(cur cursors.IntegerArrayCursor, every, offset int64, window interval.Window) cursors.Cursor {
+			if every != 0 || offset != 0 {
+				window, _ = interval.NewWindow(
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(offset, 0, false),
+				)
+			}
+			return newIntegerWindowSpreadArrayCursor(cur, window)
+		}
+		tc.run(t)
+	}
+}
+
+func TestWindowPercentileArrayCursor(t *testing.T) {
+	maxTimestamp := time.Unix(0, math.MaxInt64)
+
+	testcases := []aggArrayCursorTest{
+		{
+			name:  "no window",
+			every: 0,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					5,
+					mustParseTime("2010-01-01T00:00:00Z"), time.Minute,
+					func(i int64) int64 { return 5 - i },
+				),
+			},
+			wantFloats: []*cursors.FloatArray{
+				makeFloatArray(1, maxTimestamp, 0, func(int64) float64 { return 3 }),
+			},
+		},
+		{
+			name:  "window",
+			every: time.Hour,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					8,
+					mustParseTime("2010-01-01T00:00:00Z"), 15*time.Minute,
+					func(i int64) int64 {
+						return i * 10
+					},
+				),
+			},
+			wantFloats: []*cursors.FloatArray{
+				makeFloatArray(2, mustParseTime("2010-01-01T01:00:00Z"), time.Hour,
+					func(i int64) float64 { return 15 + float64(i)*40 }),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc.createCursorFn = func(cur cursors.IntegerArrayCursor, every, offset int64, window interval.Window) cursors.Cursor {
+			if every != 0 || offset != 0 {
+				window, _ = interval.NewWindow(
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(offset, 0, false),
+				)
+			}
+			return newIntegerWindowPercentileArrayCursor(cur, window, 0.5, DefaultPercentileCompression)
+		}
+		tc.run(t)
+	}
+}
+
+func TestWindowCountDistinctArrayCursor(t *testing.T) {
+	maxTimestamp := time.Unix(0, math.MaxInt64)
+
+	testcases := []aggArrayCursorTest{
+		{
+			name:  "no window",
+			every: 0,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					6,
+					mustParseTime("2010-01-01T00:00:00Z"), time.Minute,
+					func(i int64) int64 { return i % 4 },
+				),
+			},
+			wantIntegers: []*cursors.IntegerArray{
+				makeIntegerArray(1, maxTimestamp, 0, func(int64) int64 { return 4 }),
+			},
+		},
+		{
+			name:  "window",
+			every: time.Hour,
+			inputArrays: []*cursors.IntegerArray{
+				makeIntegerArray(
+					8,
+					mustParseTime("2010-01-01T00:00:00Z"), 15*time.Minute,
+					func(i int64) int64 {
+						return []int64{0, 0, 1, 1, 5, 5, 5, 5}[i]
+					},
+				),
+			},
+			wantIntegers: []*cursors.IntegerArray{
+				makeIntegerArray(2, mustParseTime("2010-01-01T01:00:00Z"), time.Hour,
+					func(i int64) int64 { return 2 - i }),
+			},
+		},
+	}
+	for _, tc := range testcases {
+		tc.createCursorFn = func(cur cursors.IntegerArrayCursor, every, offset int64, window interval.Window) cursors.Cursor {
+			if every != 0 || offset != 0 {
+				window, _ = interval.NewWindow(
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(every, 0, false),
+					values.MakeDuration(offset, 0, false),
+				)
+			}
+			return newIntegerWindowCountDistinctArrayCursor(cur, window)
+		}
+		tc.run(t)
+	}
+}
+
 // This test replicates GitHub issue
 // https://github.com/influxdata/influxdb/issues/20035
 func TestMultiShardArrayCursor(t *testing.T) {
@@ -0,0 +1,141 @@
+// Generated by tmpl
+// https://github.com/benbjohnson/tmpl
+//
+// DO NOT EDIT!
+// Source: response_writer.gen.go.tmpl
+
+package reads
+
+import (
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// writeFloatPoints writes a series frame for tags followed by the points
+// of cur, unless cur has no points.
+func (w *ResponseWriter) writeFloatPoints(tags models.Tags, cur cursors.FloatArrayCursor) {
+	a := cur.Next()
+	if a.Len() == 0 {
+		return
+	}
+	w.writeSeries(tags, datatypes.ReadResponse_DataTypeFloat)
+	for ; w.err == nil && a.Len() > 0; a = cur.Next() {
+		f := &datatypes.ReadResponse_FloatPointsFrame{
+			Timestamps: make([]int64, a.Len()),
+			Values:     make([]float64, a.Len()),
+		}
+		copy(f.Timestamps, a.Timestamps)
+		copy(f.Values, a.Values)
+		sz := 16 * len(f.Timestamps)
+		w.appendFrame(&datatypes.ReadResponse_Frame{
+			Data: &datatypes.ReadResponse_Frame_FloatPoints{FloatPoints: f},
+		}, sz)
+	}
+	if err := cur.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writeIntegerPoints writes a series frame for tags followed by the points
+// of cur, unless cur has no points.
+func (w *ResponseWriter) writeIntegerPoints(tags models.Tags, cur cursors.IntegerArrayCursor) {
+	a := cur.Next()
+	if a.Len() == 0 {
+		return
+	}
+	w.writeSeries(tags, datatypes.ReadResponse_DataTypeInteger)
+	for ; w.err == nil && a.Len() > 0; a = cur.Next() {
+		f := &datatypes.ReadResponse_IntegerPointsFrame{
+			Timestamps: make([]int64, a.Len()),
+			Values:     make([]int64, a.Len()),
+		}
+		copy(f.Timestamps, a.Timestamps)
+		copy(f.Values, a.Values)
+		sz := 16 * len(f.Timestamps)
+		w.appendFrame(&datatypes.ReadResponse_Frame{
+			Data: &datatypes.ReadResponse_Frame_IntegerPoints{IntegerPoints: f},
+		}, sz)
+	}
+	if err := cur.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writeUnsignedPoints writes a series frame for tags followed by the points
+// of cur, unless cur has no points.
+func (w *ResponseWriter) writeUnsignedPoints(tags models.Tags, cur cursors.UnsignedArrayCursor) {
+	a := cur.Next()
+	if a.Len() == 0 {
+		return
+	}
+	w.writeSeries(tags, datatypes.ReadResponse_DataTypeUnsigned)
+	for ; w.err == nil && a.Len() > 0; a = cur.Next() {
+		f := &datatypes.ReadResponse_UnsignedPointsFrame{
+			Timestamps: make([]int64, a.Len()),
+			Values:     make([]uint64, a.Len()),
+		}
+		copy(f.Timestamps, a.Timestamps)
+		copy(f.Values, a.Values)
+		sz := 16 * len(f.Timestamps)
+		w.appendFrame(&datatypes.ReadResponse_Frame{
+			Data: &datatypes.ReadResponse_Frame_UnsignedPoints{UnsignedPoints: f},
+		}, sz)
+	}
+	if err := cur.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writeStringPoints writes a series frame for tags followed by the points
+// of cur, unless cur has no points.
+func (w *ResponseWriter) writeStringPoints(tags models.Tags, cur cursors.StringArrayCursor) {
+	a := cur.Next()
+	if a.Len() == 0 {
+		return
+	}
+	w.writeSeries(tags, datatypes.ReadResponse_DataTypeString)
+	for ; w.err == nil && a.Len() > 0; a = cur.Next() {
+		f := &datatypes.ReadResponse_StringPointsFrame{
+			Timestamps: make([]int64, a.Len()),
+			Values:     make([]string, a.Len()),
+		}
+		copy(f.Timestamps, a.Timestamps)
+		copy(f.Values, a.Values)
+		sz := 8 * len(f.Timestamps)
+		for _, v := range f.Values {
+			sz += len(v) + 1
+		}
+		w.appendFrame(&datatypes.ReadResponse_Frame{
+			Data: &datatypes.ReadResponse_Frame_StringPoints{StringPoints: f},
+		}, sz)
+	}
+	if err := cur.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+// writeBooleanPoints writes a series frame for tags followed by the points
+// of cur, unless cur has no points.
+func (w *ResponseWriter) writeBooleanPoints(tags models.Tags, cur cursors.BooleanArrayCursor) {
+	a := cur.Next()
+	if a.Len() == 0 {
+		return
+	}
+	w.writeSeries(tags, datatypes.ReadResponse_DataTypeBoolean)
+	for ; w.err == nil && a.Len() > 0; a = cur.Next() {
+		f := &datatypes.ReadResponse_BooleanPointsFrame{
+			Timestamps: make([]int64, a.Len()),
+			Values:     make([]bool, a.Len()),
+		}
+		copy(f.Timestamps, a.Timestamps)
+		copy(f.Values, a.Values)
+		sz := 9 * len(f.Timestamps)
+		w.appendFrame(&datatypes.ReadResponse_Frame{
+			Data: &datatypes.ReadResponse_Frame_BooleanPoints{BooleanPoints: f},
+		}, sz)
+	}
+	if err := cur.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
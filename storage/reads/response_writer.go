@@ -0,0 +1,179 @@
+package reads
+
+import (
+	"fmt"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// ResponseStream is the destination of the responses of a ResponseWriter,
+// such as the server side of a streaming read RPC.
+type ResponseStream interface {
+	Send(*datatypes.ReadResponse) error
+}
+
+const (
+	// writeSize is the approximate size in bytes of the frames buffered
+	// before they are sent as a single response.
+	writeSize = 64 << 10
+
+	// seriesFrameOverhead approximates the encoded size of a series frame
+	// beyond its tag keys and values.
+	seriesFrameOverhead = 16
+)
+
+// ResponseWriter encodes result sets as a stream of ReadResponse messages.
+//
+// Each series is written as a SeriesFrame followed by one or more points
+// frames of the series' data type; series without points are omitted. Each
+// group of a GroupResultSet is introduced by a GroupFrame. Frames are
+// buffered and sent once they reach about writeSize bytes, so a single series
+// may span several responses.
+type ResponseWriter struct {
+	stream ResponseStream
+	res    *datatypes.ReadResponse
+	sz     int
+	err    error
+}
+
+// NewResponseWriter returns a ResponseWriter that sends responses to stream.
+func NewResponseWriter(stream ResponseStream) *ResponseWriter {
+	return &ResponseWriter{
+		stream: stream,
+		res:    &datatypes.ReadResponse{},
+	}
+}
+
+// Err returns the first error encountered reading the result sets or sending
+// responses.
+func (w *ResponseWriter) Err() error { return w.err }
+
+// WriteResultSet writes the series of rs. It does not close rs.
+func (w *ResponseWriter) WriteResultSet(rs ResultSet) error {
+	for w.err == nil && rs.Next() {
+		cur := rs.Cursor()
+		if cur == nil {
+			continue
+		}
+		w.writeCursor(rs.Tags(), cur)
+		cur.Close()
+	}
+	if err := rs.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+// WriteGroupResultSet writes the groups of rs and their series. It closes
+// each group but not rs.
+func (w *ResponseWriter) WriteGroupResultSet(rs GroupResultSet) error {
+	for w.err == nil {
+		gc := rs.Next()
+		if gc == nil {
+			break
+		}
+		w.writeGroup(gc)
+		gc.Close()
+	}
+	if err := rs.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+	return w.err
+}
+
+func (w *ResponseWriter) writeGroup(gc GroupCursor) {
+	w.appendFrame(&datatypes.ReadResponse_Frame{
+		Data: &datatypes.ReadResponse_Frame_Group{
+			Group: &datatypes.ReadResponse_GroupFrame{
+				TagKeys:          copyBytesSlice(gc.Keys()),
+				PartitionKeyVals: copyBytesSlice(gc.PartitionKeyVals()),
+			},
+		},
+	}, frameBytes(gc.Keys())+frameBytes(gc.PartitionKeyVals()))
+
+	for w.err == nil && gc.Next() {
+		cur := gc.Cursor()
+		if cur == nil {
+			continue
+		}
+		w.writeCursor(gc.Tags(), cur)
+		cur.Close()
+	}
+	if err := gc.Err(); err != nil && w.err == nil {
+		w.err = err
+	}
+}
+
+func (w *ResponseWriter) writeCursor(tags models.Tags, cur cursors.Cursor) {
+	switch cur := cur.(type) {
+	case cursors.FloatArrayCursor:
+		w.writeFloatPoints(tags, cur)
+	case cursors.IntegerArrayCursor:
+		w.writeIntegerPoints(tags, cur)
+	case cursors.UnsignedArrayCursor:
+		w.writeUnsignedPoints(tags, cur)
+	case cursors.BooleanArrayCursor:
+		w.writeBooleanPoints(tags, cur)
+	case cursors.StringArrayCursor:
+		w.writeStringPoints(tags, cur)
+	default:
+		w.err = fmt.Errorf("unsupported cursor type: %T", cur)
+	}
+}
+
+func (w *ResponseWriter) writeSeries(tags models.Tags, typ datatypes.ReadResponse_DataType) {
+	sz := seriesFrameOverhead
+	frameTags := make([]*datatypes.Tag, len(tags))
+	for i, t := range tags {
+		frameTags[i] = &datatypes.Tag{
+			Key:   append([]byte(nil), t.Key...),
+			Value: append([]byte(nil), t.Value...),
+		}
+		sz += len(t.Key) + len(t.Value)
+	}
+	w.appendFrame(&datatypes.ReadResponse_Frame{
+		Data: &datatypes.ReadResponse_Frame_Series{
+			Series: &datatypes.ReadResponse_SeriesFrame{Tags: frameTags, DataType: typ},
+		},
+	}, sz)
+}
+
+func (w *ResponseWriter) appendFrame(f *datatypes.ReadResponse_Frame, sz int) {
+	w.res.Frames = append(w.res.Frames, f)
+	w.sz += sz
+	if w.sz >= writeSize {
+		w.Flush()
+	}
+}
+
+// Flush sends the buffered frames, if any.
+func (w *ResponseWriter) Flush() {
+	if w.err != nil || len(w.res.Frames) == 0 {
+		return
+	}
+	if err := w.stream.Send(w.res); err != nil {
+		w.err = err
+	}
+	w.res = &datatypes.ReadResponse{}
+	w.sz = 0
+}
+
+func frameBytes(vs [][]byte) (n int) {
+	for _, v := range vs {
+		n += len(v)
+	}
+	return n
+}
+
+func copyBytesSlice(vs [][]byte) [][]byte {
+	if vs == nil {
+		return nil
+	}
+	out := make([][]byte, len(vs))
+	for i, v := range vs {
+		out[i] = append([]byte(nil), v...)
+	}
+	return out
+}
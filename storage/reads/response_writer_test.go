@@ -0,0 +1,114 @@
+package reads_test
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/pkg/data/gen"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/stretchr/testify/require"
+)
+
+type responseStream struct {
+	responses []*datatypes.ReadResponse
+	err       error
+}
+
+func (s *responseStream) Send(res *datatypes.ReadResponse) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.responses = append(s.responses, res)
+	return nil
+}
+
+func (s *responseStream) frames() []*datatypes.ReadResponse_Frame {
+	var frames []*datatypes.ReadResponse_Frame
+	for _, res := range s.responses {
+		frames = append(frames, res.Frames...)
+	}
+	return frames
+}
+
+func newGeneratorResultSet(t *testing.T, values int) reads.ResultSet {
+	t.Helper()
+	spec, err := gen.NewSpecFromToml(`
+[[measurements]]
+name = "m0"
+sample = 1.0
+tags = [
+	{ name = "tag0", source = { type = "sequence", start = 0, count = 3 } },
+]
+fields = [
+	{ name = "v0", count = ` + strconv.Itoa(values) + `, source = 1.0 },
+]`)
+	require.NoError(t, err)
+	sg := gen.NewSeriesGeneratorFromSpec(spec, gen.TimeRange{
+		Start: time.Unix(1000, 0),
+		End:   time.Unix(2000, 0),
+	})
+	return mock.NewResultSetFromSeriesGenerator(sg)
+}
+
+func TestResponseWriter_WriteResultSet(t *testing.T) {
+	var stream responseStream
+	w := reads.NewResponseWriter(&stream)
+	require.NoError(t, w.WriteResultSet(newGeneratorResultSet(t, 3)))
+	w.Flush()
+	require.NoError(t, w.Err())
+
+	// The frames are small enough to be sent in a single response.
+	require.Len(t, stream.responses, 1)
+	frames := stream.frames()
+	require.Len(t, frames, 6)
+	for i := 0; i < len(frames); i += 2 {
+		series := frames[i].GetSeries()
+		require.NotNil(t, series)
+		require.Equal(t, datatypes.ReadResponse_DataTypeFloat, series.DataType)
+
+		var tag0 string
+		for _, tag := range series.Tags {
+			if string(tag.Key) == "tag0" {
+				tag0 = string(tag.Value)
+			}
+		}
+		require.Equal(t, "value"+strconv.Itoa(i/2), tag0)
+
+		points := frames[i+1].GetFloatPoints()
+		require.NotNil(t, points)
+		require.Equal(t, []float64{1, 1, 1}, points.Values)
+		require.Equal(t, []int64{1000000000000, 1333333000000, 1666666000000}, points.Timestamps)
+	}
+}
+
+func TestResponseWriter_WriteResultSet_Flush(t *testing.T) {
+	var stream responseStream
+	w := reads.NewResponseWriter(&stream)
+	require.NoError(t, w.WriteResultSet(newGeneratorResultSet(t, 10000)))
+	w.Flush()
+	require.NoError(t, w.Err())
+
+	// Large series are split across responses.
+	require.Greater(t, len(stream.responses), 1)
+	var series, points int
+	for _, f := range stream.frames() {
+		switch {
+		case f.GetSeries() != nil:
+			series++
+		case f.GetFloatPoints() != nil:
+			points += len(f.GetFloatPoints().Values)
+		}
+	}
+	require.Equal(t, 3, series)
+	require.Equal(t, 30000, points)
+}
+
+func TestResponseWriter_SendError(t *testing.T) {
+	stream := responseStream{err: errors.New("closed")}
+	w := reads.NewResponseWriter(&stream)
+	require.EqualError(t, w.WriteResultSet(newGeneratorResultSet(t, 10000)), "closed")
+}
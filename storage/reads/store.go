@@ -87,5 +87,9 @@ type Store interface {
 	ReadSeriesCardinality(ctx context.Context, req *datatypes.ReadSeriesCardinalityRequest) (cursors.Int64Iterator, error)
 	SupportReadSeriesCardinality(ctx context.Context) bool
 
+	// SupportWindowAggregate reports whether WindowAggregate can compute
+	// the given aggregate.
+	SupportWindowAggregate(ctx context.Context, agg datatypes.Aggregate_AggregateType) bool
+
 	GetSource(orgID, bucketID uint64) proto.Message
 }
@@ -0,0 +1,769 @@
+// Generated by tmpl
+// https://github.com/benbjohnson/tmpl
+//
+// DO NOT EDIT!
+// Source: window_summary_cursor.gen.go.tmpl
+
+package reads
+
+import (
+	"errors"
+
+	"github.com/influxdata/flux/interval"
+	"github.com/influxdata/flux/values"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+)
+
+// floatWindow holds the aggregates of the values of a window.
+type floatWindow struct {
+	stop                       int64
+	count                      int64
+	sum, min, max, first, last float64
+	minTime, maxTime           int64
+	firstTime, lastTime        int64
+}
+
+func (w *floatWindow) add(t int64, v float64) {
+	if w.count == 0 {
+		w.first, w.firstTime = v, t
+		w.min, w.minTime = v, t
+		w.max, w.maxTime = v, t
+	} else {
+		if v < w.min {
+			w.min, w.minTime = v, t
+		}
+		if v > w.max {
+			w.max, w.maxTime = v, t
+		}
+	}
+	w.last, w.lastTime = v, t
+	w.count++
+	w.sum += v
+}
+
+func (w *floatWindow) addSummary(s *cursors.FloatBlockSummary) {
+	if w.count == 0 {
+		w.first, w.firstTime = s.First, s.MinTime
+		w.min, w.minTime = s.Min, s.MinValueTime
+		w.max, w.maxTime = s.Max, s.MaxValueTime
+	} else {
+		if s.Min < w.min {
+			w.min, w.minTime = s.Min, s.MinValueTime
+		}
+		if s.Max > w.max {
+			w.max, w.maxTime = s.Max, s.MaxValueTime
+		}
+	}
+	w.last, w.lastTime = s.Last, s.MaxTime
+	w.count += s.Count
+	w.sum += s.Sum
+}
+
+// floatWindowSummaryReader reads the windows of a series across shards,
+// aggregating the values returned by the shards' cursors together with the
+// summaries of the blocks the cursors do not return.
+type floatWindowSummaryReader struct {
+	cursorContext
+	window    interval.Window
+	summarize func(minTime, maxTime int64) bool
+
+	cur       cursors.FloatArrayCursor
+	ts        []int64
+	vs        []float64
+	summaries []cursors.FloatBlockSummary
+	stats     cursors.CursorStats
+}
+
+func (r *floatWindowSummaryReader) reset(cur cursors.FloatArrayCursor) {
+	r.cur = cur
+	r.ts, r.vs = nil, nil
+	r.summaries = nil
+	if c, ok := cur.(cursors.FloatSummaryArrayCursor); ok {
+		r.summaries = c.Summaries()
+	}
+}
+
+func (r *floatWindowSummaryReader) Err() error { return r.err }
+
+func (r *floatWindowSummaryReader) Stats() cursors.CursorStats {
+	stats := r.stats
+	if r.cur != nil {
+		stats.Add(r.cur.Stats())
+	}
+	return stats
+}
+
+func (r *floatWindowSummaryReader) Close() {
+	if r.cur != nil {
+		r.stats.Add(r.cur.Stats())
+		r.cur.Close()
+		r.cur = nil
+	}
+}
+
+// peek reports whether there is a value or summary left to read.
+func (r *floatWindowSummaryReader) peek() bool {
+	for {
+		if len(r.ts) == 0 && r.cur != nil {
+			a := r.cur.Next()
+			r.ts, r.vs = a.Timestamps, a.Values
+			if len(r.ts) == 0 {
+				r.Close()
+			}
+		}
+		if len(r.ts) > 0 || len(r.summaries) > 0 {
+			return true
+		}
+		if !r.nextShard() {
+			return false
+		}
+	}
+}
+
+func (r *floatWindowSummaryReader) nextShard() bool {
+	for r.err == nil && len(r.itrs) > 0 {
+		var itr cursors.CursorIterator
+		itr, r.itrs = r.itrs[0], r.itrs[1:]
+		cur, _ := nextSummarizedCursor(r.ctx, itr, r.req, r.summarize)
+		if cur == nil {
+			continue
+		}
+		c, ok := cur.(cursors.FloatArrayCursor)
+		if !ok {
+			cur.Close()
+			r.err = errors.New("expected float cursor")
+			return false
+		}
+		r.reset(c)
+		return true
+	}
+	return false
+}
+
+// next reads the next window with values into w.
+func (r *floatWindowSummaryReader) next(w *floatWindow) bool {
+	*w = floatWindow{}
+	for r.peek() {
+		if len(r.ts) > 0 && (len(r.summaries) == 0 || r.ts[0] < r.summaries[0].MinTime) {
+			t := r.ts[0]
+			if w.count == 0 {
+				w.stop = int64(r.window.GetLatestBounds(values.Time(t)).Stop())
+			} else if t >= w.stop {
+				break
+			}
+			w.add(t, r.vs[0])
+			r.ts, r.vs = r.ts[1:], r.vs[1:]
+			continue
+		}
+
+		s := &r.summaries[0]
+		if w.count == 0 {
+			w.stop = int64(r.window.GetLatestBounds(values.Time(s.MinTime)).Stop())
+		} else if s.MinTime >= w.stop {
+			break
+		}
+		w.addSummary(s)
+		r.summaries = r.summaries[1:]
+	}
+	return w.count > 0
+}
+
+// floatWindowSummaryArrayCursor computes the sum, min, max, first or last
+// or mean of the windows of a series.
+type floatWindowSummaryArrayCursor struct {
+	*floatWindowSummaryReader
+	agg datatypes.Aggregate_AggregateType
+	res *cursors.FloatArray
+}
+
+func (c *floatWindowSummaryArrayCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w floatWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		switch c.agg {
+		case datatypes.Aggregate_AggregateTypeSum:
+			c.res.Timestamps = append(c.res.Timestamps, w.stop)
+			c.res.Values = append(c.res.Values, w.sum)
+		case datatypes.Aggregate_AggregateTypeMin:
+			c.res.Timestamps = append(c.res.Timestamps, w.minTime)
+			c.res.Values = append(c.res.Values, w.min)
+		case datatypes.Aggregate_AggregateTypeMax:
+			c.res.Timestamps = append(c.res.Timestamps, w.maxTime)
+			c.res.Values = append(c.res.Values, w.max)
+		case datatypes.Aggregate_AggregateTypeFirst:
+			c.res.Timestamps = append(c.res.Timestamps, w.firstTime)
+			c.res.Values = append(c.res.Values, w.first)
+		case datatypes.Aggregate_AggregateTypeLast:
+			c.res.Timestamps = append(c.res.Timestamps, w.lastTime)
+			c.res.Values = append(c.res.Values, w.last)
+		case datatypes.Aggregate_AggregateTypeMean:
+			c.res.Timestamps = append(c.res.Timestamps, w.stop)
+			c.res.Values = append(c.res.Values, w.sum/float64(w.count))
+		}
+	}
+	return c.res
+}
+
+// floatWindowSummaryCountArrayCursor computes the count of the windows of
+// a series.
+type floatWindowSummaryCountArrayCursor struct {
+	*floatWindowSummaryReader
+	res *cursors.IntegerArray
+}
+
+func (c *floatWindowSummaryCountArrayCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w floatWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		c.res.Timestamps = append(c.res.Timestamps, w.stop)
+		c.res.Values = append(c.res.Values, w.count)
+	}
+	return c.res
+}
+
+// newFloatWindowSummaryArrayCursor returns a cursor computing the window
+// aggregate agg of the series read by cur and the cursors of the remaining
+// shards in cc.
+func newFloatWindowSummaryArrayCursor(cc cursorContext, cur cursors.FloatArrayCursor, agg datatypes.Aggregate_AggregateType, window interval.Window, summarize func(minTime, maxTime int64) bool) cursors.Cursor {
+	r := &floatWindowSummaryReader{
+		cursorContext: cc,
+		window:        window,
+		summarize:     summarize,
+	}
+	r.reset(cur)
+
+	switch agg {
+	case datatypes.Aggregate_AggregateTypeCount:
+		return &floatWindowSummaryCountArrayCursor{
+			floatWindowSummaryReader: r,
+			res:                      cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		}
+	default:
+		return &floatWindowSummaryArrayCursor{
+			floatWindowSummaryReader: r,
+			agg:                      agg,
+			res:                      cursors.NewFloatArrayLen(MaxPointsPerBlock),
+		}
+	}
+}
+
+// integerWindow holds the aggregates of the values of a window.
+type integerWindow struct {
+	stop                       int64
+	count                      int64
+	sum, min, max, first, last int64
+	minTime, maxTime           int64
+	firstTime, lastTime        int64
+}
+
+func (w *integerWindow) add(t int64, v int64) {
+	if w.count == 0 {
+		w.first, w.firstTime = v, t
+		w.min, w.minTime = v, t
+		w.max, w.maxTime = v, t
+	} else {
+		if v < w.min {
+			w.min, w.minTime = v, t
+		}
+		if v > w.max {
+			w.max, w.maxTime = v, t
+		}
+	}
+	w.last, w.lastTime = v, t
+	w.count++
+	w.sum += v
+}
+
+func (w *integerWindow) addSummary(s *cursors.IntegerBlockSummary) {
+	if w.count == 0 {
+		w.first, w.firstTime = s.First, s.MinTime
+		w.min, w.minTime = s.Min, s.MinValueTime
+		w.max, w.maxTime = s.Max, s.MaxValueTime
+	} else {
+		if s.Min < w.min {
+			w.min, w.minTime = s.Min, s.MinValueTime
+		}
+		if s.Max > w.max {
+			w.max, w.maxTime = s.Max, s.MaxValueTime
+		}
+	}
+	w.last, w.lastTime = s.Last, s.MaxTime
+	w.count += s.Count
+	w.sum += s.Sum
+}
+
+// integerWindowSummaryReader reads the windows of a series across shards,
+// aggregating the values returned by the shards' cursors together with the
+// summaries of the blocks the cursors do not return.
+type integerWindowSummaryReader struct {
+	cursorContext
+	window    interval.Window
+	summarize func(minTime, maxTime int64) bool
+
+	cur       cursors.IntegerArrayCursor
+	ts        []int64
+	vs        []int64
+	summaries []cursors.IntegerBlockSummary
+	stats     cursors.CursorStats
+}
+
+func (r *integerWindowSummaryReader) reset(cur cursors.IntegerArrayCursor) {
+	r.cur = cur
+	r.ts, r.vs = nil, nil
+	r.summaries = nil
+	if c, ok := cur.(cursors.IntegerSummaryArrayCursor); ok {
+		r.summaries = c.Summaries()
+	}
+}
+
+func (r *integerWindowSummaryReader) Err() error { return r.err }
+
+func (r *integerWindowSummaryReader) Stats() cursors.CursorStats {
+	stats := r.stats
+	if r.cur != nil {
+		stats.Add(r.cur.Stats())
+	}
+	return stats
+}
+
+func (r *integerWindowSummaryReader) Close() {
+	if r.cur != nil {
+		r.stats.Add(r.cur.Stats())
+		r.cur.Close()
+		r.cur = nil
+	}
+}
+
+// peek reports whether there is a value or summary left to read.
+func (r *integerWindowSummaryReader) peek() bool {
+	for {
+		if len(r.ts) == 0 && r.cur != nil {
+			a := r.cur.Next()
+			r.ts, r.vs = a.Timestamps, a.Values
+			if len(r.ts) == 0 {
+				r.Close()
+			}
+		}
+		if len(r.ts) > 0 || len(r.summaries) > 0 {
+			return true
+		}
+		if !r.nextShard() {
+			return false
+		}
+	}
+}
+
+func (r *integerWindowSummaryReader) nextShard() bool {
+	for r.err == nil && len(r.itrs) > 0 {
+		var itr cursors.CursorIterator
+		itr, r.itrs = r.itrs[0], r.itrs[1:]
+		cur, _ := nextSummarizedCursor(r.ctx, itr, r.req, r.summarize)
+		if cur == nil {
+			continue
+		}
+		c, ok := cur.(cursors.IntegerArrayCursor)
+		if !ok {
+			cur.Close()
+			r.err = errors.New("expected integer cursor")
+			return false
+		}
+		r.reset(c)
+		return true
+	}
+	return false
+}
+
+// next reads the next window with values into w.
+func (r *integerWindowSummaryReader) next(w *integerWindow) bool {
+	*w = integerWindow{}
+	for r.peek() {
+		if len(r.ts) > 0 && (len(r.summaries) == 0 || r.ts[0] < r.summaries[0].MinTime) {
+			t := r.ts[0]
+			if w.count == 0 {
+				w.stop = int64(r.window.GetLatestBounds(values.Time(t)).Stop())
+			} else if t >= w.stop {
+				break
+			}
+			w.add(t, r.vs[0])
+			r.ts, r.vs = r.ts[1:], r.vs[1:]
+			continue
+		}
+
+		s := &r.summaries[0]
+		if w.count == 0 {
+			w.stop = int64(r.window.GetLatestBounds(values.Time(s.MinTime)).Stop())
+		} else if s.MinTime >= w.stop {
+			break
+		}
+		w.addSummary(s)
+		r.summaries = r.summaries[1:]
+	}
+	return w.count > 0
+}
+
+// integerWindowSummaryArrayCursor computes the sum, min, max, first or last
+// of the windows of a series.
+type integerWindowSummaryArrayCursor struct {
+	*integerWindowSummaryReader
+	agg datatypes.Aggregate_AggregateType
+	res *cursors.IntegerArray
+}
+
+func (c *integerWindowSummaryArrayCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w integerWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		switch c.agg {
+		case datatypes.Aggregate_AggregateTypeSum:
+			c.res.Timestamps = append(c.res.Timestamps, w.stop)
+			c.res.Values = append(c.res.Values, w.sum)
+		case datatypes.Aggregate_AggregateTypeMin:
+			c.res.Timestamps = append(c.res.Timestamps, w.minTime)
+			c.res.Values = append(c.res.Values, w.min)
+		case datatypes.Aggregate_AggregateTypeMax:
+			c.res.Timestamps = append(c.res.Timestamps, w.maxTime)
+			c.res.Values = append(c.res.Values, w.max)
+		case datatypes.Aggregate_AggregateTypeFirst:
+			c.res.Timestamps = append(c.res.Timestamps, w.firstTime)
+			c.res.Values = append(c.res.Values, w.first)
+		case datatypes.Aggregate_AggregateTypeLast:
+			c.res.Timestamps = append(c.res.Timestamps, w.lastTime)
+			c.res.Values = append(c.res.Values, w.last)
+		}
+	}
+	return c.res
+}
+
+// integerWindowSummaryCountArrayCursor computes the count of the windows of
+// a series.
+type integerWindowSummaryCountArrayCursor struct {
+	*integerWindowSummaryReader
+	res *cursors.IntegerArray
+}
+
+func (c *integerWindowSummaryCountArrayCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w integerWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		c.res.Timestamps = append(c.res.Timestamps, w.stop)
+		c.res.Values = append(c.res.Values, w.count)
+	}
+	return c.res
+}
+
+// integerWindowSummaryMeanArrayCursor computes the mean of the windows of a
+// series.
+type integerWindowSummaryMeanArrayCursor struct {
+	*integerWindowSummaryReader
+	res *cursors.FloatArray
+}
+
+func (c *integerWindowSummaryMeanArrayCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w integerWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		c.res.Timestamps = append(c.res.Timestamps, w.stop)
+		c.res.Values = append(c.res.Values, float64(w.sum)/float64(w.count))
+	}
+	return c.res
+}
+
+// newIntegerWindowSummaryArrayCursor returns a cursor computing the window
+// aggregate agg of the series read by cur and the cursors of the remaining
+// shards in cc.
+func newIntegerWindowSummaryArrayCursor(cc cursorContext, cur cursors.IntegerArrayCursor, agg datatypes.Aggregate_AggregateType, window interval.Window, summarize func(minTime, maxTime int64) bool) cursors.Cursor {
+	r := &integerWindowSummaryReader{
+		cursorContext: cc,
+		window:        window,
+		summarize:     summarize,
+	}
+	r.reset(cur)
+
+	switch agg {
+	case datatypes.Aggregate_AggregateTypeCount:
+		return &integerWindowSummaryCountArrayCursor{
+			integerWindowSummaryReader: r,
+			res:                        cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		}
+	case datatypes.Aggregate_AggregateTypeMean:
+		return &integerWindowSummaryMeanArrayCursor{
+			integerWindowSummaryReader: r,
+			res:                        cursors.NewFloatArrayLen(MaxPointsPerBlock),
+		}
+	default:
+		return &integerWindowSummaryArrayCursor{
+			integerWindowSummaryReader: r,
+			agg:                        agg,
+			res:                        cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		}
+	}
+}
+
+// unsignedWindow holds the aggregates of the values of a window.
+type unsignedWindow struct {
+	stop                       int64
+	count                      int64
+	sum, min, max, first, last uint64
+	minTime, maxTime           int64
+	firstTime, lastTime        int64
+}
+
+func (w *unsignedWindow) add(t int64, v uint64) {
+	if w.count == 0 {
+		w.first, w.firstTime = v, t
+		w.min, w.minTime = v, t
+		w.max, w.maxTime = v, t
+	} else {
+		if v < w.min {
+			w.min, w.minTime = v, t
+		}
+		if v > w.max {
+			w.max, w.maxTime = v, t
+		}
+	}
+	w.last, w.lastTime = v, t
+	w.count++
+	w.sum += v
+}
+
+func (w *unsignedWindow) addSummary(s *cursors.UnsignedBlockSummary) {
+	if w.count == 0 {
+		w.first, w.firstTime = s.First, s.MinTime
+		w.min, w.minTime = s.Min, s.MinValueTime
+		w.max, w.maxTime = s.Max, s.MaxValueTime
+	} else {
+		if s.Min < w.min {
+			w.min, w.minTime = s.Min, s.MinValueTime
+		}
+		if s.Max > w.max {
+			w.max, w.maxTime = s.Max, s.MaxValueTime
+		}
+	}
+	w.last, w.lastTime = s.Last, s.MaxTime
+	w.count += s.Count
+	w.sum += s.Sum
+}
+
+// unsignedWindowSummaryReader reads the windows of a series across shards,
+// aggregating the values returned by the shards' cursors together with the
+// summaries of the blocks the cursors do not return.
+type unsignedWindowSummaryReader struct {
+	cursorContext
+	window    interval.Window
+	summarize func(minTime, maxTime int64) bool
+
+	cur       cursors.UnsignedArrayCursor
+	ts        []int64
+	vs        []uint64
+	summaries []cursors.UnsignedBlockSummary
+	stats     cursors.CursorStats
+}
+
+func (r *unsignedWindowSummaryReader) reset(cur cursors.UnsignedArrayCursor) {
+	r.cur = cur
+	r.ts, r.vs = nil, nil
+	r.summaries = nil
+	if c, ok := cur.(cursors.UnsignedSummaryArrayCursor); ok {
+		r.summaries = c.Summaries()
+	}
+}
+
+func (r *unsignedWindowSummaryReader) Err() error { return r.err }
+
+func (r *unsignedWindowSummaryReader) Stats() cursors.CursorStats {
+	stats := r.stats
+	if r.cur != nil {
+		stats.Add(r.cur.Stats())
+	}
+	return stats
+}
+
+func (r *unsignedWindowSummaryReader) Close() {
+	if r.cur != nil {
+		r.stats.Add(r.cur.Stats())
+		r.cur.Close()
+		r.cur = nil
+	}
+}
+
+// peek reports whether there is a value or summary left to read.
+func (r *unsignedWindowSummaryReader) peek() bool {
+	for {
+		if len(r.ts) == 0 && r.cur != nil {
+			a := r.cur.Next()
+			r.ts, r.vs = a.Timestamps, a.Values
+			if len(r.ts) == 0 {
+				r.Close()
+			}
+		}
+		if len(r.ts) > 0 || len(r.summaries) > 0 {
+			return true
+		}
+		if !r.nextShard() {
+			return false
+		}
+	}
+}
+
+func (r *unsignedWindowSummaryReader) nextShard() bool {
+	for r.err == nil && len(r.itrs) > 0 {
+		var itr cursors.CursorIterator
+		itr, r.itrs = r.itrs[0], r.itrs[1:]
+		cur, _ := nextSummarizedCursor(r.ctx, itr, r.req, r.summarize)
+		if cur == nil {
+			continue
+		}
+		c, ok := cur.(cursors.UnsignedArrayCursor)
+		if !ok {
+			cur.Close()
+			r.err = errors.New("expected unsigned cursor")
+			return false
+		}
+		r.reset(c)
+		return true
+	}
+	return false
+}
+
+// next reads the next window with values into w.
+func (r *unsignedWindowSummaryReader) next(w *unsignedWindow) bool {
+	*w = unsignedWindow{}
+	for r.peek() {
+		if len(r.ts) > 0 && (len(r.summaries) == 0 || r.ts[0] < r.summaries[0].MinTime) {
+			t := r.ts[0]
+			if w.count == 0 {
+				w.stop = int64(r.window.GetLatestBounds(values.Time(t)).Stop())
+			} else if t >= w.stop {
+				break
+			}
+			w.add(t, r.vs[0])
+			r.ts, r.vs = r.ts[1:], r.vs[1:]
+			continue
+		}
+
+		s := &r.summaries[0]
+		if w.count == 0 {
+			w.stop = int64(r.window.GetLatestBounds(values.Time(s.MinTime)).Stop())
+		} else if s.MinTime >= w.stop {
+			break
+		}
+		w.addSummary(s)
+		r.summaries = r.summaries[1:]
+	}
+	return w.count > 0
+}
+
+// unsignedWindowSummaryArrayCursor computes the sum, min, max, first or last
+// of the windows of a series.
+type unsignedWindowSummaryArrayCursor struct {
+	*unsignedWindowSummaryReader
+	agg datatypes.Aggregate_AggregateType
+	res *cursors.UnsignedArray
+}
+
+func (c *unsignedWindowSummaryArrayCursor) Next() *cursors.UnsignedArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w unsignedWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		switch c.agg {
+		case datatypes.Aggregate_AggregateTypeSum:
+			c.res.Timestamps = append(c.res.Timestamps, w.stop)
+			c.res.Values = append(c.res.Values, w.sum)
+		case datatypes.Aggregate_AggregateTypeMin:
+			c.res.Timestamps = append(c.res.Timestamps, w.minTime)
+			c.res.Values = append(c.res.Values, w.min)
+		case datatypes.Aggregate_AggregateTypeMax:
+			c.res.Timestamps = append(c.res.Timestamps, w.maxTime)
+			c.res.Values = append(c.res.Values, w.max)
+		case datatypes.Aggregate_AggregateTypeFirst:
+			c.res.Timestamps = append(c.res.Timestamps, w.firstTime)
+			c.res.Values = append(c.res.Values, w.first)
+		case datatypes.Aggregate_AggregateTypeLast:
+			c.res.Timestamps = append(c.res.Timestamps, w.lastTime)
+			c.res.Values = append(c.res.Values, w.last)
+		}
+	}
+	return c.res
+}
+
+// unsignedWindowSummaryCountArrayCursor computes the count of the windows of
+// a series.
+type unsignedWindowSummaryCountArrayCursor struct {
+	*unsignedWindowSummaryReader
+	res *cursors.IntegerArray
+}
+
+func (c *unsignedWindowSummaryCountArrayCursor) Next() *cursors.IntegerArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w unsignedWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		c.res.Timestamps = append(c.res.Timestamps, w.stop)
+		c.res.Values = append(c.res.Values, w.count)
+	}
+	return c.res
+}
+
+// unsignedWindowSummaryMeanArrayCursor computes the mean of the windows of a
+// series.
+type unsignedWindowSummaryMeanArrayCursor struct {
+	*unsignedWindowSummaryReader
+	res *cursors.FloatArray
+}
+
+func (c *unsignedWindowSummaryMeanArrayCursor) Next() *cursors.FloatArray {
+	c.res.Timestamps = c.res.Timestamps[:0]
+	c.res.Values = c.res.Values[:0]
+
+	var w unsignedWindow
+	for c.res.Len() < MaxPointsPerBlock && c.next(&w) {
+		c.res.Timestamps = append(c.res.Timestamps, w.stop)
+		c.res.Values = append(c.res.Values, float64(w.sum)/float64(w.count))
+	}
+	return c.res
+}
+
+// newUnsignedWindowSummaryArrayCursor returns a cursor computing the window
+// aggregate agg of the series read by cur and the cursors of the remaining
+// shards in cc.
+func newUnsignedWindowSummaryArrayCursor(cc cursorContext, cur cursors.UnsignedArrayCursor, agg datatypes.Aggregate_AggregateType, window interval.Window, summarize func(minTime, maxTime int64) bool) cursors.Cursor {
+	r := &unsignedWindowSummaryReader{
+		cursorContext: cc,
+		window:        window,
+		summarize:     summarize,
+	}
+	r.reset(cur)
+
+	switch agg {
+	case datatypes.Aggregate_AggregateTypeCount:
+		return &unsignedWindowSummaryCountArrayCursor{
+			unsignedWindowSummaryReader: r,
+			res:                         cursors.NewIntegerArrayLen(MaxPointsPerBlock),
+		}
+	case datatypes.Aggregate_AggregateTypeMean:
+		return &unsignedWindowSummaryMeanArrayCursor{
+			unsignedWindowSummaryReader: r,
+			res:                         cursors.NewFloatArrayLen(MaxPointsPerBlock),
+		}
+	default:
+		return &unsignedWindowSummaryArrayCursor{
+			unsignedWindowSummaryReader: r,
+			agg:                         agg,
+			res:                         cursors.NewUnsignedArrayLen(MaxPointsPerBlock),
+		}
+	}
+}
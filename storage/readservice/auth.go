@@ -0,0 +1,111 @@
+package readservice
+
+import (
+	"context"
+	"strings"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/authorizer"
+	icontext "github.com/influxdata/influxdb/v2/context"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/v1/services/storage"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	authorizationKey = "authorization"
+	tokenScheme      = "token "
+	bearerScheme     = "bearer "
+)
+
+// authenticate is a stream interceptor that places the authorization of the
+// token of the stream's metadata on the stream's context.
+func (s *Server) authenticate(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := ss.Context()
+	auth, err := s.findAuthorization(ctx)
+	if err != nil {
+		s.log.Info("Unauthorized", zap.String("method", info.FullMethod), zap.Error(err))
+		return toStatus(&errors.Error{Code: errors.EUnauthorized, Msg: "unauthorized access", Err: err})
+	}
+	return handler(srv, &authenticatedStream{
+		ServerStream: ss,
+		ctx:          icontext.SetAuthorizer(ctx, auth),
+	})
+}
+
+func (s *Server) findAuthorization(ctx context.Context) (*influxdb.Authorization, error) {
+	token, err := getToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := s.auths.FindAuthorizationByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !auth.IsActive() {
+		return nil, &errors.Error{Code: errors.EForbidden, Msg: "authorization is inactive"}
+	}
+	if auth.GetUserID().Valid() {
+		u, err := s.users.FindUserByID(ctx, auth.GetUserID())
+		if err != nil {
+			return nil, err
+		}
+		if u.Status == influxdb.Inactive {
+			return nil, &errors.Error{Code: errors.EForbidden, Msg: "User is inactive"}
+		}
+	}
+	return auth, nil
+}
+
+// getToken parses the token from the authorization metadata of ctx.
+func getToken(ctx context.Context) (string, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	vs := md.Get(authorizationKey)
+	if len(vs) == 0 || vs[0] == "" {
+		return "", &errors.Error{Code: errors.EUnauthorized, Msg: "authorization metadata is missing"}
+	}
+	v := vs[0]
+	for _, scheme := range []string{tokenScheme, bearerScheme} {
+		if len(v) > len(scheme) && strings.EqualFold(v[:len(scheme)], scheme) {
+			return v[len(scheme):], nil
+		}
+	}
+	return "", &errors.Error{Code: errors.EUnauthorized, Msg: "authorization scheme is invalid"}
+}
+
+// authorizeRead returns an error if the authorizer of ctx may not read the
+// bucket of the read source src.
+func (s *Server) authorizeRead(ctx context.Context, src *anypb.Any) error {
+	rs, err := storage.GetReadSource(src)
+	if err != nil {
+		return &errors.Error{Code: errors.EInvalid, Msg: "invalid read source", Err: err}
+	}
+	orgID, bucketID := platform.ID(rs.OrgID), platform.ID(rs.BucketID)
+
+	// The storage engine finds data by bucket alone, so make sure the bucket
+	// belongs to the organization the authorizer is checked against.
+	b, err := s.buckets.FindBucketByID(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if b.OrgID != orgID {
+		return &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	}
+	if _, _, err := authorizer.AuthorizeReadBucket(ctx, b.Type, b.ID, b.OrgID); err != nil {
+		// The client is authenticated, so report a lack of permission
+		// rather than of credentials.
+		return &errors.Error{Code: errors.EForbidden, Msg: errors.ErrorMessage(err), Err: err}
+	}
+	return nil
+}
+
+type authenticatedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *authenticatedStream) Context() context.Context { return s.ctx }
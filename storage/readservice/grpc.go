@@ -0,0 +1,297 @@
+package readservice
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is the full name of the gRPC storage read service.
+const ServiceName = "influxdata.platform.storage.Storage"
+
+// stringValuesBatchSize is the number of values sent in each
+// StringValuesResponse of the TagKeys and TagValues methods.
+const stringValuesBatchSize = 1000
+
+// Server serves reads of the storage engine to external clients over gRPC.
+// The service is described by storage.proto.
+type Server struct {
+	log     *zap.Logger
+	store   reads.Store
+	auths   influxdb.AuthorizationService
+	users   influxdb.UserService
+	buckets influxdb.BucketService
+}
+
+// NewServer returns a Server reading from store. Clients are authenticated by
+// the token of their requests and authorized to read buckets by the
+// permissions of its authorization.
+func NewServer(log *zap.Logger, store reads.Store, auths influxdb.AuthorizationService, users influxdb.UserService, buckets influxdb.BucketService) *Server {
+	return &Server{
+		log:     log,
+		store:   store,
+		auths:   auths,
+		users:   users,
+		buckets: buckets,
+	}
+}
+
+// NewGRPCServer returns a gRPC server with s registered, created with opts.
+func (s *Server) NewGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(append(opts, grpc.StreamInterceptor(s.authenticate))...)
+	srv.RegisterService(&serviceDesc, s)
+	return srv
+}
+
+// ReadFilter streams the series matching req.
+func (s *Server) ReadFilter(ctx context.Context, req *datatypes.ReadFilterRequest, stream reads.ResponseStream) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.authorizeRead(ctx, req.ReadSource); err != nil {
+		return err
+	}
+	rs, err := s.store.ReadFilter(ctx, req)
+	if err != nil {
+		return err
+	}
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+	return writeResultSet(stream, rs)
+}
+
+// ReadGroup streams the groups of the series matching req.
+func (s *Server) ReadGroup(ctx context.Context, req *datatypes.ReadGroupRequest, stream reads.ResponseStream) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.authorizeRead(ctx, req.ReadSource); err != nil {
+		return err
+	}
+	rs, err := s.store.ReadGroup(ctx, req)
+	if err != nil {
+		return err
+	}
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+
+	w := reads.NewResponseWriter(stream)
+	if err := w.WriteGroupResultSet(rs); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Err()
+}
+
+// ReadWindowAggregate streams the series matching req aggregated by window.
+func (s *Server) ReadWindowAggregate(ctx context.Context, req *datatypes.ReadWindowAggregateRequest, stream reads.ResponseStream) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.authorizeRead(ctx, req.ReadSource); err != nil {
+		return err
+	}
+	for _, agg := range req.Aggregate {
+		if !s.store.SupportWindowAggregate(ctx, agg.Type) {
+			return &errors.Error{
+				Code: errors.EInvalid,
+				Msg:  "unsupported window aggregate " + agg.Type.String(),
+			}
+		}
+	}
+	rs, err := s.store.WindowAggregate(ctx, req)
+	if err != nil {
+		return err
+	}
+	if rs == nil {
+		return nil
+	}
+	defer rs.Close()
+	return writeResultSet(stream, rs)
+}
+
+// TagKeys streams the tag keys of the series matching req.
+func (s *Server) TagKeys(ctx context.Context, req *datatypes.TagKeysRequest, stream stringValuesStream) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.authorizeRead(ctx, req.TagsSource); err != nil {
+		return err
+	}
+	itr, err := s.store.TagKeys(ctx, req)
+	if err != nil {
+		return err
+	}
+	return writeStringValues(stream, itr)
+}
+
+// TagValues streams the values of the tag key of the series matching req.
+func (s *Server) TagValues(ctx context.Context, req *datatypes.TagValuesRequest, stream stringValuesStream) error {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	if err := s.authorizeRead(ctx, req.TagsSource); err != nil {
+		return err
+	}
+	itr, err := s.store.TagValues(ctx, req)
+	if err != nil {
+		return err
+	}
+	return writeStringValues(stream, itr)
+}
+
+func writeResultSet(stream reads.ResponseStream, rs reads.ResultSet) error {
+	w := reads.NewResponseWriter(stream)
+	if err := w.WriteResultSet(rs); err != nil {
+		return err
+	}
+	w.Flush()
+	return w.Err()
+}
+
+type stringValuesStream interface {
+	Send(*datatypes.StringValuesResponse) error
+}
+
+func writeStringValues(stream stringValuesStream, itr cursors.StringIterator) error {
+	if itr == nil {
+		return nil
+	}
+	values := make([][]byte, 0, stringValuesBatchSize)
+	for itr.Next() {
+		values = append(values, []byte(itr.Value()))
+		if len(values) == stringValuesBatchSize {
+			if err := stream.Send(&datatypes.StringValuesResponse{Values: values}); err != nil {
+				return err
+			}
+			values = make([][]byte, 0, stringValuesBatchSize)
+		}
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	return stream.Send(&datatypes.StringValuesResponse{Values: values})
+}
+
+// toStatus returns err as a gRPC status error, mapping the codes of platform
+// errors to their gRPC equivalents.
+func toStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	var code codes.Code
+	switch errors.ErrorCode(err) {
+	case errors.EInvalid, errors.EUnprocessableEntity:
+		code = codes.InvalidArgument
+	case errors.ENotFound:
+		code = codes.NotFound
+	case errors.EUnauthorized:
+		code = codes.Unauthenticated
+	case errors.EForbidden:
+		code = codes.PermissionDenied
+	case errors.ETooManyRequests:
+		code = codes.ResourceExhausted
+	case errors.EUnavailable:
+		code = codes.Unavailable
+	case errors.ENotImplemented:
+		code = codes.Unimplemented
+	case errors.ETooLarge:
+		code = codes.OutOfRange
+	default:
+		if ctxErr := status.FromContextError(err); ctxErr.Code() != codes.Unknown {
+			return ctxErr.Err()
+		}
+		code = codes.Internal
+	}
+	return status.Error(code, errors.ErrorMessage(err))
+}
+
+type readResponseStream struct{ grpc.ServerStream }
+
+func (s readResponseStream) Send(m *datatypes.ReadResponse) error { return s.SendMsg(m) }
+
+type stringValuesResponseStream struct{ grpc.ServerStream }
+
+func (s stringValuesResponseStream) Send(m *datatypes.StringValuesResponse) error {
+	return s.SendMsg(m)
+}
+
+// serviceDesc describes the Storage service of storage.proto.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*interface{})(nil),
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ReadFilter",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.ReadFilterRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return toStatus(srv.(*Server).ReadFilter(stream.Context(), req, readResponseStream{stream}))
+			},
+		},
+		{
+			StreamName:    "ReadGroup",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.ReadGroupRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return toStatus(srv.(*Server).ReadGroup(stream.Context(), req, readResponseStream{stream}))
+			},
+		},
+		{
+			StreamName:    "ReadWindowAggregate",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.ReadWindowAggregateRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return toStatus(srv.(*Server).ReadWindowAggregate(stream.Context(), req, readResponseStream{stream}))
+			},
+		},
+		{
+			StreamName:    "TagKeys",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.TagKeysRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return toStatus(srv.(*Server).TagKeys(stream.Context(), req, stringValuesResponseStream{stream}))
+			},
+		},
+		{
+			StreamName:    "TagValues",
+			ServerStreams: true,
+			Handler: func(srv interface{}, stream grpc.ServerStream) error {
+				req := new(datatypes.TagValuesRequest)
+				if err := stream.RecvMsg(req); err != nil {
+					return err
+				}
+				return toStatus(srv.(*Server).TagValues(stream.Context(), req, stringValuesResponseStream{stream}))
+			},
+		},
+	},
+	Metadata: "storage.proto",
+}
@@ -0,0 +1,212 @@
+package readservice
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/pkg/data/gen"
+	"github.com/influxdata/influxdb/v2/storage/reads"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb/cursors"
+	"github.com/influxdata/influxdb/v2/v1/services/storage"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+var (
+	orgID          = platform.ID(10)
+	bucketID       = platform.ID(20)
+	otherBucketID  = platform.ID(21)
+	otherOrgBucket = platform.ID(22)
+	userID         = platform.ID(30)
+)
+
+func newTestClient(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+
+	store := &mock.ReadsStore{
+		ReadFilterFn: func(_ context.Context, req *datatypes.ReadFilterRequest) (reads.ResultSet, error) {
+			spec, err := gen.NewSpecFromToml(`
+[[measurements]]
+name = "m0"
+sample = 1.0
+tags = [
+	{ name = "tag0", source = { type = "sequence", start = 0, count = 2 } },
+]
+fields = [
+	{ name = "v0", count = 3, source = 1 },
+]`)
+			require.NoError(t, err)
+			return mock.NewResultSetFromSeriesGenerator(gen.NewSeriesGeneratorFromSpec(spec, gen.TimeRange{
+				Start: time.Unix(0, req.Range.Start),
+				End:   time.Unix(0, req.Range.End),
+			})), nil
+		},
+		TagValuesFn: func(_ context.Context, req *datatypes.TagValuesRequest) (cursors.StringIterator, error) {
+			values := make([]string, stringValuesBatchSize+1)
+			for i := range values {
+				values[i] = req.TagKey
+			}
+			return cursors.NewStringSliceIterator(values), nil
+		},
+	}
+
+	auths := mock.NewAuthorizationService()
+	auths.FindAuthorizationByTokenFn = func(_ context.Context, token string) (*influxdb.Authorization, error) {
+		switch token {
+		case "reader":
+			return &influxdb.Authorization{
+				ID:     1,
+				UserID: userID,
+				OrgID:  orgID,
+				Status: influxdb.Active,
+				Permissions: []influxdb.Permission{{
+					Action:   influxdb.ReadAction,
+					Resource: influxdb.Resource{Type: influxdb.BucketsResourceType, ID: &bucketID, OrgID: &orgID},
+				}},
+			}, nil
+		case "inactive":
+			return &influxdb.Authorization{ID: 2, UserID: userID, OrgID: orgID, Status: influxdb.Inactive}, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "authorization not found"}
+	}
+	users := mock.NewUserService()
+	users.FindUserByIDFn = func(_ context.Context, id platform.ID) (*influxdb.User, error) {
+		return &influxdb.User{ID: id, Status: influxdb.Active}, nil
+	}
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		if id == otherOrgBucket {
+			return &influxdb.Bucket{ID: id, OrgID: orgID + 1}, nil
+		}
+		return &influxdb.Bucket{ID: id, OrgID: orgID}, nil
+	}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(zaptest.NewLogger(t), store, auths, users, buckets).NewGRPCServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// call invokes the streaming method of the service with req and returns the
+// messages received, created by newMsg.
+func call(ctx context.Context, conn *grpc.ClientConn, method string, req proto.Message, newMsg func() proto.Message) ([]proto.Message, error) {
+	desc := &grpc.StreamDesc{StreamName: method, ServerStreams: true}
+	stream, err := conn.NewStream(ctx, desc, "/"+ServiceName+"/"+method)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(req); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	var msgs []proto.Message
+	for {
+		m := newMsg()
+		if err := stream.RecvMsg(m); err == io.EOF {
+			return msgs, nil
+		} else if err != nil {
+			return msgs, err
+		}
+		msgs = append(msgs, m)
+	}
+}
+
+func readSource(t *testing.T, bucketID platform.ID) *anypb.Any {
+	t.Helper()
+	src, err := anypb.New(&storage.ReadSource{OrgID: uint64(orgID), BucketID: uint64(bucketID)})
+	require.NoError(t, err)
+	return src
+}
+
+func withToken(token string) context.Context {
+	return metadata.AppendToOutgoingContext(context.Background(), "authorization", "Token "+token)
+}
+
+func TestServer_ReadFilter(t *testing.T) {
+	conn := newTestClient(t)
+	req := &datatypes.ReadFilterRequest{
+		ReadSource: readSource(t, bucketID),
+		Range:      &datatypes.TimestampRange{Start: 0, End: int64(3 * time.Second)},
+	}
+	newMsg := func() proto.Message { return new(datatypes.ReadResponse) }
+
+	msgs, err := call(withToken("reader"), conn, "ReadFilter", req, newMsg)
+	require.NoError(t, err)
+	require.Len(t, msgs, 1)
+	frames := msgs[0].(*datatypes.ReadResponse).Frames
+	require.Len(t, frames, 4)
+	require.NotNil(t, frames[0].GetSeries())
+	require.Equal(t, []int64{1, 1, 1}, frames[1].GetIntegerPoints().Values)
+	require.Equal(t, []int64{0, int64(time.Second), int64(2 * time.Second)}, frames[1].GetIntegerPoints().Timestamps)
+
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		req  *datatypes.ReadFilterRequest
+		code codes.Code
+	}{
+		{name: "missing token", ctx: context.Background(), req: req, code: codes.Unauthenticated},
+		{name: "unknown token", ctx: withToken("unknown"), req: req, code: codes.Unauthenticated},
+		{name: "inactive token", ctx: withToken("inactive"), req: req, code: codes.Unauthenticated},
+		{
+			name: "other bucket",
+			ctx:  withToken("reader"),
+			req:  &datatypes.ReadFilterRequest{ReadSource: readSource(t, otherBucketID)},
+			code: codes.PermissionDenied,
+		},
+		{
+			name: "bucket of other organization",
+			ctx:  withToken("reader"),
+			req:  &datatypes.ReadFilterRequest{ReadSource: readSource(t, otherOrgBucket)},
+			code: codes.NotFound,
+		},
+		{
+			name: "missing source",
+			ctx:  withToken("reader"),
+			req:  &datatypes.ReadFilterRequest{},
+			code: codes.InvalidArgument,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := call(tt.ctx, conn, "ReadFilter", tt.req, newMsg)
+			require.Equal(t, tt.code, status.Code(err), "unexpected error %v", err)
+		})
+	}
+}
+
+func TestServer_TagValues(t *testing.T) {
+	conn := newTestClient(t)
+	req := &datatypes.TagValuesRequest{TagsSource: readSource(t, bucketID), TagKey: "tag0"}
+
+	msgs, err := call(withToken("reader"), conn, "TagValues", req, func() proto.Message { return new(datatypes.StringValuesResponse) })
+	require.NoError(t, err)
+	require.Len(t, msgs, 2)
+	require.Len(t, msgs[0].(*datatypes.StringValuesResponse).Values, stringValuesBatchSize)
+	require.Equal(t, [][]byte{[]byte("tag0")}, msgs[1].(*datatypes.StringValuesResponse).Values)
+}
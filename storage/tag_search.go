@@ -0,0 +1,89 @@
+package storage
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	errors2 "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/kit/tracing"
+	"github.com/influxdata/influxdb/v2/storage/reads/datatypes"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// SearchTagValues returns the values of a tag key of a bucket matching opts.
+// The values of the _measurement key are the names of the bucket's
+// measurements.
+func (e *Engine) SearchTagValues(ctx context.Context, orgID, bucketID platform.ID, key string, opts influxdb.TagSearchOptions) (*influxdb.TagSearchResult, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx)
+	defer span.Finish()
+
+	search := tsdb.Search{
+		Prefix: opts.Prefix,
+		Fuzzy:  opts.Fuzzy,
+		After:  opts.After,
+		Limit:  opts.Limit,
+	}
+	if search.Limit <= 0 {
+		search.Limit = influxdb.DefaultTagSearchLimit
+	} else if search.Limit > influxdb.MaxTagSearchLimit {
+		search.Limit = influxdb.MaxTagSearchLimit
+	}
+	if opts.Regex != "" {
+		re, err := regexp.Compile(opts.Regex)
+		if err != nil {
+			return nil, &errors2.Error{
+				Code: errors2.EInvalid,
+				Msg:  "invalid regular expression",
+				Err:  err,
+			}
+		}
+		search.Regex = re
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if e.closing == nil {
+		return nil, ErrEngineClosed
+	}
+
+	if e.metaClient.Database(bucketID.String()) == nil {
+		return nil, &errors2.Error{
+			Code: errors2.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+
+	var shardIDs []uint64
+	for _, sh := range e.tsdbStore.ShardsByDatabase(bucketID.String()) {
+		shardIDs = append(shardIDs, sh.ID())
+	}
+
+	var (
+		values []string
+		more   bool
+		err    error
+	)
+	if key == datatypes.MeasurementKey {
+		values, more, err = e.tsdbStore.SearchMeasurementNames(ctx, shardIDs, search)
+	} else {
+		var name []byte
+		if opts.Measurement != "" {
+			name = []byte(opts.Measurement)
+		}
+		values, more, err = e.tsdbStore.SearchTagValues(ctx, shardIDs, name, []byte(key), search)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	result := &influxdb.TagSearchResult{Values: values}
+	if result.Values == nil {
+		result.Values = []string{}
+	}
+	if more {
+		result.Next = values[len(values)-1]
+	}
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package influxdb
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+)
+
+// DefaultTagSearchLimit is the number of values returned by a tag value
+// search unless a limit is given.
+const DefaultTagSearchLimit = 100
+
+// MaxTagSearchLimit is the largest number of values a tag value search
+// returns.
+const MaxTagSearchLimit = 10000
+
+// TagSearchOptions selects the values returned by a tag value search. Values
+// are returned in ascending order and must match every filter set.
+type TagSearchOptions struct {
+	// Measurement restricts the search to the values of a measurement.
+	Measurement string
+
+	// Prefix matches values starting with it.
+	Prefix string
+
+	// Regex matches values matching the regular expression.
+	Regex string
+
+	// Fuzzy matches values containing its characters in order, ignoring
+	// case.
+	Fuzzy string
+
+	// After skips values up to and including it. It is set to the Next value
+	// of a result to read the following page.
+	After string
+
+	// Limit bounds the number of values returned.
+	Limit int
+}
+
+// TagSearchResult is a page of the values found by a tag value search.
+type TagSearchResult struct {
+	Values []string `json:"values"`
+
+	// Next is set if more values match, and is passed as the After option
+	// to read them.
+	Next string `json:"next,omitempty"`
+}
+
+// TagSearchService searches the tag values of buckets. Searching the values
+// of the _measurement key searches the names of the measurements.
+type TagSearchService interface {
+	// SearchTagValues returns the values of a tag key of a bucket matching opts.
+	SearchTagValues(ctx context.Context, orgID, bucketID platform.ID, key string, opts TagSearchOptions) (*TagSearchResult, error)
+}
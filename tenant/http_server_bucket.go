@@ -22,6 +22,26 @@ type BucketHandler struct {
 	log       *zap.Logger
 	bucketSvc influxdb.BucketService
 	labelSvc  influxdb.LabelService // we may need this for now but we dont want it permanently
+	resources []bucketResource
+}
+
+// bucketResource is a handler mounted below the route of a bucket.
+type bucketResource struct {
+	pattern string
+	handler http.Handler
+}
+
+// BucketHandlerOption is a functional option for a *BucketHandler.
+type BucketHandlerOption func(*BucketHandler)
+
+// WithBucketResource mounts h at pattern below the route of a bucket, such as
+// "/cardinality" for /api/v2/buckets/{id}/cardinality. A nil h is not mounted.
+func WithBucketResource(pattern string, h http.Handler) BucketHandlerOption {
+	return func(b *BucketHandler) {
+		if h != nil {
+			b.resources = append(b.resources, bucketResource{pattern: pattern, handler: h})
+		}
+	}
 }
 
 const (
@@ -29,13 +49,16 @@ const (
 )
 
 // NewHTTPBucketHandler constructs a new http server.
-func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, labelSvc influxdb.LabelService, urmHandler, labelHandler http.Handler) *BucketHandler {
+func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, labelSvc influxdb.LabelService, urmHandler, labelHandler http.Handler, opts ...BucketHandlerOption) *BucketHandler {
 	svr := &BucketHandler{
 		api:       kithttp.NewAPI(kithttp.WithLog(log)),
 		log:       log,
 		bucketSvc: bucketSvc,
 		labelSvc:  labelSvc,
 	}
+	for _, opt := range opts {
+		opt(svr)
+	}
 
 	r := chi.NewRouter()
 	r.Use(
@@ -59,6 +82,9 @@ func NewHTTPBucketHandler(log *zap.Logger, bucketSvc influxdb.BucketService, lab
 			mountableRouter.Mount("/members", urmHandler)
 			mountableRouter.Mount("/owners", urmHandler)
 			mountableRouter.Mount("/labels", labelHandler)
+			for _, res := range svr.resources {
+				mountableRouter.Mount(res.pattern, res.handler)
+			}
 		})
 	})
 
@@ -79,6 +105,16 @@ type bucket struct {
 	Name                string          `json:"name"`
 	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
 	RetentionRules      []retentionRule `json:"retentionRules"`
+	WALMode             string          `json:"walMode,omitempty"`
+	WALFsyncDelay       string          `json:"walFsyncDelay,omitempty"` // Go duration syntax, e.g. "100ms"
+	LatenessWindow      string          `json:"latenessWindow,omitempty"`
+	FutureSkewLimit     string          `json:"futureSkewLimit,omitempty"`
+	LatePointsAction    string          `json:"latePointsAction,omitempty"`
+	LatePointsBucketID  platform.ID     `json:"latePointsBucketID,omitempty"`
+	DeadLetterBucketID  platform.ID     `json:"deadLetterBucketID,omitempty"`
+
+	CardinalityLimits *influxdb.CardinalityLimits `json:"cardinalityLimits,omitempty"`
+	SchemaType        influxdb.SchemaType         `json:"schemaType,omitempty"`
 	influxdb.CRUDLog
 }
 
@@ -103,6 +139,11 @@ func (b *bucket) toInfluxDB() *influxdb.Bucket {
 		sgDuration = time.Duration(b.RetentionRules[0].ShardGroupDurationSeconds) * time.Second
 	}
 
+	// The durations have already been validated by the server that encoded them.
+	walFsyncDelay, _ := parseBucketDuration("WAL fsync delay", b.WALFsyncDelay)
+	latenessWindow, _ := parseBucketDuration("lateness window", b.LatenessWindow)
+	futureSkewLimit, _ := parseBucketDuration("future skew limit", b.FutureSkewLimit)
+
 	return &influxdb.Bucket{
 		ID:                  b.ID,
 		OrgID:               b.OrgID,
@@ -112,6 +153,15 @@ func (b *bucket) toInfluxDB() *influxdb.Bucket {
 		RetentionPolicyName: b.RetentionPolicyName,
 		RetentionPeriod:     rpDuration,
 		ShardGroupDuration:  sgDuration,
+		WALMode:             influxdb.BucketWALMode(b.WALMode),
+		WALFsyncDelay:       walFsyncDelay,
+		LatenessWindow:      latenessWindow,
+		FutureSkewLimit:     futureSkewLimit,
+		LatePointsAction:    influxdb.BucketLatePointsAction(b.LatePointsAction),
+		LatePointsBucketID:  b.LatePointsBucketID,
+		DeadLetterBucketID:  b.DeadLetterBucketID,
+		CardinalityLimits:   b.CardinalityLimits,
+		SchemaType:          b.SchemaType,
 		CRUDLog:             b.CRUDLog,
 	}
 }
@@ -129,9 +179,25 @@ func newBucket(pb *influxdb.Bucket) *bucket {
 		Description:         pb.Description,
 		RetentionPolicyName: pb.RetentionPolicyName,
 		RetentionRules:      []retentionRule{},
+		WALMode:             string(pb.WALMode),
+		LatePointsAction:    string(pb.LatePointsAction),
+		LatePointsBucketID:  pb.LatePointsBucketID,
+		DeadLetterBucketID:  pb.DeadLetterBucketID,
+		CardinalityLimits:   pb.CardinalityLimits,
+		SchemaType:          pb.SchemaType,
 		CRUDLog:             pb.CRUDLog,
 	}
 
+	if pb.WALFsyncDelay > 0 {
+		bkt.WALFsyncDelay = pb.WALFsyncDelay.String()
+	}
+	if pb.LatenessWindow > 0 {
+		bkt.LatenessWindow = pb.LatenessWindow.String()
+	}
+	if pb.FutureSkewLimit > 0 {
+		bkt.FutureSkewLimit = pb.FutureSkewLimit.String()
+	}
+
 	// Only append a retention rule if the user wants to explicitly set
 	// a parameter on the rule.
 	//
@@ -157,9 +223,18 @@ type retentionRuleUpdate struct {
 
 // bucketUpdate is used for serialization/deserialization with retention rules.
 type bucketUpdate struct {
-	Name           *string               `json:"name,omitempty"`
-	Description    *string               `json:"description,omitempty"`
-	RetentionRules []retentionRuleUpdate `json:"retentionRules,omitempty"`
+	Name               *string               `json:"name,omitempty"`
+	Description        *string               `json:"description,omitempty"`
+	RetentionRules     []retentionRuleUpdate `json:"retentionRules,omitempty"`
+	WALMode            *string               `json:"walMode,omitempty"`
+	WALFsyncDelay      *string               `json:"walFsyncDelay,omitempty"`
+	LatenessWindow     *string               `json:"latenessWindow,omitempty"`
+	FutureSkewLimit    *string               `json:"futureSkewLimit,omitempty"`
+	LatePointsAction   *string               `json:"latePointsAction,omitempty"`
+	LatePointsBucketID *platform.ID          `json:"latePointsBucketID,omitempty"`
+	DeadLetterBucketID *platform.ID          `json:"deadLetterBucketID,omitempty"`
+
+	CardinalityLimits *influxdb.CardinalityLimits `json:"cardinalityLimits,omitempty"`
 }
 
 func (b *bucketUpdate) OK() error {
@@ -186,6 +261,32 @@ func (b *bucketUpdate) OK() error {
 		}
 	}
 
+	if b.WALMode != nil {
+		if err := influxdb.BucketWALMode(*b.WALMode).Valid(); err != nil {
+			return err
+		}
+	}
+	if b.WALFsyncDelay != nil {
+		if _, err := parseBucketDuration("WAL fsync delay", *b.WALFsyncDelay); err != nil {
+			return err
+		}
+	}
+	if b.LatenessWindow != nil {
+		if _, err := parseBucketDuration("lateness window", *b.LatenessWindow); err != nil {
+			return err
+		}
+	}
+	if b.FutureSkewLimit != nil {
+		if _, err := parseBucketDuration("future skew limit", *b.FutureSkewLimit); err != nil {
+			return err
+		}
+	}
+	if b.LatePointsAction != nil {
+		if err := influxdb.BucketLatePointsAction(*b.LatePointsAction).Valid(); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -212,6 +313,30 @@ func (b *bucketUpdate) toInfluxDB() *influxdb.BucketUpdate {
 		}
 	}
 
+	if b.WALMode != nil {
+		mode := influxdb.BucketWALMode(*b.WALMode)
+		upd.WALMode = &mode
+	}
+	if b.WALFsyncDelay != nil {
+		delay, _ := parseBucketDuration("WAL fsync delay", *b.WALFsyncDelay)
+		upd.WALFsyncDelay = &delay
+	}
+	if b.LatenessWindow != nil {
+		window, _ := parseBucketDuration("lateness window", *b.LatenessWindow)
+		upd.LatenessWindow = &window
+	}
+	if b.FutureSkewLimit != nil {
+		limit, _ := parseBucketDuration("future skew limit", *b.FutureSkewLimit)
+		upd.FutureSkewLimit = &limit
+	}
+	if b.LatePointsAction != nil {
+		action := influxdb.BucketLatePointsAction(*b.LatePointsAction)
+		upd.LatePointsAction = &action
+	}
+	upd.LatePointsBucketID = b.LatePointsBucketID
+	upd.DeadLetterBucketID = b.DeadLetterBucketID
+	upd.CardinalityLimits = b.CardinalityLimits
+
 	return &upd
 }
 
@@ -226,6 +351,30 @@ func newBucketUpdate(pb *influxdb.BucketUpdate) *bucketUpdate {
 		RetentionRules: []retentionRuleUpdate{},
 	}
 
+	if pb.WALMode != nil {
+		mode := string(*pb.WALMode)
+		up.WALMode = &mode
+	}
+	if pb.WALFsyncDelay != nil {
+		delay := pb.WALFsyncDelay.String()
+		up.WALFsyncDelay = &delay
+	}
+	if pb.LatenessWindow != nil {
+		window := pb.LatenessWindow.String()
+		up.LatenessWindow = &window
+	}
+	if pb.FutureSkewLimit != nil {
+		limit := pb.FutureSkewLimit.String()
+		up.FutureSkewLimit = &limit
+	}
+	if pb.LatePointsAction != nil {
+		action := string(*pb.LatePointsAction)
+		up.LatePointsAction = &action
+	}
+	up.LatePointsBucketID = pb.LatePointsBucketID
+	up.DeadLetterBucketID = pb.DeadLetterBucketID
+	up.CardinalityLimits = pb.CardinalityLimits
+
 	if pb.RetentionPeriod == nil && pb.ShardGroupDuration == nil {
 		return up
 	}
@@ -320,6 +469,16 @@ type postBucketRequest struct {
 	Description         string          `json:"description"`
 	RetentionPolicyName string          `json:"rp,omitempty"` // This to support v1 sources
 	RetentionRules      []retentionRule `json:"retentionRules"`
+	WALMode             string          `json:"walMode,omitempty"`
+	WALFsyncDelay       string          `json:"walFsyncDelay,omitempty"`
+	LatenessWindow      string          `json:"latenessWindow,omitempty"`
+	FutureSkewLimit     string          `json:"futureSkewLimit,omitempty"`
+	LatePointsAction    string          `json:"latePointsAction,omitempty"`
+	LatePointsBucketID  platform.ID     `json:"latePointsBucketID,omitempty"`
+	DeadLetterBucketID  platform.ID     `json:"deadLetterBucketID,omitempty"`
+
+	CardinalityLimits *influxdb.CardinalityLimits `json:"cardinalityLimits,omitempty"`
+	SchemaType        influxdb.SchemaType         `json:"schemaType,omitempty"`
 }
 
 func (b *postBucketRequest) OK() error {
@@ -354,6 +513,22 @@ func (b *postBucketRequest) OK() error {
 		}
 	}
 
+	if err := influxdb.BucketWALMode(b.WALMode).Valid(); err != nil {
+		return err
+	}
+	if _, err := parseBucketDuration("WAL fsync delay", b.WALFsyncDelay); err != nil {
+		return err
+	}
+	if _, err := parseBucketDuration("lateness window", b.LatenessWindow); err != nil {
+		return err
+	}
+	if _, err := parseBucketDuration("future skew limit", b.FutureSkewLimit); err != nil {
+		return err
+	}
+	if err := influxdb.BucketLatePointsAction(b.LatePointsAction).Valid(); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -366,6 +541,9 @@ func (b postBucketRequest) toInfluxDB() *influxdb.Bucket {
 		rpDur = time.Duration(rule.EverySeconds) * time.Second
 		sgDur = time.Duration(rule.ShardGroupDurationSeconds) * time.Second
 	}
+	walFsyncDelay, _ := parseBucketDuration("WAL fsync delay", b.WALFsyncDelay)
+	latenessWindow, _ := parseBucketDuration("lateness window", b.LatenessWindow)
+	futureSkewLimit, _ := parseBucketDuration("future skew limit", b.FutureSkewLimit)
 
 	return &influxdb.Bucket{
 		OrgID:               b.OrgID,
@@ -375,7 +553,39 @@ func (b postBucketRequest) toInfluxDB() *influxdb.Bucket {
 		RetentionPolicyName: b.RetentionPolicyName,
 		RetentionPeriod:     rpDur,
 		ShardGroupDuration:  sgDur,
+		WALMode:             influxdb.BucketWALMode(b.WALMode),
+		WALFsyncDelay:       walFsyncDelay,
+		LatenessWindow:      latenessWindow,
+		FutureSkewLimit:     futureSkewLimit,
+		LatePointsAction:    influxdb.BucketLatePointsAction(b.LatePointsAction),
+		LatePointsBucketID:  b.LatePointsBucketID,
+		DeadLetterBucketID:  b.DeadLetterBucketID,
+		CardinalityLimits:   b.CardinalityLimits,
+		SchemaType:          b.SchemaType,
+	}
+}
+
+// parseBucketDuration parses a bucket setting given in Go duration syntax,
+// such as "100ms" or "2h". An empty string is treated as zero.
+func parseBucketDuration(name, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Msg:  fmt.Sprintf("invalid %s %q", name, s),
+			Err:  err,
+		}
+	}
+	if d < 0 {
+		return 0, &errors.Error{
+			Code: errors.EUnprocessableEntity,
+			Msg:  fmt.Sprintf("%s cannot be negative", name),
+		}
 	}
+	return d, nil
 }
 
 // handleGetBucket is the HTTP handler for the GET /api/v2/buckets/:id route.
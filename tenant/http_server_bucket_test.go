@@ -208,3 +208,33 @@ func TestHTTPBucketService_InvalidRetention(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPBucketService_WALMode(t *testing.T) {
+	fields := itesting.BucketFields{
+		OrgIDs:        mock.NewIncrementingIDGenerator(idOne),
+		BucketIDs:     mock.NewIncrementingIDGenerator(idOne),
+		TimeGenerator: mock.TimeGenerator{FakeValue: time.Date(2006, 5, 4, 1, 2, 3, 0, time.UTC)},
+		Organizations: []*influxdb.Organization{{Name: "theorg"}},
+		Buckets:       []*influxdb.Bucket{{OrgID: idOne, Name: "bucket1"}},
+	}
+
+	s, _, done := initBucketHttpService(fields, t)
+	defer done()
+	ctx := context.Background()
+
+	mode := influxdb.BucketWALModeGroupCommit
+	delay := 100 * time.Millisecond
+	bucket, err := s.UpdateBucket(ctx, idOne, influxdb.BucketUpdate{WALMode: &mode, WALFsyncDelay: &delay})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bucket.WALMode != mode || bucket.WALFsyncDelay != delay {
+		t.Fatalf("unexpected WAL settings: got %q/%v, exp %q/%v", bucket.WALMode, bucket.WALFsyncDelay, mode, delay)
+	}
+
+	invalid := influxdb.BucketWALMode("sometimes")
+	_, err = s.UpdateBucket(ctx, idOne, influxdb.BucketUpdate{WALMode: &invalid})
+	if errors.ErrorCode(err) != errors.EInvalid {
+		t.Fatalf("expected invalid error, got %v", err)
+	}
+}
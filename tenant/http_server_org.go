@@ -30,7 +30,7 @@ func (h *OrgHandler) Prefix() string {
 }
 
 // NewHTTPOrgHandler constructs a new http server.
-func NewHTTPOrgHandler(log *zap.Logger, orgService influxdb.OrganizationService, urm http.Handler, secretHandler http.Handler) *OrgHandler {
+func NewHTTPOrgHandler(log *zap.Logger, orgService influxdb.OrganizationService, urm http.Handler, secretHandler http.Handler, usageHandler http.Handler) *OrgHandler {
 	svr := &OrgHandler{
 		api:    kithttp.NewAPI(kithttp.WithLog(log)),
 		log:    log,
@@ -58,6 +58,9 @@ func NewHTTPOrgHandler(log *zap.Logger, orgService influxdb.OrganizationService,
 			mountableRouter.Mount("/members", urm)
 			mountableRouter.Mount("/owners", urm)
 			mountableRouter.Mount("/secrets", secretHandler)
+			if usageHandler != nil {
+				mountableRouter.Mount("/usage", usageHandler)
+			}
 		})
 	})
 	svr.Router = r
@@ -38,7 +38,7 @@ func initHttpOrgService(f itesting.OrganizationFields, t *testing.T) (influxdb.O
 		t.Fatalf("failed to populate organizations: %s", err)
 	}
 
-	handler := tenant.NewHTTPOrgHandler(zaptest.NewLogger(t), tenant.NewService(storage), nil, nil)
+	handler := tenant.NewHTTPOrgHandler(zaptest.NewLogger(t), tenant.NewService(storage), nil, nil, nil)
 	r := chi.NewRouter()
 	r.Mount(handler.Prefix(), handler)
 	server := httptest.NewServer(r)
@@ -76,10 +76,17 @@ func (s *AuthedOrgService) CreateOrganization(ctx context.Context, o *influxdb.O
 }
 
 // UpdateOrganization checks to see if the authorizer on context has write access to the organization provided.
+// Changing the quotas of an organization requires write access to the global orgs resource, so that
+// organizations cannot raise their own quotas.
 func (s *AuthedOrgService) UpdateOrganization(ctx context.Context, id platform.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
 	if _, _, err := authorizer.AuthorizeWriteOrg(ctx, id); err != nil {
 		return nil, err
 	}
+	if upd.Quotas != nil {
+		if _, _, err := authorizer.AuthorizeWriteGlobal(ctx, influxdb.OrgsResourceType); err != nil {
+			return nil, err
+		}
+	}
 	return s.s.UpdateOrganization(ctx, id, upd)
 }
 
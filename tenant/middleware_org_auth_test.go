@@ -398,6 +398,35 @@ func TestOrgService_UpdateOrganization(t *testing.T) {
 	}
 }
 
+func TestOrgService_UpdateOrganization_Quotas(t *testing.T) {
+	orgSvc := &mock.OrganizationService{
+		UpdateOrganizationF: func(ctx context.Context, id platform.ID, upd influxdb.OrganizationUpdate) (*influxdb.Organization, error) {
+			return &influxdb.Organization{ID: id, Quotas: upd.Quotas}, nil
+		},
+	}
+	s := tenant.NewAuthedOrgService(orgSvc)
+	upd := influxdb.OrganizationUpdate{Quotas: &influxdb.OrgQuotas{MaxBuckets: 10}}
+
+	// Write access to the organization itself is not enough to change its quotas.
+	ctx := influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, []influxdb.Permission{{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.OrgsResourceType, ID: influxdbtesting.IDPtr(1)},
+	}}))
+	_, err := s.UpdateOrganization(ctx, 1, upd)
+	influxdbtesting.ErrorsEqual(t, err, &errors.Error{
+		Msg:  "write:orgs is unauthorized",
+		Code: errors.EUnauthorized,
+	})
+
+	ctx = influxdbcontext.SetAuthorizer(context.Background(), mock.NewMockAuthorizer(false, []influxdb.Permission{{
+		Action:   influxdb.WriteAction,
+		Resource: influxdb.Resource{Type: influxdb.OrgsResourceType},
+	}}))
+	if _, err := s.UpdateOrganization(ctx, 1, upd); err != nil {
+		t.Fatal(err)
+	}
+}
+
 func TestOrgService_DeleteOrganization(t *testing.T) {
 	type fields struct {
 		OrgService influxdb.OrganizationService
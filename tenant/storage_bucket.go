@@ -407,6 +407,30 @@ func (s *Store) UpdateBucket(ctx context.Context, tx kv.Tx, id platform.ID, upd
 	if upd.ShardGroupDuration != nil {
 		bucket.ShardGroupDuration = *upd.ShardGroupDuration
 	}
+	if upd.WALMode != nil {
+		bucket.WALMode = *upd.WALMode
+	}
+	if upd.WALFsyncDelay != nil {
+		bucket.WALFsyncDelay = *upd.WALFsyncDelay
+	}
+	if upd.LatenessWindow != nil {
+		bucket.LatenessWindow = *upd.LatenessWindow
+	}
+	if upd.FutureSkewLimit != nil {
+		bucket.FutureSkewLimit = *upd.FutureSkewLimit
+	}
+	if upd.LatePointsAction != nil {
+		bucket.LatePointsAction = *upd.LatePointsAction
+	}
+	if upd.LatePointsBucketID != nil {
+		bucket.LatePointsBucketID = *upd.LatePointsBucketID
+	}
+	if upd.CardinalityLimits != nil {
+		bucket.CardinalityLimits = upd.CardinalityLimits
+	}
+	if upd.DeadLetterBucketID != nil {
+		bucket.DeadLetterBucketID = *upd.DeadLetterBucketID
+	}
 
 	v, err := marshalBucket(bucket)
 	if err != nil {
@@ -236,6 +236,14 @@ func (s *Store) UpdateOrg(ctx context.Context, tx kv.Tx, id platform.ID, upd inf
 		u.Description = *upd.Description
 	}
 
+	if upd.CardinalityLimits != nil {
+		u.CardinalityLimits = upd.CardinalityLimits
+	}
+
+	if upd.Quotas != nil {
+		u.Quotas = upd.Quotas
+	}
+
 	v, err := marshalOrg(u)
 	if err != nil {
 		return nil, err
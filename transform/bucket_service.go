@@ -0,0 +1,38 @@
+package transform
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"go.uber.org/zap"
+)
+
+// BucketService wraps an influxdb.BucketService and removes the transforms of
+// the buckets it deletes.
+type BucketService struct {
+	influxdb.BucketService
+	log        *zap.Logger
+	transforms *Service
+}
+
+// NewBucketService returns a new BucketService deleting the transforms of s.
+func NewBucketService(log *zap.Logger, bucketSvc influxdb.BucketService, s *Service) *BucketService {
+	return &BucketService{
+		BucketService: bucketSvc,
+		log:           log,
+		transforms:    s,
+	}
+}
+
+// DeleteBucket deletes a bucket and its transform.
+func (s *BucketService) DeleteBucket(ctx context.Context, id platform.ID) error {
+	if err := s.BucketService.DeleteBucket(ctx, id); err != nil {
+		return err
+	}
+	if err := s.transforms.DeleteBucketTransform(ctx, id); err != nil && err != influxdb.ErrBucketTransformNotFound {
+		s.log.Error("Failed to delete transform for bucket",
+			zap.String("bucket_id", id.String()), zap.Error(err))
+	}
+	return nil
+}
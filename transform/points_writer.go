@@ -0,0 +1,147 @@
+package transform
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PointsWriter wraps a storage.PointsWriter and applies the transform of the
+// bucket written to, if any, to the points before writing them. Points
+// dropped by a rule are not written, and points rejected by a rule are
+// reported by a tsdb.PartialWriteError once the remaining points are written.
+type PointsWriter struct {
+	underlying storage.PointsWriter
+	transforms *Service
+
+	pointsTouched *prometheus.CounterVec
+}
+
+// NewPointsWriter returns a new PointsWriter applying the transforms of s to
+// the points written to w.
+func NewPointsWriter(w storage.PointsWriter, s *Service) *PointsWriter {
+	return &PointsWriter{
+		underlying: w,
+		transforms: s,
+		pointsTouched: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "transform",
+			Name:      "points_touched_total",
+			Help:      "Number of points changed, dropped or rejected by a rule of the transform of a bucket",
+		}, []string{"bucket", "rule", "type"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the points touched by the rules
+// of each bucket.
+func (w *PointsWriter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{w.pointsTouched}
+}
+
+// WritePoints writes the transformed points to the underlying PointsWriter.
+func (w *PointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	c, err := w.transforms.chain(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if len(c) == 0 {
+		return w.underlying.WritePoints(ctx, orgID, bucketID, points)
+	}
+
+	var (
+		bucket  = bucketID.String()
+		touched = make([]int, len(c))
+		kept    = points[:0:0]
+		in      = make(inputs)
+		partial tsdb.PartialWriteError
+	)
+	for _, p := range points {
+		tp, reason := c.apply(p, func(i int) { touched[i]++ })
+		if tp != nil {
+			kept = append(kept, tp)
+			in.add(tp, p)
+			continue
+		}
+		if reason == "" {
+			continue
+		}
+		addDropped(&partial, tsdb.DroppedPoint{Point: p, Code: tsdb.DropTransform, Reason: reason})
+	}
+	for i, n := range touched {
+		if n > 0 {
+			w.pointsTouched.WithLabelValues(bucket, strconv.Itoa(i), string(c[i].Type)).Add(float64(n))
+		}
+	}
+
+	if len(kept) > 0 {
+		err := w.underlying.WritePoints(ctx, orgID, bucketID, kept)
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			mergeDropped(&partial, perr, in)
+		} else if err != nil {
+			return err
+		}
+	}
+	if partial.Dropped > 0 {
+		return partial
+	}
+	return nil
+}
+
+// addDropped adds the dropped point dp to partial.
+func addDropped(partial *tsdb.PartialWriteError, dp tsdb.DroppedPoint) {
+	if partial.Dropped == 0 {
+		partial.Reason = dp.Reason
+	}
+	partial.Dropped++
+	partial.DroppedKeys = append(partial.DroppedKeys, dp.Point.Key())
+	partial.Points = append(partial.Points, dp)
+}
+
+// mergeDropped adds the points dropped by the underlying writer to partial.
+// They are the transformed points, which are reported as they were written.
+func mergeDropped(partial *tsdb.PartialWriteError, perr tsdb.PartialWriteError, in inputs) {
+	if partial.Dropped == 0 {
+		partial.Reason = perr.Reason
+	}
+	partial.Dropped += perr.Dropped
+	if len(perr.Points) == 0 {
+		partial.DroppedKeys = append(partial.DroppedKeys, perr.DroppedKeys...)
+		return
+	}
+	for _, dp := range perr.Points {
+		dp.Point = in.original(dp.Point)
+		partial.DroppedKeys = append(partial.DroppedKeys, dp.Point.Key())
+		partial.Points = append(partial.Points, dp)
+	}
+}
+
+// inputs maps the series key and time of the transformed points to the
+// points they were transformed from, in order.
+type inputs map[string][]models.Point
+
+func inputKey(p models.Point) string {
+	return string(p.Key()) + "\x00" + strconv.FormatInt(p.UnixNano(), 10)
+}
+
+// add records that the point p was transformed to tp.
+func (in inputs) add(tp, p models.Point) {
+	k := inputKey(tp)
+	in[k] = append(in[k], p)
+}
+
+// original returns the point tp was transformed from, tp itself if it is not
+// known.
+func (in inputs) original(tp models.Point) models.Point {
+	k := inputKey(tp)
+	ps := in[k]
+	if len(ps) == 0 {
+		return tp
+	}
+	in[k] = ps[1:]
+	return ps[0]
+}
@@ -0,0 +1,157 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingPointsWriter struct {
+	points []models.Point
+}
+
+func (w *recordingPointsWriter) WritePoints(_ context.Context, _, _ platform.ID, points []models.Point) error {
+	w.points = append(w.points, points...)
+	return nil
+}
+
+func parsePoints(t *testing.T, lp string) []models.Point {
+	points, err := models.ParsePointsString(lp)
+	require.NoError(t, err)
+	return points
+}
+
+func pointLines(points []models.Point) []string {
+	var lines []string
+	for _, p := range points {
+		lines = append(lines, p.String())
+	}
+	return lines
+}
+
+func TestPointsWriter_WritePoints(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	var underlying recordingPointsWriter
+	w := NewPointsWriter(&underlying, svc)
+
+	// Without a transform, points are written as they are.
+	points := parsePoints(t, "cpu,host=a usage=1 1")
+	require.NoError(t, w.WritePoints(ctx, orgID, bucketID, points))
+	require.Equal(t, []string{"cpu,host=a usage=1 1"}, pointLines(underlying.points))
+
+	require.NoError(t, svc.PutBucketTransform(ctx, &influxdb.BucketTransform{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Rules: []influxdb.TransformRule{
+			{Type: influxdb.TransformDropMeasurement, Pattern: "^debug_"},
+			{Type: influxdb.TransformDropField, Pattern: "^tmp_"},
+			{Type: influxdb.TransformRenameTag, From: "hostname", To: "host"},
+			{Type: influxdb.TransformRenameField, Measurement: "^cpu$", From: "value", To: "usage"},
+			{Type: influxdb.TransformAddTag, Key: "region", Value: "eu"},
+			{Type: influxdb.TransformConvertField, Key: "cores", DataType: influxdb.SchemaColumnDataTypeInteger.Ptr()},
+			{Type: influxdb.TransformRequireTags, Tags: []string{"host"}},
+		},
+	}))
+
+	underlying.points = nil
+	points = parsePoints(t, `debug_trace n=1 1
+cpu,hostname=a value=0.5,tmp_x=1,cores=4 2
+cpu,host=b,region=eu usage=1 3
+disk,hostname=c value=2,cores="8" 4
+mem free=1 5
+cpu,host=d cores=1.5 6
+tmp tmp_only=1 7`)
+	err := w.WritePoints(ctx, orgID, bucketID, points)
+	require.Equal(t, []string{
+		"cpu,host=a,region=eu cores=4i,usage=0.5 2",
+		"cpu,host=b,region=eu usage=1 3",
+		"disk,host=c,region=eu cores=8i,value=2 4",
+	}, pointLines(underlying.points))
+
+	perr, ok := err.(tsdb.PartialWriteError)
+	require.True(t, ok, "expected a partial write error, got %v", err)
+	require.Equal(t, 2, perr.Dropped)
+	require.Equal(t, `transform rule 6: missing required tag "host"`, perr.Reason)
+	require.Len(t, perr.Points, 2)
+	require.Equal(t, tsdb.DropTransform, perr.Points[0].Code)
+	require.Equal(t, "mem free=1 5", perr.Points[0].Point.String())
+	require.Equal(t, "cpu,host=d cores=1.5 6", perr.Points[1].Point.String())
+	require.Equal(t, `transform rule 5: field "cores": cannot convert 1.5 to integer`, perr.Points[1].Reason)
+
+	// Rules count the points they touched.
+	touched := func(rule, typ string) float64 {
+		return testutil.ToFloat64(w.pointsTouched.WithLabelValues(bucketID.String(), rule, typ))
+	}
+	require.Equal(t, 1.0, touched("0", "dropMeasurement"))
+	require.Equal(t, 2.0, touched("1", "dropField"))
+	require.Equal(t, 2.0, touched("2", "renameTag"))
+	require.Equal(t, 1.0, touched("3", "renameField"))
+	require.Equal(t, 4.0, touched("4", "addTag"))
+	require.Equal(t, 3.0, touched("5", "convertField"))
+	require.Equal(t, 1.0, touched("6", "requireTags"))
+}
+
+// droppingPointsWriter drops the points of a measurement, as the storage
+// engine would.
+type droppingPointsWriter struct {
+	measurement string
+}
+
+func (w *droppingPointsWriter) WritePoints(_ context.Context, _, _ platform.ID, points []models.Point) error {
+	var partial tsdb.PartialWriteError
+	for _, p := range points {
+		if string(p.Name()) == w.measurement {
+			partial.Reason = "field type conflict"
+			partial.Dropped++
+			partial.DroppedKeys = append(partial.DroppedKeys, p.Key())
+			partial.Points = append(partial.Points, tsdb.DroppedPoint{Point: p, Code: tsdb.DropFieldTypeConflict, Reason: partial.Reason})
+		}
+	}
+	if partial.Dropped > 0 {
+		return partial
+	}
+	return nil
+}
+
+func TestPointsWriter_WritePoints_DroppedBelow(t *testing.T) {
+	svc, _ := newTestService(t)
+	w := NewPointsWriter(&droppingPointsWriter{measurement: "cpu"}, svc)
+
+	require.NoError(t, svc.PutBucketTransform(ctx, &influxdb.BucketTransform{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Rules: []influxdb.TransformRule{
+			{Type: influxdb.TransformRenameTag, From: "hostname", To: "host"},
+			{Type: influxdb.TransformRequireTags, Tags: []string{"host"}},
+		},
+	}))
+
+	// The points dropped by the underlying writer are reported as they were
+	// written, so that their lines can be found.
+	err := w.WritePoints(ctx, orgID, bucketID, parsePoints(t, `mem free=1 1
+cpu,hostname=a usage=1 2
+cpu,hostname=a usage=2 3
+disk,host=b used=1 4`))
+	perr, ok := err.(tsdb.PartialWriteError)
+	require.True(t, ok, "expected a partial write error, got %v", err)
+	require.Equal(t, 3, perr.Dropped)
+	require.Equal(t, `transform rule 1: missing required tag "host"`, perr.Reason)
+	require.Equal(t, [][]byte{[]byte("mem"), []byte("cpu,hostname=a"), []byte("cpu,hostname=a")}, perr.DroppedKeys)
+
+	var dropped []string
+	for _, dp := range perr.Points {
+		dropped = append(dropped, string(dp.Code)+": "+dp.Point.String())
+	}
+	require.Equal(t, []string{
+		"transform: mem free=1 1",
+		"field_type_conflict: cpu,hostname=a usage=1 2",
+		"field_type_conflict: cpu,hostname=a usage=2 3",
+	}, dropped)
+}
@@ -0,0 +1,285 @@
+package transform
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// rule is a transform rule with its regular expressions compiled.
+type rule struct {
+	influxdb.TransformRule
+	measurement *regexp.Regexp
+	pattern     *regexp.Regexp
+}
+
+// chain is the compiled rules of a transform, in order.
+type chain []rule
+
+func compile(rs []influxdb.TransformRule) (chain, error) {
+	c := make(chain, 0, len(rs))
+	for _, r := range rs {
+		cr := rule{TransformRule: r}
+		var err error
+		if r.Measurement != "" {
+			if cr.measurement, err = regexp.Compile(r.Measurement); err != nil {
+				return nil, err
+			}
+		}
+		if r.Pattern != "" {
+			if cr.pattern, err = regexp.Compile(r.Pattern); err != nil {
+				return nil, err
+			}
+		}
+		c = append(c, cr)
+	}
+	return c, nil
+}
+
+// point is a point being transformed. Its tags are copied and its fields
+// decoded once a rule needs them.
+type point struct {
+	models.Point
+	name    []byte
+	tags    models.Tags
+	copied  bool
+	fields  models.Fields
+	changed bool
+}
+
+// readTags returns the tags of the point.
+func (p *point) readTags() models.Tags {
+	if p.copied {
+		return p.tags
+	}
+	return p.Point.Tags()
+}
+
+// writeTags returns the tags of the point, which may be changed.
+func (p *point) writeTags() *models.Tags {
+	if !p.copied {
+		p.tags = p.Point.Tags().Clone()
+		p.copied = true
+	}
+	return &p.tags
+}
+
+// loadFields returns the fields of the point, which may be changed.
+func (p *point) loadFields() (models.Fields, error) {
+	if p.fields == nil {
+		fields, err := p.Point.Fields()
+		if err != nil {
+			return nil, err
+		}
+		p.fields = fields
+	}
+	return p.fields, nil
+}
+
+// apply applies the rules of c to pt and returns the transformed point, which
+// is nil if a rule dropped or rejected it, with the reason it was rejected.
+// touched is called with the index of each rule which changed, dropped or
+// rejected the point.
+func (c chain) apply(pt models.Point, touched func(i int)) (models.Point, string) {
+	p := &point{Point: pt, name: pt.Name()}
+
+	for i := range c {
+		r := &c[i]
+		if r.measurement != nil && !r.measurement.Match(p.name) {
+			continue
+		}
+
+		switch r.Type {
+		case influxdb.TransformDropMeasurement:
+			if r.pattern.Match(p.name) {
+				touched(i)
+				return nil, ""
+			}
+
+		case influxdb.TransformDropField:
+			fields, err := p.loadFields()
+			if err != nil {
+				return nil, err.Error()
+			}
+			n := len(fields)
+			for k := range fields {
+				if r.pattern.MatchString(k) {
+					delete(fields, k)
+				}
+			}
+			if len(fields) == n {
+				continue
+			}
+			touched(i)
+			if len(fields) == 0 {
+				return nil, ""
+			}
+			p.changed = true
+
+		case influxdb.TransformRenameTag:
+			v := p.readTags().Get([]byte(r.From))
+			if v == nil {
+				continue
+			}
+			tags := p.writeTags()
+			tags.Delete([]byte(r.From))
+			tags.Set([]byte(r.To), v)
+			touched(i)
+			p.changed = true
+
+		case influxdb.TransformRenameField:
+			fields, err := p.loadFields()
+			if err != nil {
+				return nil, err.Error()
+			}
+			v, ok := fields[r.From]
+			if !ok {
+				continue
+			}
+			delete(fields, r.From)
+			fields[r.To] = v
+			touched(i)
+			p.changed = true
+
+		case influxdb.TransformAddTag:
+			if p.readTags().GetString(r.Key) == r.Value {
+				continue
+			}
+			p.writeTags().SetString(r.Key, r.Value)
+			touched(i)
+			p.changed = true
+
+		case influxdb.TransformConvertField:
+			fields, err := p.loadFields()
+			if err != nil {
+				return nil, err.Error()
+			}
+			v, ok := fields[r.Key]
+			if !ok {
+				continue
+			}
+			cv, err := convert(v, *r.DataType)
+			if err != nil {
+				touched(i)
+				return nil, fmt.Sprintf("transform rule %d: field %q: %v", i, r.Key, err)
+			}
+			if cv == v {
+				continue
+			}
+			fields[r.Key] = cv
+			touched(i)
+			p.changed = true
+
+		case influxdb.TransformRequireTags:
+			tags := p.readTags()
+			for _, k := range r.Tags {
+				if tags.Get([]byte(k)) == nil {
+					touched(i)
+					return nil, fmt.Sprintf("transform rule %d: missing required tag %q", i, k)
+				}
+			}
+		}
+	}
+
+	if !p.changed {
+		return pt, ""
+	}
+	fields, err := p.loadFields()
+	if err != nil {
+		return nil, err.Error()
+	}
+	np, err := models.NewPoint(string(p.name), p.readTags(), fields, pt.Time())
+	if err != nil {
+		return nil, err.Error()
+	}
+	return np, ""
+}
+
+// convert converts a field value to a data type.
+func convert(v interface{}, dt influxdb.SchemaColumnDataType) (interface{}, error) {
+	switch dt {
+	case influxdb.SchemaColumnDataTypeFloat:
+		switch v := v.(type) {
+		case float64:
+			return v, nil
+		case int64:
+			return float64(v), nil
+		case uint64:
+			return float64(v), nil
+		case bool:
+			return boolNumber(v), nil
+		case string:
+			return strconv.ParseFloat(v, 64)
+		}
+
+	case influxdb.SchemaColumnDataTypeInteger:
+		switch v := v.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == math.Trunc(v) && v >= math.MinInt64 && v < math.MaxInt64 {
+				return int64(v), nil
+			}
+		case uint64:
+			if v <= math.MaxInt64 {
+				return int64(v), nil
+			}
+		case bool:
+			return int64(boolNumber(v)), nil
+		case string:
+			return strconv.ParseInt(v, 10, 64)
+		}
+
+	case influxdb.SchemaColumnDataTypeUnsigned:
+		switch v := v.(type) {
+		case uint64:
+			return v, nil
+		case int64:
+			if v >= 0 {
+				return uint64(v), nil
+			}
+		case float64:
+			if v == math.Trunc(v) && v >= 0 && v < math.MaxUint64 {
+				return uint64(v), nil
+			}
+		case bool:
+			return uint64(boolNumber(v)), nil
+		case string:
+			return strconv.ParseUint(v, 10, 64)
+		}
+
+	case influxdb.SchemaColumnDataTypeString:
+		switch v := v.(type) {
+		case string:
+			return v, nil
+		case float64:
+			return strconv.FormatFloat(v, 'f', -1, 64), nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case uint64:
+			return strconv.FormatUint(v, 10), nil
+		case bool:
+			return strconv.FormatBool(v), nil
+		}
+
+	case influxdb.SchemaColumnDataTypeBoolean:
+		switch v := v.(type) {
+		case bool:
+			return v, nil
+		case string:
+			return strconv.ParseBool(v)
+		}
+	}
+	return nil, fmt.Errorf("cannot convert %v to %s", v, dt.Ptr())
+}
+
+func boolNumber(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
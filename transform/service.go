@@ -0,0 +1,211 @@
+// Package transform stores the transforms of buckets and applies their rules
+// to the points written to them before they are stored.
+package transform
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/sqlite"
+)
+
+var _ influxdb.BucketTransformService = (*Service)(nil)
+
+// Service is a BucketTransformService backed by the sqlite store. It caches
+// the compiled rules of the buckets written to so that points can be
+// transformed without a query per write.
+type Service struct {
+	store   *sqlite.SqlStore
+	buckets influxdb.BucketService
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[platform.ID]chain
+}
+
+// NewService returns a new Service. buckets is used to check the buckets
+// transforms are set on.
+func NewService(store *sqlite.SqlStore, buckets influxdb.BucketService) *Service {
+	return &Service{
+		store:   store,
+		buckets: buckets,
+		now:     time.Now,
+		cache:   make(map[platform.ID]chain),
+	}
+}
+
+// row is a bucket transform as stored in the bucket_transforms table.
+type row struct {
+	BucketID  platform.ID `db:"bucket_id"`
+	OrgID     platform.ID `db:"org_id"`
+	Rules     rules       `db:"rules"`
+	CreatedAt time.Time   `db:"created_at"`
+	UpdatedAt time.Time   `db:"updated_at"`
+}
+
+func (r *row) toInfluxDB() *influxdb.BucketTransform {
+	return &influxdb.BucketTransform{
+		OrgID:    r.OrgID,
+		BucketID: r.BucketID,
+		Rules:    r.Rules,
+		CRUDLog: influxdb.CRUDLog{
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		},
+	}
+}
+
+// rules stores the rules of a transform as a JSON array.
+type rules []influxdb.TransformRule
+
+// Value implements the database/sql Valuer interface.
+func (r rules) Value() (driver.Value, error) {
+	b, err := json.Marshal([]influxdb.TransformRule(r))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the database/sql Scanner interface.
+func (r *rules) Scan(value interface{}) error {
+	v, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("bucket transform rules: unexpected type %T", value)
+	}
+	return json.Unmarshal([]byte(v), (*[]influxdb.TransformRule)(r))
+}
+
+var selectColumns = []string{"bucket_id", "org_id", "rules", "created_at", "updated_at"}
+
+// FindBucketTransform returns the transform of a bucket.
+func (s *Service) FindBucketTransform(ctx context.Context, bucketID platform.ID) (*influxdb.BucketTransform, error) {
+	query, args, err := sq.Select(selectColumns...).
+		From("bucket_transforms").
+		Where(sq.Eq{"bucket_id": bucketID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var r row
+	if err := s.store.DB.GetContext(ctx, &r, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, influxdb.ErrBucketTransformNotFound
+		}
+		return nil, err
+	}
+	return r.toInfluxDB(), nil
+}
+
+// PutBucketTransform sets the transform of the bucket of t, which must belong
+// to t.OrgID, and updates t with the stored transform.
+func (s *Service) PutBucketTransform(ctx context.Context, t *influxdb.BucketTransform) error {
+	if err := t.Validate(); err != nil {
+		return err
+	}
+
+	b, err := s.buckets.FindBucketByID(ctx, t.BucketID)
+	if err != nil {
+		return err
+	}
+	if b.OrgID != t.OrgID {
+		return &ierrors.Error{
+			Code: ierrors.ENotFound,
+			Msg:  "bucket not found",
+		}
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	now := s.now().UTC()
+	query, args, err := sq.Insert("bucket_transforms").
+		Columns(selectColumns...).
+		Values(t.BucketID, t.OrgID, rules(t.Rules), now, now).
+		Suffix("ON CONFLICT (bucket_id) DO UPDATE SET rules = excluded.rules, updated_at = excluded.updated_at").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	s.invalidate(t.BucketID)
+
+	// The creation time of a replaced transform is kept, so the stored
+	// transform is read back.
+	stored, err := s.FindBucketTransform(ctx, t.BucketID)
+	if err != nil {
+		return err
+	}
+	*t = *stored
+	return nil
+}
+
+// DeleteBucketTransform removes the transform of a bucket.
+func (s *Service) DeleteBucketTransform(ctx context.Context, bucketID platform.ID) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("bucket_transforms").
+		Where(sq.Eq{"bucket_id": bucketID}).
+		Suffix("RETURNING bucket_id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var id platform.ID
+	if err := s.store.DB.GetContext(ctx, &id, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return influxdb.ErrBucketTransformNotFound
+		}
+		return err
+	}
+	s.invalidate(bucketID)
+
+	return nil
+}
+
+// chain returns the cached rules of the transform of a bucket, reading them
+// on a miss. The chain of a bucket without a transform is empty. Loading
+// while holding the lock keeps a concurrent change from being overwritten by
+// the rules read before it.
+func (s *Service) chain(ctx context.Context, bucketID platform.ID) (chain, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.cache[bucketID]; ok {
+		return c, nil
+	}
+
+	var c chain
+	t, err := s.FindBucketTransform(ctx, bucketID)
+	if err == nil {
+		if c, err = compile(t.Rules); err != nil {
+			return nil, err
+		}
+	} else if err != influxdb.ErrBucketTransformNotFound {
+		return nil, err
+	}
+	s.cache[bucketID] = c
+	return c, nil
+}
+
+func (s *Service) invalidate(bucketID platform.ID) {
+	s.mu.Lock()
+	delete(s.cache, bucketID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,94 @@
+package transform
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx = context.Background()
+
+	orgID    = platform.ID(10)
+	bucketID = platform.ID(100)
+)
+
+func TestService_CRUD(t *testing.T) {
+	svc, buckets := newTestService(t)
+
+	_, err := svc.FindBucketTransform(ctx, bucketID)
+	require.Equal(t, influxdb.ErrBucketTransformNotFound, err)
+
+	tr := &influxdb.BucketTransform{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Rules: []influxdb.TransformRule{
+			{Type: influxdb.TransformAddTag, Key: "region", Value: "eu"},
+		},
+	}
+	require.NoError(t, svc.PutBucketTransform(ctx, tr))
+	require.False(t, tr.CreatedAt.IsZero())
+
+	got, err := svc.FindBucketTransform(ctx, bucketID)
+	require.NoError(t, err)
+	require.Equal(t, tr, got)
+
+	// Putting a transform replaces its rules.
+	replaced := &influxdb.BucketTransform{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Rules: []influxdb.TransformRule{
+			{Type: influxdb.TransformRequireTags, Tags: []string{"host"}},
+			{Type: influxdb.TransformConvertField, Key: "n", DataType: influxdb.SchemaColumnDataTypeInteger.Ptr()},
+		},
+	}
+	require.NoError(t, svc.PutBucketTransform(ctx, replaced))
+	require.Equal(t, tr.CreatedAt, replaced.CreatedAt)
+	got, err = svc.FindBucketTransform(ctx, bucketID)
+	require.NoError(t, err)
+	require.Equal(t, replaced.Rules, got.Rules)
+
+	invalid := &influxdb.BucketTransform{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Rules:    []influxdb.TransformRule{{Type: influxdb.TransformDropField, Pattern: "("}},
+	}
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.PutBucketTransform(ctx, invalid)))
+	invalid.Rules = nil
+	require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.PutBucketTransform(ctx, invalid)))
+
+	otherOrg := &influxdb.BucketTransform{OrgID: platform.ID(11), BucketID: bucketID, Rules: tr.Rules}
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(svc.PutBucketTransform(ctx, otherOrg)))
+
+	// Deleting a bucket deletes its transform.
+	bs := NewBucketService(zaptest.NewLogger(t), buckets, svc)
+	require.NoError(t, bs.DeleteBucket(ctx, bucketID))
+	_, err = svc.FindBucketTransform(ctx, bucketID)
+	require.Equal(t, influxdb.ErrBucketTransformNotFound, err)
+	require.Equal(t, influxdb.ErrBucketTransformNotFound, svc.DeleteBucketTransform(ctx, bucketID))
+}
+
+func newTestService(t *testing.T) (*Service, *mock.BucketService) {
+	store, clean := sqlite.NewTestStore(t)
+	t.Cleanup(func() { clean(t) })
+	require.NoError(t, sqlite.NewMigrator(store, zaptest.NewLogger(t)).Up(ctx, migrations.AllUp))
+
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		if id != bucketID {
+			return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+		}
+		return &influxdb.Bucket{ID: id, OrgID: orgID}, nil
+	}
+	buckets.DeleteBucketFn = func(context.Context, platform.ID) error { return nil }
+
+	return NewService(store, buckets), buckets
+}
@@ -0,0 +1,593 @@
+package tsdb
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/pkg/estimator"
+	"github.com/influxdata/influxdb/v2/pkg/estimator/hll"
+)
+
+// CardinalityLimits bounds the number of series that may be created in a
+// database. A limit of zero disables it.
+type CardinalityLimits struct {
+	// MaxSeries bounds the number of series in the database.
+	MaxSeries int64
+
+	// MaxSeriesPerMeasurement bounds the number of series of a measurement
+	// within a shard.
+	MaxSeriesPerMeasurement int64
+
+	// MaxValuesPerTag bounds the number of values of a tag key of a
+	// measurement within a shard.
+	MaxValuesPerTag int64
+
+	// Org groups databases whose combined number of series is bounded by
+	// MaxOrgSeries.
+	Org          string
+	MaxOrgSeries int64
+}
+
+// Enabled returns true if any limit is set.
+func (l CardinalityLimits) Enabled() bool {
+	return l.MaxSeries > 0 || l.MaxSeriesPerMeasurement > 0 || l.MaxValuesPerTag > 0 ||
+		(l.Org != "" && l.MaxOrgSeries > 0)
+}
+
+// CardinalityLimiter provides an index with the cardinality limits of the
+// database it belongs to.
+type CardinalityLimiter interface {
+	// CardinalityLimits returns the current limits of the database.
+	CardinalityLimits() CardinalityLimits
+
+	// OrgSeriesN returns the number of series in all databases of org.
+	OrgSeriesN(org string) int64
+}
+
+// cardinalityLimits holds the cardinality limits of each database of a store.
+// It is guarded by its own lock so indexes may consult it while writing
+// without contending with the store.
+type cardinalityLimits struct {
+	mu       sync.RWMutex
+	defaults CardinalityLimits
+	limits   map[string]CardinalityLimits
+	sfiles   map[string]*SeriesFile
+}
+
+func newCardinalityLimits() *cardinalityLimits {
+	return &cardinalityLimits{
+		limits: make(map[string]CardinalityLimits),
+		sfiles: make(map[string]*SeriesFile),
+	}
+}
+
+func (c *cardinalityLimits) get(database string) CardinalityLimits {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if l, ok := c.limits[database]; ok {
+		return l
+	}
+	return c.defaults
+}
+
+func (c *cardinalityLimits) orgSeriesN(org string) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var n int64
+	for db, l := range c.limits {
+		if l.Org != org {
+			continue
+		}
+		if sfile := c.sfiles[db]; sfile != nil {
+			n += int64(sfile.SeriesCount())
+		}
+	}
+	return n
+}
+
+func (c *cardinalityLimits) addSeriesFile(database string, sfile *SeriesFile) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.sfiles[database] = sfile
+}
+
+func (c *cardinalityLimits) remove(database string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.limits, database)
+	delete(c.sfiles, database)
+}
+
+// databaseLimiter is the CardinalityLimiter of a single database.
+type databaseLimiter struct {
+	limits *cardinalityLimits
+	db     string
+}
+
+func (l databaseLimiter) CardinalityLimits() CardinalityLimits { return l.limits.get(l.db) }
+func (l databaseLimiter) OrgSeriesN(org string) int64          { return l.limits.orgSeriesN(org) }
+
+// CardinalityReport returns the number of series in the shards along with the
+// measurements with the most series, their tag keys with the most values and
+// those keys' values with the most series. Up to limit of each are returned.
+//
+// If exact is set, series are counted by unioning the series of every shard.
+// Otherwise the series counts stored by the index of each shard are summed,
+// without reading any series, and scaled by the overlap between shards as
+// estimated from their series sketches.
+func (s *Store) CardinalityReport(ctx context.Context, shardIDs []uint64, limit int, exact bool) (int64, []influxdb.MeasurementCardinality, error) {
+	shards := s.Shards(shardIDs)
+	if len(shards) == 0 {
+		return 0, nil, nil
+	}
+
+	indexes := make([]Index, 0, len(shards))
+	for _, sh := range shards {
+		idx, err := sh.Index()
+		if err != nil {
+			return 0, nil, err
+		}
+		indexes = append(indexes, idx)
+	}
+
+	seriesN, scale, err := s.cardinalityScale(shards, indexes, exact)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// The series of every measurement and the values of every tag key are
+	// counted first, so the series of tag values only have to be counted for
+	// the tag keys that are reported.
+	measurements := newSeriesCounter(exact)
+	values := make(map[string]*valueCounter)
+	for _, idx := range indexes {
+		if err := ctx.Err(); err != nil {
+			return 0, nil, err
+		}
+		if err := countMeasurements(idx, measurements, values, exact); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	var report []influxdb.MeasurementCardinality
+	for name := range measurements.keys() {
+		report = append(report, influxdb.MeasurementCardinality{
+			Name:    name,
+			SeriesN: measurements.count(name, scale),
+		})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].SeriesN != report[j].SeriesN {
+			return report[i].SeriesN > report[j].SeriesN
+		}
+		return report[i].Name < report[j].Name
+	})
+	if len(report) > limit {
+		report = report[:limit]
+	}
+
+	for i := range report {
+		m := &report[i]
+		prefix := m.Name + "\x00"
+		for tkey, vc := range values {
+			if strings.HasPrefix(tkey, prefix) {
+				m.TagKeys = append(m.TagKeys, influxdb.TagKeyCardinality{
+					Key:     tkey[len(prefix):],
+					ValuesN: vc.count(),
+				})
+			}
+		}
+		sort.Slice(m.TagKeys, func(i, j int) bool {
+			a, b := m.TagKeys[i], m.TagKeys[j]
+			if a.ValuesN != b.ValuesN {
+				return a.ValuesN > b.ValuesN
+			}
+			return a.Key < b.Key
+		})
+		if len(m.TagKeys) > limit {
+			m.TagKeys = m.TagKeys[:limit]
+		}
+
+		for j := range m.TagKeys {
+			k := &m.TagKeys[j]
+			if err := ctx.Err(); err != nil {
+				return 0, nil, err
+			}
+			k.Values, err = tagValueCardinalities(indexes, []byte(m.Name), []byte(k.Key), limit, exact, scale)
+			if err != nil {
+				return 0, nil, err
+			}
+		}
+	}
+	return seriesN, report, nil
+}
+
+// SeriesGrowth is the number of series of a group of shards and the number of
+// those not found in any earlier shards.
+type SeriesGrowth struct {
+	SeriesN    int64
+	NewSeriesN int64
+}
+
+// SeriesGrowthReport returns the series growth of each group of shards, in
+// order. The series of earlier, and of every group before it, are not new to
+// a group.
+func (s *Store) SeriesGrowthReport(ctx context.Context, earlier []uint64, groups [][]uint64) ([]SeriesGrowth, error) {
+	seen, err := s.seriesIDSetOf(earlier)
+	if err != nil {
+		return nil, err
+	}
+
+	growth := make([]SeriesGrowth, 0, len(groups))
+	for _, ids := range groups {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ss, err := s.seriesIDSetOf(ids)
+		if err != nil {
+			return nil, err
+		}
+		growth = append(growth, SeriesGrowth{
+			SeriesN:    int64(ss.Cardinality()),
+			NewSeriesN: int64(ss.AndNot(seen).Cardinality()),
+		})
+		seen.Merge(ss)
+	}
+	return growth, nil
+}
+
+// seriesIDSetOf returns the union of the series of the shards.
+func (s *Store) seriesIDSetOf(shardIDs []uint64) (*SeriesIDSet, error) {
+	ss := NewSeriesIDSet()
+	for _, sh := range s.Shards(shardIDs) {
+		idx, err := sh.Index()
+		if err != nil {
+			return nil, err
+		}
+		ss.Merge(idx.SeriesIDSet())
+	}
+	return ss, nil
+}
+
+// cardinalityScale returns the number of series in the shards and the factor
+// by which the sum of per-shard counts is scaled to account for series that
+// exist in more than one shard.
+func (s *Store) cardinalityScale(shards []*Shard, indexes []Index, exact bool) (int64, float64, error) {
+	if exact {
+		ss := NewSeriesIDSet()
+		for _, idx := range indexes {
+			ss.Merge(idx.SeriesIDSet())
+		}
+		return int64(ss.Cardinality()), 1, nil
+	}
+
+	var sum int64
+	for _, idx := range indexes {
+		sum += int64(idx.SeriesIDSet().Cardinality())
+	}
+	if sum == 0 {
+		return 0, 1, nil
+	}
+
+	var ss, ts estimator.Sketch
+	for _, sh := range shards {
+		sketch, tsketch, err := sh.SeriesSketches()
+		if err != nil {
+			return 0, 0, err
+		}
+		if ss == nil {
+			ss, ts = sketch.Clone(), tsketch.Clone()
+		} else if err := ss.Merge(sketch); err != nil {
+			return 0, 0, err
+		} else if err := ts.Merge(tsketch); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	n := int64(ss.Count()) - int64(ts.Count())
+	if n <= 0 {
+		return 0, 1, nil
+	} else if n >= sum {
+		return sum, 1, nil
+	}
+	return n, float64(n) / float64(sum), nil
+}
+
+// seriesNIndex is implemented by indexes which store the number of series of
+// their measurements and tag values, so that they can be estimated without
+// reading the series.
+type seriesNIndex interface {
+	MeasurementSeriesNs() (map[string]int64, error)
+	TagValueSeriesNs(name, key []byte) (map[string]int64, error)
+}
+
+// countMeasurements adds the series of every measurement of idx to
+// measurements, and the values of every tag key to values.
+func countMeasurements(idx Index, measurements *seriesCounter, values map[string]*valueCounter, exact bool) error {
+	if sidx, ok := idx.(seriesNIndex); ok && !exact {
+		counts, err := sidx.MeasurementSeriesNs()
+		if err != nil {
+			return err
+		}
+		for name, n := range counts {
+			if n == 0 {
+				continue
+			}
+			measurements.addN(name, n)
+			if err := countTagValues(idx, []byte(name), values, exact); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	mitr, err := idx.MeasurementIterator()
+	if err != nil {
+		return err
+	} else if mitr == nil {
+		return nil
+	}
+	defer mitr.Close()
+
+	for {
+		name, err := mitr.Next()
+		if err != nil {
+			return err
+		} else if name == nil {
+			return nil
+		}
+
+		sitr, err := idx.MeasurementSeriesIDIterator(name)
+		if err != nil {
+			return err
+		}
+		ss, err := seriesIDSetOf(sitr)
+		if err != nil {
+			return err
+		}
+		measurements.add(string(name), ss)
+
+		if err := countTagValues(idx, name, values, exact); err != nil {
+			return err
+		}
+	}
+}
+
+// countTagValues adds the values of every tag key of a measurement to values.
+func countTagValues(idx Index, name []byte, values map[string]*valueCounter, exact bool) error {
+	kitr, err := idx.TagKeyIterator(name)
+	if err != nil {
+		return err
+	} else if kitr == nil {
+		return nil
+	}
+	defer kitr.Close()
+
+	for {
+		key, err := kitr.Next()
+		if err != nil {
+			return err
+		} else if key == nil {
+			return nil
+		}
+
+		tkey := string(name) + "\x00" + string(key)
+		vc := values[tkey]
+		if vc == nil {
+			vc = newValueCounter(exact)
+			values[tkey] = vc
+		}
+
+		vitr, err := idx.TagValueIterator(name, key)
+		if err != nil {
+			return err
+		} else if vitr == nil {
+			continue
+		}
+		for {
+			value, err := vitr.Next()
+			if err != nil {
+				vitr.Close()
+				return err
+			} else if value == nil {
+				break
+			}
+			vc.add(value)
+		}
+		if err := vitr.Close(); err != nil {
+			return err
+		}
+	}
+}
+
+// tagValueCardinalities returns up to limit values of a tag key with the most
+// series across the indexes.
+func tagValueCardinalities(indexes []Index, name, key []byte, limit int, exact bool, scale float64) ([]influxdb.TagValueCardinality, error) {
+	counter := newSeriesCounter(exact)
+	for _, idx := range indexes {
+		if sidx, ok := idx.(seriesNIndex); ok && !exact {
+			counts, err := sidx.TagValueSeriesNs(name, key)
+			if err != nil {
+				return nil, err
+			}
+			for value, n := range counts {
+				if n > 0 {
+					counter.addN(value, n)
+				}
+			}
+			continue
+		}
+
+		vitr, err := idx.TagValueIterator(name, key)
+		if err != nil {
+			return nil, err
+		} else if vitr == nil {
+			continue
+		}
+
+		for {
+			value, err := vitr.Next()
+			if err != nil {
+				vitr.Close()
+				return nil, err
+			} else if value == nil {
+				break
+			}
+
+			sitr, err := idx.TagValueSeriesIDIterator(name, key, value)
+			if err != nil {
+				vitr.Close()
+				return nil, err
+			}
+			ss, err := seriesIDSetOf(sitr)
+			if err != nil {
+				vitr.Close()
+				return nil, err
+			}
+			counter.add(string(value), ss)
+		}
+		if err := vitr.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	var values []influxdb.TagValueCardinality
+	for value := range counter.keys() {
+		values = append(values, influxdb.TagValueCardinality{
+			Value:   value,
+			SeriesN: counter.count(value, scale),
+		})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].SeriesN != values[j].SeriesN {
+			return values[i].SeriesN > values[j].SeriesN
+		}
+		return values[i].Value < values[j].Value
+	})
+	if len(values) > limit {
+		values = values[:limit]
+	}
+	return values, nil
+}
+
+// seriesIDSetOf returns the series of itr as a set.
+func seriesIDSetOf(itr SeriesIDIterator) (*SeriesIDSet, error) {
+	if itr == nil {
+		return NewSeriesIDSet(), nil
+	}
+	defer itr.Close()
+
+	if sitr, ok := itr.(SeriesIDSetIterator); ok {
+		return sitr.SeriesIDSet(), nil
+	}
+
+	ss := NewSeriesIDSet()
+	for {
+		e, err := itr.Next()
+		if err != nil {
+			return nil, err
+		} else if e.SeriesID == 0 {
+			return ss, nil
+		}
+		ss.AddNoLock(e.SeriesID)
+	}
+}
+
+// seriesCounter counts the series of keys across shards, either exactly by
+// unioning their series or by summing their per-shard counts.
+type seriesCounter struct {
+	exact bool
+	sets  map[string]*SeriesIDSet
+	sums  map[string]int64
+}
+
+func newSeriesCounter(exact bool) *seriesCounter {
+	return &seriesCounter{
+		exact: exact,
+		sets:  make(map[string]*SeriesIDSet),
+		sums:  make(map[string]int64),
+	}
+}
+
+func (c *seriesCounter) add(key string, ss *SeriesIDSet) {
+	if !c.exact {
+		c.sums[key] += int64(ss.Cardinality())
+		return
+	}
+	if set, ok := c.sets[key]; ok {
+		set.Merge(ss)
+	} else {
+		c.sets[key] = ss.Clone()
+	}
+}
+
+// addN adds n series to the sum of key. It is only used when not counting
+// exactly.
+func (c *seriesCounter) addN(key string, n int64) {
+	c.sums[key] += n
+}
+
+// count returns the number of series of key. Summed counts are scaled by
+// scale, but never below one series.
+func (c *seriesCounter) count(key string, scale float64) int64 {
+	if c.exact {
+		if set := c.sets[key]; set != nil {
+			return int64(set.Cardinality())
+		}
+		return 0
+	}
+
+	n := c.sums[key]
+	if n == 0 {
+		return 0
+	}
+	if scaled := int64(math.Round(float64(n) * scale)); scaled > 0 {
+		return scaled
+	}
+	return 1
+}
+
+func (c *seriesCounter) keys() map[string]struct{} {
+	keys := make(map[string]struct{}, len(c.sets)+len(c.sums))
+	for k := range c.sets {
+		keys[k] = struct{}{}
+	}
+	for k := range c.sums {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
+
+// valueCounter counts the distinct values of a tag key across shards, either
+// exactly or with a sketch.
+type valueCounter struct {
+	values map[string]struct{}
+	sketch estimator.Sketch
+}
+
+func newValueCounter(exact bool) *valueCounter {
+	if exact {
+		return &valueCounter{values: make(map[string]struct{})}
+	}
+	return &valueCounter{sketch: hll.NewDefaultPlus()}
+}
+
+func (c *valueCounter) add(value []byte) {
+	if c.sketch != nil {
+		c.sketch.Add(value)
+		return
+	}
+	c.values[string(value)] = struct{}{}
+}
+
+func (c *valueCounter) count() int64 {
+	if c.sketch != nil {
+		return int64(c.sketch.Count())
+	}
+	return int64(len(c.values))
+}
@@ -36,6 +36,10 @@ const (
 	// will compact all TSM files in a shard if it hasn't received a write or delete
 	DefaultCompactFullWriteColdDuration = time.Duration(4 * time.Hour)
 
+	// DefaultCompactTombstoneThreshold is the percentage of a TSM generation's
+	// data that must be deleted before the engine rewrites it to reclaim the space.
+	DefaultCompactTombstoneThreshold = 25
+
 	// DefaultCompactThroughput is the rate limit in bytes per second that we
 	// will allow TSM compactions to write to disk. Not that short bursts are allowed
 	// to happen at a possibly larger value, set by DefaultCompactThroughputBurst.
@@ -52,7 +56,24 @@ const (
 	DefaultMaxPointsPerBlock = 1000
 
 	// DefaultMaxValuesPerTag is the maximum number of values a tag can have within a measurement.
-	DefaultMaxValuesPerTag = 100000
+	// A value of 0 disables the limit.
+	DefaultMaxValuesPerTag = 0
+
+	// DefaultMaxSeriesPerBucket is the maximum number of series a bucket can have.
+	// A value of 0 disables the limit.
+	DefaultMaxSeriesPerBucket = 0
+
+	// DefaultMaxSeriesPerMeasurement is the maximum number of series a measurement
+	// can have within a shard. A value of 0 disables the limit.
+	DefaultMaxSeriesPerMeasurement = 0
+
+	// DefaultMaxSeriesPerOrg is the maximum number of series all buckets of an
+	// organization can have together. A value of 0 disables the limit.
+	DefaultMaxSeriesPerOrg = 0
+
+	// DefaultSeriesGCInterval is the interval between passes of the series
+	// garbage collector. A value of 0 disables it.
+	DefaultSeriesGCInterval = time.Duration(0)
 
 	// DefaultMaxConcurrentCompactions is the maximum number of concurrent full and level compactions
 	// that can run at one time.  A value of 0 results in 50% of runtime.GOMAXPROCS(0) used at runtime.
@@ -112,8 +133,27 @@ type Config struct {
 	CompactThroughput              toml.Size     `toml:"compact-throughput"`
 	CompactThroughputBurst         toml.Size     `toml:"compact-throughput-burst"`
 
+	// CompactTombstoneThreshold is the percentage of deleted data at which a TSM
+	// generation is rewritten on its own to drop the deleted blocks. A value of 0
+	// disables tombstone compactions.
+	CompactTombstoneThreshold int `toml:"compact-tombstone-threshold"`
+
+	// CompactRollups enables writing the count, sum, min, max, first and last of
+	// each block of a TSM file alongside it when the file is written, so that
+	// window aggregates over whole blocks do not decode them.
+	CompactRollups bool `toml:"compact-rollups"`
+
 	// Limits
 
+	// MaxSeriesPerBucket, MaxSeriesPerMeasurement, MaxValuesPerTag and MaxSeriesPerOrg
+	// are the series cardinality limits of buckets that do not set their own, or
+	// whose organization does not. New series that would exceed a limit are
+	// rejected with a partial write error. A value of 0 disables a limit.
+	MaxSeriesPerBucket      int `toml:"max-series-per-bucket"`
+	MaxSeriesPerMeasurement int `toml:"max-series-per-measurement"`
+	MaxValuesPerTag         int `toml:"max-values-per-tag"`
+	MaxSeriesPerOrg         int `toml:"max-series-per-org"`
+
 	// MaxConcurrentCompactions is the maximum number of concurrent level and full compactions
 	// that can be running at one time across all shards.  Compactions scheduled to run when the
 	// limit is reached are blocked until a running compaction completes.  Snapshot compactions are
@@ -138,6 +178,14 @@ type Config struct {
 	// 8 (series file partition quantity) and runtime.GOMAXPROCS(0).
 	SeriesFileMaxConcurrentSnapshotCompactions int `toml:"series-file-max-concurrent-snapshot-compactions"`
 
+	// SeriesGCInterval is the interval between passes of the series garbage
+	// collector, which removes series that no longer have data in any shard
+	// from the index and series file. A series is removed once two
+	// consecutive passes find it without data. When enabled, the segments of
+	// series files are also rewritten without removed series when opened.
+	// A value of 0 disables the collector.
+	SeriesGCInterval toml.Duration `toml:"series-gc-interval"`
+
 	TraceLoggingEnabled bool `toml:"trace-logging-enabled"`
 
 	// TSMWillNeed controls whether we hint to the kernel that we intend to
@@ -161,6 +209,12 @@ func NewConfig() Config {
 		CompactFullWriteColdDuration:   toml.Duration(DefaultCompactFullWriteColdDuration),
 		CompactThroughput:              toml.Size(DefaultCompactThroughput),
 		CompactThroughputBurst:         toml.Size(DefaultCompactThroughputBurst),
+		CompactTombstoneThreshold:      DefaultCompactTombstoneThreshold,
+
+		MaxSeriesPerBucket:      DefaultMaxSeriesPerBucket,
+		MaxSeriesPerMeasurement: DefaultMaxSeriesPerMeasurement,
+		MaxValuesPerTag:         DefaultMaxValuesPerTag,
+		MaxSeriesPerOrg:         DefaultMaxSeriesPerOrg,
 
 		MaxConcurrentCompactions: DefaultMaxConcurrentCompactions,
 
@@ -170,12 +224,23 @@ func NewConfig() Config {
 		SeriesIDSetCacheSize: DefaultSeriesIDSetCacheSize,
 
 		SeriesFileMaxConcurrentSnapshotCompactions: DefaultSeriesFileMaxConcurrentSnapshotCompactions,
+		SeriesGCInterval: toml.Duration(DefaultSeriesGCInterval),
 
 		TraceLoggingEnabled: false,
 		TSMWillNeed:         false,
 	}
 }
 
+// CardinalityLimits returns the series cardinality limits of databases that
+// do not set their own.
+func (c *Config) CardinalityLimits() CardinalityLimits {
+	return CardinalityLimits{
+		MaxSeries:               int64(c.MaxSeriesPerBucket),
+		MaxSeriesPerMeasurement: int64(c.MaxSeriesPerMeasurement),
+		MaxValuesPerTag:         int64(c.MaxValuesPerTag),
+	}
+}
+
 // Validate validates the configuration hold by c.
 func (c *Config) Validate() error {
 	if c.Dir == "" {
@@ -188,6 +253,14 @@ func (c *Config) Validate() error {
 		return errors.New("max-concurrent-compactions must be non-negative")
 	}
 
+	if c.CompactTombstoneThreshold < 0 || c.CompactTombstoneThreshold > 100 {
+		return errors.New("compact-tombstone-threshold must be between 0 and 100")
+	}
+
+	if c.MaxSeriesPerBucket < 0 || c.MaxSeriesPerMeasurement < 0 || c.MaxValuesPerTag < 0 || c.MaxSeriesPerOrg < 0 {
+		return errors.New("series cardinality limits must be non-negative")
+	}
+
 	if c.SeriesIDSetCacheSize < 0 {
 		return errors.New("series-id-set-cache-size must be non-negative")
 	}
@@ -196,6 +269,10 @@ func (c *Config) Validate() error {
 		return errors.New("series-file-max-concurrent-compactions must be non-negative")
 	}
 
+	if c.SeriesGCInterval < 0 {
+		return errors.New("series-gc-interval must be non-negative")
+	}
+
 	valid := false
 	for _, e := range RegisteredEngines() {
 		if e == c.Engine {
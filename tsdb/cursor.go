@@ -20,11 +20,21 @@ type (
 	StringArrayCursor   = cursors.StringArrayCursor
 	BooleanArrayCursor  = cursors.BooleanArrayCursor
 
+	FloatBlockSummary    = cursors.FloatBlockSummary
+	IntegerBlockSummary  = cursors.IntegerBlockSummary
+	UnsignedBlockSummary = cursors.UnsignedBlockSummary
+
+	FloatSummaryArrayCursor    = cursors.FloatSummaryArrayCursor
+	IntegerSummaryArrayCursor  = cursors.IntegerSummaryArrayCursor
+	UnsignedSummaryArrayCursor = cursors.UnsignedSummaryArrayCursor
+
 	Cursor          = cursors.Cursor
 	CursorStats     = cursors.CursorStats
 	CursorRequest   = cursors.CursorRequest
 	CursorIterator  = cursors.CursorIterator
 	CursorIterators = cursors.CursorIterators
+
+	SummaryCursorIterator = cursors.SummaryCursorIterator
 )
 
 func NewIntegerArrayLen(sz int) *IntegerArray   { return cursors.NewIntegerArrayLen(sz) }
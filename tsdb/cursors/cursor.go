@@ -72,6 +72,18 @@ type CursorIterator interface {
 	Stats() CursorStats
 }
 
+// A SummaryCursorIterator is a CursorIterator that can return blocks of
+// values as summaries, so that aggregates over the blocks can be computed
+// without reading their values.
+type SummaryCursorIterator interface {
+	CursorIterator
+
+	// NextSummarized returns a cursor for r. If the cursor is a numeric
+	// SummaryArrayCursor, blocks of values for which summarize returns true
+	// may be returned as summaries. Only ascending requests are summarized.
+	NextSummarized(ctx context.Context, r *CursorRequest, summarize func(minTime, maxTime int64) bool) (Cursor, error)
+}
+
 type CursorIterators []CursorIterator
 
 // Stats returns the aggregate stats of all cursor iterators.
@@ -1,4 +1,5 @@
 package cursors
 
 //go:generate env GO111MODULE=on go run github.com/benbjohnson/tmpl -data=@arrayvalues.gen.go.tmpldata arrayvalues.gen.go.tmpl
+//go:generate env GO111MODULE=on go run github.com/benbjohnson/tmpl -data=@arrayvalues.gen.go.tmpldata summary.gen.go.tmpl
 //go:generate stringer -type FieldType
@@ -0,0 +1,76 @@
+// Generated by tmpl
+// https://github.com/benbjohnson/tmpl
+//
+// DO NOT EDIT!
+// Source: summary.gen.go.tmpl
+
+package cursors
+
+// FloatBlockSummary summarizes a block of float64 values so that aggregates over
+// the block can be computed without reading its values.
+type FloatBlockSummary struct {
+	MinTime, MaxTime int64 // times of the first and last values of the block
+
+	Count                      int64
+	Sum, Min, Max, First, Last float64
+
+	// MinValueTime and MaxValueTime are the times of the first occurrences of
+	// Min and Max.
+	MinValueTime, MaxValueTime int64
+}
+
+// A FloatSummaryArrayCursor is a FloatArrayCursor that returns some
+// blocks of values as summaries rather than values.
+type FloatSummaryArrayCursor interface {
+	FloatArrayCursor
+
+	// Summaries returns the summaries of the blocks whose values are not
+	// returned by Next, in ascending time order.
+	Summaries() []FloatBlockSummary
+}
+
+// IntegerBlockSummary summarizes a block of int64 values so that aggregates over
+// the block can be computed without reading its values.
+type IntegerBlockSummary struct {
+	MinTime, MaxTime int64 // times of the first and last values of the block
+
+	Count                      int64
+	Sum, Min, Max, First, Last int64
+
+	// MinValueTime and MaxValueTime are the times of the first occurrences of
+	// Min and Max.
+	MinValueTime, MaxValueTime int64
+}
+
+// A IntegerSummaryArrayCursor is a IntegerArrayCursor that returns some
+// blocks of values as summaries rather than values.
+type IntegerSummaryArrayCursor interface {
+	IntegerArrayCursor
+
+	// Summaries returns the summaries of the blocks whose values are not
+	// returned by Next, in ascending time order.
+	Summaries() []IntegerBlockSummary
+}
+
+// UnsignedBlockSummary summarizes a block of uint64 values so that aggregates over
+// the block can be computed without reading its values.
+type UnsignedBlockSummary struct {
+	MinTime, MaxTime int64 // times of the first and last values of the block
+
+	Count                      int64
+	Sum, Min, Max, First, Last uint64
+
+	// MinValueTime and MaxValueTime are the times of the first occurrences of
+	// Min and Max.
+	MinValueTime, MaxValueTime int64
+}
+
+// A UnsignedSummaryArrayCursor is a UnsignedArrayCursor that returns some
+// blocks of values as summaries rather than values.
+type UnsignedSummaryArrayCursor interface {
+	UnsignedArrayCursor
+
+	// Summaries returns the summaries of the blocks whose values are not
+	// returned by Next, in ascending time order.
+	Summaries() []UnsignedBlockSummary
+}
@@ -11,6 +11,7 @@ import (
 	"sort"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/estimator"
@@ -33,11 +34,16 @@ type Engine interface {
 	SetEnabled(enabled bool)
 	SetCompactionsEnabled(enabled bool)
 	ScheduleFullCompaction() error
+	CompactionStatus() influxdb.ShardCompactionStatus
+	Compact(ctx context.Context, optimize bool) error
+	PurgeTombstones(ctx context.Context) error
 
 	WithLogger(*zap.Logger)
 
 	LoadMetadataIndex(shardID uint64, index Index) error
 
+	SetWALPolicy(p WALPolicy)
+
 	CreateSnapshot(skipCacheOk bool) (string, error)
 	Backup(w io.Writer, basePath string, since time.Time) error
 	Export(w io.Writer, basePath string, start time.Time, end time.Time) error
@@ -54,6 +60,8 @@ type Engine interface {
 	CreateSeriesListIfNotExists(keys, names [][]byte, tags []models.Tags) error
 	DeleteSeriesRange(ctx context.Context, itr SeriesIterator, min, max int64) error
 	DeleteSeriesRangeWithPredicate(ctx context.Context, itr SeriesIterator, predicate func(name []byte, tags models.Tags) (int64, int64, bool)) error
+	SeriesWithoutData(ctx context.Context) (*SeriesIDSet, error)
+	DropSeriesWithoutData(ctx context.Context, ids *SeriesIDSet) (int, error)
 
 	MeasurementsSketches() (estimator.Sketch, estimator.Sketch, error)
 	SeriesSketches() (estimator.Sketch, estimator.Sketch, error)
@@ -164,6 +172,7 @@ type EngineOptions struct {
 	CompactionLimiter           limiter.Fixed
 	CompactionThroughputLimiter limiter.Rate
 	WALEnabled                  bool
+	WALPolicy                   WALPolicy
 	MonitorDisabled             bool
 
 	// DatabaseFilter is a predicate controlling which databases may be opened.
@@ -181,6 +190,10 @@ type EngineOptions struct {
 	Config       Config
 	SeriesIDSets SeriesIDSets
 
+	// CardinalityLimiter provides the series cardinality limits enforced by
+	// the index. If nil, no limits are enforced.
+	CardinalityLimiter CardinalityLimiter
+
 	OnNewEngine func(Engine)
 
 	FileStoreObserver FileStoreObserver
@@ -201,6 +214,56 @@ func NewEngineOptions() EngineOptions {
 
 type CompactionPlannerCreator func(cfg Config) interface{}
 
+// WALMode controls how writes to a shard's write-ahead log are made durable.
+type WALMode string
+
+const (
+	// WALModeDefault uses the server-wide WAL configuration.
+	WALModeDefault WALMode = ""
+
+	// WALModeFsync fsyncs the WAL before acknowledging every write.
+	WALModeFsync WALMode = "fsync"
+
+	// WALModeGroupCommit batches the fsyncs of concurrent writes, waiting up
+	// to the policy's FsyncDelay before syncing.
+	WALModeGroupCommit WALMode = "group-commit"
+
+	// WALModeNone skips the WAL entirely. Writes are only held in the cache,
+	// so anything not yet snapshotted to TSM is lost on a crash.
+	WALModeNone WALMode = "none"
+)
+
+// Valid returns an error if m is not a known WAL mode.
+func (m WALMode) Valid() error {
+	switch m {
+	case WALModeDefault, WALModeFsync, WALModeGroupCommit, WALModeNone:
+		return nil
+	}
+	return fmt.Errorf("unknown WAL mode %q", string(m))
+}
+
+// WALPolicy describes the durability of writes to a shard.
+type WALPolicy struct {
+	Mode WALMode
+
+	// FsyncDelay is the maximum time a write waits for its fsync when Mode
+	// is WALModeGroupCommit.
+	FsyncDelay time.Duration
+}
+
+// SyncDelay returns the WAL fsync delay for the policy, falling back to
+// defaultDelay when the policy uses the server-wide configuration.
+func (p WALPolicy) SyncDelay(defaultDelay time.Duration) time.Duration {
+	switch p.Mode {
+	case WALModeFsync:
+		return 0
+	case WALModeGroupCommit:
+		return p.FsyncDelay
+	default:
+		return defaultDelay
+	}
+}
+
 // FileStoreObserver is passed notifications before the file store adds or deletes files. In this way, it can
 // be sure to observe every file that is added or removed even in the presence of process death.
 type FileStoreObserver interface {
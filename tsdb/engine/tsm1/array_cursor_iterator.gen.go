@@ -34,6 +34,40 @@ func (q *arrayCursorIterator) buildFloatArrayCursor(ctx context.Context, name []
 	}
 }
 
+// buildFloatSummaryArrayCursor creates an ascending array cursor for a float field
+// that returns the blocks accepted by summarize as summaries.
+func (q *arrayCursorIterator) buildFloatSummaryArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, summarize func(minTime, maxTime int64) bool) tsdb.FloatArrayCursor {
+	key := q.seriesFieldKeyBytes(name, tags, field)
+	cacheValues := q.e.Cache.Values(key)
+	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), true)
+	summaries := keyCursor.summarize(opt.StartTime, opt.EndTime, cacheValues, summarize)
+	if q.sum.Float == nil {
+		q.sum.Float = &floatSummaryArrayCursor{
+			floatArrayAscendingCursor: newFloatArrayAscendingCursor(),
+		}
+	}
+	c := q.sum.Float
+	c.reset(opt.SeekTime(), opt.StopTime(), cacheValues, keyCursor)
+	c.summaries = c.summaries[:0]
+	for i := range summaries {
+		c.summaries = append(c.summaries, summaries[i].FloatSummary())
+	}
+	return c
+}
+
+// floatSummaryArrayCursor is an ascending array cursor that returns some
+// blocks as summaries.
+type floatSummaryArrayCursor struct {
+	*floatArrayAscendingCursor
+	summaries []tsdb.FloatBlockSummary
+}
+
+// Summaries returns the summaries of the blocks whose values are not returned
+// by Next.
+func (c *floatSummaryArrayCursor) Summaries() []tsdb.FloatBlockSummary {
+	return c.summaries
+}
+
 // buildIntegerArrayCursor creates an array cursor for a integer field.
 func (q *arrayCursorIterator) buildIntegerArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.IntegerArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
@@ -54,6 +88,40 @@ func (q *arrayCursorIterator) buildIntegerArrayCursor(ctx context.Context, name
 	}
 }
 
+// buildIntegerSummaryArrayCursor creates an ascending array cursor for a integer field
+// that returns the blocks accepted by summarize as summaries.
+func (q *arrayCursorIterator) buildIntegerSummaryArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, summarize func(minTime, maxTime int64) bool) tsdb.IntegerArrayCursor {
+	key := q.seriesFieldKeyBytes(name, tags, field)
+	cacheValues := q.e.Cache.Values(key)
+	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), true)
+	summaries := keyCursor.summarize(opt.StartTime, opt.EndTime, cacheValues, summarize)
+	if q.sum.Integer == nil {
+		q.sum.Integer = &integerSummaryArrayCursor{
+			integerArrayAscendingCursor: newIntegerArrayAscendingCursor(),
+		}
+	}
+	c := q.sum.Integer
+	c.reset(opt.SeekTime(), opt.StopTime(), cacheValues, keyCursor)
+	c.summaries = c.summaries[:0]
+	for i := range summaries {
+		c.summaries = append(c.summaries, summaries[i].IntegerSummary())
+	}
+	return c
+}
+
+// integerSummaryArrayCursor is an ascending array cursor that returns some
+// blocks as summaries.
+type integerSummaryArrayCursor struct {
+	*integerArrayAscendingCursor
+	summaries []tsdb.IntegerBlockSummary
+}
+
+// Summaries returns the summaries of the blocks whose values are not returned
+// by Next.
+func (c *integerSummaryArrayCursor) Summaries() []tsdb.IntegerBlockSummary {
+	return c.summaries
+}
+
 // buildUnsignedArrayCursor creates an array cursor for a unsigned field.
 func (q *arrayCursorIterator) buildUnsignedArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.UnsignedArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
@@ -74,6 +142,40 @@ func (q *arrayCursorIterator) buildUnsignedArrayCursor(ctx context.Context, name
 	}
 }
 
+// buildUnsignedSummaryArrayCursor creates an ascending array cursor for a unsigned field
+// that returns the blocks accepted by summarize as summaries.
+func (q *arrayCursorIterator) buildUnsignedSummaryArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions, summarize func(minTime, maxTime int64) bool) tsdb.UnsignedArrayCursor {
+	key := q.seriesFieldKeyBytes(name, tags, field)
+	cacheValues := q.e.Cache.Values(key)
+	keyCursor := q.e.KeyCursor(ctx, key, opt.SeekTime(), true)
+	summaries := keyCursor.summarize(opt.StartTime, opt.EndTime, cacheValues, summarize)
+	if q.sum.Unsigned == nil {
+		q.sum.Unsigned = &unsignedSummaryArrayCursor{
+			unsignedArrayAscendingCursor: newUnsignedArrayAscendingCursor(),
+		}
+	}
+	c := q.sum.Unsigned
+	c.reset(opt.SeekTime(), opt.StopTime(), cacheValues, keyCursor)
+	c.summaries = c.summaries[:0]
+	for i := range summaries {
+		c.summaries = append(c.summaries, summaries[i].UnsignedSummary())
+	}
+	return c
+}
+
+// unsignedSummaryArrayCursor is an ascending array cursor that returns some
+// blocks as summaries.
+type unsignedSummaryArrayCursor struct {
+	*unsignedArrayAscendingCursor
+	summaries []tsdb.UnsignedBlockSummary
+}
+
+// Summaries returns the summaries of the blocks whose values are not returned
+// by Next.
+func (c *unsignedSummaryArrayCursor) Summaries() []tsdb.UnsignedBlockSummary {
+	return c.summaries
+}
+
 // buildStringArrayCursor creates an array cursor for a string field.
 func (q *arrayCursorIterator) buildStringArrayCursor(ctx context.Context, name []byte, tags models.Tags, field string, opt query.IteratorOptions) tsdb.StringArrayCursor {
 	key := q.seriesFieldKeyBytes(name, tags, field)
@@ -30,6 +30,12 @@ type arrayCursorIterator struct {
 		Boolean  *booleanArrayDescendingCursor
 		String   *stringArrayDescendingCursor
 	}
+
+	sum struct {
+		Float    *floatSummaryArrayCursor
+		Integer  *integerSummaryArrayCursor
+		Unsigned *unsignedSummaryArrayCursor
+	}
 }
 
 func (q *arrayCursorIterator) Stats() tsdb.CursorStats {
@@ -37,6 +43,21 @@ func (q *arrayCursorIterator) Stats() tsdb.CursorStats {
 }
 
 func (q *arrayCursorIterator) Next(ctx context.Context, r *tsdb.CursorRequest) (tsdb.Cursor, error) {
+	return q.next(ctx, r, nil)
+}
+
+// NextSummarized returns a cursor for r. Blocks of numeric values within the
+// requested time range that are accepted by summarize, and that overlap
+// neither other blocks, cached values nor tombstones, are returned as the
+// summaries written alongside their TSM file rather than decoded.
+func (q *arrayCursorIterator) NextSummarized(ctx context.Context, r *tsdb.CursorRequest, summarize func(minTime, maxTime int64) bool) (tsdb.Cursor, error) {
+	if !r.Ascending {
+		summarize = nil
+	}
+	return q.next(ctx, r, summarize)
+}
+
+func (q *arrayCursorIterator) next(ctx context.Context, r *tsdb.CursorRequest, summarize func(minTime, maxTime int64) bool) (tsdb.Cursor, error) {
 	// Look up fields for measurement.
 	mf := q.e.fieldset.Fields(r.Name)
 	if mf == nil {
@@ -60,6 +81,17 @@ func (q *arrayCursorIterator) Next(ctx context.Context, r *tsdb.CursorRequest) (
 	opt.EndTime = r.EndTime // inclusive
 
 	// Return appropriate cursor based on type.
+	if summarize != nil {
+		switch f.Type {
+		case influxql.Float:
+			return q.buildFloatSummaryArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, summarize), nil
+		case influxql.Integer:
+			return q.buildIntegerSummaryArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, summarize), nil
+		case influxql.Unsigned:
+			return q.buildUnsignedSummaryArrayCursor(ctx, r.Name, r.Tags, r.Field, opt, summarize), nil
+		}
+	}
+
 	switch f.Type {
 	case influxql.Float:
 		return q.buildFloatArrayCursor(ctx, r.Name, r.Tags, r.Field, opt), nil
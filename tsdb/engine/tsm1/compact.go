@@ -21,11 +21,13 @@ import (
 	"path/filepath"
 	"runtime"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/pkg/limiter"
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"go.uber.org/zap"
@@ -96,6 +98,12 @@ type CompactionPlanner interface {
 	Plan(lastWrite time.Time) ([]CompactionGroup, int64)
 	PlanLevel(level int) ([]CompactionGroup, int64)
 	PlanOptimize() ([]CompactionGroup, int64)
+
+	// PlanTombstones returns generations whose estimated fraction of deleted
+	// data is at least threshold, so they can be rewritten to reclaim disk
+	// space. A threshold of 0 plans every generation with tombstones.
+	PlanTombstones(threshold float64) ([]CompactionGroup, int64)
+
 	Release(group []CompactionGroup)
 	FullyCompacted() (bool, string)
 
@@ -144,6 +152,7 @@ type fileStore interface {
 	LastModified() time.Time
 	BlockCount(path string, idx int) int
 	ParseFileName(path string) (int, int, error)
+	TombstonedFraction(path string) float64
 }
 
 func NewDefaultPlanner(fs fileStore, writeColdDuration time.Duration) *DefaultPlanner {
@@ -193,6 +202,83 @@ func (t *tsmGeneration) level() int {
 	return 4
 }
 
+// FileStoreGenerations returns the TSM generations of f, newest first.
+func FileStoreGenerations(f *FileStore) []influxdb.CompactionGeneration {
+	generations := make(map[int]*tsmGeneration)
+	for _, stat := range f.Stats() {
+		id, _, err := f.ParseFileName(stat.Path)
+		if err != nil {
+			continue
+		}
+		g := generations[id]
+		if g == nil {
+			g = newTsmGeneration(id, f.ParseFileName)
+			generations[id] = g
+		}
+		g.files = append(g.files, stat)
+	}
+
+	ordered := make(tsmGenerations, 0, len(generations))
+	for _, g := range generations {
+		ordered = append(ordered, g)
+	}
+	sort.Sort(sort.Reverse(ordered))
+
+	a := make([]influxdb.CompactionGeneration, 0, len(ordered))
+	for _, g := range ordered {
+		gen := influxdb.CompactionGeneration{
+			ID:            g.id,
+			Level:         g.level(),
+			Size:          int64(g.size()),
+			HasTombstones: g.hasTombstones(),
+		}
+		for _, stat := range g.files {
+			gen.Files = append(gen.Files, filepath.Base(stat.Path))
+		}
+		a = append(a, gen)
+	}
+	return a
+}
+
+// PlanCompactions returns the groups the planner would compact next, in the
+// order the engine's compaction loop considers them. Planned files are
+// released before returning.
+func PlanCompactions(p CompactionPlanner, lastWrite time.Time, tombstoneThreshold float64) []influxdb.CompactionGroup {
+	var plan []influxdb.CompactionGroup
+	for level := 1; level <= 3; level++ {
+		groups, _ := p.PlanLevel(level)
+		plan = append(plan, plannedGroups(strconv.Itoa(level), groups)...)
+		p.Release(groups)
+	}
+
+	groups, _ := p.Plan(lastWrite)
+	label := levelFull
+	if len(groups) == 0 && tombstoneThreshold > 0 {
+		groups, _ = p.PlanTombstones(tombstoneThreshold)
+		label = levelTombstone
+	}
+	if len(groups) == 0 {
+		groups, _ = p.PlanOptimize()
+		label = levelOpt
+	}
+	plan = append(plan, plannedGroups(label, groups)...)
+	p.Release(groups)
+	return plan
+}
+
+// plannedGroups converts planned compaction groups to their file names.
+func plannedGroups(level string, groups []CompactionGroup) []influxdb.CompactionGroup {
+	a := make([]influxdb.CompactionGroup, 0, len(groups))
+	for _, group := range groups {
+		files := make([]string, 0, len(group))
+		for _, f := range group {
+			files = append(files, filepath.Base(f))
+		}
+		a = append(a, influxdb.CompactionGroup{Level: level, Files: files})
+	}
+	return a
+}
+
 // count returns the number of files in the generation.
 func (t *tsmGeneration) count() int {
 	return len(t.files)
@@ -423,6 +509,53 @@ func (c *DefaultPlanner) PlanOptimize() ([]CompactionGroup, int64) {
 	return cGroups, int64(len(cGroups))
 }
 
+// PlanTombstones returns a group for each generation whose estimated fraction
+// of tombstoned data is at least threshold. Each generation is rewritten on
+// its own so that deleted blocks are dropped without waiting for the files to
+// be included in a full compaction.
+func (c *DefaultPlanner) PlanTombstones(threshold float64) ([]CompactionGroup, int64) {
+	// If a full plan has been requested, don't plan any generations which will
+	// prevent the full plan from acquiring them.
+	c.mu.RLock()
+	if c.forceFull {
+		c.mu.RUnlock()
+		return nil, 0
+	}
+	c.mu.RUnlock()
+
+	// Files already planned are not skipped, so that acquire fails and the
+	// caller can tell that tombstoned generations are still waiting.
+	var cGroups []CompactionGroup
+	for _, gen := range c.findGenerations(false) {
+		if !gen.hasTombstones() {
+			continue
+		}
+
+		if threshold > 0 {
+			var deleted, size float64
+			for _, f := range gen.files {
+				deleted += c.FileStore.TombstonedFraction(f.Path) * float64(f.Size)
+				size += float64(f.Size)
+			}
+			if size == 0 || deleted/size < threshold {
+				continue
+			}
+		}
+
+		var cGroup CompactionGroup
+		for _, f := range gen.files {
+			cGroup = append(cGroup, f.Path)
+		}
+		cGroups = append(cGroups, cGroup)
+	}
+
+	if !c.acquire(cGroups) {
+		return nil, int64(len(cGroups))
+	}
+
+	return cGroups, int64(len(cGroups))
+}
+
 // Plan returns a set of TSM files to rewrite for level 4 or higher.  The planning returns
 // multiple groups if possible to allow compactions to run concurrently.
 func (c *DefaultPlanner) Plan(lastWrite time.Time) ([]CompactionGroup, int64) {
@@ -709,6 +842,10 @@ type Compactor struct {
 	Dir  string
 	Size int
 
+	// Rollups enables writing the summaries of the blocks of new TSM files
+	// alongside them.
+	Rollups bool
+
 	FileStore interface {
 		NextGeneration() int
 		TSMReader(path string) *TSMReader
@@ -1071,6 +1208,9 @@ func (c *Compactor) writeNewFiles(generation, sequence int, src []string, iter K
 			if err := os.RemoveAll(fileName); err != nil {
 				return nil, err
 			}
+			if err := os.RemoveAll(rollupPath(fileName)); err != nil {
+				return nil, err
+			}
 			break
 		} else if _, ok := err.(errCompactionInProgress); ok {
 			// Don't clean up the file as another compaction is using it.  This should not happen as the
@@ -1082,10 +1222,12 @@ func (c *Compactor) writeNewFiles(generation, sequence int, src []string, iter K
 			// discard later errors to return the first one from the write() call
 			for _, f := range files {
 				_ = os.RemoveAll(f)
+				_ = os.RemoveAll(rollupPath(f))
 			}
 			// Remove the temp file
 			// discard later errors to return the first one from the write() call
 			_ = os.RemoveAll(fileName)
+			_ = os.RemoveAll(rollupPath(fileName))
 			return nil, err
 		}
 
@@ -1134,6 +1276,34 @@ func (c *Compactor) write(path string, iter KeyIterator, throttle bool, logger *
 		}
 	}
 
+	// The summaries of the blocks are written alongside the TSM file. The TSM
+	// file is usable without them, so failing to write them is not an error.
+	var rollups *rollupWriter
+	if c.Rollups {
+		rollups, err = newRollupWriter(rollupPath(path))
+		if err != nil {
+			logger.Info("Error creating block summaries", zap.String("output_file", path), zap.Error(err))
+			rollups, err = nil, nil
+		}
+	}
+	defer func() {
+		if rollups == nil {
+			return
+		}
+		if err != nil && err != ErrMaxBlocksExceeded && err != errMaxFileExceeded {
+			_ = rollups.remove()
+			return
+		}
+		stat, statErr := os.Stat(path)
+		if statErr == nil {
+			statErr = rollups.close(uint32(stat.Size()))
+		}
+		if statErr != nil {
+			logger.Info("Error writing block summaries", zap.String("output_file", path), zap.Error(statErr))
+			_ = rollups.remove()
+		}
+	}()
+
 	defer func() {
 		closeErr := w.Close()
 		if err == nil {
@@ -1175,7 +1345,15 @@ func (c *Compactor) write(path string, iter KeyIterator, throttle bool, logger *
 		}
 
 		// Write the key and value
-		if err := w.WriteBlock(key, minTime, maxTime, block); err == ErrMaxBlocksExceeded {
+		err = w.WriteBlock(key, minTime, maxTime, block)
+		if rollups != nil && (err == nil || err == ErrMaxBlocksExceeded) {
+			if err := rollups.add(key, block); err != nil {
+				logger.Info("Error summarizing block", zap.String("output_file", path), zap.Error(err))
+				_ = rollups.remove()
+				rollups = nil
+			}
+		}
+		if err == ErrMaxBlocksExceeded {
 			if err := w.WriteIndex(); err != nil {
 				return err
 			}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/influxdata/influxdb/v2/tsdb"
 	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 )
 
@@ -2416,6 +2417,59 @@ func TestDefaultPlanner_PlanOptimize_Tombstones(t *testing.T) {
 
 }
 
+// Ensure that the planner rewrites generations whose deleted fraction
+// reaches the threshold, each in its own group.
+func TestDefaultPlanner_PlanTombstones(t *testing.T) {
+	data := []tsm1.FileStat{
+		{
+			Path:         "01-04.tsm1",
+			Size:         100 * 1024 * 1024,
+			HasTombstone: true,
+		},
+		{
+			Path:         "01-05.tsm1",
+			Size:         100 * 1024 * 1024,
+			HasTombstone: true,
+		},
+		{
+			Path:         "02-04.tsm1",
+			Size:         100 * 1024 * 1024,
+			HasTombstone: true,
+		},
+		{
+			Path: "03-04.tsm1",
+			Size: 100 * 1024 * 1024,
+		},
+	}
+
+	fs := &fakeFileStore{
+		PathsFn: func() []tsm1.FileStat {
+			return data
+		},
+		tombstoned: map[string]float64{
+			"01-04.tsm1": 0.9,
+			"01-05.tsm1": 0.3,
+			"02-04.tsm1": 0.1,
+		},
+	}
+	cp := tsm1.NewDefaultPlanner(fs, tsdb.DefaultCompactFullWriteColdDuration)
+
+	tsm, pLen := cp.PlanTombstones(0.5)
+	require.Equal(t, int64(1), pLen)
+	require.Equal(t, []tsm1.CompactionGroup{{"01-04.tsm1", "01-05.tsm1"}}, tsm)
+
+	// Planned files must not be planned again until released.
+	tsm, pLen = cp.PlanTombstones(0.5)
+	require.Equal(t, int64(1), pLen)
+	require.Empty(t, tsm)
+	cp.Release([]tsm1.CompactionGroup{{"01-04.tsm1", "01-05.tsm1"}})
+
+	// A threshold of 0 plans every generation with tombstones.
+	tsm, pLen = cp.PlanTombstones(0)
+	require.Equal(t, int64(2), pLen)
+	require.Equal(t, []tsm1.CompactionGroup{{"01-04.tsm1", "01-05.tsm1"}, {"02-04.tsm1"}}, tsm)
+}
+
 // Ensure that the planner will compact all files if no writes
 // have happened in some interval
 func TestDefaultPlanner_Plan_FullOnCold(t *testing.T) {
@@ -3034,6 +3088,7 @@ type fakeFileStore struct {
 	PathsFn      func() []tsm1.FileStat
 	lastModified time.Time
 	blockCount   int
+	tombstoned   map[string]float64
 	readers      []*tsm1.TSMReader
 }
 
@@ -3053,6 +3108,10 @@ func (w *fakeFileStore) BlockCount(path string, idx int) int {
 	return w.blockCount
 }
 
+func (w *fakeFileStore) TombstonedFraction(path string) float64 {
+	return w.tombstoned[path]
+}
+
 func (w *fakeFileStore) TSMReader(path string) *tsm1.TSMReader {
 	r := MustOpenTSMReader(path)
 	w.readers = append(w.readers, r)
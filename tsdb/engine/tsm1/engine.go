@@ -13,12 +13,14 @@ import (
 	"path/filepath"
 	"regexp"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/models"
@@ -127,10 +129,23 @@ type Engine struct {
 	// a snapshot of the cache to a TSM file
 	CacheFlushWriteColdDuration time.Duration
 
+	// CompactTombstoneThreshold is the fraction of a generation's data that must
+	// be deleted for the generation to be rewritten on its own. Tombstone
+	// compactions are disabled if it is 0.
+	CompactTombstoneThreshold float64
+
 	// WALEnabled determines whether writes to the WAL are enabled.  If this is false,
 	// writes will only exist in the cache and can be lost if a snapshot has not occurred.
 	WALEnabled bool
 
+	// walBypassed is non-zero when the shard's WAL policy skips the WAL for
+	// point writes. Unlike WALEnabled it can be toggled while the engine is open.
+	walBypassed int32
+
+	// defaultWALFsyncDelay is the server-wide WAL fsync delay used when the
+	// WAL policy does not override it.
+	defaultWALFsyncDelay time.Duration
+
 	// Invoked when creating a backup file "as new".
 	formatFileName FormatFileNameFunc
 
@@ -146,6 +161,15 @@ type Engine struct {
 
 	scheduler *scheduler
 
+	// planMu protects lastPlan, the compaction groups planned by the most
+	// recent iteration of the compaction loop.
+	planMu   sync.Mutex
+	lastPlan []influxdb.CompactionGroup
+
+	// manualMu is held while an on-demand compaction runs, so Close can
+	// wait for it to be aborted before closing the file store.
+	manualMu sync.Mutex
+
 	// provides access to the total set of series IDs
 	seriesIDSets tsdb.SeriesIDSets
 
@@ -169,7 +193,6 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 	var wal *WAL
 	if opt.WALEnabled {
 		wal = NewWAL(walPath, opt.Config.WALMaxConcurrentWrites, opt.Config.WALMaxWriteDelay, etags)
-		wal.syncDelay = time.Duration(opt.Config.WALFsyncDelay)
 	}
 
 	fs := NewFileStore(path, etags)
@@ -185,6 +208,7 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 	c.Dir = path
 	c.FileStore = fs
 	c.RateLimit = opt.CompactionThroughputLimiter
+	c.Rollups = opt.Config.CompactRollups
 
 	var planner CompactionPlanner = NewDefaultPlanner(fs, time.Duration(opt.Config.CompactFullWriteColdDuration))
 	if opt.CompactionPlannerCreator != nil {
@@ -215,14 +239,18 @@ func NewEngine(id uint64, idx tsdb.Index, path string, walPath string, sfile *ts
 
 		CacheFlushMemorySizeThreshold: uint64(opt.Config.CacheSnapshotMemorySize),
 		CacheFlushWriteColdDuration:   time.Duration(opt.Config.CacheSnapshotWriteColdDuration),
+		CompactTombstoneThreshold:     float64(opt.Config.CompactTombstoneThreshold) / 100,
 		enableCompactionsOnOpen:       true,
 		WALEnabled:                    opt.WALEnabled,
+		defaultWALFsyncDelay:          time.Duration(opt.Config.WALFsyncDelay),
 		formatFileName:                DefaultFormatFileName,
 		stats:                         stats,
 		compactionLimiter:             opt.CompactionLimiter,
 		seriesIDSets:                  opt.SeriesIDSets,
 	}
 
+	e.SetWALPolicy(opt.WALPolicy)
+
 	// Feature flag to enable per-series type checking, by default this is off and
 	// e.seriesTypeMap will be nil.
 	if os.Getenv("INFLUXDB_SERIES_TYPE_CHECK_ENABLED") != "" {
@@ -527,6 +555,135 @@ func (e *Engine) ScheduleFullCompaction() error {
 	return nil
 }
 
+// Compact writes a snapshot of the cache and compacts all TSM files into as
+// few files as possible, blocking until the compaction completes or ctx is
+// cancelled. If optimize is true, blocks are copied without being
+// recompressed. Background compactions are paused while it runs.
+func (e *Engine) Compact(ctx context.Context, optimize bool) error {
+	if err := e.WriteSnapshot(); err != nil {
+		return err
+	}
+
+	e.manualMu.Lock()
+	defer e.manualMu.Unlock()
+
+	e.mu.RLock()
+	enabled := e.done != nil
+	e.mu.RUnlock()
+	if !enabled {
+		return errCompactionsDisabled
+	}
+
+	// Stop background compactions, then allow the compactor to run this one.
+	e.disableLevelCompactions(true)
+	defer e.enableLevelCompactions(true)
+	e.Compactor.EnableCompactions()
+	defer e.Compactor.DisableCompactions()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			e.Compactor.DisableCompactions()
+		case <-done:
+		}
+	}()
+
+	if ok, _ := e.CompactionPlan.FullyCompacted(); ok {
+		return nil
+	}
+
+	var group CompactionGroup
+	for _, f := range e.FileStore.Stats() {
+		group = append(group, f.Path)
+	}
+	if len(group) == 0 {
+		return nil
+	}
+	sort.Strings(group)
+
+	s := e.fullCompactionStrategy(group, optimize)
+	start := time.Now()
+	err := s.compactGroup()
+	s.durationSecondsStat.Observe(time.Since(start).Seconds())
+	if err == errCompactionsDisabled && ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return err
+}
+
+// PurgeTombstones rewrites every TSM generation that has tombstones so that
+// deleted data is removed from disk. It blocks until no tombstoned generations
+// remain or ctx is done. Generations already being compacted are waited on.
+func (e *Engine) PurgeTombstones(ctx context.Context) error {
+	for {
+		groups, n := e.CompactionPlan.PlanTombstones(0)
+		if n == 0 {
+			return nil
+		}
+
+		for i, group := range groups {
+			if err := ctx.Err(); err != nil {
+				e.CompactionPlan.Release(groups[i:])
+				return err
+			}
+
+			s := e.tombstoneCompactionStrategy(group)
+			start := time.Now()
+			err := s.compactGroup()
+			s.durationSecondsStat.Observe(time.Since(start).Seconds())
+			e.CompactionPlan.Release(groups[i : i+1])
+			if err != nil {
+				e.CompactionPlan.Release(groups[i+1:])
+				return err
+			}
+		}
+
+		// The tombstoned files are in use by another compaction, which will
+		// drop the deleted data; wait for it to finish.
+		if len(groups) == 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Second):
+			}
+		}
+	}
+}
+
+// CompactionStatus returns the TSM generations of the engine and the
+// compaction groups planned by the most recent run of the compaction loop.
+func (e *Engine) CompactionStatus() influxdb.ShardCompactionStatus {
+	var status influxdb.ShardCompactionStatus
+	status.Generations = FileStoreGenerations(e.FileStore)
+
+	e.planMu.Lock()
+	status.Planned = append([]influxdb.CompactionGroup{}, e.lastPlan...)
+	e.planMu.Unlock()
+
+	status.FullyCompacted, status.Reason = e.CompactionPlan.FullyCompacted()
+	return status
+}
+
+// recordPlan saves the groups planned by an iteration of the compaction loop
+// for CompactionStatus. The last element of levels holds the full or
+// optimize groups, labelled fullLevel.
+func (e *Engine) recordPlan(fullLevel string, levels ...[]CompactionGroup) {
+	var plan []influxdb.CompactionGroup
+	for i, groups := range levels {
+		label := fullLevel
+		if i < len(levels)-1 {
+			label = strconv.Itoa(i + 1)
+		}
+		plan = append(plan, plannedGroups(label, groups)...)
+	}
+
+	e.planMu.Lock()
+	e.lastPlan = plan
+	e.planMu.Unlock()
+}
+
 // Path returns the path the engine was opened with.
 func (e *Engine) Path() string { return e.path }
 
@@ -614,6 +771,7 @@ const (
 	level3           = "3"
 	levelOpt         = "opt"
 	levelFull        = "full"
+	levelTombstone   = "tombstone"
 	levelKey         = "level"
 	levelCache       = "cache"
 )
@@ -757,6 +915,19 @@ func (e *Engine) Open(ctx context.Context) error {
 
 // Close closes the engine. Subsequent calls to Close are a nop.
 func (e *Engine) Close() error {
+	// Without the WAL, the cache is the only copy of recent writes. Persist it
+	// while snapshots are still enabled so a clean shutdown loses nothing.
+	if e.walIsBypassed() {
+		if err := e.WriteSnapshot(); err != nil && err != errCompactionsDisabled {
+			e.logger.Warn("Error writing cache snapshot on close for shard without WAL", zap.Error(err))
+		}
+	}
+
+	// Abort any on-demand compaction and wait for it to give up its files.
+	e.Compactor.DisableCompactions()
+	e.manualMu.Lock()
+	defer e.manualMu.Unlock()
+
 	e.SetCompactionsEnabled(false)
 
 	// Lock now and close everything else down.
@@ -774,6 +945,27 @@ func (e *Engine) Close() error {
 	return nil
 }
 
+// SetWALPolicy changes how point writes to the engine are made durable. It is
+// safe to call while the engine is open. Deletes are always written to the WAL.
+func (e *Engine) SetWALPolicy(p tsdb.WALPolicy) {
+	if !e.WALEnabled {
+		return
+	}
+
+	e.WAL.SetSyncDelay(p.SyncDelay(e.defaultWALFsyncDelay))
+
+	var bypass int32
+	if p.Mode == tsdb.WALModeNone {
+		bypass = 1
+	}
+	atomic.StoreInt32(&e.walBypassed, bypass)
+}
+
+// walIsBypassed returns true if point writes currently skip the WAL.
+func (e *Engine) walIsBypassed() bool {
+	return atomic.LoadInt32(&e.walBypassed) != 0
+}
+
 // WithLogger sets the logger for the engine.
 func (e *Engine) WithLogger(log *zap.Logger) {
 	e.logger = log.With(zap.String("engine", "tsm1"))
@@ -1345,7 +1537,7 @@ func (e *Engine) WritePoints(ctx context.Context, points []models.Point) error {
 		return err
 	}
 
-	if e.WALEnabled {
+	if e.WALEnabled && !e.walIsBypassed() {
 		if _, err := e.WAL.WriteMulti(ctx, values); err != nil {
 			return err
 		}
@@ -1586,6 +1778,98 @@ func (e *Engine) deleteSeriesRange(ctx context.Context, seriesKeys [][]byte, min
 		}
 	}
 
+	_, err := e.dropSeriesWithoutData(ctx, seriesKeys, deleteKeys)
+	return err
+}
+
+// SeriesWithoutData returns the series in the shard's index that have no
+// values on disk or in the cache.
+func (e *Engine) SeriesWithoutData(ctx context.Context) (*tsdb.SeriesIDSet, error) {
+	ids := e.index.SeriesIDSet()
+	if ids.Cardinality() == 0 {
+		return tsdb.NewSeriesIDSet(), nil
+	}
+
+	hasData := tsdb.NewSeriesIDSet()
+	addKeys := func(keyAt func(i int) []byte, n int) error {
+		ss := tsdb.NewSeriesIDSet()
+		buf := make([]byte, 1024) // For use when accessing series file.
+		var prev []byte
+		for i := 0; i < n; i++ {
+			if i%1000 == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+			}
+
+			// Keys of the same series are adjacent, one for each field.
+			seriesKey, _ := SeriesAndFieldFromCompositeKey(keyAt(i))
+			if prev != nil && bytes.Equal(seriesKey, prev) {
+				continue
+			}
+			prev = seriesKey
+
+			name, tags := models.ParseKeyBytes(seriesKey)
+			if id := e.sfile.SeriesID(name, tags, buf); id != 0 {
+				ss.AddNoLock(id)
+			}
+		}
+		hasData.Merge(ss)
+		return nil
+	}
+
+	if err := e.FileStore.Apply(ctx, func(r TSMFile) error {
+		return addKeys(func(i int) []byte {
+			key, _ := r.KeyAt(i)
+			return key
+		}, r.KeyCount())
+	}); err != nil {
+		return nil, err
+	}
+
+	cacheKeys := e.Cache.Keys()
+	if err := addKeys(func(i int) []byte { return cacheKeys[i] }, len(cacheKeys)); err != nil {
+		return nil, err
+	}
+
+	return ids.AndNot(hasData), nil
+}
+
+// DropSeriesWithoutData removes the series in ids that still have no values on
+// disk or in the cache from the index, and from the series file if no other
+// shard holds them. It returns the number of series removed from the index.
+func (e *Engine) DropSeriesWithoutData(ctx context.Context, ids *tsdb.SeriesIDSet) (int, error) {
+	var seriesKeys [][]byte
+	ids.ForEach(func(id uint64) {
+		if name, tags := e.sfile.Series(id); name != nil {
+			seriesKeys = append(seriesKeys, models.MakeKey(name, tags))
+		}
+	})
+	if len(seriesKeys) == 0 {
+		return 0, nil
+	}
+	bytesutil.Sort(seriesKeys)
+
+	// Ensure that the index does not compact away the series while they are
+	// being dropped.
+	if tsiIndex, ok := e.index.(*tsi1.Index); ok {
+		tsiIndex.DisableCompactions()
+		defer tsiIndex.EnableCompactions()
+		tsiIndex.Wait()
+	}
+	e.sfile.DisableCompactions()
+	defer e.sfile.EnableCompactions()
+	e.sfile.Wait()
+
+	return e.dropSeriesWithoutData(ctx, seriesKeys, nil)
+}
+
+// dropSeriesWithoutData removes the series in seriesKeys that no longer have
+// any values on disk or in the cache from the index, and from the series file
+// if no other shard holds them. deleteKeys are the sorted cache keys of the
+// series whose values were just deleted. seriesKeys must be sorted and is
+// modified. It returns the number of series removed from the index.
+func (e *Engine) dropSeriesWithoutData(ctx context.Context, seriesKeys, deleteKeys [][]byte) (int, error) {
 	// The series are deleted on disk, but the index may still say they exist.
 	// Depending on the the min,max time passed in, the series may or not actually
 	// exists now.  To reconcile the index, we walk the series keys that still exists
@@ -1594,6 +1878,7 @@ func (e *Engine) deleteSeriesRange(ctx context.Context, seriesKeys [][]byte, min
 	// Note: this is inherently racy if writes are occurring to the same measurement/series are
 	// being removed.  A write could occur and exist in the cache at this point, but we
 	// would delete it from the index.
+	var n int
 	minKey := seriesKeys[0]
 
 	// Apply runs this func concurrently.  The seriesKeys slice is mutated concurrently
@@ -1629,7 +1914,7 @@ func (e *Engine) deleteSeriesRange(ctx context.Context, seriesKeys [][]byte, min
 		}
 		return nil
 	}); err != nil {
-		return err
+		return 0, err
 	}
 
 	// The seriesKeys slice is mutated if they are still found in the cache.
@@ -1696,27 +1981,28 @@ func (e *Engine) deleteSeriesRange(ctx context.Context, seriesKeys [][]byte, min
 			measurements[string(name)] = struct{}{}
 			// Remove the series from the local index.
 			if err := e.index.DropSeries(sid, k, false); err != nil {
-				return err
+				return 0, err
 			}
 
 			// Add the id to the set of delete ids.
 			ids.Add(sid)
+			n++
 		}
 
 		fielsetChanged := false
 		for k := range measurements {
 			if dropped, err := e.index.DropMeasurementIfSeriesNotExist([]byte(k)); err != nil {
-				return err
+				return 0, err
 			} else if dropped {
 				if err := e.cleanupMeasurement([]byte(k)); err != nil {
-					return err
+					return 0, err
 				}
 				fielsetChanged = true
 			}
 		}
 		if fielsetChanged {
 			if err := e.fieldset.Save(); err != nil {
-				return err
+				return 0, err
 			}
 		}
 
@@ -1725,7 +2011,7 @@ func (e *Engine) deleteSeriesRange(ctx context.Context, seriesKeys [][]byte, min
 		if err := e.seriesIDSets.ForEach(func(s *tsdb.SeriesIDSet) {
 			ids = ids.AndNot(s)
 		}); err != nil {
-			return err
+			return 0, err
 		}
 
 		// Remove the remaining ids from the series file as they no longer exist
@@ -1738,11 +2024,11 @@ func (e *Engine) deleteSeriesRange(ctx context.Context, seriesKeys [][]byte, min
 			}
 		})
 		if err != nil {
-			return err
+			return 0, err
 		}
 	}
 
-	return nil
+	return n, nil
 }
 
 func (e *Engine) cleanupMeasurement(name []byte) error {
@@ -2007,14 +2293,25 @@ func (e *Engine) compact(wg *sync.WaitGroup) {
 			level2Groups, len2 := e.CompactionPlan.PlanLevel(2)
 			level3Groups, len3 := e.CompactionPlan.PlanLevel(3)
 			level4Groups, len4 := e.CompactionPlan.Plan(e.LastModified())
+			level4Label := levelFull
 
 			e.stats.Queued.With(prometheus.Labels{levelKey: levelFull}).Set(float64(len4))
 
+			// If no full compactions are needed, rewrite any generations that are
+			// mostly deleted data.
+			if len(level4Groups) == 0 && e.CompactTombstoneThreshold > 0 {
+				level4Groups, len4 = e.CompactionPlan.PlanTombstones(e.CompactTombstoneThreshold)
+				level4Label = levelTombstone
+				e.stats.Queued.With(prometheus.Labels{levelKey: levelTombstone}).Set(float64(len4))
+			}
+
 			// If no full compactions are need, see if an optimize is needed
 			if len(level4Groups) == 0 {
 				level4Groups, len4 = e.CompactionPlan.PlanOptimize()
+				level4Label = levelOpt
 				e.stats.Queued.With(prometheus.Labels{levelKey: levelOpt}).Set(float64(len4))
 			}
+			e.recordPlan(level4Label, level1Groups, level2Groups, level3Groups, level4Groups)
 
 			// Update the level plan queue stats
 			// For stats, use the length needed, even if the lock was
@@ -2147,12 +2444,13 @@ type compactionStrategy struct {
 // Apply concurrently compacts all the groups in a compaction strategy.
 func (s *compactionStrategy) Apply() {
 	start := time.Now()
-	s.compactGroup()
+	_ = s.compactGroup()
 	s.durationSecondsStat.Observe(time.Since(start).Seconds())
 }
 
 // compactGroup executes the compaction strategy against a single CompactionGroup.
-func (s *compactionStrategy) compactGroup() {
+// Errors are logged and returned.
+func (s *compactionStrategy) compactGroup() error {
 	group := s.group
 	log, logEnd := logger.NewOperation(context.TODO(), s.logger, "TSM compaction", "tsm1_compact_group")
 	defer logEnd()
@@ -2180,7 +2478,7 @@ func (s *compactionStrategy) compactGroup() {
 			if _, ok := err.(errCompactionInProgress); ok {
 				time.Sleep(time.Second)
 			}
-			return
+			return err
 		}
 
 		log.Warn("Error compacting TSM files", zap.Error(err))
@@ -2198,7 +2496,7 @@ func (s *compactionStrategy) compactGroup() {
 
 		s.errorStat.Inc()
 		time.Sleep(time.Second)
-		return
+		return err
 	}
 
 	if err := s.fileStore.ReplaceWithCallback(group, files, nil); err != nil {
@@ -2212,7 +2510,7 @@ func (s *compactionStrategy) compactGroup() {
 				log.Error("Unable to remove file", zap.String("path", file), zap.Error(err))
 			}
 		}
-		return
+		return err
 	}
 
 	for i, f := range files {
@@ -2220,6 +2518,7 @@ func (s *compactionStrategy) compactGroup() {
 	}
 	log.Info("Finished compacting files",
 		zap.Int("tsm1_files_n", len(files)))
+	return nil
 }
 
 // levelCompactionStrategy returns a compactionStrategy for the given level.
@@ -2262,6 +2561,23 @@ func (e *Engine) fullCompactionStrategy(group CompactionGroup, optimize bool) *c
 	return s
 }
 
+// tombstoneCompactionStrategy returns a strategy that rewrites a single
+// generation to drop its deleted data.
+func (e *Engine) tombstoneCompactionStrategy(group CompactionGroup) *compactionStrategy {
+	plabel := prometheus.Labels{levelKey: levelTombstone}
+	return &compactionStrategy{
+		group:               group,
+		logger:              e.logger.With(zap.String("tsm1_strategy", levelTombstone)),
+		fileStore:           e.FileStore,
+		compactor:           e.Compactor,
+		fast:                true,
+		engine:              e,
+		level:               4,
+		errorStat:           e.stats.Failed.With(plabel),
+		durationSecondsStat: e.stats.Duration.With(plabel),
+	}
+}
+
 // reloadCache reads the WAL segment files and loads them into the cache.
 func (e *Engine) reloadCache() error {
 	now := time.Now()
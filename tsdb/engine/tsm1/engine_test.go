@@ -2243,6 +2243,116 @@ func TestEngine_WritePoints_Reload(t *testing.T) {
 	}
 }
 
+func TestEngine_SetWALPolicy(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			e := MustOpenEngine(t, index)
+			defer e.Close()
+
+			e.SetWALPolicy(tsdb.WALPolicy{Mode: tsdb.WALModeGroupCommit, FsyncDelay: 50 * time.Millisecond})
+			require.Equal(t, 50*time.Millisecond, e.WAL.SyncDelay())
+
+			e.SetWALPolicy(tsdb.WALPolicy{Mode: tsdb.WALModeFsync})
+			require.Equal(t, time.Duration(0), e.WAL.SyncDelay())
+
+			e.SetWALPolicy(tsdb.WALPolicy{Mode: tsdb.WALModeNone})
+			require.NoError(t, e.WritePointsString(`cpu,host=A value=1.1 1`))
+			require.Equal(t, int64(0), e.WAL.DiskSizeBytes(), "expected no WAL writes")
+			require.Equal(t, 0, e.FileStore.Count())
+
+			// Closing must persist the cache since the WAL holds nothing to replay.
+			require.NoError(t, e.Reopen())
+			require.Equal(t, 1, e.FileStore.Count())
+
+			e.SetWALPolicy(tsdb.WALPolicy{})
+			require.NoError(t, e.WritePointsString(`cpu,host=A value=1.2 2`))
+			require.Greater(t, e.WAL.DiskSizeBytes(), int64(0))
+		})
+	}
+}
+
+func TestEngine_Compact(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			e, err := NewEngine(t, index)
+			require.NoError(t, err)
+
+			// mock the planner so background compactions don't run during the test
+			e.CompactionPlan = &mockPlanner{}
+			require.NoError(t, e.Open(context.Background()))
+			defer e.Close()
+
+			for i := 1; i <= 3; i++ {
+				require.NoError(t, e.WritePointsString(fmt.Sprintf("cpu,host=A value=%d %d", i, i)))
+				e.MustWriteSnapshot()
+			}
+
+			status := e.CompactionStatus()
+			require.Len(t, status.Generations, 3)
+			require.Equal(t, 3, status.Generations[0].ID, "expected newest generation first")
+			for _, g := range status.Generations {
+				require.Equal(t, 1, g.Level)
+				require.Len(t, g.Files, 1)
+				require.Greater(t, g.Size, int64(0))
+			}
+			require.False(t, status.FullyCompacted)
+
+			require.NoError(t, e.Compact(context.Background(), false))
+
+			status = e.CompactionStatus()
+			require.Len(t, status.Generations, 1)
+			require.Equal(t, 2, status.Generations[0].Level)
+			require.Equal(t, 1, e.FileStore.Count())
+
+			e.SetCompactionsEnabled(false)
+			require.Error(t, e.Compact(context.Background(), true))
+		})
+	}
+}
+
+func TestEngine_PurgeTombstones(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			e := MustOpenEngine(t, index)
+			defer e.Close()
+
+			var points []models.Point
+			for i := 0; i < 100; i++ {
+				points = append(points,
+					MustParsePointString(fmt.Sprintf("cpu,host=%d value=%d %d", i, i, i)),
+					MustParsePointString(fmt.Sprintf("mem,host=%d value=%d %d", i, i, i)),
+				)
+			}
+			for _, p := range points {
+				require.NoError(t, e.CreateSeriesIfNotExists(p.Key(), p.Name(), p.Tags()))
+			}
+			require.NoError(t, e.WritePoints(context.Background(), points))
+			require.NoError(t, e.WriteSnapshot())
+
+			var keys [][]byte
+			for i := 0; i < 90; i++ {
+				keys = append(keys, []byte(fmt.Sprintf("cpu,host=%d", i)))
+			}
+			itr := &seriesIterator{keys: keys}
+			require.NoError(t, e.DeleteSeriesRange(context.Background(), itr, math.MinInt64, math.MaxInt64))
+
+			stats := e.FileStore.Stats()
+			require.Len(t, stats, 1)
+			require.True(t, stats[0].HasTombstone)
+			require.InDelta(t, 0.45, e.FileStore.TombstonedFraction(stats[0].Path), 0.05)
+			size := stats[0].Size
+
+			require.NoError(t, e.PurgeTombstones(context.Background()))
+
+			stats = e.FileStore.Stats()
+			require.Len(t, stats, 1)
+			require.False(t, stats[0].HasTombstone)
+			require.Less(t, stats[0].Size, size)
+			require.Len(t, e.FileStore.Keys(), 110)
+		})
+	}
+}
+
 func TestEngine_Invalid_UTF8(t *testing.T) {
 	for _, index := range tsdb.RegisteredIndexes() {
 		t.Run(index, func(t *testing.T) {
@@ -2808,6 +2918,7 @@ type mockPlanner struct{}
 func (m *mockPlanner) Plan(lastWrite time.Time) ([]tsm1.CompactionGroup, int64) { return nil, 0 }
 func (m *mockPlanner) PlanLevel(level int) ([]tsm1.CompactionGroup, int64)      { return nil, 0 }
 func (m *mockPlanner) PlanOptimize() ([]tsm1.CompactionGroup, int64)            { return nil, 0 }
+func (m *mockPlanner) PlanTombstones(float64) ([]tsm1.CompactionGroup, int64)   { return nil, 0 }
 func (m *mockPlanner) Release(groups []tsm1.CompactionGroup)                    {}
 func (m *mockPlanner) FullyCompacted() (bool, string)                           { return false, "not compacted" }
 func (m *mockPlanner) ForceFull()                                               {}
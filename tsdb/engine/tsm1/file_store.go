@@ -81,6 +81,10 @@ type TSMFile interface {
 	// TombstoneRange returns ranges of time that are deleted for the given key.
 	TombstoneRange(key []byte) []TimeRange
 
+	// BlockSummaries returns the summaries of the blocks of key, or nil if the
+	// file has none.
+	BlockSummaries(key []byte) []BlockSummary
+
 	// KeyRange returns the min and max keys in the file.
 	KeyRange() ([]byte, []byte)
 
@@ -739,6 +743,17 @@ func (f *FileStore) TSMReader(path string) *TSMReader {
 	return nil
 }
 
+// TombstonedFraction returns the estimated fraction of the TSM file at path
+// that has been deleted but not yet removed from disk.
+func (f *FileStore) TombstonedFraction(path string) float64 {
+	r := f.TSMReader(path)
+	if r == nil {
+		return 0
+	}
+	defer r.Unref()
+	return r.TombstonedFraction()
+}
+
 // KeyCursor returns a KeyCursor for key and t across the files in the FileStore.
 func (f *FileStore) KeyCursor(ctx context.Context, key []byte, t int64, ascending bool) *KeyCursor {
 	f.mu.RLock()
@@ -817,6 +832,16 @@ func (f *FileStore) replace(oldFiles, newFiles []string, updatedFn func(r []TSMF
 			if err := os.Rename(oldName, newName); err != nil {
 				return err
 			}
+
+			// Move the block summaries written with the file into place.
+			if err := os.Rename(rollupPath(oldName), rollupPath(newName)); os.IsNotExist(err) {
+				err = os.Remove(rollupPath(newName))
+				if err != nil && !os.IsNotExist(err) {
+					return err
+				}
+			} else if err != nil {
+				return err
+			}
 		}
 
 		// Any error after this point should result in the file being bein named
@@ -42,6 +42,10 @@ type TSMReader struct {
 	// tombstoner ensures tombstoned keys are not available by the index.
 	tombstoner *Tombstoner
 
+	// rollups holds the block summaries written alongside the file. It is nil
+	// if the file has none.
+	rollups *rollupReader
+
 	// size is the size of the file on disk.
 	size int64
 
@@ -50,6 +54,12 @@ type TSMReader struct {
 
 	// deleteMu limits concurrent deletes
 	deleteMu sync.Mutex
+
+	// tombstonedMu protects the cached result of TombstonedFraction, which is
+	// valid while the tombstone file is unchanged.
+	tombstonedMu       sync.Mutex
+	tombstonedStat     TombstoneStat
+	tombstonedFraction float64
 }
 
 // TSMIndex represent the index section of a TSM file.  The index records all
@@ -248,6 +258,12 @@ func NewTSMReader(f *os.File, options ...tsmReaderOption) (*TSMReader, error) {
 		return nil, err
 	}
 
+	// Block summaries are an optimization, so a file whose summaries can not
+	// be read is used without them.
+	if r, err := openRollupReader(rollupPath(t.Path()), t.size); err == nil {
+		t.rollups = r
+	}
+
 	return t, nil
 }
 
@@ -371,6 +387,12 @@ func (t *TSMReader) Close() error {
 		return err
 	}
 
+	if t.rollups != nil {
+		if err := t.rollups.Close(); err != nil {
+			return err
+		}
+	}
+
 	return t.index.Close()
 }
 
@@ -407,7 +429,13 @@ func (t *TSMReader) Remove() error {
 func (t *TSMReader) Rename(path string) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	return t.accessor.rename(path)
+	if err := t.accessor.rename(path); err != nil {
+		return err
+	}
+	if t.rollups != nil {
+		return t.rollups.rename(path)
+	}
+	return nil
 }
 
 // Remove removes any underlying files stored on disk for this reader.
@@ -428,6 +456,10 @@ func (t *TSMReader) remove() error {
 	if err := t.tombstoner.Delete(); err != nil {
 		return err
 	}
+
+	if t.rollups != nil {
+		return t.rollups.remove()
+	}
 	return nil
 }
 
@@ -549,6 +581,50 @@ func (t *TSMReader) TombstoneStats() TombstoneStat {
 	return fs
 }
 
+// TombstonedFraction returns the estimated fraction, between 0 and 1, of the
+// file's block data that has been deleted but is still stored on disk. Blocks
+// whose time range is entirely tombstoned count as deleted.
+func (t *TSMReader) TombstonedFraction() float64 {
+	stat := t.TombstoneStats()
+	if !stat.TombstoneExists {
+		return 0
+	}
+
+	t.tombstonedMu.Lock()
+	defer t.tombstonedMu.Unlock()
+	if t.tombstonedStat == stat {
+		return t.tombstonedFraction
+	}
+
+	t.mu.RLock()
+	// The block data is everything but the header, index and footer.
+	data := t.size - int64(t.index.Size()) - 5 - 8
+	var live int64
+	var entries []IndexEntry
+	for i := 0; i < t.index.KeyCount(); i++ {
+		var key []byte
+		key, _, entries = t.index.Key(i, &entries)
+		tombstones := t.index.TombstoneRange(key)
+	ENTRIES:
+		for _, e := range entries {
+			for _, tr := range tombstones {
+				if tr.Min <= e.MinTime && tr.Max >= e.MaxTime {
+					continue ENTRIES
+				}
+			}
+			live += int64(e.Size)
+		}
+	}
+	t.mu.RUnlock()
+
+	var fraction float64
+	if data > 0 && live < data {
+		fraction = 1 - float64(live)/float64(data)
+	}
+	t.tombstonedStat, t.tombstonedFraction = stat, fraction
+	return fraction
+}
+
 // TombstoneRange returns ranges of time that are deleted for the given key.
 func (t *TSMReader) TombstoneRange(key []byte) []TimeRange {
 	t.mu.RLock()
@@ -557,6 +633,19 @@ func (t *TSMReader) TombstoneRange(key []byte) []TimeRange {
 	return tr
 }
 
+// BlockSummaries returns the summaries of the blocks of key written alongside
+// the file, or nil if there are none.
+func (t *TSMReader) BlockSummaries(key []byte) []BlockSummary {
+	if t.rollups == nil {
+		return nil
+	}
+	a, err := t.rollups.summaries(key)
+	if err != nil {
+		return nil
+	}
+	return a
+}
+
 // Stats returns the FileStat for the TSMReader's underlying file.
 func (t *TSMReader) Stats() FileStat {
 	minTime, maxTime := t.index.TimeRange()
@@ -0,0 +1,599 @@
+package tsm1
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/pkg/file"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// RollupFileExtension is the extension of the files holding the block
+// summaries of TSM files.
+const RollupFileExtension = "rollup"
+
+const (
+	// rollupMagic identifies a rollup file.
+	rollupMagic = 0x16D1A0B5
+
+	// rollupVersion is the version of the rollup file format.
+	rollupVersion = 1
+
+	rollupHeaderSize = 5
+	rollupFooterSize = 16
+
+	// blockSummarySize is the encoded size of a BlockSummary.
+	blockSummarySize = 11 * 8
+)
+
+var errInvalidRollup = errors.New("tsm1: invalid rollup file")
+
+// BlockSummary summarizes the values of a numeric TSM block so that aggregates
+// over the block can be computed without decoding it. Sum, Min, Max, First and
+// Last hold the bit patterns of values of the block's type.
+type BlockSummary struct {
+	MinTime, MaxTime int64 // times of the first and last values of the block
+
+	Count int64
+
+	Sum, Min, Max, First, Last uint64
+
+	// MinValueTime and MaxValueTime are the times of the first occurrences of
+	// the minimum and maximum values.
+	MinValueTime, MaxValueTime int64
+}
+
+// FloatSummary returns s as a summary of float values.
+func (s *BlockSummary) FloatSummary() tsdb.FloatBlockSummary {
+	return tsdb.FloatBlockSummary{
+		MinTime:      s.MinTime,
+		MaxTime:      s.MaxTime,
+		Count:        s.Count,
+		Sum:          math.Float64frombits(s.Sum),
+		Min:          math.Float64frombits(s.Min),
+		Max:          math.Float64frombits(s.Max),
+		First:        math.Float64frombits(s.First),
+		Last:         math.Float64frombits(s.Last),
+		MinValueTime: s.MinValueTime,
+		MaxValueTime: s.MaxValueTime,
+	}
+}
+
+// IntegerSummary returns s as a summary of integer values.
+func (s *BlockSummary) IntegerSummary() tsdb.IntegerBlockSummary {
+	return tsdb.IntegerBlockSummary{
+		MinTime:      s.MinTime,
+		MaxTime:      s.MaxTime,
+		Count:        s.Count,
+		Sum:          int64(s.Sum),
+		Min:          int64(s.Min),
+		Max:          int64(s.Max),
+		First:        int64(s.First),
+		Last:         int64(s.Last),
+		MinValueTime: s.MinValueTime,
+		MaxValueTime: s.MaxValueTime,
+	}
+}
+
+// UnsignedSummary returns s as a summary of unsigned values.
+func (s *BlockSummary) UnsignedSummary() tsdb.UnsignedBlockSummary {
+	return tsdb.UnsignedBlockSummary{
+		MinTime:      s.MinTime,
+		MaxTime:      s.MaxTime,
+		Count:        s.Count,
+		Sum:          s.Sum,
+		Min:          s.Min,
+		Max:          s.Max,
+		First:        s.First,
+		Last:         s.Last,
+		MinValueTime: s.MinValueTime,
+		MaxValueTime: s.MaxValueTime,
+	}
+}
+
+func (s *BlockSummary) appendTo(b []byte) []byte {
+	for _, v := range [...]uint64{
+		uint64(s.MinTime), uint64(s.MaxTime), uint64(s.Count),
+		s.Sum, s.Min, s.Max, s.First, s.Last,
+		uint64(s.MinValueTime), uint64(s.MaxValueTime),
+	} {
+		b = appendUint64(b, v)
+	}
+	// Reserved for future use.
+	return appendUint64(b, 0)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func (s *BlockSummary) unmarshal(b []byte) {
+	s.MinTime = int64(binary.BigEndian.Uint64(b[0:]))
+	s.MaxTime = int64(binary.BigEndian.Uint64(b[8:]))
+	s.Count = int64(binary.BigEndian.Uint64(b[16:]))
+	s.Sum = binary.BigEndian.Uint64(b[24:])
+	s.Min = binary.BigEndian.Uint64(b[32:])
+	s.Max = binary.BigEndian.Uint64(b[40:])
+	s.First = binary.BigEndian.Uint64(b[48:])
+	s.Last = binary.BigEndian.Uint64(b[56:])
+	s.MinValueTime = int64(binary.BigEndian.Uint64(b[64:]))
+	s.MaxValueTime = int64(binary.BigEndian.Uint64(b[72:]))
+}
+
+// SummarizeBlock returns the summary of an encoded block. It returns false if
+// the block does not hold numeric values.
+func SummarizeBlock(block []byte) (BlockSummary, bool, error) {
+	var s BlockSummary
+	typ, err := BlockType(block)
+	if err != nil {
+		return s, false, err
+	}
+
+	switch typ {
+	case BlockFloat64:
+		var a tsdb.FloatArray
+		if err := DecodeFloatArrayBlock(block, &a); err != nil {
+			return s, false, err
+		} else if a.Len() == 0 {
+			return s, false, nil
+		}
+		sum, min, max := 0.0, a.Values[0], a.Values[0]
+		s.MinValueTime, s.MaxValueTime = a.Timestamps[0], a.Timestamps[0]
+		for i, v := range a.Values {
+			sum += v
+			if v < min {
+				min, s.MinValueTime = v, a.Timestamps[i]
+			}
+			if v > max {
+				max, s.MaxValueTime = v, a.Timestamps[i]
+			}
+		}
+		s.MinTime, s.MaxTime, s.Count = a.MinTime(), a.MaxTime(), int64(a.Len())
+		s.Sum, s.Min, s.Max = math.Float64bits(sum), math.Float64bits(min), math.Float64bits(max)
+		s.First, s.Last = math.Float64bits(a.Values[0]), math.Float64bits(a.Values[a.Len()-1])
+
+	case BlockInteger:
+		var a tsdb.IntegerArray
+		if err := DecodeIntegerArrayBlock(block, &a); err != nil {
+			return s, false, err
+		} else if a.Len() == 0 {
+			return s, false, nil
+		}
+		var sum int64
+		min, max := a.Values[0], a.Values[0]
+		s.MinValueTime, s.MaxValueTime = a.Timestamps[0], a.Timestamps[0]
+		for i, v := range a.Values {
+			sum += v
+			if v < min {
+				min, s.MinValueTime = v, a.Timestamps[i]
+			}
+			if v > max {
+				max, s.MaxValueTime = v, a.Timestamps[i]
+			}
+		}
+		s.MinTime, s.MaxTime, s.Count = a.MinTime(), a.MaxTime(), int64(a.Len())
+		s.Sum, s.Min, s.Max = uint64(sum), uint64(min), uint64(max)
+		s.First, s.Last = uint64(a.Values[0]), uint64(a.Values[a.Len()-1])
+
+	case BlockUnsigned:
+		var a tsdb.UnsignedArray
+		if err := DecodeUnsignedArrayBlock(block, &a); err != nil {
+			return s, false, err
+		} else if a.Len() == 0 {
+			return s, false, nil
+		}
+		var sum uint64
+		min, max := a.Values[0], a.Values[0]
+		s.MinValueTime, s.MaxValueTime = a.Timestamps[0], a.Timestamps[0]
+		for i, v := range a.Values {
+			sum += v
+			if v < min {
+				min, s.MinValueTime = v, a.Timestamps[i]
+			}
+			if v > max {
+				max, s.MaxValueTime = v, a.Timestamps[i]
+			}
+		}
+		s.MinTime, s.MaxTime, s.Count = a.MinTime(), a.MaxTime(), int64(a.Len())
+		s.Sum, s.Min, s.Max = sum, min, max
+		s.First, s.Last = a.Values[0], a.Values[a.Len()-1]
+
+	default:
+		return s, false, nil
+	}
+	return s, true, nil
+}
+
+// summarize removes from the cursor the blocks that can be returned as
+// summaries and returns their summaries in ascending time order. A block is
+// summarized if it lies within [min, max], is accepted by fn, overlaps neither
+// another block nor a cached value, has no tombstoned values and its TSM file
+// has a summary of it. It must be called before the cursor is read.
+func (c *KeyCursor) summarize(min, max int64, cacheValues Values, fn func(minTime, maxTime int64) bool) []BlockSummary {
+	// Most files have no summaries, so look for them first.
+	fileSummaries := make(map[TSMFile][]BlockSummary)
+	var found bool
+	for _, l := range c.seeks {
+		if _, ok := fileSummaries[l.r]; !ok {
+			a := l.r.BlockSummaries(c.key)
+			fileSummaries[l.r] = a
+			found = found || len(a) > 0
+		}
+	}
+	if !found {
+		return nil
+	}
+
+	sorted := make([]*location, len(c.seeks))
+	copy(sorted, c.seeks)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].entry.MinTime < sorted[j].entry.MinTime })
+
+	overlaps := make(map[*location]bool)
+	for i, l := range sorted {
+		for _, next := range sorted[i+1:] {
+			if next.entry.MinTime > l.entry.MaxTime {
+				break
+			}
+			overlaps[l], overlaps[next] = true, true
+		}
+	}
+
+	var summaries []BlockSummary
+	seeks := c.seeks[:0]
+	for _, l := range sorted {
+		if s, ok := c.blockSummary(l, min, max, overlaps[l], cacheValues, fn, fileSummaries); ok {
+			summaries = append(summaries, s)
+			l.r.Unref()
+			continue
+		}
+		seeks = append(seeks, l)
+	}
+	if len(summaries) == 0 {
+		return nil
+	}
+
+	// The remaining locations keep the order of the cursor.
+	sort.Sort(ascLocations(seeks))
+	for i := len(seeks); i < len(c.seeks); i++ {
+		c.seeks[i] = nil
+	}
+	c.seeks = seeks
+	c.seek(min)
+	return summaries
+}
+
+// blockSummary returns the summary of the block at l if it can be summarized.
+func (c *KeyCursor) blockSummary(l *location, min, max int64, overlaps bool, cacheValues Values, fn func(minTime, maxTime int64) bool, fileSummaries map[TSMFile][]BlockSummary) (BlockSummary, bool) {
+	e := l.entry
+	if overlaps || e.MinTime < min || e.MaxTime > max || !fn(e.MinTime, e.MaxTime) {
+		return BlockSummary{}, false
+	}
+
+	i := sort.Search(len(cacheValues), func(i int) bool { return cacheValues[i].UnixNano() >= e.MinTime })
+	if i < len(cacheValues) && cacheValues[i].UnixNano() <= e.MaxTime {
+		return BlockSummary{}, false
+	}
+
+	for _, t := range l.r.TombstoneRange(c.key) {
+		if e.OverlapsTimeRange(t.Min, t.Max) {
+			return BlockSummary{}, false
+		}
+	}
+
+	a := fileSummaries[l.r]
+	i = sort.Search(len(a), func(i int) bool { return a[i].MinTime >= e.MinTime })
+	if i == len(a) || a[i].MinTime != e.MinTime || a[i].MaxTime != e.MaxTime {
+		return BlockSummary{}, false
+	}
+	return a[i], true
+}
+
+// rollupPath returns the path of the rollup file of the TSM file at path.
+func rollupPath(path string) string {
+	if tmp := "." + CompactionTempExtension; strings.HasSuffix(path, tmp) {
+		return rollupPath(strings.TrimSuffix(path, tmp)) + tmp
+	}
+	return strings.TrimSuffix(path, "."+TSMFileExtension) + "." + RollupFileExtension
+}
+
+// rollupWriter writes the summaries of the blocks of a TSM file as the
+// blocks are written. Blocks must be added in the order they are written to
+// the TSM file.
+//
+// A rollup file consists of a header, a record for each key holding the
+// summaries of the key's blocks, an index of the offsets of the records and
+// a footer holding the offset of the index and the size of the TSM file, so
+// that summaries are never used with a TSM file they were not written for.
+type rollupWriter struct {
+	f *os.File
+	w *bufio.Writer
+	n uint64
+
+	offsets   []uint64
+	key       []byte
+	summaries []BlockSummary
+	buf       []byte
+}
+
+func newRollupWriter(path string) (*rollupWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR|os.O_TRUNC, 0666)
+	if err != nil {
+		return nil, err
+	}
+
+	w := &rollupWriter{f: f, w: bufio.NewWriterSize(f, 1024*1024)}
+	var hdr [rollupHeaderSize]byte
+	binary.BigEndian.PutUint32(hdr[:], rollupMagic)
+	hdr[4] = rollupVersion
+	w.buf = append(w.buf[:0], hdr[:]...)
+	if err := w.write(w.buf); err != nil {
+		_ = w.remove()
+		return nil, err
+	}
+	return w, nil
+}
+
+// add summarizes a block of key.
+func (w *rollupWriter) add(key []byte, block []byte) error {
+	s, ok, err := SummarizeBlock(block)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(key, w.key) {
+		if err := w.flush(); err != nil {
+			return err
+		}
+		w.key = append(w.key[:0], key...)
+	}
+	if ok {
+		w.summaries = append(w.summaries, s)
+	}
+	return nil
+}
+
+// flush writes the record of the current key.
+func (w *rollupWriter) flush() error {
+	if len(w.summaries) == 0 {
+		return nil
+	}
+
+	w.offsets = append(w.offsets, w.n)
+	var hdr [4]byte
+	binary.BigEndian.PutUint16(hdr[:2], uint16(len(w.key)))
+	w.buf = append(w.buf[:0], hdr[:2]...)
+	w.buf = append(w.buf, w.key...)
+	binary.BigEndian.PutUint32(hdr[:], uint32(len(w.summaries)))
+	w.buf = append(w.buf, hdr[:]...)
+	for i := range w.summaries {
+		w.buf = w.summaries[i].appendTo(w.buf)
+	}
+	w.summaries = w.summaries[:0]
+	return w.write(w.buf)
+}
+
+func (w *rollupWriter) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.n += uint64(n)
+	return err
+}
+
+// close writes the index and footer of the file and closes it. tsmSize is the
+// size of the TSM file the summaries were written for.
+func (w *rollupWriter) close(tsmSize uint32) error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+
+	indexStart := w.n
+	w.buf = w.buf[:0]
+	for _, off := range w.offsets {
+		w.buf = appendUint64(w.buf, off)
+	}
+	w.buf = appendUint64(w.buf, indexStart)
+	w.buf = appendUint64(w.buf, uint64(tsmSize))
+	if err := w.write(w.buf); err != nil {
+		return err
+	}
+
+	if err := w.w.Flush(); err != nil {
+		return err
+	} else if err := w.f.Sync(); err != nil {
+		return err
+	}
+	return w.f.Close()
+}
+
+// remove closes and removes the file.
+func (w *rollupWriter) remove() error {
+	_ = w.f.Close()
+	return os.Remove(w.f.Name())
+}
+
+// rollupReader reads the block summaries of a rollup file.
+type rollupReader struct {
+	mu sync.RWMutex
+	f  *os.File
+	b  []byte
+
+	// index is the section of b holding the offsets of the records.
+	index []byte
+
+	// tsmSize is the size of the TSM file the summaries must have been
+	// written for.
+	tsmSize uint64
+}
+
+// openRollupReader opens the rollup file at path written for a TSM file of
+// tsmSize bytes.
+func openRollupReader(path string, tsmSize int64) (*rollupReader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &rollupReader{f: f, tsmSize: uint64(tsmSize)}
+	if err := r.mmap(); err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rollupReader) mmap() error {
+	stat, err := r.f.Stat()
+	if err != nil {
+		return err
+	}
+	size := int(stat.Size())
+	if size < rollupHeaderSize+rollupFooterSize {
+		return errInvalidRollup
+	}
+
+	b, err := mmap(r.f, 0, size)
+	if err != nil {
+		return err
+	}
+	if binary.BigEndian.Uint32(b) != rollupMagic || b[4] != rollupVersion {
+		_ = munmap(b)
+		return errInvalidRollup
+	}
+
+	indexEnd := uint64(size - rollupFooterSize)
+	indexStart := binary.BigEndian.Uint64(b[indexEnd:])
+	if indexStart < rollupHeaderSize || indexStart > indexEnd || (indexEnd-indexStart)%8 != 0 ||
+		binary.BigEndian.Uint64(b[indexEnd+8:]) != r.tsmSize {
+		_ = munmap(b)
+		return errInvalidRollup
+	}
+
+	r.b, r.index = b, b[indexStart:indexEnd]
+	return nil
+}
+
+// path returns the path of the file.
+func (r *rollupReader) path() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.f.Name()
+}
+
+// keyAt returns the key and the summaries section of the i-th record.
+func (r *rollupReader) keyAt(i int) ([]byte, []byte, error) {
+	off := binary.BigEndian.Uint64(r.index[i*8:])
+	if off+2 > uint64(len(r.b)) {
+		return nil, nil, errInvalidRollup
+	}
+	keyEnd := off + 2 + uint64(binary.BigEndian.Uint16(r.b[off:]))
+	if keyEnd+4 > uint64(len(r.b)) {
+		return nil, nil, errInvalidRollup
+	}
+	n := uint64(binary.BigEndian.Uint32(r.b[keyEnd:]))
+	end := keyEnd + 4 + n*blockSummarySize
+	if end > uint64(len(r.b)) {
+		return nil, nil, errInvalidRollup
+	}
+	return r.b[off+2 : keyEnd], r.b[keyEnd+4 : end], nil
+}
+
+// summaries returns the block summaries of key.
+func (r *rollupReader) summaries(key []byte) ([]BlockSummary, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if r.b == nil {
+		return nil, nil
+	}
+
+	var err error
+	n := len(r.index) / 8
+	i := sort.Search(n, func(i int) bool {
+		k, _, e := r.keyAt(i)
+		if e != nil {
+			err = e
+			return true
+		}
+		return bytes.Compare(k, key) >= 0
+	})
+	if err != nil {
+		return nil, err
+	} else if i == n {
+		return nil, nil
+	}
+
+	k, b, err := r.keyAt(i)
+	if err != nil {
+		return nil, err
+	} else if !bytes.Equal(k, key) {
+		return nil, nil
+	}
+
+	a := make([]BlockSummary, len(b)/blockSummarySize)
+	for i := range a {
+		a[i].unmarshal(b[i*blockSummarySize:])
+	}
+	return a, nil
+}
+
+// rename renames the file to the rollup file of the TSM file at path.
+func (r *rollupReader) rename(tsmPath string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.close(); err != nil {
+		return err
+	}
+
+	path := rollupPath(tsmPath)
+	if err := file.RenameFile(r.f.Name(), path); err != nil {
+		return err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	r.f = f
+	return r.mmap()
+}
+
+// Close unmaps and closes the file.
+func (r *rollupReader) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.close()
+}
+
+func (r *rollupReader) close() error {
+	if r.b == nil {
+		return nil
+	}
+	if err := munmap(r.b); err != nil {
+		return err
+	}
+	r.b, r.index = nil, nil
+	return r.f.Close()
+}
+
+// remove closes and removes the file.
+func (r *rollupReader) remove() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.close(); err != nil {
+		return err
+	}
+	if err := os.Remove(r.f.Name()); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error removing rollup file %q: %w", r.f.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,282 @@
+package tsm1_test
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/influxdata/influxdb/v2/tsdb/engine/tsm1"
+	"github.com/influxdata/influxql"
+	"go.uber.org/zap"
+)
+
+func TestCompactor_Rollups(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	c := tsm1.NewCache(0, tsdb.EngineTags{})
+	for k, v := range map[string][]tsm1.Value{
+		"cpu,host=A#!~#value": {tsm1.NewValue(1, 3.0), tsm1.NewValue(2, 1.0), tsm1.NewValue(3, 5.0), tsm1.NewValue(4, 1.0)},
+		"cpu,host=B#!~#value": {tsm1.NewValue(5, int64(-2)), tsm1.NewValue(6, int64(7))},
+		"cpu,host=C#!~#value": {tsm1.NewValue(7, "a")},
+	} {
+		if err := c.Write([]byte(k), v); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	compactor := tsm1.NewCompactor()
+	compactor.Dir = dir
+	compactor.FileStore = &fakeFileStore{}
+	compactor.Rollups = true
+	compactor.Open()
+
+	files, err := compactor.WriteSnapshot(c, zap.NewNop())
+	if err != nil {
+		t.Fatalf("unexpected error writing snapshot: %v", err)
+	} else if got, exp := len(files), 1; got != exp {
+		t.Fatalf("files length mismatch: got %v, exp %v", got, exp)
+	}
+
+	r := MustOpenTSMReader(files[0])
+	defer r.Close()
+
+	if got, exp := r.BlockSummaries([]byte("cpu,host=A#!~#value")), []tsm1.BlockSummary{{
+		MinTime: 1, MaxTime: 4, Count: 4,
+		Sum: f64bits(10), Min: f64bits(1), Max: f64bits(5), First: f64bits(3), Last: f64bits(1),
+		MinValueTime: 2, MaxValueTime: 3,
+	}}; !cmp.Equal(got, exp) {
+		t.Fatalf("unexpected float summaries: %s", cmp.Diff(got, exp))
+	}
+
+	got := r.BlockSummaries([]byte("cpu,host=B#!~#value"))
+	if len(got) != 1 {
+		t.Fatalf("unexpected integer summaries: %v", got)
+	}
+	if got, exp := got[0].IntegerSummary(), (tsdb.IntegerBlockSummary{
+		MinTime: 5, MaxTime: 6, Count: 2, Sum: 5, Min: -2, Max: 7, First: -2, Last: 7,
+		MinValueTime: 5, MaxValueTime: 6,
+	}); got != exp {
+		t.Fatalf("unexpected integer summary: %s", cmp.Diff(got, exp))
+	}
+
+	if got := r.BlockSummaries([]byte("cpu,host=C#!~#value")); got != nil {
+		t.Fatalf("expected no summaries of string block, got %v", got)
+	}
+	if got := r.BlockSummaries([]byte("mem,host=A#!~#value")); got != nil {
+		t.Fatalf("expected no summaries of missing key, got %v", got)
+	}
+}
+
+func TestCompactor_Rollups_Disabled(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	c := tsm1.NewCache(0, tsdb.EngineTags{})
+	if err := c.Write([]byte("cpu,host=A#!~#value"), []tsm1.Value{tsm1.NewValue(1, 1.0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	compactor := tsm1.NewCompactor()
+	compactor.Dir = dir
+	compactor.FileStore = &fakeFileStore{}
+	compactor.Open()
+
+	files, err := compactor.WriteSnapshot(c, zap.NewNop())
+	if err != nil {
+		t.Fatalf("unexpected error writing snapshot: %v", err)
+	}
+
+	r := MustOpenTSMReader(files[0])
+	defer r.Close()
+	if got := r.BlockSummaries([]byte("cpu,host=A#!~#value")); got != nil {
+		t.Fatalf("expected no summaries, got %v", got)
+	}
+}
+
+// Ensure summaries written for another TSM file are ignored.
+func TestTSMReader_BlockSummaries_Stale(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	var files []string
+	for i, values := range [][]tsm1.Value{
+		{tsm1.NewValue(1, 1.0)},
+		{tsm1.NewValue(1, 1.0), tsm1.NewValue(2, 2.0)},
+	} {
+		c := tsm1.NewCache(0, tsdb.EngineTags{})
+		if err := c.Write([]byte("cpu,host=A#!~#value"), values); err != nil {
+			t.Fatal(err)
+		}
+
+		compactor := tsm1.NewCompactor()
+		compactor.Dir = filepath.Join(dir, strconv.Itoa(i))
+		compactor.FileStore = &fakeFileStore{}
+		compactor.Rollups = true
+		compactor.Open()
+		if err := os.Mkdir(compactor.Dir, 0777); err != nil {
+			t.Fatal(err)
+		}
+		a, err := compactor.WriteSnapshot(c, zap.NewNop())
+		if err != nil {
+			t.Fatalf("unexpected error writing snapshot: %v", err)
+		}
+		files = append(files, a...)
+	}
+
+	rollup := func(path string) string {
+		return strings.Replace(path, "."+tsm1.TSMFileExtension, "."+tsm1.RollupFileExtension, 1)
+	}
+	if err := os.Rename(rollup(files[1]), rollup(files[0])); err != nil {
+		t.Fatal(err)
+	}
+
+	r := MustOpenTSMReader(files[0])
+	defer r.Close()
+	if got := r.BlockSummaries([]byte("cpu,host=A#!~#value")); got != nil {
+		t.Fatalf("expected no summaries, got %v", got)
+	}
+}
+
+func TestEngine_NextSummarized(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			e := MustOpenEngine(t, index)
+			defer e.Close()
+			e.Compactor.Rollups = true
+
+			e.MeasurementFields([]byte("cpu")).CreateFieldIfNotExists([]byte("value"), influxql.Float)
+			e.CreateSeriesIfNotExists([]byte("cpu,host=A"), []byte("cpu"), models.NewTags(map[string]string{"host": "A"}))
+
+			if err := e.WritePointsString(
+				`cpu,host=A value=1 1`,
+				`cpu,host=A value=2 2`,
+				`cpu,host=A value=3 3`,
+			); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+			e.MustWriteSnapshot()
+
+			if err := e.WritePointsString(
+				`cpu,host=A value=10 10`,
+				`cpu,host=A value=11 11`,
+				`cpu,host=A value=12 12`,
+			); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+			e.MustWriteSnapshot()
+
+			if err := e.WritePointsString(`cpu,host=A value=20 20`); err != nil {
+				t.Fatalf("failed to write points: %s", err.Error())
+			}
+
+			read := func() ([]tsdb.FloatBlockSummary, *tsdb.FloatArray) {
+				t.Helper()
+				q, err := e.CreateCursorIterator(context.Background())
+				if err != nil {
+					t.Fatal(err)
+				}
+				cur, err := q.(tsdb.SummaryCursorIterator).NextSummarized(context.Background(), &tsdb.CursorRequest{
+					Name:      []byte("cpu"),
+					Tags:      models.ParseTags([]byte("cpu,host=A")),
+					Field:     "value",
+					Ascending: true,
+					StartTime: 0,
+					EndTime:   100,
+				}, func(minTime, maxTime int64) bool { return maxTime < 10 })
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer cur.Close()
+
+				fcur := cur.(tsdb.FloatSummaryArrayCursor)
+				summaries := fcur.Summaries()
+				a := tsdb.NewFloatArrayLen(0)
+				for b := fcur.Next(); b.Len() > 0; b = fcur.Next() {
+					a.Timestamps = append(a.Timestamps, b.Timestamps...)
+					a.Values = append(a.Values, b.Values...)
+				}
+				return summaries, a
+			}
+
+			summaries, a := read()
+			if exp := []tsdb.FloatBlockSummary{{
+				MinTime: 1, MaxTime: 3, Count: 3, Sum: 6, Min: 1, Max: 3, First: 1, Last: 3,
+				MinValueTime: 1, MaxValueTime: 3,
+			}}; !cmp.Equal(summaries, exp) {
+				t.Fatalf("unexpected summaries: %s", cmp.Diff(summaries, exp))
+			}
+			if exp := []int64{10, 11, 12, 20}; !cmp.Equal(a.Timestamps, exp) {
+				t.Fatalf("unexpected timestamps: %s", cmp.Diff(a.Timestamps, exp))
+			}
+
+			// Blocks with deleted values are decoded.
+			if err := e.FileStore.DeleteRange([][]byte{[]byte("cpu,host=A#!~#value")}, 2, 2); err != nil {
+				t.Fatal(err)
+			}
+			summaries, a = read()
+			if len(summaries) != 0 {
+				t.Fatalf("expected no summaries, got %v", summaries)
+			}
+			if exp := []int64{1, 3, 10, 11, 12, 20}; !cmp.Equal(a.Timestamps, exp) {
+				t.Fatalf("unexpected timestamps: %s", cmp.Diff(a.Timestamps, exp))
+			}
+		})
+	}
+}
+
+func f64bits(v float64) uint64 { return math.Float64bits(v) }
+
+// Ensure rollup files follow their TSM files in and out of the file store.
+func TestFileStore_Replace_Rollups(t *testing.T) {
+	dir := MustTempDir()
+	defer os.RemoveAll(dir)
+
+	fs := newTestFileStore(dir)
+	defer fs.Close()
+	if err := fs.Open(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	c := tsm1.NewCache(0, tsdb.EngineTags{})
+	if err := c.Write([]byte("cpu,host=A#!~#value"), []tsm1.Value{tsm1.NewValue(1, 1.0)}); err != nil {
+		t.Fatal(err)
+	}
+
+	compactor := tsm1.NewCompactor()
+	compactor.Dir = dir
+	compactor.FileStore = fs
+	compactor.Rollups = true
+	compactor.Open()
+
+	files, err := compactor.WriteSnapshot(c, zap.NewNop())
+	if err != nil {
+		t.Fatalf("unexpected error writing snapshot: %v", err)
+	}
+	if err := fs.Replace(nil, files); err != nil {
+		t.Fatal(err)
+	}
+
+	tsmPath := fs.Files()[0].Path()
+	path := strings.TrimSuffix(tsmPath, tsm1.TSMFileExtension) + tsm1.RollupFileExtension
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected rollup file: %v", err)
+	}
+	if got := fs.Files()[0].BlockSummaries([]byte("cpu,host=A#!~#value")); len(got) != 1 {
+		t.Fatalf("unexpected summaries: %v", got)
+	}
+
+	if err := fs.Replace([]string{tsmPath}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("expected rollup file to be removed: %v", err)
+	}
+}
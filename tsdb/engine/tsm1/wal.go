@@ -93,8 +93,8 @@ type WAL struct {
 	closing chan struct{}
 
 	// syncDelay sets the duration to wait before fsyncing writes.  A value of 0 (default)
-	// will cause every write to be fsync'd.  This must be set before the WAL
-	// is opened if a non-default value is required.
+	// will cause every write to be fsync'd.  It must be accessed atomically
+	// once the WAL is opened; see SetSyncDelay.
 	syncDelay time.Duration
 
 	// WALOutput is the writer used by the logger.
@@ -317,14 +317,15 @@ func (l *WAL) scheduleSync() {
 
 		// time.NewTicker requires a > 0 delay, since 0 indicates no delay, use a closed
 		// channel which will always be ready to read from.
-		if l.syncDelay == 0 {
+		syncDelay := l.SyncDelay()
+		if syncDelay == 0 {
 			// Create a RW chan and close it
 			timerChrw := make(chan time.Time)
 			close(timerChrw)
 			// Convert it to a read-only
 			timerCh = timerChrw
 		} else {
-			t := time.NewTicker(l.syncDelay)
+			t := time.NewTicker(syncDelay)
 			defer t.Stop()
 			timerCh = t.C
 		}
@@ -348,6 +349,18 @@ func (l *WAL) scheduleSync() {
 	}()
 }
 
+// SetSyncDelay sets the duration a write waits before its segment is fsync'd.
+// A value of 0 fsyncs every write. It is safe to call while the WAL is open and
+// takes effect from the next scheduled sync.
+func (l *WAL) SetSyncDelay(d time.Duration) {
+	atomic.StoreInt64((*int64)(&l.syncDelay), int64(d))
+}
+
+// SyncDelay returns the duration a write waits before its segment is fsync'd.
+func (l *WAL) SyncDelay() time.Duration {
+	return time.Duration(atomic.LoadInt64((*int64)(&l.syncDelay)))
+}
+
 // sync fsyncs the current wal segments and notifies any waiters.  Callers must ensure
 // a write lock on the WAL is obtained before calling sync.
 func (l *WAL) sync() {
@@ -23,11 +23,13 @@ func ValidateFields(mf *MeasurementFields, point models.Point, skipSizeValidatio
 			// unescape the string, and must at least parse the string)
 			if pointSize > MaxFieldValueLength && iter.Type() == models.String {
 				if sz := len(iter.StringValue()); sz > MaxFieldValueLength {
+					reason := fmt.Sprintf(
+						"input field \"%s\" on measurement \"%s\" is too long, %d > %d",
+						iter.FieldKey(), point.Name(), sz, MaxFieldValueLength)
 					return PartialWriteError{
-						Reason: fmt.Sprintf(
-							"input field \"%s\" on measurement \"%s\" is too long, %d > %d",
-							iter.FieldKey(), point.Name(), sz, MaxFieldValueLength),
+						Reason:  reason,
 						Dropped: 1,
+						Points:  []DroppedPoint{{Point: point, Code: DropInvalid, Reason: reason}},
 					}
 				}
 			}
@@ -51,11 +53,13 @@ func ValidateFields(mf *MeasurementFields, point models.Point, skipSizeValidatio
 
 		// If the types are not the same, there is a conflict.
 		if f.Type != dataType {
+			reason := fmt.Sprintf(
+				"%s: input field \"%s\" on measurement \"%s\" is type %s, already exists as type %s",
+				ErrFieldTypeConflict, iter.FieldKey(), point.Name(), dataType, f.Type)
 			return PartialWriteError{
-				Reason: fmt.Sprintf(
-					"%s: input field \"%s\" on measurement \"%s\" is type %s, already exists as type %s",
-					ErrFieldTypeConflict, iter.FieldKey(), point.Name(), dataType, f.Type),
+				Reason:  reason,
 				Dropped: 1,
+				Points:  []DroppedPoint{{Point: point, Code: DropFieldTypeConflict, Reason: reason}},
 			}
 		}
 	}
@@ -63,6 +63,11 @@ type Index interface {
 	TagKeySeriesIDIterator(name, key []byte) (SeriesIDIterator, error)
 	TagValueSeriesIDIterator(name, key, value []byte) (SeriesIDIterator, error)
 
+	// TagValueIteratorFrom returns an iterator over the values of a tag key
+	// starting at the first value greater than or equal to seek. If filter is
+	// set, values known not to pass it may be skipped.
+	TagValueIteratorFrom(name, key, seek []byte, filter *ValueFilter) (TagValueIterator, error)
+
 	// Sets a shared fieldset from the engine.
 	FieldSet() *MeasurementFieldSet
 	SetFieldSet(fs *MeasurementFieldSet)
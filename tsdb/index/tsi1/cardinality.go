@@ -0,0 +1,306 @@
+package tsi1
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// cardinalityCounts caches the number of series of the measurements of an
+// index and of values of their tag keys, so that the cardinality limits are
+// checked without scanning the index on every write. A count is loaded from
+// the index the first time it is needed, counted up as new series are
+// admitted and forgotten once series of its measurement are dropped, to be
+// loaded again. The counts are only kept while limits are enforced.
+//
+// A series or tag value first written by concurrent writes may be counted
+// twice, which makes the limits slightly conservative until the count is
+// loaded again.
+type cardinalityCounts struct {
+	mu     sync.Mutex
+	series map[string]int64            // by measurement
+	values map[string]map[string]int64 // by measurement and tag key
+}
+
+func newCardinalityCounts() *cardinalityCounts {
+	return &cardinalityCounts{
+		series: make(map[string]int64),
+		values: make(map[string]map[string]int64),
+	}
+}
+
+// forget drops the counts of a measurement.
+func (c *cardinalityCounts) forget(name []byte) {
+	c.mu.Lock()
+	delete(c.series, string(name))
+	delete(c.values, string(name))
+	c.mu.Unlock()
+}
+
+// reset drops all of the counts.
+func (c *cardinalityCounts) reset() {
+	c.mu.Lock()
+	c.keep(tsdb.CardinalityLimits{})
+	c.mu.Unlock()
+}
+
+// keep drops the counts which are not checked against limits, as they are
+// not kept up to date. c.mu must be held.
+func (c *cardinalityCounts) keep(limits tsdb.CardinalityLimits) {
+	if limits.MaxSeriesPerMeasurement <= 0 && len(c.series) > 0 {
+		c.series = make(map[string]int64)
+	}
+	if limits.MaxValuesPerTag <= 0 && len(c.values) > 0 {
+		c.values = make(map[string]map[string]int64)
+	}
+}
+
+// cardinalityCheck tracks the cardinality of the series in a batch that do
+// not exist yet. The counts of the index are locked while the batch is
+// checked, and counted up as its series are admitted.
+type cardinalityCheck struct {
+	idx    *Index
+	limits tsdb.CardinalityLimits
+	counts *cardinalityCounts
+
+	seriesN, orgSeriesN int64 // -1 until loaded
+
+	created map[string]struct{} // new series keys accepted in the batch
+	values  map[string]struct{} // new tag values accepted in the batch
+}
+
+// enforceCardinalityLimits removes the new series that would exceed the
+// limits of the index's database from keys, names and tagsSlice. If any
+// series are removed, it returns a tsdb.PartialWriteError naming the first
+// offending key along with the keys of all removed series.
+func (i *Index) enforceCardinalityLimits(keys, names [][]byte, tagsSlice []models.Tags) ([][]byte, [][]byte, []models.Tags, error) {
+	if i.limiter == nil {
+		return keys, names, tagsSlice, nil
+	}
+	limits := i.limiter.CardinalityLimits()
+	if !limits.Enabled() {
+		i.counts.reset()
+		return keys, names, tagsSlice, nil
+	}
+
+	c := &cardinalityCheck{
+		idx:        i,
+		limits:     limits,
+		counts:     i.counts,
+		seriesN:    -1,
+		orgSeriesN: -1,
+		created:    make(map[string]struct{}),
+		values:     make(map[string]struct{}),
+	}
+	c.counts.mu.Lock()
+	defer c.counts.mu.Unlock()
+	c.counts.keep(limits)
+
+	var (
+		buf     []byte
+		dropped map[string]struct{}
+		reason  string
+	)
+	for k, key := range keys {
+		if _, ok := dropped[string(key)]; ok {
+			continue
+		} else if _, ok := c.created[string(key)]; ok {
+			continue
+		}
+
+		// The series file is shared by the shards of the database, so a
+		// series found in it may still be new to this index.
+		id := i.sfile.SeriesID(names[k], tagsSlice[k], buf)
+		if id != 0 && i.partition(key).seriesIDSet.Contains(id) {
+			continue
+		}
+
+		msg, err := c.admit(key, names[k], tagsSlice[k], id != 0)
+		if err != nil {
+			return nil, nil, nil, err
+		} else if msg == "" {
+			continue
+		}
+
+		if dropped == nil {
+			dropped = make(map[string]struct{})
+		}
+		dropped[string(key)] = struct{}{}
+		if reason == "" {
+			reason = msg
+		}
+	}
+
+	if len(dropped) == 0 {
+		return keys, names, tagsSlice, nil
+	}
+
+	// The caller's slices are left untouched as it may still refer to them
+	// by position.
+	var (
+		okKeys, okNames [][]byte
+		okTags          []models.Tags
+		n               int
+	)
+	for k, key := range keys {
+		if _, ok := dropped[string(key)]; ok {
+			n++
+			continue
+		}
+		okKeys, okNames, okTags = append(okKeys, key), append(okNames, names[k]), append(okTags, tagsSlice[k])
+	}
+
+	droppedKeys := make([][]byte, 0, len(dropped))
+	for key := range dropped {
+		droppedKeys = append(droppedKeys, []byte(key))
+	}
+	sort.Slice(droppedKeys, func(a, b int) bool { return bytes.Compare(droppedKeys[a], droppedKeys[b]) < 0 })
+
+	return okKeys, okNames, okTags, tsdb.PartialWriteError{
+		Reason:      reason,
+		Dropped:     n,
+		DroppedKeys: droppedKeys,
+	}
+}
+
+// admit checks whether the series key new to the index may be created. If it
+// may not, it returns the reason. A series already in the series file, as
+// another shard of the database has it, is not checked against nor counted
+// towards the limits of the bucket and organization, which it already counts
+// towards.
+func (c *cardinalityCheck) admit(key, name []byte, tags models.Tags, inFile bool) (string, error) {
+	if max := c.limits.MaxSeries; max > 0 && !inFile {
+		if c.seriesN < 0 {
+			c.seriesN = int64(c.idx.sfile.SeriesCount())
+		}
+		if c.seriesN >= max {
+			return fmt.Sprintf("max-series-per-bucket limit exceeded (%d/%d): key=%q", c.seriesN, max, key), nil
+		}
+	}
+
+	if max := c.limits.MaxOrgSeries; max > 0 && c.limits.Org != "" && !inFile {
+		if c.orgSeriesN < 0 {
+			c.orgSeriesN = c.idx.limiter.OrgSeriesN(c.limits.Org)
+		}
+		if c.orgSeriesN >= max {
+			return fmt.Sprintf("max-series-per-org limit exceeded (%d/%d): key=%q", c.orgSeriesN, max, key), nil
+		}
+	}
+
+	if max := c.limits.MaxSeriesPerMeasurement; max > 0 {
+		n, ok := c.counts.series[string(name)]
+		if !ok {
+			var err error
+			if n, err = c.idx.measurementSeriesN(name); err != nil {
+				return "", err
+			}
+			c.counts.series[string(name)] = n
+		}
+		if n >= max {
+			return fmt.Sprintf("max-series-per-measurement limit exceeded (%d/%d): measurement=%q key=%q", n, max, name, key), nil
+		}
+	}
+
+	// Tag values that are new to the measurement are collected so they are
+	// only counted once the whole series is admitted.
+	type tagValue struct{ tkey, vkey string }
+	var newValues []tagValue
+	values := c.counts.values[string(name)]
+	if max := c.limits.MaxValuesPerTag; max > 0 {
+		for _, t := range tags {
+			vkey := string(name) + "\x00" + string(t.Key) + "\x00" + string(t.Value)
+			if _, ok := c.values[vkey]; ok {
+				continue
+			}
+			if ok, err := c.idx.HasTagValue(name, t.Key, t.Value); err != nil {
+				return "", err
+			} else if ok {
+				continue
+			}
+
+			tkey := string(t.Key)
+			n, ok := values[tkey]
+			if !ok {
+				var err error
+				if n, err = c.idx.tagValuesN(name, t.Key); err != nil {
+					return "", err
+				}
+				if values == nil {
+					values = make(map[string]int64)
+					c.counts.values[string(name)] = values
+				}
+				values[tkey] = n
+			}
+			if n >= max {
+				return fmt.Sprintf("max-values-per-tag limit exceeded (%d/%d): measurement=%q tag=%q value=%q", n, max, name, t.Key, t.Value), nil
+			}
+			newValues = append(newValues, tagValue{tkey: tkey, vkey: vkey})
+		}
+	}
+
+	c.created[string(key)] = struct{}{}
+	if c.seriesN >= 0 && !inFile {
+		c.seriesN++
+	}
+	if c.orgSeriesN >= 0 && !inFile {
+		c.orgSeriesN++
+	}
+	if _, ok := c.counts.series[string(name)]; ok {
+		c.counts.series[string(name)]++
+	}
+	for _, v := range newValues {
+		c.values[v.vkey] = struct{}{}
+		values[v.tkey]++
+	}
+	return "", nil
+}
+
+// measurementSeriesN returns the number of series of a measurement, counted
+// from the index.
+func (i *Index) measurementSeriesN(name []byte) (int64, error) {
+	itr, err := i.MeasurementSeriesIDIterator(name)
+	if err != nil {
+		return 0, err
+	} else if itr == nil {
+		return 0, nil
+	}
+	defer itr.Close()
+
+	var n int64
+	for {
+		e, err := itr.Next()
+		if err != nil {
+			return 0, err
+		} else if e.SeriesID == 0 {
+			return n, nil
+		}
+		n++
+	}
+}
+
+// tagValuesN returns the number of values of a tag key of a measurement,
+// counted from the index.
+func (i *Index) tagValuesN(name, key []byte) (int64, error) {
+	itr, err := i.TagValueIterator(name, key)
+	if err != nil {
+		return 0, err
+	} else if itr == nil {
+		return 0, nil
+	}
+	defer itr.Close()
+
+	var n int64
+	for {
+		v, err := itr.Next()
+		if err != nil {
+			return 0, err
+		} else if v == nil {
+			return n, nil
+		}
+		n++
+	}
+}
@@ -339,6 +339,20 @@ func (fs *FileSet) TagValueIterator(name, key []byte) TagValueIterator {
 	return MergeTagValueIterators(a...)
 }
 
+// TagValueIteratorFrom returns a value iterator for a tag key starting at the
+// first value greater than or equal to seek, skipping values not containing
+// the trigrams and runes of filter.
+func (fs *FileSet) TagValueIteratorFrom(name, key, seek []byte, filter *tsdb.ValueFilter) TagValueIterator {
+	a := make([]TagValueIterator, 0, len(fs.files))
+	for _, f := range fs.files {
+		itr := f.TagValueIteratorFrom(name, key, seek, filter)
+		if itr != nil {
+			a = append(a, itr)
+		}
+	}
+	return MergeTagValueIterators(a...)
+}
+
 // TagValueSeriesIDIterator returns a series iterator for a single tag value.
 func (fs *FileSet) TagValueSeriesIDIterator(name, key, value []byte) (tsdb.SeriesIDIterator, error) {
 	ss := tsdb.NewSeriesIDSet()
@@ -398,6 +412,69 @@ func (fs *FileSet) SeriesSketches() (estimator.Sketch, estimator.Sketch, error)
 	return sketch, tSketch, nil
 }
 
+// seriesNElem is implemented by measurement and tag value elements which know
+// how many series they have without reading them.
+type seriesNElem interface {
+	SeriesN() uint64
+}
+
+// MeasurementSeriesNs returns the number of series of every measurement, as
+// stored in the files. Series deleted since they were written to a file are
+// still counted until the file is compacted.
+func (fs *FileSet) MeasurementSeriesNs() map[string]int64 {
+	m := make(map[string]int64)
+	deleted := make(map[string]struct{})
+	for _, f := range fs.files {
+		itr := f.MeasurementIterator()
+		if itr == nil {
+			continue
+		}
+		for e := itr.Next(); e != nil; e = itr.Next() {
+			name := string(e.Name())
+			if _, ok := deleted[name]; ok {
+				continue
+			} else if e.Deleted() {
+				// Older files only hold series of the dropped measurement.
+				deleted[name] = struct{}{}
+				continue
+			}
+			if e, ok := e.(seriesNElem); ok {
+				m[name] += int64(e.SeriesN())
+			}
+		}
+	}
+	return m
+}
+
+// TagValueSeriesNs returns the number of series of every value of a tag key,
+// as stored in the files. Like MeasurementSeriesNs, it may count deleted series.
+func (fs *FileSet) TagValueSeriesNs(name, key []byte) map[string]int64 {
+	m := make(map[string]int64)
+	deleted := make(map[string]struct{})
+	for _, f := range fs.files {
+		if e := f.TagKey(name, key); e != nil && e.Deleted() {
+			break
+		}
+		itr := f.TagValueIterator(name, key)
+		if itr == nil {
+			continue
+		}
+		for e := itr.Next(); e != nil; e = itr.Next() {
+			value := string(e.Value())
+			if _, ok := deleted[value]; ok {
+				continue
+			} else if e.Deleted() {
+				deleted[value] = struct{}{}
+				continue
+			}
+			if e, ok := e.(seriesNElem); ok {
+				m[value] += int64(e.SeriesN())
+			}
+		}
+	}
+	return m
+}
+
 // File represents a log or index file.
 type File interface {
 	Close() error
@@ -415,6 +492,7 @@ type File interface {
 
 	TagValue(name, key, value []byte) TagValueElem
 	TagValueIterator(name, key []byte) TagValueIterator
+	TagValueIteratorFrom(name, key, seek []byte, filter *tsdb.ValueFilter) TagValueIterator
 
 	// Series iteration.
 	MeasurementSeriesIDIterator(name []byte) tsdb.SeriesIDIterator
@@ -46,6 +46,7 @@ func init() {
 			WithMaximumLogFileSize(int64(opt.Config.MaxIndexLogFileSize)),
 			WithMaximumLogFileAge(time.Duration(opt.Config.CompactFullWriteColdDuration)),
 			WithSeriesIDCacheSize(opt.Config.SeriesIDSetCacheSize),
+			WithCardinalityLimiter(opt.CardinalityLimiter),
 		)
 		return idx
 	})
@@ -129,6 +130,14 @@ var WithSeriesIDCacheSize = func(sz int) IndexOption {
 	}
 }
 
+// WithCardinalityLimiter sets the limiter providing the series cardinality
+// limits enforced when new series are created.
+var WithCardinalityLimiter = func(l tsdb.CardinalityLimiter) IndexOption {
+	return func(i *Index) {
+		i.limiter = l
+	}
+}
+
 // Index represents a collection of layered index files and WAL.
 type Index struct {
 	mu         sync.RWMutex
@@ -151,6 +160,10 @@ type Index struct {
 	sfile    *tsdb.SeriesFile // series lookup file
 	database string           // Name of database.
 
+	// Limits the cardinality of new series, if set.
+	limiter tsdb.CardinalityLimiter
+	counts  *cardinalityCounts
+
 	// Cached sketches.
 	mSketch, mTSketch estimator.Sketch // Measurement sketches
 	sSketch, sTSketch estimator.Sketch // Series sketches
@@ -180,6 +193,7 @@ func NewIndex(sfile *tsdb.SeriesFile, database string, options ...IndexOption) *
 		mTSketch:          hll.NewDefaultPlus(),
 		sSketch:           hll.NewDefaultPlus(),
 		sTSketch:          hll.NewDefaultPlus(),
+		counts:            newCardinalityCounts(),
 		PartitionN:        DefaultPartitionN,
 	}
 
@@ -621,6 +635,7 @@ func (i *Index) DropMeasurement(name []byte) error {
 			}
 		}()
 	}
+	defer i.counts.forget(name)
 
 	// Check for error
 	for i := 0; i < cap(errC); i++ {
@@ -648,6 +663,15 @@ func (i *Index) CreateSeriesListIfNotExists(keys [][]byte, names [][]byte, tagsS
 		return errors.New("names/tags length mismatch in index")
 	}
 
+	// Series that would exceed the cardinality limits are dropped and
+	// reported once the rest are created.
+	keys, names, tagsSlice, limitErr := i.enforceCardinalityLimits(keys, names, tagsSlice)
+	if limitErr != nil {
+		if _, ok := limitErr.(tsdb.PartialWriteError); !ok {
+			return limitErr
+		}
+	}
+
 	// We need to move different series into collections for each partition
 	// to process.
 	pNames := make([][][]byte, i.PartitionN)
@@ -726,9 +750,11 @@ func (i *Index) CreateSeriesListIfNotExists(keys [][]byte, names [][]byte, tagsS
 		}()
 	}
 
-	// Check for error
-	for i := 0; i < cap(errC); i++ {
+	// Check for error. The series counted by the cardinality check may not
+	// have been created.
+	for j := 0; j < cap(errC); j++ {
 		if err := <-errC; err != nil {
+			i.counts.reset()
 			return err
 		}
 	}
@@ -744,13 +770,18 @@ func (i *Index) CreateSeriesListIfNotExists(keys [][]byte, names [][]byte, tagsS
 		i.mSketch.Add(name)
 	}
 
-	return nil
+	return limitErr
 }
 
 // CreateSeriesIfNotExists creates a series if it doesn't exist or is deleted.
 func (i *Index) CreateSeriesIfNotExists(key, name []byte, tags models.Tags) error {
+	if _, _, _, err := i.enforceCardinalityLimits([][]byte{key}, [][]byte{name}, []models.Tags{tags}); err != nil {
+		return err
+	}
+
 	ids, err := i.partition(key).createSeriesListIfNotExists([][]byte{name}, []models.Tags{tags})
 	if err != nil {
+		i.counts.reset()
 		return err
 	}
 
@@ -790,7 +821,9 @@ func (i *Index) CreateSeriesIfNotExists(key, name []byte, tags models.Tags) erro
 // and this is the last series to the measurement, the measurement will also be dropped.
 func (i *Index) DropSeries(seriesID uint64, key []byte, cascade bool) error {
 	// Remove from partition.
-	if err := i.partition(key).DropSeries(seriesID); err != nil {
+	err := i.partition(key).DropSeries(seriesID)
+	i.counts.forget(models.ParseName(key))
+	if err != nil {
 		return err
 	}
 
@@ -858,6 +891,40 @@ func (i *Index) SeriesSketches() (estimator.Sketch, estimator.Sketch, error) {
 	return i.sSketch.Clone(), i.sTSketch.Clone(), nil
 }
 
+// MeasurementSeriesNs returns the number of series of every measurement, as
+// stored in the index files without reading the series. The counts may include
+// recently deleted series.
+func (i *Index) MeasurementSeriesNs() (map[string]int64, error) {
+	m := make(map[string]int64)
+	for _, p := range i.partitions {
+		pm, err := p.MeasurementSeriesNs()
+		if err != nil {
+			return nil, err
+		}
+		for name, n := range pm {
+			m[name] += n
+		}
+	}
+	return m, nil
+}
+
+// TagValueSeriesNs returns the number of series of every value of a tag key,
+// as stored in the index files without reading the series. The counts may
+// include recently deleted series.
+func (i *Index) TagValueSeriesNs(name, key []byte) (map[string]int64, error) {
+	m := make(map[string]int64)
+	for _, p := range i.partitions {
+		pm, err := p.TagValueSeriesNs(name, key)
+		if err != nil {
+			return nil, err
+		}
+		for value, n := range pm {
+			m[value] += n
+		}
+	}
+	return m, nil
+}
+
 // Since indexes are not shared across shards, the count returned by SeriesN
 // cannot be combined with other shard's results. If you need to count series
 // across indexes then use either the database-wide series file, or merge the
@@ -981,6 +1048,20 @@ func (i *Index) TagValueIterator(name, key []byte) (tsdb.TagValueIterator, error
 	return tsdb.MergeTagValueIterators(a...), nil
 }
 
+// TagValueIteratorFrom returns an iterator for the values of a single key
+// starting at the first value greater than or equal to seek, skipping values
+// not containing the trigrams and runes of filter.
+func (i *Index) TagValueIteratorFrom(name, key, seek []byte, filter *tsdb.ValueFilter) (tsdb.TagValueIterator, error) {
+	a := make([]tsdb.TagValueIterator, 0, len(i.partitions))
+	for _, p := range i.partitions {
+		itr := p.TagValueIteratorFrom(name, key, seek, filter)
+		if itr != nil {
+			a = append(a, itr)
+		}
+	}
+	return tsdb.MergeTagValueIterators(a...), nil
+}
+
 // TagKeySeriesIDIterator returns a series iterator for all values across a single key.
 func (i *Index) TagKeySeriesIDIterator(name, key []byte) (tsdb.SeriesIDIterator, error) {
 	a := make([]tsdb.SeriesIDIterator, 0, len(i.partitions))
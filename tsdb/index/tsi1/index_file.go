@@ -289,6 +289,17 @@ func (f *IndexFile) TagValueIterator(name, key []byte) TagValueIterator {
 	return ke.TagValueIterator()
 }
 
+// TagValueIteratorFrom returns a value iterator for a tag key starting at the
+// first value greater than or equal to seek, skipping values not containing
+// the trigrams and runes of filter.
+func (f *IndexFile) TagValueIteratorFrom(name, key, seek []byte, filter *tsdb.ValueFilter) TagValueIterator {
+	tblk := f.tblks[string(name)]
+	if tblk == nil {
+		return nil
+	}
+	return tblk.TagValueIteratorFrom(key, seek, filter)
+}
+
 // TagKeySeriesIDIterator returns a series iterator for a tag key and a flag
 // indicating if a tombstone exists on the measurement or key.
 func (f *IndexFile) TagKeySeriesIDIterator(name, key []byte) (tsdb.SeriesIDIterator, error) {
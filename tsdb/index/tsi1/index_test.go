@@ -211,6 +211,30 @@ func TestIndex_DropMeasurement(t *testing.T) {
 	})
 }
 
+func TestIndex_SeriesNs(t *testing.T) {
+	idx := MustOpenDefaultIndex()
+	defer idx.Close()
+
+	require.NoError(t, idx.CreateSeriesSliceIfNotExists([]Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "east", "host": "a"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "east", "host": "b"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"region": "west", "host": "c"})},
+		{Name: []byte("disk"), Tags: models.NewTags(map[string]string{"region": "north"})},
+		{Name: []byte("mem"), Tags: models.NewTags(map[string]string{"region": "west"})},
+	}))
+	require.NoError(t, idx.DropMeasurement([]byte("mem")))
+
+	idx.Run(t, func(t *testing.T) {
+		m, err := idx.MeasurementSeriesNs()
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"cpu": 3, "disk": 1}, m)
+
+		values, err := idx.TagValueSeriesNs([]byte("cpu"), []byte("region"))
+		require.NoError(t, err)
+		require.Equal(t, map[string]int64{"east": 2, "west": 1}, values)
+	})
+}
+
 func TestIndex_OpenFail(t *testing.T) {
 	idx := NewDefaultIndex()
 	require.NoError(t, idx.Open())
@@ -355,6 +379,194 @@ func TestIndex_DiskSizeBytes(t *testing.T) {
 	})
 }
 
+// cardinalityLimiter is a tsdb.CardinalityLimiter with fixed limits.
+type cardinalityLimiter struct {
+	limits     tsdb.CardinalityLimits
+	orgSeriesN int64
+}
+
+func (l *cardinalityLimiter) CardinalityLimits() tsdb.CardinalityLimits { return l.limits }
+func (l *cardinalityLimiter) OrgSeriesN(org string) int64               { return l.orgSeriesN }
+
+// Ensure series exceeding the cardinality limits are not created and are
+// reported as dropped.
+func TestIndex_CardinalityLimits(t *testing.T) {
+	existing := []Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": "a"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": "b"})},
+		{Name: []byte("mem"), Tags: models.NewTags(map[string]string{"host": "a"})},
+	}
+	batch := []Series{
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": "a"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": "c"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": "d"})},
+		{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": "d"})},
+		{Name: []byte("mem"), Tags: models.NewTags(map[string]string{"host": "b"})},
+	}
+
+	tests := []struct {
+		name       string
+		limits     tsdb.CardinalityLimits
+		orgSeriesN int64
+		reason     string
+		dropped    int
+		keys       []string
+	}{
+		{
+			name:   "under limits",
+			limits: tsdb.CardinalityLimits{MaxSeries: 6, MaxSeriesPerMeasurement: 4, MaxValuesPerTag: 4},
+		},
+		{
+			name:    "max series",
+			limits:  tsdb.CardinalityLimits{MaxSeries: 4},
+			reason:  `max-series-per-bucket limit exceeded (4/4): key="cpu,host=d"`,
+			dropped: 3,
+			keys:    []string{"cpu,host=d", "mem,host=b"},
+		},
+		{
+			name:       "max org series",
+			limits:     tsdb.CardinalityLimits{Org: "org0", MaxOrgSeries: 10},
+			orgSeriesN: 9,
+			reason:     `max-series-per-org limit exceeded (10/10): key="cpu,host=d"`,
+			dropped:    3,
+			keys:       []string{"cpu,host=d", "mem,host=b"},
+		},
+		{
+			name:    "max series per measurement",
+			limits:  tsdb.CardinalityLimits{MaxSeriesPerMeasurement: 3},
+			reason:  `max-series-per-measurement limit exceeded (3/3): measurement="cpu" key="cpu,host=d"`,
+			dropped: 2,
+			keys:    []string{"cpu,host=d"},
+		},
+		{
+			name:    "max values per tag",
+			limits:  tsdb.CardinalityLimits{MaxValuesPerTag: 2},
+			reason:  `max-values-per-tag limit exceeded (2/2): measurement="cpu" tag="host" value="c"`,
+			dropped: 3,
+			keys:    []string{"cpu,host=c", "cpu,host=d"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sfile := MustOpenSeriesFile()
+			defer sfile.Close()
+
+			limiter := &cardinalityLimiter{}
+			idx := &Index{
+				Index: tsi1.NewIndex(sfile.SeriesFile, "db0",
+					tsi1.WithPath(t.TempDir()),
+					tsi1.WithCardinalityLimiter(limiter),
+				),
+				SeriesFile: sfile,
+			}
+			require.NoError(t, idx.Index.Open())
+			defer idx.Index.Close()
+
+			require.NoError(t, idx.CreateSeriesSliceIfNotExists(existing))
+
+			limiter.limits, limiter.orgSeriesN = tt.limits, tt.orgSeriesN
+			err := idx.CreateSeriesSliceIfNotExists(batch)
+			if tt.reason == "" {
+				require.NoError(t, err)
+				require.Equal(t, uint64(6), sfile.SeriesCount())
+				return
+			}
+
+			var perr tsdb.PartialWriteError
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, tt.reason, perr.Reason)
+			require.Equal(t, tt.dropped, perr.Dropped)
+
+			keys := make([]string, 0, len(perr.DroppedKeys))
+			for _, key := range perr.DroppedKeys {
+				keys = append(keys, string(key))
+			}
+			require.Equal(t, tt.keys, keys)
+
+			// The series within the limits are still created.
+			require.Equal(t, uint64(3+3-len(tt.keys)), sfile.SeriesCount())
+		})
+	}
+}
+
+// Ensure the counts checked against the cardinality limits are kept across
+// batches and reloaded once series are dropped.
+func TestIndex_CardinalityLimits_Counts(t *testing.T) {
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+
+	limiter := &cardinalityLimiter{limits: tsdb.CardinalityLimits{MaxSeriesPerMeasurement: 3}}
+	idx := &Index{
+		Index: tsi1.NewIndex(sfile.SeriesFile, "db0",
+			tsi1.WithPath(t.TempDir()),
+			tsi1.WithCardinalityLimiter(limiter),
+		),
+		SeriesFile: sfile,
+	}
+	require.NoError(t, idx.Index.Open())
+	defer idx.Index.Close()
+
+	series := func(host string) []Series {
+		return []Series{{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": host})}}
+	}
+	for _, host := range []string{"a", "b", "c"} {
+		require.NoError(t, idx.CreateSeriesSliceIfNotExists(series(host)))
+	}
+	var perr tsdb.PartialWriteError
+	require.ErrorAs(t, idx.CreateSeriesSliceIfNotExists(series("d")), &perr)
+	require.Equal(t, `max-series-per-measurement limit exceeded (3/3): measurement="cpu" key="cpu,host=d"`, perr.Reason)
+
+	key := models.MakeKey([]byte("cpu"), models.NewTags(map[string]string{"host": "a"}))
+	id := sfile.SeriesID([]byte("cpu"), models.NewTags(map[string]string{"host": "a"}), nil)
+	require.NoError(t, idx.DropSeries(id, key, false))
+	require.NoError(t, idx.CreateSeriesSliceIfNotExists(series("d")))
+}
+
+// Ensure series of another shard of the database are checked against the
+// limits of the index they are new to.
+func TestIndex_CardinalityLimits_Shards(t *testing.T) {
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+
+	openIndex := func(limiter tsdb.CardinalityLimiter) *Index {
+		idx := &Index{
+			Index: tsi1.NewIndex(sfile.SeriesFile, "db0",
+				tsi1.WithPath(t.TempDir()),
+				tsi1.WithCardinalityLimiter(limiter),
+			),
+			SeriesFile: sfile,
+		}
+		require.NoError(t, idx.Index.Open())
+		return idx
+	}
+
+	series := func(hosts ...string) []Series {
+		var a []Series
+		for _, host := range hosts {
+			a = append(a, Series{Name: []byte("cpu"), Tags: models.NewTags(map[string]string{"host": host})})
+		}
+		return a
+	}
+
+	idx1 := openIndex(&cardinalityLimiter{})
+	defer idx1.Index.Close()
+	require.NoError(t, idx1.CreateSeriesSliceIfNotExists(series("a", "b", "c")))
+
+	// The series of the first shard are new to the second one, yet already
+	// count towards the series of the bucket.
+	idx2 := openIndex(&cardinalityLimiter{limits: tsdb.CardinalityLimits{MaxSeries: 3, MaxSeriesPerMeasurement: 2, MaxValuesPerTag: 2}})
+	defer idx2.Index.Close()
+	var perr tsdb.PartialWriteError
+	require.ErrorAs(t, idx2.CreateSeriesSliceIfNotExists(series("a", "b", "c")), &perr)
+	require.Equal(t, `max-series-per-measurement limit exceeded (2/2): measurement="cpu" key="cpu,host=c"`, perr.Reason)
+	require.Equal(t, 1, perr.Dropped)
+
+	n, err := idx2.MeasurementSeriesNs()
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{"cpu": 2}, n)
+}
+
 func TestIndex_TagValueSeriesIDIterator(t *testing.T) {
 	idx1 := MustOpenDefaultIndex() // Uses the single series creation method CreateSeriesIfNotExists
 	defer idx1.Close()
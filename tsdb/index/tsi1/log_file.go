@@ -432,6 +432,32 @@ func (f *LogFile) TagValueIterator(name, key []byte) TagValueIterator {
 	return tk.TagValueIterator()
 }
 
+// TagValueIteratorFrom returns a value iterator for a tag key starting at the
+// first value greater than or equal to seek, skipping values not containing
+// the trigrams and runes of filter.
+func (f *LogFile) TagValueIteratorFrom(name, key, seek []byte, filter *tsdb.ValueFilter) TagValueIterator {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	mm, ok := f.mms[string(name)]
+	if !ok {
+		return nil
+	}
+
+	tk, ok := mm.tagSet[string(key)]
+	if !ok {
+		return nil
+	}
+
+	names, grams := tk.sortedValues(filter != nil)
+	i := sort.Search(len(names), func(i int) bool { return bytes.Compare(names[i], seek) >= 0 })
+	itr := &logTagValueNameIterator{f: f, values: tk.tagValues, names: names[i:]}
+	if filter != nil {
+		itr.names, itr.positions, itr.filtered = names, grams.positions(filter, i), true
+	}
+	return itr
+}
+
 // DeleteTagKey adds a tombstone for a tag key to the log file.
 func (f *LogFile) DeleteTagKey(name, key []byte) error {
 	f.mu.Lock()
@@ -1320,10 +1346,13 @@ func (m *logMeasurement) hasSeries(ss *tsdb.SeriesIDSet) bool {
 func (m *logMeasurement) Name() []byte  { return m.name }
 func (m *logMeasurement) Deleted() bool { return m.deleted }
 
+// SeriesN returns the number of series of the measurement.
+func (m *logMeasurement) SeriesN() uint64 { return uint64(m.cardinality()) }
+
 func (m *logMeasurement) createTagSetIfNotExists(key []byte) logTagKey {
 	ts, ok := m.tagSet[string(key)]
 	if !ok {
-		ts = logTagKey{f: m.f, name: key, tagValues: make(map[string]logTagValue)}
+		ts = logTagKey{f: m.f, name: key, tagValues: make(map[string]logTagValue), sorted: &logTagValueNames{}}
 	}
 	return ts
 }
@@ -1364,6 +1393,7 @@ type logTagKey struct {
 	name      []byte
 	deleted   bool
 	tagValues map[string]logTagValue
+	sorted    *logTagValueNames
 }
 
 // bytes estimates the memory footprint of this logTagKey, in bytes.
@@ -1396,10 +1426,47 @@ func (tk *logTagKey) createTagValueIfNotExists(value []byte) logTagValue {
 	tv, ok := tk.tagValues[string(value)]
 	if !ok {
 		tv = logTagValue{name: value, series: make(map[uint64]struct{})}
+		tk.sorted.reset()
 	}
 	return tv
 }
 
+// sortedValues returns the names of the values of the tag key in ascending
+// order, along with a trigram index over them if grams is set. Both are cached
+// until a value is added. The log file must be read locked.
+func (tk *logTagKey) sortedValues(grams bool) ([][]byte, *valueGrams) {
+	c := tk.sorted
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.names == nil {
+		c.names = make([][]byte, 0, len(tk.tagValues))
+		for _, v := range tk.tagValues {
+			c.names = append(c.names, v.name)
+		}
+		sort.Slice(c.names, func(i, j int) bool { return bytes.Compare(c.names[i], c.names[j]) < 0 })
+	}
+	if grams && c.grams == nil {
+		names := c.names
+		c.grams = newValueGrams(len(names), func(i int) []byte { return names[i] })
+	}
+	return c.names, c.grams
+}
+
+// logTagValueNames caches the sorted names of the values of a tag key.
+type logTagValueNames struct {
+	mu    sync.Mutex
+	names [][]byte
+	grams *valueGrams
+}
+
+// reset drops the cached names once a value is added.
+func (c *logTagValueNames) reset() {
+	c.mu.Lock()
+	c.names, c.grams = nil, nil
+	c.mu.Unlock()
+}
+
 // logTagKey is a sortable list of log tag keys.
 type logTagKeySlice []logTagKey
 
@@ -1473,6 +1540,9 @@ func (tv *logTagValue) seriesIDSet() *tsdb.SeriesIDSet {
 func (tv *logTagValue) Value() []byte { return tv.name }
 func (tv *logTagValue) Deleted() bool { return tv.deleted }
 
+// SeriesN returns the number of series of the tag value.
+func (tv *logTagValue) SeriesN() uint64 { return uint64(tv.cardinality()) }
+
 // logTagValue is a sortable list of log tag values.
 type logTagValueSlice []logTagValue
 
@@ -1521,6 +1591,39 @@ func (itr *logTagValueIterator) Next() (e TagValueElem) {
 	return e
 }
 
+// logTagValueNameIterator iterates over the values of a tag key in the order of
+// their sorted names, reading each value only once it is reached. If filtered,
+// only the names at positions are read.
+type logTagValueNameIterator struct {
+	f         *LogFile
+	values    map[string]logTagValue
+	names     [][]byte
+	positions []uint32
+	filtered  bool
+	e         logTagValue
+}
+
+// Next returns the next element in the iterator.
+func (itr *logTagValueNameIterator) Next() TagValueElem {
+	var name []byte
+	if itr.filtered {
+		if len(itr.positions) == 0 {
+			return nil
+		}
+		name, itr.positions = itr.names[itr.positions[0]], itr.positions[1:]
+	} else {
+		if len(itr.names) == 0 {
+			return nil
+		}
+		name, itr.names = itr.names[0], itr.names[1:]
+	}
+
+	itr.f.mu.RLock()
+	itr.e = itr.values[string(name)]
+	itr.f.mu.RUnlock()
+	return &itr.e
+}
+
 // FormatLogFileName generates a log filename for the given index.
 func FormatLogFileName(id int) string {
 	return fmt.Sprintf("L0-%08d%s", id, LogFileExt)
@@ -207,6 +207,49 @@ func TestLogFile_DeleteMeasurement(t *testing.T) {
 	}
 }
 
+func TestLogFile_TagValueIteratorFrom(t *testing.T) {
+	sfile := MustOpenSeriesFile()
+	defer sfile.Close()
+
+	f := MustOpenLogFile(sfile.SeriesFile)
+	defer f.Close()
+
+	add := func(hosts ...string) {
+		t.Helper()
+		names := make([][]byte, len(hosts))
+		tags := make([]models.Tags, len(hosts))
+		for i, host := range hosts {
+			names[i] = []byte("cpu")
+			tags[i] = models.NewTags(map[string]string{"host": host})
+		}
+		if _, err := f.AddSeriesList(tsdb.NewSeriesIDSet(), names, tags); err != nil {
+			t.Fatal(err)
+		}
+	}
+	values := func(seek string, filter *tsdb.ValueFilter) []string {
+		var a []string
+		itr := f.TagValueIteratorFrom([]byte("cpu"), []byte("host"), []byte(seek), filter)
+		for e := itr.Next(); e != nil; e = itr.Next() {
+			a = append(a, string(e.Value()))
+		}
+		return a
+	}
+
+	add("web-b", "server-b", "server-a", "Server-C")
+	if got, exp := values("server-b", nil), []string{"server-b", "web-b"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, exp %v", got, exp)
+	}
+	if got, exp := values("", &tsdb.ValueFilter{Trigrams: []string{"ser"}}), []string{"Server-C", "server-a", "server-b"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, exp %v", got, exp)
+	}
+
+	// Values added after the values were sorted are found.
+	add("server-d")
+	if got, exp := values("server-c", &tsdb.ValueFilter{Runes: []rune{'-'}}), []string{"server-d", "web-b"}; !reflect.DeepEqual(got, exp) {
+		t.Fatalf("got %v, exp %v", got, exp)
+	}
+}
+
 // Ensure log file can recover correctly.
 func TestLogFile_Open(t *testing.T) {
 	t.Run("Truncate", func(t *testing.T) {
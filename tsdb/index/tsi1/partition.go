@@ -682,15 +682,22 @@ func (p *Partition) createSeriesListIfNotExists(names [][]byte, tagsSlice []mode
 	}
 	defer fs.Release()
 
+	// The series file does not delete series until they are in the index.
+	// It is held before the partition lock, so that it is never waited for
+	// while holding the partition.
+	added := p.sfile.AddingSeries()
+
 	// Ensure fileset cannot change during insert.
 	p.mu.RLock()
 	// Insert series into log file.
 	ids, err := p.activeLogFile.AddSeriesList(p.seriesIDSet, names, tagsSlice)
 	if err != nil {
 		p.mu.RUnlock()
+		added()
 		return nil, err
 	}
 	p.mu.RUnlock()
+	added()
 
 	if err := p.CheckLogFile(); err != nil {
 		return nil, err
@@ -736,6 +743,28 @@ func (p *Partition) SeriesSketches() (estimator.Sketch, estimator.Sketch, error)
 	return fs.SeriesSketches()
 }
 
+// MeasurementSeriesNs returns the number of series of every measurement in
+// the partition, as stored in its files.
+func (p *Partition) MeasurementSeriesNs() (map[string]int64, error) {
+	fs, err := p.RetainFileSet()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Release()
+	return fs.MeasurementSeriesNs(), nil
+}
+
+// TagValueSeriesNs returns the number of series of every value of a tag key in
+// the partition, as stored in its files.
+func (p *Partition) TagValueSeriesNs(name, key []byte) (map[string]int64, error) {
+	fs, err := p.RetainFileSet()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Release()
+	return fs.TagValueSeriesNs(name, key), nil
+}
+
 // HasTagKey returns true if tag key exists.
 func (p *Partition) HasTagKey(name, key []byte) (bool, error) {
 	fs, err := p.RetainFileSet()
@@ -786,6 +815,23 @@ func (p *Partition) TagValueIterator(name, key []byte) tsdb.TagValueIterator {
 	return newFileSetTagValueIterator(fs, NewTSDBTagValueIteratorAdapter(itr))
 }
 
+// TagValueIteratorFrom returns an iterator for the values of a single key
+// starting at the first value greater than or equal to seek, skipping values
+// not containing the trigrams and runes of filter.
+func (p *Partition) TagValueIteratorFrom(name, key, seek []byte, filter *tsdb.ValueFilter) tsdb.TagValueIterator {
+	fs, err := p.RetainFileSet()
+	if err != nil {
+		return nil
+	}
+
+	itr := fs.TagValueIteratorFrom(name, key, seek, filter)
+	if itr == nil {
+		fs.Release()
+		return nil
+	}
+	return newFileSetTagValueIterator(fs, NewTSDBTagValueIteratorAdapter(itr))
+}
+
 // TagKeySeriesIDIterator returns a series iterator for all values across a single key.
 func (p *Partition) TagKeySeriesIDIterator(name, key []byte) (tsdb.SeriesIDIterator, error) {
 	fs, err := p.RetainFileSet()
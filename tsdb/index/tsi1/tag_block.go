@@ -6,6 +6,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
+	"sync"
 
 	"github.com/influxdata/influxdb/v2/pkg/rhh"
 	"github.com/influxdata/influxdb/v2/tsdb"
@@ -51,6 +53,14 @@ type TagBlock struct {
 	hashData  []byte
 
 	version int // tag block version
+
+	// Offsets of the values of tag keys in sorted order and trigram indexes
+	// over them, built on first use by TagValueIteratorFrom.
+	sorted struct {
+		mu      sync.RWMutex
+		offsets map[string][]uint64
+		grams   map[string]*valueGrams
+	}
 }
 
 // Version returns the encoding version parsed from the data.
@@ -201,6 +211,91 @@ func (blk *TagBlock) TagKeyIterator() TagKeyIterator {
 	}
 }
 
+// TagValueIteratorFrom returns an iterator over the values of a tag key
+// starting at the first value greater than or equal to seek. If filter is set,
+// only the values containing its trigrams and runes are returned.
+func (blk *TagBlock) TagValueIteratorFrom(key, seek []byte, filter *tsdb.ValueFilter) TagValueIterator {
+	var ke TagBlockKeyElem
+	if !blk.DecodeTagKeyElem(key, &ke) {
+		return nil
+	} else if len(seek) == 0 && filter == nil {
+		return ke.TagValueIterator()
+	}
+
+	// Values are stored in sorted order, so the first value not before seek
+	// is found by a binary search over their offsets.
+	offsets := blk.sortedValueOffsets(&ke)
+	var e TagBlockValueElem
+	i := sort.Search(len(offsets), func(i int) bool {
+		e.unmarshal(blk.data[offsets[i]:])
+		return bytes.Compare(e.value, seek) >= 0
+	})
+	if filter != nil {
+		return &tagBlockValuePositionIterator{
+			data:      blk.data,
+			offsets:   offsets,
+			positions: blk.valueGrams(&ke, offsets).positions(filter, i),
+		}
+	} else if i == len(offsets) {
+		return &tagBlockValueIterator{}
+	}
+	return &tagBlockValueIterator{data: ke.data.buf[offsets[i]-ke.data.offset:]}
+}
+
+// valueGrams returns the trigram index over the sorted values of a tag key.
+// It is built once and cached.
+func (blk *TagBlock) valueGrams(ke *TagBlockKeyElem, offsets []uint64) *valueGrams {
+	blk.sorted.mu.RLock()
+	g := blk.sorted.grams[string(ke.key)]
+	blk.sorted.mu.RUnlock()
+	if g != nil {
+		return g
+	}
+
+	var e TagBlockValueElem
+	g = newValueGrams(len(offsets), func(i int) []byte {
+		e.unmarshal(blk.data[offsets[i]:])
+		return e.value
+	})
+
+	blk.sorted.mu.Lock()
+	if blk.sorted.grams == nil {
+		blk.sorted.grams = make(map[string]*valueGrams)
+	}
+	blk.sorted.grams[string(ke.key)] = g
+	blk.sorted.mu.Unlock()
+	return g
+}
+
+// sortedValueOffsets returns the offsets of the values of a tag key in
+// ascending order. They are read from the key's hash index once and cached.
+func (blk *TagBlock) sortedValueOffsets(ke *TagBlockKeyElem) []uint64 {
+	blk.sorted.mu.RLock()
+	offsets, ok := blk.sorted.offsets[string(ke.key)]
+	blk.sorted.mu.RUnlock()
+	if ok {
+		return offsets
+	}
+
+	hashData := ke.hashIndex.buf
+	valueN := int(binary.BigEndian.Uint64(hashData[:TagValueNSize]))
+	offsets = make([]uint64, 0, valueN)
+	for i := 0; i < valueN; i++ {
+		if offset := binary.BigEndian.Uint64(hashData[TagValueNSize+(i*TagValueOffsetSize):]); offset != 0 {
+			offsets = append(offsets, offset)
+		}
+	}
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+
+	blk.sorted.mu.Lock()
+	if blk.sorted.offsets == nil {
+		blk.sorted.offsets = make(map[string][]uint64)
+	}
+	blk.sorted.offsets[string(ke.key)] = offsets
+	blk.sorted.mu.Unlock()
+	return offsets
+}
+
 // tagBlockKeyIterator represents an iterator over all keys in a TagBlock.
 type tagBlockKeyIterator struct {
 	blk     *TagBlock
@@ -244,6 +339,25 @@ func (itr *tagBlockValueIterator) Next() TagValueElem {
 	return &itr.e
 }
 
+// tagBlockValuePositionIterator iterates over the values of a tag key at the
+// given positions of their sorted offsets.
+type tagBlockValuePositionIterator struct {
+	data      []byte
+	offsets   []uint64
+	positions []uint32
+	e         TagBlockValueElem
+}
+
+// Next returns the next element in the iterator.
+func (itr *tagBlockValuePositionIterator) Next() TagValueElem {
+	if len(itr.positions) == 0 {
+		return nil
+	}
+	itr.e.unmarshal(itr.data[itr.offsets[itr.positions[0]]:])
+	itr.positions = itr.positions[1:]
+	return &itr.e
+}
+
 // TagBlockKeyElem represents a tag key element in a TagBlock.
 type TagBlockKeyElem struct {
 	flag byte
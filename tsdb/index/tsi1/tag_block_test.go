@@ -86,6 +86,62 @@ func TestTagBlockWriter(t *testing.T) {
 	}
 }
 
+// Ensure tag values can be iterated from a seek value.
+func TestTagBlock_TagValueIteratorFrom(t *testing.T) {
+	var buf bytes.Buffer
+	enc := tsi1.NewTagBlockEncoder(&buf)
+	if err := enc.EncodeKey([]byte("host"), false); err != nil {
+		t.Fatal(err)
+	}
+	var all []string
+	for i := 0; i < 100; i++ {
+		all = append(all, fmt.Sprintf("server%02d", i))
+		if err := enc.EncodeValue([]byte(all[i]), false, tsdb.NewSeriesIDSet(uint64(i+1))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	var blk tsi1.TagBlock
+	if err := blk.UnmarshalBinary(buf.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	values := func(itr tsi1.TagValueIterator) []string {
+		var a []string
+		for e := itr.Next(); e != nil; e = itr.Next() {
+			a = append(a, string(e.Value()))
+		}
+		return a
+	}
+
+	for _, tt := range []struct {
+		seek   string
+		filter *tsdb.ValueFilter
+		exp    []string
+	}{
+		{seek: "a", exp: all},
+		{seek: "server42", exp: all[42:]},
+		{seek: "server965", exp: all[97:]},
+		{seek: "server990", exp: nil},
+		{seek: "", filter: &tsdb.ValueFilter{Trigrams: []string{"r42"}}, exp: all[42:43]},
+		{seek: "server95", filter: &tsdb.ValueFilter{Runes: []rune{'9'}}, exp: all[95:]},
+		{seek: "server5", filter: &tsdb.ValueFilter{Trigrams: []string{"ver"}, Runes: []rune{'s', '7'}}, exp: []string{"server57", "server67", "server70", "server71", "server72", "server73", "server74", "server75", "server76", "server77", "server78", "server79", "server87", "server97"}},
+		{seek: "", filter: &tsdb.ValueFilter{Trigrams: []string{"zzz"}}, exp: nil},
+	} {
+		got := values(blk.TagValueIteratorFrom([]byte("host"), []byte(tt.seek), tt.filter))
+		if !reflect.DeepEqual(got, tt.exp) {
+			t.Fatalf("seek %q: got %v, exp %v", tt.seek, got, tt.exp)
+		}
+	}
+
+	if itr := blk.TagValueIteratorFrom([]byte("region"), []byte("a"), nil); itr != nil {
+		t.Fatal("expected nil iterator for missing key")
+	}
+}
+
 var benchmarkTagBlock10x1000 *tsi1.TagBlock
 var benchmarkTagBlock100x1000 *tsi1.TagBlock
 var benchmarkTagBlock1000x1000 *tsi1.TagBlock
@@ -0,0 +1,109 @@
+package tsi1
+
+import (
+	"bytes"
+	"sort"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/influxdata/influxdb/v2/tsdb"
+)
+
+// valueGrams is a trigram index over a sorted list of tag values. It maps the
+// trigrams and runes of the lowered values to the ascending positions of the
+// values containing them, so that a search only reads the values which may
+// match its filter.
+type valueGrams struct {
+	n        int
+	trigrams map[string][]uint32
+	runes    map[rune][]uint32
+}
+
+// newValueGrams indexes the n values returned by value, in order.
+func newValueGrams(n int, value func(i int) []byte) *valueGrams {
+	g := &valueGrams{
+		n:        n,
+		trigrams: make(map[string][]uint32),
+		runes:    make(map[rune][]uint32),
+	}
+	for i := 0; i < n; i++ {
+		v := value(i)
+		pos := uint32(i)
+
+		lower := bytes.ToLower(v)
+		for j := 0; j+3 <= len(lower); j++ {
+			if a := g.trigrams[string(lower[j:j+3])]; len(a) == 0 || a[len(a)-1] != pos {
+				g.trigrams[string(lower[j:j+3])] = append(a, pos)
+			}
+		}
+
+		for len(v) > 0 {
+			r, size := utf8.DecodeRune(v)
+			v = v[size:]
+			r = unicode.ToLower(r)
+			if a := g.runes[r]; len(a) == 0 || a[len(a)-1] != pos {
+				g.runes[r] = append(a, pos)
+			}
+		}
+	}
+	return g
+}
+
+// positions returns the ascending positions, from from onward, of the values
+// which contain every trigram and rune of filter.
+func (g *valueGrams) positions(filter *tsdb.ValueFilter, from int) []uint32 {
+	lists := make([][]uint32, 0, len(filter.Trigrams)+len(filter.Runes))
+	for _, t := range filter.Trigrams {
+		a, ok := g.trigrams[t]
+		if !ok {
+			return nil
+		}
+		lists = append(lists, a)
+	}
+	for _, r := range filter.Runes {
+		a, ok := g.runes[r]
+		if !ok {
+			return nil
+		}
+		lists = append(lists, a)
+	}
+
+	if len(lists) == 0 {
+		a := make([]uint32, 0, g.n-from)
+		for i := from; i < g.n; i++ {
+			a = append(a, uint32(i))
+		}
+		return a
+	}
+
+	// Intersect starting with the shortest list, so every step is bounded
+	// by the number of values left.
+	sort.Slice(lists, func(i, j int) bool { return len(lists[i]) < len(lists[j]) })
+	a := lists[0]
+	a = a[sort.Search(len(a), func(i int) bool { return a[i] >= uint32(from) }):]
+	for _, b := range lists[1:] {
+		if len(a) == 0 {
+			break
+		}
+		a = intersectPositions(a, b)
+	}
+	return a
+}
+
+// intersectPositions returns the positions found in both a and b, which must
+// be in ascending order. The result is newly allocated.
+func intersectPositions(a, b []uint32) []uint32 {
+	var c []uint32
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			c = append(c, a[0])
+			a, b = a[1:], b[1:]
+		}
+	}
+	return c
+}
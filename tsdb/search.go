@@ -0,0 +1,286 @@
+package tsdb
+
+import (
+	"bytes"
+	"context"
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Search selects the measurement names or tag values returned by a search.
+// Values are returned in ascending order and must match every filter set.
+type Search struct {
+	// Prefix matches values starting with it.
+	Prefix string
+
+	// Regex matches values it matches.
+	Regex *regexp.Regexp
+
+	// Fuzzy matches values containing its characters in order, ignoring
+	// case.
+	Fuzzy string
+
+	// After skips values up to and including it, so results can be paged
+	// through by passing the last value of a page.
+	After string
+
+	// Limit bounds the number of values returned.
+	Limit int
+}
+
+// seek returns the first value that can match s.
+func (s *Search) seek() []byte {
+	seek := []byte(s.Prefix)
+	if s.After != "" {
+		// The smallest value sorted after After.
+		if after := append([]byte(s.After), 0); bytes.Compare(after, seek) > 0 {
+			seek = after
+		}
+	}
+	return seek
+}
+
+// ValueFilter holds what every value matching a search contains, so that an
+// index keeping trigrams of its values can skip those which cannot match.
+// Values are compared after lowering them with bytes.ToLower.
+type ValueFilter struct {
+	// Trigrams are the three-byte sequences of the lowered value.
+	Trigrams []string
+
+	// Runes are the runes of the value, lowered with unicode.ToLower.
+	Runes []rune
+}
+
+// filter returns the filter of s, or nil if every value may match.
+func (s *Search) filter() *ValueFilter {
+	var f ValueFilter
+	if s.Regex != nil {
+		f.Trigrams = regexTrigrams(s.Regex)
+	}
+
+	seen := make(map[rune]struct{})
+	for _, r := range s.Fuzzy {
+		r = unicode.ToLower(r)
+		if _, ok := seen[r]; !ok {
+			seen[r] = struct{}{}
+			f.Runes = append(f.Runes, r)
+		}
+	}
+
+	if len(f.Trigrams) == 0 && len(f.Runes) == 0 {
+		return nil
+	}
+	return &f
+}
+
+// regexTrigrams returns the lowered trigrams of the literals every value
+// matched by re contains.
+func regexTrigrams(re *regexp.Regexp) []string {
+	parsed, err := syntax.Parse(re.String(), syntax.Perl)
+	if err != nil {
+		return nil
+	}
+
+	var trigrams []string
+	seen := make(map[string]struct{})
+	for _, lit := range requiredLiterals(parsed.Simplify()) {
+		lit = strings.ToLower(lit)
+		for i := 0; i+3 <= len(lit); i++ {
+			if _, ok := seen[lit[i:i+3]]; !ok {
+				seen[lit[i:i+3]] = struct{}{}
+				trigrams = append(trigrams, lit[i:i+3])
+			}
+		}
+	}
+	return trigrams
+}
+
+// requiredLiterals returns strings that any text matched by re contains.
+// Case-insensitive literals and alternations are not required.
+func requiredLiterals(re *syntax.Regexp) []string {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if re.Flags&syntax.FoldCase != 0 {
+			return nil
+		}
+		return []string{string(re.Rune)}
+	case syntax.OpCapture, syntax.OpPlus:
+		return requiredLiterals(re.Sub[0])
+	case syntax.OpRepeat:
+		if re.Min > 0 {
+			return requiredLiterals(re.Sub[0])
+		}
+	case syntax.OpConcat:
+		// Adjacent literals are joined, as they are matched contiguously.
+		var lits []string
+		var run strings.Builder
+		for _, sub := range re.Sub {
+			if sub.Op == syntax.OpLiteral && sub.Flags&syntax.FoldCase == 0 {
+				run.WriteString(string(sub.Rune))
+				continue
+			}
+			if run.Len() > 0 {
+				lits = append(lits, run.String())
+				run.Reset()
+			}
+			lits = append(lits, requiredLiterals(sub)...)
+		}
+		if run.Len() > 0 {
+			lits = append(lits, run.String())
+		}
+		return lits
+	}
+	return nil
+}
+
+func (s *Search) match(value []byte) bool {
+	if s.Regex != nil && !s.Regex.Match(value) {
+		return false
+	}
+	return s.Fuzzy == "" || fuzzyMatch(s.Fuzzy, value)
+}
+
+// fuzzyMatch returns true if value contains the characters of pattern in
+// order, ignoring case.
+func fuzzyMatch(pattern string, value []byte) bool {
+	for _, pr := range pattern {
+		pr = unicode.ToLower(pr)
+		for {
+			if len(value) == 0 {
+				return false
+			}
+			vr, size := utf8.DecodeRune(value)
+			value = value[size:]
+			if unicode.ToLower(vr) == pr {
+				break
+			}
+		}
+	}
+	return true
+}
+
+// collect reads the values of itr matching s. It returns true if more values
+// match than the limit.
+func (s *Search) collect(ctx context.Context, itr TagValueIterator) ([]string, bool, error) {
+	seek, prefix := s.seek(), []byte(s.Prefix)
+	var values []string
+	for i := 0; ; i++ {
+		if i%1000 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, false, err
+			}
+		}
+
+		value, err := itr.Next()
+		if err != nil {
+			return nil, false, err
+		} else if value == nil || !bytes.HasPrefix(value, prefix) && bytes.Compare(value, prefix) > 0 {
+			return values, false, nil
+		} else if bytes.Compare(value, seek) < 0 || !bytes.HasPrefix(value, prefix) || !s.match(value) {
+			continue
+		}
+
+		if len(values) == s.Limit {
+			return values, true, nil
+		}
+		values = append(values, string(value))
+	}
+}
+
+// SearchTagValues returns the values of a tag key in the shards matching
+// search, in ascending order. The values of a single measurement are searched
+// if name is set. It returns true if there are more matching values than the
+// search's limit.
+//
+// Values are looked up by seeking into the sorted values of each index, so
+// only the values from the search's prefix onward are read. Regex and fuzzy
+// searches only read the values containing the trigrams of the regex's
+// literals and the fuzzy pattern's characters.
+func (s *Store) SearchTagValues(ctx context.Context, shardIDs []uint64, name, key []byte, search Search) ([]string, bool, error) {
+	indexes, err := s.shardIndexes(shardIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	filter := search.filter()
+	var itrs []TagValueIterator
+	defer func() { TagValueIterators(itrs).Close() }()
+	for _, idx := range indexes {
+		names := [][]byte{name}
+		if name == nil {
+			if names, err = measurementNames(idx); err != nil {
+				return nil, false, err
+			}
+		}
+
+		for _, name := range names {
+			itr, err := idx.TagValueIteratorFrom(name, key, search.seek(), filter)
+			if err != nil {
+				return nil, false, err
+			} else if itr != nil {
+				itrs = append(itrs, itr)
+			}
+		}
+	}
+
+	itr := MergeTagValueIterators(itrs...)
+	if itr == nil {
+		return nil, false, nil
+	}
+	return search.collect(ctx, itr)
+}
+
+// SearchMeasurementNames returns the names of the measurements in the shards
+// matching search, in ascending order. It returns true if there are more
+// matching names than the search's limit.
+func (s *Store) SearchMeasurementNames(ctx context.Context, shardIDs []uint64, search Search) ([]string, bool, error) {
+	indexes, err := s.shardIndexes(shardIDs)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var itrs []MeasurementIterator
+	defer func() { MeasurementIterators(itrs).Close() }()
+	for _, idx := range indexes {
+		itr, err := idx.MeasurementIterator()
+		if err != nil {
+			return nil, false, err
+		} else if itr != nil {
+			itrs = append(itrs, itr)
+		}
+	}
+
+	itr := MergeMeasurementIterators(itrs...)
+	if itr == nil {
+		return nil, false, nil
+	}
+	return search.collect(ctx, itr)
+}
+
+func (s *Store) shardIndexes(shardIDs []uint64) ([]Index, error) {
+	shards := s.Shards(shardIDs)
+	indexes := make([]Index, 0, len(shards))
+	for _, sh := range shards {
+		idx, err := sh.Index()
+		if err == ErrEngineClosed || err == ErrShardDisabled {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, idx)
+	}
+	return indexes, nil
+}
+
+// measurementNames returns the names of the measurements of idx.
+func measurementNames(idx Index) ([][]byte, error) {
+	var names [][]byte
+	err := idx.ForEachMeasurementName(func(name []byte) error {
+		names = append(names, append([]byte(nil), name...))
+		return nil
+	})
+	return names, err
+}
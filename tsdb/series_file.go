@@ -41,6 +41,12 @@ type SeriesFile struct {
 
 	refs sync.RWMutex // RWMutex to track references to the SeriesFile that are in use.
 
+	// adding is held for reading while indexes add series, from the creation
+	// of their ids until they are in the index, and for writing while
+	// DeleteSeriesIDsNotIn deletes series, so that it never deletes a series
+	// being added to an index.
+	adding sync.RWMutex
+
 	Logger *zap.Logger
 }
 
@@ -119,6 +125,32 @@ func (f *SeriesFile) Close() (err error) {
 	return f.close()
 }
 
+// DeletedSeries returns the number and size of the entries of deleted series
+// that CompactSegments would remove.
+func (f *SeriesFile) DeletedSeries() (n int, size int64) {
+	for _, p := range f.partitions {
+		pn, psize := p.DeletedSeries()
+		n, size = n+pn, size+psize
+	}
+	return n, size
+}
+
+// CompactSegments rewrites the segments of each partition without the entries
+// of deleted series and rebuilds the partitions' indexes. It returns the
+// number of bytes reclaimed. Partitions whose index is being compacted are
+// left as they are.
+func (f *SeriesFile) CompactSegments() (int64, error) {
+	var reclaimed int64
+	for _, p := range f.partitions {
+		n, err := p.CompactSegments()
+		reclaimed += n
+		if err != nil {
+			return reclaimed, err
+		}
+	}
+	return reclaimed, nil
+}
+
 // Path returns the path to the file.
 func (f *SeriesFile) Path() string { return f.path }
 
@@ -203,6 +235,51 @@ func (f *SeriesFile) DeleteSeriesID(id uint64) error {
 	return p.DeleteSeriesID(id)
 }
 
+// AddingSeries blocks DeleteSeriesIDsNotIn until the returned function is
+// called. Indexes call it before creating the ids of the series they add, and
+// the returned function once the series are in the index.
+func (f *SeriesFile) AddingSeries() func() {
+	f.adding.RLock()
+	return f.adding.RUnlock
+}
+
+// DeleteSeriesIDsNotIn flags the series of ids which are in none of the sets
+// returned by sets as permanently deleted, and returns their number. Series
+// are not added to indexes meanwhile: sets is called once the series being
+// added are in their index.
+func (f *SeriesFile) DeleteSeriesIDsNotIn(ids *SeriesIDSet, sets func() []*SeriesIDSet) (int, error) {
+	f.adding.Lock()
+	defer f.adding.Unlock()
+
+	in := sets()
+	var (
+		n   int
+		err error
+	)
+	ids.ForEach(func(id uint64) {
+		if err != nil {
+			return
+		}
+		for _, set := range in {
+			if set.Contains(id) {
+				return
+			}
+		}
+		if err = f.DeleteSeriesID(id); err == nil {
+			n++
+		}
+	})
+	return n, err
+}
+
+// ForEachSeriesID calls fn with the id of every series of the file which is
+// not deleted.
+func (f *SeriesFile) ForEachSeriesID(fn func(id uint64)) {
+	for _, p := range f.partitions {
+		p.ForEachSeriesID(fn)
+	}
+}
+
 // IsDeleted returns true if the ID has been deleted before.
 func (f *SeriesFile) IsDeleted(id uint64) bool {
 	p := f.SeriesIDPartition(id)
@@ -258,6 +258,109 @@ func TestSeriesFile_Compaction(t *testing.T) {
 	require.Equal(t, 900, int(sfile.SeriesCount()))
 }
 
+func TestSeriesFile_DeleteSeriesIDsNotIn(t *testing.T) {
+	sfile := MustOpenSeriesFile(t)
+	defer sfile.Close()
+
+	ids, err := sfile.CreateSeriesListIfNotExists(
+		[][]byte{[]byte("cpu"), []byte("cpu"), []byte("cpu")},
+		[]models.Tags{
+			models.NewTags(map[string]string{"host": "a"}),
+			models.NewTags(map[string]string{"host": "b"}),
+			models.NewTags(map[string]string{"host": "c"}),
+		})
+	require.NoError(t, err)
+
+	// Series in a set are kept, even if they are to be deleted.
+	del := tsdb.NewSeriesIDSet(ids[0], ids[1])
+	in := tsdb.NewSeriesIDSet(ids[1])
+	n, err := sfile.DeleteSeriesIDsNotIn(del, func() []*tsdb.SeriesIDSet { return []*tsdb.SeriesIDSet{in} })
+	require.NoError(t, err)
+	require.Equal(t, 1, n)
+	require.True(t, sfile.IsDeleted(ids[0]))
+	require.False(t, sfile.IsDeleted(ids[1]))
+
+	var live []uint64
+	sfile.ForEachSeriesID(func(id uint64) { live = append(live, id) })
+	require.ElementsMatch(t, []uint64{ids[1], ids[2]}, live)
+}
+
+func TestSeriesFile_CompactSegments(t *testing.T) {
+	sfile := MustOpenSeriesFile(t)
+	defer sfile.Close()
+
+	var mms [][]byte
+	var tagSets []models.Tags
+	for i := 0; i < 1000; i++ {
+		mms = append(mms, []byte("cpu"))
+		tagSets = append(tagSets, models.NewTags(map[string]string{"region": fmt.Sprintf("r%d", i)}))
+	}
+	ids, err := sfile.CreateSeriesListIfNotExists(mms, tagSets)
+	require.NoError(t, err)
+
+	// Delete a subset of keys, including the most recent ones.
+	var maxID uint64
+	partitionMaxIDs := make(map[int]uint64)
+	for i, id := range ids {
+		if i%10 == 0 || i >= 990 {
+			require.NoError(t, sfile.DeleteSeriesID(id))
+		}
+		if id > maxID {
+			maxID = id
+		}
+		if p := sfile.SeriesIDPartitionID(id); id > partitionMaxIDs[p] {
+			partitionMaxIDs[p] = id
+		}
+	}
+
+	// The entry of each partition's most recent series is kept to preserve
+	// its id sequence, and still counts towards the number of series.
+	seriesN := 891
+	for _, id := range partitionMaxIDs {
+		if sfile.IsDeleted(id) {
+			seriesN++
+		}
+	}
+
+	n, size := sfile.DeletedSeries()
+	require.Greater(t, n, 100)
+	require.Greater(t, size, int64(0))
+
+	// Series keys read before the segments are compacted remain valid.
+	key := sfile.SeriesKey(ids[1])
+	want := append([]byte(nil), key...)
+
+	reclaimed, err := sfile.CompactSegments()
+	require.NoError(t, err)
+	require.Greater(t, reclaimed, int64(0))
+	require.Equal(t, want, key)
+	require.Equal(t, want, sfile.SeriesKey(ids[1]))
+
+	n, _ = sfile.DeletedSeries()
+	require.Zero(t, n)
+
+	check := func() {
+		t.Helper()
+		for i, id := range ids {
+			deleted := i%10 == 0 || i >= 990
+			require.Equal(t, deleted, sfile.IsDeleted(id))
+			if !deleted {
+				require.Equal(t, id, sfile.SeriesID(mms[i], tagSets[i], nil))
+			}
+		}
+		require.Equal(t, seriesN, int(sfile.SeriesCount()))
+	}
+	check()
+
+	// Deleted series ids are not reassigned once the file is reopened.
+	require.NoError(t, sfile.Reopen())
+	check()
+
+	newIDs, err := sfile.CreateSeriesListIfNotExists([][]byte{[]byte("cpu")}, []models.Tags{tagSets[995]})
+	require.NoError(t, err)
+	require.Greater(t, newIDs[0], maxID)
+}
+
 var cachedCompactionSeriesFile *SeriesFile
 
 func BenchmarkSeriesFile_Compaction(b *testing.B) {
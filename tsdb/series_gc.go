@@ -0,0 +1,345 @@
+package tsdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/logger"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+// seriesGC holds the series found without data by the previous pass of the
+// series garbage collector. A series is only removed once two consecutive
+// passes find it without data, so series whose first points are still being
+// written are left alone.
+type seriesGC struct {
+	mu          sync.Mutex
+	shardSeries map[uint64]*SeriesIDSet // series without data, by shard
+	fileSeries  map[string]*SeriesIDSet // series file series in no shard, by database
+}
+
+func newSeriesGC() *seriesGC {
+	return &seriesGC{
+		shardSeries: make(map[uint64]*SeriesIDSet),
+		fileSeries:  make(map[string]*SeriesIDSet),
+	}
+}
+
+// SeriesGCStats summarizes a pass of the series garbage collector over a
+// database.
+type SeriesGCStats struct {
+	// IndexSeriesDropped is the number of series removed from shard indexes.
+	IndexSeriesDropped int
+
+	// SeriesFileSeriesDropped is the number of series removed from the series
+	// file because no shard holds them.
+	SeriesFileSeriesDropped int
+
+	// PendingSeries is the number of series found without data that are
+	// removed by the next pass unless they are written to.
+	PendingSeries int
+}
+
+// CollectSeries runs a pass of the series garbage collector over a database.
+// Series in a shard's index that have had no data in two consecutive passes
+// are removed from the index, and series in the series file that have been in
+// no shard in two consecutive passes are removed from the series file.
+func (s *Store) CollectSeries(ctx context.Context, database string) (SeriesGCStats, error) {
+	var stats SeriesGCStats
+
+	s.mu.RLock()
+	shards := s.filterShards(byDatabase(database))
+	sfile := s.sfiles[database]
+	unloaded, err := s.unloadedShards(database)
+	s.mu.RUnlock()
+	if err != nil {
+		return stats, err
+	} else if sfile == nil {
+		return stats, nil
+	}
+
+	s.seriesGC.mu.Lock()
+	defer s.seriesGC.mu.Unlock()
+
+	for _, sh := range shards {
+		if err := ctx.Err(); err != nil {
+			return stats, err
+		}
+
+		dead, err := sh.SeriesWithoutData(ctx)
+		if err == ErrEngineClosed || err == ErrShardDisabled {
+			continue
+		} else if err != nil {
+			return stats, err
+		}
+
+		if prev := s.seriesGC.shardSeries[sh.ID()]; prev != nil {
+			confirmed := dead.And(prev)
+			n, err := sh.DropSeriesWithoutData(ctx, confirmed)
+			if err != nil {
+				return stats, err
+			}
+			stats.IndexSeriesDropped += n
+			dead = dead.AndNot(confirmed)
+		}
+		s.seriesGC.shardSeries[sh.ID()] = dead
+		stats.PendingSeries += int(dead.Cardinality())
+	}
+
+	// Series are only removed from the series file if every shard of the
+	// database could be checked: the series of a shard which failed to load
+	// or is not open would otherwise be taken for orphans.
+	indexes := make([]Index, 0, len(shards))
+	for _, sh := range shards {
+		idx, err := sh.Index()
+		if err == ErrEngineClosed || err == ErrShardDisabled {
+			unloaded = append(unloaded, sh.ID())
+			continue
+		} else if err != nil {
+			return stats, err
+		}
+		indexes = append(indexes, idx)
+	}
+	if len(unloaded) > 0 {
+		s.Logger.Info("Skipping series file garbage collection of database with unloaded shards",
+			logger.Database(database),
+			zap.Uint64s("shards", unloaded))
+		delete(s.seriesGC.fileSeries, database)
+		return stats, nil
+	}
+	sets := func() []*SeriesIDSet {
+		sets := make([]*SeriesIDSet, 0, len(indexes))
+		for _, idx := range indexes {
+			sets = append(sets, idx.SeriesIDSet())
+		}
+		return sets
+	}
+
+	// Only the series in no shard are collected, rather than every series
+	// of the file.
+	in := sets()
+	orphans := NewSeriesIDSet()
+	sfile.ForEachSeriesID(func(id uint64) {
+		for _, set := range in {
+			if set.Contains(id) {
+				return
+			}
+		}
+		orphans.AddNoLock(id)
+	})
+
+	if prev := s.seriesGC.fileSeries[database]; prev != nil {
+		// The series may have been written to a shard since, which the
+		// series file checks again once no series are being added.
+		confirmed := orphans.And(prev)
+		n, err := sfile.DeleteSeriesIDsNotIn(confirmed, sets)
+		stats.SeriesFileSeriesDropped += n
+		if err != nil {
+			return stats, err
+		}
+		orphans = orphans.AndNot(confirmed)
+	}
+	s.seriesGC.fileSeries[database] = orphans
+	stats.PendingSeries += int(orphans.Cardinality())
+
+	// The entries of the deleted series are removed from the segments of
+	// the series file right away, rather than when it is next opened.
+	if stats.SeriesFileSeriesDropped > 0 {
+		if err := s.compactSeriesFile(database, sfile); err != nil {
+			return stats, err
+		}
+	}
+
+	return stats, nil
+}
+
+// unloadedShards returns the shards of a database found on disk which are not
+// loaded, such as those which failed to open. s.mu must be held, so that the
+// shards being created are found loaded.
+func (s *Store) unloadedShards(database string) ([]uint64, error) {
+	rps, err := os.ReadDir(filepath.Join(s.path, database))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var unloaded []uint64
+	for _, rp := range rps {
+		if !rp.IsDir() || rp.Name() == SeriesFileDirectory {
+			continue
+		}
+		dirs, err := os.ReadDir(filepath.Join(s.path, database, rp.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, dir := range dirs {
+			id, err := strconv.ParseUint(dir.Name(), 10, 64)
+			if err != nil || !dir.IsDir() {
+				continue
+			}
+			if _, ok := s.shards[id]; ok {
+				continue
+			} else if _, ok := s.pendingShardDeletes[id]; ok {
+				continue
+			}
+			unloaded = append(unloaded, id)
+		}
+	}
+	return unloaded, nil
+}
+
+// forgetSeriesGC discards the garbage collector's state for a database.
+func (s *Store) forgetSeriesGC(database string, shardIDs ...uint64) {
+	s.seriesGC.mu.Lock()
+	defer s.seriesGC.mu.Unlock()
+	if database != "" {
+		delete(s.seriesGC.fileSeries, database)
+	}
+	for _, id := range shardIDs {
+		delete(s.seriesGC.shardSeries, id)
+	}
+}
+
+// collectSeries runs a pass of the series garbage collector over every
+// database at interval until the store is closed.
+func (s *Store) collectSeries(interval time.Duration) {
+	defer s.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-t.C:
+			start := time.Now()
+			for _, database := range s.Databases() {
+				log := s.Logger.With(logger.Database(database))
+				stats, err := s.CollectSeries(ctx, database)
+				if ctx.Err() != nil {
+					return
+				} else if err != nil {
+					log.Info("Series garbage collection failed", zap.Error(err))
+					globalSeriesGCMetrics.errors.Inc()
+				}
+
+				labels := prometheus.Labels{bucketLabel: database}
+				globalSeriesGCMetrics.indexSeriesDropped.With(labels).Add(float64(stats.IndexSeriesDropped))
+				globalSeriesGCMetrics.seriesFileSeriesDropped.With(labels).Add(float64(stats.SeriesFileSeriesDropped))
+				globalSeriesGCMetrics.pendingSeries.With(labels).Set(float64(stats.PendingSeries))
+				if stats.IndexSeriesDropped > 0 || stats.SeriesFileSeriesDropped > 0 {
+					log.Info("Collected series",
+						zap.Int("index_series_dropped", stats.IndexSeriesDropped),
+						zap.Int("series_file_series_dropped", stats.SeriesFileSeriesDropped),
+						zap.Int("pending_series", stats.PendingSeries))
+				}
+			}
+			globalSeriesGCMetrics.passes.Inc()
+			globalSeriesGCMetrics.lastPassDuration.Set(time.Since(start).Seconds())
+		}
+	}
+}
+
+// compactSeriesFile rewrites the segments of a series file without the series
+// removed by the garbage collector.
+func (s *Store) compactSeriesFile(database string, sfile *SeriesFile) error {
+	n, err := sfile.CompactSegments()
+	if n > 0 {
+		s.Logger.Info("Compacted series file segments",
+			logger.Database(database),
+			zap.Int64("bytes_reclaimed", n))
+		globalSeriesGCMetrics.segmentBytesReclaimed.With(prometheus.Labels{bucketLabel: database}).Add(float64(n))
+	}
+	return err
+}
+
+var globalSeriesGCMetrics = newSeriesGCMetrics()
+
+const seriesGCSubsystem = "series_gc"
+
+type seriesGCMetrics struct {
+	passes                  prometheus.Counter
+	errors                  prometheus.Counter
+	lastPassDuration        prometheus.Gauge
+	indexSeriesDropped      *prometheus.CounterVec
+	seriesFileSeriesDropped *prometheus.CounterVec
+	pendingSeries           *prometheus.GaugeVec
+	segmentBytesReclaimed   *prometheus.CounterVec
+}
+
+func newSeriesGCMetrics() *seriesGCMetrics {
+	labels := []string{bucketLabel}
+	return &seriesGCMetrics{
+		passes: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: seriesGCSubsystem,
+			Name:      "passes_total",
+			Help:      "Number of passes of the series garbage collector",
+		}),
+		errors: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: seriesGCSubsystem,
+			Name:      "errors_total",
+			Help:      "Number of buckets the series garbage collector failed to collect",
+		}),
+		lastPassDuration: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: storageNamespace,
+			Subsystem: seriesGCSubsystem,
+			Name:      "last_pass_duration_seconds",
+			Help:      "Duration of the last pass of the series garbage collector",
+		}),
+		indexSeriesDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: seriesGCSubsystem,
+			Name:      "index_series_dropped_total",
+			Help:      "Number of series without data removed from shard indexes per bucket",
+		}, labels),
+		seriesFileSeriesDropped: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: seriesGCSubsystem,
+			Name:      "series_file_series_dropped_total",
+			Help:      "Number of series in no shard removed from the series file per bucket",
+		}, labels),
+		pendingSeries: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: storageNamespace,
+			Subsystem: seriesGCSubsystem,
+			Name:      "pending_series",
+			Help:      "Number of series without data to be removed by the next pass per bucket",
+		}, labels),
+		segmentBytesReclaimed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: storageNamespace,
+			Subsystem: seriesGCSubsystem,
+			Name:      "segment_bytes_reclaimed_total",
+			Help:      "Number of bytes reclaimed by compacting series file segments per bucket",
+		}, labels),
+	}
+}
+
+// SeriesGCCollectors returns the metrics of the series garbage collector.
+func SeriesGCCollectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		globalSeriesGCMetrics.passes,
+		globalSeriesGCMetrics.errors,
+		globalSeriesGCMetrics.lastPassDuration,
+		globalSeriesGCMetrics.indexSeriesDropped,
+		globalSeriesGCMetrics.seriesFileSeriesDropped,
+		globalSeriesGCMetrics.pendingSeries,
+		globalSeriesGCMetrics.segmentBytesReclaimed,
+	}
+}
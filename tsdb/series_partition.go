@@ -11,7 +11,9 @@ import (
 
 	"github.com/influxdata/influxdb/v2/logger"
 	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/file"
 	"github.com/influxdata/influxdb/v2/pkg/limiter"
+	"github.com/influxdata/influxdb/v2/pkg/mmap"
 	"github.com/influxdata/influxdb/v2/pkg/rhh"
 	"go.uber.org/zap"
 )
@@ -40,6 +42,11 @@ type SeriesPartition struct {
 	index    *SeriesIndex
 	seq      uint64 // series id sequence
 
+	// retired holds the memory maps of the segments replaced by
+	// CompactSegments, which are unmapped once the partition is closed as
+	// series keys read before may still refer to them.
+	retired [][]byte
+
 	compacting          bool
 	compactionLimiter   limiter.Fixed
 	compactionsDisabled int
@@ -157,6 +164,13 @@ func (p *SeriesPartition) Close() (err error) {
 	}
 	p.segments = nil
 
+	for _, data := range p.retired {
+		if e := mmap.Unmap(data); e != nil && err == nil {
+			err = e
+		}
+	}
+	p.retired = nil
+
 	if p.index != nil {
 		if e := p.index.Close(); e != nil && err == nil {
 			err = e
@@ -434,6 +448,165 @@ func (p *SeriesPartition) AppendSeriesIDs(a []uint64) []uint64 {
 	return a
 }
 
+// ForEachSeriesID calls fn with the id of every series of the partition which
+// is not deleted.
+func (p *SeriesPartition) ForEachSeriesID(fn func(id uint64)) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return
+	}
+
+	for _, segment := range p.segments {
+		segment.ForEachEntry(func(flag uint8, id uint64, _ int64, _ []byte) error {
+			if flag == SeriesEntryInsertFlag && !p.index.IsDeleted(id) {
+				fn(id)
+			}
+			return nil
+		})
+	}
+}
+
+// DeletedSeries returns the number and size of the entries of deleted series
+// that CompactSegments would remove.
+func (p *SeriesPartition) DeletedSeries() (n int, size int64) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return 0, 0
+	}
+
+	keep := p.keepSegmentEntry()
+	for _, segment := range p.segments {
+		segment.ForEachEntry(func(flag uint8, id uint64, _ int64, key []byte) error {
+			if ok, _ := keep(flag, id); !ok {
+				if flag == SeriesEntryInsertFlag {
+					n++
+				}
+				size += int64(len(AppendSeriesEntry(nil, flag, id, key)))
+			}
+			return nil
+		})
+	}
+	return n, size
+}
+
+// CompactSegments rewrites the partition's segments without the entries of
+// deleted series and rebuilds its index. It returns the number of bytes
+// reclaimed. A partition whose index is being compacted is left as it is.
+func (p *SeriesPartition) CompactSegments() (int64, error) {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		return 0, ErrSeriesPartitionClosed
+	} else if p.compacting {
+		p.mu.Unlock()
+		return 0, nil
+	}
+	reclaimed, err := p.compactSegments()
+	if err != nil || reclaimed == 0 {
+		p.mu.Unlock()
+		return reclaimed, err
+	}
+	p.compacting = true
+	p.mu.Unlock()
+
+	// Persist the rebuilt index rather than holding it in memory.
+	err = NewSeriesPartitionCompactor().Compact(p)
+
+	p.mu.Lock()
+	p.compacting = false
+	p.mu.Unlock()
+	return reclaimed, err
+}
+
+// compactSegments rewrites the partition's segments without the entries of
+// deleted series and rebuilds its index in memory. p.mu must be held.
+//
+// Series keys read from the partition refer to the segments' memory maps, so
+// the maps of the replaced segments are retired rather than unmapped.
+func (p *SeriesPartition) compactSegments() (int64, error) {
+	// Write the compacted segments next to the current ones first.
+	const tmpExt = ".compacting"
+	keep := p.keepSegmentEntry()
+	var (
+		segments  []*SeriesSegment
+		reclaimed int64
+	)
+	for _, segment := range p.segments {
+		var n int64
+		segment.ForEachEntry(func(flag uint8, id uint64, _ int64, key []byte) error {
+			if ok, _ := keep(flag, id); !ok {
+				n += int64(len(AppendSeriesEntry(nil, flag, id, key)))
+			}
+			return nil
+		})
+		if n == 0 {
+			continue
+		}
+
+		if err := segment.compactToPath(segment.Path()+tmpExt, keep); err != nil {
+			for _, segment := range segments {
+				os.Remove(segment.Path() + tmpExt)
+			}
+			return 0, err
+		}
+		segments = append(segments, segment)
+		reclaimed += n
+	}
+	if len(segments) == 0 {
+		return 0, nil
+	}
+
+	// The offsets in the index no longer match the compacted segments, so it
+	// is removed before they are swapped in and is then rebuilt from them.
+	if err := p.index.Close(); err != nil {
+		return 0, err
+	} else if err := os.Remove(p.IndexPath()); err != nil && !os.IsNotExist(err) {
+		return 0, err
+	}
+
+	for _, segment := range segments {
+		// Compacted segments are extended back to their full size, as they
+		// are memory mapped at that size.
+		if err := segment.CloseForWrite(); err != nil {
+			return 0, err
+		}
+		p.retired, segment.data = append(p.retired, segment.data), nil
+		if err := os.Truncate(segment.Path()+tmpExt, int64(SeriesSegmentSize(segment.ID()))); err != nil {
+			return 0, err
+		} else if err := file.RenameFile(segment.Path()+tmpExt, segment.Path()); err != nil {
+			return 0, err
+		} else if err := segment.Open(); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := p.activeSegment().InitForWrite(); err != nil {
+		return reclaimed, err
+	}
+
+	p.index = NewSeriesIndex(p.IndexPath())
+	if err := p.index.Open(); err != nil {
+		return reclaimed, err
+	}
+	return reclaimed, p.index.Recover(p.segments)
+}
+
+// keepSegmentEntry returns whether a segment entry is kept when the segment is
+// compacted. The entries of deleted series are dropped, except for those of
+// the most recently assigned series id so the id sequence survives reopening
+// the partition.
+func (p *SeriesPartition) keepSegmentEntry() func(flag uint8, id uint64) (bool, error) {
+	var maxID uint64
+	if p.seq > SeriesFilePartitionN {
+		maxID = p.seq - SeriesFilePartitionN
+	}
+	return func(flag uint8, id uint64) (bool, error) {
+		return id == maxID || !p.index.IsDeleted(id), nil
+	}
+}
+
 // activeSegment returns the last segment.
 func (p *SeriesPartition) activeSegment() *SeriesSegment {
 	if len(p.segments) == 0 {
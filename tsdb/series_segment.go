@@ -270,6 +270,19 @@ func (s *SeriesSegment) Clone() *SeriesSegment {
 
 // CompactToPath rewrites the segment to a new file and removes tombstoned entries.
 func (s *SeriesSegment) CompactToPath(path string, index *SeriesIndex) error {
+	return s.compactToPath(path, func(flag uint8, id uint64) (bool, error) {
+		if index.IsDeleted(id) {
+			return false, nil // series id has been deleted from index
+		} else if flag == SeriesEntryTombstoneFlag {
+			return false, fmt.Errorf("[series id %d]: tombstone entry but exists in index", id)
+		}
+		return true, nil
+	})
+}
+
+// compactToPath rewrites the segment to a new file with only the entries for
+// which keep returns true.
+func (s *SeriesSegment) compactToPath(path string, keep func(flag uint8, id uint64) (bool, error)) error {
 	dst, err := CreateSeriesSegment(s.id, path)
 	if err != nil {
 		return err
@@ -281,13 +294,11 @@ func (s *SeriesSegment) CompactToPath(path string, index *SeriesIndex) error {
 	}
 
 	// Iterate through the segment and write any entries to a new segment
-	// that exist in the index.
+	// that are kept.
 	var buf []byte
 	if err = s.ForEachEntry(func(flag uint8, id uint64, _ int64, key []byte) error {
-		if index.IsDeleted(id) {
-			return nil // series id has been deleted from index
-		} else if flag == SeriesEntryTombstoneFlag {
-			return fmt.Errorf("[series id %d]: tombstone entry but exists in index", id)
+		if ok, err := keep(flag, id); err != nil || !ok {
+			return err
 		}
 
 		// copy entry over to new segment
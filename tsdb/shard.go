@@ -18,6 +18,7 @@ import (
 	"unicode"
 	"unsafe"
 
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/models"
 	"github.com/influxdata/influxdb/v2/pkg/bytesutil"
@@ -98,6 +99,53 @@ type PartialWriteError struct {
 
 	// A sorted slice of series keys that were dropped.
 	DroppedKeys [][]byte
+
+	// The points that were dropped and why, in no particular order.
+	Points []DroppedPoint
+}
+
+// DropCode classifies why a point was dropped from a write.
+type DropCode string
+
+const (
+	// DropInvalid is the code of points which can never be written, such as
+	// those with a "time" tag or a string field which is too long.
+	DropInvalid DropCode = "invalid"
+	// DropFieldTypeConflict is the code of points with a field whose type
+	// differs from the type of the field in its shard.
+	DropFieldTypeConflict DropCode = "field_type_conflict"
+	// DropRetention is the code of points outside of the time range accepted
+	// by the retention policy or late-arrival policy of the bucket.
+	DropRetention DropCode = "retention"
+	// DropSchema is the code of points not matching the explicit schema of
+	// the bucket.
+	DropSchema DropCode = "schema"
+	// DropCardinalityLimit is the code of points of new series exceeding a
+	// cardinality limit of the bucket.
+	DropCardinalityLimit DropCode = "cardinality_limit"
+	// DropTransform is the code of points rejected by a rule of the transform
+	// of the bucket.
+	DropTransform DropCode = "transform"
+	// DropValidation is the code of points rejected by a rule of the
+	// validation of the bucket.
+	DropValidation DropCode = "validation"
+)
+
+// DroppedPoint is a point dropped from a write, with the reason it was
+// dropped for.
+type DroppedPoint struct {
+	Point  models.Point
+	Code   DropCode
+	Reason string
+}
+
+// DropPoints returns the dropped points of points dropped for the same reason.
+func DropPoints(code DropCode, reason string, points []models.Point) []DroppedPoint {
+	dropped := make([]DroppedPoint, 0, len(points))
+	for _, p := range points {
+		dropped = append(dropped, DroppedPoint{Point: p, Code: code, Reason: reason})
+	}
+	return dropped
 }
 
 func (e PartialWriteError) Error() string {
@@ -196,6 +244,17 @@ func (s *Shard) setEnabledNoLock(enabled bool) {
 	}
 }
 
+// SetWALPolicy changes how writes to the shard are made durable. The policy
+// is retained and applied again if the shard is reopened.
+func (s *Shard) SetWALPolicy(p WALPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.options.WALPolicy = p
+	if s._engine != nil {
+		s._engine.SetWALPolicy(p)
+	}
+}
+
 // ScheduleFullCompaction forces a full compaction to be schedule on the shard.
 func (s *Shard) ScheduleFullCompaction() error {
 	engine, err := s.Engine()
@@ -205,6 +264,57 @@ func (s *Shard) ScheduleFullCompaction() error {
 	return engine.ScheduleFullCompaction()
 }
 
+// CompactionStatus returns the TSM file layout of the shard and the
+// compactions most recently planned for it.
+func (s *Shard) CompactionStatus() (influxdb.ShardCompactionStatus, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return influxdb.ShardCompactionStatus{}, err
+	}
+	status := engine.CompactionStatus()
+	status.ShardID = s.id
+	return status, nil
+}
+
+// Compact fully compacts the shard, blocking until the compaction completes
+// or ctx is cancelled. If optimize is true, blocks are not recompressed.
+func (s *Shard) Compact(ctx context.Context, optimize bool) error {
+	engine, err := s.Engine()
+	if err != nil {
+		return err
+	}
+	return engine.Compact(ctx, optimize)
+}
+
+// PurgeTombstones rewrites the shard's TSM files that hold deleted data,
+// blocking until the space is reclaimed or ctx is cancelled.
+func (s *Shard) PurgeTombstones(ctx context.Context) error {
+	engine, err := s.Engine()
+	if err != nil {
+		return err
+	}
+	return engine.PurgeTombstones(ctx)
+}
+
+// SeriesWithoutData returns the series in the shard's index that have no data.
+func (s *Shard) SeriesWithoutData(ctx context.Context) (*SeriesIDSet, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return nil, err
+	}
+	return engine.SeriesWithoutData(ctx)
+}
+
+// DropSeriesWithoutData removes the series in ids that still have no data from
+// the shard's index. It returns the number of series removed.
+func (s *Shard) DropSeriesWithoutData(ctx context.Context, ids *SeriesIDSet) (int, error) {
+	engine, err := s.Engine()
+	if err != nil {
+		return 0, err
+	}
+	return engine.DropSeriesWithoutData(ctx, ids)
+}
+
 // ID returns the shards ID.
 func (s *Shard) ID() uint64 {
 	return s.id
@@ -686,8 +796,18 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 		err            error
 		dropped        int
 		reason         string // only first error reason is set unless returned from CreateSeriesListIfNotExists
+		droppedPoints  []DroppedPoint
 	)
 
+	// drop records a point dropped before reaching the index.
+	drop := func(p models.Point, code DropCode, r string) {
+		dropped++
+		if reason == "" {
+			reason = r
+		}
+		droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Code: code, Reason: r})
+	}
+
 	// Create all series against the index in bulk.
 	keys := make([][]byte, len(points))
 	names := make([][]byte, len(points))
@@ -702,21 +822,15 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 
 		// Drop any series w/ a "time" tag, these are illegal
 		if v := tags.Get(timeBytes); v != nil {
-			dropped++
-			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
-			}
+			drop(p, DropInvalid, fmt.Sprintf(
+				"invalid tag key: input tag \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name())))
 			continue
 		}
 
 		// Drop any series with invalid unicode characters in the key.
 		if validateKeys && !models.ValidKeyTokens(string(p.Name()), tags) {
-			dropped++
-			if reason == "" {
-				reason = fmt.Sprintf("key contains invalid unicode: %q", makePrintable(string(p.Key())))
-			}
+			drop(p, DropInvalid, fmt.Sprintf("key contains invalid unicode: %q", makePrintable(string(p.Key()))))
 			continue
 		}
 
@@ -734,17 +848,22 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 	}
 
 	// Add new series. Check for partial writes.
-	var droppedKeys [][]byte
+	var (
+		droppedKeys [][]byte
+		limitReason string
+	)
 	if err := engine.CreateSeriesListIfNotExists(keys, names, tagsSlice); err != nil {
 		switch err := err.(type) {
 		// (DSB) This was previously *PartialWriteError. Now catch pointer and value types.
 		case *PartialWriteError:
 			reason = err.Reason
+			limitReason = err.Reason
 			dropped += err.Dropped
 			droppedKeys = err.DroppedKeys
 			s.stats.writesDropped.Add(float64(err.Dropped))
 		case PartialWriteError:
 			reason = err.Reason
+			limitReason = err.Reason
 			dropped += err.Dropped
 			droppedKeys = err.DroppedKeys
 			s.stats.writesDropped.Add(float64(err.Dropped))
@@ -766,17 +885,15 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 			break
 		}
 		if !validField {
-			if reason == "" {
-				reason = fmt.Sprintf(
-					"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
-					"time", string(p.Name()))
-			}
-			dropped++
+			drop(p, DropInvalid, fmt.Sprintf(
+				"invalid field name: input field \"%s\" on measurement \"%s\" is invalid",
+				"time", string(p.Name())))
 			continue
 		}
 
 		// Skip any points whos keys have been dropped. Dropped has already been incremented for them.
 		if len(droppedKeys) > 0 && bytesutil.Contains(droppedKeys, keys[i]) {
+			droppedPoints = append(droppedPoints, DroppedPoint{Point: p, Code: DropCardinalityLimit, Reason: limitReason})
 			continue
 		}
 
@@ -791,6 +908,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 					reason = err.Reason
 				}
 				dropped += err.Dropped
+				droppedPoints = append(droppedPoints, err.Points...)
 				s.stats.writesDropped.Add(float64(err.Dropped))
 			default:
 				return nil, nil, err
@@ -831,7 +949,7 @@ func (s *Shard) validateSeriesAndFields(points []models.Point) ([]models.Point,
 	}
 
 	if dropped > 0 {
-		err = PartialWriteError{Reason: reason, Dropped: dropped}
+		err = PartialWriteError{Reason: reason, Dropped: dropped, DroppedKeys: droppedKeys, Points: droppedPoints}
 	}
 
 	return points[:j], fieldsToCreate, err
@@ -111,6 +111,16 @@ type Store struct {
 	// is stored by shard.
 	epochs map[uint64]*epochTracker
 
+	// WAL durability policies by database. Databases without an entry use
+	// the policy in EngineOptions.
+	walPolicies map[string]WALPolicy
+
+	// Series cardinality limits by database.
+	cardinality *cardinalityLimits
+
+	// State of the series garbage collector between passes.
+	seriesGC *seriesGC
+
 	EngineOptions EngineOptions
 
 	baseLogger *zap.Logger
@@ -131,6 +141,9 @@ func NewStore(path string) *Store {
 		pendingShardDeletes: make(map[uint64]struct{}),
 		badShards:           shardErrorMap{shardErrors: make(map[uint64]error)},
 		epochs:              make(map[uint64]*epochTracker),
+		walPolicies:         make(map[string]WALPolicy),
+		cardinality:         newCardinalityLimits(),
+		seriesGC:            newSeriesGC(),
 		EngineOptions:       NewEngineOptions(),
 		Logger:              zap.NewNop(),
 		baseLogger:          zap.NewNop(),
@@ -258,6 +271,10 @@ func (s *Store) Open(ctx context.Context) error {
 		return err
 	}
 
+	s.cardinality.mu.Lock()
+	s.cardinality.defaults = s.EngineOptions.Config.CardinalityLimits()
+	s.cardinality.mu.Unlock()
+
 	if err := s.loadShards(ctx); err != nil {
 		return err
 	}
@@ -280,6 +297,11 @@ func (s *Store) Open(ctx context.Context) error {
 		}()
 	}
 
+	if interval := time.Duration(s.EngineOptions.Config.SeriesGCInterval); interval > 0 {
+		s.wg.Add(1)
+		go s.collectSeries(interval)
+	}
+
 	return nil
 }
 
@@ -430,9 +452,13 @@ func (s *Store) loadShards(ctx context.Context) error {
 
 					// Copy options and assign shared index.
 					opt := s.EngineOptions
+					if p, ok := s.walPolicies[db]; ok {
+						opt.WALPolicy = p
+					}
 
 					// Provide an implementation of the ShardIDSets
 					opt.SeriesIDSets = shardSet{store: s, db: db}
+					opt.CardinalityLimiter = databaseLimiter{limits: s.cardinality, db: db}
 
 					// Open engine.
 					shard := NewShard(shardID, path, walPath, sfile, opt)
@@ -556,7 +582,14 @@ func (s *Store) openSeriesFile(database string) (*SeriesFile, error) {
 	if err := sfile.Open(); err != nil {
 		return nil, err
 	}
+	if s.EngineOptions.Config.SeriesGCInterval > 0 {
+		if err := s.compactSeriesFile(database, sfile); err != nil {
+			sfile.Close()
+			return nil, err
+		}
+	}
 	s.sfiles[database] = sfile
+	s.cardinality.addSeriesFile(database, sfile)
 	return sfile, nil
 }
 
@@ -632,6 +665,13 @@ func (s *Store) Shards(ids []uint64) []*Shard {
 	return a
 }
 
+// ShardsByDatabase returns the shards belonging to database.
+func (s *Store) ShardsByDatabase(database string) []*Shard {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.filterShards(byDatabase(database))
+}
+
 // ShardGroup returns a ShardGroup with a list of shards by id.
 func (s *Store) ShardGroup(ids []uint64) ShardGroup {
 	return Shards(s.Shards(ids))
@@ -697,6 +737,10 @@ func (s *Store) CreateShard(ctx context.Context, database, retentionPolicy strin
 	// Copy index options and pass in shared index.
 	opt := s.EngineOptions
 	opt.SeriesIDSets = shardSet{store: s, db: database}
+	opt.CardinalityLimiter = databaseLimiter{limits: s.cardinality, db: database}
+	if p, ok := s.walPolicies[database]; ok {
+		opt.WALPolicy = p
+	}
 
 	path := filepath.Join(s.path, database, retentionPolicy, strconv.FormatUint(shardID, 10))
 	shard := NewShard(shardID, path, walPath, sfile, opt)
@@ -735,6 +779,29 @@ func (s *Store) CreateShardSnapshot(id uint64, skipCacheOk bool) (string, error)
 	return sh.CreateSnapshot(skipCacheOk)
 }
 
+// SetWALPolicy sets the WAL durability policy for all current and future
+// shards of a database. It may be called before the store is opened.
+func (s *Store) SetWALPolicy(database string, p WALPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.walPolicies[database] = p
+	for _, sh := range s.shards {
+		if sh.database == database {
+			sh.SetWALPolicy(p)
+		}
+	}
+}
+
+// SetCardinalityLimits sets the series cardinality limits enforced when new
+// series are created in a database. Databases without limits use those of the
+// store's configuration. It may be called before the store is opened.
+func (s *Store) SetCardinalityLimits(database string, l CardinalityLimits) {
+	s.cardinality.mu.Lock()
+	defer s.cardinality.mu.Unlock()
+	s.cardinality.limits[database] = l
+}
+
 // SetShardEnabled enables or disables a shard for read and writes.
 func (s *Store) SetShardEnabled(shardID uint64, enabled bool) error {
 	sh := s.Shard(shardID)
@@ -761,6 +828,7 @@ func (s *Store) DeleteShard(shardID uint64) error {
 	if sh == nil {
 		return nil
 	}
+	s.forgetSeriesGC("", shardID)
 
 	// Remove the shard from Store so it's not returned to callers requesting
 	// shards. Also mark that this shard is currently being deleted in a separate
@@ -861,6 +929,12 @@ func (s *Store) DeleteDatabase(name string) error {
 		return err
 	}
 
+	shardIDs := make([]uint64, 0, len(shards))
+	for _, sh := range shards {
+		shardIDs = append(shardIDs, sh.ID())
+	}
+	s.forgetSeriesGC(name, shardIDs...)
+
 	dbPath := filepath.Clean(filepath.Join(s.path, name))
 
 	s.mu.Lock()
@@ -868,6 +942,7 @@ func (s *Store) DeleteDatabase(name string) error {
 
 	sfile := s.sfiles[name]
 	delete(s.sfiles, name)
+	s.cardinality.remove(name)
 
 	// Close series file.
 	if sfile != nil {
@@ -896,6 +971,7 @@ func (s *Store) DeleteDatabase(name string) error {
 
 	// Remove database from store list of databases
 	delete(s.databases, name)
+	delete(s.walPolicies, name)
 
 	return nil
 }
@@ -1106,6 +1182,27 @@ func (s *Store) DiskSize() (int64, error) {
 	return size, nil
 }
 
+// DatabaseDiskSize returns the size of the shard files of a database in
+// bytes. Like DiskSize, it does not include the WAL size.
+func (s *Store) DatabaseDiskSize(database string) (int64, error) {
+	var size int64
+
+	s.mu.RLock()
+	shards := s.filterShards(byDatabase(database))
+	s.mu.RUnlock()
+
+	for _, sh := range shards {
+		sz, err := sh.DiskSize()
+		if err == ErrEngineClosed {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		size += sz
+	}
+	return size, nil
+}
+
 // sketchesForDatabase returns merged sketches for the provided database, by
 // walking each shard in the database and merging the sketches found there.
 func (s *Store) sketchesForDatabase(dbName string, getSketches func(*Shard) (estimator.Sketch, estimator.Sketch, error)) (estimator.Sketch, estimator.Sketch, error) {
@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/davecgh/go-spew/spew"
+	"github.com/influxdata/influxdb/v2"
 	"github.com/influxdata/influxdb/v2/influxql/query"
 	"github.com/influxdata/influxdb/v2/internal"
 	"github.com/influxdata/influxdb/v2/models"
@@ -752,6 +753,263 @@ func TestStore_Shard_SeriesN(t *testing.T) {
 	}
 }
 
+// Ensure the store applies the cardinality limits of a database, and of the
+// organization it belongs to, to writes.
+func TestStore_CardinalityLimits(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			s := MustOpenStore(t, index)
+			defer s.Close()
+
+			s.SetCardinalityLimits("db0", tsdb.CardinalityLimits{MaxSeries: 2, Org: "org0", MaxOrgSeries: 3})
+			s.SetCardinalityLimits("db1", tsdb.CardinalityLimits{Org: "org0", MaxOrgSeries: 3})
+
+			s.MustCreateShardWithData("db0", "rp0", 1,
+				`cpu,host=a value=1 0`,
+				`cpu,host=b value=1 0`,
+			)
+			require.NoError(t, s.CreateShard(context.Background(), "db1", "rp0", 2, true))
+
+			write := func(shardID uint64, data string) error {
+				points, err := models.ParsePointsString(data)
+				require.NoError(t, err)
+				return s.WriteToShard(context.Background(), shardID, points)
+			}
+
+			// db0 is at its own limit, but existing series may still be written.
+			err := write(1, "cpu,host=a value=2 10\ncpu,host=c value=2 10")
+			var perr tsdb.PartialWriteError
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, `max-series-per-bucket limit exceeded (2/2): key="cpu,host=c"`, perr.Reason)
+			require.Equal(t, 1, perr.Dropped)
+			require.Equal(t, [][]byte{[]byte("cpu,host=c")}, perr.DroppedKeys)
+			require.Len(t, perr.Points, 1)
+			require.Equal(t, tsdb.DropCardinalityLimit, perr.Points[0].Code)
+			require.Equal(t, "cpu,host=c value=2 10", perr.Points[0].Point.String())
+
+			// db1 shares the organization's limit with db0.
+			require.NoError(t, write(2, "mem,host=a value=1 0"))
+			err = write(2, "mem,host=b value=1 0")
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, `max-series-per-org limit exceeded (3/3): key="mem,host=b"`, perr.Reason)
+
+			// Lifting the limit allows the series to be created.
+			s.SetCardinalityLimits("db1", tsdb.CardinalityLimits{})
+			require.NoError(t, write(2, "mem,host=b value=1 0"))
+		})
+	}
+}
+
+func TestStore_CardinalityReport(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			s := MustOpenStore(t, index)
+			defer s.Close()
+
+			s.MustCreateShardWithData("db0", "rp0", 1,
+				`cpu,host=a,region=west value=1 0`,
+				`cpu,host=b,region=west value=1 0`,
+				`mem,host=a value=1 0`,
+			)
+			s.MustCreateShardWithData("db0", "rp0", 2,
+				`cpu,host=a,region=west value=1 10`,
+				`cpu,host=c,region=east value=1 10`,
+				`disk,host=a value=1 10`,
+			)
+
+			for _, exact := range []bool{true, false} {
+				seriesN, measurements, err := s.CardinalityReport(context.Background(), []uint64{1, 2}, 2, exact)
+				require.NoError(t, err)
+				require.Equal(t, int64(5), seriesN)
+				require.Len(t, measurements, 2)
+
+				cpu := measurements[0]
+				require.Equal(t, "cpu", cpu.Name)
+				require.Equal(t, int64(3), cpu.SeriesN)
+				require.Equal(t, "disk", measurements[1].Name)
+				require.Len(t, cpu.TagKeys, 2)
+				if !exact {
+					// Estimates scale every count by the overlap of
+					// the shards, so only totals are checked.
+					continue
+				}
+
+				require.Equal(t, influxdb.TagKeyCardinality{
+					Key:     "host",
+					ValuesN: 3,
+					Values: []influxdb.TagValueCardinality{
+						{Value: "a", SeriesN: 1},
+						{Value: "b", SeriesN: 1},
+					},
+				}, cpu.TagKeys[0])
+				require.Equal(t, "region", cpu.TagKeys[1].Key)
+				require.Equal(t, []influxdb.TagValueCardinality{
+					{Value: "west", SeriesN: 2},
+					{Value: "east", SeriesN: 1},
+				}, cpu.TagKeys[1].Values)
+			}
+
+			growth, err := s.SeriesGrowthReport(context.Background(), []uint64{1}, [][]uint64{{2}})
+			require.NoError(t, err)
+			require.Equal(t, []tsdb.SeriesGrowth{{SeriesN: 3, NewSeriesN: 2}}, growth)
+		})
+	}
+}
+
+func TestStore_SearchTagValues(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			s := MustOpenStore(t, index)
+			defer s.Close()
+
+			s.MustCreateShardWithData("db0", "rp0", 1,
+				`cpu,host=server-a value=1 0`,
+				`cpu,host=server-b value=1 0`,
+				`mem,host=web-a value=1 0`,
+			)
+			s.MustCreateShardWithData("db0", "rp0", 2,
+				`cpu,host=server-b value=1 10`,
+				`cpu,host=server-c value=1 10`,
+				`disk,host=Server-D value=1 10`,
+			)
+
+			ctx := context.Background()
+			shards := []uint64{1, 2}
+			for _, tt := range []struct {
+				name   string
+				search tsdb.Search
+				m      string
+				exp    []string
+				more   bool
+			}{
+				{name: "all", search: tsdb.Search{Limit: 10}, exp: []string{"Server-D", "server-a", "server-b", "server-c", "web-a"}},
+				{name: "prefix", search: tsdb.Search{Prefix: "server-", Limit: 10}, exp: []string{"server-a", "server-b", "server-c"}},
+				{name: "limit", search: tsdb.Search{Prefix: "server-", Limit: 2}, exp: []string{"server-a", "server-b"}, more: true},
+				{name: "after", search: tsdb.Search{Prefix: "server-", After: "server-b", Limit: 2}, exp: []string{"server-c"}},
+				{name: "regex", search: tsdb.Search{Regex: regexp.MustCompile(`-[aD]$`), Limit: 10}, exp: []string{"Server-D", "server-a", "web-a"}},
+				{name: "regex literal", search: tsdb.Search{Regex: regexp.MustCompile(`^server-[bc]$`), Limit: 10}, exp: []string{"server-b", "server-c"}},
+				{name: "regex fold case", search: tsdb.Search{Regex: regexp.MustCompile(`(?i)SERVER-d`), Limit: 10}, exp: []string{"Server-D"}},
+				{name: "regex alternate", search: tsdb.Search{Regex: regexp.MustCompile(`ver-a|web`), Limit: 10}, exp: []string{"server-a", "web-a"}},
+				{name: "regex after", search: tsdb.Search{Regex: regexp.MustCompile(`rver-`), After: "server-a", Limit: 1}, exp: []string{"server-b"}, more: true},
+				{name: "fuzzy", search: tsdb.Search{Fuzzy: "SVD", Limit: 10}, exp: []string{"Server-D"}},
+				{name: "measurement", search: tsdb.Search{Limit: 10}, m: "mem", exp: []string{"web-a"}},
+			} {
+				t.Run(tt.name, func(t *testing.T) {
+					var name []byte
+					if tt.m != "" {
+						name = []byte(tt.m)
+					}
+					values, more, err := s.SearchTagValues(ctx, shards, name, []byte("host"), tt.search)
+					require.NoError(t, err)
+					require.Equal(t, tt.exp, values)
+					require.Equal(t, tt.more, more)
+				})
+			}
+
+			names, more, err := s.SearchMeasurementNames(ctx, shards, tsdb.Search{Prefix: "d", Limit: 10})
+			require.NoError(t, err)
+			require.Equal(t, []string{"disk"}, names)
+			require.False(t, more)
+
+			names, more, err = s.SearchMeasurementNames(ctx, shards, tsdb.Search{After: "cpu", Limit: 1})
+			require.NoError(t, err)
+			require.Equal(t, []string{"disk"}, names)
+			require.True(t, more)
+		})
+	}
+}
+
+func TestStore_CollectSeries(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			s := MustOpenStore(t, index)
+			defer s.Close()
+
+			s.MustCreateShardWithData("db0", "rp0", 1,
+				`cpu,host=a value=1 0`,
+				`cpu,host=b value=1 10`,
+			)
+
+			// A series in the shard's index without any data.
+			idx, err := s.Shard(1).Index()
+			require.NoError(t, err)
+			tags := models.NewTags(map[string]string{"host": "c"})
+			require.NoError(t, idx.CreateSeriesIfNotExists(models.MakeKey([]byte("cpu"), tags), []byte("cpu"), tags))
+
+			ctx := context.Background()
+			stats, err := s.CollectSeries(ctx, "db0")
+			require.NoError(t, err)
+			require.Equal(t, tsdb.SeriesGCStats{PendingSeries: 1}, stats)
+
+			stats, err = s.CollectSeries(ctx, "db0")
+			require.NoError(t, err)
+			require.Equal(t, tsdb.SeriesGCStats{IndexSeriesDropped: 1}, stats)
+
+			// A series in the series file but in no shard.
+			_, err = s.SeriesFile("db0").CreateSeriesListIfNotExists([][]byte{[]byte("mem")}, []models.Tags{tags})
+			require.NoError(t, err)
+
+			stats, err = s.CollectSeries(ctx, "db0")
+			require.NoError(t, err)
+			require.Equal(t, tsdb.SeriesGCStats{PendingSeries: 1}, stats)
+
+			stats, err = s.CollectSeries(ctx, "db0")
+			require.NoError(t, err)
+			require.Equal(t, tsdb.SeriesGCStats{SeriesFileSeriesDropped: 1}, stats)
+
+			keys, err := s.SeriesCardinality(ctx, "db0")
+			require.NoError(t, err)
+			require.Equal(t, int64(2), keys)
+		})
+	}
+}
+
+// Ensure the series of a shard which is not loaded are not removed from the
+// series file.
+func TestStore_CollectSeries_UnloadedShard(t *testing.T) {
+	for _, index := range tsdb.RegisteredIndexes() {
+		t.Run(index, func(t *testing.T) {
+			s := MustOpenStore(t, index)
+			defer s.Close()
+
+			s.MustCreateShardWithData("db0", "rp0", 1, `cpu,host=a value=1 0`)
+			s.MustCreateShardWithData("db0", "rp0", 2, `mem,host=a value=1 0`)
+
+			// The second shard is left unloaded, as if it failed to open.
+			require.NoError(t, s.Store.Close())
+			s.Store = tsdb.NewStore(s.Path())
+			s.EngineOptions.IndexVersion = index
+			s.EngineOptions.Config.WALDir = filepath.Join(s.Path(), "wal")
+			s.EngineOptions.ShardFilter = func(_, _ string, id uint64) bool { return id != 2 }
+			s.WithLogger(zaptest.NewLogger(t))
+			require.NoError(t, s.Open(context.Background()))
+			require.Nil(t, s.Shard(2))
+
+			ctx := context.Background()
+			for i := 0; i < 2; i++ {
+				stats, err := s.CollectSeries(ctx, "db0")
+				require.NoError(t, err)
+				require.Equal(t, tsdb.SeriesGCStats{}, stats)
+			}
+
+			// Neither are they removed while a loaded shard is disabled.
+			require.NoError(t, s.Reopen(t))
+			s.Shard(2).SetEnabled(false)
+			for i := 0; i < 2; i++ {
+				stats, err := s.CollectSeries(ctx, "db0")
+				require.NoError(t, err)
+				require.Equal(t, tsdb.SeriesGCStats{}, stats)
+			}
+
+			s.Shard(2).SetEnabled(true)
+			idx, err := s.Shard(2).Index()
+			require.NoError(t, err)
+			require.NotZero(t, s.SeriesFile("db0").SeriesID([]byte("mem"), models.NewTags(map[string]string{"host": "a"}), nil))
+			require.Equal(t, uint64(1), idx.SeriesIDSet().Cardinality())
+		})
+	}
+}
+
 func TestStore_MeasurementNames_Deduplicate(t *testing.T) {
 
 	test := func(t *testing.T, index string) {
@@ -1,10 +1,12 @@
 package coordinator
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -44,14 +46,37 @@ type PointsWriter struct {
 		WriteToShard(ctx context.Context, shardID uint64, points []models.Point) error
 	}
 
+	// RedirectPoints, if set, writes the points a database redirects to its
+	// late points database through the whole write path of the latter, such
+	// as its transform, validation and schema, rather than straight to its
+	// shards. The late points policy of the late points database is not
+	// applied to the writes made with ctx, so points are never redirected
+	// more than once.
+	RedirectPoints func(ctx context.Context, source, target string, points []models.Point) error
+
 	stats *engineWriteMetrics
 }
 
+// redirectedKey marks the context of the writes of redirected points.
+type redirectedKey struct{}
+
+// Actions taken for points outside of a retention policy's lateness window or
+// future skew limit. An empty action accepts the points.
+const (
+	LatePointsAccept   = "accept"
+	LatePointsReject   = "reject"
+	LatePointsRedirect = "redirect"
+)
+
 // WritePointsRequest represents a request to write point data to the cluster.
 type WritePointsRequest struct {
 	Database        string
 	RetentionPolicy string
 	Points          []models.Point
+
+	// ignoreLatePolicy is set for points redirected from another retention
+	// policy so they are not refused or redirected a second time.
+	ignoreLatePolicy bool
 }
 
 // AddPoint adds a point to the WritePointRequest with field key 'value'
@@ -81,6 +106,22 @@ type ShardMapping struct {
 	Points  map[uint64][]models.Point  // The points associated with a shard ID
 	Shards  map[uint64]*meta.ShardInfo // The shards that have been mapped, keyed by shard ID
 	Dropped []models.Point             // Points that were dropped
+
+	// Late and Future hold points refused because they were older than the
+	// retention policy's lateness window or further ahead than its future
+	// skew limit.
+	Late   []models.Point
+	Future []models.Point
+
+	// Redirected holds points outside the retention policy's lateness window
+	// or future skew limit that must be written to RedirectDatabase instead.
+	Redirected       []models.Point
+	RedirectDatabase string
+
+	// LatenessWindow and FutureSkewLimit are the bounds the points were
+	// checked against, for reporting refused points.
+	LatenessWindow  time.Duration
+	FutureSkewLimit time.Duration
 }
 
 // NewShardMapping creates an empty ShardMapping.
@@ -227,7 +268,13 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 		min = time.Now().Add(-rp.Duration)
 	}
 
-	for _, p := range wp.Points {
+	mapping := NewShardMapping(len(wp.Points))
+	points := wp.Points
+	if !wp.ignoreLatePolicy && (rp.LatenessWindow > 0 || rp.FutureSkewLimit > 0) {
+		points = mapping.applyLatePolicy(rp, wp.Points, time.Now())
+	}
+
+	for _, p := range points {
 		// Either the point is outside the scope of the RP, or we already have
 		// a suitable shard group for the point.
 		if p.Time().Before(min) || list.Covers(p.Time()) {
@@ -247,8 +294,7 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 		list.Add(*sg)
 	}
 
-	mapping := NewShardMapping(len(wp.Points))
-	for _, p := range wp.Points {
+	for _, p := range points {
 		sg := list.ShardGroupAt(p.Time())
 		if sg == nil {
 			// We didn't create a shard group because the point was outside the
@@ -264,6 +310,45 @@ func (w *PointsWriter) MapShards(wp *WritePointsRequest) (*ShardMapping, error)
 	return mapping, nil
 }
 
+// applyLatePolicy separates points outside the lateness window and future
+// skew limit of rp according to its LatePointsAction, returning the points
+// that should be written to rp.
+func (s *ShardMapping) applyLatePolicy(rp *meta.RetentionPolicyInfo, points []models.Point, now time.Time) []models.Point {
+	if rp.LatePointsAction == "" || rp.LatePointsAction == LatePointsAccept {
+		return points
+	}
+
+	s.LatenessWindow, s.FutureSkewLimit = rp.LatenessWindow, rp.FutureSkewLimit
+	if rp.LatePointsAction == LatePointsRedirect {
+		s.RedirectDatabase = rp.LatePointsDatabase
+	}
+
+	var earliest, latest time.Time
+	if rp.LatenessWindow > 0 {
+		earliest = now.Add(-rp.LatenessWindow)
+	}
+	if rp.FutureSkewLimit > 0 {
+		latest = now.Add(rp.FutureSkewLimit)
+	}
+
+	accepted := make([]models.Point, 0, len(points))
+	for _, p := range points {
+		late := !earliest.IsZero() && p.Time().Before(earliest)
+		future := !latest.IsZero() && p.Time().After(latest)
+		switch {
+		case !late && !future:
+			accepted = append(accepted, p)
+		case rp.LatePointsAction == LatePointsRedirect:
+			s.Redirected = append(s.Redirected, p)
+		case late:
+			s.Late = append(s.Late, p)
+		default:
+			s.Future = append(s.Future, p)
+		}
+	}
+	return accepted
+}
+
 // sgList is a wrapper around a meta.ShardGroupInfos where we can also check
 // if a given time is covered by any of the shard groups in the list.
 type sgList struct {
@@ -372,20 +457,60 @@ func (w *PointsWriter) WritePointsPrivileged(
 	points []models.Point,
 ) error {
 	w.stats.pointsWriteRequested.Observe(float64(len(points)))
+	return w.writePoints(ctx, &WritePointsRequest{Database: database, RetentionPolicy: retentionPolicy, Points: points})
+}
 
+func (w *PointsWriter) writePoints(ctx context.Context, wp *WritePointsRequest) error {
+	if ctx.Value(redirectedKey{}) != nil {
+		wp.ignoreLatePolicy = true
+	}
+	database, retentionPolicy := wp.Database, wp.RetentionPolicy
 	if retentionPolicy == "" {
 		db := w.MetaClient.Database(database)
 		if db == nil {
 			return influxdb.ErrDatabaseNotFound(database)
 		}
 		retentionPolicy = db.DefaultRetentionPolicy
+		wp.RetentionPolicy = retentionPolicy
 	}
 
-	shardMappings, err := w.MapShards(&WritePointsRequest{Database: database, RetentionPolicy: retentionPolicy, Points: points})
+	shardMappings, err := w.MapShards(wp)
 	if err != nil {
 		return err
 	}
 
+	var partial partialWriteErrors
+	if len(shardMappings.Late) > 0 {
+		partial.add(fmt.Sprintf("points older than lateness window of %s", shardMappings.LatenessWindow), tsdb.DropRetention, shardMappings.Late)
+	}
+	if len(shardMappings.Future) > 0 {
+		partial.add(fmt.Sprintf("points beyond future skew limit of %s", shardMappings.FutureSkewLimit), tsdb.DropRetention, shardMappings.Future)
+	}
+	if len(shardMappings.Redirected) > 0 {
+		// Redirected points are written to the default retention policy of the
+		// target without applying its own late-arrival policy, so points are
+		// never redirected more than once. They go through the whole write
+		// path of the target if RedirectPoints is set.
+		target := shardMappings.RedirectDatabase
+		if target == "" || w.MetaClient.Database(target) == nil {
+			partial.add(fmt.Sprintf("late points bucket %q not found", target), tsdb.DropRetention, shardMappings.Redirected)
+		} else {
+			var err error
+			if w.RedirectPoints != nil {
+				err = w.RedirectPoints(context.WithValue(ctx, redirectedKey{}, true), database, target, shardMappings.Redirected)
+			} else {
+				err = w.writePoints(ctx, &WritePointsRequest{Database: target, Points: shardMappings.Redirected, ignoreLatePolicy: true})
+			}
+			if err != nil {
+				pwe, ok := err.(tsdb.PartialWriteError)
+				if !ok {
+					return err
+				}
+				partial.merge("redirected "+pwe.Reason, pwe)
+			}
+		}
+	}
+
 	// Write each shard in it's own goroutine and return as soon as one fails.
 	ch := make(chan error, len(shardMappings.Points))
 	for shardID, points := range shardMappings.Points {
@@ -397,15 +522,18 @@ func (w *PointsWriter) WritePointsPrivileged(
 				w.stats.pointsWriteErr.Observe(float64(len(points)))
 			}
 			if err == tsdb.ErrShardDeletion {
-				err = tsdb.PartialWriteError{Reason: fmt.Sprintf("shard %d is pending deletion", shard.ID), Dropped: len(points)}
+				reason := fmt.Sprintf("shard %d is pending deletion", shard.ID)
+				err = tsdb.PartialWriteError{Reason: reason, Dropped: len(points), Points: tsdb.DropPoints(tsdb.DropRetention, reason, points)}
 			}
 			ch <- err
 		}(shardMappings.Shards[shardID], database, retentionPolicy, points)
 	}
 
 	if len(shardMappings.Dropped) > 0 {
-		w.stats.pointsWriteDropped.Observe(float64(len(shardMappings.Dropped)))
-		err = tsdb.PartialWriteError{Reason: "points beyond retention policy", Dropped: len(shardMappings.Dropped)}
+		partial.add("points beyond retention policy", tsdb.DropRetention, shardMappings.Dropped)
+	}
+	if partial.dropped > 0 {
+		w.stats.pointsWriteDropped.Observe(float64(partial.dropped))
 	}
 	timeout := time.NewTimer(w.WriteTimeout)
 	defer timeout.Stop()
@@ -418,12 +546,60 @@ func (w *PointsWriter) WritePointsPrivileged(
 			// return timeout error to caller
 			return ErrTimeout
 		case err := <-ch:
-			if err != nil {
+			// The points dropped by shards are reported with the others.
+			if pwe, ok := err.(tsdb.PartialWriteError); ok {
+				partial.merge(pwe.Reason, pwe)
+			} else if err != nil {
 				return err
 			}
 		}
 	}
-	return err
+	if partial.dropped > 0 {
+		return partial.err()
+	}
+	return nil
+}
+
+// partialWriteErrors accumulates points dropped from a write request for
+// different reasons into a single tsdb.PartialWriteError.
+type partialWriteErrors struct {
+	reasons []string
+	dropped int
+	keys    [][]byte
+	points  []tsdb.DroppedPoint
+}
+
+// add records a reason for dropping points, and the series keys of points.
+func (e *partialWriteErrors) add(reason string, code tsdb.DropCode, points []models.Point) {
+	e.reasons = append(e.reasons, reason)
+	e.dropped += len(points)
+	for _, p := range points {
+		e.keys = append(e.keys, p.Key())
+	}
+	e.points = append(e.points, tsdb.DropPoints(code, reason, points)...)
+}
+
+// merge records a reason for dropping the points described by pwe.
+func (e *partialWriteErrors) merge(reason string, pwe tsdb.PartialWriteError) {
+	e.reasons = append(e.reasons, reason)
+	e.dropped += pwe.Dropped
+	e.keys = append(e.keys, pwe.DroppedKeys...)
+	e.points = append(e.points, pwe.Points...)
+}
+
+// err returns a tsdb.PartialWriteError describing all dropped points.
+func (e *partialWriteErrors) err() error {
+	pwe := tsdb.PartialWriteError{Reason: strings.Join(e.reasons, "; "), Dropped: e.dropped, Points: e.points}
+	if len(e.keys) > 0 {
+		sort.Slice(e.keys, func(i, j int) bool { return bytes.Compare(e.keys[i], e.keys[j]) < 0 })
+		pwe.DroppedKeys = e.keys[:0]
+		for i, k := range e.keys {
+			if i == 0 || !bytes.Equal(k, e.keys[i-1]) {
+				pwe.DroppedKeys = append(pwe.DroppedKeys, k)
+			}
+		}
+	}
+	return pwe
 }
 
 // writeToShards writes points to a shard.
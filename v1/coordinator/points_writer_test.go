@@ -401,6 +401,281 @@ func TestPointsWriter_WritePoints_Dropped(t *testing.T) {
 	}
 }
 
+func TestPointsWriter_WritePoints_LatePoints(t *testing.T) {
+	now := time.Now()
+	newRequest := func() *coordinator.WritePointsRequest {
+		pr := &coordinator.WritePointsRequest{Database: "mydb", RetentionPolicy: "autogen"}
+		pr.AddPoint("cpu", 1.0, now, nil)
+		pr.AddPoint("cpu", 2.0, now.Add(-time.Hour), map[string]string{"host": "late"})
+		pr.AddPoint("cpu", 3.0, now.Add(time.Hour), map[string]string{"host": "future"})
+		return pr
+	}
+
+	for _, tt := range []struct {
+		name     string
+		action   string
+		target   string
+		expErr   string
+		expShard map[string]int
+	}{
+		{
+			name:     "accept",
+			action:   coordinator.LatePointsAccept,
+			expShard: map[string]int{"mydb": 3},
+		},
+		{
+			name:     "reject",
+			action:   coordinator.LatePointsReject,
+			expErr:   "partial write: points older than lateness window of 30m0s; points beyond future skew limit of 5m0s dropped=2",
+			expShard: map[string]int{"mydb": 1},
+		},
+		{
+			name:     "redirect",
+			action:   coordinator.LatePointsRedirect,
+			target:   "late",
+			expShard: map[string]int{"mydb": 1, "late": 2},
+		},
+		{
+			name:     "redirect to missing bucket",
+			action:   coordinator.LatePointsRedirect,
+			target:   "missing",
+			expErr:   `partial write: late points bucket "missing" not found dropped=2`,
+			expShard: map[string]int{"mydb": 1},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			rps := map[string]*meta.RetentionPolicyInfo{
+				"mydb": {
+					Name:               "autogen",
+					ShardGroupDuration: 24 * time.Hour,
+					LatenessWindow:     30 * time.Minute,
+					FutureSkewLimit:    5 * time.Minute,
+					LatePointsAction:   tt.action,
+					LatePointsDatabase: tt.target,
+				},
+				"late": {Name: "autogen", ShardGroupDuration: 24 * time.Hour},
+			}
+			shards := map[uint64]string{1: "mydb", 2: "late"}
+
+			ms := PointsWriterMetaClient{}
+			ms.DatabaseFn = func(database string) *meta.DatabaseInfo {
+				if rps[database] == nil {
+					return nil
+				}
+				return &meta.DatabaseInfo{Name: database, DefaultRetentionPolicy: "autogen"}
+			}
+			ms.RetentionPolicyFn = func(database, name string) (*meta.RetentionPolicyInfo, error) {
+				return rps[database], nil
+			}
+			ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+				id := uint64(1)
+				if database == "late" {
+					id = 2
+				}
+				return &meta.ShardGroupInfo{
+					ID:        id,
+					StartTime: timestamp.Add(-12 * time.Hour),
+					EndTime:   timestamp.Add(12 * time.Hour),
+					Shards:    []meta.ShardInfo{{ID: id}},
+				}, nil
+			}
+
+			var mu sync.Mutex
+			written := make(map[string]int)
+			c := coordinator.NewPointsWriter(time.Second, "")
+			c.MetaClient = ms
+			c.TSDBStore = &fakeStore{
+				WriteFn: func(_ context.Context, shardID uint64, points []models.Point) error {
+					mu.Lock()
+					defer mu.Unlock()
+					written[shards[shardID]] += len(points)
+					return nil
+				},
+			}
+			c.Open()
+			defer c.Close()
+
+			pr := newRequest()
+			err := c.WritePointsPrivileged(context.Background(), pr.Database, pr.RetentionPolicy, models.ConsistencyLevelOne, pr.Points)
+			if tt.expErr == "" && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			} else if tt.expErr != "" {
+				pwe, ok := err.(tsdb.PartialWriteError)
+				if !ok {
+					t.Fatalf("expected partial write error, got %v", err)
+				}
+				if got := pwe.Error(); got != tt.expErr {
+					t.Fatalf("unexpected error: got %q, exp %q", got, tt.expErr)
+				}
+				if got, exp := len(pwe.DroppedKeys), 2; got != exp {
+					t.Fatalf("unexpected dropped keys: got %d, exp %d", got, exp)
+				}
+				if got, exp := len(pwe.Points), 2; got != exp {
+					t.Fatalf("unexpected dropped points: got %d, exp %d", got, exp)
+				}
+				for _, d := range pwe.Points {
+					if d.Code != tsdb.DropRetention {
+						t.Fatalf("unexpected drop code: got %q, exp %q", d.Code, tsdb.DropRetention)
+					}
+				}
+			}
+			if fmt.Sprint(written) != fmt.Sprint(tt.expShard) {
+				t.Fatalf("unexpected points written: got %v, exp %v", written, tt.expShard)
+			}
+		})
+	}
+}
+
+// Ensures redirected points are passed to RedirectPoints, whose writes do not
+// apply the late points policy of the late points database.
+func TestPointsWriter_WritePoints_RedirectPoints(t *testing.T) {
+	now := time.Now()
+	pr := &coordinator.WritePointsRequest{Database: "mydb", RetentionPolicy: "autogen"}
+	pr.AddPoint("cpu", 1.0, now, nil)
+	pr.AddPoint("cpu", 2.0, now.Add(-time.Hour), map[string]string{"host": "late"})
+
+	// The late points database redirects its own late points back.
+	rps := map[string]*meta.RetentionPolicyInfo{
+		"mydb": {
+			Name:               "autogen",
+			ShardGroupDuration: 24 * time.Hour,
+			LatenessWindow:     30 * time.Minute,
+			LatePointsAction:   coordinator.LatePointsRedirect,
+			LatePointsDatabase: "late",
+		},
+		"late": {
+			Name:               "autogen",
+			ShardGroupDuration: 24 * time.Hour,
+			LatenessWindow:     30 * time.Minute,
+			LatePointsAction:   coordinator.LatePointsRedirect,
+			LatePointsDatabase: "mydb",
+		},
+	}
+	shards := map[uint64]string{1: "mydb", 2: "late"}
+
+	ms := PointsWriterMetaClient{}
+	ms.DatabaseFn = func(database string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{Name: database, DefaultRetentionPolicy: "autogen"}
+	}
+	ms.RetentionPolicyFn = func(database, name string) (*meta.RetentionPolicyInfo, error) {
+		return rps[database], nil
+	}
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		id := uint64(1)
+		if database == "late" {
+			id = 2
+		}
+		return &meta.ShardGroupInfo{
+			ID:        id,
+			StartTime: timestamp.Add(-12 * time.Hour),
+			EndTime:   timestamp.Add(12 * time.Hour),
+			Shards:    []meta.ShardInfo{{ID: id}},
+		}, nil
+	}
+
+	var mu sync.Mutex
+	written := make(map[string]int)
+	c := coordinator.NewPointsWriter(time.Second, "")
+	c.MetaClient = ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(_ context.Context, shardID uint64, points []models.Point) error {
+			mu.Lock()
+			defer mu.Unlock()
+			written[shards[shardID]] += len(points)
+			return nil
+		},
+	}
+
+	// The redirected points are written back through the points writer, as
+	// the write path of the late points database does, and partly dropped.
+	var redirects []string
+	c.RedirectPoints = func(ctx context.Context, source, target string, points []models.Point) error {
+		redirects = append(redirects, fmt.Sprintf("%s->%s:%d", source, target, len(points)))
+		if err := c.WritePointsPrivileged(ctx, target, "", models.ConsistencyLevelOne, points); err != nil {
+			return err
+		}
+		return tsdb.PartialWriteError{Reason: "transform dropped points", Dropped: 1, DroppedKeys: [][]byte{points[0].Key()}}
+	}
+	c.Open()
+	defer c.Close()
+
+	err := c.WritePointsPrivileged(context.Background(), pr.Database, pr.RetentionPolicy, models.ConsistencyLevelOne, pr.Points)
+	pwe, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("expected partial write error, got %v", err)
+	}
+	if got, exp := pwe.Error(), "partial write: redirected transform dropped points dropped=1"; got != exp {
+		t.Fatalf("unexpected error: got %q, exp %q", got, exp)
+	}
+	if got, exp := fmt.Sprint(redirects), "[mydb->late:1]"; got != exp {
+		t.Fatalf("unexpected redirects: got %s, exp %s", got, exp)
+	}
+	if got, exp := fmt.Sprint(written), fmt.Sprint(map[string]int{"mydb": 1, "late": 1}); got != exp {
+		t.Fatalf("unexpected points written: got %v, exp %v", got, exp)
+	}
+}
+
+// Ensures the points dropped by shards are reported with those dropped by the
+// points writer.
+func TestPointsWriter_WritePoints_ShardDropped(t *testing.T) {
+	now := time.Now()
+	pr := &coordinator.WritePointsRequest{Database: "mydb", RetentionPolicy: "autogen"}
+	pr.AddPoint("cpu", 1.0, now, nil)
+	pr.AddPoint("cpu", 2.0, now.Add(-time.Hour), map[string]string{"host": "late"})
+
+	rp := &meta.RetentionPolicyInfo{
+		Name:               "autogen",
+		ShardGroupDuration: 24 * time.Hour,
+		LatenessWindow:     30 * time.Minute,
+		LatePointsAction:   coordinator.LatePointsReject,
+	}
+	ms := PointsWriterMetaClient{}
+	ms.DatabaseFn = func(database string) *meta.DatabaseInfo {
+		return &meta.DatabaseInfo{Name: database, DefaultRetentionPolicy: "autogen"}
+	}
+	ms.RetentionPolicyFn = func(database, name string) (*meta.RetentionPolicyInfo, error) {
+		return rp, nil
+	}
+	ms.CreateShardGroupIfNotExistsFn = func(database, policy string, timestamp time.Time) (*meta.ShardGroupInfo, error) {
+		return &meta.ShardGroupInfo{
+			ID:        1,
+			StartTime: timestamp.Add(-12 * time.Hour),
+			EndTime:   timestamp.Add(12 * time.Hour),
+			Shards:    []meta.ShardInfo{{ID: 1}},
+		}, nil
+	}
+
+	c := coordinator.NewPointsWriter(time.Second, "")
+	c.MetaClient = &ms
+	c.TSDBStore = &fakeStore{
+		WriteFn: func(_ context.Context, shardID uint64, points []models.Point) error {
+			return tsdb.PartialWriteError{
+				Reason:  "field type conflict",
+				Dropped: len(points),
+				Points:  tsdb.DropPoints(tsdb.DropFieldTypeConflict, "field type conflict", points),
+			}
+		},
+	}
+	c.Open()
+	defer c.Close()
+
+	err := c.WritePointsPrivileged(context.Background(), pr.Database, pr.RetentionPolicy, models.ConsistencyLevelOne, pr.Points)
+	pwe, ok := err.(tsdb.PartialWriteError)
+	if !ok {
+		t.Fatalf("expected partial write error, got %v", err)
+	}
+	if got, exp := pwe.Error(), "partial write: points older than lateness window of 30m0s; field type conflict dropped=2"; got != exp {
+		t.Fatalf("unexpected error: got %q, exp %q", got, exp)
+	}
+	var codes []tsdb.DropCode
+	for _, d := range pwe.Points {
+		codes = append(codes, d.Code)
+	}
+	if got, exp := fmt.Sprint(codes), fmt.Sprint([]tsdb.DropCode{tsdb.DropRetention, tsdb.DropFieldTypeConflict}); got != exp {
+		t.Fatalf("unexpected drop codes: got %s, exp %s", got, exp)
+	}
+}
+
 var shardID uint64
 
 type fakeStore struct {
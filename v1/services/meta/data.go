@@ -202,6 +202,16 @@ type RetentionPolicyUpdate struct {
 	Duration           *time.Duration
 	ReplicaN           *int
 	ShardGroupDuration *time.Duration
+	WALMode            *string
+	WALFsyncDelay      *time.Duration
+	LatenessWindow     *time.Duration
+	FutureSkewLimit    *time.Duration
+	LatePointsAction   *string
+	LatePointsDatabase *string
+
+	MaxSeries               *int64
+	MaxSeriesPerMeasurement *int64
+	MaxValuesPerTag         *int64
 }
 
 // SetName sets the RetentionPolicyUpdate.Name.
@@ -216,6 +226,35 @@ func (rpu *RetentionPolicyUpdate) SetReplicaN(v int) { rpu.ReplicaN = &v }
 // SetShardGroupDuration sets the RetentionPolicyUpdate.ShardGroupDuration.
 func (rpu *RetentionPolicyUpdate) SetShardGroupDuration(v time.Duration) { rpu.ShardGroupDuration = &v }
 
+// SetWALMode sets the RetentionPolicyUpdate.WALMode.
+func (rpu *RetentionPolicyUpdate) SetWALMode(v string) { rpu.WALMode = &v }
+
+// SetWALFsyncDelay sets the RetentionPolicyUpdate.WALFsyncDelay.
+func (rpu *RetentionPolicyUpdate) SetWALFsyncDelay(v time.Duration) { rpu.WALFsyncDelay = &v }
+
+// SetLatenessWindow sets the RetentionPolicyUpdate.LatenessWindow.
+func (rpu *RetentionPolicyUpdate) SetLatenessWindow(v time.Duration) { rpu.LatenessWindow = &v }
+
+// SetFutureSkewLimit sets the RetentionPolicyUpdate.FutureSkewLimit.
+func (rpu *RetentionPolicyUpdate) SetFutureSkewLimit(v time.Duration) { rpu.FutureSkewLimit = &v }
+
+// SetLatePointsAction sets the RetentionPolicyUpdate.LatePointsAction.
+func (rpu *RetentionPolicyUpdate) SetLatePointsAction(v string) { rpu.LatePointsAction = &v }
+
+// SetLatePointsDatabase sets the RetentionPolicyUpdate.LatePointsDatabase.
+func (rpu *RetentionPolicyUpdate) SetLatePointsDatabase(v string) { rpu.LatePointsDatabase = &v }
+
+// SetMaxSeries sets the RetentionPolicyUpdate.MaxSeries.
+func (rpu *RetentionPolicyUpdate) SetMaxSeries(v int64) { rpu.MaxSeries = &v }
+
+// SetMaxSeriesPerMeasurement sets the RetentionPolicyUpdate.MaxSeriesPerMeasurement.
+func (rpu *RetentionPolicyUpdate) SetMaxSeriesPerMeasurement(v int64) {
+	rpu.MaxSeriesPerMeasurement = &v
+}
+
+// SetMaxValuesPerTag sets the RetentionPolicyUpdate.MaxValuesPerTag.
+func (rpu *RetentionPolicyUpdate) SetMaxValuesPerTag(v int64) { rpu.MaxValuesPerTag = &v }
+
 // UpdateRetentionPolicy updates an existing retention policy.
 func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPolicyUpdate, makeDefault bool) error {
 	// Find database.
@@ -262,6 +301,33 @@ func (data *Data) UpdateRetentionPolicy(database, name string, rpu *RetentionPol
 	if rpu.ShardGroupDuration != nil {
 		rpi.ShardGroupDuration = NormalisedShardDuration(*rpu.ShardGroupDuration, rpi.Duration)
 	}
+	if rpu.WALMode != nil {
+		rpi.WALMode = *rpu.WALMode
+	}
+	if rpu.WALFsyncDelay != nil {
+		rpi.WALFsyncDelay = *rpu.WALFsyncDelay
+	}
+	if rpu.LatenessWindow != nil {
+		rpi.LatenessWindow = *rpu.LatenessWindow
+	}
+	if rpu.FutureSkewLimit != nil {
+		rpi.FutureSkewLimit = *rpu.FutureSkewLimit
+	}
+	if rpu.LatePointsAction != nil {
+		rpi.LatePointsAction = *rpu.LatePointsAction
+	}
+	if rpu.LatePointsDatabase != nil {
+		rpi.LatePointsDatabase = *rpu.LatePointsDatabase
+	}
+	if rpu.MaxSeries != nil {
+		rpi.MaxSeries = *rpu.MaxSeries
+	}
+	if rpu.MaxSeriesPerMeasurement != nil {
+		rpi.MaxSeriesPerMeasurement = *rpu.MaxSeriesPerMeasurement
+	}
+	if rpu.MaxValuesPerTag != nil {
+		rpi.MaxValuesPerTag = *rpu.MaxValuesPerTag
+	}
 
 	if di.DefaultRetentionPolicy != rpi.Name && makeDefault {
 		di.DefaultRetentionPolicy = rpi.Name
@@ -1085,6 +1151,27 @@ type RetentionPolicySpec struct {
 	ReplicaN           *int
 	Duration           *time.Duration
 	ShardGroupDuration time.Duration
+
+	// WALMode and WALFsyncDelay control the durability of writes to the
+	// policy's shards. An empty WALMode uses the server-wide WAL settings.
+	WALMode       string
+	WALFsyncDelay time.Duration
+
+	// LatenessWindow and FutureSkewLimit bound how far a point's timestamp
+	// may be behind or ahead of the server clock. Zero disables a bound.
+	// Points outside the bounds are handled according to LatePointsAction,
+	// and redirected points are written to LatePointsDatabase.
+	LatenessWindow     time.Duration
+	FutureSkewLimit    time.Duration
+	LatePointsAction   string
+	LatePointsDatabase string
+
+	// MaxSeries, MaxSeriesPerMeasurement and MaxValuesPerTag are the series
+	// cardinality limits of the policy's database. Zero uses the server's
+	// limits and a negative value disables a limit.
+	MaxSeries               int64
+	MaxSeriesPerMeasurement int64
+	MaxValuesPerTag         int64
 }
 
 // NewRetentionPolicyInfo creates a new retention policy info from the specification.
@@ -1127,6 +1214,33 @@ func (s *RetentionPolicySpec) marshal() *internal.RetentionPolicySpec {
 	if s.ReplicaN != nil {
 		pb.ReplicaN = proto.Uint32(uint32(*s.ReplicaN))
 	}
+	if s.WALMode != "" {
+		pb.WALMode = proto.String(s.WALMode)
+	}
+	if s.WALFsyncDelay > 0 {
+		pb.WALFsyncDelay = proto.Int64(int64(s.WALFsyncDelay))
+	}
+	if s.LatenessWindow > 0 {
+		pb.LatenessWindow = proto.Int64(int64(s.LatenessWindow))
+	}
+	if s.FutureSkewLimit > 0 {
+		pb.FutureSkewLimit = proto.Int64(int64(s.FutureSkewLimit))
+	}
+	if s.LatePointsAction != "" {
+		pb.LatePointsAction = proto.String(s.LatePointsAction)
+	}
+	if s.LatePointsDatabase != "" {
+		pb.LatePointsDatabase = proto.String(s.LatePointsDatabase)
+	}
+	if s.MaxSeries != 0 {
+		pb.MaxSeries = proto.Int64(s.MaxSeries)
+	}
+	if s.MaxSeriesPerMeasurement != 0 {
+		pb.MaxSeriesPerMeasurement = proto.Int64(s.MaxSeriesPerMeasurement)
+	}
+	if s.MaxValuesPerTag != 0 {
+		pb.MaxValuesPerTag = proto.Int64(s.MaxValuesPerTag)
+	}
 	return pb
 }
 
@@ -1146,6 +1260,15 @@ func (s *RetentionPolicySpec) unmarshal(pb *internal.RetentionPolicySpec) {
 		replicaN := int(pb.GetReplicaN())
 		s.ReplicaN = &replicaN
 	}
+	s.WALMode = pb.GetWALMode()
+	s.WALFsyncDelay = time.Duration(pb.GetWALFsyncDelay())
+	s.LatenessWindow = time.Duration(pb.GetLatenessWindow())
+	s.FutureSkewLimit = time.Duration(pb.GetFutureSkewLimit())
+	s.LatePointsAction = pb.GetLatePointsAction()
+	s.LatePointsDatabase = pb.GetLatePointsDatabase()
+	s.MaxSeries = pb.GetMaxSeries()
+	s.MaxSeriesPerMeasurement = pb.GetMaxSeriesPerMeasurement()
+	s.MaxValuesPerTag = pb.GetMaxValuesPerTag()
 }
 
 // MarshalBinary encodes RetentionPolicySpec to a binary format.
@@ -1171,6 +1294,27 @@ type RetentionPolicyInfo struct {
 	ShardGroupDuration time.Duration
 	ShardGroups        []ShardGroupInfo
 	Subscriptions      []SubscriptionInfo
+
+	// WALMode and WALFsyncDelay control the durability of writes to the
+	// policy's shards. An empty WALMode uses the server-wide WAL settings.
+	WALMode       string
+	WALFsyncDelay time.Duration
+
+	// LatenessWindow and FutureSkewLimit bound how far a point's timestamp
+	// may be behind or ahead of the server clock. Zero disables a bound.
+	// Points outside the bounds are handled according to LatePointsAction,
+	// and redirected points are written to LatePointsDatabase.
+	LatenessWindow     time.Duration
+	FutureSkewLimit    time.Duration
+	LatePointsAction   string
+	LatePointsDatabase string
+
+	// MaxSeries, MaxSeriesPerMeasurement and MaxValuesPerTag are the series
+	// cardinality limits of the policy's database. Zero uses the server's
+	// limits and a negative value disables a limit.
+	MaxSeries               int64
+	MaxSeriesPerMeasurement int64
+	MaxValuesPerTag         int64
 }
 
 // NewRetentionPolicyInfo returns a new instance of RetentionPolicyInfo
@@ -1196,6 +1340,16 @@ func (rpi *RetentionPolicyInfo) ToSpec() *RetentionPolicySpec {
 		ReplicaN:           &rpi.ReplicaN,
 		Duration:           &rpi.Duration,
 		ShardGroupDuration: rpi.ShardGroupDuration,
+		WALMode:            rpi.WALMode,
+		WALFsyncDelay:      rpi.WALFsyncDelay,
+		LatenessWindow:     rpi.LatenessWindow,
+		FutureSkewLimit:    rpi.FutureSkewLimit,
+		LatePointsAction:   rpi.LatePointsAction,
+		LatePointsDatabase: rpi.LatePointsDatabase,
+
+		MaxSeries:               rpi.MaxSeries,
+		MaxSeriesPerMeasurement: rpi.MaxSeriesPerMeasurement,
+		MaxValuesPerTag:         rpi.MaxValuesPerTag,
 	}
 }
 
@@ -1206,6 +1360,16 @@ func (rpi *RetentionPolicyInfo) Apply(spec *RetentionPolicySpec) *RetentionPolic
 		ReplicaN:           rpi.ReplicaN,
 		Duration:           rpi.Duration,
 		ShardGroupDuration: rpi.ShardGroupDuration,
+		WALMode:            rpi.WALMode,
+		WALFsyncDelay:      rpi.WALFsyncDelay,
+		LatenessWindow:     rpi.LatenessWindow,
+		FutureSkewLimit:    rpi.FutureSkewLimit,
+		LatePointsAction:   rpi.LatePointsAction,
+		LatePointsDatabase: rpi.LatePointsDatabase,
+
+		MaxSeries:               rpi.MaxSeries,
+		MaxSeriesPerMeasurement: rpi.MaxSeriesPerMeasurement,
+		MaxValuesPerTag:         rpi.MaxValuesPerTag,
 	}
 	if spec.Name != "" {
 		rp.Name = spec.Name
@@ -1216,6 +1380,33 @@ func (rpi *RetentionPolicyInfo) Apply(spec *RetentionPolicySpec) *RetentionPolic
 	if spec.Duration != nil {
 		rp.Duration = *spec.Duration
 	}
+	if spec.WALMode != "" {
+		rp.WALMode = spec.WALMode
+	}
+	if spec.WALFsyncDelay > 0 {
+		rp.WALFsyncDelay = spec.WALFsyncDelay
+	}
+	if spec.LatenessWindow > 0 {
+		rp.LatenessWindow = spec.LatenessWindow
+	}
+	if spec.FutureSkewLimit > 0 {
+		rp.FutureSkewLimit = spec.FutureSkewLimit
+	}
+	if spec.LatePointsAction != "" {
+		rp.LatePointsAction = spec.LatePointsAction
+	}
+	if spec.LatePointsDatabase != "" {
+		rp.LatePointsDatabase = spec.LatePointsDatabase
+	}
+	if spec.MaxSeries != 0 {
+		rp.MaxSeries = spec.MaxSeries
+	}
+	if spec.MaxSeriesPerMeasurement != 0 {
+		rp.MaxSeriesPerMeasurement = spec.MaxSeriesPerMeasurement
+	}
+	if spec.MaxValuesPerTag != 0 {
+		rp.MaxValuesPerTag = spec.MaxValuesPerTag
+	}
 	rp.ShardGroupDuration = NormalisedShardDuration(spec.ShardGroupDuration, rp.Duration)
 	return rp
 }
@@ -1266,6 +1457,33 @@ func (rpi *RetentionPolicyInfo) marshal() *internal.RetentionPolicyInfo {
 		Duration:           proto.Int64(int64(rpi.Duration)),
 		ShardGroupDuration: proto.Int64(int64(rpi.ShardGroupDuration)),
 	}
+	if rpi.WALMode != "" {
+		pb.WALMode = proto.String(rpi.WALMode)
+	}
+	if rpi.WALFsyncDelay > 0 {
+		pb.WALFsyncDelay = proto.Int64(int64(rpi.WALFsyncDelay))
+	}
+	if rpi.LatenessWindow > 0 {
+		pb.LatenessWindow = proto.Int64(int64(rpi.LatenessWindow))
+	}
+	if rpi.FutureSkewLimit > 0 {
+		pb.FutureSkewLimit = proto.Int64(int64(rpi.FutureSkewLimit))
+	}
+	if rpi.LatePointsAction != "" {
+		pb.LatePointsAction = proto.String(rpi.LatePointsAction)
+	}
+	if rpi.LatePointsDatabase != "" {
+		pb.LatePointsDatabase = proto.String(rpi.LatePointsDatabase)
+	}
+	if rpi.MaxSeries != 0 {
+		pb.MaxSeries = proto.Int64(rpi.MaxSeries)
+	}
+	if rpi.MaxSeriesPerMeasurement != 0 {
+		pb.MaxSeriesPerMeasurement = proto.Int64(rpi.MaxSeriesPerMeasurement)
+	}
+	if rpi.MaxValuesPerTag != 0 {
+		pb.MaxValuesPerTag = proto.Int64(rpi.MaxValuesPerTag)
+	}
 
 	pb.ShardGroups = make([]*internal.ShardGroupInfo, len(rpi.ShardGroups))
 	for i, sgi := range rpi.ShardGroups {
@@ -1286,6 +1504,15 @@ func (rpi *RetentionPolicyInfo) unmarshal(pb *internal.RetentionPolicyInfo) {
 	rpi.ReplicaN = int(pb.GetReplicaN())
 	rpi.Duration = time.Duration(pb.GetDuration())
 	rpi.ShardGroupDuration = time.Duration(pb.GetShardGroupDuration())
+	rpi.WALMode = pb.GetWALMode()
+	rpi.WALFsyncDelay = time.Duration(pb.GetWALFsyncDelay())
+	rpi.LatenessWindow = time.Duration(pb.GetLatenessWindow())
+	rpi.FutureSkewLimit = time.Duration(pb.GetFutureSkewLimit())
+	rpi.LatePointsAction = pb.GetLatePointsAction()
+	rpi.LatePointsDatabase = pb.GetLatePointsDatabase()
+	rpi.MaxSeries = pb.GetMaxSeries()
+	rpi.MaxSeriesPerMeasurement = pb.GetMaxSeriesPerMeasurement()
+	rpi.MaxValuesPerTag = pb.GetMaxValuesPerTag()
 
 	if len(pb.GetShardGroups()) > 0 {
 		rpi.ShardGroups = make([]ShardGroupInfo, len(pb.GetShardGroups()))
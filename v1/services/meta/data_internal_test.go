@@ -61,3 +61,81 @@ func Test_Data_RetentionPolicy_MarshalBinary(t *testing.T) {
 		t.Errorf("unexpected DeletedAt time.  got: %s, exp: %s", got, exp)
 	}
 }
+
+func Test_Data_RetentionPolicy_WALPolicy_MarshalBinary(t *testing.T) {
+	rpi := &RetentionPolicyInfo{
+		Name:          "rp",
+		ReplicaN:      1,
+		WALMode:       "group-commit",
+		WALFsyncDelay: 100 * time.Millisecond,
+	}
+
+	var other RetentionPolicyInfo
+	other.unmarshal(rpi.marshal())
+	if got, exp := other.WALMode, rpi.WALMode; got != exp {
+		t.Errorf("unexpected WAL mode.  got: %s, exp: %s", got, exp)
+	}
+	if got, exp := other.WALFsyncDelay, rpi.WALFsyncDelay; got != exp {
+		t.Errorf("unexpected WAL fsync delay.  got: %s, exp: %s", got, exp)
+	}
+
+	// Policies persisted before the WAL fields were added use the defaults.
+	other = RetentionPolicyInfo{}
+	other.unmarshal((&RetentionPolicyInfo{Name: "rp", ReplicaN: 1}).marshal())
+	if other.WALMode != "" || other.WALFsyncDelay != 0 {
+		t.Errorf("unexpected WAL policy for legacy retention policy: %q %s", other.WALMode, other.WALFsyncDelay)
+	}
+
+	data := &Data{}
+	if err := data.CreateDatabase("db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateRetentionPolicy("db", &RetentionPolicyInfo{Name: "rp", ReplicaN: 1}, true); err != nil {
+		t.Fatal(err)
+	}
+	rpu := &RetentionPolicyUpdate{}
+	rpu.SetWALMode("none")
+	if err := data.UpdateRetentionPolicy("db", "rp", rpu, false); err != nil {
+		t.Fatal(err)
+	}
+	if rp, _ := data.RetentionPolicy("db", "rp"); rp.WALMode != "none" {
+		t.Errorf("unexpected WAL mode after update: %q", rp.WALMode)
+	}
+}
+
+func Test_Data_RetentionPolicy_LatePoints_MarshalBinary(t *testing.T) {
+	spec := &RetentionPolicySpec{
+		Name:               "rp",
+		LatenessWindow:     time.Hour,
+		FutureSkewLimit:    5 * time.Minute,
+		LatePointsAction:   "redirect",
+		LatePointsDatabase: "late",
+	}
+	rpi := spec.NewRetentionPolicyInfo()
+
+	var other RetentionPolicyInfo
+	other.unmarshal(rpi.marshal())
+	if got, exp := *other.ToSpec(), *rpi.ToSpec(); got.LatenessWindow != exp.LatenessWindow ||
+		got.FutureSkewLimit != exp.FutureSkewLimit ||
+		got.LatePointsAction != exp.LatePointsAction ||
+		got.LatePointsDatabase != exp.LatePointsDatabase {
+		t.Errorf("unexpected late points policy.  got: %+v, exp: %+v", got, exp)
+	}
+
+	data := &Data{}
+	if err := data.CreateDatabase("db"); err != nil {
+		t.Fatal(err)
+	}
+	if err := data.CreateRetentionPolicy("db", rpi, true); err != nil {
+		t.Fatal(err)
+	}
+	rpu := &RetentionPolicyUpdate{}
+	rpu.SetLatePointsAction("reject")
+	rpu.SetLatenessWindow(0)
+	if err := data.UpdateRetentionPolicy("db", "rp", rpu, false); err != nil {
+		t.Fatal(err)
+	}
+	if rp, _ := data.RetentionPolicy("db", "rp"); rp.LatePointsAction != "reject" || rp.LatenessWindow != 0 || rp.FutureSkewLimit != 5*time.Minute {
+		t.Errorf("unexpected late points policy after update: %+v", rp)
+	}
+}
@@ -424,10 +424,19 @@ type RetentionPolicySpec struct {
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name               *string `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
-	Duration           *int64  `protobuf:"varint,2,opt,name=Duration" json:"Duration,omitempty"`
-	ShardGroupDuration *int64  `protobuf:"varint,3,opt,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
-	ReplicaN           *uint32 `protobuf:"varint,4,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
+	Name                    *string `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
+	Duration                *int64  `protobuf:"varint,2,opt,name=Duration" json:"Duration,omitempty"`
+	ShardGroupDuration      *int64  `protobuf:"varint,3,opt,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
+	ReplicaN                *uint32 `protobuf:"varint,4,opt,name=ReplicaN" json:"ReplicaN,omitempty"`
+	WALMode                 *string `protobuf:"bytes,5,opt,name=WALMode" json:"WALMode,omitempty"`
+	WALFsyncDelay           *int64  `protobuf:"varint,6,opt,name=WALFsyncDelay" json:"WALFsyncDelay,omitempty"`
+	LatenessWindow          *int64  `protobuf:"varint,7,opt,name=LatenessWindow" json:"LatenessWindow,omitempty"`
+	FutureSkewLimit         *int64  `protobuf:"varint,8,opt,name=FutureSkewLimit" json:"FutureSkewLimit,omitempty"`
+	LatePointsAction        *string `protobuf:"bytes,9,opt,name=LatePointsAction" json:"LatePointsAction,omitempty"`
+	LatePointsDatabase      *string `protobuf:"bytes,10,opt,name=LatePointsDatabase" json:"LatePointsDatabase,omitempty"`
+	MaxSeries               *int64  `protobuf:"varint,11,opt,name=MaxSeries" json:"MaxSeries,omitempty"`
+	MaxSeriesPerMeasurement *int64  `protobuf:"varint,12,opt,name=MaxSeriesPerMeasurement" json:"MaxSeriesPerMeasurement,omitempty"`
+	MaxValuesPerTag         *int64  `protobuf:"varint,13,opt,name=MaxValuesPerTag" json:"MaxValuesPerTag,omitempty"`
 }
 
 func (x *RetentionPolicySpec) Reset() {
@@ -490,17 +499,89 @@ func (x *RetentionPolicySpec) GetReplicaN() uint32 {
 	return 0
 }
 
+func (x *RetentionPolicySpec) GetWALMode() string {
+	if x != nil && x.WALMode != nil {
+		return *x.WALMode
+	}
+	return ""
+}
+
+func (x *RetentionPolicySpec) GetWALFsyncDelay() int64 {
+	if x != nil && x.WALFsyncDelay != nil {
+		return *x.WALFsyncDelay
+	}
+	return 0
+}
+
+func (x *RetentionPolicySpec) GetLatenessWindow() int64 {
+	if x != nil && x.LatenessWindow != nil {
+		return *x.LatenessWindow
+	}
+	return 0
+}
+
+func (x *RetentionPolicySpec) GetFutureSkewLimit() int64 {
+	if x != nil && x.FutureSkewLimit != nil {
+		return *x.FutureSkewLimit
+	}
+	return 0
+}
+
+func (x *RetentionPolicySpec) GetLatePointsAction() string {
+	if x != nil && x.LatePointsAction != nil {
+		return *x.LatePointsAction
+	}
+	return ""
+}
+
+func (x *RetentionPolicySpec) GetLatePointsDatabase() string {
+	if x != nil && x.LatePointsDatabase != nil {
+		return *x.LatePointsDatabase
+	}
+	return ""
+}
+
+func (x *RetentionPolicySpec) GetMaxSeries() int64 {
+	if x != nil && x.MaxSeries != nil {
+		return *x.MaxSeries
+	}
+	return 0
+}
+
+func (x *RetentionPolicySpec) GetMaxSeriesPerMeasurement() int64 {
+	if x != nil && x.MaxSeriesPerMeasurement != nil {
+		return *x.MaxSeriesPerMeasurement
+	}
+	return 0
+}
+
+func (x *RetentionPolicySpec) GetMaxValuesPerTag() int64 {
+	if x != nil && x.MaxValuesPerTag != nil {
+		return *x.MaxValuesPerTag
+	}
+	return 0
+}
+
 type RetentionPolicyInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
 	unknownFields protoimpl.UnknownFields
 
-	Name               *string             `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
-	Duration           *int64              `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
-	ShardGroupDuration *int64              `protobuf:"varint,3,req,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
-	ReplicaN           *uint32             `protobuf:"varint,4,req,name=ReplicaN" json:"ReplicaN,omitempty"`
-	ShardGroups        []*ShardGroupInfo   `protobuf:"bytes,5,rep,name=ShardGroups" json:"ShardGroups,omitempty"`
-	Subscriptions      []*SubscriptionInfo `protobuf:"bytes,6,rep,name=Subscriptions" json:"Subscriptions,omitempty"`
+	Name                    *string             `protobuf:"bytes,1,req,name=Name" json:"Name,omitempty"`
+	Duration                *int64              `protobuf:"varint,2,req,name=Duration" json:"Duration,omitempty"`
+	ShardGroupDuration      *int64              `protobuf:"varint,3,req,name=ShardGroupDuration" json:"ShardGroupDuration,omitempty"`
+	ReplicaN                *uint32             `protobuf:"varint,4,req,name=ReplicaN" json:"ReplicaN,omitempty"`
+	ShardGroups             []*ShardGroupInfo   `protobuf:"bytes,5,rep,name=ShardGroups" json:"ShardGroups,omitempty"`
+	Subscriptions           []*SubscriptionInfo `protobuf:"bytes,6,rep,name=Subscriptions" json:"Subscriptions,omitempty"`
+	WALMode                 *string             `protobuf:"bytes,7,opt,name=WALMode" json:"WALMode,omitempty"`
+	WALFsyncDelay           *int64              `protobuf:"varint,8,opt,name=WALFsyncDelay" json:"WALFsyncDelay,omitempty"`
+	LatenessWindow          *int64              `protobuf:"varint,9,opt,name=LatenessWindow" json:"LatenessWindow,omitempty"`
+	FutureSkewLimit         *int64              `protobuf:"varint,10,opt,name=FutureSkewLimit" json:"FutureSkewLimit,omitempty"`
+	LatePointsAction        *string             `protobuf:"bytes,11,opt,name=LatePointsAction" json:"LatePointsAction,omitempty"`
+	LatePointsDatabase      *string             `protobuf:"bytes,12,opt,name=LatePointsDatabase" json:"LatePointsDatabase,omitempty"`
+	MaxSeries               *int64              `protobuf:"varint,13,opt,name=MaxSeries" json:"MaxSeries,omitempty"`
+	MaxSeriesPerMeasurement *int64              `protobuf:"varint,14,opt,name=MaxSeriesPerMeasurement" json:"MaxSeriesPerMeasurement,omitempty"`
+	MaxValuesPerTag         *int64              `protobuf:"varint,15,opt,name=MaxValuesPerTag" json:"MaxValuesPerTag,omitempty"`
 }
 
 func (x *RetentionPolicyInfo) Reset() {
@@ -577,6 +658,69 @@ func (x *RetentionPolicyInfo) GetSubscriptions() []*SubscriptionInfo {
 	return nil
 }
 
+func (x *RetentionPolicyInfo) GetWALMode() string {
+	if x != nil && x.WALMode != nil {
+		return *x.WALMode
+	}
+	return ""
+}
+
+func (x *RetentionPolicyInfo) GetWALFsyncDelay() int64 {
+	if x != nil && x.WALFsyncDelay != nil {
+		return *x.WALFsyncDelay
+	}
+	return 0
+}
+
+func (x *RetentionPolicyInfo) GetLatenessWindow() int64 {
+	if x != nil && x.LatenessWindow != nil {
+		return *x.LatenessWindow
+	}
+	return 0
+}
+
+func (x *RetentionPolicyInfo) GetFutureSkewLimit() int64 {
+	if x != nil && x.FutureSkewLimit != nil {
+		return *x.FutureSkewLimit
+	}
+	return 0
+}
+
+func (x *RetentionPolicyInfo) GetLatePointsAction() string {
+	if x != nil && x.LatePointsAction != nil {
+		return *x.LatePointsAction
+	}
+	return ""
+}
+
+func (x *RetentionPolicyInfo) GetLatePointsDatabase() string {
+	if x != nil && x.LatePointsDatabase != nil {
+		return *x.LatePointsDatabase
+	}
+	return ""
+}
+
+func (x *RetentionPolicyInfo) GetMaxSeries() int64 {
+	if x != nil && x.MaxSeries != nil {
+		return *x.MaxSeries
+	}
+	return 0
+}
+
+func (x *RetentionPolicyInfo) GetMaxSeriesPerMeasurement() int64 {
+	if x != nil && x.MaxSeriesPerMeasurement != nil {
+		return *x.MaxSeriesPerMeasurement
+	}
+	return 0
+}
+
+func (x *RetentionPolicyInfo) GetMaxValuesPerTag() int64 {
+	if x != nil && x.MaxValuesPerTag != nil {
+		return *x.MaxValuesPerTag
+	}
+	return 0
+}
+
 type ShardGroupInfo struct {
 	state         protoimpl.MessageState
 	sizeCache     protoimpl.SizeCache
@@ -3148,7 +3292,7 @@ var file_internal_meta_proto_rawDesc = []byte{
 	0x69, 0x65, 0x73, 0x18, 0x04, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x19, 0x2e, 0x6d, 0x65, 0x74, 0x61,
 	0x2e, 0x43, 0x6f, 0x6e, 0x74, 0x69, 0x6e, 0x75, 0x6f, 0x75, 0x73, 0x51, 0x75, 0x65, 0x72, 0x79,
 	0x49, 0x6e, 0x66, 0x6f, 0x52, 0x11, 0x43, 0x6f, 0x6e, 0x74, 0x69, 0x6e, 0x75, 0x6f, 0x75, 0x73,
-	0x51, 0x75, 0x65, 0x72, 0x69, 0x65, 0x73, 0x22, 0x91, 0x01, 0x0a, 0x13, 0x52, 0x65, 0x74, 0x65,
+	0x51, 0x75, 0x65, 0x72, 0x69, 0x65, 0x73, 0x22, 0x81, 0x04, 0x0a, 0x13, 0x52, 0x65, 0x74, 0x65,
 	0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x53, 0x70, 0x65, 0x63, 0x12,
 	0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e,
 	0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18,
@@ -3157,7 +3301,30 @@ var file_internal_meta_proto_rawDesc = []byte{
 	0x61, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x03, 0x52, 0x12, 0x53, 0x68, 0x61,
 	0x72, 0x64, 0x47, 0x72, 0x6f, 0x75, 0x70, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x12,
 	0x1a, 0x0a, 0x08, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x4e, 0x18, 0x04, 0x20, 0x01, 0x28,
-	0x0d, 0x52, 0x08, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x4e, 0x22, 0x87, 0x02, 0x0a, 0x13,
+	0x0d, 0x52, 0x08, 0x52, 0x65, 0x70, 0x6c, 0x69, 0x63, 0x61, 0x4e, 0x12, 0x18, 0x0a, 0x07, 0x57,
+	0x41, 0x4c, 0x4d, 0x6f, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x57, 0x41,
+	0x4c, 0x4d, 0x6f, 0x64, 0x65, 0x12, 0x24, 0x0a, 0x0d, 0x57, 0x41, 0x4c, 0x46, 0x73, 0x79, 0x6e,
+	0x63, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x18, 0x06, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x57, 0x41,
+	0x4c, 0x46, 0x73, 0x79, 0x6e, 0x63, 0x44, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x26, 0x0a, 0x0e, 0x4c,
+	0x61, 0x74, 0x65, 0x6e, 0x65, 0x73, 0x73, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x07, 0x20,
+	0x01, 0x28, 0x03, 0x52, 0x0e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x65, 0x73, 0x73, 0x57, 0x69, 0x6e,
+	0x64, 0x6f, 0x77, 0x12, 0x28, 0x0a, 0x0f, 0x46, 0x75, 0x74, 0x75, 0x72, 0x65, 0x53, 0x6b, 0x65,
+	0x77, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x46, 0x75,
+	0x74, 0x75, 0x72, 0x65, 0x53, 0x6b, 0x65, 0x77, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x2a, 0x0a,
+	0x10, 0x4c, 0x61, 0x74, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x41, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x10, 0x4c, 0x61, 0x74, 0x65, 0x50, 0x6f, 0x69,
+	0x6e, 0x74, 0x73, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2e, 0x0a, 0x12, 0x4c, 0x61, 0x74,
+	0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18,
+	0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x4c, 0x61, 0x74, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x73, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x4d, 0x61, 0x78,
+	0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x18, 0x0b, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x4d, 0x61,
+	0x78, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x12, 0x38, 0x0a, 0x17, 0x4d, 0x61, 0x78, 0x53, 0x65,
+	0x72, 0x69, 0x65, 0x73, 0x50, 0x65, 0x72, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65,
+	0x6e, 0x74, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x03, 0x52, 0x17, 0x4d, 0x61, 0x78, 0x53, 0x65, 0x72,
+	0x69, 0x65, 0x73, 0x50, 0x65, 0x72, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e,
+	0x74, 0x12, 0x28, 0x0a, 0x0f, 0x4d, 0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x50, 0x65,
+	0x72, 0x54, 0x61, 0x67, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x4d, 0x61, 0x78, 0x56,
+	0x61, 0x6c, 0x75, 0x65, 0x73, 0x50, 0x65, 0x72, 0x54, 0x61, 0x67, 0x22, 0xf7, 0x04, 0x0a, 0x13,
 	0x52, 0x65, 0x74, 0x65, 0x6e, 0x74, 0x69, 0x6f, 0x6e, 0x50, 0x6f, 0x6c, 0x69, 0x63, 0x79, 0x49,
 	0x6e, 0x66, 0x6f, 0x12, 0x12, 0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x01, 0x20, 0x02, 0x28,
 	0x09, 0x52, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x12, 0x1a, 0x0a, 0x08, 0x44, 0x75, 0x72, 0x61, 0x74,
@@ -3174,7 +3341,30 @@ var file_internal_meta_proto_rawDesc = []byte{
 	0x72, 0x69, 0x70, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x18, 0x06, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x16,
 	0x2e, 0x6d, 0x65, 0x74, 0x61, 0x2e, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69,
 	0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x0d, 0x53, 0x75, 0x62, 0x73, 0x63, 0x72, 0x69, 0x70,
-	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x22, 0xc1, 0x01, 0x0a, 0x0e, 0x53, 0x68, 0x61, 0x72, 0x64, 0x47,
+	0x74, 0x69, 0x6f, 0x6e, 0x73, 0x12, 0x18, 0x0a, 0x07, 0x57, 0x41, 0x4c, 0x4d, 0x6f, 0x64, 0x65,
+	0x18, 0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x57, 0x41, 0x4c, 0x4d, 0x6f, 0x64, 0x65, 0x12,
+	0x24, 0x0a, 0x0d, 0x57, 0x41, 0x4c, 0x46, 0x73, 0x79, 0x6e, 0x63, 0x44, 0x65, 0x6c, 0x61, 0x79,
+	0x18, 0x08, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0d, 0x57, 0x41, 0x4c, 0x46, 0x73, 0x79, 0x6e, 0x63,
+	0x44, 0x65, 0x6c, 0x61, 0x79, 0x12, 0x26, 0x0a, 0x0e, 0x4c, 0x61, 0x74, 0x65, 0x6e, 0x65, 0x73,
+	0x73, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x18, 0x09, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0e, 0x4c,
+	0x61, 0x74, 0x65, 0x6e, 0x65, 0x73, 0x73, 0x57, 0x69, 0x6e, 0x64, 0x6f, 0x77, 0x12, 0x28, 0x0a,
+	0x0f, 0x46, 0x75, 0x74, 0x75, 0x72, 0x65, 0x53, 0x6b, 0x65, 0x77, 0x4c, 0x69, 0x6d, 0x69, 0x74,
+	0x18, 0x0a, 0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x46, 0x75, 0x74, 0x75, 0x72, 0x65, 0x53, 0x6b,
+	0x65, 0x77, 0x4c, 0x69, 0x6d, 0x69, 0x74, 0x12, 0x2a, 0x0a, 0x10, 0x4c, 0x61, 0x74, 0x65, 0x50,
+	0x6f, 0x69, 0x6e, 0x74, 0x73, 0x41, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x0b, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x10, 0x4c, 0x61, 0x74, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x41, 0x63, 0x74,
+	0x69, 0x6f, 0x6e, 0x12, 0x2e, 0x0a, 0x12, 0x4c, 0x61, 0x74, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74,
+	0x73, 0x44, 0x61, 0x74, 0x61, 0x62, 0x61, 0x73, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x12, 0x4c, 0x61, 0x74, 0x65, 0x50, 0x6f, 0x69, 0x6e, 0x74, 0x73, 0x44, 0x61, 0x74, 0x61, 0x62,
+	0x61, 0x73, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x4d, 0x61, 0x78, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73,
+	0x18, 0x0d, 0x20, 0x01, 0x28, 0x03, 0x52, 0x09, 0x4d, 0x61, 0x78, 0x53, 0x65, 0x72, 0x69, 0x65,
+	0x73, 0x12, 0x38, 0x0a, 0x17, 0x4d, 0x61, 0x78, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x50, 0x65,
+	0x72, 0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x18, 0x0e, 0x20, 0x01,
+	0x28, 0x03, 0x52, 0x17, 0x4d, 0x61, 0x78, 0x53, 0x65, 0x72, 0x69, 0x65, 0x73, 0x50, 0x65, 0x72,
+	0x4d, 0x65, 0x61, 0x73, 0x75, 0x72, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x12, 0x28, 0x0a, 0x0f, 0x4d,
+	0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x50, 0x65, 0x72, 0x54, 0x61, 0x67, 0x18, 0x0f,
+	0x20, 0x01, 0x28, 0x03, 0x52, 0x0f, 0x4d, 0x61, 0x78, 0x56, 0x61, 0x6c, 0x75, 0x65, 0x73, 0x50,
+	0x65, 0x72, 0x54, 0x61, 0x67, 0x22, 0xc1, 0x01, 0x0a, 0x0e, 0x53, 0x68, 0x61, 0x72, 0x64, 0x47,
 	0x72, 0x6f, 0x75, 0x70, 0x49, 0x6e, 0x66, 0x6f, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44, 0x18, 0x01,
 	0x20, 0x02, 0x28, 0x04, 0x52, 0x02, 0x49, 0x44, 0x12, 0x1c, 0x0a, 0x09, 0x53, 0x74, 0x61, 0x72,
 	0x74, 0x54, 0x69, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x02, 0x28, 0x03, 0x52, 0x09, 0x53, 0x74, 0x61,
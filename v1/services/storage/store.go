@@ -778,6 +778,11 @@ func (s *Store) SupportReadSeriesCardinality(ctx context.Context) bool {
 	return true
 }
 
+func (s *Store) SupportWindowAggregate(ctx context.Context, agg datatypes.Aggregate_AggregateType) bool {
+	_, ok := datatypes.Aggregate_AggregateType_name[int32(agg)]
+	return ok && agg != datatypes.Aggregate_AggregateTypeNone
+}
+
 // Returns two slices of shard IDs - the first is shards that are entirely in
 // the provided time range; the second is shards that are not entirely within
 // the provided time range.
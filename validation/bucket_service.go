@@ -0,0 +1,38 @@
+package validation
+
+import (
+	"context"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"go.uber.org/zap"
+)
+
+// BucketService wraps an influxdb.BucketService and removes the validations
+// of the buckets it deletes.
+type BucketService struct {
+	influxdb.BucketService
+	log         *zap.Logger
+	validations *Service
+}
+
+// NewBucketService returns a new BucketService deleting the validations of s.
+func NewBucketService(log *zap.Logger, bucketSvc influxdb.BucketService, s *Service) *BucketService {
+	return &BucketService{
+		BucketService: bucketSvc,
+		log:           log,
+		validations:   s,
+	}
+}
+
+// DeleteBucket deletes a bucket and its validation.
+func (s *BucketService) DeleteBucket(ctx context.Context, id platform.ID) error {
+	if err := s.BucketService.DeleteBucket(ctx, id); err != nil {
+		return err
+	}
+	if err := s.validations.DeleteBucketValidation(ctx, id); err != nil && err != influxdb.ErrBucketValidationNotFound {
+		s.log.Error("Failed to delete validation for bucket",
+			zap.String("bucket_id", id.String()), zap.Error(err))
+	}
+	return nil
+}
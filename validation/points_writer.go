@@ -0,0 +1,161 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PointsWriter wraps a storage.PointsWriter and checks the points written to
+// a bucket against the rules of its validation, if any, before writing them.
+// Points rejected by a rule are reported by a tsdb.PartialWriteError once the
+// remaining points are written. Points quarantined by a rule are written to
+// the quarantine bucket of the validation by an influxdb.SideBucketWriter,
+// without being checked again.
+type PointsWriter struct {
+	underlying  storage.PointsWriter
+	validations *Service
+	side        influxdb.SideBucketWriter
+	now         func() time.Time
+
+	violations *prometheus.CounterVec
+}
+
+// NewPointsWriter returns a new PointsWriter checking the points written to w
+// against the validations of s, and quarantining points with side.
+func NewPointsWriter(w storage.PointsWriter, s *Service, side influxdb.SideBucketWriter) *PointsWriter {
+	return &PointsWriter{
+		underlying:  w,
+		validations: s,
+		side:        side,
+		now:         time.Now,
+		violations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "storage",
+			Subsystem: "validation",
+			Name:      "violations_total",
+			Help:      "Number of points violating a rule of the validation of a bucket",
+		}, []string{"bucket", "rule", "type", "mode"}),
+	}
+}
+
+// PrometheusCollectors returns the metrics of the violations of the rules of
+// each bucket.
+func (w *PointsWriter) PrometheusCollectors() []prometheus.Collector {
+	return []prometheus.Collector{w.violations}
+}
+
+// WritePoints writes the valid points to the underlying PointsWriter.
+func (w *PointsWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	v, err := w.validations.validator(ctx, bucketID)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return w.underlying.WritePoints(ctx, orgID, bucketID, points)
+	}
+
+	var (
+		now         = w.now()
+		violated    = make([]int, len(v.rules))
+		kept        = points[:0:0]
+		quarantined []models.Point
+		reasons     []string
+		partial     tsdb.PartialWriteError
+	)
+	for _, p := range points {
+		vp, o, reason := v.apply(p, now, func(i int) { violated[i]++ })
+		switch o {
+		case keep:
+			kept = append(kept, vp)
+		case quarantine:
+			quarantined = append(quarantined, vp)
+			reasons = append(reasons, reason)
+		default:
+			addDropped(&partial, p, reason)
+		}
+	}
+	bucket := bucketID.String()
+	for i, n := range violated {
+		if n > 0 {
+			r := &v.rules[i]
+			w.violations.WithLabelValues(bucket, strconv.Itoa(i), string(r.Type), string(r.Mode)).Add(float64(n))
+		}
+	}
+
+	if len(quarantined) > 0 {
+		w.quarantine(ctx, orgID, bucketID, *v.quarantine, quarantined, reasons, &partial)
+	}
+	if len(kept) > 0 {
+		err := w.underlying.WritePoints(ctx, orgID, bucketID, kept)
+		if perr, ok := err.(tsdb.PartialWriteError); ok {
+			mergeDropped(&partial, perr)
+		} else if err != nil {
+			return err
+		}
+	}
+	if partial.Dropped > 0 {
+		return partial
+	}
+	return nil
+}
+
+// quarantine writes the points of a write to bucketID to its quarantine
+// bucket, with the reason each was quarantined for. The points which cannot
+// be written there are added to partial as rejected.
+func (w *PointsWriter) quarantine(ctx context.Context, orgID, bucketID, quarantineID platform.ID, points []models.Point, reasons []string, partial *tsdb.PartialWriteError) {
+	var (
+		qpoints = make([]models.Point, 0, len(points))
+		written = points[:0:0]
+		wreason = reasons[:0:0]
+	)
+	for i, p := range points {
+		qp, err := quarantined(p, reasons[i])
+		if err != nil {
+			addDropped(partial, p, reasons[i])
+			continue
+		}
+		qpoints = append(qpoints, qp)
+		written = append(written, p)
+		wreason = append(wreason, reasons[i])
+	}
+	if len(qpoints) == 0 {
+		return
+	}
+
+	err := w.side.WriteSidePoints(ctx, orgID, bucketID, quarantineID, qpoints)
+	if perr, ok := err.(tsdb.PartialWriteError); ok {
+		mergeDropped(partial, perr)
+	} else if err != nil {
+		for i, p := range written {
+			addDropped(partial, p, fmt.Sprintf("%s: quarantine: %v", wreason[i], err))
+		}
+	}
+}
+
+// addDropped adds the point p rejected for reason to partial.
+func addDropped(partial *tsdb.PartialWriteError, p models.Point, reason string) {
+	if partial.Dropped == 0 {
+		partial.Reason = reason
+	}
+	partial.Dropped++
+	partial.DroppedKeys = append(partial.DroppedKeys, p.Key())
+	partial.Points = append(partial.Points, tsdb.DroppedPoint{Point: p, Code: tsdb.DropValidation, Reason: reason})
+}
+
+// mergeDropped adds the points dropped by perr to partial.
+func mergeDropped(partial *tsdb.PartialWriteError, perr tsdb.PartialWriteError) {
+	if partial.Dropped == 0 {
+		partial.Reason = perr.Reason
+	}
+	partial.Dropped += perr.Dropped
+	partial.DroppedKeys = append(partial.DroppedKeys, perr.DroppedKeys...)
+	partial.Points = append(partial.Points, perr.Points...)
+}
@@ -0,0 +1,165 @@
+package validation
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingPointsWriter records the points written to each bucket. It fails
+// the writes to the buckets in errs.
+type recordingPointsWriter struct {
+	points map[platform.ID][]models.Point
+	errs   map[platform.ID]error
+}
+
+func (w *recordingPointsWriter) WritePoints(_ context.Context, _, bucketID platform.ID, points []models.Point) error {
+	if err := w.errs[bucketID]; err != nil {
+		return err
+	}
+	if w.points == nil {
+		w.points = make(map[platform.ID][]models.Point)
+	}
+	w.points[bucketID] = append(w.points[bucketID], points...)
+	return nil
+}
+
+// sideWriter writes the points set aside with a recordingPointsWriter.
+type sideWriter struct {
+	w *recordingPointsWriter
+}
+
+func (s sideWriter) WriteSidePoints(ctx context.Context, orgID, _, sideID platform.ID, points []models.Point) error {
+	return s.w.WritePoints(ctx, orgID, sideID, points)
+}
+
+func parsePoints(t *testing.T, lp string) []models.Point {
+	points, err := models.ParsePointsString(lp)
+	require.NoError(t, err)
+	return points
+}
+
+func pointLines(points []models.Point) []string {
+	var lines []string
+	for _, p := range points {
+		lines = append(lines, p.String())
+	}
+	return lines
+}
+
+func TestPointsWriter_WritePoints(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	var underlying recordingPointsWriter
+	w := NewPointsWriter(&underlying, svc, sideWriter{&underlying})
+	w.now = func() time.Time { return time.Unix(1000, 0) }
+
+	// Without a validation, points are written as they are.
+	require.NoError(t, w.WritePoints(ctx, orgID, bucketID, parsePoints(t, "cpu,host=a usage=1 1000000000000")))
+	require.Equal(t, []string{"cpu,host=a usage=1 1000000000000"}, pointLines(underlying.points[bucketID]))
+	underlying.points = nil
+
+	qid := quarantineBucketID
+	require.NoError(t, svc.PutBucketValidation(ctx, &influxdb.BucketValidation{
+		OrgID:              orgID,
+		BucketID:           bucketID,
+		QuarantineBucketID: &qid,
+		Rules: []influxdb.ValidationRule{
+			{Type: influxdb.ValidationRequiredTags, Mode: influxdb.ValidationModeReject, Measurement: "^cpu$", Tags: []string{"host"}},
+			{Type: influxdb.ValidationRange, Mode: influxdb.ValidationModeDropField, Field: "usage", Min: float(0), Max: float(100)},
+			{Type: influxdb.ValidationEnum, Mode: influxdb.ValidationModeQuarantine, Tag: "env", Values: []string{"prod", "dev"}},
+			{Type: influxdb.ValidationEnum, Mode: influxdb.ValidationModeReject, Field: "state", Values: []string{"up", "down"}},
+			{Type: influxdb.ValidationRequiredFields, Mode: influxdb.ValidationModeReject, Measurement: "^mem$", Fields: []string{"free"}},
+			{Type: influxdb.ValidationTimestamp, Mode: influxdb.ValidationModeReject, MaxPastSeconds: seconds(60), MaxFutureSeconds: seconds(10)},
+		},
+	}))
+
+	points := parsePoints(t, `cpu,host=a usage=50 1000000000000
+cpu usage=50 1000000000000
+cpu,host=a usage=150,idle=1 1000000000000
+cpu,host=a usage=150 1000000000000
+cpu,host=a,env=test usage=1 1000000000000
+net,env=dev state="up" 1000000000000
+net state="sideways" 1000000000000
+mem free=1 1000000000000
+mem used=1 1000000000000
+disk free=1 900000000000
+disk free=1 1020000000000
+disk free=1 1005000000000`)
+	err := w.WritePoints(ctx, orgID, bucketID, points)
+	perr, ok := err.(tsdb.PartialWriteError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, 6, perr.Dropped)
+	require.Equal(t, `validation rule 0: missing required tag "host"`, perr.Reason)
+	for _, p := range perr.Points {
+		require.Equal(t, tsdb.DropValidation, p.Code)
+	}
+	require.Equal(t, []string{
+		`validation rule 0: missing required tag "host"`,
+		`validation rule 1: field "usage" value 150 is out of range`,
+		`validation rule 3: field "state" value "sideways" is not allowed`,
+		`validation rule 4: missing required field "free"`,
+		`validation rule 5: timestamp 1970-01-01T00:15:00Z is more than 60s in the past`,
+		`validation rule 5: timestamp 1970-01-01T00:17:00Z is more than 10s in the future`,
+	}, droppedReasons(perr))
+
+	require.Equal(t, []string{
+		"cpu,host=a usage=50 1000000000000",
+		"cpu,host=a idle=1 1000000000000",
+		`net,env=dev state="up" 1000000000000`,
+		"mem free=1 1000000000000",
+		"disk free=1 1005000000000",
+	}, pointLines(underlying.points[bucketID]))
+	require.Equal(t, []string{
+		`cpu,env=test,host=a _violation="validation rule 2: tag \"env\" value \"test\" is not allowed",usage=1 1000000000000`,
+	}, pointLines(underlying.points[quarantineBucketID]))
+
+	bucket := bucketID.String()
+	require.Equal(t, float64(1), testutil.ToFloat64(w.violations.WithLabelValues(bucket, "0", "requiredTags", "reject")))
+	require.Equal(t, float64(2), testutil.ToFloat64(w.violations.WithLabelValues(bucket, "1", "range", "dropField")))
+	require.Equal(t, float64(1), testutil.ToFloat64(w.violations.WithLabelValues(bucket, "2", "enum", "quarantine")))
+	require.Equal(t, float64(2), testutil.ToFloat64(w.violations.WithLabelValues(bucket, "5", "timestamp", "reject")))
+}
+
+func TestPointsWriter_QuarantineError(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	underlying := recordingPointsWriter{errs: map[platform.ID]error{
+		quarantineBucketID: &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"},
+	}}
+	w := NewPointsWriter(&underlying, svc, sideWriter{&underlying})
+
+	qid := quarantineBucketID
+	require.NoError(t, svc.PutBucketValidation(ctx, &influxdb.BucketValidation{
+		OrgID:              orgID,
+		BucketID:           bucketID,
+		QuarantineBucketID: &qid,
+		Rules: []influxdb.ValidationRule{
+			{Type: influxdb.ValidationRequiredTags, Mode: influxdb.ValidationModeQuarantine, Tags: []string{"host"}},
+		},
+	}))
+
+	// Points which cannot be quarantined are rejected.
+	err := w.WritePoints(ctx, orgID, bucketID, parsePoints(t, "cpu,host=a usage=1 1\ncpu usage=2 1"))
+	perr, ok := err.(tsdb.PartialWriteError)
+	require.True(t, ok, "unexpected error %v", err)
+	require.Equal(t, 1, perr.Dropped)
+	require.Equal(t, `validation rule 0: missing required tag "host": quarantine: bucket not found`, perr.Reason)
+	require.Equal(t, []string{"cpu,host=a usage=1 1"}, pointLines(underlying.points[bucketID]))
+}
+
+func droppedReasons(perr tsdb.PartialWriteError) []string {
+	var reasons []string
+	for _, p := range perr.Points {
+		reasons = append(reasons, p.Reason)
+	}
+	return reasons
+}
@@ -0,0 +1,221 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// rule is a validation rule with its regular expression compiled and its
+// allowed values indexed.
+type rule struct {
+	influxdb.ValidationRule
+	measurement *regexp.Regexp
+	values      map[string]struct{}
+}
+
+// validator is the compiled rules of a validation, in order.
+type validator struct {
+	rules      []rule
+	quarantine *platform.ID
+}
+
+func compile(v *influxdb.BucketValidation) (*validator, error) {
+	c := &validator{
+		rules:      make([]rule, 0, len(v.Rules)),
+		quarantine: v.QuarantineBucketID,
+	}
+	for _, r := range v.Rules {
+		cr := rule{ValidationRule: r}
+		if r.Measurement != "" {
+			var err error
+			if cr.measurement, err = regexp.Compile(r.Measurement); err != nil {
+				return nil, err
+			}
+		}
+		if len(r.Values) > 0 {
+			cr.values = make(map[string]struct{}, len(r.Values))
+			for _, v := range r.Values {
+				cr.values[v] = struct{}{}
+			}
+		}
+		c.rules = append(c.rules, cr)
+	}
+	return c, nil
+}
+
+// outcome is what is done with a point once it has been checked.
+type outcome int
+
+const (
+	// keep writes the point, possibly without the fields dropped by a rule.
+	keep outcome = iota
+	// reject drops the point and reports it to the writer.
+	reject
+	// quarantine writes the point to the quarantine bucket.
+	quarantine
+)
+
+// apply checks pt against the rules of v at the time now. It returns the
+// point to write, which is pt without the fields dropped by a rule, what is
+// done with it and the reason of the violation which rejected or quarantined
+// it. violated is called with the index of each rule the point violated.
+func (v *validator) apply(pt models.Point, now time.Time, violated func(i int)) (models.Point, outcome, string) {
+	var (
+		name    = pt.Name()
+		fields  models.Fields
+		dropped bool
+	)
+	loadFields := func() (models.Fields, error) {
+		if fields == nil {
+			var err error
+			if fields, err = pt.Fields(); err != nil {
+				return nil, err
+			}
+		}
+		return fields, nil
+	}
+
+	for i := range v.rules {
+		r := &v.rules[i]
+		if r.measurement != nil && !r.measurement.Match(name) {
+			continue
+		}
+
+		var (
+			reason string
+			field  string
+		)
+		switch r.Type {
+		case influxdb.ValidationRange, influxdb.ValidationEnum:
+			if r.Type == influxdb.ValidationEnum && r.Tag != "" {
+				tv := pt.Tags().Get([]byte(r.Tag))
+				if tv == nil {
+					continue
+				}
+				if _, ok := r.values[string(tv)]; !ok {
+					reason = fmt.Sprintf("tag %q value %q is not allowed", r.Tag, tv)
+				}
+				break
+			}
+			fs, err := loadFields()
+			if err != nil {
+				return nil, reject, err.Error()
+			}
+			fv, ok := fs[r.Field]
+			if !ok {
+				continue
+			}
+			if r.Type == influxdb.ValidationRange {
+				reason = checkRange(r, fv)
+			} else {
+				reason = checkEnum(r, fv)
+			}
+			field = r.Field
+
+		case influxdb.ValidationRequiredFields:
+			fs, err := loadFields()
+			if err != nil {
+				return nil, reject, err.Error()
+			}
+			for _, k := range r.Fields {
+				if _, ok := fs[k]; !ok {
+					reason = fmt.Sprintf("missing required field %q", k)
+					break
+				}
+			}
+
+		case influxdb.ValidationRequiredTags:
+			tags := pt.Tags()
+			for _, k := range r.Tags {
+				if tags.Get([]byte(k)) == nil {
+					reason = fmt.Sprintf("missing required tag %q", k)
+					break
+				}
+			}
+
+		case influxdb.ValidationTimestamp:
+			t := pt.Time()
+			if r.MaxPastSeconds != nil && now.Sub(t) > time.Duration(*r.MaxPastSeconds)*time.Second {
+				reason = fmt.Sprintf("timestamp %s is more than %ds in the past", t.UTC().Format(time.RFC3339Nano), *r.MaxPastSeconds)
+			} else if r.MaxFutureSeconds != nil && t.Sub(now) > time.Duration(*r.MaxFutureSeconds)*time.Second {
+				reason = fmt.Sprintf("timestamp %s is more than %ds in the future", t.UTC().Format(time.RFC3339Nano), *r.MaxFutureSeconds)
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		violated(i)
+		reason = fmt.Sprintf("validation rule %d: %s", i, reason)
+		switch r.Mode {
+		case influxdb.ValidationModeDropField:
+			delete(fields, field)
+			if len(fields) == 0 {
+				return nil, reject, reason
+			}
+			dropped = true
+		case influxdb.ValidationModeQuarantine:
+			return pt, quarantine, reason
+		default:
+			return nil, reject, reason
+		}
+	}
+
+	if !dropped {
+		return pt, keep, ""
+	}
+	np, err := models.NewPoint(string(name), pt.Tags(), fields, pt.Time())
+	if err != nil {
+		return nil, reject, err.Error()
+	}
+	return np, keep, ""
+}
+
+// checkRange returns why the field value v violates the range rule r, if it
+// does.
+func checkRange(r *rule, v interface{}) string {
+	var f float64
+	switch v := v.(type) {
+	case float64:
+		f = v
+	case int64:
+		f = float64(v)
+	case uint64:
+		f = float64(v)
+	default:
+		return fmt.Sprintf("field %q value %v is not numeric", r.Field, v)
+	}
+	if (r.Min != nil && f < *r.Min) || (r.Max != nil && f > *r.Max) {
+		return fmt.Sprintf("field %q value %v is out of range", r.Field, v)
+	}
+	return ""
+}
+
+// checkEnum returns why the field value v violates the enum rule r, if it
+// does.
+func checkEnum(r *rule, v interface{}) string {
+	s, ok := v.(string)
+	if !ok {
+		return fmt.Sprintf("field %q value %v is not a string", r.Field, v)
+	}
+	if _, ok := r.values[s]; !ok {
+		return fmt.Sprintf("field %q value %q is not allowed", r.Field, s)
+	}
+	return ""
+}
+
+// quarantined returns pt with the reason it was quarantined in the
+// influxdb.ValidationViolationField field.
+func quarantined(pt models.Point, reason string) (models.Point, error) {
+	fields, err := pt.Fields()
+	if err != nil {
+		return nil, err
+	}
+	fields[influxdb.ValidationViolationField] = reason
+	return models.NewPoint(string(pt.Name()), pt.Tags(), fields, pt.Time())
+}
@@ -0,0 +1,228 @@
+// Package validation stores the validations of buckets and checks the points
+// written to them against their rules before they are stored.
+package validation
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	sq "github.com/Masterminds/squirrel"
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/sqlite"
+)
+
+var _ influxdb.BucketValidationService = (*Service)(nil)
+
+// Service is a BucketValidationService backed by the sqlite store. It caches
+// the compiled rules of the buckets written to so that points can be checked
+// without a query per write.
+type Service struct {
+	store   *sqlite.SqlStore
+	buckets influxdb.BucketService
+	now     func() time.Time
+
+	mu    sync.Mutex
+	cache map[platform.ID]*validator
+}
+
+// NewService returns a new Service. buckets is used to check the buckets
+// validations are set on, and their quarantine buckets.
+func NewService(store *sqlite.SqlStore, buckets influxdb.BucketService) *Service {
+	return &Service{
+		store:   store,
+		buckets: buckets,
+		now:     time.Now,
+		cache:   make(map[platform.ID]*validator),
+	}
+}
+
+// row is a bucket validation as stored in the bucket_validations table.
+type row struct {
+	BucketID           platform.ID  `db:"bucket_id"`
+	OrgID              platform.ID  `db:"org_id"`
+	QuarantineBucketID *platform.ID `db:"quarantine_bucket_id"`
+	Rules              rules        `db:"rules"`
+	CreatedAt          time.Time    `db:"created_at"`
+	UpdatedAt          time.Time    `db:"updated_at"`
+}
+
+func (r *row) toInfluxDB() *influxdb.BucketValidation {
+	return &influxdb.BucketValidation{
+		OrgID:              r.OrgID,
+		BucketID:           r.BucketID,
+		QuarantineBucketID: r.QuarantineBucketID,
+		Rules:              r.Rules,
+		CRUDLog: influxdb.CRUDLog{
+			CreatedAt: r.CreatedAt,
+			UpdatedAt: r.UpdatedAt,
+		},
+	}
+}
+
+// rules stores the rules of a validation as a JSON array.
+type rules []influxdb.ValidationRule
+
+// Value implements the database/sql Valuer interface.
+func (r rules) Value() (driver.Value, error) {
+	b, err := json.Marshal([]influxdb.ValidationRule(r))
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// Scan implements the database/sql Scanner interface.
+func (r *rules) Scan(value interface{}) error {
+	v, ok := value.(string)
+	if !ok {
+		return fmt.Errorf("bucket validation rules: unexpected type %T", value)
+	}
+	return json.Unmarshal([]byte(v), (*[]influxdb.ValidationRule)(r))
+}
+
+var selectColumns = []string{"bucket_id", "org_id", "quarantine_bucket_id", "rules", "created_at", "updated_at"}
+
+// FindBucketValidation returns the validation of a bucket.
+func (s *Service) FindBucketValidation(ctx context.Context, bucketID platform.ID) (*influxdb.BucketValidation, error) {
+	query, args, err := sq.Select(selectColumns...).
+		From("bucket_validations").
+		Where(sq.Eq{"bucket_id": bucketID}).
+		ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var r row
+	if err := s.store.DB.GetContext(ctx, &r, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, influxdb.ErrBucketValidationNotFound
+		}
+		return nil, err
+	}
+	return r.toInfluxDB(), nil
+}
+
+// PutBucketValidation sets the validation of the bucket of v, which must
+// belong to v.OrgID like its quarantine bucket, and updates v with the stored
+// validation.
+func (s *Service) PutBucketValidation(ctx context.Context, v *influxdb.BucketValidation) error {
+	if err := v.Validate(); err != nil {
+		return err
+	}
+
+	if err := s.checkBucket(ctx, v.OrgID, v.BucketID, "bucket not found"); err != nil {
+		return err
+	}
+	if v.QuarantineBucketID != nil {
+		if err := s.checkBucket(ctx, v.OrgID, *v.QuarantineBucketID, "quarantine bucket not found"); err != nil {
+			return err
+		}
+	}
+
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	now := s.now().UTC()
+	query, args, err := sq.Insert("bucket_validations").
+		Columns(selectColumns...).
+		Values(v.BucketID, v.OrgID, v.QuarantineBucketID, rules(v.Rules), now, now).
+		Suffix("ON CONFLICT (bucket_id) DO UPDATE SET quarantine_bucket_id = excluded.quarantine_bucket_id, rules = excluded.rules, updated_at = excluded.updated_at").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.store.DB.ExecContext(ctx, query, args...); err != nil {
+		return err
+	}
+	s.invalidate(v.BucketID)
+
+	// The creation time of a replaced validation is kept, so the stored
+	// validation is read back.
+	stored, err := s.FindBucketValidation(ctx, v.BucketID)
+	if err != nil {
+		return err
+	}
+	*v = *stored
+	return nil
+}
+
+// checkBucket returns a not found error with msg if the bucket id does not
+// belong to orgID.
+func (s *Service) checkBucket(ctx context.Context, orgID, id platform.ID, msg string) error {
+	b, err := s.buckets.FindBucketByID(ctx, id)
+	if err != nil {
+		if ierrors.ErrorCode(err) == ierrors.ENotFound {
+			return &ierrors.Error{Code: ierrors.ENotFound, Msg: msg}
+		}
+		return err
+	}
+	if b.OrgID != orgID {
+		return &ierrors.Error{Code: ierrors.ENotFound, Msg: msg}
+	}
+	return nil
+}
+
+// DeleteBucketValidation removes the validation of a bucket.
+func (s *Service) DeleteBucketValidation(ctx context.Context, bucketID platform.ID) error {
+	s.store.Mu.Lock()
+	defer s.store.Mu.Unlock()
+
+	query, args, err := sq.Delete("bucket_validations").
+		Where(sq.Eq{"bucket_id": bucketID}).
+		Suffix("RETURNING bucket_id").
+		ToSql()
+	if err != nil {
+		return err
+	}
+
+	var id platform.ID
+	if err := s.store.DB.GetContext(ctx, &id, query, args...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return influxdb.ErrBucketValidationNotFound
+		}
+		return err
+	}
+	s.invalidate(bucketID)
+
+	return nil
+}
+
+// validator returns the cached validator of a bucket, reading its validation
+// on a miss. The validator of a bucket without a validation is nil. Loading
+// while holding the lock keeps a concurrent change from being overwritten by
+// the rules read before it.
+func (s *Service) validator(ctx context.Context, bucketID platform.ID) (*validator, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if v, ok := s.cache[bucketID]; ok {
+		return v, nil
+	}
+
+	var v *validator
+	bv, err := s.FindBucketValidation(ctx, bucketID)
+	if err == nil {
+		if v, err = compile(bv); err != nil {
+			return nil, err
+		}
+	} else if err != influxdb.ErrBucketValidationNotFound {
+		return nil, err
+	}
+	s.cache[bucketID] = v
+	return v, nil
+}
+
+func (s *Service) invalidate(bucketID platform.ID) {
+	s.mu.Lock()
+	delete(s.cache, bucketID)
+	s.mu.Unlock()
+}
@@ -0,0 +1,112 @@
+package validation
+
+import (
+	"context"
+	"testing"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/mock"
+	"github.com/influxdata/influxdb/v2/sqlite"
+	"github.com/influxdata/influxdb/v2/sqlite/migrations"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx = context.Background()
+
+	orgID              = platform.ID(10)
+	bucketID           = platform.ID(100)
+	quarantineBucketID = platform.ID(101)
+	otherOrgBucketID   = platform.ID(200)
+)
+
+func float(f float64) *float64 { return &f }
+
+func seconds(s int64) *int64 { return &s }
+
+func TestService_CRUD(t *testing.T) {
+	svc, buckets := newTestService(t)
+
+	_, err := svc.FindBucketValidation(ctx, bucketID)
+	require.Equal(t, influxdb.ErrBucketValidationNotFound, err)
+
+	v := &influxdb.BucketValidation{
+		OrgID:    orgID,
+		BucketID: bucketID,
+		Rules: []influxdb.ValidationRule{
+			{Type: influxdb.ValidationRange, Mode: influxdb.ValidationModeReject, Field: "usage", Min: float(0), Max: float(100)},
+		},
+	}
+	require.NoError(t, svc.PutBucketValidation(ctx, v))
+	require.False(t, v.CreatedAt.IsZero())
+
+	got, err := svc.FindBucketValidation(ctx, bucketID)
+	require.NoError(t, err)
+	require.Equal(t, v, got)
+
+	// Putting a validation replaces its rules and quarantine bucket.
+	qid := quarantineBucketID
+	replaced := &influxdb.BucketValidation{
+		OrgID:              orgID,
+		BucketID:           bucketID,
+		QuarantineBucketID: &qid,
+		Rules: []influxdb.ValidationRule{
+			{Type: influxdb.ValidationRequiredTags, Mode: influxdb.ValidationModeQuarantine, Tags: []string{"host"}},
+			{Type: influxdb.ValidationTimestamp, Mode: influxdb.ValidationModeReject, MaxFutureSeconds: seconds(60)},
+		},
+	}
+	require.NoError(t, svc.PutBucketValidation(ctx, replaced))
+	require.Equal(t, v.CreatedAt, replaced.CreatedAt)
+	got, err = svc.FindBucketValidation(ctx, bucketID)
+	require.NoError(t, err)
+	require.Equal(t, replaced.Rules, got.Rules)
+	require.Equal(t, quarantineBucketID, *got.QuarantineBucketID)
+
+	for _, invalid := range []*influxdb.BucketValidation{
+		{OrgID: orgID, BucketID: bucketID},
+		{OrgID: orgID, BucketID: bucketID, Rules: []influxdb.ValidationRule{{Type: influxdb.ValidationRange, Mode: influxdb.ValidationModeReject, Field: "usage"}}},
+		{OrgID: orgID, BucketID: bucketID, Rules: []influxdb.ValidationRule{{Type: influxdb.ValidationRequiredTags, Mode: influxdb.ValidationModeDropField, Tags: []string{"host"}}}},
+		{OrgID: orgID, BucketID: bucketID, Rules: []influxdb.ValidationRule{{Type: influxdb.ValidationRequiredTags, Mode: influxdb.ValidationModeQuarantine, Tags: []string{"host"}}}},
+		{OrgID: orgID, BucketID: bucketID, Rules: []influxdb.ValidationRule{{Type: influxdb.ValidationEnum, Mode: influxdb.ValidationModeReject, Measurement: "(", Tag: "env", Values: []string{"prod"}}}},
+	} {
+		require.Equal(t, errors.EInvalid, errors.ErrorCode(svc.PutBucketValidation(ctx, invalid)))
+	}
+
+	otherOrg := &influxdb.BucketValidation{OrgID: platform.ID(11), BucketID: bucketID, Rules: v.Rules}
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(svc.PutBucketValidation(ctx, otherOrg)))
+
+	// The quarantine bucket must belong to the organization of the bucket.
+	otherQuarantine := otherOrgBucketID
+	replaced.QuarantineBucketID = &otherQuarantine
+	require.Equal(t, errors.ENotFound, errors.ErrorCode(svc.PutBucketValidation(ctx, replaced)))
+
+	// Deleting a bucket deletes its validation.
+	bs := NewBucketService(zaptest.NewLogger(t), buckets, svc)
+	require.NoError(t, bs.DeleteBucket(ctx, bucketID))
+	_, err = svc.FindBucketValidation(ctx, bucketID)
+	require.Equal(t, influxdb.ErrBucketValidationNotFound, err)
+	require.Equal(t, influxdb.ErrBucketValidationNotFound, svc.DeleteBucketValidation(ctx, bucketID))
+}
+
+func newTestService(t *testing.T) (*Service, *mock.BucketService) {
+	store, clean := sqlite.NewTestStore(t)
+	t.Cleanup(func() { clean(t) })
+	require.NoError(t, sqlite.NewMigrator(store, zaptest.NewLogger(t)).Up(ctx, migrations.AllUp))
+
+	buckets := mock.NewBucketService()
+	buckets.FindBucketByIDFn = func(_ context.Context, id platform.ID) (*influxdb.Bucket, error) {
+		switch id {
+		case bucketID, quarantineBucketID:
+			return &influxdb.Bucket{ID: id, OrgID: orgID}, nil
+		case otherOrgBucketID:
+			return &influxdb.Bucket{ID: id, OrgID: platform.ID(11)}, nil
+		}
+		return nil, &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}
+	}
+	buckets.DeleteBucketFn = func(context.Context, platform.ID) error { return nil }
+
+	return NewService(store, buckets), buckets
+}
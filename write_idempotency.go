@@ -0,0 +1,43 @@
+package influxdb
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+)
+
+// WriteResult is the response to a write request, kept so that a retry of the
+// request can be answered without writing again.
+type WriteResult struct {
+	StatusCode int         `json:"statusCode"`
+	Header     http.Header `json:"header,omitempty"`
+	Body       []byte      `json:"body,omitempty"`
+}
+
+// ErrWriteInProgress is returned when a write is retried with the idempotency
+// key of a write which has not completed yet.
+var ErrWriteInProgress = &errors.Error{
+	Code: errors.EConflict,
+	Msg:  "a write with the same idempotency key is in progress",
+}
+
+// WriteIdempotencyService remembers the results of the writes made to an
+// organization with an idempotency key for a window of time, so that a write
+// retried with the same key is answered with the result of the first rather
+// than written twice.
+type WriteIdempotencyService interface {
+	// BeginWrite reserves key for a write to an organization. If a write
+	// used key within the window, its result is returned and nothing is
+	// reserved. ErrWriteInProgress is returned if that write has not
+	// completed yet.
+	BeginWrite(ctx context.Context, orgID platform.ID, key string) (*WriteResult, error)
+
+	// CompleteWrite records the result of the write key was reserved for.
+	CompleteWrite(ctx context.Context, orgID platform.ID, key string, result *WriteResult) error
+
+	// ReleaseWrite releases key without recording a result, so that the
+	// write can be retried.
+	ReleaseWrite(ctx context.Context, orgID platform.ID, key string) error
+}
@@ -0,0 +1,69 @@
+package influxdb
+
+import (
+	"context"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// WriteBatchStatus is the state of a batch of points queued to be written.
+type WriteBatchStatus string
+
+const (
+	// WriteBatchPending is the status of a batch which has not been written.
+	WriteBatchPending WriteBatchStatus = "pending"
+	// WriteBatchApplied is the status of a batch which has been written,
+	// possibly dropping some of its points.
+	WriteBatchApplied WriteBatchStatus = "applied"
+	// WriteBatchFailed is the status of a batch which, or a part of which,
+	// could not be written.
+	WriteBatchFailed WriteBatchStatus = "failed"
+)
+
+// WriteBatch is a batch of points written to a bucket asynchronously.
+type WriteBatch struct {
+	ID         platform.ID      `json:"id"`
+	OrgID      platform.ID      `json:"orgID"`
+	BucketID   platform.ID      `json:"bucketID"`
+	Status     WriteBatchStatus `json:"status"`
+	Points     int              `json:"points"`
+	EnqueuedAt time.Time        `json:"enqueuedAt"`
+	// AppliedAt is when the batch was written or failed to be.
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+	// DroppedPoints is the number of points of an applied batch which were
+	// rejected by the storage engine.
+	DroppedPoints int `json:"droppedPoints,omitempty"`
+	// Error describes why the batch failed, or why its points were dropped.
+	Error string `json:"error,omitempty"`
+}
+
+// ErrWriteBatchNotFound is returned when a write batch is not known.
+var ErrWriteBatchNotFound = &errors.Error{
+	Code: errors.ENotFound,
+	Msg:  "write batch not found",
+}
+
+// ErrWriteQueueFull is returned when points cannot be queued because the
+// write queue is full.
+var ErrWriteQueueFull = &errors.Error{
+	Code: errors.ETooManyRequests,
+	Msg:  "write queue is full",
+}
+
+// WriteQueue stores batches of points durably to write them later, so that
+// writes can be acknowledged before they reach the storage engine.
+type WriteQueue interface {
+	// NewWriteBatchID returns the ID of a new batch.
+	NewWriteBatchID() platform.ID
+
+	// EnqueuePoints queues points to be written to a bucket as part of the
+	// batch id. A batch may be queued in several parts.
+	EnqueuePoints(ctx context.Context, id, orgID, bucketID platform.ID, points []models.Point) error
+
+	// FindWriteBatchByID returns a batch which is queued or was applied
+	// recently.
+	FindWriteBatchByID(ctx context.Context, id platform.ID) (*WriteBatch, error)
+}
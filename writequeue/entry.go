@@ -0,0 +1,69 @@
+package writequeue
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/models"
+)
+
+// entryVersion is the version of the encoding of entries.
+const entryVersion = 1
+
+// entryHeaderSize is the size of the header of an encoded entry.
+const entryHeaderSize = 1 + 4*8
+
+// entry is a part of a batch as stored in the queue: a header made of the
+// version of the encoding, the IDs of the batch, its organization and its
+// bucket and the time it was enqueued in Unix nanoseconds, followed by its
+// points as line protocol.
+type entry struct {
+	batchID    platform.ID
+	orgID      platform.ID
+	bucketID   platform.ID
+	enqueuedAt time.Time
+	lp         []byte
+}
+
+func encodeEntry(e *entry, points []models.Point) []byte {
+	size := entryHeaderSize
+	for _, p := range points {
+		size += p.StringSize() + 1
+	}
+
+	b := make([]byte, entryHeaderSize, size)
+	b[0] = entryVersion
+	binary.BigEndian.PutUint64(b[1:], uint64(e.batchID))
+	binary.BigEndian.PutUint64(b[9:], uint64(e.orgID))
+	binary.BigEndian.PutUint64(b[17:], uint64(e.bucketID))
+	binary.BigEndian.PutUint64(b[25:], uint64(e.enqueuedAt.UnixNano()))
+	for _, p := range points {
+		b = p.AppendString(b)
+		b = append(b, '\n')
+	}
+	return b
+}
+
+func decodeEntry(b []byte) (*entry, error) {
+	if len(b) < entryHeaderSize {
+		return nil, fmt.Errorf("write queue entry is too short: %d bytes", len(b))
+	}
+	if b[0] != entryVersion {
+		return nil, fmt.Errorf("unsupported write queue entry version %d", b[0])
+	}
+	return &entry{
+		batchID:    platform.ID(binary.BigEndian.Uint64(b[1:])),
+		orgID:      platform.ID(binary.BigEndian.Uint64(b[9:])),
+		bucketID:   platform.ID(binary.BigEndian.Uint64(b[17:])),
+		enqueuedAt: time.Unix(0, int64(binary.BigEndian.Uint64(b[25:]))).UTC(),
+		lp:         b[entryHeaderSize:],
+	}, nil
+}
+
+// points returns the number of points of the entry.
+func (e *entry) points() int {
+	return bytes.Count(e.lp, []byte{'\n'})
+}
@@ -0,0 +1,75 @@
+package writequeue
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	namespace = "write"
+	subsystem = "queue"
+)
+
+// metrics are the metrics of the write queue.
+type metrics struct {
+	pointsEnqueued *prometheus.CounterVec
+	bytesEnqueued  prometheus.Counter
+	enqueueErrors  prometheus.Counter
+	pointsApplied  prometheus.Counter
+	pointsDropped  prometheus.Counter
+	applyErrors    prometheus.Counter
+
+	batches  prometheus.GaugeFunc
+	size     prometheus.GaugeFunc
+	applyLag prometheus.GaugeFunc
+}
+
+func newMetrics(s *Service) *metrics {
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		})
+	}
+	gauge := func(name, help string, fn func() float64) prometheus.GaugeFunc {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      name,
+			Help:      help,
+		}, fn)
+	}
+
+	return &metrics{
+		pointsEnqueued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "points_enqueued_total",
+			Help:      "Number of points queued to be written, by organization",
+		}, []string{"org_id"}),
+		bytesEnqueued: counter("bytes_enqueued_total", "Number of bytes appended to the write queue"),
+		enqueueErrors: counter("enqueue_errors_total", "Number of writes which could not be queued"),
+		pointsApplied: counter("points_applied_total", "Number of queued points written to the storage engine"),
+		pointsDropped: counter("points_dropped_total", "Number of queued points which could not be written"),
+		applyErrors:   counter("apply_errors_total", "Number of queued writes which failed"),
+
+		batches:  gauge("batches", "Number of batches waiting in the write queue", s.pendingBatches),
+		size:     gauge("size_bytes", "Number of bytes of the write queue waiting to be written", s.queuedBytes),
+		applyLag: gauge("apply_lag_seconds", "Time the oldest batch waiting in the write queue has waited", s.applyLag),
+	}
+}
+
+func (m *metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.pointsEnqueued,
+		m.bytesEnqueued,
+		m.enqueueErrors,
+		m.pointsApplied,
+		m.pointsDropped,
+		m.applyErrors,
+		m.batches,
+		m.size,
+		m.applyLag,
+	}
+}
@@ -0,0 +1,476 @@
+// Package writequeue acknowledges writes before they reach the storage
+// engine. The points of a write are appended to a durable queue on disk, from
+// which a drainer writes them in order with the points writer of the server.
+//
+// A write is a batch, identified by an ID, whose status can be looked up
+// while it waits in the queue and for a retention period once it has been
+// applied. Batches which are still queued when the server stops are applied
+// once it starts again; the status of batches applied before is forgotten.
+//
+// Writes failing with an internal error are retried with a backoff, so that a
+// storage engine which is slow or briefly unavailable delays the queue rather
+// than losing its points. Other failures, and writes still failing after
+// maxApplyAttempts, fail the batch.
+package writequeue
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	ierrors "github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/pkg/durablequeue"
+	"github.com/influxdata/influxdb/v2/snowflake"
+	"github.com/influxdata/influxdb/v2/storage"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultMaxSize is the size of the queue unless a size is given.
+	DefaultMaxSize = 1024 * 1024 * 1024
+
+	// DefaultStatusRetention is how long the status of an applied batch is
+	// kept unless a retention is given.
+	DefaultStatusRetention = time.Hour
+
+	// maxApplyAttempts is the number of times a write failing with an
+	// internal error is attempted before its batch fails.
+	maxApplyAttempts = 10
+)
+
+var _ influxdb.WriteQueue = (*Service)(nil)
+
+// Service is a WriteQueue storing batches in a durablequeue.Queue and
+// writing them with a storage.PointsWriter once opened.
+type Service struct {
+	log     *zap.Logger
+	dir     string
+	maxSize int64
+	// segmentSize is the size of the segment files of the queue.
+	segmentSize int64
+	retention   time.Duration
+	writer      storage.PointsWriter
+	idGen       platform.IDGenerator
+	now         func() time.Time
+
+	// retryInterval is the time waited before retrying a failed write,
+	// doubled on every attempt up to maxRetryInterval.
+	retryInterval    time.Duration
+	maxRetryInterval time.Duration
+
+	metrics *metrics
+
+	mu    sync.Mutex
+	queue *durablequeue.Queue
+	// batches are the batches queued or applied within the retention.
+	batches map[platform.ID]*batch
+	// queued are the entries of the queue, in order.
+	queued []queuedEntry
+	// applied are the batches applied, in order, to forget their status.
+	applied []appliedBatch
+
+	receive chan struct{}
+	done    chan struct{}
+	wg      sync.WaitGroup
+}
+
+// batch is the status of a batch with the number of its entries which are
+// still queued.
+type batch struct {
+	influxdb.WriteBatch
+	entries int
+	failed  bool
+}
+
+type queuedEntry struct {
+	batchID    platform.ID
+	enqueuedAt time.Time
+}
+
+type appliedBatch struct {
+	id        platform.ID
+	appliedAt time.Time
+}
+
+// NewService returns a Service queuing up to maxSize bytes in dir and writing
+// the points queued with writer. maxSize must be at least twice the size of a
+// segment of the queue, durablequeue.DefaultSegmentSize. The status of
+// applied batches is kept for retention. Zero values use DefaultMaxSize and
+// DefaultStatusRetention.
+func NewService(log *zap.Logger, dir string, maxSize int64, retention time.Duration, writer storage.PointsWriter) *Service {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+	if retention <= 0 {
+		retention = DefaultStatusRetention
+	}
+	s := &Service{
+		log:              log,
+		dir:              dir,
+		maxSize:          maxSize,
+		segmentSize:      durablequeue.DefaultSegmentSize,
+		retention:        retention,
+		writer:           writer,
+		idGen:            snowflake.NewDefaultIDGenerator(),
+		now:              time.Now,
+		retryInterval:    time.Second,
+		maxRetryInterval: 30 * time.Second,
+		batches:          make(map[platform.ID]*batch),
+		receive:          make(chan struct{}, 1),
+		done:             make(chan struct{}),
+	}
+	s.metrics = newMetrics(s)
+	return s
+}
+
+// PrometheusCollectors returns the metrics of the queue.
+func (s *Service) PrometheusCollectors() []prometheus.Collector {
+	return s.metrics.collectors()
+}
+
+// Open opens the queue and starts writing the batches it holds.
+func (s *Service) Open(ctx context.Context) error {
+	if err := os.MkdirAll(s.dir, 0777); err != nil {
+		return err
+	}
+	q, err := durablequeue.NewQueue(
+		s.dir,
+		s.maxSize,
+		s.segmentSize,
+		&durablequeue.SharedCount{},
+		durablequeue.MaxWritesPending,
+		func([]byte) error { return nil },
+	)
+	if err != nil {
+		return err
+	}
+	q.WithLogger(s.log)
+	if err := q.Open(); err != nil {
+		return err
+	}
+
+	// The batches left in the queue by the last run are indexed so that
+	// their status can be looked up until they are applied.
+	err = q.ForEach(func(b []byte) error {
+		e, err := decodeEntry(b)
+		if err != nil {
+			return err
+		}
+		s.addEntry(e, e.points())
+		return nil
+	})
+	if err != nil {
+		q.Close()
+		return fmt.Errorf("failed to read write queue: %w", err)
+	}
+
+	s.mu.Lock()
+	s.queue = q
+	pending := len(s.batches)
+	s.mu.Unlock()
+	if pending > 0 {
+		s.log.Info("Applying queued writes", zap.Int("batches", pending))
+	}
+
+	s.wg.Add(1)
+	go s.run()
+	s.notify()
+	return nil
+}
+
+// Close stops writing the queued batches and closes the queue. The batches
+// which are still queued are written once the queue is opened again.
+func (s *Service) Close() error {
+	s.mu.Lock()
+	q := s.queue
+	s.mu.Unlock()
+	if q == nil {
+		return nil
+	}
+
+	close(s.done)
+	s.wg.Wait()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.queue = nil
+	return q.Close()
+}
+
+// NewWriteBatchID returns the ID of a new batch.
+func (s *Service) NewWriteBatchID() platform.ID {
+	return s.idGen.ID()
+}
+
+// EnqueuePoints appends points to the queue as part of the batch id.
+func (s *Service) EnqueuePoints(ctx context.Context, id, orgID, bucketID platform.ID, points []models.Point) error {
+	e := &entry{
+		batchID:    id,
+		orgID:      orgID,
+		bucketID:   bucketID,
+		enqueuedAt: s.now().UTC(),
+	}
+	b := encodeEntry(e, points)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.queue == nil {
+		return &ierrors.Error{
+			Code: ierrors.EUnavailable,
+			Msg:  "write queue is closed",
+		}
+	}
+	// Entries are appended under the lock so that queued lists them in the
+	// order of the queue.
+	if err := s.queue.Append(b); err != nil {
+		s.metrics.enqueueErrors.Inc()
+		if err == durablequeue.ErrQueueFull || err == durablequeue.ErrQueueBlocked {
+			return influxdb.ErrWriteQueueFull
+		}
+		return &ierrors.Error{
+			Code: ierrors.EInternal,
+			Msg:  "failed to queue points",
+			Err:  err,
+		}
+	}
+	s.addEntryLocked(e, len(points))
+	s.metrics.pointsEnqueued.WithLabelValues(orgID.String()).Add(float64(len(points)))
+	s.metrics.bytesEnqueued.Add(float64(len(b)))
+
+	s.notify()
+	return nil
+}
+
+// FindWriteBatchByID returns the status of a batch which is queued or was
+// applied within the retention.
+func (s *Service) FindWriteBatchByID(ctx context.Context, id platform.ID) (*influxdb.WriteBatch, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.batches[id]
+	if !ok {
+		return nil, influxdb.ErrWriteBatchNotFound
+	}
+	wb := b.WriteBatch
+	return &wb, nil
+}
+
+func (s *Service) addEntry(e *entry, points int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addEntryLocked(e, points)
+}
+
+func (s *Service) addEntryLocked(e *entry, points int) {
+	b, ok := s.batches[e.batchID]
+	if !ok {
+		b = &batch{WriteBatch: influxdb.WriteBatch{
+			ID:         e.batchID,
+			OrgID:      e.orgID,
+			BucketID:   e.bucketID,
+			EnqueuedAt: e.enqueuedAt,
+		}}
+		s.batches[e.batchID] = b
+	}
+	// A part of a batch may be queued after the previous parts are applied.
+	b.Status = influxdb.WriteBatchPending
+	b.AppliedAt = nil
+	b.Points += points
+	b.entries++
+	s.queued = append(s.queued, queuedEntry{batchID: e.batchID, enqueuedAt: e.enqueuedAt})
+}
+
+// notify wakes the drainer up without blocking if it is already awake.
+func (s *Service) notify() {
+	select {
+	case s.receive <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) run() {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-s.receive:
+			s.drain()
+		}
+	}
+}
+
+// drain applies the queued entries until the queue is empty or the Service
+// is closed.
+func (s *Service) drain() {
+	for {
+		scan, err := s.queue.NewScanner()
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				s.log.Error("Failed to read write queue", zap.Error(err))
+			}
+			return
+		}
+
+		for scan.Next() {
+			e, err := decodeEntry(scan.Bytes())
+			if err != nil {
+				s.log.Error("Dropping invalid write queue entry", zap.Error(err))
+			} else if !s.apply(e) {
+				return
+			}
+			if _, err := scan.Advance(); err != nil {
+				s.log.Error("Failed to advance write queue", zap.Error(err))
+				return
+			}
+		}
+		if err := scan.Err(); err != nil {
+			// Advancing past the error drops the rest of the segment.
+			s.log.Error("Failed to read write queue segment", zap.Error(err))
+			if _, err := scan.Advance(); err != nil {
+				s.log.Error("Dropped write queue segment", zap.Error(err))
+			}
+		}
+	}
+}
+
+// apply writes an entry, retrying internal errors. It returns false if the
+// Service was closed before the entry could be written.
+func (s *Service) apply(e *entry) bool {
+	points, err := models.ParsePoints(e.lp)
+	if err == nil {
+		interval := s.retryInterval
+		for attempt := 1; ; attempt++ {
+			err = s.writer.WritePoints(context.Background(), e.orgID, e.bucketID, points)
+			if err == nil || !retryable(err) || attempt == maxApplyAttempts {
+				break
+			}
+
+			s.log.Warn("Failed to apply queued write, retrying",
+				zap.Stringer("batch_id", e.batchID), zap.Int("attempt", attempt), zap.Error(err))
+			select {
+			case <-s.done:
+				return false
+			case <-time.After(interval):
+			}
+			if interval *= 2; interval > s.maxRetryInterval {
+				interval = s.maxRetryInterval
+			}
+		}
+	}
+
+	s.complete(e, e.points(), err)
+	return true
+}
+
+// retryable reports whether a write failed for a reason which may go away.
+func retryable(err error) bool {
+	if _, ok := err.(tsdb.PartialWriteError); ok {
+		return false
+	}
+	switch ierrors.ErrorCode(err) {
+	case ierrors.EInternal, ierrors.EUnavailable, ierrors.ETooManyRequests:
+		return true
+	default:
+		return false
+	}
+}
+
+// complete records the result of writing the n points of an entry.
+func (s *Service) complete(e *entry, n int, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.queued) > 0 {
+		s.queued = s.queued[1:]
+	}
+
+	b, ok := s.batches[e.batchID]
+	if !ok {
+		return
+	}
+	b.entries--
+
+	if perr, ok := err.(tsdb.PartialWriteError); ok {
+		s.metrics.pointsApplied.Add(float64(n - perr.Dropped))
+		s.metrics.pointsDropped.Add(float64(perr.Dropped))
+		b.DroppedPoints += perr.Dropped
+		b.Error = perr.Error()
+	} else if err != nil {
+		s.metrics.pointsDropped.Add(float64(n))
+		s.metrics.applyErrors.Inc()
+		s.log.Error("Failed to apply queued write", zap.Stringer("batch_id", e.batchID), zap.Error(err))
+		b.failed = true
+		b.Error = err.Error()
+	} else {
+		s.metrics.pointsApplied.Add(float64(n))
+	}
+
+	now := s.now().UTC()
+	if b.entries == 0 {
+		b.Status = influxdb.WriteBatchApplied
+		if b.failed {
+			b.Status = influxdb.WriteBatchFailed
+		}
+		b.AppliedAt = &now
+		s.applied = append(s.applied, appliedBatch{id: b.ID, appliedAt: now})
+	}
+	s.forgetLocked(now)
+}
+
+// forgetLocked forgets the status of the batches applied longer than the
+// retention ago.
+func (s *Service) forgetLocked(now time.Time) {
+	cutoff := now.Add(-s.retention)
+	for len(s.applied) > 0 && s.applied[0].appliedAt.Before(cutoff) {
+		a := s.applied[0]
+		s.applied = s.applied[1:]
+		// A batch queued again once applied is forgotten once applied again.
+		if b, ok := s.batches[a.id]; ok && b.AppliedAt != nil && b.AppliedAt.Equal(a.appliedAt) {
+			delete(s.batches, a.id)
+		}
+	}
+}
+
+// pendingBatches returns the number of batches waiting in the queue.
+func (s *Service) pendingBatches() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.batches {
+		if b.entries > 0 {
+			n++
+		}
+	}
+	return float64(n)
+}
+
+// queuedBytes returns the number of bytes waiting in the queue.
+func (s *Service) queuedBytes() float64 {
+	s.mu.Lock()
+	q := s.queue
+	s.mu.Unlock()
+	if q == nil {
+		return 0
+	}
+	return float64(q.TotalBytes())
+}
+
+// applyLag returns the time the oldest queued entry has waited.
+func (s *Service) applyLag() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.queued) == 0 {
+		return 0
+	}
+	return s.now().Sub(s.queued[0].enqueuedAt).Seconds()
+}
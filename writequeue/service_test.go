@@ -0,0 +1,240 @@
+package writequeue
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/influxdata/influxdb/v2"
+	"github.com/influxdata/influxdb/v2/kit/platform"
+	"github.com/influxdata/influxdb/v2/kit/platform/errors"
+	"github.com/influxdata/influxdb/v2/models"
+	"github.com/influxdata/influxdb/v2/tsdb"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap/zaptest"
+)
+
+var (
+	ctx = context.Background()
+
+	orgID    = platform.ID(10)
+	bucketID = platform.ID(100)
+)
+
+func TestService_EnqueuePoints(t *testing.T) {
+	pw := &pointsWriter{}
+	svc := newTestService(t, t.TempDir(), pw)
+
+	id := svc.NewWriteBatchID()
+	require.NoError(t, svc.EnqueuePoints(ctx, id, orgID, bucketID, parsePoints(t, "cpu value=1 1\ncpu value=2 2")))
+	require.NoError(t, svc.EnqueuePoints(ctx, id, orgID, bucketID, parsePoints(t, "mem value=3 3")))
+
+	b := waitForStatus(t, svc, id, influxdb.WriteBatchApplied)
+	require.Equal(t, orgID, b.OrgID)
+	require.Equal(t, bucketID, b.BucketID)
+	require.Equal(t, 3, b.Points)
+	require.NotNil(t, b.AppliedAt)
+	require.Empty(t, b.Error)
+
+	written := pw.written()
+	require.Len(t, written, 3)
+	require.Equal(t, "cpu value=1 1", written[0].String())
+	require.Equal(t, "mem value=3 3", written[2].String())
+
+	require.Equal(t, float64(3), testutil.ToFloat64(svc.metrics.pointsApplied))
+	require.Equal(t, float64(3), testutil.ToFloat64(svc.metrics.pointsEnqueued.WithLabelValues(orgID.String())))
+	require.Equal(t, float64(0), testutil.ToFloat64(svc.metrics.batches))
+	require.Equal(t, float64(0), testutil.ToFloat64(svc.metrics.applyLag))
+
+	_, err := svc.FindWriteBatchByID(ctx, platform.ID(1))
+	require.Equal(t, influxdb.ErrWriteBatchNotFound, err)
+}
+
+func TestService_PartialWrite(t *testing.T) {
+	pw := &pointsWriter{err: tsdb.PartialWriteError{Reason: "field type conflict", Dropped: 1}}
+	svc := newTestService(t, t.TempDir(), pw)
+
+	id := svc.NewWriteBatchID()
+	require.NoError(t, svc.EnqueuePoints(ctx, id, orgID, bucketID, parsePoints(t, "cpu value=1 1\ncpu value=2 2")))
+
+	b := waitForStatus(t, svc, id, influxdb.WriteBatchApplied)
+	require.Equal(t, 1, b.DroppedPoints)
+	require.Contains(t, b.Error, "field type conflict")
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.metrics.pointsApplied))
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.metrics.pointsDropped))
+}
+
+func TestService_FailedWrite(t *testing.T) {
+	pw := &pointsWriter{err: &errors.Error{Code: errors.ENotFound, Msg: "bucket not found"}}
+	svc := newTestService(t, t.TempDir(), pw)
+
+	id := svc.NewWriteBatchID()
+	require.NoError(t, svc.EnqueuePoints(ctx, id, orgID, bucketID, parsePoints(t, "cpu value=1 1")))
+
+	b := waitForStatus(t, svc, id, influxdb.WriteBatchFailed)
+	require.Equal(t, "bucket not found", b.Error)
+	require.Equal(t, 1, pw.attempts())
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.metrics.applyErrors))
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.metrics.pointsDropped))
+}
+
+func TestService_RetryWrite(t *testing.T) {
+	pw := &pointsWriter{err: &errors.Error{Code: errors.EUnavailable, Msg: "engine is busy"}, failures: 2}
+	svc := newTestService(t, t.TempDir(), pw)
+
+	id := svc.NewWriteBatchID()
+	require.NoError(t, svc.EnqueuePoints(ctx, id, orgID, bucketID, parsePoints(t, "cpu value=1 1")))
+
+	waitForStatus(t, svc, id, influxdb.WriteBatchApplied)
+	require.Equal(t, 3, pw.attempts())
+	require.Len(t, pw.written(), 1)
+}
+
+func TestService_Reopen(t *testing.T) {
+	dir := t.TempDir()
+
+	// The write is retried until the queue is closed, leaving it queued.
+	pw := &pointsWriter{err: &errors.Error{Code: errors.EInternal, Msg: "engine is closed"}, failures: -1}
+	svc := newTestService(t, dir, pw)
+	svc.retryInterval = time.Hour
+
+	id := svc.NewWriteBatchID()
+	require.NoError(t, svc.EnqueuePoints(ctx, id, orgID, bucketID, parsePoints(t, "cpu value=1 1\ncpu value=2 2")))
+	require.Eventually(t, func() bool { return pw.attempts() > 0 }, 5*time.Second, 10*time.Millisecond)
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.metrics.batches))
+	require.NoError(t, svc.Close())
+
+	pw = &pointsWriter{}
+	bw := &blockingWriter{pointsWriter: pw, unblock: make(chan struct{})}
+	svc = NewService(zaptest.NewLogger(t), dir, 0, 0, bw)
+	require.NoError(t, svc.Open(ctx))
+	defer svc.Close()
+
+	b, err := svc.FindWriteBatchByID(ctx, id)
+	require.NoError(t, err)
+	require.Equal(t, influxdb.WriteBatchPending, b.Status)
+	require.Equal(t, 2, b.Points)
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.metrics.batches))
+
+	close(bw.unblock)
+	waitForStatus(t, svc, id, influxdb.WriteBatchApplied)
+	require.Len(t, pw.written(), 2)
+}
+
+func TestService_StatusRetention(t *testing.T) {
+	pw := &pointsWriter{}
+	svc := newTestService(t, t.TempDir(), pw)
+
+	var (
+		mu  sync.Mutex
+		now = time.Now()
+	)
+	svc.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return now
+	}
+
+	first := svc.NewWriteBatchID()
+	require.NoError(t, svc.EnqueuePoints(ctx, first, orgID, bucketID, parsePoints(t, "cpu value=1 1")))
+	waitForStatus(t, svc, first, influxdb.WriteBatchApplied)
+
+	mu.Lock()
+	now = now.Add(DefaultStatusRetention + time.Second)
+	mu.Unlock()
+
+	second := svc.NewWriteBatchID()
+	require.NoError(t, svc.EnqueuePoints(ctx, second, orgID, bucketID, parsePoints(t, "cpu value=2 2")))
+	waitForStatus(t, svc, second, influxdb.WriteBatchApplied)
+
+	_, err := svc.FindWriteBatchByID(ctx, first)
+	require.Equal(t, influxdb.ErrWriteBatchNotFound, err)
+}
+
+func TestService_QueueFull(t *testing.T) {
+	svc := NewService(zaptest.NewLogger(t), t.TempDir(), 64, 0, &pointsWriter{})
+	svc.segmentSize = 32
+	require.NoError(t, svc.Open(ctx))
+	defer svc.Close()
+
+	err := svc.EnqueuePoints(ctx, svc.NewWriteBatchID(), orgID, bucketID, parsePoints(t, "cpu,host=a,region=west value=1,other=2,more=3 1"))
+	require.Equal(t, influxdb.ErrWriteQueueFull, err)
+	require.Equal(t, float64(1), testutil.ToFloat64(svc.metrics.enqueueErrors))
+}
+
+func newTestService(t *testing.T, dir string, pw *pointsWriter) *Service {
+	svc := NewService(zaptest.NewLogger(t), dir, 0, 0, pw)
+	svc.retryInterval = time.Millisecond
+	require.NoError(t, svc.Open(ctx))
+	t.Cleanup(func() { svc.Close() })
+	return svc
+}
+
+func parsePoints(t *testing.T, lp string) []models.Point {
+	points, err := models.ParsePointsString(lp)
+	require.NoError(t, err)
+	return points
+}
+
+// waitForStatus waits for the batch id to have status.
+func waitForStatus(t *testing.T, svc *Service, id platform.ID, status influxdb.WriteBatchStatus) *influxdb.WriteBatch {
+	t.Helper()
+	var b *influxdb.WriteBatch
+	require.Eventually(t, func() bool {
+		var err error
+		b, err = svc.FindWriteBatchByID(ctx, id)
+		require.NoError(t, err)
+		return b.Status == status
+	}, 5*time.Second, 10*time.Millisecond)
+	return b
+}
+
+// pointsWriter records the points written to it. If err is set, it fails the
+// first failures writes with it, or every write if failures is not positive.
+type pointsWriter struct {
+	mu       sync.Mutex
+	err      error
+	failures int
+	calls    int
+	points   []models.Point
+}
+
+func (w *pointsWriter) WritePoints(_ context.Context, _, _ platform.ID, points []models.Point) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.calls++
+	if w.err != nil && (w.failures <= 0 || w.calls <= w.failures) {
+		if _, ok := w.err.(tsdb.PartialWriteError); !ok {
+			return w.err
+		}
+		w.points = append(w.points, points[1:]...)
+		return w.err
+	}
+	w.points = append(w.points, points...)
+	return nil
+}
+
+func (w *pointsWriter) written() []models.Point {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return append([]models.Point(nil), w.points...)
+}
+
+func (w *pointsWriter) attempts() int {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.calls
+}
+
+// blockingWriter writes once unblock is closed.
+type blockingWriter struct {
+	*pointsWriter
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) WritePoints(ctx context.Context, orgID, bucketID platform.ID, points []models.Point) error {
+	<-w.unblock
+	return w.pointsWriter.WritePoints(ctx, orgID, bucketID, points)
+}